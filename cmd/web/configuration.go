@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+	"gitlab.com/massimo-ua/projecta/pkg/env"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,11 +20,40 @@ const (
 	jwtSecret                        = "JWT_SECRET"
 	tokenTTL                         = "TOKEN_TTL"
 	googleCertCacheSecondsTTL        = "GOOGLE_CERT_CACHE_SECONDS_TTL"
+	rateProviders                    = "RATE_PROVIDERS"
+	rateCurrencies                   = "RATE_CURRENCIES"
+	ratesFile                        = "RATES_FILE"
+	ratesCacheSecondsTTL             = "RATES_CACHE_SECONDS_TTL"
+	ratesRounding                    = "RATES_ROUNDING"
+	attachmentsStorage               = "ATTACHMENTS_STORAGE"
+	attachmentsDir                   = "ATTACHMENTS_DIR"
+	s3Endpoint                       = "S3_ENDPOINT"
+	s3Region                         = "S3_REGION"
+	s3Bucket                         = "S3_BUCKET"
+	s3AccessKey                      = "S3_ACCESS_KEY"
+	s3SecretKey                      = "S3_SECRET_KEY"
+	s3PathStyle                      = "S3_PATH_STYLE"
 	defaultTokenTTL                  = 300
 	defaultGoogleCertCacheSecondsTTL = 24 * 60 * 60
 	defaultHttpReadTimeout           = 30 * time.Second
 	defaultHttpWriteTimeout          = 45 * time.Second
 	shutdownTimeout                  = 10 * time.Second
+	defaultRateProviders             = "nbu"
+	defaultRateCurrencies            = "UAH,USD,EUR,PLN"
+	defaultRatesCacheSecondsTTL      = 30 * 60
+	defaultAttachmentsStorage        = "local"
+	defaultAttachmentsDir            = "data/attachments"
+)
+
+const (
+	nbuRateProvider  = "nbu"
+	ecbRateProvider  = "ecb"
+	fileRateProvider = "file"
+)
+
+const (
+	localAttachmentsStorage = "local"
+	s3AttachmentsStorage    = "s3"
 )
 
 func loadConfig() (*core.AppConfig, error) {
@@ -34,6 +66,17 @@ func loadConfig() (*core.AppConfig, error) {
 		HttpReadTimeout:    defaultHttpReadTimeout,
 		HttpWriteTimeout:   defaultHttpWriteTimeout,
 		ShutdownTimeout:    shutdownTimeout,
+		RateProviders:      splitList(strings.ToLower(env.GetEnv(rateProviders, defaultRateProviders))),
+		RateCurrencies:     splitList(env.GetEnv(rateCurrencies, defaultRateCurrencies)),
+		RatesFile:          os.Getenv(ratesFile),
+		RatesRounding:      os.Getenv(ratesRounding),
+		AttachmentsStorage: strings.ToLower(env.GetEnv(attachmentsStorage, defaultAttachmentsStorage)),
+		AttachmentsDir:     env.GetEnv(attachmentsDir, defaultAttachmentsDir),
+		S3Endpoint:         os.Getenv(s3Endpoint),
+		S3Region:           os.Getenv(s3Region),
+		S3Bucket:           os.Getenv(s3Bucket),
+		S3AccessKey:        os.Getenv(s3AccessKey),
+		S3SecretKey:        os.Getenv(s3SecretKey),
 	}
 
 	var missingConfigs []string
@@ -86,9 +129,79 @@ func loadConfig() (*core.AppConfig, error) {
 		}
 	}
 
+	for _, provider := range config.RateProviders {
+		switch provider {
+		case nbuRateProvider, ecbRateProvider:
+		case fileRateProvider:
+			if config.RatesFile == "" {
+				missingConfigs = append(missingConfigs, ratesFile)
+			}
+		default:
+			missingConfigs = append(missingConfigs, rateProviders)
+		}
+	}
+
+	if _, err := currency.ParseRoundingMode(config.RatesRounding); err != nil {
+		missingConfigs = append(missingConfigs, ratesRounding)
+	}
+
+	ratesCacheSecondsTTLFromEnv := os.Getenv(ratesCacheSecondsTTL)
+
+	if ratesCacheSecondsTTLFromEnv == "" {
+		config.RatesCacheTTL = defaultRatesCacheSecondsTTL
+	} else {
+		ttl, err := strconv.Atoi(ratesCacheSecondsTTLFromEnv)
+
+		if err != nil {
+			missingConfigs = append(missingConfigs, ratesCacheSecondsTTL)
+		} else {
+			config.RatesCacheTTL = ttl
+		}
+	}
+
+	switch config.AttachmentsStorage {
+	case localAttachmentsStorage:
+	case s3AttachmentsStorage:
+		for _, required := range []struct{ name, value string }{
+			{s3Region, config.S3Region},
+			{s3Bucket, config.S3Bucket},
+			{s3AccessKey, config.S3AccessKey},
+			{s3SecretKey, config.S3SecretKey},
+		} {
+			if required.value == "" {
+				missingConfigs = append(missingConfigs, required.name)
+			}
+		}
+	default:
+		missingConfigs = append(missingConfigs, attachmentsStorage)
+	}
+
+	if pathStyle := os.Getenv(s3PathStyle); pathStyle != "" {
+		value, err := strconv.ParseBool(pathStyle)
+
+		if err != nil {
+			missingConfigs = append(missingConfigs, s3PathStyle)
+		} else {
+			config.S3PathStyle = value
+		}
+	}
+
 	if len(missingConfigs) > 0 {
 		return nil, exceptions.NewInternalException("failed to read configuration", fmt.Errorf("missing required configurations: %v", missingConfigs))
 	}
 
 	return config, nil
 }
+
+// splitList parses a comma separated list, e.g. "nbu, ecb" into [nbu ecb].
+func splitList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -3,19 +3,24 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/people"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
 	"gitlab.com/massimo-ua/projecta/pkg/crypto"
 	"gitlab.com/massimo-ua/projecta/pkg/currency"
 	"gitlab.com/massimo-ua/projecta/pkg/dal"
 	"gitlab.com/massimo-ua/projecta/pkg/logger"
+	"gitlab.com/massimo-ua/projecta/pkg/storage"
 	"gitlab.com/massimo-ua/projecta/pkg/web"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 )
@@ -27,7 +32,15 @@ func createErrorHandler(logger core.Logger) func(err error) {
 	}
 }
 
-func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection) (http.Handler, error) {
+// backgroundJob runs alongside the HTTP server until ctx is done.
+type backgroundJob interface {
+	Run(ctx context.Context)
+}
+
+// setupAppHandler wires the services into the HTTP handler and returns it along
+// with the jobs main runs in the background: the scheduler booking the
+// recurring payments and the sweeper of the attachment files.
+func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection, logger core.Logger) (http.Handler, []backgroundJob, error) {
 	peopleRepository := dal.NewPgPeopleRepository(db)
 	hasher := crypto.NewBcryptHasher(0)
 	googleAuth, err := crypto.NewGoogleAuthProvider(crypto.GoogleAuthConfig{
@@ -37,7 +50,7 @@ func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection) (http.Handl
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	tokenProvider := crypto.NewJwtTokenProvider(
@@ -58,8 +71,31 @@ func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection) (http.Handl
 	typeRepository := dal.NewPgCostTypeRepository(db)
 	paymentRepository := dal.NewPgPaymentRepository(db)
 	assetRepository := dal.NewPgAssetRepository(db)
+	budgetRepository := dal.NewPgBudgetRepository(db)
+	fixedRateRepository := dal.NewPgFixedRateRepository(db)
+	settlementRepository := dal.NewPgSettlementRepository(db)
+	statementRepository := dal.NewPgStatementRepository(db)
+	recurringPaymentRepository := dal.NewPgRecurringPaymentRepository(db)
+	loanRepository := dal.NewPgLoanRepository(db)
+	vendorRepository := dal.NewPgVendorRepository(db)
+	contractRepository := dal.NewPgContractRepository(db)
+	estimateRepository := dal.NewPgEstimateRepository(db)
+	attachmentRepository := dal.NewPgAttachmentRepository(db)
+	tagRepository := dal.NewPgTagRepository(db)
+	rateProvider, err := setupRateProvider(config, dal.NewPgExchangeRateRepository(db))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attachmentStorage, err := setupAttachmentStorage(config)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
 	peopleService := projecta.NewPeopleService(peopleRepository)
-	projectService := projecta.NewProjectService(projectRepository, peopleService)
+	projectService := projecta.NewProjectService(db, projectRepository, peopleService)
 	categoryService := projecta.NewCategoryService(categoryRepository, projectService)
 	typeService := projecta.NewTypeService(typeRepository, categoryRepository, projectRepository)
 	paymentService := projecta.NewPaymentService(
@@ -67,6 +103,8 @@ func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection) (http.Handl
 		typeRepository,
 		projectRepository,
 		peopleService,
+		rateProvider,
+		fixedRateRepository,
 	)
 	assetService := asset.NewService(
 		db,
@@ -76,13 +114,62 @@ func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection) (http.Handl
 		projectRepository,
 		paymentRepository,
 	)
+	budgetService := projecta.NewBudgetService(
+		budgetRepository,
+		categoryRepository,
+		typeRepository,
+		projectRepository,
+	)
+	fixedRateService := projecta.NewFixedRateService(fixedRateRepository, projectRepository)
+	settlementService := projecta.NewSettlementService(settlementRepository, projectRepository)
+	paymentImportService := projecta.NewPaymentImportService(
+		db,
+		paymentRepository,
+		categoryRepository,
+		typeRepository,
+		projectRepository,
+		peopleService,
+		rateProvider,
+		fixedRateRepository,
+	)
+	statementService := statement.NewService(
+		db,
+		statementRepository,
+		paymentService,
+		typeRepository,
+		projectRepository,
+	)
 
-	rateProvider := currency.NewNBUCurrencyRateProvider(currency.NBUCurrencyRateProviderOptions{
-		SupportedCurrencies: []string{"UAH", "USD", "EUR", "PLN"},
-		CacheTTL:            30 * time.Minute,
-	})
+	recurringPaymentService := projecta.NewRecurringPaymentService(
+		db,
+		recurringPaymentRepository,
+		paymentRepository,
+		typeRepository,
+		projectRepository,
+		peopleService,
+		rateProvider,
+		fixedRateRepository,
+	)
 
-	return web.MakeHTTPHandler(
+	loanService := projecta.NewLoanService(loanRepository, paymentRepository, projectRepository)
+	vendorService := projecta.NewVendorService(db, vendorRepository, paymentRepository, projectRepository)
+	contractService := projecta.NewContractService(contractRepository, vendorRepository, paymentRepository, projectRepository)
+	estimateService := projecta.NewEstimateService(
+		db,
+		estimateRepository,
+		vendorRepository,
+		typeRepository,
+		contractRepository,
+		paymentRepository,
+		projectRepository,
+		peopleService,
+		rateProvider,
+		fixedRateRepository,
+	)
+	attachmentService := attachment.NewService(db, attachmentRepository, attachmentStorage, projectRepository)
+	tagService := projecta.NewTagService(db, tagRepository, projectRepository)
+
+	handler, err := web.MakeHTTPHandler(
 		customerService,
 		tokenProvider,
 		authService,
@@ -91,8 +178,119 @@ func setupAppHandler(config *core.AppConfig, db *dal.PgDbConnection) (http.Handl
 		typeService,
 		paymentService,
 		assetService,
+		budgetService,
+		fixedRateService,
+		settlementService,
+		paymentImportService,
+		statementService,
+		recurringPaymentService,
+		loanService,
+		vendorService,
+		contractService,
+		estimateService,
+		attachmentService,
+		tagService,
 		rateProvider,
 	)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return handler, []backgroundJob{
+		projecta.NewRecurringPaymentScheduler(recurringPaymentService, logger, 0),
+		attachment.NewSweeper(attachmentService, logger, 0),
+	}, nil
+}
+
+// setupRateProvider chains the configured rate providers, each one used only
+// when the ones before it fail. NBU rates are served from the store kept up to
+// date by setupRateRefresher.
+func setupRateProvider(config *core.AppConfig, store currency.RateStore) (currency.RateProvider, error) {
+	names := config.RateProviders
+	if len(names) == 0 {
+		names = []string{nbuRateProvider}
+	}
+
+	rounding, err := currency.ParseRoundingMode(config.RatesRounding)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cacheTTL := time.Duration(config.RatesCacheTTL) * time.Second
+	providers := make([]currency.RateProvider, 0, len(names))
+
+	for _, name := range names {
+		switch name {
+		case nbuRateProvider:
+			providers = append(providers, currency.NewStoredRateProvider(currency.StoredRateProviderOptions{
+				Source:   currency.NBURatesSource,
+				Store:    store,
+				Fetcher:  newNBUProvider(config),
+				Rounding: rounding,
+			}))
+		case ecbRateProvider:
+			providers = append(providers, currency.NewECBCurrencyRateProvider(currency.ECBCurrencyRateProviderOptions{
+				SupportedCurrencies: config.RateCurrencies,
+				CacheTTL:            cacheTTL,
+				Rounding:            rounding,
+			}))
+		case fileRateProvider:
+			provider, err := currency.NewStaticRateProvider(config.RatesFile, rounding)
+
+			if err != nil {
+				return nil, err
+			}
+
+			providers = append(providers, provider)
+		default:
+			return nil, exceptions.NewInternalException(fmt.Sprintf("unknown exchange rate provider: %s", name), nil)
+		}
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	return currency.NewCompositeRateProvider(providers...), nil
+}
+
+// setupAttachmentStorage returns the storage of the attached files, a directory
+// of the server unless an S3 compatible service is configured.
+func setupAttachmentStorage(config *core.AppConfig) (attachment.Storage, error) {
+	switch config.AttachmentsStorage {
+	case "", localAttachmentsStorage:
+		return storage.NewLocalStorage(config.AttachmentsDir)
+	case s3AttachmentsStorage:
+		return storage.NewS3Storage(storage.S3StorageOptions{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			PathStyle: config.S3PathStyle,
+		})
+	default:
+		return nil, exceptions.NewInternalException(fmt.Sprintf("unknown attachments storage: %s", config.AttachmentsStorage), nil)
+	}
+}
+
+// setupRateRefresher returns the refresher of the stored NBU rates, or nil when
+// the NBU provider is not configured.
+func setupRateRefresher(config *core.AppConfig, store currency.RateStore, logger core.Logger) *currency.RateRefresher {
+	if len(config.RateProviders) > 0 && !slices.Contains(config.RateProviders, nbuRateProvider) {
+		return nil
+	}
+
+	return currency.NewRateRefresher(newNBUProvider(config), store, logger, 0)
+}
+
+func newNBUProvider(config *core.AppConfig) *currency.NBUCurrencyRateProvider {
+	return currency.NewNBUCurrencyRateProvider(currency.NBUCurrencyRateProviderOptions{
+		SupportedCurrencies: config.RateCurrencies,
+		CacheTTL:            time.Duration(config.RatesCacheTTL) * time.Second,
+	})
 }
 
 func main() {
@@ -128,12 +326,20 @@ func main() {
 
 	log.Info("Database Connection Established", map[string]any{"connection_time": time.Since(startTime)})
 
-	webAPI, err := setupAppHandler(config, db)
+	webAPI, jobs, err := setupAppHandler(config, db, log)
 
 	if err != nil {
 		handleError(err)
 	}
 
+	if rateRefresher := setupRateRefresher(config, dal.NewPgExchangeRateRepository(db), log); rateRefresher != nil {
+		go rateRefresher.Run(ctx)
+	}
+
+	for _, job := range jobs {
+		go job.Run(ctx)
+	}
+
 	server := &http.Server{
 		Addr:    config.HttpUri,
 		Handler: webAPI,
@@ -18,6 +18,10 @@ type Asset struct {
 	price       *money.Money
 	acquiredAt  time.Time
 	owner       *projecta.Owner
+	// vendorID is the vendor the asset was bought from, uuid.Nil when unknown.
+	vendorID uuid.UUID
+	// tags are the names of the tags of the asset, sorted.
+	tags []string
 }
 
 func NewAsset(
@@ -73,6 +77,14 @@ func (a *Asset) Owner() *projecta.Owner {
 	return a.owner
 }
 
+func (a *Asset) VendorID() uuid.UUID {
+	return a.vendorID
+}
+
+func (a *Asset) Tags() []string {
+	return a.tags
+}
+
 func (a *Asset) SetName(name string) {
 	a.name = name
 }
@@ -101,8 +113,23 @@ func (a *Asset) SetOwner(owner *projecta.Owner) {
 	a.owner = owner
 }
 
+func (a *Asset) SetVendorID(vendorID uuid.UUID) {
+	a.vendorID = vendorID
+}
+
+func (a *Asset) SetTags(tags []string) {
+	a.tags = tags
+}
+
 type Collection = core.PaginatedCollection[*Asset]
 
 func NewCollection(total int) *Collection {
 	return core.NewPaginatedCollection[*Asset](total)
 }
+
+// Subtotal sums the prices of the assets of a currency acquired on the same day.
+type Subtotal struct {
+	Amount *money.Money
+	Date   time.Time
+	Count  int
+}
@@ -69,6 +69,14 @@ func TestAssetGettersAndSetters(t *testing.T) {
 		t.Errorf("Updated references mismatch")
 	}
 
+	if a.Tags() != nil {
+		t.Errorf("expected no tags, got %v", a.Tags())
+	}
+	a.SetTags([]string{"kitchen"})
+	if len(a.Tags()) != 1 || a.Tags()[0] != "kitchen" {
+		t.Errorf("Tags mismatch: got %v", a.Tags())
+	}
+
 	col := asset.NewCollection(5)
 	if col.Total() != 5 {
 		t.Errorf("Collection total mismatch")
@@ -12,6 +12,13 @@ type CollectionFilter struct {
 	TypeID    uuid.UUID
 	OwnerID   uuid.UUID
 	Name      string
+	// Tags matches the assets carrying all of them.
+	Tags []string
+}
+
+type TotalsFilter struct {
+	ProjectID uuid.UUID
+	OwnerID   uuid.UUID
 }
 
 type Filter struct {
@@ -10,6 +10,7 @@ type Service interface {
 	Create(ctx context.Context, command CreateAssetCommand) (*Asset, error)
 	Update(ctx context.Context, command UpdateAssetCommand) error
 	Remove(ctx context.Context, command RemoveAssetCommand) error
+	Totals(ctx context.Context, filter TotalsFilter) ([]*Subtotal, error)
 }
 
 type Repository interface {
@@ -17,4 +18,5 @@ type Repository interface {
 	Remove(ctx context.Context, asset *Asset) error
 	FindOne(ctx context.Context, filter Filter) (*Asset, error)
 	Find(ctx context.Context, filter CollectionFilter) (*Collection, error)
+	Totals(ctx context.Context, filter TotalsFilter) ([]*Subtotal, error)
 }
@@ -60,6 +60,25 @@ func (s *ServiceImpl) Find(ctx context.Context, filter CollectionFilter) (*Colle
 	return collection, nil
 }
 
+// Totals sums the asset prices of a project per currency and acquisition day.
+func (s *ServiceImpl) Totals(ctx context.Context, filter TotalsFilter) ([]*Subtotal, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToFindAsset, err)
+	}
+
+	filter.OwnerID = personID
+
+	totals, err := s.assets.Totals(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindAsset, err)
+	}
+
+	return totals, nil
+}
+
 func (s *ServiceImpl) FindOne(ctx context.Context, filter Filter) (*Asset, error) {
 	personID, err := core.AuthGuard(ctx)
 
@@ -97,6 +116,10 @@ func (s *ServiceImpl) Create(ctx context.Context, command CreateAssetCommand) (*
 		return nil, exceptions.NewInternalException(failedToCreateAsset, err)
 	}
 
+	if err = project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
 	costType, err := s.types.FindOne(ctx, projecta.TypeFilter{TypeID: command.TypeID, ProjectID: command.ProjectID})
 
 	if err != nil {
@@ -169,6 +192,10 @@ func (s *ServiceImpl) Remove(ctx context.Context, command RemoveAssetCommand) er
 		return exceptions.NewUnauthorizedException(failedToFindAsset, err)
 	}
 
+	if _, err = projecta.FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
 	asset, err := s.assets.FindOne(ctx, Filter{ID: command.AssetID, OwnerID: personID})
 
 	if err != nil {
@@ -185,12 +212,16 @@ func (s *ServiceImpl) Update(ctx context.Context, command UpdateAssetCommand) er
 		return exceptions.NewUnauthorizedException(failedToUpdateAsset, err)
 	}
 
-	_, err = s.projects.FindOne(ctx, projecta.ProjectFilter{ProjectID: command.ProjectID})
+	project, err := s.projects.FindOne(ctx, projecta.ProjectFilter{ProjectID: command.ProjectID})
 
 	if err != nil {
 		return exceptions.NewInternalException(failedToUpdateAsset, err)
 	}
 
+	if err = project.EnsureWritable(); err != nil {
+		return err
+	}
+
 	asset, err := s.assets.FindOne(ctx, Filter{ID: command.AssetID, OwnerID: personID})
 
 	if err != nil {
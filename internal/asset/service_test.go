@@ -28,6 +28,8 @@ type mockAssetRepo struct {
 	findOneErr error
 	asset      *asset.Asset
 	col        *asset.Collection
+	totals     []*asset.Subtotal
+	filter     asset.TotalsFilter
 }
 
 func (m *mockAssetRepo) Save(ctx context.Context, a *asset.Asset) error { return m.saveErr }
@@ -43,6 +45,13 @@ func (m *mockAssetRepo) Find(ctx context.Context, filter asset.CollectionFilter)
 	}
 	return asset.NewCollection(0), nil
 }
+func (m *mockAssetRepo) Totals(ctx context.Context, filter asset.TotalsFilter) ([]*asset.Subtotal, error) {
+	m.filter = filter
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	return m.totals, nil
+}
 func (m *mockAssetRepo) FindOne(ctx context.Context, filter asset.Filter) (*asset.Asset, error) {
 	if m.findOneErr != nil {
 		return nil, m.findOneErr
@@ -102,7 +111,28 @@ func (m *mockProjectRepo) FindByShareToken(ctx context.Context, token uuid.UUID)
 	}
 	return m.project, nil
 }
-func (m *mockProjectRepo) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+func (m *mockProjectRepo) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, role projecta.ProjectRole) (bool, error) {
+	return m.err == nil, m.err
+}
+func (m *mockProjectRepo) FindShareLink(ctx context.Context, token uuid.UUID) (*projecta.ShareLink, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) CreateShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	return m.err
+}
+func (m *mockProjectRepo) FindShareLinks(ctx context.Context, project *projecta.Project) ([]*projecta.ShareLink, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) UseShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	return m.err
+}
+func (m *mockProjectRepo) RemoveShareLink(ctx context.Context, projectID uuid.UUID, linkID uuid.UUID) error {
+	return m.err
+}
+func (m *mockProjectRepo) FindMembers(ctx context.Context, projectID uuid.UUID) ([]*projecta.ProjectMember, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) RemoveShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
 	return m.err
 }
 
@@ -114,6 +144,9 @@ func (m *mockPaymentRepo) Save(ctx context.Context, p *projecta.Payment) error {
 func (m *mockPaymentRepo) Remove(ctx context.Context, p *projecta.Payment) error {
 	return nil
 }
+func (m *mockPaymentRepo) Totals(ctx context.Context, filter projecta.PaymentTotalsFilter) ([]*projecta.PaymentSubtotal, error) {
+	return nil, nil
+}
 func (m *mockPaymentRepo) Find(ctx context.Context, filter projecta.PaymentCollectionFilter) (*projecta.PaymentCollection, error) {
 	return nil, nil
 }
@@ -151,6 +184,28 @@ func TestAssetService(t *testing.T) {
 		}
 	})
 
+	t.Run("Totals unauthorized, success and error", func(t *testing.T) {
+		repo := &mockAssetRepo{totals: []*asset.Subtotal{{Amount: money.New(1000, money.USD), Date: now, Count: 1}}}
+		svc := asset.NewService(&mockDb{}, repo, &mockPeopleService{}, &mockTypeRepo{}, &mockProjectRepo{}, &mockPaymentRepo{})
+
+		if _, err := svc.Totals(context.Background(), asset.TotalsFilter{}); err == nil {
+			t.Errorf("expected unauthorized error")
+		}
+
+		totals, err := svc.Totals(authedCtx, asset.TotalsFilter{ProjectID: project.ProjectID})
+		if err != nil || len(totals) != 1 {
+			t.Errorf("expected totals, got %v, err: %v", totals, err)
+		}
+		if repo.filter.OwnerID != requesterID || repo.filter.ProjectID != project.ProjectID {
+			t.Errorf("expected the requester to be passed as owner, got %+v", repo.filter)
+		}
+
+		svcErr := asset.NewService(&mockDb{}, &mockAssetRepo{findErr: errors.New("db error")}, &mockPeopleService{}, &mockTypeRepo{}, &mockProjectRepo{}, &mockPaymentRepo{})
+		if _, err = svcErr.Totals(authedCtx, asset.TotalsFilter{}); err == nil {
+			t.Errorf("expected totals error")
+		}
+	})
+
 	t.Run("FindOne unauthorized and success", func(t *testing.T) {
 		repo := &mockAssetRepo{asset: existingAsset}
 		svc := asset.NewService(&mockDb{}, repo, &mockPeopleService{}, &mockTypeRepo{}, &mockProjectRepo{}, &mockPaymentRepo{})
@@ -249,14 +304,21 @@ func TestAssetService(t *testing.T) {
 
 	t.Run("Remove success and errors", func(t *testing.T) {
 		assetRepo := &mockAssetRepo{asset: existingAsset}
-		svc := asset.NewService(&mockDb{}, assetRepo, &mockPeopleService{}, &mockTypeRepo{}, &mockProjectRepo{}, &mockPaymentRepo{})
+		projRepo := &mockProjectRepo{project: project}
+		svc := asset.NewService(&mockDb{}, assetRepo, &mockPeopleService{}, &mockTypeRepo{}, projRepo, &mockPaymentRepo{})
 
 		err := svc.Remove(context.Background(), asset.RemoveAssetCommand{})
 		if err == nil {
 			t.Errorf("expected unauthorized")
 		}
 
-		svcErr := asset.NewService(&mockDb{}, &mockAssetRepo{findOneErr: errors.New("not found")}, &mockPeopleService{}, &mockTypeRepo{}, &mockProjectRepo{}, &mockPaymentRepo{})
+		svcProjErr := asset.NewService(&mockDb{}, assetRepo, &mockPeopleService{}, &mockTypeRepo{}, &mockProjectRepo{err: errors.New("proj err")}, &mockPaymentRepo{})
+		err = svcProjErr.Remove(authedCtx, asset.RemoveAssetCommand{})
+		if err == nil {
+			t.Errorf("expected project error")
+		}
+
+		svcErr := asset.NewService(&mockDb{}, &mockAssetRepo{findOneErr: errors.New("not found")}, &mockPeopleService{}, &mockTypeRepo{}, projRepo, &mockPaymentRepo{})
 		err = svcErr.Remove(authedCtx, asset.RemoveAssetCommand{})
 		if err == nil {
 			t.Errorf("expected findone error")
@@ -313,4 +375,40 @@ func TestAssetService(t *testing.T) {
 			t.Errorf("unexpected update error: %v", err)
 		}
 	})
+
+	t.Run("Archived project is read-only", func(t *testing.T) {
+		archived := *project
+		archived.Archive(time.Now())
+		projRepo := &mockProjectRepo{project: &archived}
+		typeRepo := &mockTypeRepo{costType: costType}
+		svc := asset.NewService(&mockDb{}, &mockAssetRepo{asset: existingAsset}, &mockPeopleService{owner: owner}, typeRepo, projRepo, &mockPaymentRepo{})
+
+		if _, err := svc.Create(authedCtx, asset.CreateAssetCommand{ProjectID: archived.ProjectID}); err == nil {
+			t.Errorf("expected create to be rejected")
+		}
+		if err := svc.Update(authedCtx, asset.UpdateAssetCommand{ProjectID: archived.ProjectID}); err == nil {
+			t.Errorf("expected update to be rejected")
+		}
+		if err := svc.Remove(authedCtx, asset.RemoveAssetCommand{ProjectID: archived.ProjectID}); err == nil {
+			t.Errorf("expected remove to be rejected")
+		}
+	})
+
+	t.Run("Viewer can not change assets", func(t *testing.T) {
+		shared := *project
+		shared.Role = projecta.RoleViewer
+		projRepo := &mockProjectRepo{project: &shared}
+		typeRepo := &mockTypeRepo{costType: costType}
+		svc := asset.NewService(&mockDb{}, &mockAssetRepo{asset: existingAsset}, &mockPeopleService{owner: owner}, typeRepo, projRepo, &mockPaymentRepo{})
+
+		if _, err := svc.Create(authedCtx, asset.CreateAssetCommand{ProjectID: shared.ProjectID}); err == nil {
+			t.Errorf("expected create to be rejected")
+		}
+		if err := svc.Update(authedCtx, asset.UpdateAssetCommand{ProjectID: shared.ProjectID}); err == nil {
+			t.Errorf("expected update to be rejected")
+		}
+		if err := svc.Remove(authedCtx, asset.RemoveAssetCommand{ProjectID: shared.ProjectID}); err == nil {
+			t.Errorf("expected remove to be rejected")
+		}
+	})
 }
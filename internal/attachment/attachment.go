@@ -0,0 +1,175 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// MaxSize caps the size of an attached file.
+const MaxSize = 10 << 20
+
+// maxFileNameLength is the longest file name kept, in characters.
+const maxFileNameLength = 255
+
+// ContentTypes are the kinds of files accepted: PDFs, scans and photos of
+// invoices, receipts and warranties.
+var ContentTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/webp"}
+
+// TargetKind is the kind of record a file is attached to.
+type TargetKind string
+
+const (
+	PaymentTarget TargetKind = "PAYMENT"
+	AssetTarget   TargetKind = "ASSET"
+)
+
+func (k TargetKind) String() string {
+	return string(k)
+}
+
+// Target is the record the attached file justifies.
+type Target struct {
+	Kind TargetKind
+	ID   uuid.UUID
+}
+
+type Attachment struct {
+	ID          uuid.UUID
+	ProjectID   uuid.UUID
+	Target      Target
+	FileName    string
+	ContentType string
+	Size        int64
+	// Checksum is the hex encoded SHA-256 of the content. A file attached
+	// several times in a project is stored once.
+	Checksum   string
+	UploadedBy uuid.UUID
+	UploadedAt time.Time
+}
+
+func NewAttachment(
+	id uuid.UUID,
+	projectID uuid.UUID,
+	target Target,
+	fileName string,
+	contentType string,
+	size int64,
+	checksum string,
+	uploadedBy uuid.UUID,
+	uploadedAt time.Time,
+) (*Attachment, error) {
+	if target.Kind != PaymentTarget && target.Kind != AssetTarget {
+		return nil, exceptions.NewValidationException("files are attached to payments or assets", nil)
+	}
+
+	if target.ID == uuid.Nil {
+		return nil, exceptions.NewValidationException("attachment target is required", nil)
+	}
+
+	fileName, err := cleanFileName(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAccepted(contentType) {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unsupported file type %q, expected one of %s", contentType, strings.Join(ContentTypes, ", ")), nil)
+	}
+
+	if size <= 0 {
+		return nil, exceptions.NewValidationException("file is empty", nil)
+	}
+
+	if size > MaxSize {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("file must not be larger than %d MB", MaxSize>>20), nil)
+	}
+
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return nil, exceptions.NewValidationException("invalid file checksum", err)
+	}
+
+	return &Attachment{
+		ID:          id,
+		ProjectID:   projectID,
+		Target:      target,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+		UploadedBy:  uploadedBy,
+		UploadedAt:  uploadedAt,
+	}, nil
+}
+
+// File is the content of the attachments of a project with the same checksum.
+type File struct {
+	ProjectID uuid.UUID
+	Checksum  string
+}
+
+// StorageKey is where the content of the file is stored.
+func (f File) StorageKey() string {
+	return f.ProjectID.String() + "/" + f.Checksum
+}
+
+// File is the content of the attachment, which the attachments of the project
+// with the same checksum share.
+func (a *Attachment) File() File {
+	return File{ProjectID: a.ProjectID, Checksum: a.Checksum}
+}
+
+// StorageKey is where the content of the attachment is stored. The files of a
+// project are stored by their checksum, so attachments with the same content
+// share the file.
+func (a *Attachment) StorageKey() string {
+	return a.File().StorageKey()
+}
+
+// Checksum is the hex encoded SHA-256 of content.
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// cleanFileName keeps the last element of a path, as browsers of some systems
+// send the full path of the file picked, and refuses the names which cannot be
+// sent back in a download.
+func cleanFileName(fileName string) (string, error) {
+	if i := strings.LastIndexAny(fileName, `/\`); i >= 0 {
+		fileName = fileName[i+1:]
+	}
+
+	fileName = strings.TrimSpace(fileName)
+
+	if fileName == "" || fileName == "." || fileName == ".." {
+		return "", exceptions.NewValidationException("file name is required", nil)
+	}
+
+	if !utf8.ValidString(fileName) || strings.IndexFunc(fileName, unicode.IsControl) >= 0 || strings.Contains(fileName, `"`) {
+		return "", exceptions.NewValidationException("invalid file name", nil)
+	}
+
+	if utf8.RuneCountInString(fileName) > maxFileNameLength {
+		return "", exceptions.NewValidationException(fmt.Sprintf("file name must not be longer than %d characters", maxFileNameLength), nil)
+	}
+
+	return fileName, nil
+}
+
+func isAccepted(contentType string) bool {
+	for _, accepted := range ContentTypes {
+		if contentType == accepted {
+			return true
+		}
+	}
+
+	return false
+}
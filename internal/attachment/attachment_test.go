@@ -0,0 +1,72 @@
+package attachment_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+func isValidationError(err error) bool {
+	var ex exceptions.Exception
+	return errors.As(err, &ex) && ex.Code == exceptions.ValidationFailed
+}
+
+func TestNewAttachment(t *testing.T) {
+	projectID, paymentID, uploadedBy := uuid.New(), uuid.New(), uuid.New()
+	target := attachment.Target{Kind: attachment.PaymentTarget, ID: paymentID}
+	checksum := attachment.Checksum([]byte("receipt"))
+	now := time.Now()
+
+	a, err := attachment.NewAttachment(uuid.New(), projectID, target, `C:\Users\john\Receipts\ receipt.pdf `, "application/pdf", 7, checksum, uploadedBy, now)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if a.FileName != "receipt.pdf" || a.Target != target || a.Size != 7 || a.UploadedBy != uploadedBy {
+		t.Errorf("unexpected attachment %+v", a)
+	}
+	if a.StorageKey() != projectID.String()+"/"+checksum {
+		t.Errorf("unexpected storage key %q", a.StorageKey())
+	}
+	if checksum != "6f32860910ca0fb2a20c7fda143666b09dbf8db5238195c90a586fb542ff0cad" {
+		t.Errorf("unexpected checksum %q", checksum)
+	}
+	if attachment.AssetTarget.String() != "ASSET" {
+		t.Errorf("unexpected target kind %q", attachment.AssetTarget)
+	}
+
+	invalid := []struct {
+		name        string
+		target      attachment.Target
+		fileName    string
+		contentType string
+		size        int64
+		checksum    string
+	}{
+		{"unknown target", attachment.Target{Kind: "EXPENSE", ID: paymentID}, "receipt.pdf", "application/pdf", 7, checksum},
+		{"missing target", attachment.Target{Kind: attachment.AssetTarget}, "receipt.pdf", "application/pdf", 7, checksum},
+		{"missing file name", target, " ", "application/pdf", 7, checksum},
+		{"directory", target, "receipts/..", "application/pdf", 7, checksum},
+		{"control characters", target, "receipt\n.pdf", "application/pdf", 7, checksum},
+		{"quotes", target, `"receipt".pdf`, "application/pdf", 7, checksum},
+		{"invalid utf-8", target, "receipt\xff.pdf", "application/pdf", 7, checksum},
+		{"long file name", target, strings.Repeat("a", 252) + ".pdf", "application/pdf", 7, checksum},
+		{"unsupported type", target, "page.html", "text/html; charset=utf-8", 7, checksum},
+		{"empty file", target, "receipt.pdf", "application/pdf", 0, checksum},
+		{"large file", target, "receipt.pdf", "application/pdf", attachment.MaxSize + 1, checksum},
+		{"invalid checksum", target, "receipt.pdf", "application/pdf", 7, "not a checksum"},
+		{"short checksum", target, "receipt.pdf", "application/pdf", 7, checksum[:62]},
+	}
+
+	for _, c := range invalid {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := attachment.NewAttachment(uuid.New(), projectID, c.target, c.fileName, c.contentType, c.size, c.checksum, uploadedBy, now); !isValidationError(err) {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package attachment
+
+import (
+	"github.com/google/uuid"
+)
+
+// UploadCommand attaches a file to a payment or an asset of a project.
+// ContentType is the detected type of Content, not the one claimed by the
+// client.
+type UploadCommand struct {
+	ProjectID   uuid.UUID
+	Target      Target
+	FileName    string
+	ContentType string
+	Content     []byte
+}
@@ -0,0 +1,18 @@
+package attachment
+
+import (
+	"github.com/google/uuid"
+)
+
+// CollectionFilter picks the attachments of a project. Target and Checksum are
+// left out of the filter when empty.
+type CollectionFilter struct {
+	ProjectID uuid.UUID
+	Target    Target
+	Checksum  string
+}
+
+type Filter struct {
+	ProjectID    uuid.UUID
+	AttachmentID uuid.UUID
+}
@@ -0,0 +1,48 @@
+package attachment
+
+import (
+	"context"
+	"io"
+
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type Service interface {
+	Find(ctx context.Context, filter CollectionFilter) ([]*Attachment, error)
+	FindOne(ctx context.Context, filter Filter) (*Attachment, error)
+	// Open returns the attachment with its content, to be closed by the caller.
+	Open(ctx context.Context, filter Filter) (*Attachment, io.ReadCloser, error)
+	Upload(ctx context.Context, command UploadCommand) (*Attachment, error)
+	Remove(ctx context.Context, command projecta.RemoveProjectResourceCommand) error
+	// Sweep removes the files no attachment uses anymore and returns how many
+	// were removed.
+	Sweep(ctx context.Context) (int, error)
+}
+
+// Repository reads the attachments of the projects the authenticated person
+// owns or has been shared. Their files are looked after on behalf of no one.
+type Repository interface {
+	Find(ctx context.Context, filter CollectionFilter) ([]*Attachment, error)
+	FindOne(ctx context.Context, filter Filter) (*Attachment, error)
+	// Save fails with a not found error when the target is not a record of the
+	// project of the attachment.
+	Save(ctx context.Context, attachment *Attachment) error
+	Remove(ctx context.Context, attachment *Attachment) error
+	// Lock holds back the other uploads and releases of the file until the end
+	// of the transaction.
+	Lock(ctx context.Context, file File) error
+	// InUse tells whether an attachment of the project has the content of file.
+	InUse(ctx context.Context, file File) (bool, error)
+	// FindReleased returns the files attachments have been removed from, also
+	// along with their payment, asset or project, until they are forgotten.
+	FindReleased(ctx context.Context) ([]File, error)
+	Forget(ctx context.Context, file File) error
+}
+
+// Storage keeps the content of the attachments.
+type Storage interface {
+	Put(ctx context.Context, key string, content []byte, contentType string) error
+	// Open fails with a not found error when nothing is stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Remove(ctx context.Context, key string) error
+}
@@ -0,0 +1,276 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+const (
+	failedToFindAttachment   = "failed to find attachment"
+	failedToUploadAttachment = "failed to upload attachment"
+	failedToRemoveAttachment = "failed to remove attachment"
+	failedToSweepAttachments = "failed to sweep attachment files"
+	attachmentNotFound       = "attachment not found"
+)
+
+type ServiceImpl struct {
+	db          core.DbConnection
+	attachments Repository
+	storage     Storage
+	projects    projecta.ProjectRepository
+}
+
+func NewService(
+	db core.DbConnection,
+	attachments Repository,
+	storage Storage,
+	projects projecta.ProjectRepository,
+) *ServiceImpl {
+	return &ServiceImpl{
+		db:          db,
+		attachments: attachments,
+		storage:     storage,
+		projects:    projects,
+	}
+}
+
+func (s *ServiceImpl) Find(ctx context.Context, filter CollectionFilter) ([]*Attachment, error) {
+	attachments, err := s.attachments.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindAttachment, err)
+	}
+
+	return attachments, nil
+}
+
+func (s *ServiceImpl) FindOne(ctx context.Context, filter Filter) (*Attachment, error) {
+	a, err := s.attachments.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(attachmentNotFound, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindAttachment, err)
+	}
+
+	return a, nil
+}
+
+func (s *ServiceImpl) Open(ctx context.Context, filter Filter) (*Attachment, io.ReadCloser, error) {
+	a, err := s.FindOne(ctx, filter)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.storage.Open(ctx, a.StorageKey())
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, nil, exceptions.NewNotFoundException("attachment content not found", err)
+		}
+
+		return nil, nil, exceptions.NewInternalException(failedToFindAttachment, err)
+	}
+
+	return a, content, nil
+}
+
+// Upload attaches the file to the target. The content is stored once per
+// project: uploading a file already attached elsewhere in the project only
+// records the new attachment, and uploading it again to the same target
+// returns the existing attachment. The file is looked up and stored under its
+// lock, so it cannot be released meanwhile by the removal of the attachment
+// sharing it.
+func (s *ServiceImpl) Upload(ctx context.Context, command UploadCommand) (*Attachment, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToUploadAttachment, err)
+	}
+
+	project, err := projecta.FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := NewAttachment(
+		uuid.New(),
+		project.ProjectID,
+		command.Target,
+		command.FileName,
+		command.ContentType,
+		int64(len(command.Content)),
+		Checksum(command.Content),
+		personID,
+		time.Now(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		return s.save(ctx, a, command.Content)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saved.(*Attachment), nil
+}
+
+func (s *ServiceImpl) save(ctx context.Context, a *Attachment, content []byte) (*Attachment, error) {
+	if err := s.attachments.Lock(ctx, a.File()); err != nil {
+		return nil, exceptions.NewInternalException(failedToUploadAttachment, err)
+	}
+
+	same, err := s.attachments.Find(ctx, CollectionFilter{ProjectID: a.ProjectID, Checksum: a.Checksum})
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToUploadAttachment, err)
+	}
+
+	for _, existing := range same {
+		if existing.Target == a.Target {
+			return existing, nil
+		}
+	}
+
+	stored := len(same) == 0
+
+	if stored {
+		if err = s.storage.Put(ctx, a.StorageKey(), content, a.ContentType); err != nil {
+			return nil, exceptions.NewInternalException(failedToUploadAttachment, err)
+		}
+	}
+
+	if err = s.attachments.Save(ctx, a); err != nil {
+		if stored {
+			// the file is not referenced by any attachment, a failure to
+			// remove it only leaves an orphan behind
+			_ = s.storage.Remove(ctx, a.StorageKey())
+		}
+
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(targetNotFound(a.Target), err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToUploadAttachment, err)
+	}
+
+	return a, nil
+}
+
+// Remove removes the attachment, and its file once no other attachment of the
+// project shares it. A file failing to be removed is left to the next sweep.
+func (s *ServiceImpl) Remove(ctx context.Context, command projecta.RemoveProjectResourceCommand) error {
+	if _, err := projecta.FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	a, err := s.FindOne(ctx, Filter{ProjectID: command.ProjectID, AttachmentID: command.ResourceID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.attachments.Remove(ctx, a); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException(attachmentNotFound, err)
+		}
+
+		return exceptions.NewInternalException(failedToRemoveAttachment, err)
+	}
+
+	// an unused file is harmless, the attachment is gone either way
+	_, _ = s.release(ctx, a.File())
+
+	return nil
+}
+
+// Sweep removes the files of the removed attachments, also of those removed
+// along with their payment, asset or project, unless another attachment of the
+// project shares them. It returns the number of files removed along with the
+// errors of the files that failed, which are tried again on the next sweep.
+func (s *ServiceImpl) Sweep(ctx context.Context) (int, error) {
+	files, err := s.attachments.FindReleased(ctx)
+
+	if err != nil {
+		return 0, exceptions.NewInternalException(failedToSweepAttachments, err)
+	}
+
+	var (
+		removed int
+		errs    []error
+	)
+
+	for _, file := range files {
+		ok, err := s.release(ctx, file)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("file %s: %w", file.StorageKey(), err))
+			continue
+		}
+
+		if ok {
+			removed++
+		}
+	}
+
+	if len(errs) > 0 {
+		return removed, exceptions.NewInternalException(failedToSweepAttachments, errors.Join(errs...))
+	}
+
+	return removed, nil
+}
+
+// release removes the released file from the storage unless an attachment uses
+// it, and forgets it. It reports whether the file has been removed.
+func (s *ServiceImpl) release(ctx context.Context, file File) (bool, error) {
+	removed, err := s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		if err := s.attachments.Lock(ctx, file); err != nil {
+			return nil, err
+		}
+
+		inUse, err := s.attachments.InUse(ctx, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !inUse {
+			if err = s.storage.Remove(ctx, file.StorageKey()); err != nil {
+				return nil, err
+			}
+		}
+
+		return !inUse, s.attachments.Forget(ctx, file)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return removed.(bool), nil
+}
+
+func targetNotFound(target Target) string {
+	if target.Kind == AssetTarget {
+		return "asset not found"
+	}
+
+	return "payment not found"
+}
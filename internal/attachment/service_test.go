@@ -0,0 +1,521 @@
+package attachment_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type mockAttachmentRepo struct {
+	found       []*attachment.Attachment
+	one         *attachment.Attachment
+	saved       []*attachment.Attachment
+	removed     []*attachment.Attachment
+	findErr     error
+	oneErr      error
+	saveErr     error
+	removeErr   error
+	locked      []attachment.File
+	lockErr     error
+	inUse       bool
+	inUseErr    error
+	released    []attachment.File
+	releasedErr error
+	forgotten   []attachment.File
+	forgetErr   error
+}
+
+func (m *mockAttachmentRepo) Find(ctx context.Context, filter attachment.CollectionFilter) ([]*attachment.Attachment, error) {
+	return m.found, m.findErr
+}
+func (m *mockAttachmentRepo) FindOne(ctx context.Context, filter attachment.Filter) (*attachment.Attachment, error) {
+	if m.oneErr != nil {
+		return nil, m.oneErr
+	}
+	return m.one, nil
+}
+func (m *mockAttachmentRepo) Save(ctx context.Context, a *attachment.Attachment) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.saved = append(m.saved, a)
+	return nil
+}
+func (m *mockAttachmentRepo) Remove(ctx context.Context, a *attachment.Attachment) error {
+	if m.removeErr != nil {
+		return m.removeErr
+	}
+	m.removed = append(m.removed, a)
+	return nil
+}
+func (m *mockAttachmentRepo) Lock(ctx context.Context, file attachment.File) error {
+	m.locked = append(m.locked, file)
+	return m.lockErr
+}
+func (m *mockAttachmentRepo) InUse(ctx context.Context, file attachment.File) (bool, error) {
+	return m.inUse, m.inUseErr
+}
+func (m *mockAttachmentRepo) FindReleased(ctx context.Context) ([]attachment.File, error) {
+	return m.released, m.releasedErr
+}
+func (m *mockAttachmentRepo) Forget(ctx context.Context, file attachment.File) error {
+	if m.forgetErr != nil {
+		return m.forgetErr
+	}
+	m.forgotten = append(m.forgotten, file)
+	return nil
+}
+
+type mockDb struct {
+	err error
+	txs int
+}
+
+func (m *mockDb) Tx(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
+	m.txs++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return fn(ctx)
+}
+func (m *mockDb) Close()                         {}
+func (m *mockDb) Ping(ctx context.Context) error { return nil }
+
+type recordingLogger struct {
+	infos  int
+	errors []error
+}
+
+func (l *recordingLogger) Info(string, map[string]any) {
+	l.infos++
+}
+
+func (l *recordingLogger) Error(_ string, err error, _ map[string]any) {
+	l.errors = append(l.errors, err)
+}
+
+type mockStorage struct {
+	files     map[string][]byte
+	removed   []string
+	putErr    error
+	openErr   error
+	removeErr error
+}
+
+func newMockStorage() *mockStorage {
+	return &mockStorage{files: make(map[string][]byte)}
+}
+
+func (m *mockStorage) Put(ctx context.Context, key string, content []byte, contentType string) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+	m.files[key] = content
+	return nil
+}
+func (m *mockStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if m.openErr != nil {
+		return nil, m.openErr
+	}
+	return io.NopCloser(strings.NewReader(string(m.files[key]))), nil
+}
+func (m *mockStorage) Remove(ctx context.Context, key string) error {
+	if m.removeErr != nil {
+		return m.removeErr
+	}
+	m.removed = append(m.removed, key)
+	delete(m.files, key)
+	return nil
+}
+
+type mockProjectRepo struct {
+	project *projecta.Project
+	err     error
+}
+
+func (m *mockProjectRepo) Create(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Update(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Remove(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Find(ctx context.Context, filter projecta.ProjectCollectionFilter) ([]*projecta.Project, error) {
+	return nil, nil
+}
+func (m *mockProjectRepo) FindOne(ctx context.Context, filter projecta.ProjectFilter) (*projecta.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.project, nil
+}
+func (m *mockProjectRepo) FindByShareToken(ctx context.Context, token uuid.UUID) (*projecta.Project, error) {
+	return m.project, m.err
+}
+func (m *mockProjectRepo) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, role projecta.ProjectRole) (bool, error) {
+	return m.err == nil, m.err
+}
+func (m *mockProjectRepo) FindShareLink(ctx context.Context, token uuid.UUID) (*projecta.ShareLink, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) CreateShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	return m.err
+}
+func (m *mockProjectRepo) FindShareLinks(ctx context.Context, project *projecta.Project) ([]*projecta.ShareLink, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) UseShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	return m.err
+}
+func (m *mockProjectRepo) RemoveShareLink(ctx context.Context, projectID uuid.UUID, linkID uuid.UUID) error {
+	return m.err
+}
+func (m *mockProjectRepo) FindMembers(ctx context.Context, projectID uuid.UUID) ([]*projecta.ProjectMember, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) RemoveShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+	return m.err
+}
+
+func hasCode(err error, code exceptions.ErrorCode) bool {
+	var ex exceptions.Exception
+	return errors.As(err, &ex) && ex.Code == code
+}
+
+func TestAttachmentService(t *testing.T) {
+	requesterID := uuid.New()
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+
+	now := time.Now()
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John Doe"}
+	project, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, now, now)
+	paymentTarget := attachment.Target{Kind: attachment.PaymentTarget, ID: uuid.New()}
+	assetTarget := attachment.Target{Kind: attachment.AssetTarget, ID: uuid.New()}
+	content := []byte("%PDF-1.7 receipt")
+
+	existing := func(target attachment.Target) *attachment.Attachment {
+		a, _ := attachment.NewAttachment(uuid.New(), project.ProjectID, target, "receipt.pdf", "application/pdf", int64(len(content)), attachment.Checksum(content), requesterID, now)
+		return a
+	}
+
+	upload := func(target attachment.Target) attachment.UploadCommand {
+		return attachment.UploadCommand{ProjectID: project.ProjectID, Target: target, FileName: "receipt.pdf", ContentType: "application/pdf", Content: content}
+	}
+
+	projects := &mockProjectRepo{project: project}
+
+	t.Run("Upload stores the file", func(t *testing.T) {
+		repo, storage := &mockAttachmentRepo{}, newMockStorage()
+		svc := attachment.NewService(&mockDb{}, repo, storage, projects)
+
+		a, err := svc.Upload(authedCtx, upload(paymentTarget))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if a.UploadedBy != requesterID || a.Target != paymentTarget || a.Checksum != attachment.Checksum(content) || a.Size != int64(len(content)) {
+			t.Errorf("unexpected attachment %+v", a)
+		}
+		if len(repo.saved) != 1 || string(storage.files[a.StorageKey()]) != string(content) {
+			t.Errorf("expected the attachment and its file to be saved, got %v, %v", repo.saved, storage.files)
+		}
+		if len(repo.locked) != 1 || repo.locked[0] != a.File() {
+			t.Errorf("expected the file to be locked, got %v", repo.locked)
+		}
+	})
+
+	t.Run("Upload of a file attached to the target already", func(t *testing.T) {
+		same := existing(paymentTarget)
+		repo, storage := &mockAttachmentRepo{found: []*attachment.Attachment{existing(assetTarget), same}}, newMockStorage()
+		svc := attachment.NewService(&mockDb{}, repo, storage, projects)
+
+		a, err := svc.Upload(authedCtx, upload(paymentTarget))
+		if err != nil || a != same {
+			t.Errorf("expected the existing attachment, got %v, %v", a, err)
+		}
+		if len(repo.saved) != 0 || len(storage.files) != 0 {
+			t.Error("expected nothing to be saved")
+		}
+	})
+
+	t.Run("Upload of a file attached elsewhere in the project", func(t *testing.T) {
+		repo, storage := &mockAttachmentRepo{found: []*attachment.Attachment{existing(paymentTarget)}}, newMockStorage()
+		svc := attachment.NewService(&mockDb{}, repo, storage, projects)
+
+		a, err := svc.Upload(authedCtx, upload(assetTarget))
+		if err != nil || a.Target != assetTarget {
+			t.Fatalf("unexpected attachment %v, %v", a, err)
+		}
+		if len(repo.saved) != 1 || len(storage.files) != 0 {
+			t.Errorf("expected the file to be shared, got %v, %v", repo.saved, storage.files)
+		}
+
+		repo.saveErr = errors.New("db error")
+		if _, err = svc.Upload(authedCtx, upload(assetTarget)); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if len(storage.removed) != 0 {
+			t.Error("expected the shared file to be kept")
+		}
+	})
+
+	t.Run("Upload to a target out of the project", func(t *testing.T) {
+		repo, storage := &mockAttachmentRepo{saveErr: exceptions.NewNotFoundException("attachment not found", nil)}, newMockStorage()
+		svc := attachment.NewService(&mockDb{}, repo, storage, projects)
+
+		_, err := svc.Upload(authedCtx, upload(assetTarget))
+		if !hasCode(err, exceptions.NotFound) || err.Error() != "asset not found" {
+			t.Errorf("expected asset not found, got %v", err)
+		}
+		if len(storage.removed) != 1 || len(storage.files) != 0 {
+			t.Error("expected the stored file to be removed")
+		}
+
+		if _, err = svc.Upload(authedCtx, upload(paymentTarget)); err == nil || err.Error() != "payment not found" {
+			t.Errorf("expected payment not found, got %v", err)
+		}
+	})
+
+	t.Run("Upload failures", func(t *testing.T) {
+		invalid := upload(paymentTarget)
+		invalid.ContentType = "text/html; charset=utf-8"
+
+		failingStorage := newMockStorage()
+		failingStorage.putErr = errors.New("disk full")
+
+		cases := []struct {
+			name     string
+			ctx      context.Context
+			repo     *mockAttachmentRepo
+			storage  *mockStorage
+			projects *mockProjectRepo
+			command  attachment.UploadCommand
+			code     exceptions.ErrorCode
+		}{
+			{"unauthenticated", context.Background(), &mockAttachmentRepo{}, newMockStorage(), projects, upload(paymentTarget), exceptions.Unauthorized},
+			{"missing project", authedCtx, &mockAttachmentRepo{}, newMockStorage(), &mockProjectRepo{err: exceptions.NewNotFoundException("project not found", nil)}, upload(paymentTarget), exceptions.NotFound},
+			{"unsupported file", authedCtx, &mockAttachmentRepo{}, newMockStorage(), projects, invalid, exceptions.ValidationFailed},
+			{"failing lock", authedCtx, &mockAttachmentRepo{lockErr: errors.New("db error")}, newMockStorage(), projects, upload(paymentTarget), exceptions.Internal},
+			{"failing lookup", authedCtx, &mockAttachmentRepo{findErr: errors.New("db error")}, newMockStorage(), projects, upload(paymentTarget), exceptions.Internal},
+			{"failing storage", authedCtx, &mockAttachmentRepo{}, failingStorage, projects, upload(paymentTarget), exceptions.Internal},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				svc := attachment.NewService(&mockDb{}, c.repo, c.storage, c.projects)
+
+				if _, err := svc.Upload(c.ctx, c.command); !hasCode(err, c.code) {
+					t.Errorf("expected %s, got %v", c.code, err)
+				}
+				if len(c.repo.saved) != 0 {
+					t.Error("expected nothing to be saved")
+				}
+			})
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		a := existing(paymentTarget)
+		svc := attachment.NewService(&mockDb{}, &mockAttachmentRepo{found: []*attachment.Attachment{a}}, newMockStorage(), projects)
+
+		attachments, err := svc.Find(authedCtx, attachment.CollectionFilter{ProjectID: project.ProjectID, Target: paymentTarget})
+		if err != nil || len(attachments) != 1 || attachments[0] != a {
+			t.Errorf("unexpected attachments %v, %v", attachments, err)
+		}
+
+		svc = attachment.NewService(&mockDb{}, &mockAttachmentRepo{findErr: errors.New("db error")}, newMockStorage(), projects)
+		if _, err = svc.Find(authedCtx, attachment.CollectionFilter{ProjectID: project.ProjectID}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		a := existing(paymentTarget)
+		storage := newMockStorage()
+		storage.files[a.StorageKey()] = content
+		filter := attachment.Filter{ProjectID: project.ProjectID, AttachmentID: a.ID}
+
+		svc := attachment.NewService(&mockDb{}, &mockAttachmentRepo{one: a}, storage, projects)
+
+		found, r, err := svc.Open(authedCtx, filter)
+		if err != nil || found != a {
+			t.Fatalf("unexpected attachment %v, %v", found, err)
+		}
+		read, _ := io.ReadAll(r)
+		_ = r.Close()
+		if string(read) != string(content) {
+			t.Errorf("unexpected content %q", read)
+		}
+
+		svc = attachment.NewService(&mockDb{}, &mockAttachmentRepo{oneErr: exceptions.NewNotFoundException("attachment not found", nil)}, storage, projects)
+		if _, _, err = svc.Open(authedCtx, filter); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		svc = attachment.NewService(&mockDb{}, &mockAttachmentRepo{oneErr: errors.New("db error")}, storage, projects)
+		if _, _, err = svc.Open(authedCtx, filter); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		storage.openErr = exceptions.NewNotFoundException("file not found", nil)
+		svc = attachment.NewService(&mockDb{}, &mockAttachmentRepo{one: a}, storage, projects)
+		if _, _, err = svc.Open(authedCtx, filter); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error for a missing file, got %v", err)
+		}
+
+		storage.openErr = errors.New("disk error")
+		if _, _, err = svc.Open(authedCtx, filter); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		a := existing(paymentTarget)
+		command := projecta.RemoveProjectResourceCommand{ProjectID: project.ProjectID, ResourceID: a.ID}
+
+		repo, storage := &mockAttachmentRepo{one: a}, newMockStorage()
+		if err := attachment.NewService(&mockDb{}, repo, storage, projects).Remove(authedCtx, command); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(repo.removed) != 1 || len(storage.removed) != 1 || storage.removed[0] != a.StorageKey() {
+			t.Errorf("expected the attachment and its file to be removed, got %v, %v", repo.removed, storage.removed)
+		}
+
+		if len(repo.locked) != 1 || len(repo.forgotten) != 1 || repo.forgotten[0] != a.File() {
+			t.Errorf("expected the file to be released under its lock, got %v, %v", repo.locked, repo.forgotten)
+		}
+
+		for _, repo := range []*mockAttachmentRepo{
+			{one: a, inUse: true},
+			{one: a, inUseErr: errors.New("db error")},
+			{one: a, lockErr: errors.New("db error")},
+		} {
+			storage := newMockStorage()
+			if err := attachment.NewService(&mockDb{}, repo, storage, projects).Remove(authedCtx, command); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(repo.removed) != 1 || len(storage.removed) != 0 {
+				t.Errorf("expected the file to be kept, got %v", storage.removed)
+			}
+		}
+
+		// the file is left to the sweep when the release fails
+		repo = &mockAttachmentRepo{one: a}
+		if err := attachment.NewService(&mockDb{err: errors.New("tx error")}, repo, newMockStorage(), projects).Remove(authedCtx, command); err != nil || len(repo.removed) != 1 {
+			t.Errorf("expected the attachment to be removed, got %v", err)
+		}
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		unused := attachment.File{ProjectID: project.ProjectID, Checksum: attachment.Checksum([]byte("unused"))}
+		shared := attachment.File{ProjectID: project.ProjectID, Checksum: attachment.Checksum(content)}
+
+		repo, storage := &mockAttachmentRepo{released: []attachment.File{unused}}, newMockStorage()
+		storage.files[unused.StorageKey()] = []byte("unused")
+		svc := attachment.NewService(&mockDb{}, repo, storage, projects)
+
+		removed, err := svc.Sweep(context.Background())
+		if err != nil || removed != 1 || len(storage.files) != 0 || len(repo.forgotten) != 1 {
+			t.Errorf("expected the unused file to be removed, got %d, %v", removed, err)
+		}
+
+		repo, storage = &mockAttachmentRepo{released: []attachment.File{shared}, inUse: true}, newMockStorage()
+		removed, err = attachment.NewService(&mockDb{}, repo, storage, projects).Sweep(context.Background())
+		if err != nil || removed != 0 || len(storage.removed) != 0 || len(repo.forgotten) != 1 {
+			t.Errorf("expected the shared file to be kept and forgotten, got %d, %v", removed, err)
+		}
+
+		failing := newMockStorage()
+		failing.removeErr = errors.New("disk error")
+
+		cases := []struct {
+			name    string
+			repo    *mockAttachmentRepo
+			storage *mockStorage
+		}{
+			{"failing lookup", &mockAttachmentRepo{releasedErr: errors.New("db error")}, newMockStorage()},
+			{"failing storage", &mockAttachmentRepo{released: []attachment.File{unused}}, failing},
+			{"failing forget", &mockAttachmentRepo{released: []attachment.File{unused}, forgetErr: errors.New("db error")}, newMockStorage()},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				if _, err := attachment.NewService(&mockDb{}, c.repo, c.storage, projects).Sweep(context.Background()); !hasCode(err, exceptions.Internal) {
+					t.Errorf("expected internal error, got %v", err)
+				}
+				if len(c.repo.forgotten) != 0 {
+					t.Error("expected the file to be swept again")
+				}
+			})
+		}
+	})
+
+	t.Run("Remove failures", func(t *testing.T) {
+		a := existing(paymentTarget)
+		command := projecta.RemoveProjectResourceCommand{ProjectID: project.ProjectID, ResourceID: a.ID}
+
+		cases := []struct {
+			name     string
+			repo     *mockAttachmentRepo
+			projects *mockProjectRepo
+			code     exceptions.ErrorCode
+		}{
+			{"missing project", &mockAttachmentRepo{one: a}, &mockProjectRepo{err: errors.New("db error")}, exceptions.Internal},
+			{"missing attachment", &mockAttachmentRepo{oneErr: exceptions.NewNotFoundException("attachment not found", nil)}, projects, exceptions.NotFound},
+			{"removed meanwhile", &mockAttachmentRepo{one: a, removeErr: exceptions.NewNotFoundException("attachment not found", nil)}, projects, exceptions.NotFound},
+			{"failing removal", &mockAttachmentRepo{one: a, removeErr: errors.New("db error")}, projects, exceptions.Internal},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				storage := newMockStorage()
+
+				if err := attachment.NewService(&mockDb{}, c.repo, storage, c.projects).Remove(authedCtx, command); !hasCode(err, c.code) {
+					t.Errorf("expected %s, got %v", c.code, err)
+				}
+				if len(storage.removed) != 0 {
+					t.Error("expected the file to be kept")
+				}
+			})
+		}
+	})
+}
+
+func TestSweeper(t *testing.T) {
+	run := func(repo *mockAttachmentRepo, storage *mockStorage) *recordingLogger {
+		logger := &recordingLogger{}
+		svc := attachment.NewService(&mockDb{}, repo, storage, &mockProjectRepo{})
+		sweeper := attachment.NewSweeper(svc, logger, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+
+		go func() {
+			sweeper.Run(ctx)
+			close(done)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		<-done
+
+		return logger
+	}
+
+	if logger := run(&mockAttachmentRepo{releasedErr: errors.New("db error")}, newMockStorage()); len(logger.errors) < 2 {
+		t.Errorf("expected the sweeper to keep running, got %d attempts", len(logger.errors))
+	}
+
+	released := attachment.File{ProjectID: uuid.New(), Checksum: attachment.Checksum([]byte("receipt"))}
+	if logger := run(&mockAttachmentRepo{released: []attachment.File{released}}, newMockStorage()); logger.infos < 1 || len(logger.errors) != 0 {
+		t.Errorf("expected the sweep to be logged, got %d, %v", logger.infos, logger.errors)
+	}
+
+	if attachment.NewSweeper(nil, &recordingLogger{}, 0) == nil {
+		t.Error("expected a sweeper with the default interval")
+	}
+}
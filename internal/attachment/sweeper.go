@@ -0,0 +1,52 @@
+package attachment
+
+import (
+	"context"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"time"
+)
+
+const defaultSweeperInterval = time.Hour
+
+// Sweeper removes the files of the removed attachments from the storage in the
+// background. Every web replica runs one, the files are locked while they are
+// released.
+type Sweeper struct {
+	service  Service
+	logger   core.Logger
+	interval time.Duration
+}
+
+func NewSweeper(service Service, logger core.Logger, interval time.Duration) *Sweeper {
+	if interval <= 0 {
+		interval = defaultSweeperInterval
+	}
+
+	return &Sweeper{
+		service:  service,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run sweeps the files right away and then every interval, until ctx is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	for {
+		removed, err := s.service.Sweep(ctx)
+
+		if err != nil {
+			s.logger.Error("failed to sweep attachment files", err, map[string]any{"removed": removed})
+		} else if removed > 0 {
+			s.logger.Info("swept attachment files", map[string]any{"removed": removed})
+		}
+
+		timer := time.NewTimer(s.interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
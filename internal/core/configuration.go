@@ -13,4 +13,17 @@ type AppConfig struct {
 	HttpReadTimeout    time.Duration
 	HttpWriteTimeout   time.Duration
 	ShutdownTimeout    time.Duration
+	RateProviders      []string
+	RateCurrencies     []string
+	RatesFile          string
+	RatesCacheTTL      int
+	RatesRounding      string
+	AttachmentsStorage string
+	AttachmentsDir     string
+	S3Endpoint         string
+	S3Region           string
+	S3Bucket           string
+	S3AccessKey        string
+	S3SecretKey        string
+	S3PathStyle        bool
 }
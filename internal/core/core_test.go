@@ -3,6 +3,8 @@ package core_test
 import (
 	"context"
 	"errors"
+	"math"
+	"math/big"
 	"testing"
 	"time"
 
@@ -46,6 +48,39 @@ func TestDates(t *testing.T) {
 	})
 }
 
+func TestDecimal(t *testing.T) {
+	if r := core.ExactDecimal(41.5); r.Cmp(big.NewRat(83, 2)) != 0 {
+		t.Errorf("expected 83/2, got %v", r)
+	}
+	if r := core.ExactDecimal(0.1); r.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("expected 1/10, got %v", r)
+	}
+	if r := core.ExactDecimal(math.NaN()); r.Sign() != 0 {
+		t.Errorf("expected NaN to read as 0, got %v", r)
+	}
+
+	cases := []struct {
+		value    *big.Rat
+		halfEven bool
+		want     int64
+	}{
+		{big.NewRat(10, 1), false, 10},
+		{big.NewRat(5, 2), false, 3},
+		{big.NewRat(5, 2), true, 2},
+		{big.NewRat(7, 2), true, 4},
+		{big.NewRat(-5, 2), false, -3},
+		{big.NewRat(-5, 2), true, -2},
+		{big.NewRat(249, 100), false, 2},
+		{big.NewRat(251, 100), true, 3},
+	}
+
+	for _, c := range cases {
+		if got := core.RoundRat(c.value, c.halfEven); got != c.want {
+			t.Errorf("RoundRat(%v, %v) = %d, want %d", c.value, c.halfEven, got, c.want)
+		}
+	}
+}
+
 func TestContext(t *testing.T) {
 	t.Run("AuthGuard with valid UUID context value", func(t *testing.T) {
 		expectedID := uuid.New()
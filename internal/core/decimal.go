@@ -0,0 +1,47 @@
+package core
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// ExactDecimal reads value as the decimal it was entered or published as, e.g.
+// 41.5 as 83/2 rather than the closest binary fraction. NaN and infinities
+// read as 0.
+func ExactDecimal(value float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+
+	return r
+}
+
+// RoundRat rounds value to the closest integer, the halves away from zero or,
+// with halfEven, to the even neighbour.
+func RoundRat(value *big.Rat, halfEven bool) int64 {
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+	if rem.Sign() == 0 {
+		return quo.Int64()
+	}
+
+	// compare twice the remainder with the denominator to find out whether the
+	// value is below, exactly at or above the half
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+
+	away := false
+	switch half.Cmp(value.Denom()) {
+	case 1:
+		away = true
+	case 0:
+		away = !halfEven || quo.Bit(0) == 1
+	}
+
+	if away {
+		quo.Add(quo, big.NewInt(int64(value.Sign())))
+	}
+
+	return quo.Int64()
+}
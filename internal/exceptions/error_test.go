@@ -133,5 +133,10 @@ func TestException(t *testing.T) {
 		if unauth.Code != exceptions.Unauthorized {
 			t.Errorf("expected Unauthorized code, got '%s'", unauth.Code)
 		}
+
+		forbidden := exceptions.NewForbiddenException("forbidden", baseErr)
+		if forbidden.Code != exceptions.Forbidden {
+			t.Errorf("expected Forbidden code, got '%s'", forbidden.Code)
+		}
 	})
 }
@@ -0,0 +1,84 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// Budget is the amount planned to be spent on a cost category, or on a single
+// cost type of that category when Type is set.
+type Budget struct {
+	ID          uuid.UUID
+	ProjectID   uuid.UUID
+	Category    *CostCategory
+	Type        *CostType
+	Amount      *money.Money
+	Description string
+}
+
+func NewBudget(
+	id uuid.UUID,
+	projectID uuid.UUID,
+	category *CostCategory,
+	costType *CostType,
+	amount *money.Money,
+	description string,
+) (*Budget, error) {
+	if category == nil {
+		return nil, exceptions.NewValidationException("budget category is required", nil)
+	}
+
+	if costType != nil && (costType.Category == nil || costType.Category.ID != category.ID) {
+		return nil, exceptions.NewValidationException("cost type does not belong to the budget category", nil)
+	}
+
+	if amount == nil || !amount.IsPositive() {
+		return nil, exceptions.NewValidationException("budget amount must be greater than 0", nil)
+	}
+
+	return &Budget{
+		ID:          id,
+		ProjectID:   projectID,
+		Category:    category,
+		Type:        costType,
+		Amount:      amount,
+		Description: description,
+	}, nil
+}
+
+type BudgetCollection = core.PaginatedCollection[*Budget]
+
+func NewBudgetCollection(total int) *BudgetCollection {
+	return core.NewPaginatedCollection[*Budget](total)
+}
+
+// BudgetReportLine holds a budget together with the paid payments booked
+// against it, summed per day and currency.
+type BudgetReportLine struct {
+	Budget *Budget
+	Actual []*PaymentSubtotal
+}
+
+type BudgetReport struct {
+	Project *Project
+	Lines   []*BudgetReportLine
+}
+
+// Nested tells whether the line budgets a cost type of a category the report
+// budgets as a whole too. Its plan and spending are part of the category ones
+// then, and are not to be added to the project totals again.
+func (r *BudgetReport) Nested(line *BudgetReportLine) bool {
+	if line.Budget.Type == nil {
+		return false
+	}
+
+	for _, other := range r.Lines {
+		if other.Budget.Type == nil && other.Budget.Category.ID == line.Budget.Category.ID {
+			return true
+		}
+	}
+
+	return false
+}
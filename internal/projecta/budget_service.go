@@ -0,0 +1,205 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToCreateBudget = "failed to create budget"
+	failedToUpdateBudget = "failed to update budget"
+	failedToFindBudget   = "failed to find budget"
+	failedToRemoveBudget = "failed to remove budget"
+	failedToBuildReport  = "failed to build budget report"
+)
+
+type BudgetServiceImpl struct {
+	budgets    BudgetRepository
+	categories CategoryRepository
+	types      TypeRepository
+	projects   ProjectRepository
+}
+
+func NewBudgetService(
+	budgets BudgetRepository,
+	categories CategoryRepository,
+	types TypeRepository,
+	projects ProjectRepository,
+) *BudgetServiceImpl {
+	return &BudgetServiceImpl{
+		budgets:    budgets,
+		categories: categories,
+		types:      types,
+		projects:   projects,
+	}
+}
+
+func (s *BudgetServiceImpl) Find(ctx context.Context, filter BudgetCollectionFilter) (*BudgetCollection, error) {
+	collection, err := s.budgets.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindBudget, err)
+	}
+
+	return collection, nil
+}
+
+func (s *BudgetServiceImpl) FindOne(ctx context.Context, filter BudgetFilter) (*Budget, error) {
+	b, err := s.budgets.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToFindBudget, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindBudget, err)
+	}
+
+	return b, nil
+}
+
+func (s *BudgetServiceImpl) Create(ctx context.Context, command CreateBudgetCommand) (*Budget, error) {
+	project, err := s.projects.FindOne(ctx, ProjectFilter{ProjectID: command.ProjectID})
+
+	if err != nil {
+		return nil, exceptions.NewValidationException(failedToCreateBudget, err)
+	}
+
+	if err = project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	category, costType, err := s.resolveScope(ctx, command.ProjectID, command.CategoryID, command.TypeID)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException(failedToCreateBudget, err)
+	}
+
+	b, err := NewBudget(
+		uuid.New(),
+		project.ProjectID,
+		category,
+		costType,
+		money.New(command.Amount, project.MainCurrency),
+		command.Description,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.budgets.Save(ctx, b); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateBudget, err)
+	}
+
+	return b, nil
+}
+
+func (s *BudgetServiceImpl) Update(ctx context.Context, command UpdateBudgetCommand) error {
+	project, err := s.projects.FindOne(ctx, ProjectFilter{ProjectID: command.ProjectID})
+
+	if err != nil {
+		return exceptions.NewValidationException(failedToUpdateBudget, err)
+	}
+
+	if err = project.EnsureWritable(); err != nil {
+		return err
+	}
+
+	b, err := s.FindOne(ctx, BudgetFilter{BudgetID: command.ID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	category, costType, err := s.resolveScope(ctx, command.ProjectID, command.CategoryID, command.TypeID)
+
+	if err != nil {
+		return exceptions.NewValidationException(failedToUpdateBudget, err)
+	}
+
+	updated, err := NewBudget(
+		b.ID,
+		b.ProjectID,
+		category,
+		costType,
+		money.New(command.Amount, project.MainCurrency),
+		command.Description,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.budgets.Save(ctx, updated); err != nil {
+		return exceptions.NewInternalException(failedToUpdateBudget, err)
+	}
+
+	return nil
+}
+
+func (s *BudgetServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	b, err := s.FindOne(ctx, BudgetFilter{BudgetID: command.ResourceID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.budgets.Remove(ctx, b); err != nil {
+		return exceptions.NewInternalException(failedToRemoveBudget, err)
+	}
+
+	return nil
+}
+
+func (s *BudgetServiceImpl) Report(ctx context.Context, projectID uuid.UUID) (*BudgetReport, error) {
+	project, err := s.projects.FindOne(ctx, ProjectFilter{ProjectID: projectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToBuildReport, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToBuildReport, err)
+	}
+
+	lines, err := s.budgets.Report(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToBuildReport, err)
+	}
+
+	return &BudgetReport{
+		Project: project,
+		Lines:   lines,
+	}, nil
+}
+
+// resolveScope loads the category a budget is attached to and, when typeID is
+// set, the cost type narrowing it down.
+func (s *BudgetServiceImpl) resolveScope(ctx context.Context, projectID, categoryID, typeID uuid.UUID) (*CostCategory, *CostType, error) {
+	category, err := s.categories.FindOne(ctx, CategoryFilter{CategoryID: categoryID, ProjectID: projectID})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if typeID == uuid.Nil {
+		return category, nil, nil
+	}
+
+	costType, err := s.types.FindOne(ctx, TypeFilter{TypeID: typeID, ProjectID: projectID})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return category, costType, nil
+}
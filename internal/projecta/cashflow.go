@@ -0,0 +1,153 @@
+package projecta
+
+import (
+	"time"
+)
+
+const DefaultBurnRateWindow = 3
+
+type CashFlowPoint struct {
+	Start      time.Time
+	Amount     int64
+	Cumulative int64
+}
+
+// CashFlow is the spending of a project period by period, in the project main
+// currency, together with a forecast of the spending until the project ends.
+type CashFlow struct {
+	Period ReportPeriod
+	Points []CashFlowPoint
+	Spent  int64
+	// BurnRate is the average spending of the last complete periods.
+	BurnRate int64
+	Planned  int64
+	// RemainingPeriods counts the periods after the current one up to the one
+	// the project ends in.
+	RemainingPeriods int
+	// Projected is the spending expected by the end of the project if the burn
+	// rate holds, the current period included: what it has not spent yet of
+	// the burn rate is still to come.
+	Projected int64
+	// ExhaustedAt is the start of the period the planned budget runs out in at
+	// the burn rate, zero when it lasts until the project ends or nothing is
+	// planned.
+	ExhaustedAt time.Time
+}
+
+// NewCashFlow lays spending, keyed by the start of the period it happened in,
+// out from the first period with spending, or the one the project starts in, up
+// to the period of now.
+func NewCashFlow(project *Project, period ReportPeriod, spending map[time.Time]int64, window int, planned int64, now time.Time) *CashFlow {
+	if window <= 0 {
+		window = DefaultBurnRateWindow
+	}
+
+	current := period.Start(now)
+	first := current
+
+	if !project.StartDate.IsZero() && period.Start(project.StartDate).Before(first) {
+		first = period.Start(project.StartDate)
+	}
+
+	for start := range spending {
+		if start.Before(first) {
+			first = start
+		}
+	}
+
+	cf := &CashFlow{
+		Period:  period,
+		Planned: planned,
+		Points:  make([]CashFlowPoint, 0),
+	}
+
+	for start := first; !start.After(current); start = period.Next(start) {
+		cf.Spent += spending[start]
+		cf.Points = append(cf.Points, CashFlowPoint{
+			Start:      start,
+			Amount:     spending[start],
+			Cumulative: cf.Spent,
+		})
+	}
+
+	// spending later than now, e.g. scheduled payments, is still spent
+	for start, amount := range spending {
+		if start.After(current) {
+			cf.Spent += amount
+		}
+	}
+
+	cf.BurnRate = burnRate(cf.Points, window)
+
+	if !project.EndDate.IsZero() {
+		for start := period.Next(current); !start.After(project.EndDate); start = period.Next(start) {
+			cf.RemainingPeriods++
+		}
+	}
+
+	var rest int64
+	if project.EndDate.IsZero() || !project.EndDate.Before(current) {
+		rest = max(cf.BurnRate-spending[current], 0)
+	}
+
+	cf.Projected = cf.Spent + rest + cf.BurnRate*int64(cf.RemainingPeriods)
+	cf.ExhaustedAt = exhaustion(period, current, cf.Spent+rest, cf.BurnRate, planned, cf.RemainingPeriods)
+
+	return cf
+}
+
+// Overrun reports whether the projected spending exceeds the planned budget.
+func (cf *CashFlow) Overrun() bool {
+	return cf.Planned > 0 && cf.Projected > cf.Planned
+}
+
+// burnRate averages the last window complete periods, the current one is not
+// over yet. A project in its first period burns what it spent so far.
+func burnRate(points []CashFlowPoint, window int) int64 {
+	complete := points[:len(points)-1]
+
+	if len(complete) == 0 {
+		return points[len(points)-1].Amount
+	}
+
+	if len(complete) > window {
+		complete = complete[len(complete)-window:]
+	}
+
+	var total int64
+	for _, p := range complete {
+		total += p.Amount
+	}
+
+	return total / int64(len(complete))
+}
+
+// exhaustion returns the start of the period the money left runs out in at
+// rate, as long as that happens before the project ends. spent includes what
+// the current period is expected to spend.
+func exhaustion(period ReportPeriod, current time.Time, spent int64, rate int64, planned int64, remaining int) time.Time {
+	if planned <= 0 {
+		return time.Time{}
+	}
+
+	if spent >= planned {
+		return current
+	}
+
+	if rate <= 0 {
+		return time.Time{}
+	}
+
+	periods := (planned - spent + rate - 1) / rate
+
+	if periods > int64(remaining) {
+		return time.Time{}
+	}
+
+	start := current
+	for ; periods > 0; periods-- {
+		start = period.Next(start)
+	}
+
+	return start
+}
@@ -43,6 +43,10 @@ func (s *CategoryServiceImpl) Create(ctx context.Context, command CreateCategory
 		)
 	}
 
+	if err = project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
 	category, err := NewCostCategory(
 		uuid.New(),
 		command.ProjectID,
@@ -60,6 +64,10 @@ func (s *CategoryServiceImpl) Create(ctx context.Context, command CreateCategory
 }
 
 func (s *CategoryServiceImpl) Remove(ctx context.Context, command RemoveCategoryCommand) error {
+	if _, err := FindWritableProject(ctx, s.projectService, command.ProjectID); err != nil {
+		return err
+	}
+
 	category, err := s.repository.FindOne(ctx, CategoryFilter{CategoryID: command.ID, ProjectID: command.ProjectID})
 
 	if err != nil {
@@ -76,6 +84,10 @@ func (s *CategoryServiceImpl) Remove(ctx context.Context, command RemoveCategory
 }
 
 func (s *CategoryServiceImpl) Update(ctx context.Context, command UpdateCategoryCommand) error {
+	if _, err := FindWritableProject(ctx, s.projectService, command.ProjectID); err != nil {
+		return err
+	}
+
 	category, err := s.repository.FindOne(ctx, CategoryFilter{CategoryID: command.ID, ProjectID: command.ProjectID})
 
 	if err != nil {
@@ -46,6 +46,36 @@ type RemoveProjectCommand struct {
 	PersonID  uuid.UUID
 }
 
+type ArchiveProjectCommand struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+}
+
+type CreateShareLinkCommand struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+	Role      ProjectRole
+	ExpiresAt time.Time
+	MaxUses   int
+}
+
+type RevokeShareLinkCommand struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+	LinkID    uuid.UUID
+}
+
+type RotateShareTokenCommand struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+}
+
+type RevokeMemberCommand struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+	MemberID  uuid.UUID
+}
+
 type CreateTypeCommand struct {
 	ProjectID   uuid.UUID
 	CategoryID  uuid.UUID
@@ -75,6 +105,12 @@ type CreatePaymentCommand struct {
 	Description string
 	PaymentDate time.Time
 	Kind        PaymentKind
+	// ExchangeRate overrides the looked up rate to the project main currency,
+	// e.g. with the rate the bank actually charged. Zero means look it up.
+	ExchangeRate float64
+	// Status of the new payment, empty means it has been paid already.
+	Status  PaymentStatus
+	DueDate time.Time
 }
 
 type UpdatePaymentCommand struct {
@@ -85,6 +121,22 @@ type UpdatePaymentCommand struct {
 	Description string
 	PaymentDate time.Time
 	Kind        PaymentKind
+	// ExchangeRate overrides the looked up rate to the project main currency,
+	// e.g. with the rate the bank actually charged. Zero means look it up.
+	ExchangeRate float64
+	// Status moves the payment along its lifecycle, empty keeps it as it is.
+	Status  PaymentStatus
+	DueDate time.Time
+}
+
+// MarkPaymentPaidCommand records a planned or due payment as made. Zero
+// values keep the planned amount and take today as the payment date.
+type MarkPaymentPaidCommand struct {
+	ProjectID    uuid.UUID
+	ID           uuid.UUID
+	Amount       *money.Money
+	PaymentDate  time.Time
+	ExchangeRate float64
 }
 
 type RemovePaymentCommand struct {
@@ -92,7 +144,235 @@ type RemovePaymentCommand struct {
 	ID        uuid.UUID
 }
 
+type CreateBudgetCommand struct {
+	ProjectID   uuid.UUID
+	CategoryID  uuid.UUID
+	TypeID      uuid.UUID
+	Amount      int64
+	Description string
+}
+
+type UpdateBudgetCommand struct {
+	ProjectID   uuid.UUID
+	ID          uuid.UUID
+	CategoryID  uuid.UUID
+	TypeID      uuid.UUID
+	Amount      int64
+	Description string
+}
+
+type CreateRecurringPaymentCommand struct {
+	ProjectID   uuid.UUID
+	TypeID      uuid.UUID
+	Description string
+	Amount      *money.Money
+	Kind        PaymentKind
+	Recurrence  Recurrence
+	StartDate   time.Time
+	EndDate     time.Time
+}
+
+type SkipOccurrenceCommand struct {
+	ProjectID   uuid.UUID
+	RecurringID uuid.UUID
+	Date        time.Time
+}
+
+type EditOccurrenceCommand struct {
+	ProjectID   uuid.UUID
+	RecurringID uuid.UUID
+	Date        time.Time
+	Amount      *money.Money
+	Description string
+	PaymentDate time.Time
+}
+
 type RemoveProjectResourceCommand struct {
 	ProjectID  uuid.UUID
 	ResourceID uuid.UUID
 }
+
+type SetFixedRateCommand struct {
+	ProjectID uuid.UUID
+	Currency  string
+	Rate      float64
+}
+
+type RemoveFixedRateCommand struct {
+	ProjectID uuid.UUID
+	Currency  string
+}
+
+type SetSettlementWeightCommand struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+	Weight    float64
+}
+
+type RecordSettlementTransferCommand struct {
+	ProjectID   uuid.UUID
+	FromID      uuid.UUID
+	ToID        uuid.UUID
+	Amount      *money.Money
+	Date        time.Time
+	Description string
+}
+
+// ImportPaymentsCommand imports payments from the rows of a file. Types are
+// looked up by name, CreateMissingTypes creates the ones not found along with
+// their categories. A dry run only reports what the import would save.
+type ImportPaymentsCommand struct {
+	ProjectID          uuid.UUID
+	Rows               []PaymentImportRow
+	CreateMissingTypes bool
+	DryRun             bool
+}
+
+type CreateLoanCommand struct {
+	ProjectID   uuid.UUID
+	Description string
+	Principal   *money.Money
+	AnnualRate  float64
+	Term        int
+	Schedule    LoanSchedule
+	StartDate   time.Time
+}
+
+type UpdateLoanCommand struct {
+	ProjectID   uuid.UUID
+	ID          uuid.UUID
+	Description string
+	Principal   *money.Money
+	AnnualRate  float64
+	Term        int
+	Schedule    LoanSchedule
+	StartDate   time.Time
+}
+
+// LinkLoanPaymentCommand books a credit payment against an installment of a
+// loan, zero Installment books it against the first one not paid yet.
+type LinkLoanPaymentCommand struct {
+	ProjectID   uuid.UUID
+	LoanID      uuid.UUID
+	PaymentID   uuid.UUID
+	Installment int
+}
+
+type UnlinkLoanPaymentCommand struct {
+	ProjectID uuid.UUID
+	LoanID    uuid.UUID
+	PaymentID uuid.UUID
+}
+
+type CreateVendorCommand struct {
+	ProjectID uuid.UUID
+	// Shared makes the vendor available in all the projects of the person
+	// creating it instead of the project only.
+	Shared      bool
+	Name        string
+	TaxID       string
+	ContactName string
+	Email       string
+	Phone       string
+	IBAN        string
+}
+
+type UpdateVendorCommand struct {
+	ProjectID   uuid.UUID
+	ID          uuid.UUID
+	Name        string
+	TaxID       string
+	ContactName string
+	Email       string
+	Phone       string
+	IBAN        string
+}
+
+// LinkVendorResourceCommand links a payment or an asset of the project to a
+// vendor, or unlinks it.
+type LinkVendorResourceCommand struct {
+	ProjectID  uuid.UUID
+	VendorID   uuid.UUID
+	ResourceID uuid.UUID
+}
+
+type CreateContractCommand struct {
+	ProjectID uuid.UUID
+	VendorID  uuid.UUID
+	Title     string
+	Total     *money.Money
+	Stages    []*ContractStage
+	SignedAt  time.Time
+}
+
+type UpdateContractCommand struct {
+	ProjectID uuid.UUID
+	ID        uuid.UUID
+	VendorID  uuid.UUID
+	Title     string
+	Total     *money.Money
+	Stages    []*ContractStage
+	SignedAt  time.Time
+}
+
+type LinkContractPaymentCommand struct {
+	ProjectID  uuid.UUID
+	ContractID uuid.UUID
+	PaymentID  uuid.UUID
+}
+
+type CreateEstimateCommand struct {
+	ProjectID uuid.UUID
+	Title     string
+}
+
+type UpdateEstimateCommand struct {
+	ProjectID uuid.UUID
+	ID        uuid.UUID
+	Title     string
+}
+
+type CreateQuoteCommand struct {
+	ProjectID  uuid.UUID
+	EstimateID uuid.UUID
+	VendorID   uuid.UUID
+	Note       string
+	Items      []*EstimateItem
+}
+
+type UpdateQuoteCommand struct {
+	ProjectID  uuid.UUID
+	EstimateID uuid.UUID
+	ID         uuid.UUID
+	VendorID   uuid.UUID
+	Note       string
+	Items      []*EstimateItem
+}
+
+type RemoveQuoteCommand struct {
+	ProjectID  uuid.UUID
+	EstimateID uuid.UUID
+	QuoteID    uuid.UUID
+}
+
+// AcceptQuoteCommand hires the vendor of a quote and turns the quote into a
+// contract or into planned payments.
+type AcceptQuoteCommand struct {
+	ProjectID  uuid.UUID
+	EstimateID uuid.UUID
+	QuoteID    uuid.UUID
+	Target     QuoteTarget
+	// DownPayment is the part of the contract total paid upfront, nil to pay
+	// it all upon completion.
+	DownPayment *money.Money
+	SignedAt    time.Time
+	// DueDate is the day the planned payments are due by.
+	DueDate time.Time
+}
+
+// TagResourceCommand replaces the tags of a payment or an asset of the project.
+type TagResourceCommand struct {
+	ProjectID  uuid.UUID
+	ResourceID uuid.UUID
+	Tags       []string
+}
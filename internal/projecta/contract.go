@@ -0,0 +1,200 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"strings"
+	"time"
+)
+
+// ContractStage is the part of the contract total paid as payments of Kind,
+// the advance as DownPayment and the rest as UponCompletionPayment.
+type ContractStage struct {
+	Kind   PaymentKind
+	Amount *money.Money
+}
+
+// Contract is the total agreed with a vendor for a piece of work, split into
+// the stages it is paid in.
+type Contract struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+	VendorID  uuid.UUID
+	Title     string
+	Total     *money.Money
+	// Stages are ordered as they are paid, the down payment first.
+	Stages   []*ContractStage
+	SignedAt time.Time
+}
+
+// NewContract checks that the stages add up to the total. A contract with no
+// stages is paid in full upon completion.
+func NewContract(
+	id uuid.UUID,
+	projectID uuid.UUID,
+	vendorID uuid.UUID,
+	title string,
+	total *money.Money,
+	stages []*ContractStage,
+	signedAt time.Time,
+) (*Contract, error) {
+	title = strings.TrimSpace(title)
+
+	if title == "" {
+		return nil, exceptions.NewValidationException("contract title is required", nil)
+	}
+
+	if vendorID == uuid.Nil {
+		return nil, exceptions.NewValidationException("contract vendor is required", nil)
+	}
+
+	if total == nil || !total.IsPositive() {
+		return nil, exceptions.NewValidationException("contract total must be greater than 0", nil)
+	}
+
+	if money.GetCurrency(total.Currency().Code) == nil {
+		return nil, exceptions.NewValidationException("unknown contract currency", nil)
+	}
+
+	if len(stages) == 0 {
+		stages = []*ContractStage{{Kind: UponCompletionPayment, Amount: total}}
+	}
+
+	byKind := make(map[PaymentKind]*ContractStage, len(stages))
+	var sum int64
+
+	for _, stage := range stages {
+		if stage.Kind != DownPayment && stage.Kind != UponCompletionPayment {
+			return nil, exceptions.NewValidationException("contract stages are paid as down payments or upon completion", nil)
+		}
+
+		if byKind[stage.Kind] != nil {
+			return nil, exceptions.NewValidationException("contract stage is given twice", nil)
+		}
+
+		if stage.Amount == nil || !stage.Amount.IsPositive() || stage.Amount.Currency().Code != total.Currency().Code {
+			return nil, exceptions.NewValidationException("contract stage must be a positive amount in the contract currency", nil)
+		}
+
+		byKind[stage.Kind] = stage
+		sum += stage.Amount.Amount()
+	}
+
+	if sum != total.Amount() {
+		return nil, exceptions.NewValidationException("contract stages must add up to the total", nil)
+	}
+
+	ordered := make([]*ContractStage, 0, len(byKind))
+
+	for _, kind := range []PaymentKind{DownPayment, UponCompletionPayment} {
+		if stage, ok := byKind[kind]; ok {
+			ordered = append(ordered, stage)
+		}
+	}
+
+	return &Contract{
+		ID:        id,
+		ProjectID: projectID,
+		VendorID:  vendorID,
+		Title:     title,
+		Total:     total,
+		Stages:    ordered,
+		SignedAt:  signedAt,
+	}, nil
+}
+
+// Stage returns the stage paid as payments of kind, nil when there is none.
+func (c *Contract) Stage(kind PaymentKind) *ContractStage {
+	for _, stage := range c.Stages {
+		if stage.Kind == kind {
+			return stage
+		}
+	}
+
+	return nil
+}
+
+type ContractCollection = core.PaginatedCollection[*Contract]
+
+func NewContractCollection(total int) *ContractCollection {
+	return core.NewPaginatedCollection[*Contract](total)
+}
+
+// ContractPayment is a payment made or planned under a contract.
+type ContractPayment struct {
+	ContractID uuid.UUID
+	PaymentID  uuid.UUID
+	Kind       PaymentKind
+	Status     PaymentStatus
+	Amount     *money.Money
+	Date       time.Time
+}
+
+type ContractStageReport struct {
+	Stage *ContractStage
+	// Paid sums the payments made, Committed the planned and due ones.
+	Paid      *money.Money
+	Committed *money.Money
+	// Remaining is what is left to pay of the stage, zero once it is paid in
+	// full or over.
+	Remaining *money.Money
+}
+
+type ContractReport struct {
+	Contract  *Contract
+	Stages    []*ContractStageReport
+	Paid      *money.Money
+	Committed *money.Money
+	Remaining *money.Money
+}
+
+// NewContractReport sums the payments of each stage. Overpaying a stage does
+// not lower what is left of the others, and cancelled payments or payments of
+// a kind the contract has no stage for are not counted.
+func NewContractReport(contract *Contract, payments []*ContractPayment) *ContractReport {
+	code := contract.Total.Currency().Code
+	paid, committed := map[PaymentKind]int64{}, map[PaymentKind]int64{}
+
+	for _, p := range payments {
+		if p.Amount.Currency().Code != code {
+			continue
+		}
+
+		switch {
+		case p.Status == PaymentPaid:
+			paid[p.Kind] += p.Amount.Amount()
+		case p.Status.IsCommitted():
+			committed[p.Kind] += p.Amount.Amount()
+		}
+	}
+
+	report := &ContractReport{
+		Contract: contract,
+		Stages:   make([]*ContractStageReport, 0, len(contract.Stages)),
+	}
+
+	var totalPaid, totalCommitted, totalRemaining int64
+
+	for _, stage := range contract.Stages {
+		remaining := max(stage.Amount.Amount()-paid[stage.Kind], 0)
+
+		report.Stages = append(report.Stages, &ContractStageReport{
+			Stage:     stage,
+			Paid:      money.New(paid[stage.Kind], code),
+			Committed: money.New(committed[stage.Kind], code),
+			Remaining: money.New(remaining, code),
+		})
+
+		totalPaid += paid[stage.Kind]
+		totalCommitted += committed[stage.Kind]
+		totalRemaining += remaining
+	}
+
+	report.Paid = money.New(totalPaid, code)
+	report.Committed = money.New(totalCommitted, code)
+	report.Remaining = money.New(totalRemaining, code)
+
+	return report
+}
@@ -0,0 +1,266 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToCreateContract      = "failed to create contract"
+	failedToUpdateContract      = "failed to update contract"
+	failedToFindContract        = "failed to find contract"
+	failedToRemoveContract      = "failed to remove contract"
+	failedToLinkContract        = "failed to link payment to contract"
+	failedToBuildContractReport = "failed to build contract report"
+)
+
+type ContractServiceImpl struct {
+	contracts ContractRepository
+	vendors   VendorRepository
+	payments  PaymentRepository
+	projects  ProjectRepository
+}
+
+func NewContractService(
+	contracts ContractRepository,
+	vendors VendorRepository,
+	payments PaymentRepository,
+	projects ProjectRepository,
+) *ContractServiceImpl {
+	return &ContractServiceImpl{
+		contracts: contracts,
+		vendors:   vendors,
+		payments:  payments,
+		projects:  projects,
+	}
+}
+
+func (s *ContractServiceImpl) Find(ctx context.Context, filter ContractCollectionFilter) (*ContractCollection, error) {
+	collection, err := s.contracts.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindContract, err)
+	}
+
+	return collection, nil
+}
+
+func (s *ContractServiceImpl) FindOne(ctx context.Context, filter ContractFilter) (*Contract, error) {
+	c, err := s.contracts.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToFindContract, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindContract, err)
+	}
+
+	return c, nil
+}
+
+// ensureVendor makes sure the vendor is one the project may sign a contract
+// with.
+func (s *ContractServiceImpl) ensureVendor(ctx context.Context, projectID uuid.UUID, vendorID uuid.UUID) error {
+	if _, err := s.vendors.FindOne(ctx, VendorFilter{VendorID: vendorID, ProjectID: projectID}); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewValidationException("contract vendor not found", err)
+		}
+
+		return exceptions.NewInternalException(failedToFindVendor, err)
+	}
+
+	return nil
+}
+
+func (s *ContractServiceImpl) Create(ctx context.Context, command CreateContractCommand) (*Contract, error) {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewContract(
+		uuid.New(),
+		project.ProjectID,
+		command.VendorID,
+		command.Title,
+		command.Total,
+		command.Stages,
+		command.SignedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.ensureVendor(ctx, project.ProjectID, c.VendorID); err != nil {
+		return nil, err
+	}
+
+	if err = s.contracts.Save(ctx, c); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateContract, err)
+	}
+
+	return c, nil
+}
+
+// Update changes the terms of the contract. Once payments are made under it
+// the vendor and the currency are fixed, and the stages paid keep existing.
+func (s *ContractServiceImpl) Update(ctx context.Context, command UpdateContractCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	c, err := s.FindOne(ctx, ContractFilter{ContractID: command.ID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	updated, err := NewContract(
+		c.ID,
+		c.ProjectID,
+		command.VendorID,
+		command.Title,
+		command.Total,
+		command.Stages,
+		command.SignedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if updated.VendorID != c.VendorID {
+		if err = s.ensureVendor(ctx, c.ProjectID, updated.VendorID); err != nil {
+			return err
+		}
+	}
+
+	payments, err := s.contracts.FindPayments(ctx, c.ID)
+
+	if err != nil {
+		return exceptions.NewInternalException(failedToUpdateContract, err)
+	}
+
+	for _, p := range payments {
+		if updated.VendorID != c.VendorID {
+			return exceptions.NewValidationException("contract vendor cannot change once payments are made under it", nil)
+		}
+
+		if p.Amount.Currency().Code != updated.Total.Currency().Code {
+			return exceptions.NewValidationException("contract currency cannot change once payments are made under it", nil)
+		}
+
+		if updated.Stage(p.Kind) == nil {
+			return exceptions.NewValidationException("contract stage cannot be dropped once payments are made under it", nil)
+		}
+	}
+
+	if err = s.contracts.Save(ctx, updated); err != nil {
+		return exceptions.NewInternalException(failedToUpdateContract, err)
+	}
+
+	return nil
+}
+
+func (s *ContractServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	c, err := s.FindOne(ctx, ContractFilter{ContractID: command.ResourceID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.contracts.Remove(ctx, c); err != nil {
+		return exceptions.NewInternalException(failedToRemoveContract, err)
+	}
+
+	return nil
+}
+
+// LinkPayment makes the payment one of the contract, counted against the stage
+// of its kind. The payment gets linked to the contract vendor too.
+func (s *ContractServiceImpl) LinkPayment(ctx context.Context, command LinkContractPaymentCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	c, err := s.FindOne(ctx, ContractFilter{ContractID: command.ContractID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	p, err := s.payments.FindOne(ctx, PaymentFilter{PaymentID: command.PaymentID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException(FailedToFindPayment, err)
+		}
+
+		return exceptions.NewInternalException(FailedToFindPayment, err)
+	}
+
+	if p.Amount.Currency().Code != c.Total.Currency().Code {
+		return exceptions.NewValidationException("payment currency does not match the contract currency", nil)
+	}
+
+	if c.Stage(p.Kind) == nil {
+		return exceptions.NewValidationException("contract has no stage paid as "+p.Kind.String(), nil)
+	}
+
+	if p.VendorID != uuid.Nil && p.VendorID != c.VendorID {
+		return exceptions.NewValidationException("payment is linked to another vendor", nil)
+	}
+
+	if err = s.contracts.LinkPayment(ctx, c, p.ID); err != nil {
+		return exceptions.NewInternalException(failedToLinkContract, err)
+	}
+
+	return nil
+}
+
+func (s *ContractServiceImpl) UnlinkPayment(ctx context.Context, command LinkContractPaymentCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	c, err := s.FindOne(ctx, ContractFilter{ContractID: command.ContractID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.contracts.UnlinkPayment(ctx, c.ID, command.PaymentID); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException("payment is not made under the contract", err)
+		}
+
+		return exceptions.NewInternalException(failedToLinkContract, err)
+	}
+
+	return nil
+}
+
+func (s *ContractServiceImpl) Report(ctx context.Context, filter ContractFilter) (*ContractReport, error) {
+	c, err := s.FindOne(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.contracts.FindPayments(ctx, c.ID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToBuildContractReport, err)
+	}
+
+	return NewContractReport(c, payments), nil
+}
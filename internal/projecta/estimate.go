@@ -0,0 +1,246 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// QuoteTarget is what an accepted quote is turned into.
+type QuoteTarget string
+
+const (
+	// QuoteToContract signs a contract with the vendor for the quote total.
+	QuoteToContract QuoteTarget = "CONTRACT"
+	// QuoteToPayments plans a payment for each item of the quote.
+	QuoteToPayments QuoteTarget = "PAYMENTS"
+)
+
+func ToQuoteTarget(target string) (QuoteTarget, error) {
+	switch t := QuoteTarget(strings.ToUpper(target)); t {
+	case QuoteToContract, QuoteToPayments:
+		return t, nil
+	default:
+		return "", exceptions.NewValidationException("invalid quote target", nil)
+	}
+}
+
+func (t QuoteTarget) String() string {
+	return string(t)
+}
+
+// EstimateItem is a line of a quote: Quantity units of work of a cost type at
+// UnitPrice each.
+type EstimateItem struct {
+	TypeID      uuid.UUID
+	Description string
+	// Quantity may be fractional, e.g. 12.5 square metres.
+	Quantity  float64
+	UnitPrice *money.Money
+}
+
+// Amount is the price of the item rounded half up to the minor unit, the
+// quantity read as the decimal it was entered as.
+func (i *EstimateItem) Amount() *money.Money {
+	price := new(big.Rat).Mul(core.ExactDecimal(i.Quantity), big.NewRat(i.UnitPrice.Amount(), 1))
+
+	return money.New(core.RoundRat(price, false), i.UnitPrice.Currency().Code)
+}
+
+// Quote is what a vendor asks for the work of an estimate. Its items may be
+// priced in different currencies.
+type Quote struct {
+	ID         uuid.UUID
+	EstimateID uuid.UUID
+	VendorID   uuid.UUID
+	Note       string
+	Items      []*EstimateItem
+}
+
+func NewQuote(
+	id uuid.UUID,
+	estimateID uuid.UUID,
+	vendorID uuid.UUID,
+	note string,
+	items []*EstimateItem,
+) (*Quote, error) {
+	if vendorID == uuid.Nil {
+		return nil, exceptions.NewValidationException("quote vendor is required", nil)
+	}
+
+	if len(items) == 0 {
+		return nil, exceptions.NewValidationException("quote must have at least one item", nil)
+	}
+
+	for _, item := range items {
+		if item.TypeID == uuid.Nil {
+			return nil, exceptions.NewValidationException("quote item cost type is required", nil)
+		}
+
+		if !(item.Quantity > 0) || math.IsInf(item.Quantity, 0) {
+			return nil, exceptions.NewValidationException("quote item quantity must be greater than 0", nil)
+		}
+
+		if item.UnitPrice == nil || !item.UnitPrice.IsPositive() {
+			return nil, exceptions.NewValidationException("quote item unit price must be greater than 0", nil)
+		}
+
+		if money.GetCurrency(item.UnitPrice.Currency().Code) == nil {
+			return nil, exceptions.NewValidationException("unknown quote item currency", nil)
+		}
+
+		item.Description = strings.TrimSpace(item.Description)
+	}
+
+	return &Quote{
+		ID:         id,
+		EstimateID: estimateID,
+		VendorID:   vendorID,
+		Note:       strings.TrimSpace(note),
+		Items:      items,
+	}, nil
+}
+
+// Totals sums the items of the quote per currency, ordered by the currency
+// code.
+func (q *Quote) Totals() []*money.Money {
+	sums := make(map[string]int64)
+
+	for _, item := range q.Items {
+		amount := item.Amount()
+		sums[amount.Currency().Code] += amount.Amount()
+	}
+
+	return toSortedAmounts(sums)
+}
+
+// Estimate gathers the quotes of several vendors for the same work, to be
+// compared before one of them is hired.
+type Estimate struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+	Title     string
+	Quotes    []*Quote
+	// AcceptedQuoteID is the quote hired, uuid.Nil while the quotes are
+	// still compared.
+	AcceptedQuoteID uuid.UUID
+}
+
+func NewEstimate(id uuid.UUID, projectID uuid.UUID, title string) (*Estimate, error) {
+	title = strings.TrimSpace(title)
+
+	if title == "" {
+		return nil, exceptions.NewValidationException("estimate title is required", nil)
+	}
+
+	return &Estimate{
+		ID:        id,
+		ProjectID: projectID,
+		Title:     title,
+		Quotes:    make([]*Quote, 0),
+	}, nil
+}
+
+// Quote returns the quote of the estimate with id, nil when there is none.
+func (e *Estimate) Quote(id uuid.UUID) *Quote {
+	for _, q := range e.Quotes {
+		if q.ID == id {
+			return q
+		}
+	}
+
+	return nil
+}
+
+func (e *Estimate) IsAccepted() bool {
+	return e.AcceptedQuoteID != uuid.Nil
+}
+
+// EnsureOpen fails once a quote has been accepted, as the quotes are no longer
+// compared then.
+func (e *Estimate) EnsureOpen() error {
+	if e.IsAccepted() {
+		return exceptions.NewValidationException("estimate has been accepted already", nil)
+	}
+
+	return nil
+}
+
+// Accept hires the vendor of the quote with id.
+func (e *Estimate) Accept(id uuid.UUID) error {
+	if err := e.EnsureOpen(); err != nil {
+		return err
+	}
+
+	if e.Quote(id) == nil {
+		return exceptions.NewNotFoundException("quote not found", nil)
+	}
+
+	e.AcceptedQuoteID = id
+
+	return nil
+}
+
+type EstimateCollection = core.PaginatedCollection[*Estimate]
+
+func NewEstimateCollection(total int) *EstimateCollection {
+	return core.NewPaginatedCollection[*Estimate](total)
+}
+
+type RankedQuote struct {
+	Quote *Quote
+	// Rank is 1 for the cheapest quote. Quotes asking the same share a rank.
+	Rank int
+	// Total is the quote total in the currency of the comparison, Difference
+	// how much more it asks than the cheapest quote.
+	Total      int64
+	Difference int64
+}
+
+type EstimateComparison struct {
+	Estimate *Estimate
+	Currency string
+	Quotes   []*RankedQuote
+}
+
+// NewEstimateComparison ranks the quotes of the estimate from the cheapest one,
+// given their totals already converted into currency.
+func NewEstimateComparison(estimate *Estimate, currency string, totals map[uuid.UUID]int64) *EstimateComparison {
+	comparison := &EstimateComparison{
+		Estimate: estimate,
+		Currency: currency,
+		Quotes:   make([]*RankedQuote, 0, len(estimate.Quotes)),
+	}
+
+	for _, q := range estimate.Quotes {
+		comparison.Quotes = append(comparison.Quotes, &RankedQuote{Quote: q, Total: totals[q.ID]})
+	}
+
+	sort.SliceStable(comparison.Quotes, func(i, j int) bool {
+		return comparison.Quotes[i].Total < comparison.Quotes[j].Total
+	})
+
+	for i, ranked := range comparison.Quotes {
+		ranked.Rank = i + 1
+		ranked.Difference = ranked.Total - comparison.Quotes[0].Total
+
+		if i > 0 && ranked.Total == comparison.Quotes[i-1].Total {
+			ranked.Rank = comparison.Quotes[i-1].Rank
+		}
+	}
+
+	return comparison
+}
+
+// QuoteAcceptance is what an accepted quote was turned into: either the
+// contract or the planned payments.
+type QuoteAcceptance struct {
+	Estimate *Estimate
+	Contract *Contract
+	Payments []*Payment
+}
@@ -0,0 +1,410 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToCreateEstimate = "failed to create estimate"
+	failedToUpdateEstimate = "failed to update estimate"
+	failedToFindEstimate   = "failed to find estimate"
+	failedToRemoveEstimate = "failed to remove estimate"
+	failedToSaveQuote      = "failed to save quote"
+	failedToAcceptQuote    = "failed to accept quote"
+)
+
+type EstimateServiceImpl struct {
+	db         core.DbConnection
+	estimates  EstimateRepository
+	vendors    VendorRepository
+	types      TypeRepository
+	contracts  ContractRepository
+	payments   PaymentRepository
+	projects   ProjectRepository
+	people     PeopleService
+	rates      ExchangeRates
+	fixedRates FixedRateRepository
+}
+
+func NewEstimateService(
+	db core.DbConnection,
+	estimates EstimateRepository,
+	vendors VendorRepository,
+	types TypeRepository,
+	contracts ContractRepository,
+	payments PaymentRepository,
+	projects ProjectRepository,
+	people PeopleService,
+	rates ExchangeRates,
+	fixedRates FixedRateRepository,
+) *EstimateServiceImpl {
+	return &EstimateServiceImpl{
+		db:         db,
+		estimates:  estimates,
+		vendors:    vendors,
+		types:      types,
+		contracts:  contracts,
+		payments:   payments,
+		projects:   projects,
+		people:     people,
+		rates:      rates,
+		fixedRates: fixedRates,
+	}
+}
+
+func (s *EstimateServiceImpl) Find(ctx context.Context, filter EstimateCollectionFilter) (*EstimateCollection, error) {
+	collection, err := s.estimates.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindEstimate, err)
+	}
+
+	return collection, nil
+}
+
+func (s *EstimateServiceImpl) FindOne(ctx context.Context, filter EstimateFilter) (*Estimate, error) {
+	e, err := s.estimates.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToFindEstimate, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindEstimate, err)
+	}
+
+	return e, nil
+}
+
+func (s *EstimateServiceImpl) Create(ctx context.Context, command CreateEstimateCommand) (*Estimate, error) {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := NewEstimate(uuid.New(), project.ProjectID, command.Title)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.estimates.Save(ctx, e); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateEstimate, err)
+	}
+
+	return e, nil
+}
+
+// findWritableEstimate loads an estimate of the project about to be changed.
+func (s *EstimateServiceImpl) findWritableEstimate(ctx context.Context, projectID uuid.UUID, estimateID uuid.UUID) (*Project, *Estimate, error) {
+	project, err := FindWritableProject(ctx, s.projects, projectID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e, err := s.FindOne(ctx, EstimateFilter{EstimateID: estimateID, ProjectID: projectID})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return project, e, nil
+}
+
+func (s *EstimateServiceImpl) Update(ctx context.Context, command UpdateEstimateCommand) error {
+	_, e, err := s.findWritableEstimate(ctx, command.ProjectID, command.ID)
+
+	if err != nil {
+		return err
+	}
+
+	updated, err := NewEstimate(e.ID, e.ProjectID, command.Title)
+
+	if err != nil {
+		return err
+	}
+
+	updated.Quotes, updated.AcceptedQuoteID = e.Quotes, e.AcceptedQuoteID
+
+	if err = s.estimates.Save(ctx, updated); err != nil {
+		return exceptions.NewInternalException(failedToUpdateEstimate, err)
+	}
+
+	return nil
+}
+
+// Remove removes the estimate with its quotes. Whatever an accepted quote was
+// turned into is kept.
+func (s *EstimateServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	_, e, err := s.findWritableEstimate(ctx, command.ProjectID, command.ResourceID)
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.estimates.Remove(ctx, e); err != nil {
+		return exceptions.NewInternalException(failedToRemoveEstimate, err)
+	}
+
+	return nil
+}
+
+// findCostType loads a cost type the items of the project may be priced as.
+func (s *EstimateServiceImpl) findCostType(ctx context.Context, projectID uuid.UUID, typeID uuid.UUID) (*CostType, error) {
+	costType, err := s.types.FindOne(ctx, TypeFilter{TypeID: typeID, ProjectID: projectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewValidationException("quote item cost type not found", err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToSaveQuote, err)
+	}
+
+	return costType, nil
+}
+
+// saveQuote checks the vendor and the cost types of the quote belong to the
+// project before saving it.
+func (s *EstimateServiceImpl) saveQuote(ctx context.Context, projectID uuid.UUID, quote *Quote) error {
+	if _, err := s.vendors.FindOne(ctx, VendorFilter{VendorID: quote.VendorID, ProjectID: projectID}); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewValidationException("quote vendor not found", err)
+		}
+
+		return exceptions.NewInternalException(failedToSaveQuote, err)
+	}
+
+	checked := make(map[uuid.UUID]bool, len(quote.Items))
+
+	for _, item := range quote.Items {
+		if checked[item.TypeID] {
+			continue
+		}
+
+		if _, err := s.findCostType(ctx, projectID, item.TypeID); err != nil {
+			return err
+		}
+
+		checked[item.TypeID] = true
+	}
+
+	// the items are replaced as a whole
+	_, err := s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		return nil, s.estimates.SaveQuote(ctx, quote)
+	})
+
+	if err != nil {
+		return exceptions.NewInternalException(failedToSaveQuote, err)
+	}
+
+	return nil
+}
+
+func (s *EstimateServiceImpl) AddQuote(ctx context.Context, command CreateQuoteCommand) (*Quote, error) {
+	_, e, err := s.findWritableEstimate(ctx, command.ProjectID, command.EstimateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = e.EnsureOpen(); err != nil {
+		return nil, err
+	}
+
+	q, err := NewQuote(uuid.New(), e.ID, command.VendorID, command.Note, command.Items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.saveQuote(ctx, e.ProjectID, q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// findOpenQuote loads a quote of an estimate still compared.
+func (s *EstimateServiceImpl) findOpenQuote(ctx context.Context, projectID uuid.UUID, estimateID uuid.UUID, quoteID uuid.UUID) (*Estimate, *Quote, error) {
+	_, e, err := s.findWritableEstimate(ctx, projectID, estimateID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = e.EnsureOpen(); err != nil {
+		return nil, nil, err
+	}
+
+	q := e.Quote(quoteID)
+
+	if q == nil {
+		return nil, nil, exceptions.NewNotFoundException("quote not found", nil)
+	}
+
+	return e, q, nil
+}
+
+func (s *EstimateServiceImpl) UpdateQuote(ctx context.Context, command UpdateQuoteCommand) error {
+	e, q, err := s.findOpenQuote(ctx, command.ProjectID, command.EstimateID, command.ID)
+
+	if err != nil {
+		return err
+	}
+
+	updated, err := NewQuote(q.ID, e.ID, command.VendorID, command.Note, command.Items)
+
+	if err != nil {
+		return err
+	}
+
+	return s.saveQuote(ctx, e.ProjectID, updated)
+}
+
+func (s *EstimateServiceImpl) RemoveQuote(ctx context.Context, command RemoveQuoteCommand) error {
+	_, q, err := s.findOpenQuote(ctx, command.ProjectID, command.EstimateID, command.QuoteID)
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.estimates.RemoveQuote(ctx, q); err != nil {
+		return exceptions.NewInternalException(failedToSaveQuote, err)
+	}
+
+	return nil
+}
+
+// Accept hires the vendor of the quote and, in the same transaction, signs a
+// contract with it or plans the payments of the quote items.
+func (s *EstimateServiceImpl) Accept(ctx context.Context, command AcceptQuoteCommand) (*QuoteAcceptance, error) {
+	project, e, err := s.findWritableEstimate(ctx, command.ProjectID, command.EstimateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = e.Accept(command.QuoteID); err != nil {
+		return nil, err
+	}
+
+	q := e.Quote(command.QuoteID)
+	acceptance := &QuoteAcceptance{Estimate: e}
+
+	switch command.Target {
+	case QuoteToContract:
+		acceptance.Contract, err = s.toContract(e, q, command)
+	case QuoteToPayments:
+		acceptance.Payments, err = s.toPayments(ctx, project, e, q, command)
+	default:
+		err = exceptions.NewValidationException("invalid quote target", nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		if acceptance.Contract != nil {
+			if err := s.contracts.Save(ctx, acceptance.Contract); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, p := range acceptance.Payments {
+			if err := s.payments.Save(ctx, p); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, s.estimates.Save(ctx, e)
+	})
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToAcceptQuote, err)
+	}
+
+	return acceptance, nil
+}
+
+// toContract signs a contract for the quote total, which has to be in a single
+// currency. The down payment is taken from the total and the rest is paid upon
+// completion.
+func (s *EstimateServiceImpl) toContract(e *Estimate, q *Quote, command AcceptQuoteCommand) (*Contract, error) {
+	totals := q.Totals()
+
+	if len(totals) != 1 {
+		return nil, exceptions.NewValidationException("quote priced in several currencies cannot be turned into a contract", nil)
+	}
+
+	total := totals[0]
+	var stages []*ContractStage
+
+	if command.DownPayment != nil {
+		stages = append(stages, &ContractStage{Kind: DownPayment, Amount: command.DownPayment})
+
+		if rest := total.Amount() - command.DownPayment.Amount(); rest != 0 {
+			stages = append(stages, &ContractStage{Kind: UponCompletionPayment, Amount: money.New(rest, total.Currency().Code)})
+		}
+	}
+
+	return NewContract(uuid.New(), e.ProjectID, q.VendorID, e.Title, total, stages, command.SignedAt)
+}
+
+// toPayments plans a payment to the vendor for each item of the quote, due by
+// the same day.
+func (s *EstimateServiceImpl) toPayments(ctx context.Context, project *Project, e *Estimate, q *Quote, command AcceptQuoteCommand) ([]*Payment, error) {
+	if command.DueDate.IsZero() {
+		return nil, exceptions.NewValidationException("due date is required to plan the payments", nil)
+	}
+
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToAcceptQuote, err)
+	}
+
+	owner, err := s.people.FindOwner(ctx, personID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToAcceptQuote, err)
+	}
+
+	payments := make([]*Payment, 0, len(q.Items))
+
+	for _, item := range q.Items {
+		costType, err := s.findCostType(ctx, project.ProjectID, item.TypeID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		description := item.Description
+		if description == "" {
+			description = e.Title
+		}
+
+		p := NewPayment(uuid.New(), project, owner, costType, description, item.Amount(), command.DueDate, UponCompletionPayment)
+		p.VendorID = q.VendorID
+
+		if err = p.startAs(PaymentPlanned, command.DueDate); err != nil {
+			return nil, err
+		}
+
+		if err = convertPayment(ctx, p, 0, false, s.fixedRates, s.rates); err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, p)
+	}
+
+	return payments, nil
+}
@@ -3,6 +3,8 @@ package projecta
 import (
 	"github.com/google/uuid"
 	"gitlab.com/massimo-ua/projecta/internal/core"
+	"slices"
+	"time"
 )
 
 type CategoryFilter struct {
@@ -18,7 +20,8 @@ type ProjectFilter struct {
 
 type ProjectCollectionFilter struct {
 	core.Pagination
-	Name string
+	Name     string
+	Archived bool
 }
 
 type TypeFilter struct {
@@ -54,4 +57,133 @@ type PaymentCollectionFilter struct {
 	CategoryID uuid.UUID
 	TypeID     uuid.UUID
 	Kind       PaymentKind
+	OwnerID    uuid.UUID
+	// From and To limit the payments to the days in between, both included.
+	// The zero time leaves the range open.
+	From time.Time
+	To   time.Time
+	// AmountMin and AmountMax bound the amount in minor units of the payment
+	// currency, both included. Zero leaves the bound open.
+	AmountMin int64
+	AmountMax int64
+	Currency  string
+	Status    PaymentStatus
+	// Query is a full-text search over the payment descriptions.
+	Query      string
+	VendorID   uuid.UUID
+	ContractID uuid.UUID
+	// Tags matches the payments carrying all of them.
+	Tags []string
+}
+
+type PaymentTotalsFilter struct {
+	ProjectID uuid.UUID
+	GroupBy   []PaymentGrouping
+	// From and To limit the payments to the days in between, both included.
+	// The zero time leaves the range open.
+	From time.Time
+	To   time.Time
+	// Committed sums the planned and due payments instead of the paid ones.
+	Committed bool
+}
+
+// Groups reports whether the subtotals are broken down by grouping.
+func (f PaymentTotalsFilter) Groups(grouping PaymentGrouping) bool {
+	return slices.Contains(f.GroupBy, grouping)
+}
+
+// PaymentReportFilter asks for the payment subtotals broken down by calendar
+// period on top of the groupings of the totals filter.
+type PaymentReportFilter struct {
+	PaymentTotalsFilter
+	Period ReportPeriod
+}
+
+// CashFlowFilter asks for the spending of a project per period, with the burn
+// rate averaged over the last Window periods.
+type CashFlowFilter struct {
+	ProjectID uuid.UUID
+	Period    ReportPeriod
+	Window    int
+}
+
+type BudgetFilter struct {
+	BudgetID  uuid.UUID
+	ProjectID uuid.UUID
+}
+
+type BudgetCollectionFilter struct {
+	core.Pagination
+	ProjectID  uuid.UUID
+	CategoryID uuid.UUID
+}
+
+type RecurringPaymentFilter struct {
+	RecurringID uuid.UUID
+	ProjectID   uuid.UUID
+}
+
+type RecurringPaymentCollectionFilter struct {
+	core.Pagination
+	ProjectID uuid.UUID
+}
+
+type LoanFilter struct {
+	LoanID    uuid.UUID
+	ProjectID uuid.UUID
+}
+
+type LoanCollectionFilter struct {
+	core.Pagination
+	ProjectID uuid.UUID
+}
+
+type VendorFilter struct {
+	VendorID  uuid.UUID
+	ProjectID uuid.UUID
+}
+
+type VendorCollectionFilter struct {
+	core.Pagination
+	ProjectID uuid.UUID
+	// Name matches the vendors whose name starts with it, in any case.
+	Name string
+}
+
+type ContractFilter struct {
+	ContractID uuid.UUID
+	ProjectID  uuid.UUID
+}
+
+type ContractCollectionFilter struct {
+	core.Pagination
+	ProjectID uuid.UUID
+	VendorID  uuid.UUID
+}
+
+type EstimateFilter struct {
+	EstimateID uuid.UUID
+	ProjectID  uuid.UUID
+}
+
+type EstimateCollectionFilter struct {
+	core.Pagination
+	ProjectID uuid.UUID
+}
+
+type TagCollectionFilter struct {
+	ProjectID uuid.UUID
+	// Name matches the tags starting with it.
+	Name  string
+	Limit int
+}
+
+// TagTotalsFilter asks for the paid payments and the assets of a project summed
+// by tag.
+type TagTotalsFilter struct {
+	ProjectID uuid.UUID
+	// From and To limit the payments and assets to the days in between, both
+	// included. The zero time leaves the range open.
+	From time.Time
+	To   time.Time
 }
@@ -0,0 +1,60 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math"
+	"strings"
+)
+
+// FixedRate is an exchange rate agreed for a project, e.g. in a contract with a
+// contractor, that wins over the market rate. Rate is the amount of
+// BaseCurrency paid for one unit of Currency.
+type FixedRate struct {
+	ProjectID    uuid.UUID
+	Currency     string
+	BaseCurrency string
+	Rate         float64
+}
+
+func NewFixedRate(projectID uuid.UUID, currency string, baseCurrency string, rate float64) (*FixedRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	baseCurrency = strings.ToUpper(strings.TrimSpace(baseCurrency))
+
+	if money.GetCurrency(currency) == nil {
+		return nil, exceptions.NewValidationException("unknown fixed rate currency", nil)
+	}
+
+	if money.GetCurrency(baseCurrency) == nil {
+		return nil, exceptions.NewValidationException("unknown fixed rate base currency", nil)
+	}
+
+	if currency == baseCurrency {
+		return nil, exceptions.NewValidationException("fixed rate currency must differ from the project main currency", nil)
+	}
+
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return nil, exceptions.NewValidationException("exchange rate must be a positive number", nil)
+	}
+
+	return &FixedRate{
+		ProjectID:    projectID,
+		Currency:     currency,
+		BaseCurrency: baseCurrency,
+		Rate:         rate,
+	}, nil
+}
+
+// RateFor returns the rate to convert one unit of from into to, as long as the
+// fixed rate covers that pair in either direction.
+func (r *FixedRate) RateFor(from string, to string) (float64, bool) {
+	switch {
+	case r.Currency == from && r.BaseCurrency == to:
+		return r.Rate, true
+	case r.BaseCurrency == from && r.Currency == to:
+		return 1 / r.Rate, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,109 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"strings"
+)
+
+const (
+	failedToFindFixedRates  = "failed to find fixed rates"
+	failedToSetFixedRate    = "failed to set fixed rate"
+	failedToRemoveFixedRate = "failed to remove fixed rate"
+)
+
+type FixedRateServiceImpl struct {
+	rates    FixedRateRepository
+	projects ProjectRepository
+}
+
+func NewFixedRateService(rates FixedRateRepository, projects ProjectRepository) *FixedRateServiceImpl {
+	return &FixedRateServiceImpl{
+		rates:    rates,
+		projects: projects,
+	}
+}
+
+func (s *FixedRateServiceImpl) Find(ctx context.Context, projectID uuid.UUID) ([]*FixedRate, error) {
+	if _, err := s.projects.FindOne(ctx, ProjectFilter{ProjectID: projectID}); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException("project not found", err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindFixedRates, err)
+	}
+
+	rates, err := s.rates.Find(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindFixedRates, err)
+	}
+
+	return rates, nil
+}
+
+// Set fixes the rate of a currency against the project main currency, replacing
+// the one fixed before. Fixed rates change the project balances, so only the
+// people managing the project may set them.
+func (s *FixedRateServiceImpl) Set(ctx context.Context, command SetFixedRateCommand) (*FixedRate, error) {
+	project, err := s.findManagedProject(ctx, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := NewFixedRate(project.ProjectID, command.Currency, project.MainCurrency, command.Rate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.rates.Save(ctx, rate); err != nil {
+		return nil, exceptions.NewInternalException(failedToSetFixedRate, err)
+	}
+
+	return rate, nil
+}
+
+func (s *FixedRateServiceImpl) Remove(ctx context.Context, command RemoveFixedRateCommand) error {
+	if _, err := s.findManagedProject(ctx, command.ProjectID); err != nil {
+		return err
+	}
+
+	if err := s.rates.Remove(ctx, command.ProjectID, strings.ToUpper(strings.TrimSpace(command.Currency))); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException("fixed rate not found", err)
+		}
+
+		return exceptions.NewInternalException(failedToRemoveFixedRate, err)
+	}
+
+	return nil
+}
+
+func (s *FixedRateServiceImpl) findManagedProject(ctx context.Context, projectID uuid.UUID) (*Project, error) {
+	project, err := FindWritableProject(ctx, s.projects, projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = project.EnsureManageable(); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// findFixedRate returns the rate fixed on the project for the pair, if any.
+func findFixedRate(rates []*FixedRate, from string, to string) (float64, bool) {
+	for _, r := range rates {
+		if rate, ok := r.RateFor(from, to); ok {
+			return rate, true
+		}
+	}
+
+	return 0, false
+}
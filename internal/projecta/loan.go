@@ -0,0 +1,278 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type LoanSchedule string
+
+const (
+	// AnnuitySchedule repays the loan with equal monthly installments, the
+	// principal share of which grows as the interest falls.
+	AnnuitySchedule LoanSchedule = "ANNUITY"
+	// DifferentiatedSchedule repays an equal share of the principal every
+	// month with the interest on the balance left on top.
+	DifferentiatedSchedule LoanSchedule = "DIFFERENTIATED"
+	// maxLoanTerm is the longest term accepted, in months.
+	maxLoanTerm = 600
+)
+
+func ToLoanSchedule(schedule string) (LoanSchedule, error) {
+	switch s := LoanSchedule(strings.ToUpper(schedule)); s {
+	case AnnuitySchedule, DifferentiatedSchedule:
+		return s, nil
+	default:
+		return "", exceptions.NewValidationException("invalid loan schedule", nil)
+	}
+}
+
+func (s LoanSchedule) String() string {
+	return string(s)
+}
+
+// Loan is money borrowed to finance the project and repaid in monthly
+// installments, the first one due a month after StartDate.
+type Loan struct {
+	ID          uuid.UUID
+	ProjectID   uuid.UUID
+	Description string
+	Principal   *money.Money
+	// AnnualRate is the nominal yearly interest rate in percent, e.g. 17.5.
+	AnnualRate float64
+	// Term is the number of monthly installments.
+	Term      int
+	Schedule  LoanSchedule
+	StartDate time.Time
+}
+
+func NewLoan(
+	id uuid.UUID,
+	projectID uuid.UUID,
+	description string,
+	principal *money.Money,
+	annualRate float64,
+	term int,
+	schedule LoanSchedule,
+	startDate time.Time,
+) (*Loan, error) {
+	if principal == nil || !principal.IsPositive() {
+		return nil, exceptions.NewValidationException("loan principal must be greater than 0", nil)
+	}
+
+	if money.GetCurrency(principal.Currency().Code) == nil {
+		return nil, exceptions.NewValidationException("unknown loan currency", nil)
+	}
+
+	if annualRate < 0 || math.IsNaN(annualRate) || math.IsInf(annualRate, 0) {
+		return nil, exceptions.NewValidationException("loan rate must not be negative", nil)
+	}
+
+	if term < 1 || term > maxLoanTerm {
+		return nil, exceptions.NewValidationException("loan term must be between 1 and 600 months", nil)
+	}
+
+	if _, err := ToLoanSchedule(schedule.String()); err != nil {
+		return nil, err
+	}
+
+	if startDate.IsZero() {
+		return nil, exceptions.NewValidationException("loan start date is required", nil)
+	}
+
+	return &Loan{
+		ID:          id,
+		ProjectID:   projectID,
+		Description: description,
+		Principal:   principal,
+		AnnualRate:  annualRate,
+		Term:        term,
+		Schedule:    schedule,
+		StartDate:   startDate,
+	}, nil
+}
+
+type LoanCollection = core.PaginatedCollection[*Loan]
+
+func NewLoanCollection(total int) *LoanCollection {
+	return core.NewPaginatedCollection[*Loan](total)
+}
+
+// Installment is a line of the amortization table.
+type Installment struct {
+	Number    int
+	DueDate   time.Time
+	Amount    *money.Money
+	Principal *money.Money
+	Interest  *money.Money
+	// Balance is the principal left to repay once the installment is paid.
+	Balance *money.Money
+}
+
+// Amortization returns the repayment schedule of the loan. The interest of a
+// month is charged on the balance left, rounded half up to the minor unit, and
+// the last installment repays whatever the rounding has left of the principal.
+func (l *Loan) Amortization() []*Installment {
+	code := l.Principal.Currency().Code
+	rate := new(big.Rat).Quo(core.ExactDecimal(l.AnnualRate), big.NewRat(1200, 1))
+	balance := l.Principal.Amount()
+
+	var annuity, share int64
+
+	if l.Schedule == AnnuitySchedule && rate.Sign() > 0 {
+		// principal * rate * (1 + rate)^term / ((1 + rate)^term - 1)
+		growth := new(big.Rat).Add(big.NewRat(1, 1), rate)
+		compound := big.NewRat(1, 1)
+
+		for n := 0; n < l.Term; n++ {
+			compound.Mul(compound, growth)
+		}
+
+		exact := new(big.Rat).Mul(big.NewRat(balance, 1), rate)
+		exact.Mul(exact, compound)
+		exact.Quo(exact, compound.Sub(compound, big.NewRat(1, 1)))
+
+		annuity = core.RoundRat(exact, false)
+	} else {
+		// with no interest an annuity is an equal share of the principal too
+		share = core.RoundRat(big.NewRat(balance, int64(l.Term)), false)
+		annuity = share
+	}
+
+	installments := make([]*Installment, 0, l.Term)
+
+	for n := 1; n <= l.Term; n++ {
+		interest := core.RoundRat(new(big.Rat).Mul(big.NewRat(balance, 1), rate), false)
+		principal := share
+
+		if l.Schedule == AnnuitySchedule {
+			principal = annuity - interest
+		}
+
+		if n == l.Term || principal > balance {
+			principal = balance
+		}
+
+		balance -= principal
+
+		installments = append(installments, &Installment{
+			Number:    n,
+			DueDate:   addMonths(l.StartDate, n),
+			Amount:    money.New(principal+interest, code),
+			Principal: money.New(principal, code),
+			Interest:  money.New(interest, code),
+			Balance:   money.New(balance, code),
+		})
+	}
+
+	return installments
+}
+
+// LoanPayment is a credit payment booked against an installment of a loan.
+// Principal and Interest tell how the payment splits, see NewLoanReport.
+type LoanPayment struct {
+	LoanID      uuid.UUID
+	PaymentID   uuid.UUID
+	Installment int
+	Amount      *money.Money
+	Date        time.Time
+	Principal   *money.Money
+	Interest    *money.Money
+}
+
+// LoanReportLine is an installment together with the payments booked against
+// it.
+type LoanReportLine struct {
+	Installment   *Installment
+	Payments      []*LoanPayment
+	Paid          *money.Money
+	PrincipalPaid *money.Money
+	InterestPaid  *money.Money
+}
+
+// IsPaid reports whether the payments cover the installment.
+func (l *LoanReportLine) IsPaid() bool {
+	return l.Paid.Amount() >= l.Installment.Amount.Amount()
+}
+
+type LoanReport struct {
+	Loan          *Loan
+	Lines         []*LoanReportLine
+	PrincipalPaid *money.Money
+	InterestPaid  *money.Money
+	// Outstanding is the principal left to repay.
+	Outstanding *money.Money
+}
+
+// NewLoanReport lays the payments out over the amortization table. The
+// payments of an installment go to its interest first, in the order they were
+// made, and the rest repays the principal; paying more than an installment
+// asks for repays the principal ahead of the schedule. Payments booked against
+// an installment past the term count against the last one.
+func NewLoanReport(loan *Loan, payments []*LoanPayment) *LoanReport {
+	code := loan.Principal.Currency().Code
+	installments := loan.Amortization()
+	lines := make([]*LoanReportLine, len(installments))
+	interestDue := make([]int64, len(installments))
+
+	for i, installment := range installments {
+		lines[i] = &LoanReportLine{
+			Installment:   installment,
+			Payments:      make([]*LoanPayment, 0),
+			Paid:          money.New(0, code),
+			PrincipalPaid: money.New(0, code),
+			InterestPaid:  money.New(0, code),
+		}
+		interestDue[i] = installment.Interest.Amount()
+	}
+
+	var principalPaid, interestPaid int64
+
+	for _, p := range payments {
+		i := min(max(p.Installment, 1), len(lines)) - 1
+		line := lines[i]
+
+		interest := min(p.Amount.Amount(), interestDue[i])
+		interestDue[i] -= interest
+		p.Interest = money.New(interest, code)
+		p.Principal = money.New(p.Amount.Amount()-interest, code)
+
+		line.Payments = append(line.Payments, p)
+		line.Paid = money.New(line.Paid.Amount()+p.Amount.Amount(), code)
+		line.InterestPaid = money.New(line.InterestPaid.Amount()+interest, code)
+		line.PrincipalPaid = money.New(line.PrincipalPaid.Amount()+p.Principal.Amount(), code)
+
+		principalPaid += p.Principal.Amount()
+		interestPaid += interest
+	}
+
+	return &LoanReport{
+		Loan:          loan,
+		Lines:         lines,
+		PrincipalPaid: money.New(principalPaid, code),
+		InterestPaid:  money.New(interestPaid, code),
+		Outstanding:   money.New(max(loan.Principal.Amount()-principalPaid, 0), code),
+	}
+}
+
+// NextInstallment returns the number of the first installment the payments
+// do not cover yet, zero once the loan has been repaid.
+func (r *LoanReport) NextInstallment() int {
+	if r.Outstanding.Amount() == 0 {
+		return 0
+	}
+
+	for _, line := range r.Lines {
+		if !line.IsPaid() {
+			return line.Installment.Number
+		}
+	}
+
+	return r.Lines[len(r.Lines)-1].Installment.Number
+}
@@ -0,0 +1,287 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToCreateLoan      = "failed to create loan"
+	failedToUpdateLoan      = "failed to update loan"
+	failedToFindLoan        = "failed to find loan"
+	failedToRemoveLoan      = "failed to remove loan"
+	failedToLinkLoan        = "failed to link payment to loan"
+	failedToBuildLoanReport = "failed to build loan report"
+)
+
+type LoanServiceImpl struct {
+	loans    LoanRepository
+	payments PaymentRepository
+	projects ProjectRepository
+}
+
+func NewLoanService(
+	loans LoanRepository,
+	payments PaymentRepository,
+	projects ProjectRepository,
+) *LoanServiceImpl {
+	return &LoanServiceImpl{
+		loans:    loans,
+		payments: payments,
+		projects: projects,
+	}
+}
+
+func (s *LoanServiceImpl) Find(ctx context.Context, filter LoanCollectionFilter) (*LoanCollection, error) {
+	collection, err := s.loans.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindLoan, err)
+	}
+
+	return collection, nil
+}
+
+func (s *LoanServiceImpl) FindOne(ctx context.Context, filter LoanFilter) (*Loan, error) {
+	l, err := s.loans.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToFindLoan, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindLoan, err)
+	}
+
+	return l, nil
+}
+
+func (s *LoanServiceImpl) Create(ctx context.Context, command CreateLoanCommand) (*Loan, error) {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := NewLoan(
+		uuid.New(),
+		project.ProjectID,
+		command.Description,
+		command.Principal,
+		command.AnnualRate,
+		command.Term,
+		command.Schedule,
+		command.StartDate,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.loans.Save(ctx, l); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateLoan, err)
+	}
+
+	return l, nil
+}
+
+// Update changes the terms of the loan. The payments booked against it stay
+// where they are, so the currency is kept and the term may not get shorter
+// than the last installment paid.
+func (s *LoanServiceImpl) Update(ctx context.Context, command UpdateLoanCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	l, err := s.FindOne(ctx, LoanFilter{LoanID: command.ID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	updated, err := NewLoan(
+		l.ID,
+		l.ProjectID,
+		command.Description,
+		command.Principal,
+		command.AnnualRate,
+		command.Term,
+		command.Schedule,
+		command.StartDate,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	payments, err := s.loans.FindPayments(ctx, l.ID)
+
+	if err != nil {
+		return exceptions.NewInternalException(failedToUpdateLoan, err)
+	}
+
+	for _, p := range payments {
+		if p.Amount.Currency().Code != updated.Principal.Currency().Code {
+			return exceptions.NewValidationException("loan currency cannot change once payments are booked against it", nil)
+		}
+
+		if p.Installment > updated.Term {
+			return exceptions.NewValidationException("loan term cannot be shorter than the installments paid", nil)
+		}
+	}
+
+	if err = s.loans.Save(ctx, updated); err != nil {
+		return exceptions.NewInternalException(failedToUpdateLoan, err)
+	}
+
+	return nil
+}
+
+func (s *LoanServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	l, err := s.FindOne(ctx, LoanFilter{LoanID: command.ResourceID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.loans.Remove(ctx, l); err != nil {
+		return exceptions.NewInternalException(failedToRemoveLoan, err)
+	}
+
+	return nil
+}
+
+// LinkPayment books a credit payment against an installment of the loan. A
+// payment is booked against a single installment, linking it again moves it.
+func (s *LoanServiceImpl) LinkPayment(ctx context.Context, command LinkLoanPaymentCommand) (*LoanPayment, error) {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return nil, err
+	}
+
+	l, err := s.FindOne(ctx, LoanFilter{LoanID: command.LoanID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.payments.FindOne(ctx, PaymentFilter{PaymentID: command.PaymentID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(FailedToFindPayment, err)
+		}
+
+		return nil, exceptions.NewInternalException(FailedToFindPayment, err)
+	}
+
+	if p.Kind != CreditPayment {
+		return nil, exceptions.NewValidationException("only credit payments can be booked against a loan", nil)
+	}
+
+	if p.Amount.Currency().Code != l.Principal.Currency().Code {
+		return nil, exceptions.NewValidationException("payment currency does not match the loan currency", nil)
+	}
+
+	installment := command.Installment
+
+	if installment == 0 {
+		payments, err := s.loans.FindPayments(ctx, l.ID)
+
+		if err != nil {
+			return nil, exceptions.NewInternalException(failedToLinkLoan, err)
+		}
+
+		// the payment being moved must not count towards the installments
+		others := make([]*LoanPayment, 0, len(payments))
+
+		for _, booked := range payments {
+			if booked.PaymentID != p.ID {
+				others = append(others, booked)
+			}
+		}
+
+		if installment = NewLoanReport(l, others).NextInstallment(); installment == 0 {
+			return nil, exceptions.NewValidationException("loan has been repaid already", nil)
+		}
+	}
+
+	if installment < 1 || installment > l.Term {
+		return nil, exceptions.NewValidationException("installment is out of the loan term", nil)
+	}
+
+	lp := &LoanPayment{
+		LoanID:      l.ID,
+		PaymentID:   p.ID,
+		Installment: installment,
+		Amount:      p.Amount,
+		Date:        p.Date,
+	}
+
+	if err = s.loans.SavePayment(ctx, lp); err != nil {
+		return nil, exceptions.NewInternalException(failedToLinkLoan, err)
+	}
+
+	payments, err := s.loans.FindPayments(ctx, l.ID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToLinkLoan, err)
+	}
+
+	// the split depends on the payments made before, a payment not paid yet
+	// repays nothing
+	for _, booked := range NewLoanReport(l, payments).Lines[installment-1].Payments {
+		if booked.PaymentID == lp.PaymentID {
+			return booked, nil
+		}
+	}
+
+	lp.Principal = money.New(0, lp.Amount.Currency().Code)
+	lp.Interest = money.New(0, lp.Amount.Currency().Code)
+
+	return lp, nil
+}
+
+func (s *LoanServiceImpl) UnlinkPayment(ctx context.Context, command UnlinkLoanPaymentCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	l, err := s.FindOne(ctx, LoanFilter{LoanID: command.LoanID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.loans.RemovePayment(ctx, l.ID, command.PaymentID); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException("payment is not booked against the loan", err)
+		}
+
+		return exceptions.NewInternalException(failedToLinkLoan, err)
+	}
+
+	return nil
+}
+
+func (s *LoanServiceImpl) Report(ctx context.Context, filter LoanFilter) (*LoanReport, error) {
+	l, err := s.FindOne(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.loans.FindPayments(ctx, l.ID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToBuildLoanReport, err)
+	}
+
+	return NewLoanReport(l, payments), nil
+}
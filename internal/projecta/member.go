@@ -0,0 +1,19 @@
+package projecta
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ProjectMember is a person with access to a project: its owner or someone who
+// accepted a share.
+type ProjectMember struct {
+	PersonID    uuid.UUID
+	DisplayName string
+	Role        ProjectRole
+	JoinedAt    time.Time
+}
+
+func (m *ProjectMember) IsOwner() bool {
+	return m.Role == RoleOwner
+}
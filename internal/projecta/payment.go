@@ -5,6 +5,8 @@ import (
 	"github.com/google/uuid"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -16,6 +18,41 @@ const (
 	CreditPayment         PaymentKind = "CREDIT_PAYMENT"
 )
 
+// PaymentStatus tells where a payment is in its lifecycle. Only the paid
+// payments count as spent, the planned and due ones are the obligations the
+// project has committed to.
+type PaymentStatus string
+
+const (
+	// PaymentPlanned is a payment expected later, e.g. the next stage of a
+	// contract.
+	PaymentPlanned PaymentStatus = "PLANNED"
+	// PaymentDue is a payment that has to be made by its due date.
+	PaymentDue PaymentStatus = "DUE"
+	// PaymentPaid is a payment made.
+	PaymentPaid PaymentStatus = "PAID"
+	// PaymentCancelled is a payment that is not going to be made.
+	PaymentCancelled PaymentStatus = "CANCELLED"
+)
+
+func ToPaymentStatus(status string) (PaymentStatus, error) {
+	switch s := PaymentStatus(strings.ToUpper(status)); s {
+	case PaymentPlanned, PaymentDue, PaymentPaid, PaymentCancelled:
+		return s, nil
+	default:
+		return "", exceptions.NewValidationException("invalid payment status", nil)
+	}
+}
+
+func (s PaymentStatus) String() string {
+	return string(s)
+}
+
+// IsCommitted reports whether the payment is an obligation not paid yet.
+func (s PaymentStatus) IsCommitted() bool {
+	return s == PaymentPlanned || s == PaymentDue
+}
+
 type Payment struct {
 	ID          uuid.UUID
 	Project     *Project
@@ -25,6 +62,24 @@ type Payment struct {
 	Amount      *money.Money
 	Date        time.Time
 	Kind        PaymentKind
+	// HomeAmount is Amount converted into the project main currency when the
+	// payment was booked. It is nil when no exchange rate could be captured.
+	HomeAmount *money.Money
+	// ExchangeRate is the rate HomeAmount was converted with.
+	ExchangeRate float64
+	// ManualRate tells whether ExchangeRate was supplied by the person booking
+	// the payment rather than looked up.
+	ManualRate bool
+	Status     PaymentStatus
+	// DueDate is the day a planned or due payment has to be made by. It is
+	// kept once the payment is paid, so late payments can be told apart.
+	DueDate time.Time
+	// VendorID is the vendor paid, ContractID the contract the payment is
+	// made under. Both are uuid.Nil when not known.
+	VendorID   uuid.UUID
+	ContractID uuid.UUID
+	// Tags are the names of the tags of the payment, sorted.
+	Tags []string
 }
 
 func ToPaymentKind(kind string) (PaymentKind, error) {
@@ -44,6 +99,90 @@ func (e PaymentKind) String() string {
 	return string(e)
 }
 
+// ChangeStatus moves the payment to status. Planned and due payments need the
+// day they are due by. Payments are paid with MarkPaid only, and paid or
+// cancelled payments stay so.
+func (p *Payment) ChangeStatus(status PaymentStatus, dueDate time.Time) error {
+	if status != p.Status {
+		switch {
+		case status == PaymentPaid:
+			return exceptions.NewValidationException("payment can only be paid by marking it paid", nil)
+		case p.Status == PaymentPaid:
+			return exceptions.NewValidationException("paid payment cannot change its status", nil)
+		case p.Status == PaymentCancelled:
+			return exceptions.NewValidationException("cancelled payment cannot change its status", nil)
+		}
+	}
+
+	return p.startAs(status, dueDate)
+}
+
+// startAs sets the status a new payment is booked with, whichever it is.
+func (p *Payment) startAs(status PaymentStatus, dueDate time.Time) error {
+	if _, err := ToPaymentStatus(status.String()); err != nil {
+		return err
+	}
+
+	if status.IsCommitted() && dueDate.IsZero() {
+		return exceptions.NewValidationException("due date is required for a planned or due payment", nil)
+	}
+
+	p.Status = status
+	p.DueDate = dueDate
+
+	return nil
+}
+
+// MarkPaid records the payment as made on date. A nil amount keeps the
+// amount planned, otherwise the amount actually paid replaces it.
+func (p *Payment) MarkPaid(date time.Time, amount *money.Money) error {
+	switch p.Status {
+	case PaymentPaid:
+		return exceptions.NewValidationException("payment has been paid already", nil)
+	case PaymentCancelled:
+		return exceptions.NewValidationException("cancelled payment cannot be paid", nil)
+	}
+
+	if amount != nil {
+		if !amount.IsPositive() {
+			return exceptions.NewValidationException("paid amount must be greater than 0", nil)
+		}
+
+		p.Amount = amount
+	}
+
+	p.Status = PaymentPaid
+	p.Date = date
+
+	return nil
+}
+
+// ApplyExchangeRate stores the payment amount booked in the home currency
+// along with the rate it was converted with.
+func (p *Payment) ApplyExchangeRate(rate float64, home *money.Money, manual bool) error {
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return exceptions.NewValidationException("exchange rate must be a positive number", nil)
+	}
+
+	if home == nil {
+		return exceptions.NewValidationException("booked amount is required", nil)
+	}
+
+	p.HomeAmount = home
+	p.ExchangeRate = rate
+	p.ManualRate = manual
+
+	return nil
+}
+
+// ClearExchangeRate forgets the stored conversion, so the payment is converted
+// with the current rates when read.
+func (p *Payment) ClearExchangeRate() {
+	p.HomeAmount = nil
+	p.ExchangeRate = 0
+	p.ManualRate = false
+}
+
 func NewPayment(
 	id uuid.UUID,
 	project *Project,
@@ -62,6 +201,7 @@ func NewPayment(
 		Amount:      amount,
 		Date:        date,
 		Kind:        kind,
+		Status:      PaymentPaid,
 	}
 }
 
@@ -70,3 +210,39 @@ type PaymentCollection = core.PaginatedCollection[*Payment]
 func NewPaymentCollection(total int) *PaymentCollection {
 	return core.NewPaginatedCollection[*Payment](total)
 }
+
+// PaymentGrouping is a dimension payment subtotals can be broken down by.
+type PaymentGrouping string
+
+const (
+	GroupByCategory PaymentGrouping = "category"
+	GroupByType     PaymentGrouping = "type"
+	GroupByKind     PaymentGrouping = "kind"
+	GroupByOwner    PaymentGrouping = "owner"
+)
+
+func ToPaymentGrouping(grouping string) (PaymentGrouping, error) {
+	switch g := PaymentGrouping(grouping); g {
+	case GroupByCategory, GroupByType, GroupByKind, GroupByOwner:
+		return g, nil
+	default:
+		return "", exceptions.NewValidationException("invalid payment grouping", nil)
+	}
+}
+
+func (g PaymentGrouping) String() string {
+	return string(g)
+}
+
+// PaymentSubtotal sums the payments of a currency made on the same day and
+// sharing the groupings asked for. Payments booked in the project main currency
+// are summed by their booked amount, as they need no further conversion.
+type PaymentSubtotal struct {
+	Amount   *money.Money
+	Date     time.Time
+	Count    int
+	Category *CostCategory
+	Type     *CostType
+	Kind     PaymentKind
+	Owner    *Owner
+}
@@ -0,0 +1,152 @@
+package projecta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// MaxPaymentImportRows is the most rows a single import takes, they are all
+// saved in one transaction.
+const MaxPaymentImportRows = 5000
+
+// paymentImportDateLayouts are the date formats accepted in imported files,
+// the ISO one and the one spreadsheets set up for Ukrainian use write.
+var paymentImportDateLayouts = []string{
+	time.DateOnly,
+	time.RFC3339,
+	"02.01.2006",
+}
+
+// PaymentImportRow is a row of an imported file with its fields as they are
+// written there. Line is the line of the file the row starts on.
+type PaymentImportRow struct {
+	Line        int
+	Date        string
+	Amount      string
+	Currency    string
+	Type        string
+	Category    string
+	Description string
+	Kind        string
+}
+
+// PaymentImportLine is what a row is imported as, the payment it makes or the
+// reasons it can not be imported.
+type PaymentImportLine struct {
+	Line    int
+	Payment *Payment
+	NewType bool
+	Errors  []string
+}
+
+func (l *PaymentImportLine) Valid() bool {
+	return len(l.Errors) == 0
+}
+
+// PaymentImport reports an import. Nothing is saved unless every row is valid,
+// so a file with errors is fixed and imported again as a whole.
+type PaymentImport struct {
+	Lines         []*PaymentImportLine
+	NewCategories []*CostCategory
+	NewTypes      []*CostType
+	Committed     bool
+}
+
+// Invalid counts the rows that can not be imported.
+func (i *PaymentImport) Invalid() int {
+	invalid := 0
+
+	for _, line := range i.Lines {
+		if !line.Valid() {
+			invalid++
+		}
+	}
+
+	return invalid
+}
+
+// ParseMoney reads an amount written in major units of currency, e.g. "1 500,50"
+// or "1,500.50" for 150050 kopiyky. The last point or comma followed by no
+// more digits than the currency has minor units is the decimal separator, any
+// other points, commas, spaces and apostrophes group the thousands.
+func ParseMoney(value string, code string) (*money.Money, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	c := money.GetCurrency(code)
+
+	if c == nil {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unknown currency %q", code), nil)
+	}
+
+	invalid := exceptions.NewValidationException(fmt.Sprintf("invalid amount %q", value), nil)
+
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'', '\u2019':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	whole, fraction := digits, ""
+
+	if point := strings.LastIndexAny(digits, ".,"); point >= 0 {
+		decimals := len(digits) - point - 1
+
+		switch {
+		case decimals > 0 && decimals <= c.Fraction:
+			whole, fraction = digits[:point], digits[point+1:]
+		case decimals != 3:
+			return nil, invalid
+		}
+	}
+
+	if groups := strings.Split(strings.ReplaceAll(whole, ",", "."), "."); len(groups) > 1 {
+		for i, group := range groups {
+			if (i > 0 && len(group) != 3) || group == "" || len(group) > 3 {
+				return nil, invalid
+			}
+		}
+	}
+
+	whole = strings.NewReplacer(".", "", ",", "").Replace(whole)
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	if strings.TrimLeft(whole+fraction, "0123456789") != "" || digits == "" {
+		return nil, invalid
+	}
+
+	units, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", c.Fraction-len(fraction)), 10, 64)
+
+	if err != nil {
+		return nil, invalid
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return money.New(units, code), nil
+}
+
+func parsePaymentImportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range paymentImportDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, exceptions.NewValidationException(fmt.Sprintf("invalid date %q", value), nil)
+}
@@ -0,0 +1,316 @@
+package projecta
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToImportPayments = "failed to import payments"
+	paymentImportPageSize  = 500
+)
+
+type PaymentImportServiceImpl struct {
+	db         core.DbConnection
+	payments   PaymentRepository
+	categories CategoryRepository
+	types      TypeRepository
+	projects   ProjectRepository
+	people     PeopleService
+	rates      ExchangeRates
+	fixedRates FixedRateRepository
+}
+
+func NewPaymentImportService(
+	db core.DbConnection,
+	payments PaymentRepository,
+	categories CategoryRepository,
+	types TypeRepository,
+	projects ProjectRepository,
+	people PeopleService,
+	rates ExchangeRates,
+	fixedRates FixedRateRepository,
+) *PaymentImportServiceImpl {
+	return &PaymentImportServiceImpl{
+		db:         db,
+		payments:   payments,
+		categories: categories,
+		types:      types,
+		projects:   projects,
+		people:     people,
+		rates:      rates,
+		fixedRates: fixedRates,
+	}
+}
+
+// Import checks every row before anything is saved and saves the payments,
+// with the types and categories created for them, in a single transaction.
+// The report lists each row either way, so a dry run shows what would be saved.
+func (s *PaymentImportServiceImpl) Import(ctx context.Context, command ImportPaymentsCommand) (*PaymentImport, error) {
+	personID, ok := ctx.Value(core.RequesterIDContextKey).(uuid.UUID)
+
+	if !ok || personID == uuid.Nil {
+		return nil, exceptions.NewInternalException(failedToImportPayments, core.FailedToIdentifyRequester)
+	}
+
+	if len(command.Rows) == 0 {
+		return nil, exceptions.NewValidationException("no payments to import", nil)
+	}
+
+	if len(command.Rows) > MaxPaymentImportRows {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("at most %d payments can be imported at once", MaxPaymentImportRows), nil)
+	}
+
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.people.FindOwner(ctx, personID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToImportPayments, err)
+	}
+
+	types, err := s.findTypes(ctx, project.ProjectID, command.CreateMissingTypes)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToImportPayments, err)
+	}
+
+	report := &PaymentImport{Lines: make([]*PaymentImportLine, 0, len(command.Rows))}
+
+	for _, row := range command.Rows {
+		report.Lines = append(report.Lines, s.importRow(ctx, row, project, owner, types))
+	}
+
+	report.NewCategories, report.NewTypes = types.newCategories, types.newTypes
+
+	if command.DryRun || report.Invalid() > 0 {
+		return report, nil
+	}
+
+	_, err = s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		for _, category := range report.NewCategories {
+			if err := s.categories.Save(ctx, category); err != nil {
+				return nil, exceptions.NewInternalException(failedToImportPayments, err)
+			}
+		}
+
+		for _, costType := range report.NewTypes {
+			if err := s.types.Save(ctx, costType); err != nil {
+				return nil, exceptions.NewInternalException(failedToImportPayments, err)
+			}
+		}
+
+		for _, line := range report.Lines {
+			if err := s.payments.Save(ctx, line.Payment); err != nil {
+				return nil, exceptions.NewInternalException(failedToImportPayments, err)
+			}
+		}
+
+		return nil, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	report.Committed = true
+
+	return report, nil
+}
+
+func (s *PaymentImportServiceImpl) importRow(
+	ctx context.Context,
+	row PaymentImportRow,
+	project *Project,
+	owner *Owner,
+	types *paymentImportTypes,
+) *PaymentImportLine {
+	line := &PaymentImportLine{Line: row.Line}
+
+	date, err := parsePaymentImportDate(row.Date)
+	if err != nil {
+		line.Errors = append(line.Errors, err.Error())
+	}
+
+	code := strings.TrimSpace(row.Currency)
+	if code == "" {
+		code = project.MainCurrency
+	}
+	if code == "" {
+		code = "UAH"
+	}
+
+	amount, err := ParseMoney(row.Amount, code)
+	if err != nil {
+		line.Errors = append(line.Errors, err.Error())
+	} else if !amount.IsPositive() {
+		line.Errors = append(line.Errors, "amount must be positive")
+	}
+
+	kind := UponCompletionPayment
+	if value := strings.ToUpper(strings.TrimSpace(row.Kind)); value != "" {
+		if kind, err = ToPaymentKind(value); err != nil {
+			line.Errors = append(line.Errors, fmt.Sprintf("invalid payment kind %q", row.Kind))
+		}
+	}
+
+	costType, created, err := types.resolve(row.Type, row.Category)
+	if err != nil {
+		line.Errors = append(line.Errors, err.Error())
+	}
+
+	if !line.Valid() {
+		return line
+	}
+
+	p := NewPayment(uuid.New(), project, owner, costType, strings.TrimSpace(row.Description), amount, date, kind)
+
+	if err = convertPayment(ctx, p, 0, false, s.fixedRates, s.rates); err != nil {
+		line.Errors = append(line.Errors, err.Error())
+		return line
+	}
+
+	line.Payment, line.NewType = p, created
+
+	return line
+}
+
+func (s *PaymentImportServiceImpl) findTypes(ctx context.Context, projectID uuid.UUID, createMissing bool) (*paymentImportTypes, error) {
+	types := &paymentImportTypes{projectID: projectID, createMissing: createMissing}
+
+	for offset := 0; ; offset += paymentImportPageSize {
+		page, err := s.types.Find(ctx, TypeCollectionFilter{
+			Pagination: core.Pagination{Limit: paymentImportPageSize, Offset: offset},
+			ProjectID:  projectID,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		types.types = append(types.types, page.Elements()...)
+
+		if len(page.Elements()) == 0 || len(types.types) >= page.Total() {
+			break
+		}
+	}
+
+	if !createMissing {
+		return types, nil
+	}
+
+	for offset := 0; ; offset += paymentImportPageSize {
+		page, err := s.categories.Find(ctx, CategoryCollectionFilter{
+			Pagination: core.Pagination{Limit: paymentImportPageSize, Offset: offset},
+			ProjectID:  projectID,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		types.categories = append(types.categories, page.Elements()...)
+
+		if len(page.Elements()) == 0 || len(types.categories) >= page.Total() {
+			break
+		}
+	}
+
+	return types, nil
+}
+
+// paymentImportTypes resolves the types of imported rows by their names, the
+// case and the spaces around them aside. A type created for a row is found by
+// the rows after it.
+type paymentImportTypes struct {
+	projectID     uuid.UUID
+	createMissing bool
+	types         []*CostType
+	categories    []*CostCategory
+	newTypes      []*CostType
+	newCategories []*CostCategory
+}
+
+// resolve finds the type named typeName. The category is needed only to tell
+// types of the same name apart, or to create a type that is missing.
+func (t *paymentImportTypes) resolve(typeName string, categoryName string) (*CostType, bool, error) {
+	typeName, categoryName = strings.TrimSpace(typeName), strings.TrimSpace(categoryName)
+
+	if typeName == "" {
+		return nil, false, exceptions.NewValidationException("cost type is required", nil)
+	}
+
+	var found []*CostType
+
+	for _, costType := range t.types {
+		if !strings.EqualFold(costType.Name, typeName) {
+			continue
+		}
+
+		if categoryName == "" || (costType.Category != nil && strings.EqualFold(costType.Category.Name, categoryName)) {
+			found = append(found, costType)
+		}
+	}
+
+	if len(found) > 1 {
+		return nil, false, exceptions.NewValidationException(fmt.Sprintf("cost type %q is found in several categories, set the category", typeName), nil)
+	}
+
+	if len(found) == 1 {
+		return found[0], slices.Contains(t.newTypes, found[0]), nil
+	}
+
+	if !t.createMissing {
+		return nil, false, exceptions.NewValidationException(fmt.Sprintf("unknown cost type %q", typeName), nil)
+	}
+
+	if categoryName == "" {
+		return nil, false, exceptions.NewValidationException(fmt.Sprintf("category is required to create cost type %q", typeName), nil)
+	}
+
+	category, err := t.category(categoryName)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	costType, err := NewCostType(t.projectID, category, typeName, "")
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	t.types = append(t.types, costType)
+	t.newTypes = append(t.newTypes, costType)
+
+	return costType, true, nil
+}
+
+func (t *paymentImportTypes) category(name string) (*CostCategory, error) {
+	for _, category := range t.categories {
+		if strings.EqualFold(category.Name, name) {
+			return category, nil
+		}
+	}
+
+	category, err := NewCostCategory(uuid.New(), t.projectID, name, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.categories = append(t.categories, category)
+	t.newCategories = append(t.newCategories, category)
+
+	return category, nil
+}
@@ -3,14 +3,17 @@ package projecta
 import (
 	"context"
 	"errors"
+	"github.com/Rhymond/go-money"
 	"github.com/google/uuid"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math/big"
 )
 
 const (
 	FailedToCreatePayment = "failed to create payment"
 	FailedToFindPayment   = "failed to find payment"
+	FailedToUpdatePayment = "failed to update payment"
 )
 
 type PaymentServiceImpl struct {
@@ -19,9 +22,17 @@ type PaymentServiceImpl struct {
 	types      TypeRepository
 	projects   ProjectRepository
 	people     PeopleService
+	rates      ExchangeRates
+	fixedRates FixedRateRepository
 }
 
 func (s *PaymentServiceImpl) Update(ctx context.Context, command UpdatePaymentCommand) error {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return err
+	}
+
 	p, err := s.payments.FindOne(ctx, PaymentFilter{
 		PaymentID: command.ID,
 		ProjectID: command.ProjectID,
@@ -41,18 +52,179 @@ func (s *PaymentServiceImpl) Update(ctx context.Context, command UpdatePaymentCo
 		return exceptions.NewValidationException(FailedToFindPayment, err)
 	}
 
+	status, dueDate := command.Status, command.DueDate
+	if status == "" {
+		status = p.Status
+	}
+	if dueDate.IsZero() && status == p.Status {
+		dueDate = p.DueDate
+	}
+
 	paymentDate := core.DateOrNow(command.PaymentDate)
 
+	// an obligation not paid yet keeps its date, following the due date when
+	// dated by it
+	if status.IsCommitted() && command.PaymentDate.IsZero() {
+		paymentDate = p.Date
+		if p.Date.Equal(p.DueDate) {
+			paymentDate = dueDate
+		}
+	}
+
+	// the booked rate stays valid as long as neither the currency nor the date
+	// of the payment change, nor the main currency of the project it is booked in
+	rate, manual := command.ExchangeRate, command.ExchangeRate > 0
+	if rate == 0 && p.HomeAmount != nil && p.Amount.Currency().Code == command.Amount.Currency().Code && p.Date.Equal(paymentDate) && p.HomeAmount.Currency().Code == project.MainCurrency {
+		rate, manual = p.ExchangeRate, p.ManualRate
+	}
+
+	if err = p.ChangeStatus(status, dueDate); err != nil {
+		return err
+	}
+
+	p.Project = project
 	p.Type = costType
 	p.Description = command.Description
 	p.Amount = command.Amount
 	p.Date = paymentDate
 	p.Kind = command.Kind
 
+	if err = s.convert(ctx, p, rate, manual); err != nil {
+		return err
+	}
+
 	return s.payments.Save(ctx, p)
 }
 
+// MarkPaid records a planned or due payment as made. The payment is converted
+// into the project main currency again, with the rate of the day it was paid.
+func (s *PaymentServiceImpl) MarkPaid(ctx context.Context, command MarkPaymentPaidCommand) (*Payment, error) {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.FindOne(ctx, PaymentFilter{PaymentID: command.ID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.Project = project
+
+	if err = p.MarkPaid(core.DateOrNow(command.PaymentDate), command.Amount); err != nil {
+		return nil, err
+	}
+
+	if err = s.convert(ctx, p, command.ExchangeRate, command.ExchangeRate > 0); err != nil {
+		return nil, err
+	}
+
+	if err = s.payments.Save(ctx, p); err != nil {
+		return nil, exceptions.NewInternalException(FailedToUpdatePayment, err)
+	}
+
+	return p, nil
+}
+
+func (s *PaymentServiceImpl) convert(ctx context.Context, p *Payment, rate float64, manual bool) error {
+	return convertPayment(ctx, p, rate, manual, s.fixedRates, s.rates)
+}
+
+// convertPayment books the payment amount in the project main currency. A
+// manual rate wins over the rate fixed on the project, which wins over the
+// looked up one. When no rate is available the payment is saved without a
+// conversion and gets converted with the current rates when read. Fixed rates
+// that fail to load fail the booking rather than have another rate booked.
+func convertPayment(ctx context.Context, p *Payment, rate float64, manual bool, fixedRates FixedRateRepository, rates ExchangeRates) error {
+	if p.Amount == nil {
+		return exceptions.NewValidationException("payment amount is required", nil)
+	}
+
+	if rate < 0 {
+		return exceptions.NewValidationException("exchange rate must be a positive number", nil)
+	}
+
+	homeCurrency := p.Project.MainCurrency
+	if homeCurrency == "" {
+		homeCurrency = "UAH"
+	}
+
+	if p.Amount.Currency().Code == homeCurrency {
+		return p.ApplyExchangeRate(1, money.New(p.Amount.Amount(), homeCurrency), false)
+	}
+
+	if rate == 0 && fixedRates != nil {
+		fixed, err := fixedRates.Find(ctx, p.Project.ProjectID)
+
+		if err != nil && !errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewInternalException(failedToFindFixedRates, err)
+		}
+
+		if found, ok := findFixedRate(fixed, p.Amount.Currency().Code, homeCurrency); ok {
+			rate, manual = found, false
+		}
+	}
+
+	if rate == 0 && rates != nil {
+		if found, err := rates.RateAt(p.Amount.Currency().Code, homeCurrency, p.Date); err == nil {
+			rate, manual = found, false
+		}
+	}
+
+	if rate == 0 {
+		p.ClearExchangeRate()
+		return nil
+	}
+
+	book := bookHalfUp
+	if rates != nil {
+		book = rates.Book
+	}
+
+	home, err := book(p.Amount, homeCurrency, rate)
+	if err != nil {
+		return err
+	}
+
+	return p.ApplyExchangeRate(rate, home, manual)
+}
+
+// bookHalfUp converts amount into to at rate, shifting it between the minor
+// units of both currencies and rounding half up. It books the manual rates when
+// there is no rate provider to round them.
+func bookHalfUp(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	value := new(big.Rat).Mul(big.NewRat(amount.Amount(), 1), core.ExactDecimal(rate))
+	from, target := fraction(amount.Currency().Code), fraction(to)
+
+	if target >= from {
+		value.Mul(value, new(big.Rat).SetInt(pow10(target-from)))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(pow10(from-target)))
+	}
+
+	return money.New(core.RoundRat(value, false), to), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// fraction is the number of minor units of the currency, 2 when unknown.
+func fraction(code string) int {
+	if c := money.GetCurrency(code); c != nil {
+		return c.Fraction
+	}
+
+	return 2
+}
+
 func (s *PaymentServiceImpl) Remove(ctx context.Context, command RemovePaymentCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
 	e, err := s.payments.FindOne(ctx, PaymentFilter{
 		PaymentID: command.ID,
 		ProjectID: command.ProjectID,
@@ -74,12 +246,16 @@ func NewPaymentService(
 	types TypeRepository,
 	projects ProjectRepository,
 	people PeopleService,
+	rates ExchangeRates,
+	fixedRates FixedRateRepository,
 ) *PaymentServiceImpl {
 	return &PaymentServiceImpl{
-		payments: payments,
-		types:    types,
-		projects: projects,
-		people:   people,
+		payments:   payments,
+		types:      types,
+		projects:   projects,
+		people:     people,
+		rates:      rates,
+		fixedRates: fixedRates,
 	}
 }
 
@@ -104,8 +280,18 @@ func (s *PaymentServiceImpl) Create(ctx context.Context, command CreatePaymentCo
 		return nil, exceptions.NewValidationException(FailedToCreatePayment, err)
 	}
 
+	if err = project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
 	paymentDate := core.DateOrNow(command.PaymentDate)
 
+	// an obligation not paid yet is dated by the day it is due, unless told
+	// otherwise
+	if command.Status.IsCommitted() && command.PaymentDate.IsZero() {
+		paymentDate = command.DueDate
+	}
+
 	payment := NewPayment(
 		uuid.New(),
 		project,
@@ -117,6 +303,16 @@ func (s *PaymentServiceImpl) Create(ctx context.Context, command CreatePaymentCo
 		command.Kind,
 	)
 
+	if command.Status != "" {
+		if err = payment.startAs(command.Status, command.DueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.convert(ctx, payment, command.ExchangeRate, command.ExchangeRate > 0); err != nil {
+		return nil, err
+	}
+
 	err = s.payments.Save(ctx, payment)
 
 	if err != nil {
@@ -136,6 +332,18 @@ func (s *PaymentServiceImpl) Find(ctx context.Context, filter PaymentCollectionF
 	return collection, nil
 }
 
+// Totals sums the payments of a project per currency and day, so they can be
+// converted without loading every payment.
+func (s *PaymentServiceImpl) Totals(ctx context.Context, filter PaymentTotalsFilter) ([]*PaymentSubtotal, error) {
+	totals, err := s.payments.Totals(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(FailedToFindPayment, err)
+	}
+
+	return totals, nil
+}
+
 func (s *PaymentServiceImpl) FindOne(ctx context.Context, filter PaymentFilter) (*Payment, error) {
 	p, err := s.payments.FindOne(ctx, filter)
 
@@ -0,0 +1,67 @@
+package projecta
+
+import (
+	"fmt"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"time"
+)
+
+// ReportPeriod is the calendar period reports sum payments by.
+type ReportPeriod string
+
+const (
+	PeriodMonth   ReportPeriod = "month"
+	PeriodQuarter ReportPeriod = "quarter"
+	PeriodYear    ReportPeriod = "year"
+)
+
+func ToReportPeriod(period string) (ReportPeriod, error) {
+	switch p := ReportPeriod(period); p {
+	case PeriodMonth, PeriodQuarter, PeriodYear:
+		return p, nil
+	default:
+		return "", exceptions.NewValidationException("invalid report period", nil)
+	}
+}
+
+func (p ReportPeriod) String() string {
+	return string(p)
+}
+
+// Start returns the first day of the period date falls in, as a UTC date so
+// the starts of the same period always compare equal.
+func (p ReportPeriod) Start(date time.Time) time.Time {
+	switch p {
+	case PeriodYear:
+		return time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	case PeriodQuarter:
+		month := date.Month() - (date.Month()-1)%3
+		return time.Date(date.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Next returns the start of the period following the one starting at start.
+func (p ReportPeriod) Next(start time.Time) time.Time {
+	switch p {
+	case PeriodYear:
+		return start.AddDate(1, 0, 0)
+	case PeriodQuarter:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Label names the period date falls in, e.g. 2026-03, 2026-Q1 or 2026.
+func (p ReportPeriod) Label(date time.Time) string {
+	switch p {
+	case PeriodYear:
+		return fmt.Sprintf("%d", date.Year())
+	case PeriodQuarter:
+		return fmt.Sprintf("%d-Q%d", date.Year(), (int(date.Month())+2)/3)
+	default:
+		return date.Format("2006-01")
+	}
+}
@@ -2,7 +2,9 @@ package projecta
 
 import (
 	"context"
+	"github.com/Rhymond/go-money"
 	"github.com/google/uuid"
+	"time"
 )
 
 type CategoryService interface {
@@ -12,17 +14,38 @@ type CategoryService interface {
 	Remove(ctx context.Context, command RemoveCategoryCommand) error
 }
 
+// ExchangeRates looks up the rate to convert one unit of a currency into
+// another on the given date, and books amounts at a rate the way the rates
+// round their conversions.
+type ExchangeRates interface {
+	RateAt(from string, to string, date time.Time) (float64, error)
+	Book(amount *money.Money, to string, rate float64) (*money.Money, error)
+}
+
 type PeopleService interface {
 	FindOwner(ctx context.Context, personID uuid.UUID) (*Owner, error)
 }
 
+// ProjectFinder is satisfied by both ProjectService and ProjectRepository.
+type ProjectFinder interface {
+	FindOne(ctx context.Context, filter ProjectFilter) (*Project, error)
+}
+
 type ProjectService interface {
 	Find(ctx context.Context, filter ProjectCollectionFilter) ([]*Project, error)
 	FindOne(ctx context.Context, filter ProjectFilter) (*Project, error)
 	Create(ctx context.Context, command CreateProjectCommand) (*Project, error)
 	Remove(ctx context.Context, command RemoveProjectCommand) error
 	Update(ctx context.Context, command UpdateProjectCommand) (*Project, error)
+	Archive(ctx context.Context, command ArchiveProjectCommand) (*Project, error)
+	Unarchive(ctx context.Context, command ArchiveProjectCommand) (*Project, error)
 	AcceptShare(ctx context.Context, token uuid.UUID, personID uuid.UUID) (*Project, error)
+	CreateShareLink(ctx context.Context, command CreateShareLinkCommand) (*ShareLink, error)
+	ShareLinks(ctx context.Context, projectID uuid.UUID) ([]*ShareLink, error)
+	RevokeShareLink(ctx context.Context, command RevokeShareLinkCommand) error
+	RotateShareToken(ctx context.Context, command RotateShareTokenCommand) (*Project, error)
+	Members(ctx context.Context, projectID uuid.UUID) ([]*ProjectMember, error)
+	RevokeMember(ctx context.Context, command RevokeMemberCommand) error
 }
 
 type TypeService interface {
@@ -38,7 +61,100 @@ type PaymentService interface {
 	Find(ctx context.Context, filter PaymentCollectionFilter) (*PaymentCollection, error)
 	Create(ctx context.Context, command CreatePaymentCommand) (*Payment, error)
 	Update(ctx context.Context, command UpdatePaymentCommand) error
+	MarkPaid(ctx context.Context, command MarkPaymentPaidCommand) (*Payment, error)
 	Remove(ctx context.Context, command RemovePaymentCommand) error
+	Totals(ctx context.Context, filter PaymentTotalsFilter) ([]*PaymentSubtotal, error)
+}
+
+type PaymentImportService interface {
+	Import(ctx context.Context, command ImportPaymentsCommand) (*PaymentImport, error)
+}
+
+type BudgetService interface {
+	Find(ctx context.Context, filter BudgetCollectionFilter) (*BudgetCollection, error)
+	FindOne(ctx context.Context, filter BudgetFilter) (*Budget, error)
+	Create(ctx context.Context, command CreateBudgetCommand) (*Budget, error)
+	Update(ctx context.Context, command UpdateBudgetCommand) error
+	Remove(ctx context.Context, command RemoveProjectResourceCommand) error
+	Report(ctx context.Context, projectID uuid.UUID) (*BudgetReport, error)
+}
+
+type LoanService interface {
+	Find(ctx context.Context, filter LoanCollectionFilter) (*LoanCollection, error)
+	FindOne(ctx context.Context, filter LoanFilter) (*Loan, error)
+	Create(ctx context.Context, command CreateLoanCommand) (*Loan, error)
+	Update(ctx context.Context, command UpdateLoanCommand) error
+	Remove(ctx context.Context, command RemoveProjectResourceCommand) error
+	LinkPayment(ctx context.Context, command LinkLoanPaymentCommand) (*LoanPayment, error)
+	UnlinkPayment(ctx context.Context, command UnlinkLoanPaymentCommand) error
+	Report(ctx context.Context, filter LoanFilter) (*LoanReport, error)
+}
+
+type VendorService interface {
+	Find(ctx context.Context, filter VendorCollectionFilter) (*VendorCollection, error)
+	FindOne(ctx context.Context, filter VendorFilter) (*Vendor, error)
+	Create(ctx context.Context, command CreateVendorCommand) (*Vendor, error)
+	Update(ctx context.Context, command UpdateVendorCommand) error
+	Remove(ctx context.Context, command RemoveProjectResourceCommand) error
+	LinkPayment(ctx context.Context, command LinkVendorResourceCommand) error
+	UnlinkPayment(ctx context.Context, command LinkVendorResourceCommand) error
+	LinkAsset(ctx context.Context, command LinkVendorResourceCommand) error
+	UnlinkAsset(ctx context.Context, command LinkVendorResourceCommand) error
+	Report(ctx context.Context, filter VendorFilter) (*VendorReport, error)
+}
+
+type ContractService interface {
+	Find(ctx context.Context, filter ContractCollectionFilter) (*ContractCollection, error)
+	FindOne(ctx context.Context, filter ContractFilter) (*Contract, error)
+	Create(ctx context.Context, command CreateContractCommand) (*Contract, error)
+	Update(ctx context.Context, command UpdateContractCommand) error
+	Remove(ctx context.Context, command RemoveProjectResourceCommand) error
+	LinkPayment(ctx context.Context, command LinkContractPaymentCommand) error
+	UnlinkPayment(ctx context.Context, command LinkContractPaymentCommand) error
+	Report(ctx context.Context, filter ContractFilter) (*ContractReport, error)
+}
+
+type EstimateService interface {
+	Find(ctx context.Context, filter EstimateCollectionFilter) (*EstimateCollection, error)
+	FindOne(ctx context.Context, filter EstimateFilter) (*Estimate, error)
+	Create(ctx context.Context, command CreateEstimateCommand) (*Estimate, error)
+	Update(ctx context.Context, command UpdateEstimateCommand) error
+	Remove(ctx context.Context, command RemoveProjectResourceCommand) error
+	AddQuote(ctx context.Context, command CreateQuoteCommand) (*Quote, error)
+	UpdateQuote(ctx context.Context, command UpdateQuoteCommand) error
+	RemoveQuote(ctx context.Context, command RemoveQuoteCommand) error
+	Accept(ctx context.Context, command AcceptQuoteCommand) (*QuoteAcceptance, error)
+}
+
+type TagService interface {
+	Find(ctx context.Context, filter TagCollectionFilter) ([]*Tag, error)
+	TagPayment(ctx context.Context, command TagResourceCommand) ([]string, error)
+	TagAsset(ctx context.Context, command TagResourceCommand) ([]string, error)
+	Totals(ctx context.Context, filter TagTotalsFilter) ([]*TagSubtotal, error)
+}
+
+type FixedRateService interface {
+	Find(ctx context.Context, projectID uuid.UUID) ([]*FixedRate, error)
+	Set(ctx context.Context, command SetFixedRateCommand) (*FixedRate, error)
+	Remove(ctx context.Context, command RemoveFixedRateCommand) error
+}
+
+type SettlementService interface {
+	Ledger(ctx context.Context, projectID uuid.UUID) (*SettlementLedger, error)
+	SetWeight(ctx context.Context, command SetSettlementWeightCommand) (*SettlementWeight, error)
+	RecordTransfer(ctx context.Context, command RecordSettlementTransferCommand) (*SettlementTransfer, error)
+	RemoveTransfer(ctx context.Context, command RemoveProjectResourceCommand) error
+}
+
+type RecurringPaymentService interface {
+	Find(ctx context.Context, filter RecurringPaymentCollectionFilter) (*RecurringPaymentCollection, error)
+	FindOne(ctx context.Context, filter RecurringPaymentFilter) (*RecurringPayment, error)
+	Upcoming(ctx context.Context, filter RecurringPaymentFilter) ([]*RecurringOccurrence, error)
+	Create(ctx context.Context, command CreateRecurringPaymentCommand) (*RecurringPayment, error)
+	Remove(ctx context.Context, command RemoveProjectResourceCommand) error
+	SkipOccurrence(ctx context.Context, command SkipOccurrenceCommand) error
+	EditOccurrence(ctx context.Context, command EditOccurrenceCommand) (*RecurringOccurrence, error)
+	Materialise(ctx context.Context, now time.Time) (int, error)
 }
 
 type CategoryRepository interface {
@@ -55,7 +171,16 @@ type ProjectRepository interface {
 	Create(ctx context.Context, project *Project) error
 	Update(ctx context.Context, project *Project) error
 	Remove(ctx context.Context, project *Project) error
-	CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error
+	// CreateShareRecord makes the person a member of the project with role. It
+	// reports false when the person is a member already, keeping their role.
+	CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, role ProjectRole) (bool, error)
+	FindShareLink(ctx context.Context, token uuid.UUID) (*ShareLink, error)
+	CreateShareLink(ctx context.Context, link *ShareLink) error
+	FindShareLinks(ctx context.Context, project *Project) ([]*ShareLink, error)
+	UseShareLink(ctx context.Context, link *ShareLink) error
+	RemoveShareLink(ctx context.Context, projectID uuid.UUID, linkID uuid.UUID) error
+	FindMembers(ctx context.Context, projectID uuid.UUID) ([]*ProjectMember, error)
+	RemoveShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error
 }
 
 type TypeRepository interface {
@@ -70,4 +195,113 @@ type PaymentRepository interface {
 	FindOne(ctx context.Context, filter PaymentFilter) (*Payment, error)
 	Save(ctx context.Context, payment *Payment) error
 	Remove(ctx context.Context, payment *Payment) error
+	Totals(ctx context.Context, filter PaymentTotalsFilter) ([]*PaymentSubtotal, error)
+}
+
+type BudgetRepository interface {
+	Find(ctx context.Context, filter BudgetCollectionFilter) (*BudgetCollection, error)
+	FindOne(ctx context.Context, filter BudgetFilter) (*Budget, error)
+	Save(ctx context.Context, budget *Budget) error
+	Remove(ctx context.Context, budget *Budget) error
+	Report(ctx context.Context, projectID uuid.UUID) ([]*BudgetReportLine, error)
+}
+
+type FixedRateRepository interface {
+	Find(ctx context.Context, projectID uuid.UUID) ([]*FixedRate, error)
+	Save(ctx context.Context, rate *FixedRate) error
+	Remove(ctx context.Context, projectID uuid.UUID, currency string) error
+}
+
+type SettlementRepository interface {
+	FindWeights(ctx context.Context, projectID uuid.UUID) ([]*SettlementWeight, error)
+	SaveWeight(ctx context.Context, weight *SettlementWeight) error
+	FindTransfers(ctx context.Context, projectID uuid.UUID) ([]*SettlementTransfer, error)
+	SaveTransfer(ctx context.Context, transfer *SettlementTransfer) error
+	RemoveTransfer(ctx context.Context, projectID uuid.UUID, transferID uuid.UUID) error
+}
+
+type RecurringPaymentRepository interface {
+	Find(ctx context.Context, filter RecurringPaymentCollectionFilter) (*RecurringPaymentCollection, error)
+	FindOne(ctx context.Context, filter RecurringPaymentFilter) (*RecurringPayment, error)
+	// FindDue returns the schedules of active projects with an occurrence due
+	// by until, whoever they belong to.
+	FindDue(ctx context.Context, until time.Time) ([]*RecurringPayment, error)
+	Save(ctx context.Context, payment *RecurringPayment) error
+	Remove(ctx context.Context, payment *RecurringPayment) error
+	// FindOccurrences returns the occurrences recorded on or after from.
+	FindOccurrences(ctx context.Context, recurringID uuid.UUID, from time.Time) ([]*RecurringOccurrence, error)
+	// SaveOccurrence fails when the occurrence has been booked already.
+	SaveOccurrence(ctx context.Context, occurrence *RecurringOccurrence) error
+	// Lock takes a lock on the schedule for the current transaction and tells
+	// whether it was free.
+	Lock(ctx context.Context, recurringID uuid.UUID) (bool, error)
+}
+
+type LoanRepository interface {
+	Find(ctx context.Context, filter LoanCollectionFilter) (*LoanCollection, error)
+	FindOne(ctx context.Context, filter LoanFilter) (*Loan, error)
+	Save(ctx context.Context, loan *Loan) error
+	Remove(ctx context.Context, loan *Loan) error
+	// FindPayments returns the paid payments booked against the loan, oldest
+	// first.
+	FindPayments(ctx context.Context, loanID uuid.UUID) ([]*LoanPayment, error)
+	// SavePayment books the payment against the installment, moving it off
+	// the loan and installment it was booked against before.
+	SavePayment(ctx context.Context, payment *LoanPayment) error
+	RemovePayment(ctx context.Context, loanID uuid.UUID, paymentID uuid.UUID) error
+}
+
+// VendorRepository finds the vendors of a project: its own ones and the ones
+// shared by the people taking part in it.
+type VendorRepository interface {
+	Find(ctx context.Context, filter VendorCollectionFilter) (*VendorCollection, error)
+	FindOne(ctx context.Context, filter VendorFilter) (*Vendor, error)
+	Save(ctx context.Context, vendor *Vendor) error
+	Remove(ctx context.Context, vendor *Vendor) error
+	// CountContracts counts the contracts with the vendor in the projects
+	// other than the given one.
+	CountContracts(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID) (int, error)
+	// RemoveContracts removes the contracts the project has with the vendor.
+	RemoveContracts(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID) error
+	LinkPayment(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, paymentID uuid.UUID) error
+	// UnlinkPayment unlinks the payment from the vendor and from the contract
+	// it was made under.
+	UnlinkPayment(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, paymentID uuid.UUID) error
+	LinkAsset(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, assetID uuid.UUID) error
+	UnlinkAsset(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, assetID uuid.UUID) error
+	// Totals sums the payments and the assets of the vendor in the projects
+	// the requester has access to.
+	Totals(ctx context.Context, vendorID uuid.UUID) ([]*VendorSubtotal, error)
+}
+
+type ContractRepository interface {
+	Find(ctx context.Context, filter ContractCollectionFilter) (*ContractCollection, error)
+	FindOne(ctx context.Context, filter ContractFilter) (*Contract, error)
+	Save(ctx context.Context, contract *Contract) error
+	Remove(ctx context.Context, contract *Contract) error
+	FindPayments(ctx context.Context, contractID uuid.UUID) ([]*ContractPayment, error)
+	// LinkPayment makes the payment one of the contract and of its vendor.
+	LinkPayment(ctx context.Context, contract *Contract, paymentID uuid.UUID) error
+	UnlinkPayment(ctx context.Context, contractID uuid.UUID, paymentID uuid.UUID) error
+}
+
+// EstimateRepository loads the estimates along with their quotes.
+type EstimateRepository interface {
+	Find(ctx context.Context, filter EstimateCollectionFilter) (*EstimateCollection, error)
+	FindOne(ctx context.Context, filter EstimateFilter) (*Estimate, error)
+	// Save saves the estimate without its quotes.
+	Save(ctx context.Context, estimate *Estimate) error
+	Remove(ctx context.Context, estimate *Estimate) error
+	// SaveQuote saves the quote replacing all its items.
+	SaveQuote(ctx context.Context, quote *Quote) error
+	RemoveQuote(ctx context.Context, quote *Quote) error
+}
+
+type TagRepository interface {
+	// Find returns the tags carried by the payments or assets of the project,
+	// the most used first.
+	Find(ctx context.Context, filter TagCollectionFilter) ([]*Tag, error)
+	// Save replaces the tags of the payment or asset of the project.
+	Save(ctx context.Context, kind TaggedKind, projectID uuid.UUID, resourceID uuid.UUID, tags []string) error
+	Totals(ctx context.Context, filter TagTotalsFilter) ([]*TagSubtotal, error)
 }
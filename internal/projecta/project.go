@@ -21,12 +21,52 @@ type Project struct {
     ShareToken   uuid.UUID
     IsShared     bool
     MainCurrency string
+    ArchivedAt   time.Time
+    // Role is the role of the person the project was loaded for.
+    Role         ProjectRole
 }
 
 func (p *Project) IsOwnedBy(owner *Owner) bool {
     return p.Owner.PersonID == owner.PersonID
 }
 
+func (p *Project) IsArchived() bool {
+    return !p.ArchivedAt.IsZero()
+}
+
+func (p *Project) Archive(at time.Time) {
+    p.ArchivedAt = at
+}
+
+func (p *Project) Unarchive() {
+    p.ArchivedAt = time.Time{}
+}
+
+// EnsureWritable returns a forbidden exception when the project content may not
+// be changed. Archived projects are read-only until they are unarchived, and
+// viewers may never change them.
+func (p *Project) EnsureWritable() error {
+    if p.IsArchived() {
+        return exceptions.NewForbiddenException("project is archived", nil)
+    }
+
+    if !p.Role.CanEdit() {
+        return exceptions.NewForbiddenException("project role does not allow changes", nil)
+    }
+
+    return nil
+}
+
+// EnsureManageable returns a forbidden exception when the project settings and
+// sharing may not be changed by the person the project was loaded for.
+func (p *Project) EnsureManageable() error {
+    if !p.Role.CanManage() {
+        return exceptions.NewForbiddenException("project role does not allow managing the project", nil)
+    }
+
+    return nil
+}
+
 func NewProject(id uuid.UUID, name string, description string, owner *Owner, startDate time.Time, endDate time.Time, mainCurrency ...string) (*Project, error) {
     if name == "" || len(name) < MinProjectNameLength || len(name) > MaxProjectNameLength {
         return nil, exceptions.NewValidationException("project name must be between 3 and 100 characters", nil)
@@ -46,5 +86,6 @@ func NewProject(id uuid.UUID, name string, description string, owner *Owner, sta
         EndDate:      endDate,
         ShareToken:   uuid.New(),
         MainCurrency: curr,
+        Role:         RoleOwner,
     }, nil
 }
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"time"
 )
 
 type ProjectServiceImpl struct {
+	db            core.DbConnection
 	repository    ProjectRepository
 	peopleService PeopleService
 }
@@ -27,8 +29,109 @@ func (s *ProjectServiceImpl) Find(ctx context.Context, filter ProjectCollectionF
 }
 
 func (s *ProjectServiceImpl) Remove(ctx context.Context, command RemoveProjectCommand) error {
-	//TODO implement me
-	panic("implement me")
+	p, err := s.findOwnedProject(ctx, command.ProjectID, command.PersonID, "failed to remove project")
+
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.Remove(ctx, p)
+
+	if err != nil {
+		return exceptions.NewInternalException("failed to remove project", err)
+	}
+
+	return nil
+}
+
+func (s *ProjectServiceImpl) Archive(ctx context.Context, command ArchiveProjectCommand) (*Project, error) {
+	p, err := s.findOwnedProject(ctx, command.ProjectID, command.PersonID, "failed to archive project")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.IsArchived() {
+		return p, nil
+	}
+
+	p.Archive(time.Now())
+
+	err = s.repository.Update(ctx, p)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException("failed to archive project", err)
+	}
+
+	return p, nil
+}
+
+func (s *ProjectServiceImpl) Unarchive(ctx context.Context, command ArchiveProjectCommand) (*Project, error) {
+	p, err := s.findOwnedProject(ctx, command.ProjectID, command.PersonID, "failed to unarchive project")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.IsArchived() {
+		return p, nil
+	}
+
+	p.Unarchive()
+
+	err = s.repository.Update(ctx, p)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException("failed to unarchive project", err)
+	}
+
+	return p, nil
+}
+
+// findOwnedProject loads the project and makes sure the person is its owner,
+// as only the owner may archive or remove a project.
+func (s *ProjectServiceImpl) findOwnedProject(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, message string) (*Project, error) {
+	p, err := s.findProject(ctx, projectID, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.IsOwnedBy(&Owner{PersonID: personID}) {
+		return nil, exceptions.NewForbiddenException(message, errors.New("only the project owner can do this"))
+	}
+
+	return p, nil
+}
+
+// findManagedProject loads the project and makes sure the person it was loaded
+// for may manage its sharing.
+func (s *ProjectServiceImpl) findManagedProject(ctx context.Context, projectID uuid.UUID, message string) (*Project, error) {
+	p, err := s.findProject(ctx, projectID, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.EnsureManageable(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *ProjectServiceImpl) findProject(ctx context.Context, projectID uuid.UUID, message string) (*Project, error) {
+	p, err := s.repository.FindOne(ctx, ProjectFilter{ProjectID: projectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(message, err)
+		}
+
+		return nil, exceptions.NewInternalException(message, err)
+	}
+
+	return p, nil
 }
 
 func (s *ProjectServiceImpl) Update(ctx context.Context, command UpdateProjectCommand) (*Project, error) {
@@ -40,6 +143,14 @@ func (s *ProjectServiceImpl) Update(ctx context.Context, command UpdateProjectCo
 		return nil, exceptions.NewInternalException("failed to find project", err)
 	}
 
+	if err = p.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	if err = p.EnsureManageable(); err != nil {
+		return nil, err
+	}
+
 	if command.Name != "" {
 		p.Name = command.Name
 	}
@@ -58,8 +169,8 @@ func (s *ProjectServiceImpl) Update(ctx context.Context, command UpdateProjectCo
 	return p, nil
 }
 
-func NewProjectService(repository ProjectRepository, peopleService PeopleService) *ProjectServiceImpl {
-	return &ProjectServiceImpl{repository: repository, peopleService: peopleService}
+func NewProjectService(db core.DbConnection, repository ProjectRepository, peopleService PeopleService) *ProjectServiceImpl {
+	return &ProjectServiceImpl{db: db, repository: repository, peopleService: peopleService}
 }
 
 func (s *ProjectServiceImpl) Create(ctx context.Context, command CreateProjectCommand) (*Project, error) {
@@ -100,25 +211,253 @@ func (s *ProjectServiceImpl) Create(ctx context.Context, command CreateProjectCo
 	return nil, exceptions.NewInternalException("failed to create project", err)
 }
 
+// AcceptShare makes the person a member of the project the share token belongs
+// to, with the role granted by the link. Project share tokens issued before
+// share links existed keep granting the editor role. Accepting a link never
+// changes the role of an existing member, nor uses the link up.
 func (s *ProjectServiceImpl) AcceptShare(ctx context.Context, token uuid.UUID, personID uuid.UUID) (*Project, error) {
 	if token == uuid.Nil {
 		return nil, exceptions.NewValidationException("invalid share token", nil)
 	}
 
-	project, err := s.repository.FindByShareToken(ctx, token)
+	link, err := s.findShareLink(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
+	project := link.Project
+
 	if project.Owner.PersonID == personID {
+		project.Role = RoleOwner
 		return project, nil
 	}
 
-	err = s.repository.CreateShareRecord(ctx, project.ProjectID, personID)
+	// the use of the link is counted along with the new member it brings in,
+	// both or none of them are recorded
+	joined, err := s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		created, err := s.repository.CreateShareRecord(ctx, project.ProjectID, personID, link.Role)
+		if err != nil {
+			return nil, exceptions.NewInternalException("failed to record project share", err)
+		}
+
+		if !created {
+			return false, nil
+		}
+
+		if err = link.EnsureUsable(time.Now()); err != nil {
+			return nil, err
+		}
+
+		// the project share token is not stored as a link and has no limits to track
+		if link.ID != uuid.Nil {
+			if err = s.repository.UseShareLink(ctx, link); err != nil {
+				if errors.Is(err, exceptions.NotFoundError) {
+					return nil, exceptions.NewForbiddenException("share link can no longer be used", err)
+				}
+
+				return nil, exceptions.NewInternalException("failed to use share link", err)
+			}
+		}
+
+		return true, nil
+	})
+
 	if err != nil {
-		return nil, exceptions.NewInternalException("failed to record project share", err)
+		return nil, err
 	}
 
 	project.IsShared = true
+	if joined.(bool) {
+		project.Role = link.Role
+	}
+
+	if member, err := s.repository.FindOne(ctx, ProjectFilter{ProjectID: project.ProjectID}); err == nil {
+		return member, nil
+	}
+
 	return project, nil
 }
+
+func (s *ProjectServiceImpl) findShareLink(ctx context.Context, token uuid.UUID) (*ShareLink, error) {
+	link, err := s.repository.FindShareLink(ctx, token)
+
+	if err == nil {
+		return link, nil
+	}
+
+	if !errors.Is(err, exceptions.NotFoundError) {
+		return nil, exceptions.NewInternalException("failed to find share link", err)
+	}
+
+	project, err := s.repository.FindByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewShareLink(uuid.Nil, token, project, RoleEditor, project.Owner.PersonID, time.Time{}, time.Time{}, 0)
+}
+
+// CreateShareLink issues a new share token granting the requested role. Owners
+// and admins may share a project, but only the owner can hand out the admin role.
+func (s *ProjectServiceImpl) CreateShareLink(ctx context.Context, command CreateShareLinkCommand) (*ShareLink, error) {
+	p, err := s.findManagedProject(ctx, command.ProjectID, "failed to create share link")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if command.Role == RoleAdmin && !p.IsOwnedBy(&Owner{PersonID: command.PersonID}) {
+		return nil, exceptions.NewForbiddenException("only the project owner can grant the admin role", nil)
+	}
+
+	link, err := NewShareLink(uuid.New(), uuid.New(), p, command.Role, command.PersonID, time.Now(), command.ExpiresAt, command.MaxUses)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.CreateShareLink(ctx, link); err != nil {
+		return nil, exceptions.NewInternalException("failed to create share link", err)
+	}
+
+	return link, nil
+}
+
+// ShareLinks lists the share links of the project. The tokens grant access to
+// the project, so only the people allowed to share it may see them.
+func (s *ProjectServiceImpl) ShareLinks(ctx context.Context, projectID uuid.UUID) ([]*ShareLink, error) {
+	p, err := s.findManagedProject(ctx, projectID, "failed to find share links")
+
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.repository.FindShareLinks(ctx, p)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException("failed to find share links", err)
+	}
+
+	return links, nil
+}
+
+// RevokeShareLink deletes the link so its token can no longer be accepted.
+// People who already joined through it keep their access.
+func (s *ProjectServiceImpl) RevokeShareLink(ctx context.Context, command RevokeShareLinkCommand) error {
+	if _, err := s.findManagedProject(ctx, command.ProjectID, "failed to revoke share link"); err != nil {
+		return err
+	}
+
+	if err := s.repository.RemoveShareLink(ctx, command.ProjectID, command.LinkID); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException("share link not found", err)
+		}
+
+		return exceptions.NewInternalException("failed to revoke share link", err)
+	}
+
+	return nil
+}
+
+// RotateShareToken replaces the project share token, so the previous one can no
+// longer be used to join the project.
+func (s *ProjectServiceImpl) RotateShareToken(ctx context.Context, command RotateShareTokenCommand) (*Project, error) {
+	p, err := s.findManagedProject(ctx, command.ProjectID, "failed to rotate share token")
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.ShareToken = uuid.New()
+
+	if err = s.repository.Update(ctx, p); err != nil {
+		return nil, exceptions.NewInternalException("failed to rotate share token", err)
+	}
+
+	return p, nil
+}
+
+// Members lists the owner and everyone who accepted a share of the project.
+func (s *ProjectServiceImpl) Members(ctx context.Context, projectID uuid.UUID) ([]*ProjectMember, error) {
+	if _, err := s.findProject(ctx, projectID, "failed to find project members"); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repository.FindMembers(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException("failed to find project members", err)
+	}
+
+	return members, nil
+}
+
+// RevokeMember removes the access of a member. Owners and admins may revoke
+// members, but only the owner can revoke an admin, and anyone may leave a
+// project on their own. The owner can not be revoked.
+func (s *ProjectServiceImpl) RevokeMember(ctx context.Context, command RevokeMemberCommand) error {
+	p, err := s.findProject(ctx, command.ProjectID, "failed to revoke project member")
+
+	if err != nil {
+		return err
+	}
+
+	members, err := s.repository.FindMembers(ctx, command.ProjectID)
+
+	if err != nil {
+		return exceptions.NewInternalException("failed to revoke project member", err)
+	}
+
+	var member *ProjectMember
+
+	for _, m := range members {
+		if m.PersonID == command.MemberID {
+			member = m
+			break
+		}
+	}
+
+	if member == nil {
+		return exceptions.NewNotFoundException("project member not found", nil)
+	}
+
+	if member.IsOwner() {
+		return exceptions.NewForbiddenException("the project owner can not be revoked", nil)
+	}
+
+	if member.PersonID != command.PersonID {
+		if err = p.EnsureManageable(); err != nil {
+			return err
+		}
+
+		if member.Role == RoleAdmin && !p.IsOwnedBy(&Owner{PersonID: command.PersonID}) {
+			return exceptions.NewForbiddenException("only the project owner can revoke an admin", nil)
+		}
+	}
+
+	if err = s.repository.RemoveShareRecord(ctx, command.ProjectID, member.PersonID); err != nil {
+		return exceptions.NewInternalException("failed to revoke project member", err)
+	}
+
+	return nil
+}
+
+// FindWritableProject loads the project a mutation is about to touch and makes
+// sure its content may still be changed.
+func FindWritableProject(ctx context.Context, projects ProjectFinder, projectID uuid.UUID) (*Project, error) {
+	p, err := projects.FindOne(ctx, ProjectFilter{ProjectID: projectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException("project not found", err)
+		}
+
+		return nil, exceptions.NewInternalException("failed to find project", err)
+	}
+
+	if err = p.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
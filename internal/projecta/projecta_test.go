@@ -3,6 +3,8 @@ package projecta_test
 import (
 	"context"
 	"errors"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/people"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
 )
 
 func TestDomainEntities(t *testing.T) {
@@ -119,10 +122,20 @@ func (m *mockPeopleService) FindOwner(ctx context.Context, personID uuid.UUID) (
 }
 
 type mockProjectRepo struct {
-	project   *projecta.Project
-	findErr   error
-	createErr error
-	updateErr error
+	project    *projecta.Project
+	findErr    error
+	createErr  error
+	updateErr  error
+	removeErr  error
+	link       *projecta.ShareLink
+	linkErr    error
+	sharedRole projecta.ProjectRole
+	savedLink  *projecta.ShareLink
+	useErr     error
+	members    []*projecta.ProjectMember
+	membersErr error
+	revokedID  uuid.UUID
+	member     bool
 }
 
 func (m *mockProjectRepo) Find(ctx context.Context, filter projecta.ProjectCollectionFilter) ([]*projecta.Project, error) {
@@ -141,19 +154,63 @@ func (m *mockProjectRepo) Create(ctx context.Context, p *projecta.Project) error
 	return m.createErr
 }
 func (m *mockProjectRepo) Update(ctx context.Context, p *projecta.Project) error { return m.updateErr }
-func (m *mockProjectRepo) Remove(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Remove(ctx context.Context, p *projecta.Project) error { return m.removeErr }
 func (m *mockProjectRepo) FindByShareToken(ctx context.Context, token uuid.UUID) (*projecta.Project, error) {
 	if m.findErr != nil {
 		return nil, m.findErr
 	}
 	return m.project, nil
 }
-func (m *mockProjectRepo) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+func (m *mockProjectRepo) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, role projecta.ProjectRole) (bool, error) {
+	if m.createErr != nil || m.member {
+		return false, m.createErr
+	}
+	m.sharedRole = role
+	return true, nil
+}
+func (m *mockProjectRepo) FindShareLink(ctx context.Context, token uuid.UUID) (*projecta.ShareLink, error) {
+	if m.linkErr != nil {
+		return nil, m.linkErr
+	}
+	if m.link == nil {
+		return nil, exceptions.NewNotFoundException("share link not found", nil)
+	}
+	return m.link, nil
+}
+func (m *mockProjectRepo) CreateShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	m.savedLink = link
 	return m.createErr
 }
+func (m *mockProjectRepo) FindShareLinks(ctx context.Context, project *projecta.Project) ([]*projecta.ShareLink, error) {
+	if m.linkErr != nil {
+		return nil, m.linkErr
+	}
+	return []*projecta.ShareLink{m.link}, nil
+}
+func (m *mockProjectRepo) UseShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	if m.useErr != nil {
+		return m.useErr
+	}
+	link.Uses++
+	return nil
+}
+func (m *mockProjectRepo) RemoveShareLink(ctx context.Context, projectID uuid.UUID, linkID uuid.UUID) error {
+	return m.removeErr
+}
+func (m *mockProjectRepo) FindMembers(ctx context.Context, projectID uuid.UUID) ([]*projecta.ProjectMember, error) {
+	if m.membersErr != nil {
+		return nil, m.membersErr
+	}
+	return m.members, nil
+}
+func (m *mockProjectRepo) RemoveShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+	m.revokedID = personID
+	return m.removeErr
+}
 
 type mockCategoryRepo struct {
 	cat        *projecta.CostCategory
+	cats       []*projecta.CostCategory
 	findErr    error
 	findOneErr error
 	saveErr    error
@@ -164,6 +221,11 @@ func (m *mockCategoryRepo) Find(ctx context.Context, filter projecta.CategoryCol
 	if m.findErr != nil {
 		return nil, m.findErr
 	}
+	if m.cats != nil {
+		col := projecta.NewCategoryCollection(len(m.cats))
+		col.Add(m.cats...)
+		return col, nil
+	}
 	return projecta.NewCategoryCollection(1), nil
 }
 func (m *mockCategoryRepo) FindOne(ctx context.Context, filter projecta.CategoryFilter) (*projecta.CostCategory, error) {
@@ -181,6 +243,7 @@ func (m *mockCategoryRepo) Remove(ctx context.Context, c *projecta.CostCategory)
 
 type mockTypeRepo struct {
 	costType   *projecta.CostType
+	types      []*projecta.CostType
 	findErr    error
 	findOneErr error
 	saveErr    error
@@ -191,6 +254,11 @@ func (m *mockTypeRepo) Find(ctx context.Context, filter projecta.TypeCollectionF
 	if m.findErr != nil {
 		return nil, m.findErr
 	}
+	if m.types != nil {
+		col := projecta.NewCostTypeCollection(len(m.types))
+		col.Add(m.types...)
+		return col, nil
+	}
 	return projecta.NewCostTypeCollection(1), nil
 }
 func (m *mockTypeRepo) FindOne(ctx context.Context, filter projecta.TypeFilter) (*projecta.CostType, error) {
@@ -210,6 +278,8 @@ type mockPaymentRepo struct {
 	findOneErr error
 	saveErr    error
 	removeErr  error
+	totals     []*projecta.PaymentSubtotal
+	saved      []*projecta.Payment
 }
 
 func (m *mockPaymentRepo) Find(ctx context.Context, filter projecta.PaymentCollectionFilter) (*projecta.PaymentCollection, error) {
@@ -224,10 +294,21 @@ func (m *mockPaymentRepo) FindOne(ctx context.Context, filter projecta.PaymentFi
 	}
 	return m.pay, nil
 }
-func (m *mockPaymentRepo) Save(ctx context.Context, p *projecta.Payment) error { return m.saveErr }
+func (m *mockPaymentRepo) Save(ctx context.Context, p *projecta.Payment) error {
+	if m.saveErr == nil {
+		m.saved = append(m.saved, p)
+	}
+	return m.saveErr
+}
 func (m *mockPaymentRepo) Remove(ctx context.Context, p *projecta.Payment) error {
 	return m.removeErr
 }
+func (m *mockPaymentRepo) Totals(ctx context.Context, filter projecta.PaymentTotalsFilter) ([]*projecta.PaymentSubtotal, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	return m.totals, nil
+}
 
 type mockPeopleRepo struct {
 	person *people.Person
@@ -252,7 +333,7 @@ func TestProjectService(t *testing.T) {
 	peopleSvc := &mockPeopleService{owner: owner}
 	projRepo := &mockProjectRepo{project: proj}
 
-	svc := projecta.NewProjectService(projRepo, peopleSvc)
+	svc := projecta.NewProjectService(&mockImportDb{}, projRepo, peopleSvc)
 
 	// Find & FindOne
 	pList, err := svc.Find(context.Background(), projecta.ProjectCollectionFilter{})
@@ -273,26 +354,26 @@ func TestProjectService(t *testing.T) {
 
 	// Create new project (FindOne returns NotFoundError)
 	projRepoNotFound := &mockProjectRepo{findErr: exceptions.NotFoundError}
-	svcNew := projecta.NewProjectService(projRepoNotFound, peopleSvc)
+	svcNew := projecta.NewProjectService(&mockImportDb{}, projRepoNotFound, peopleSvc)
 	pNew, err := svcNew.Create(context.Background(), projecta.CreateProjectCommand{PersonID: owner.PersonID, Name: "New Project", Description: "Desc"})
 	if err != nil || pNew == nil {
 		t.Fatalf("Create new project error: %v", err)
 	}
 
 	// Create error branches
-	svcPeopleErr := projecta.NewProjectService(projRepo, &mockPeopleService{err: errors.New("err")})
+	svcPeopleErr := projecta.NewProjectService(&mockImportDb{}, projRepo, &mockPeopleService{err: errors.New("err")})
 	_, err = svcPeopleErr.Create(context.Background(), projecta.CreateProjectCommand{})
 	if err == nil {
 		t.Errorf("expected error when FindOwner fails")
 	}
 
-	svcCreateErr := projecta.NewProjectService(&mockProjectRepo{findErr: exceptions.NotFoundError, createErr: errors.New("err")}, peopleSvc)
+	svcCreateErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: exceptions.NotFoundError, createErr: errors.New("err")}, peopleSvc)
 	_, err = svcCreateErr.Create(context.Background(), projecta.CreateProjectCommand{Name: "New Project"})
 	if err == nil {
 		t.Errorf("expected error when repo Create fails")
 	}
 
-	svcUnknownErr := projecta.NewProjectService(&mockProjectRepo{findErr: errors.New("unknown")}, peopleSvc)
+	svcUnknownErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: errors.New("unknown")}, peopleSvc)
 	_, err = svcUnknownErr.Create(context.Background(), projecta.CreateProjectCommand{Name: "New Project"})
 	if err == nil {
 		t.Errorf("expected error when repo FindOne returns unknown error")
@@ -315,7 +396,7 @@ func TestProjectService(t *testing.T) {
 		t.Errorf("expected error when FindOne returns error")
 	}
 
-	svcSaveErr := projecta.NewProjectService(&mockProjectRepo{project: proj, updateErr: errors.New("err")}, peopleSvc)
+	svcSaveErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, updateErr: errors.New("err")}, peopleSvc)
 	_, err = svcSaveErr.Update(context.Background(), projecta.UpdateProjectCommand{ProjectID: proj.ProjectID})
 	if err == nil {
 		t.Errorf("expected error when repo Update fails")
@@ -327,18 +408,8 @@ func TestProjectService(t *testing.T) {
 }
 
 func TestUnimplementedPanics(t *testing.T) {
-	svc := projecta.NewProjectService(&mockProjectRepo{}, &mockPeopleService{})
 	typeSvc := projecta.NewTypeService(&mockTypeRepo{}, &mockCategoryRepo{}, &mockProjectRepo{})
 
-	t.Run("ProjectService Remove panic", func(t *testing.T) {
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("expected panic")
-			}
-		}()
-		_ = svc.Remove(context.Background(), projecta.RemoveProjectCommand{})
-	})
-
 	t.Run("TypeService Update panic", func(t *testing.T) {
 		defer func() {
 			if r := recover(); r == nil {
@@ -349,12 +420,263 @@ func TestUnimplementedPanics(t *testing.T) {
 	})
 }
 
+func TestProjectArchivalAndRemoval(t *testing.T) {
+	owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Owner"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	ctx := context.Background()
+
+	svc := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner})
+	command := projecta.ArchiveProjectCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID}
+
+	// Only the owner may archive
+	if _, err := svc.Archive(ctx, projecta.ArchiveProjectCommand{ProjectID: proj.ProjectID, PersonID: uuid.New()}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected forbidden error for non-owner, got %v", err)
+	}
+
+	p, err := svc.Archive(ctx, command)
+	if err != nil || !p.IsArchived() {
+		t.Fatalf("Archive error: %v", err)
+	}
+	archivedAt := p.ArchivedAt
+
+	// Archiving twice keeps the original timestamp
+	if p, err = svc.Archive(ctx, command); err != nil || p.ArchivedAt != archivedAt {
+		t.Errorf("expected archive to be idempotent, got %v", err)
+	}
+
+	// Archived projects are read-only
+	if err = proj.EnsureWritable(); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected forbidden error for archived project, got %v", err)
+	}
+	if _, err = svc.Update(ctx, projecta.UpdateProjectCommand{ProjectID: proj.ProjectID, Name: "Renamed"}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected forbidden error updating archived project, got %v", err)
+	}
+	if _, err = projecta.FindWritableProject(ctx, svc, proj.ProjectID); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected forbidden error from FindWritableProject, got %v", err)
+	}
+
+	p, err = svc.Unarchive(ctx, command)
+	if err != nil || p.IsArchived() {
+		t.Fatalf("Unarchive error: %v", err)
+	}
+	if p, err = svc.Unarchive(ctx, command); err != nil || p.IsArchived() {
+		t.Errorf("expected unarchive to be idempotent, got %v", err)
+	}
+	if _, err = projecta.FindWritableProject(ctx, svc, proj.ProjectID); err != nil {
+		t.Errorf("FindWritableProject error: %v", err)
+	}
+
+	// Lookup and persistence failures
+	svcNotFound := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: exceptions.NotFoundError}, &mockPeopleService{})
+	svcFindErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: errors.New("err")}, &mockPeopleService{})
+	svcUpdateErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, updateErr: errors.New("err")}, &mockPeopleService{})
+
+	if _, err = svcNotFound.Archive(ctx, command); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on Archive, got %v", err)
+	}
+	if _, err = svcFindErr.Unarchive(ctx, command); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Unarchive, got %v", err)
+	}
+	if _, err = svcUpdateErr.Archive(ctx, command); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when archive is not saved, got %v", err)
+	}
+	proj.Archive(time.Now())
+	if _, err = svcUpdateErr.Unarchive(ctx, command); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when unarchive is not saved, got %v", err)
+	}
+	proj.Unarchive()
+	if _, err = projecta.FindWritableProject(ctx, svcNotFound, proj.ProjectID); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error from FindWritableProject, got %v", err)
+	}
+	if _, err = projecta.FindWritableProject(ctx, svcFindErr, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error from FindWritableProject, got %v", err)
+	}
+
+	// Remove is reserved to the owner
+	if err = svc.Remove(ctx, projecta.RemoveProjectCommand{ProjectID: proj.ProjectID, PersonID: uuid.New()}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected forbidden error removing as non-owner, got %v", err)
+	}
+	if err = svc.Remove(ctx, projecta.RemoveProjectCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID}); err != nil {
+		t.Errorf("Remove error: %v", err)
+	}
+	if err = svcNotFound.Remove(ctx, projecta.RemoveProjectCommand{}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on Remove, got %v", err)
+	}
+	svcRemoveErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, removeErr: errors.New("err")}, &mockPeopleService{})
+	if err = svcRemoveErr.Remove(ctx, projecta.RemoveProjectCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Remove, got %v", err)
+	}
+}
+
+func TestArchivedProjectIsReadOnly(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	proj.Archive(time.Now())
+
+	for name, mutate := range projectMutations(ctx, proj, owner) {
+		if err := mutate(); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("%s: expected forbidden error, got %v", name, err)
+		}
+	}
+}
+
+func TestViewerCannotChangeProject(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	proj.Role = projecta.RoleViewer
+
+	for name, mutate := range projectMutations(ctx, proj, &projecta.Owner{PersonID: requesterID}) {
+		if err := mutate(); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("%s: expected forbidden error, got %v", name, err)
+		}
+	}
+
+	svc := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj}, &mockPeopleService{})
+	if _, err := svc.Update(ctx, projecta.UpdateProjectCommand{ProjectID: proj.ProjectID, Name: "Renamed"}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected forbidden error on Update, got %v", err)
+	}
+
+	proj.Role = projecta.RoleEditor
+	if _, err := svc.Update(ctx, projecta.UpdateProjectCommand{ProjectID: proj.ProjectID, Name: "Renamed"}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected editors to be unable to update project settings, got %v", err)
+	}
+
+	proj.Role = projecta.RoleAdmin
+	if _, err := svc.Update(ctx, projecta.UpdateProjectCommand{ProjectID: proj.ProjectID, Name: "Renamed"}); err != nil {
+		t.Errorf("expected admins to update project settings, got %v", err)
+	}
+}
+
+// projectMutations lists the service calls that change the project content.
+func projectMutations(ctx context.Context, proj *projecta.Project, owner *projecta.Owner) map[string]func() error {
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+
+	projRepo := &mockProjectRepo{project: proj}
+	catRepo := &mockCategoryRepo{cat: cat}
+	typeRepo := &mockTypeRepo{costType: costType}
+
+	catSvc := projecta.NewCategoryService(catRepo, projecta.NewProjectService(&mockImportDb{}, projRepo, &mockPeopleService{}))
+	typeSvc := projecta.NewTypeService(typeRepo, catRepo, projRepo)
+	paySvc := projecta.NewPaymentService(&mockPaymentRepo{}, typeRepo, projRepo, &mockPeopleService{owner: owner}, nil, nil)
+	budgetSvc := projecta.NewBudgetService(&mockBudgetRepo{}, catRepo, typeRepo, projRepo)
+	loanSvc := projecta.NewLoanService(&mockLoanRepo{}, &mockPaymentRepo{}, projRepo)
+	vendorSvc := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{}, &mockPaymentRepo{}, projRepo)
+	contractSvc := projecta.NewContractService(&mockContractRepo{}, &mockVendorRepo{}, &mockPaymentRepo{}, projRepo)
+	estimateSvc := projecta.NewEstimateService(&mockImportDb{}, &mockEstimateRepo{}, &mockVendorRepo{}, typeRepo, &mockContractRepo{}, &mockPaymentRepo{}, projRepo, &mockPeopleService{owner: owner}, nil, nil)
+
+	return map[string]func() error{
+		"create category": func() error {
+			_, err := catSvc.Create(ctx, projecta.CreateCategoryCommand{ProjectID: proj.ProjectID, Name: "Category"})
+			return err
+		},
+		"update category": func() error { return catSvc.Update(ctx, projecta.UpdateCategoryCommand{ProjectID: proj.ProjectID}) },
+		"remove category": func() error { return catSvc.Remove(ctx, projecta.RemoveCategoryCommand{ProjectID: proj.ProjectID}) },
+		"create type": func() error {
+			_, err := typeSvc.Create(ctx, projecta.CreateTypeCommand{ProjectID: proj.ProjectID, Name: "Type"})
+			return err
+		},
+		"remove type": func() error {
+			return typeSvc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"create payment": func() error {
+			_, err := paySvc.Create(ctx, projecta.CreatePaymentCommand{ProjectID: proj.ProjectID, Amount: money.New(1, "UAH")})
+			return err
+		},
+		"update payment": func() error { return paySvc.Update(ctx, projecta.UpdatePaymentCommand{ProjectID: proj.ProjectID}) },
+		"remove payment": func() error { return paySvc.Remove(ctx, projecta.RemovePaymentCommand{ProjectID: proj.ProjectID}) },
+		"create budget": func() error {
+			_, err := budgetSvc.Create(ctx, projecta.CreateBudgetCommand{ProjectID: proj.ProjectID, Amount: 1})
+			return err
+		},
+		"update budget": func() error {
+			return budgetSvc.Update(ctx, projecta.UpdateBudgetCommand{ProjectID: proj.ProjectID, Amount: 1})
+		},
+		"remove budget": func() error {
+			return budgetSvc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"create loan": func() error {
+			_, err := loanSvc.Create(ctx, projecta.CreateLoanCommand{ProjectID: proj.ProjectID})
+			return err
+		},
+		"update loan": func() error { return loanSvc.Update(ctx, projecta.UpdateLoanCommand{ProjectID: proj.ProjectID}) },
+		"remove loan": func() error {
+			return loanSvc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"link loan payment": func() error {
+			_, err := loanSvc.LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID})
+			return err
+		},
+		"unlink loan payment": func() error {
+			return loanSvc.UnlinkPayment(ctx, projecta.UnlinkLoanPaymentCommand{ProjectID: proj.ProjectID})
+		},
+		"create vendor": func() error {
+			_, err := vendorSvc.Create(ctx, projecta.CreateVendorCommand{ProjectID: proj.ProjectID, Name: "Vendor"})
+			return err
+		},
+		"update vendor": func() error { return vendorSvc.Update(ctx, projecta.UpdateVendorCommand{ProjectID: proj.ProjectID}) },
+		"remove vendor": func() error {
+			return vendorSvc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"link vendor payment": func() error {
+			return vendorSvc.LinkPayment(ctx, projecta.LinkVendorResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"unlink vendor asset": func() error {
+			return vendorSvc.UnlinkAsset(ctx, projecta.LinkVendorResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"create contract": func() error {
+			_, err := contractSvc.Create(ctx, projecta.CreateContractCommand{ProjectID: proj.ProjectID})
+			return err
+		},
+		"update contract": func() error {
+			return contractSvc.Update(ctx, projecta.UpdateContractCommand{ProjectID: proj.ProjectID})
+		},
+		"remove contract": func() error {
+			return contractSvc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"link contract payment": func() error {
+			return contractSvc.LinkPayment(ctx, projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID})
+		},
+		"unlink contract payment": func() error {
+			return contractSvc.UnlinkPayment(ctx, projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID})
+		},
+		"create estimate": func() error {
+			_, err := estimateSvc.Create(ctx, projecta.CreateEstimateCommand{ProjectID: proj.ProjectID, Title: "Estimate"})
+			return err
+		},
+		"update estimate": func() error {
+			return estimateSvc.Update(ctx, projecta.UpdateEstimateCommand{ProjectID: proj.ProjectID})
+		},
+		"remove estimate": func() error {
+			return estimateSvc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID})
+		},
+		"add quote": func() error {
+			_, err := estimateSvc.AddQuote(ctx, projecta.CreateQuoteCommand{ProjectID: proj.ProjectID})
+			return err
+		},
+		"update quote": func() error {
+			return estimateSvc.UpdateQuote(ctx, projecta.UpdateQuoteCommand{ProjectID: proj.ProjectID})
+		},
+		"remove quote": func() error {
+			return estimateSvc.RemoveQuote(ctx, projecta.RemoveQuoteCommand{ProjectID: proj.ProjectID})
+		},
+		"accept quote": func() error {
+			_, err := estimateSvc.Accept(ctx, projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID})
+			return err
+		},
+	}
+}
+
 func TestProjectSharing(t *testing.T) {
 	ownerID := uuid.New()
 	owner := &projecta.Owner{PersonID: ownerID, DisplayName: "Owner"}
 	proj, _ := projecta.NewProject(uuid.New(), "Shared Project", "Desc", owner, time.Now(), time.Now())
 	projRepo := &mockProjectRepo{project: proj}
-	svc := projecta.NewProjectService(projRepo, &mockPeopleService{owner: owner})
+	svc := projecta.NewProjectService(&mockImportDb{}, projRepo, &mockPeopleService{owner: owner})
 
 	// AcceptShare by owner
 	p, err := svc.AcceptShare(context.Background(), proj.ShareToken, ownerID)
@@ -372,22 +694,310 @@ func TestProjectSharing(t *testing.T) {
 		t.Errorf("expected IsShared to be true for recipient")
 	}
 
+	if projRepo.sharedRole != projecta.RoleEditor || pShared.Role != projecta.RoleEditor {
+		t.Errorf("expected project share token to grant the editor role, got %s", projRepo.sharedRole)
+	}
+
+	// AcceptShare with a share link
+	viewerLink, _ := projecta.NewShareLink(uuid.New(), uuid.New(), proj, projecta.RoleViewer, ownerID, time.Now(), time.Time{}, 0)
+	linkRepo := &mockProjectRepo{project: proj, link: viewerLink}
+	svcLink := projecta.NewProjectService(&mockImportDb{}, linkRepo, &mockPeopleService{owner: owner})
+	pViewer, err := svcLink.AcceptShare(context.Background(), viewerLink.Token, uuid.New())
+	if err != nil || linkRepo.sharedRole != projecta.RoleViewer || pViewer.Role != projecta.RoleViewer {
+		t.Errorf("expected share link to grant the viewer role, got %v", err)
+	}
+
+	svcLinkErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{linkErr: errors.New("db error")}, &mockPeopleService{owner: owner})
+	if _, err = svcLinkErr.AcceptShare(context.Background(), uuid.New(), recipientID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when share link lookup fails, got %v", err)
+	}
+
+	svcShareErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, link: viewerLink, createErr: errors.New("db error")}, &mockPeopleService{owner: owner})
+	if _, err = svcShareErr.AcceptShare(context.Background(), viewerLink.Token, recipientID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when share record fails, got %v", err)
+	}
+
+	if _, err = svc.AcceptShare(context.Background(), uuid.Nil, recipientID); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for empty token, got %v", err)
+	}
+
 	// AcceptShare with error
 	errRepo := &mockProjectRepo{findErr: errors.New("not found")}
-	svcErr := projecta.NewProjectService(errRepo, &mockPeopleService{owner: owner})
+	svcErr := projecta.NewProjectService(&mockImportDb{}, errRepo, &mockPeopleService{owner: owner})
 	_, err = svcErr.AcceptShare(context.Background(), uuid.New(), recipientID)
 	if err == nil {
 		t.Errorf("expected error when share token not found")
 	}
 }
 
+func TestProjectRoles(t *testing.T) {
+	for _, role := range []projecta.ProjectRole{projecta.RoleOwner, projecta.RoleAdmin, projecta.RoleEditor, projecta.RoleViewer} {
+		parsed, err := projecta.ToProjectRole(role.String())
+		if err != nil || parsed != role {
+			t.Errorf("expected %s to parse, got %v", role, err)
+		}
+	}
+	if _, err := projecta.ToProjectRole("GUEST"); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for unknown role, got %v", err)
+	}
+
+	if !projecta.RoleEditor.CanEdit() || projecta.RoleViewer.CanEdit() {
+		t.Errorf("expected only viewers to be unable to edit")
+	}
+	if !projecta.RoleAdmin.CanManage() || projecta.RoleEditor.CanManage() {
+		t.Errorf("expected only owners and admins to manage")
+	}
+
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	if proj.Role != projecta.RoleOwner {
+		t.Errorf("expected new project to be owned, got %s", proj.Role)
+	}
+
+	if _, err := projecta.NewShareLink(uuid.New(), uuid.New(), nil, projecta.RoleViewer, owner.PersonID, time.Now(), time.Time{}, 0); err == nil {
+		t.Errorf("expected error for link without project")
+	}
+	if _, err := projecta.NewShareLink(uuid.New(), uuid.New(), proj, projecta.RoleOwner, owner.PersonID, time.Now(), time.Time{}, 0); err == nil {
+		t.Errorf("expected error for link granting ownership")
+	}
+}
+
+func TestCreateShareLink(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	adminID := uuid.New()
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+
+	projRepo := &mockProjectRepo{project: proj}
+	svc := projecta.NewProjectService(&mockImportDb{}, projRepo, &mockPeopleService{})
+
+	link, err := svc.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, Role: projecta.RoleAdmin})
+	if err != nil || link.Role != projecta.RoleAdmin || projRepo.savedLink != link || link.Token == proj.ShareToken {
+		t.Errorf("expected owner to create an admin link, got %v", err)
+	}
+
+	proj.Role = projecta.RoleAdmin
+	if _, err = svc.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: adminID, Role: projecta.RoleAdmin}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected admins to be unable to grant the admin role, got %v", err)
+	}
+	if _, err = svc.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: adminID, Role: projecta.RoleEditor}); err != nil {
+		t.Errorf("expected admin to create an editor link, got %v", err)
+	}
+	if _, err = svc.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: adminID, Role: projecta.RoleOwner}); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for owner link, got %v", err)
+	}
+
+	proj.Role = projecta.RoleEditor
+	if _, err = svc.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: adminID, Role: projecta.RoleViewer}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected editors to be unable to share, got %v", err)
+	}
+	proj.Role = projecta.RoleOwner
+
+	svcNotFound := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: exceptions.NewNotFoundException("not found", nil)}, &mockPeopleService{})
+	if _, err = svcNotFound.CreateShareLink(ctx, projecta.CreateShareLinkCommand{}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	svcFindErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: errors.New("db error")}, &mockPeopleService{})
+	if _, err = svcFindErr.CreateShareLink(ctx, projecta.CreateShareLinkCommand{}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error, got %v", err)
+	}
+	svcSaveErr := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, createErr: errors.New("db error")}, &mockPeopleService{})
+	if _, err = svcSaveErr.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, Role: projecta.RoleViewer}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on save, got %v", err)
+	}
+}
+
+func TestShareLinkLimits(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	now := time.Now()
+
+	if _, err := projecta.NewShareLink(uuid.New(), uuid.New(), proj, projecta.RoleViewer, owner.PersonID, now, now.Add(-time.Minute), 0); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for link expiring in the past, got %v", err)
+	}
+	if _, err := projecta.NewShareLink(uuid.New(), uuid.New(), proj, projecta.RoleViewer, owner.PersonID, now, time.Time{}, -1); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for negative max uses, got %v", err)
+	}
+
+	link, _ := projecta.NewShareLink(uuid.New(), uuid.New(), proj, projecta.RoleViewer, owner.PersonID, now, now.Add(time.Hour), 2)
+	if err := link.EnsureUsable(now); err != nil {
+		t.Errorf("expected fresh link to be usable, got %v", err)
+	}
+	if err := link.EnsureUsable(now.Add(time.Hour)); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected expired link to be rejected, got %v", err)
+	}
+	link.Uses = 2
+	if err := link.EnsureUsable(now); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected used up link to be rejected, got %v", err)
+	}
+
+	// accepting counts a use of the link
+	link.Uses = 1
+	projRepo := &mockProjectRepo{project: proj, link: link}
+	svc := projecta.NewProjectService(&mockImportDb{}, projRepo, &mockPeopleService{})
+	if _, err := svc.AcceptShare(ctx, link.Token, uuid.New()); err != nil || link.Uses != 2 {
+		t.Errorf("expected link use to be counted, got %v", err)
+	}
+	if _, err := svc.AcceptShare(ctx, link.Token, uuid.New()); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected used up link to be rejected, got %v", err)
+	}
+
+	// the owner following a link does not use it up
+	if _, err := svc.AcceptShare(ctx, link.Token, owner.PersonID); err != nil {
+		t.Errorf("expected owner to open a used up link, got %v", err)
+	}
+
+	link.Uses = 0
+	raceRepo := &mockProjectRepo{project: proj, link: link, useErr: exceptions.NewNotFoundException("used up", nil)}
+	svcRace := projecta.NewProjectService(&mockImportDb{}, raceRepo, &mockPeopleService{})
+	if _, err := svcRace.AcceptShare(ctx, link.Token, uuid.New()); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected link used up concurrently to be rejected, got %v", err)
+	}
+	useErrRepo := &mockProjectRepo{project: proj, link: link, useErr: errors.New("db error")}
+	svcUseErr := projecta.NewProjectService(&mockImportDb{}, useErrRepo, &mockPeopleService{})
+	if _, err := svcUseErr.AcceptShare(ctx, link.Token, uuid.New()); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when counting the use fails, got %v", err)
+	}
+
+	// an existing member neither uses the link up nor changes role
+	link.Uses = 0
+	memberRepo := &mockProjectRepo{project: proj, link: link, member: true}
+	svcMember := projecta.NewProjectService(&mockImportDb{}, memberRepo, &mockPeopleService{})
+	if _, err := svcMember.AcceptShare(ctx, link.Token, uuid.New()); err != nil || link.Uses != 0 || memberRepo.sharedRole != "" {
+		t.Errorf("expected the link to be left unused by a member, got %d uses: %v", link.Uses, err)
+	}
+	link.Uses = link.MaxUses
+	if _, err := svcMember.AcceptShare(ctx, link.Token, uuid.New()); err != nil {
+		t.Errorf("expected a member to open a used up link, got %v", err)
+	}
+
+	failingTx := projecta.NewProjectService(&mockImportDb{err: errors.New("tx error")}, &mockProjectRepo{project: proj, link: link}, &mockPeopleService{})
+	if _, err := failingTx.AcceptShare(ctx, link.Token, uuid.New()); err == nil {
+		t.Error("expected transaction error")
+	}
+
+	created, err := svc.CreateShareLink(ctx, projecta.CreateShareLinkCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, Role: projecta.RoleEditor, ExpiresAt: now.Add(time.Hour), MaxUses: 3})
+	if err != nil || created.MaxUses != 3 || !created.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected link with limits, got %v", err)
+	}
+}
+
+func TestShareManagement(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	adminID, editorID, viewerID := uuid.New(), uuid.New(), uuid.New()
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	link, _ := projecta.NewShareLink(uuid.New(), uuid.New(), proj, projecta.RoleViewer, owner.PersonID, time.Now(), time.Time{}, 0)
+	members := []*projecta.ProjectMember{
+		{PersonID: owner.PersonID, Role: projecta.RoleOwner},
+		{PersonID: adminID, Role: projecta.RoleAdmin},
+		{PersonID: editorID, Role: projecta.RoleEditor},
+		{PersonID: viewerID, Role: projecta.RoleViewer},
+	}
+
+	projRepo := &mockProjectRepo{project: proj, link: link, members: members}
+	svc := projecta.NewProjectService(&mockImportDb{}, projRepo, &mockPeopleService{})
+
+	// members
+	list, err := svc.Members(ctx, proj.ProjectID)
+	if err != nil || len(list) != 4 {
+		t.Errorf("Members error: %v", err)
+	}
+	if _, err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, membersErr: errors.New("db error")}, &mockPeopleService{}).Members(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Members, got %v", err)
+	}
+	if _, err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: exceptions.NewNotFoundException("not found", nil)}, &mockPeopleService{}).Members(ctx, proj.ProjectID); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on Members, got %v", err)
+	}
+
+	// share links
+	links, err := svc.ShareLinks(ctx, proj.ProjectID)
+	if err != nil || len(links) != 1 {
+		t.Errorf("ShareLinks error: %v", err)
+	}
+	if _, err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, linkErr: errors.New("db error")}, &mockPeopleService{}).ShareLinks(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on ShareLinks, got %v", err)
+	}
+	if err = svc.RevokeShareLink(ctx, projecta.RevokeShareLinkCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, LinkID: link.ID}); err != nil {
+		t.Errorf("RevokeShareLink error: %v", err)
+	}
+	if err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, removeErr: exceptions.NewNotFoundException("not found", nil)}, &mockPeopleService{}).RevokeShareLink(ctx, projecta.RevokeShareLinkCommand{ProjectID: proj.ProjectID}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on RevokeShareLink, got %v", err)
+	}
+	if err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, removeErr: errors.New("db error")}, &mockPeopleService{}).RevokeShareLink(ctx, projecta.RevokeShareLinkCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on RevokeShareLink, got %v", err)
+	}
+
+	// share token rotation
+	previous := proj.ShareToken
+	rotated, err := svc.RotateShareToken(ctx, projecta.RotateShareTokenCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID})
+	if err != nil || rotated.ShareToken == previous {
+		t.Errorf("expected share token to change, got %v", err)
+	}
+	if _, err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, updateErr: errors.New("db error")}, &mockPeopleService{}).RotateShareToken(ctx, projecta.RotateShareTokenCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on RotateShareToken, got %v", err)
+	}
+
+	// revoking members
+	if err = svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, MemberID: adminID}); err != nil || projRepo.revokedID != adminID {
+		t.Errorf("expected owner to revoke an admin, got %v", err)
+	}
+	if err = svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, MemberID: owner.PersonID}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected owner to be irrevocable, got %v", err)
+	}
+	if err = svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: owner.PersonID, MemberID: uuid.New()}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error for unknown member, got %v", err)
+	}
+
+	proj.Role = projecta.RoleAdmin
+	if err = svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: uuid.New(), MemberID: adminID}); !hasCode(err, exceptions.Forbidden) {
+		t.Errorf("expected admins to be unable to revoke admins, got %v", err)
+	}
+	if err = svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: adminID, MemberID: editorID}); err != nil {
+		t.Errorf("expected admin to revoke an editor, got %v", err)
+	}
+
+	proj.Role = projecta.RoleViewer
+	for name, mutate := range map[string]func() error{
+		"revoke member": func() error {
+			return svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: viewerID, MemberID: editorID})
+		},
+		"revoke share link": func() error {
+			return svc.RevokeShareLink(ctx, projecta.RevokeShareLinkCommand{ProjectID: proj.ProjectID, PersonID: viewerID})
+		},
+		"rotate share token": func() error {
+			_, err := svc.RotateShareToken(ctx, projecta.RotateShareTokenCommand{ProjectID: proj.ProjectID})
+			return err
+		},
+		"list share links": func() error { _, err := svc.ShareLinks(ctx, proj.ProjectID); return err },
+	} {
+		if err = mutate(); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("%s: expected forbidden error for viewer, got %v", name, err)
+		}
+	}
+	if err = svc.RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: viewerID, MemberID: viewerID}); err != nil || projRepo.revokedID != viewerID {
+		t.Errorf("expected viewer to leave the project, got %v", err)
+	}
+
+	if err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, membersErr: errors.New("db error")}, &mockPeopleService{}).RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when members can not be loaded, got %v", err)
+	}
+	if err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj, members: members, removeErr: errors.New("db error")}, &mockPeopleService{}).RevokeMember(ctx, projecta.RevokeMemberCommand{ProjectID: proj.ProjectID, PersonID: viewerID, MemberID: viewerID}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when share record removal fails, got %v", err)
+	}
+	if err = projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: errors.New("db error")}, &mockPeopleService{}).RevokeMember(ctx, projecta.RevokeMemberCommand{}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error when project can not be loaded, got %v", err)
+	}
+}
+
 func TestCategoryService(t *testing.T) {
 	owner := &projecta.Owner{PersonID: uuid.New()}
 	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
 	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Category", "Desc")
 
 	catRepo := &mockCategoryRepo{cat: cat}
-	projSvc := projecta.NewProjectService(&mockProjectRepo{project: proj}, &mockPeopleService{owner: owner})
+	projSvc := projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner})
 	svc := projecta.NewCategoryService(catRepo, projSvc)
 
 	// Find
@@ -415,13 +1025,13 @@ func TestCategoryService(t *testing.T) {
 	}
 
 	// Create error branches
-	projSvcErr := projecta.NewCategoryService(catRepo, projecta.NewProjectService(&mockProjectRepo{findErr: errors.New("err")}, &mockPeopleService{}))
+	projSvcErr := projecta.NewCategoryService(catRepo, projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{findErr: errors.New("err")}, &mockPeopleService{}))
 	_, err = projSvcErr.Create(context.Background(), projecta.CreateCategoryCommand{ProjectID: proj.ProjectID})
 	if err == nil {
 		t.Errorf("expected error when project FindOne fails")
 	}
 
-	projSvcNil := projecta.NewCategoryService(catRepo, projecta.NewProjectService(&mockProjectRepo{project: nil}, &mockPeopleService{}))
+	projSvcNil := projecta.NewCategoryService(catRepo, projecta.NewProjectService(&mockImportDb{}, &mockProjectRepo{project: nil}, &mockPeopleService{}))
 	_, err = projSvcNil.Create(context.Background(), projecta.CreateCategoryCommand{ProjectID: proj.ProjectID})
 	if err == nil {
 		t.Errorf("expected error when project is nil")
@@ -551,7 +1161,7 @@ func TestPaymentService(t *testing.T) {
 	projRepo := &mockProjectRepo{project: proj}
 	peopleSvc := &mockPeopleService{owner: owner}
 
-	svc := projecta.NewPaymentService(payRepo, typeRepo, projRepo, peopleSvc)
+	svc := projecta.NewPaymentService(payRepo, typeRepo, projRepo, peopleSvc, nil, nil)
 
 	// Create
 	createdPay, err := svc.Create(authedCtx, projecta.CreatePaymentCommand{
@@ -566,20 +1176,20 @@ func TestPaymentService(t *testing.T) {
 	}
 
 	// Create error branches
-	svcTypeErr := projecta.NewPaymentService(payRepo, &mockTypeRepo{findOneErr: errors.New("err")}, projRepo, peopleSvc)
+	svcTypeErr := projecta.NewPaymentService(payRepo, &mockTypeRepo{findOneErr: errors.New("err")}, projRepo, peopleSvc, nil, nil)
 	_, err = svcTypeErr.Create(authedCtx, projecta.CreatePaymentCommand{})
 	if err == nil {
 		t.Errorf("expected error on type FindOne")
 	}
 
-	svcProjErr := projecta.NewPaymentService(payRepo, typeRepo, &mockProjectRepo{findErr: errors.New("err")}, peopleSvc)
+	svcProjErr := projecta.NewPaymentService(payRepo, typeRepo, &mockProjectRepo{findErr: errors.New("err")}, peopleSvc, nil, nil)
 	_, err = svcProjErr.Create(authedCtx, projecta.CreatePaymentCommand{})
 	if err == nil {
 		t.Errorf("expected error on project FindOne")
 	}
 
-	svcSaveErr := projecta.NewPaymentService(&mockPaymentRepo{saveErr: errors.New("err")}, typeRepo, projRepo, peopleSvc)
-	_, err = svcSaveErr.Create(authedCtx, projecta.CreatePaymentCommand{})
+	svcSaveErr := projecta.NewPaymentService(&mockPaymentRepo{saveErr: errors.New("err")}, typeRepo, projRepo, peopleSvc, nil, nil)
+	_, err = svcSaveErr.Create(authedCtx, projecta.CreatePaymentCommand{Amount: money.New(100, money.UAH)})
 	if err == nil {
 		t.Errorf("expected error on payment Save")
 	}
@@ -596,24 +1206,36 @@ func TestPaymentService(t *testing.T) {
 		t.Errorf("Find error: %v", err)
 	}
 
-	svcFindErr := projecta.NewPaymentService(&mockPaymentRepo{findErr: errors.New("err")}, typeRepo, projRepo, peopleSvc)
+	svcFindErr := projecta.NewPaymentService(&mockPaymentRepo{findErr: errors.New("err")}, typeRepo, projRepo, peopleSvc, nil, nil)
 	_, err = svcFindErr.Find(authedCtx, projecta.PaymentCollectionFilter{})
 	if err == nil {
 		t.Errorf("expected Find error")
 	}
 
+	// Totals
+	svcTotals := projecta.NewPaymentService(&mockPaymentRepo{totals: []*projecta.PaymentSubtotal{{Amount: money.New(100, money.UAH), Count: 1}}}, typeRepo, projRepo, peopleSvc, nil, nil)
+	totals, err := svcTotals.Totals(authedCtx, projecta.PaymentTotalsFilter{})
+	if err != nil || len(totals) != 1 {
+		t.Errorf("Totals error: %v", err)
+	}
+
+	_, err = svcFindErr.Totals(authedCtx, projecta.PaymentTotalsFilter{})
+	if !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected Totals internal error, got %v", err)
+	}
+
 	_, err = svc.FindOne(authedCtx, projecta.PaymentFilter{})
 	if err != nil {
 		t.Errorf("FindOne error: %v", err)
 	}
 
-	svcPayNotFound := projecta.NewPaymentService(&mockPaymentRepo{findOneErr: exceptions.NotFoundError}, typeRepo, projRepo, peopleSvc)
+	svcPayNotFound := projecta.NewPaymentService(&mockPaymentRepo{findOneErr: exceptions.NotFoundError}, typeRepo, projRepo, peopleSvc, nil, nil)
 	_, err = svcPayNotFound.FindOne(authedCtx, projecta.PaymentFilter{})
 	if err == nil {
 		t.Errorf("expected not found error")
 	}
 
-	svcPayFindErr := projecta.NewPaymentService(&mockPaymentRepo{findOneErr: errors.New("err")}, typeRepo, projRepo, peopleSvc)
+	svcPayFindErr := projecta.NewPaymentService(&mockPaymentRepo{findOneErr: errors.New("err")}, typeRepo, projRepo, peopleSvc, nil, nil)
 	_, err = svcPayFindErr.FindOne(authedCtx, projecta.PaymentFilter{})
 	if err == nil {
 		t.Errorf("expected internal error")
@@ -643,7 +1265,7 @@ func TestPaymentService(t *testing.T) {
 		t.Errorf("expected internal error on Update FindOne")
 	}
 
-	svcUpdTypeErr := projecta.NewPaymentService(payRepo, &mockTypeRepo{findOneErr: errors.New("err")}, projRepo, peopleSvc)
+	svcUpdTypeErr := projecta.NewPaymentService(payRepo, &mockTypeRepo{findOneErr: errors.New("err")}, projRepo, peopleSvc, nil, nil)
 	err = svcUpdTypeErr.Update(authedCtx, updCmd)
 	if err == nil {
 		t.Errorf("expected type FindOne error on Update")
@@ -666,23 +1288,3835 @@ func TestPaymentService(t *testing.T) {
 	}
 }
 
-func TestPeopleService(t *testing.T) {
-	pID := uuid.New()
-	cred, _ := people.NewCredentials("LOCAL", "user@example.com", "secret")
-	p, _ := people.NewPerson(pID, "John", "Doe", "J.D.", []people.Credentials{cred})
+type mockExchangeRates struct {
+	rate     float64
+	err      error
+	dates    []time.Time
+	rounding currency.RoundingMode
+}
 
-	svc := projecta.NewPeopleService(&mockPeopleRepo{person: p})
-	owner, err := svc.FindOwner(context.Background(), pID)
-	if err != nil || owner == nil {
-		t.Fatalf("FindOwner error: %v", err)
-	}
-	if owner.PersonID != pID || owner.DisplayName != "J.D." {
-		t.Errorf("owner fields mismatch")
+func (m *mockExchangeRates) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return m.rounding.Book(amount, to, rate)
+}
+
+func (m *mockExchangeRates) RateAt(from string, to string, date time.Time) (float64, error) {
+	m.dates = append(m.dates, date)
+	return m.rate, m.err
+}
+
+func TestPaymentExchangeRates(t *testing.T) {
+	requesterID := uuid.New()
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	paidAt := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	newService := func(pay *projecta.Payment, rates projecta.ExchangeRates) *projecta.PaymentServiceImpl {
+		return projecta.NewPaymentService(&mockPaymentRepo{pay: pay}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, rates, nil)
 	}
 
-	svcErr := projecta.NewPeopleService(&mockPeopleRepo{err: errors.New("err")})
-	_, err = svcErr.FindOwner(context.Background(), pID)
-	if err == nil {
-		t.Errorf("expected error when FindByID fails")
+	create := func(svc *projecta.PaymentServiceImpl, amount *money.Money, rate float64) (*projecta.Payment, error) {
+		return svc.Create(authedCtx, projecta.CreatePaymentCommand{
+			ProjectID:    proj.ProjectID,
+			TypeID:       costType.ID,
+			Amount:       amount,
+			PaymentDate:  paidAt,
+			Kind:         projecta.DownPayment,
+			ExchangeRate: rate,
+		})
 	}
+
+	t.Run("Books the rate of the payment date", func(t *testing.T) {
+		rates := &mockExchangeRates{rate: 38.5}
+		pay, err := create(newService(nil, rates), money.New(1000, money.USD), 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pay.HomeAmount.Amount() != 38500 || pay.HomeAmount.Currency().Code != money.UAH || pay.ExchangeRate != 38.5 || pay.ManualRate {
+			t.Errorf("unexpected conversion %v at %v", pay.HomeAmount, pay.ExchangeRate)
+		}
+		if len(rates.dates) != 1 || !rates.dates[0].Equal(paidAt) {
+			t.Errorf("expected rate lookup at payment date, got %v", rates.dates)
+		}
+	})
+
+	t.Run("Manual rate wins over the looked up one", func(t *testing.T) {
+		rates := &mockExchangeRates{rate: 38.5}
+		pay, err := create(newService(nil, rates), money.New(1000, money.USD), 39.1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pay.HomeAmount.Amount() != 39100 || !pay.ManualRate || len(rates.dates) != 0 {
+			t.Errorf("expected manual conversion, got %v", pay.HomeAmount)
+		}
+	})
+
+	t.Run("Rate fixed on the project wins over the market one", func(t *testing.T) {
+		fixed, _ := projecta.NewFixedRate(proj.ProjectID, money.USD, money.UAH, 41.5)
+		rates := &mockExchangeRates{rate: 38.5}
+		svc := projecta.NewPaymentService(&mockPaymentRepo{}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, rates, &mockFixedRateRepo{rates: []*projecta.FixedRate{fixed}})
+
+		pay, err := create(svc, money.New(1000, money.USD), 0)
+		if err != nil || pay.HomeAmount.Amount() != 41500 || pay.ManualRate || len(rates.dates) != 0 {
+			t.Errorf("expected conversion at fixed rate, got %v, %v", pay, err)
+		}
+
+		if pay, err = create(svc, money.New(1000, money.USD), 39.1); err != nil || pay.HomeAmount.Amount() != 39100 {
+			t.Errorf("expected manual conversion, got %v, %v", pay, err)
+		}
+
+		if pay, err = create(svc, money.New(1000, money.EUR), 0); err != nil || pay.HomeAmount.Amount() != 38500 || len(rates.dates) != 1 {
+			t.Errorf("expected market conversion of currencies without a fixed rate, got %v, %v", pay, err)
+		}
+
+		failing := projecta.NewPaymentService(&mockPaymentRepo{}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, rates, &mockFixedRateRepo{findErr: errors.New("db")})
+		if _, err = create(failing, money.New(1000, money.USD), 0); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected fixed rates error, got %v", err)
+		}
+
+		missing := projecta.NewPaymentService(&mockPaymentRepo{}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, rates, &mockFixedRateRepo{findErr: exceptions.NotFoundError})
+		if pay, err = create(missing, money.New(1000, money.USD), 0); err != nil || pay.HomeAmount.Amount() != 38500 {
+			t.Errorf("expected market conversion without fixed rates, got %v, %v", pay, err)
+		}
+	})
+
+	t.Run("Home currency payments convert at par", func(t *testing.T) {
+		pay, err := create(newService(nil, nil), money.New(1000, money.UAH), 0)
+		if err != nil || pay.HomeAmount.Amount() != 1000 || pay.ExchangeRate != 1 {
+			t.Errorf("expected conversion at par, got %v, %v", pay, err)
+		}
+	})
+
+	t.Run("Missing rate leaves the payment unconverted", func(t *testing.T) {
+		pay, err := create(newService(nil, &mockExchangeRates{err: errors.New("rates are down")}), money.New(1000, money.USD), 0)
+		if err != nil || pay.HomeAmount != nil || pay.ExchangeRate != 0 {
+			t.Errorf("expected unconverted payment, got %v, %v", pay, err)
+		}
+	})
+
+	t.Run("Books the amount the way the rates round", func(t *testing.T) {
+		for mode, expected := range map[currency.RoundingMode]int64{currency.RoundHalfUp: 3, currency.RoundHalfEven: 2} {
+			pay, err := create(newService(nil, &mockExchangeRates{rounding: mode}), money.New(1, money.USD), 2.5)
+			if err != nil || pay.HomeAmount.Amount() != expected {
+				t.Errorf("expected %d, got %v, %v", expected, pay, err)
+			}
+		}
+	})
+
+	t.Run("Manual rate is booked without rates to round it", func(t *testing.T) {
+		pay, err := create(newService(nil, nil), money.New(1000, money.USD), 39.1)
+		if err != nil || pay.HomeAmount.Amount() != 39100 || !pay.ManualRate {
+			t.Errorf("expected manual conversion, got %v, %v", pay, err)
+		}
+
+		if pay, err = create(newService(nil, nil), money.New(100, money.JPY), 0.275); err != nil || pay.HomeAmount.Amount() != 2750 {
+			t.Errorf("expected the amount shifted to the minor units of UAH, got %v, %v", pay, err)
+		}
+
+		proj.MainCurrency = money.JPY
+		defer func() { proj.MainCurrency = money.UAH }()
+
+		if pay, err = create(newService(nil, nil), money.New(1000, money.USD), 150.55); err != nil || pay.HomeAmount.Amount() != 1506 || pay.HomeAmount.Currency().Code != money.JPY {
+			t.Errorf("expected the half rounded up in JPY, got %v, %v", pay, err)
+		}
+	})
+
+	t.Run("Invalid rates are rejected", func(t *testing.T) {
+		if _, err := create(newService(nil, nil), money.New(1000, money.USD), -1); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err := create(newService(nil, nil), nil, 0); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		pay := projecta.NewPayment(uuid.New(), proj, owner, costType, "Payment", money.New(100, money.USD), paidAt, projecta.DownPayment)
+		if err := pay.ApplyExchangeRate(0, money.New(100, money.UAH), true); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if err := pay.ApplyExchangeRate(41, nil, true); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("Update keeps the booked rate unless currency or date change", func(t *testing.T) {
+		pay := projecta.NewPayment(uuid.New(), proj, owner, costType, "Payment", money.New(1000, money.USD), paidAt, projecta.DownPayment)
+		_ = pay.ApplyExchangeRate(39.1, money.New(39100, money.UAH), true)
+
+		rates := &mockExchangeRates{rate: 41}
+		svc := newService(pay, rates)
+		cmd := projecta.UpdatePaymentCommand{
+			ID:          pay.ID,
+			ProjectID:   proj.ProjectID,
+			TypeID:      costType.ID,
+			Amount:      money.New(2000, money.USD),
+			PaymentDate: paidAt,
+			Kind:        projecta.DownPayment,
+		}
+
+		if err := svc.Update(authedCtx, cmd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pay.HomeAmount.Amount() != 78200 || !pay.ManualRate || len(rates.dates) != 0 {
+			t.Errorf("expected booked rate to be kept, got %v", pay.HomeAmount)
+		}
+
+		cmd.PaymentDate = paidAt.AddDate(0, 0, 1)
+		if err := svc.Update(authedCtx, cmd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pay.HomeAmount.Amount() != 82000 || pay.ManualRate || len(rates.dates) != 1 {
+			t.Errorf("expected rate of the new date, got %v", pay.HomeAmount)
+		}
+
+		cmd.ExchangeRate = -2
+		if err := svc.Update(authedCtx, cmd); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("Update books again once the main currency changes", func(t *testing.T) {
+		usdProj, _ := projecta.NewProject(proj.ProjectID, "Project", "Desc", owner, time.Now(), time.Now())
+		pay := projecta.NewPayment(uuid.New(), usdProj, owner, costType, "Payment", money.New(1000, money.EUR), paidAt, projecta.DownPayment)
+		_ = pay.ApplyExchangeRate(45, money.New(45000, money.UAH), false)
+		usdProj.MainCurrency = money.USD
+
+		rates := &mockExchangeRates{rate: 1.08}
+		svc := projecta.NewPaymentService(&mockPaymentRepo{pay: pay}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: usdProj}, &mockPeopleService{owner: owner}, rates, nil)
+
+		err := svc.Update(authedCtx, projecta.UpdatePaymentCommand{
+			ID:          pay.ID,
+			ProjectID:   usdProj.ProjectID,
+			TypeID:      costType.ID,
+			Description: "Renamed",
+			Amount:      money.New(1000, money.EUR),
+			PaymentDate: paidAt,
+			Kind:        projecta.DownPayment,
+		})
+		if err != nil || pay.HomeAmount.Amount() != 1080 || pay.HomeAmount.Currency().Code != money.USD || pay.ExchangeRate != 1.08 || len(rates.dates) != 1 {
+			t.Errorf("expected the payment booked in USD, got %v, %v", pay.HomeAmount, err)
+		}
+	})
+}
+
+type mockFixedRateRepo struct {
+	rates     []*projecta.FixedRate
+	findErr   error
+	saveErr   error
+	removeErr error
+	saved     *projecta.FixedRate
+}
+
+func (m *mockFixedRateRepo) Find(ctx context.Context, projectID uuid.UUID) ([]*projecta.FixedRate, error) {
+	return m.rates, m.findErr
+}
+func (m *mockFixedRateRepo) Save(ctx context.Context, rate *projecta.FixedRate) error {
+	m.saved = rate
+	return m.saveErr
+}
+func (m *mockFixedRateRepo) Remove(ctx context.Context, projectID uuid.UUID, currency string) error {
+	return m.removeErr
+}
+
+func TestPaymentStatus(t *testing.T) {
+	requesterID := uuid.New()
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	dueAt := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	paidAt := time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)
+
+	planned := func() *projecta.Payment {
+		p := projecta.NewPayment(uuid.New(), proj, owner, costType, "Stage 2", money.New(1000, money.USD), dueAt, projecta.DownPayment)
+		p.Status = projecta.PaymentPlanned
+		p.DueDate = dueAt
+		return p
+	}
+
+	t.Run("Parses statuses", func(t *testing.T) {
+		for _, raw := range []string{"planned", "DUE", "Paid", "cancelled"} {
+			s, err := projecta.ToPaymentStatus(raw)
+			if err != nil || s.String() != strings.ToUpper(raw) {
+				t.Errorf("unexpected status %q for %q: %v", s, raw, err)
+			}
+		}
+		if _, err := projecta.ToPaymentStatus("overdue"); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if !projecta.PaymentPlanned.IsCommitted() || !projecta.PaymentDue.IsCommitted() || projecta.PaymentPaid.IsCommitted() || projecta.PaymentCancelled.IsCommitted() {
+			t.Errorf("unexpected committed statuses")
+		}
+	})
+
+	t.Run("New payments are paid", func(t *testing.T) {
+		p := projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay", money.New(100, money.USD), paidAt, projecta.DownPayment)
+		if p.Status != projecta.PaymentPaid || !p.DueDate.IsZero() {
+			t.Errorf("unexpected status %v", p.Status)
+		}
+	})
+
+	t.Run("Changes status", func(t *testing.T) {
+		p := planned()
+		if err := p.ChangeStatus("LATE", dueAt); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected invalid status error, got %v", err)
+		}
+		if err := p.ChangeStatus(projecta.PaymentDue, time.Time{}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected missing due date error, got %v", err)
+		}
+		if err := p.ChangeStatus(projecta.PaymentPaid, time.Time{}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected paid only by marking it paid, got %v", err)
+		}
+		if err := p.ChangeStatus(projecta.PaymentDue, dueAt); err != nil || p.Status != projecta.PaymentDue {
+			t.Errorf("unexpected due result %v: %v", p.Status, err)
+		}
+		if err := p.ChangeStatus(projecta.PaymentCancelled, time.Time{}); err != nil || p.Status != projecta.PaymentCancelled {
+			t.Errorf("unexpected cancel result %v: %v", p.Status, err)
+		}
+		if err := p.ChangeStatus(projecta.PaymentPlanned, dueAt); !hasCode(err, exceptions.ValidationFailed) || p.Status != projecta.PaymentCancelled {
+			t.Errorf("expected cancelled payment to stay cancelled, got %v: %v", p.Status, err)
+		}
+
+		paid := projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay", money.New(100, money.USD), paidAt, projecta.DownPayment)
+		if err := paid.ChangeStatus(projecta.PaymentPlanned, dueAt); !hasCode(err, exceptions.ValidationFailed) || paid.Status != projecta.PaymentPaid {
+			t.Errorf("expected paid payment to stay paid, got %v: %v", paid.Status, err)
+		}
+		if err := paid.ChangeStatus(projecta.PaymentPaid, time.Time{}); err != nil {
+			t.Errorf("unexpected error keeping the payment paid: %v", err)
+		}
+	})
+
+	t.Run("Marks paid", func(t *testing.T) {
+		p := planned()
+		if err := p.MarkPaid(paidAt, money.New(0, money.USD)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected invalid amount error, got %v", err)
+		}
+		if err := p.MarkPaid(paidAt, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Status != projecta.PaymentPaid || !p.Date.Equal(paidAt) || !p.DueDate.Equal(dueAt) || p.Amount.Amount() != 1000 {
+			t.Errorf("unexpected paid payment %+v", p)
+		}
+		if err := p.MarkPaid(paidAt, nil); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected paid already error, got %v", err)
+		}
+
+		cancelled := planned()
+		cancelled.Status = projecta.PaymentCancelled
+		if err := cancelled.MarkPaid(paidAt, nil); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected cancelled error, got %v", err)
+		}
+	})
+
+	t.Run("Creates a planned payment dated by its due date", func(t *testing.T) {
+		svc := projecta.NewPaymentService(&mockPaymentRepo{}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, &mockExchangeRates{rate: 40}, nil)
+		cmd := projecta.CreatePaymentCommand{
+			ProjectID: proj.ProjectID,
+			TypeID:    costType.ID,
+			Amount:    money.New(1000, money.USD),
+			Kind:      projecta.DownPayment,
+			Status:    projecta.PaymentPlanned,
+			DueDate:   dueAt,
+		}
+		p, err := svc.Create(authedCtx, cmd)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Status != projecta.PaymentPlanned || !p.Date.Equal(dueAt) || !p.DueDate.Equal(dueAt) {
+			t.Errorf("unexpected planned payment %+v", p)
+		}
+
+		cmd.DueDate = time.Time{}
+		if _, err = svc.Create(authedCtx, cmd); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected missing due date error, got %v", err)
+		}
+	})
+
+	t.Run("Update keeps or changes the status", func(t *testing.T) {
+		p := planned()
+		svc := projecta.NewPaymentService(&mockPaymentRepo{pay: p}, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, &mockExchangeRates{rate: 40}, nil)
+		cmd := projecta.UpdatePaymentCommand{
+			ID:        p.ID,
+			ProjectID: proj.ProjectID,
+			TypeID:    costType.ID,
+			Amount:    money.New(1200, money.USD),
+			Kind:      projecta.DownPayment,
+		}
+		if err := svc.Update(authedCtx, cmd); err != nil || p.Status != projecta.PaymentPlanned || !p.DueDate.Equal(dueAt) || !p.Date.Equal(dueAt) {
+			t.Errorf("expected status and date kept, got %v %v %v: %v", p.Status, p.DueDate, p.Date, err)
+		}
+
+		later := dueAt.AddDate(0, 1, 0)
+		cmd.Status, cmd.DueDate = projecta.PaymentDue, later
+		if err := svc.Update(authedCtx, cmd); err != nil || p.Status != projecta.PaymentDue || !p.DueDate.Equal(later) || !p.Date.Equal(later) {
+			t.Errorf("expected status changed and dated by the new due date, got %v %v %v: %v", p.Status, p.DueDate, p.Date, err)
+		}
+
+		cmd.Status, cmd.DueDate = "", time.Time{}
+		p.Date = paidAt
+		if err := svc.Update(authedCtx, cmd); err != nil || !p.Date.Equal(paidAt) {
+			t.Errorf("expected a date apart from the due date kept, got %v: %v", p.Date, err)
+		}
+
+		cmd.Status, cmd.DueDate = "LATE", time.Time{}
+		if err := svc.Update(authedCtx, cmd); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected invalid status error, got %v", err)
+		}
+
+		cmd.Status = projecta.PaymentPaid
+		if err := svc.Update(authedCtx, cmd); !hasCode(err, exceptions.ValidationFailed) || p.Status != projecta.PaymentDue {
+			t.Errorf("expected the payment to be paid only by marking it paid, got %v: %v", p.Status, err)
+		}
+	})
+
+	t.Run("Marks a payment paid at the rate of the day", func(t *testing.T) {
+		p := planned()
+		repo := &mockPaymentRepo{pay: p}
+		rates := &mockExchangeRates{rate: 41}
+		svc := projecta.NewPaymentService(repo, &mockTypeRepo{costType: costType}, &mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, rates, nil)
+
+		paid, err := svc.MarkPaid(authedCtx, projecta.MarkPaymentPaidCommand{
+			ProjectID:   proj.ProjectID,
+			ID:          p.ID,
+			Amount:      money.New(1100, money.USD),
+			PaymentDate: paidAt,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if paid.Status != projecta.PaymentPaid || paid.HomeAmount.Amount() != 45100 || len(repo.saved) != 1 {
+			t.Errorf("unexpected paid payment %v at %v", paid.HomeAmount, paid.ExchangeRate)
+		}
+		if len(rates.dates) != 1 || !rates.dates[0].Equal(paidAt) {
+			t.Errorf("expected rate lookup at payment date, got %v", rates.dates)
+		}
+
+		if _, err = svc.MarkPaid(authedCtx, projecta.MarkPaymentPaidCommand{ProjectID: proj.ProjectID, ID: p.ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected paid already error, got %v", err)
+		}
+	})
+
+	t.Run("Mark paid failures", func(t *testing.T) {
+		cmd := projecta.MarkPaymentPaidCommand{ProjectID: proj.ProjectID, ExchangeRate: 40}
+		newService := func(repo *mockPaymentRepo, projects *mockProjectRepo) *projecta.PaymentServiceImpl {
+			return projecta.NewPaymentService(repo, &mockTypeRepo{costType: costType}, projects, &mockPeopleService{owner: owner}, nil, nil)
+		}
+
+		_, err := newService(&mockPaymentRepo{pay: planned()}, &mockProjectRepo{findErr: exceptions.NotFoundError}).MarkPaid(authedCtx, cmd)
+		if err == nil {
+			t.Errorf("expected project error")
+		}
+
+		_, err = newService(&mockPaymentRepo{findOneErr: exceptions.NotFoundError}, &mockProjectRepo{project: proj}).MarkPaid(authedCtx, cmd)
+		if !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		_, err = newService(&mockPaymentRepo{pay: planned(), saveErr: errors.New("db")}, &mockProjectRepo{project: proj}).MarkPaid(authedCtx, cmd)
+		if !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+}
+
+func TestFixedRates(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+
+	t.Run("NewFixedRate validates the pair and the rate", func(t *testing.T) {
+		rate, err := projecta.NewFixedRate(proj.ProjectID, " usd ", "uah", 41.5)
+		if err != nil || rate.Currency != money.USD || rate.BaseCurrency != money.UAH {
+			t.Fatalf("unexpected fixed rate %v, %v", rate, err)
+		}
+
+		for name, args := range map[string]struct {
+			currency, base string
+			rate           float64
+		}{
+			"unknown currency":      {"XXXX", "UAH", 1},
+			"unknown base currency": {"USD", "", 1},
+			"same currency":         {"UAH", "UAH", 1},
+			"zero rate":             {"USD", "UAH", 0},
+		} {
+			if _, err = projecta.NewFixedRate(proj.ProjectID, args.currency, args.base, args.rate); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		if r, ok := rate.RateFor(money.USD, money.UAH); !ok || r != 41.5 {
+			t.Errorf("expected direct rate, got %v", r)
+		}
+		if r, ok := rate.RateFor(money.UAH, money.USD); !ok || r != 1/41.5 {
+			t.Errorf("expected inverse rate, got %v", r)
+		}
+		if _, ok := rate.RateFor(money.EUR, money.UAH); ok {
+			t.Error("expected no rate for another pair")
+		}
+	})
+
+	t.Run("Service", func(t *testing.T) {
+		repo := &mockFixedRateRepo{}
+		svc := projecta.NewFixedRateService(repo, &mockProjectRepo{project: proj})
+
+		rate, err := svc.Set(ctx, projecta.SetFixedRateCommand{ProjectID: proj.ProjectID, Currency: "usd", Rate: 41.5})
+		if err != nil || repo.saved != rate || rate.BaseCurrency != proj.MainCurrency {
+			t.Fatalf("unexpected fixed rate %v, %v", rate, err)
+		}
+
+		if _, err = svc.Set(ctx, projecta.SetFixedRateCommand{ProjectID: proj.ProjectID, Currency: "UAH", Rate: 1}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		repo.rates = []*projecta.FixedRate{rate}
+		if rates, err := svc.Find(ctx, proj.ProjectID); err != nil || len(rates) != 1 {
+			t.Errorf("expected fixed rates, got %v", err)
+		}
+
+		if err = svc.Remove(ctx, projecta.RemoveFixedRateCommand{ProjectID: proj.ProjectID, Currency: "usd"}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Service errors", func(t *testing.T) {
+		dbErr := errors.New("db error")
+		setCmd := projecta.SetFixedRateCommand{ProjectID: proj.ProjectID, Currency: "USD", Rate: 41.5}
+		removeCmd := projecta.RemoveFixedRateCommand{ProjectID: proj.ProjectID, Currency: "USD"}
+
+		svc := projecta.NewFixedRateService(&mockFixedRateRepo{findErr: dbErr, saveErr: dbErr, removeErr: dbErr}, &mockProjectRepo{project: proj})
+		if _, err := svc.Find(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if _, err := svc.Set(ctx, setCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if err := svc.Remove(ctx, removeCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		svc = projecta.NewFixedRateService(&mockFixedRateRepo{removeErr: exceptions.NotFoundError}, &mockProjectRepo{project: proj})
+		if err := svc.Remove(ctx, removeCmd); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		svc = projecta.NewFixedRateService(&mockFixedRateRepo{}, &mockProjectRepo{findErr: exceptions.NotFoundError})
+		if _, err := svc.Find(ctx, proj.ProjectID); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := svc.Remove(ctx, removeCmd); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		svc = projecta.NewFixedRateService(&mockFixedRateRepo{}, &mockProjectRepo{findErr: dbErr})
+		if _, err := svc.Find(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		editor := *proj
+		editor.Role = projecta.RoleEditor
+		svc = projecta.NewFixedRateService(&mockFixedRateRepo{}, &mockProjectRepo{project: &editor})
+		if _, err := svc.Set(ctx, setCmd); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected forbidden error, got %v", err)
+		}
+	})
+}
+
+func TestPeopleService(t *testing.T) {
+	pID := uuid.New()
+	cred, _ := people.NewCredentials("LOCAL", "user@example.com", "secret")
+	p, _ := people.NewPerson(pID, "John", "Doe", "J.D.", []people.Credentials{cred})
+
+	svc := projecta.NewPeopleService(&mockPeopleRepo{person: p})
+	owner, err := svc.FindOwner(context.Background(), pID)
+	if err != nil || owner == nil {
+		t.Fatalf("FindOwner error: %v", err)
+	}
+	if owner.PersonID != pID || owner.DisplayName != "J.D." {
+		t.Errorf("owner fields mismatch")
+	}
+
+	svcErr := projecta.NewPeopleService(&mockPeopleRepo{err: errors.New("err")})
+	_, err = svcErr.FindOwner(context.Background(), pID)
+	if err == nil {
+		t.Errorf("expected error when FindByID fails")
+	}
+}
+
+func hasCode(err error, code exceptions.ErrorCode) bool {
+	var exception exceptions.Exception
+	return errors.As(err, &exception) && exception.Code == code
+}
+
+type mockBudgetRepo struct {
+	budget     *projecta.Budget
+	lines      []*projecta.BudgetReportLine
+	findErr    error
+	findOneErr error
+	saveErr    error
+	removeErr  error
+	reportErr  error
+}
+
+func (m *mockBudgetRepo) Find(ctx context.Context, filter projecta.BudgetCollectionFilter) (*projecta.BudgetCollection, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	return projecta.NewBudgetCollection(1), nil
+}
+func (m *mockBudgetRepo) FindOne(ctx context.Context, filter projecta.BudgetFilter) (*projecta.Budget, error) {
+	if m.findOneErr != nil {
+		return nil, m.findOneErr
+	}
+	return m.budget, nil
+}
+func (m *mockBudgetRepo) Save(ctx context.Context, b *projecta.Budget) error { return m.saveErr }
+func (m *mockBudgetRepo) Remove(ctx context.Context, b *projecta.Budget) error {
+	return m.removeErr
+}
+func (m *mockBudgetRepo) Report(ctx context.Context, projectID uuid.UUID) ([]*projecta.BudgetReportLine, error) {
+	if m.reportErr != nil {
+		return nil, m.reportErr
+	}
+	return m.lines, nil
+}
+
+func TestBudgetEntity(t *testing.T) {
+	projID := uuid.New()
+	cat, _ := projecta.NewCostCategory(uuid.New(), projID, "Electrical", "Desc")
+	otherCat, _ := projecta.NewCostCategory(uuid.New(), projID, "Plumbing", "Desc")
+	costType, _ := projecta.NewCostType(projID, cat, "Wiring", "Desc")
+
+	b, err := projecta.NewBudget(uuid.New(), projID, cat, costType, money.New(100, "UAH"), "Desc")
+	if err != nil {
+		t.Fatalf("unexpected budget error: %v", err)
+	}
+	if b.Category != cat || b.Type != costType || b.Amount.Amount() != 100 {
+		t.Errorf("budget fields mismatch")
+	}
+
+	_, err = projecta.NewBudget(uuid.New(), projID, nil, nil, money.New(100, "UAH"), "")
+	if !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for missing category, got %v", err)
+	}
+
+	_, err = projecta.NewBudget(uuid.New(), projID, otherCat, costType, money.New(100, "UAH"), "")
+	if !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for foreign cost type, got %v", err)
+	}
+
+	_, err = projecta.NewBudget(uuid.New(), projID, cat, nil, money.New(0, "UAH"), "")
+	if !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for zero amount, got %v", err)
+	}
+
+	if projecta.NewBudgetCollection(2).Total() != 2 {
+		t.Errorf("budget collection total mismatch")
+	}
+
+	otherType, _ := projecta.NewCostType(projID, otherCat, "Other", "")
+	categoryLine := &projecta.BudgetReportLine{Budget: &projecta.Budget{Category: cat}}
+	typeLine := &projecta.BudgetReportLine{Budget: b}
+	otherLine := &projecta.BudgetReportLine{Budget: &projecta.Budget{Category: otherCat, Type: otherType}}
+	report := &projecta.BudgetReport{Lines: []*projecta.BudgetReportLine{categoryLine, typeLine, otherLine}}
+	if report.Nested(categoryLine) || !report.Nested(typeLine) || report.Nested(otherLine) {
+		t.Errorf("expected only the type budget of a budgeted category to be nested")
+	}
+}
+
+func TestBudgetService(t *testing.T) {
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	budget, _ := projecta.NewBudget(uuid.New(), proj.ProjectID, cat, nil, money.New(100, proj.MainCurrency), "")
+
+	budgetRepo := &mockBudgetRepo{budget: budget, lines: []*projecta.BudgetReportLine{{Budget: budget}}}
+	catRepo := &mockCategoryRepo{cat: cat}
+	typeRepo := &mockTypeRepo{costType: costType}
+	projRepo := &mockProjectRepo{project: proj}
+
+	svc := projecta.NewBudgetService(budgetRepo, catRepo, typeRepo, projRepo)
+	ctx := context.Background()
+
+	// Find & FindOne
+	if _, err := svc.Find(ctx, projecta.BudgetCollectionFilter{}); err != nil {
+		t.Errorf("Find error: %v", err)
+	}
+	if _, err := svc.FindOne(ctx, projecta.BudgetFilter{}); err != nil {
+		t.Errorf("FindOne error: %v", err)
+	}
+
+	svcFindErr := projecta.NewBudgetService(&mockBudgetRepo{findErr: errors.New("err"), findOneErr: errors.New("err")}, catRepo, typeRepo, projRepo)
+	if _, err := svcFindErr.Find(ctx, projecta.BudgetCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Find, got %v", err)
+	}
+	if _, err := svcFindErr.FindOne(ctx, projecta.BudgetFilter{}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on FindOne, got %v", err)
+	}
+
+	svcNotFound := projecta.NewBudgetService(&mockBudgetRepo{findOneErr: exceptions.NotFoundError}, catRepo, typeRepo, projRepo)
+	if _, err := svcNotFound.FindOne(ctx, projecta.BudgetFilter{}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on FindOne, got %v", err)
+	}
+
+	// Create for a category and for a single type
+	created, err := svc.Create(ctx, projecta.CreateBudgetCommand{ProjectID: proj.ProjectID, CategoryID: cat.ID, Amount: 500})
+	if err != nil || created.Type != nil || created.Amount.Currency().Code != proj.MainCurrency {
+		t.Fatalf("Create error: %v", err)
+	}
+
+	created, err = svc.Create(ctx, projecta.CreateBudgetCommand{ProjectID: proj.ProjectID, CategoryID: cat.ID, TypeID: costType.ID, Amount: 500})
+	if err != nil || created.Type != costType {
+		t.Fatalf("Create with type error: %v", err)
+	}
+
+	// Create error branches
+	svcProjErr := projecta.NewBudgetService(budgetRepo, catRepo, typeRepo, &mockProjectRepo{findErr: errors.New("err")})
+	if _, err = svcProjErr.Create(ctx, projecta.CreateBudgetCommand{}); err == nil {
+		t.Errorf("expected error on project FindOne")
+	}
+
+	svcCatErr := projecta.NewBudgetService(budgetRepo, &mockCategoryRepo{findOneErr: errors.New("err")}, typeRepo, projRepo)
+	if _, err = svcCatErr.Create(ctx, projecta.CreateBudgetCommand{Amount: 1}); err == nil {
+		t.Errorf("expected error on category FindOne")
+	}
+
+	svcTypeErr := projecta.NewBudgetService(budgetRepo, catRepo, &mockTypeRepo{findOneErr: errors.New("err")}, projRepo)
+	if _, err = svcTypeErr.Create(ctx, projecta.CreateBudgetCommand{TypeID: uuid.New(), Amount: 1}); err == nil {
+		t.Errorf("expected error on type FindOne")
+	}
+
+	if _, err = svc.Create(ctx, projecta.CreateBudgetCommand{Amount: 0}); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error on zero amount, got %v", err)
+	}
+
+	svcSaveErr := projecta.NewBudgetService(&mockBudgetRepo{budget: budget, saveErr: errors.New("err")}, catRepo, typeRepo, projRepo)
+	if _, err = svcSaveErr.Create(ctx, projecta.CreateBudgetCommand{Amount: 1}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Save, got %v", err)
+	}
+
+	// Update
+	if err = svc.Update(ctx, projecta.UpdateBudgetCommand{ProjectID: proj.ProjectID, ID: budget.ID, CategoryID: cat.ID, Amount: 700}); err != nil {
+		t.Errorf("Update error: %v", err)
+	}
+	if err = svcProjErr.Update(ctx, projecta.UpdateBudgetCommand{}); err == nil {
+		t.Errorf("expected error on project FindOne")
+	}
+	if err = svcNotFound.Update(ctx, projecta.UpdateBudgetCommand{Amount: 1}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on Update, got %v", err)
+	}
+	if err = svcCatErr.Update(ctx, projecta.UpdateBudgetCommand{Amount: 1}); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error on category FindOne, got %v", err)
+	}
+	if err = svc.Update(ctx, projecta.UpdateBudgetCommand{Amount: -1}); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error on negative amount, got %v", err)
+	}
+	if err = svcSaveErr.Update(ctx, projecta.UpdateBudgetCommand{Amount: 1}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Save, got %v", err)
+	}
+
+	// Remove
+	if err = svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ResourceID: budget.ID, ProjectID: proj.ProjectID}); err != nil {
+		t.Errorf("Remove error: %v", err)
+	}
+	if err = svcNotFound.Remove(ctx, projecta.RemoveProjectResourceCommand{}); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on Remove, got %v", err)
+	}
+	svcRemErr := projecta.NewBudgetService(&mockBudgetRepo{budget: budget, removeErr: errors.New("err")}, catRepo, typeRepo, projRepo)
+	if err = svcRemErr.Remove(ctx, projecta.RemoveProjectResourceCommand{}); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Remove, got %v", err)
+	}
+
+	// Report
+	report, err := svc.Report(ctx, proj.ProjectID)
+	if err != nil || report.Project != proj || len(report.Lines) != 1 {
+		t.Errorf("Report error: %v", err)
+	}
+	svcProjNotFound := projecta.NewBudgetService(budgetRepo, catRepo, typeRepo, &mockProjectRepo{findErr: exceptions.NotFoundError})
+	if _, err = svcProjNotFound.Report(ctx, proj.ProjectID); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error on Report, got %v", err)
+	}
+	if _, err = svcProjErr.Report(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on Report, got %v", err)
+	}
+	svcReportErr := projecta.NewBudgetService(&mockBudgetRepo{reportErr: errors.New("err")}, catRepo, typeRepo, projRepo)
+	if _, err = svcReportErr.Report(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+		t.Errorf("expected internal error on repository Report, got %v", err)
+	}
+}
+
+func TestReportPeriods(t *testing.T) {
+	date := time.Date(2026, time.August, 17, 15, 4, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		period string
+		start  time.Time
+		label  string
+	}{
+		{"month", time.Date(2026, time.August, 1, 0, 0, 0, 0, time.UTC), "2026-08"},
+		{"quarter", time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC), "2026-Q3"},
+		{"year", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), "2026"},
+	} {
+		period, err := projecta.ToReportPeriod(tc.period)
+		if err != nil || period.String() != tc.period {
+			t.Fatalf("unexpected period %v, %v", period, err)
+		}
+		if start := period.Start(date); !start.Equal(tc.start) {
+			t.Errorf("expected %s to start on %v, got %v", tc.period, tc.start, start)
+		}
+		if label := period.Label(date); label != tc.label {
+			t.Errorf("expected %s label %s, got %s", tc.period, tc.label, label)
+		}
+	}
+
+	if _, err := projecta.ToReportPeriod("week"); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	for _, g := range []string{"category", "type", "kind", "owner"} {
+		if grouping, err := projecta.ToPaymentGrouping(g); err != nil || grouping.String() != g {
+			t.Errorf("unexpected grouping %v, %v", grouping, err)
+		}
+	}
+
+	if _, err := projecta.ToPaymentGrouping("vendor"); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	filter := projecta.PaymentTotalsFilter{GroupBy: []projecta.PaymentGrouping{projecta.GroupByOwner}}
+	if !filter.Groups(projecta.GroupByOwner) || filter.Groups(projecta.GroupByKind) {
+		t.Errorf("unexpected groupings %v", filter.GroupBy)
+	}
+}
+
+func TestCashFlow(t *testing.T) {
+	month := func(m time.Month) time.Time {
+		return time.Date(2026, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+	now := time.Date(2026, time.May, 15, 12, 0, 0, 0, time.UTC)
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	project, _ := projecta.NewProject(uuid.New(), "House", "", owner, time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, time.September, 30, 0, 0, 0, 0, time.UTC))
+
+	spending := map[time.Time]int64{
+		month(time.January):  100,
+		month(time.February): 200,
+		month(time.March):    300,
+		month(time.April):    400,
+		month(time.May):      50,
+		// a payment scheduled ahead
+		month(time.July): 1000,
+	}
+
+	t.Run("series, burn rate and projection", func(t *testing.T) {
+		cf := projecta.NewCashFlow(project, projecta.PeriodMonth, spending, 3, 3000, now)
+
+		if len(cf.Points) != 5 || !cf.Points[0].Start.Equal(month(time.January)) || cf.Points[4].Cumulative != 1050 || cf.Points[3].Amount != 400 {
+			t.Errorf("unexpected series %+v", cf.Points)
+		}
+		// May has spent 50 of the 300 it is expected to
+		if cf.Spent != 2050 || cf.BurnRate != 300 || cf.RemainingPeriods != 4 || cf.Projected != 3500 {
+			t.Errorf("unexpected forecast %+v", cf)
+		}
+		if !cf.Overrun() || !cf.ExhaustedAt.Equal(month(time.August)) {
+			t.Errorf("expected the budget to run out in August, got %v", cf.ExhaustedAt)
+		}
+	})
+
+	t.Run("current period already above the burn rate", func(t *testing.T) {
+		busy := map[time.Time]int64{month(time.February): 300, month(time.March): 300, month(time.April): 300, month(time.May): 500}
+		cf := projecta.NewCashFlow(project, projecta.PeriodMonth, busy, 3, 2600, now)
+
+		if cf.Projected != 2600 || cf.Overrun() || !cf.ExhaustedAt.Equal(month(time.September)) {
+			t.Errorf("unexpected forecast %+v", cf)
+		}
+
+		if cf = projecta.NewCashFlow(project, projecta.PeriodMonth, busy, 3, 1300, now); !cf.ExhaustedAt.Equal(month(time.May)) {
+			t.Errorf("expected the budget exhausted now, got %v", cf.ExhaustedAt)
+		}
+	})
+
+	t.Run("project already over", func(t *testing.T) {
+		over, _ := projecta.NewProject(uuid.New(), "Shed", "", owner, month(time.January), month(time.March))
+		cf := projecta.NewCashFlow(over, projecta.PeriodMonth, spending, 3, 0, now)
+
+		if cf.RemainingPeriods != 0 || cf.Projected != cf.Spent {
+			t.Errorf("expected nothing left to spend, got %+v", cf)
+		}
+	})
+
+	t.Run("budget lasts", func(t *testing.T) {
+		cf := projecta.NewCashFlow(project, projecta.PeriodMonth, spending, 0, 5000, now)
+
+		// the default window averages the same three months
+		if cf.BurnRate != 300 || cf.Overrun() || !cf.ExhaustedAt.IsZero() {
+			t.Errorf("unexpected forecast %+v", cf)
+		}
+
+		if cf = projecta.NewCashFlow(project, projecta.PeriodMonth, spending, 3, 0, now); cf.Overrun() || !cf.ExhaustedAt.IsZero() {
+			t.Errorf("expected no forecast without a plan, got %+v", cf)
+		}
+	})
+
+	t.Run("budget already spent", func(t *testing.T) {
+		cf := projecta.NewCashFlow(project, projecta.PeriodMonth, spending, 3, 1000, now)
+
+		if !cf.ExhaustedAt.Equal(month(time.May)) {
+			t.Errorf("expected the budget exhausted now, got %v", cf.ExhaustedAt)
+		}
+	})
+
+	t.Run("first period and open end", func(t *testing.T) {
+		fresh, _ := projecta.NewProject(uuid.New(), "Fresh", "", owner, time.Time{}, time.Time{})
+		cf := projecta.NewCashFlow(fresh, projecta.PeriodQuarter, map[time.Time]int64{month(time.April): 600}, 3, 10000, now)
+
+		if len(cf.Points) != 1 || cf.BurnRate != 600 || cf.RemainingPeriods != 0 || cf.Projected != 600 || !cf.ExhaustedAt.IsZero() {
+			t.Errorf("unexpected cash flow %+v", cf)
+		}
+	})
+
+	t.Run("nothing spent", func(t *testing.T) {
+		cf := projecta.NewCashFlow(project, projecta.PeriodYear, map[time.Time]int64{}, 3, 1000, now)
+
+		if len(cf.Points) != 1 || cf.Spent != 0 || cf.BurnRate != 0 || cf.RemainingPeriods != 0 || !cf.ExhaustedAt.IsZero() {
+			t.Errorf("unexpected cash flow %+v", cf)
+		}
+	})
+
+	t.Run("spending before the project start", func(t *testing.T) {
+		cf := projecta.NewCashFlow(project, projecta.PeriodMonth, map[time.Time]int64{month(time.January).AddDate(0, -2, 0): 10}, 3, 0, now)
+
+		if len(cf.Points) != 7 || cf.Points[0].Amount != 10 {
+			t.Errorf("expected the series to start with the first spending, got %+v", cf.Points)
+		}
+	})
+}
+
+type mockSettlementRepo struct {
+	weights       []*projecta.SettlementWeight
+	transfers     []*projecta.SettlementTransfer
+	findErr       error
+	saveErr       error
+	removeErr     error
+	savedWeight   *projecta.SettlementWeight
+	savedTransfer *projecta.SettlementTransfer
+}
+
+func (m *mockSettlementRepo) FindWeights(ctx context.Context, projectID uuid.UUID) ([]*projecta.SettlementWeight, error) {
+	return m.weights, m.findErr
+}
+func (m *mockSettlementRepo) SaveWeight(ctx context.Context, weight *projecta.SettlementWeight) error {
+	m.savedWeight = weight
+	return m.saveErr
+}
+func (m *mockSettlementRepo) FindTransfers(ctx context.Context, projectID uuid.UUID) ([]*projecta.SettlementTransfer, error) {
+	return m.transfers, m.findErr
+}
+func (m *mockSettlementRepo) SaveTransfer(ctx context.Context, transfer *projecta.SettlementTransfer) error {
+	m.savedTransfer = transfer
+	return m.saveErr
+}
+func (m *mockSettlementRepo) RemoveTransfer(ctx context.Context, projectID uuid.UUID, transferID uuid.UUID) error {
+	return m.removeErr
+}
+
+func TestSettlement(t *testing.T) {
+	alice := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Alice"}
+	bob := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Bob"}
+	carol := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Carol"}
+	owner := func(m *projecta.ProjectMember) *projecta.Owner {
+		return &projecta.Owner{PersonID: m.PersonID, DisplayName: m.DisplayName}
+	}
+	projectID := uuid.New()
+
+	t.Run("NewSettlementWeight and NewSettlementTransfer validate their input", func(t *testing.T) {
+		for _, w := range []float64{-1, math.NaN(), math.Inf(1)} {
+			if _, err := projecta.NewSettlementWeight(projectID, alice.PersonID, w); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("expected validation error for weight %v, got %v", w, err)
+			}
+		}
+
+		for name, args := range map[string]struct {
+			from, to *projecta.Owner
+			amount   *money.Money
+		}{
+			"no sender":        {nil, owner(bob), money.New(1, money.UAH)},
+			"same person":      {owner(alice), owner(alice), money.New(1, money.UAH)},
+			"zero amount":      {owner(alice), owner(bob), money.New(0, money.UAH)},
+			"unknown currency": {owner(alice), owner(bob), money.New(1, "XXXX")},
+		} {
+			if _, err := projecta.NewSettlementTransfer(uuid.New(), projectID, args.from, args.to, args.amount, time.Now(), ""); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("equal split hands the remainder out and settles with the biggest creditor", func(t *testing.T) {
+		s := projecta.NewSettlement([]*projecta.ProjectMember{alice, bob, carol}, nil)
+		s.AddPaid(owner(alice), 100)
+
+		if err := s.Settle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if s.Total != 100 || s.Balances[0].Share+s.Balances[1].Share+s.Balances[2].Share != 100 || s.Balances[0].Share != 34 || s.Balances[1].Share != 33 {
+			t.Errorf("unexpected shares %+v %+v %+v", s.Balances[0], s.Balances[1], s.Balances[2])
+		}
+		if len(s.Transfers) != 2 || s.Transfers[0].To.PersonID != alice.PersonID || s.Transfers[0].Amount != 33 || s.Transfers[1].Amount != 33 {
+			t.Errorf("unexpected transfers %+v", s.Transfers)
+		}
+	})
+
+	t.Run("weights, recorded transfers and former members", func(t *testing.T) {
+		double, _ := projecta.NewSettlementWeight(projectID, carol.PersonID, 2)
+		dave := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Dave"}
+
+		s := projecta.NewSettlement([]*projecta.ProjectMember{alice, bob, carol}, []*projecta.SettlementWeight{double})
+		s.AddPaid(owner(alice), 600)
+		s.AddPaid(dave, 200)
+		s.AddTransfer(owner(carol), owner(alice), 100)
+
+		if err := s.Settle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		balances := map[uuid.UUID]int64{}
+		for _, b := range s.Balances {
+			balances[b.PersonID] = b.Balance()
+		}
+
+		if balances[alice.PersonID] != 300 || balances[bob.PersonID] != -200 || balances[carol.PersonID] != -300 || balances[dave.PersonID] != 200 {
+			t.Errorf("unexpected balances %v", balances)
+		}
+		if len(s.Transfers) != 2 || s.Transfers[0].From.PersonID != carol.PersonID || s.Transfers[0].To.PersonID != alice.PersonID || s.Transfers[0].Amount != 300 {
+			t.Errorf("unexpected transfers %+v", s.Transfers)
+		}
+
+		var settled int64
+		for _, tr := range s.Transfers {
+			settled += tr.Amount
+		}
+		if settled != 500 {
+			t.Errorf("expected transfers to settle 500, got %d", settled)
+		}
+	})
+
+	t.Run("nobody bearing the costs", func(t *testing.T) {
+		zero, _ := projecta.NewSettlementWeight(projectID, alice.PersonID, 0)
+
+		s := projecta.NewSettlement([]*projecta.ProjectMember{alice}, []*projecta.SettlementWeight{zero})
+		if err := s.Settle(); err != nil || len(s.Transfers) != 0 {
+			t.Errorf("expected nothing to settle, got %v", err)
+		}
+
+		s.AddPaid(owner(alice), 10)
+		if err := s.Settle(); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+}
+
+func TestSettlementService(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	alice := &projecta.ProjectMember{PersonID: owner.PersonID, DisplayName: "Alice", Role: projecta.RoleOwner}
+	bob := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Bob", Role: projecta.RoleEditor}
+	members := []*projecta.ProjectMember{alice, bob}
+	dbErr := errors.New("db error")
+
+	transferCmd := projecta.RecordSettlementTransferCommand{
+		ProjectID: proj.ProjectID,
+		FromID:    bob.PersonID,
+		ToID:      alice.PersonID,
+		Amount:    money.New(500, money.UAH),
+		Date:      time.Now(),
+	}
+	weightCmd := projecta.SetSettlementWeightCommand{ProjectID: proj.ProjectID, PersonID: bob.PersonID, Weight: 2}
+	removeCmd := projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: uuid.New()}
+
+	t.Run("success", func(t *testing.T) {
+		repo := &mockSettlementRepo{}
+		svc := projecta.NewSettlementService(repo, &mockProjectRepo{project: proj, members: members})
+
+		ledger, err := svc.Ledger(ctx, proj.ProjectID)
+		if err != nil || ledger.Project != proj || len(ledger.Members) != 2 {
+			t.Fatalf("unexpected ledger %v, %v", ledger, err)
+		}
+
+		weight, err := svc.SetWeight(ctx, weightCmd)
+		if err != nil || repo.savedWeight != weight || weight.Weight != 2 {
+			t.Errorf("unexpected weight %v, %v", weight, err)
+		}
+
+		transfer, err := svc.RecordTransfer(ctx, transferCmd)
+		if err != nil || repo.savedTransfer != transfer || transfer.From.DisplayName != "Bob" || transfer.To.DisplayName != "Alice" {
+			t.Errorf("unexpected transfer %v, %v", transfer, err)
+		}
+
+		if err = svc.RemoveTransfer(ctx, removeCmd); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("validation and membership", func(t *testing.T) {
+		svc := projecta.NewSettlementService(&mockSettlementRepo{}, &mockProjectRepo{project: proj, members: members})
+
+		if _, err := svc.SetWeight(ctx, projecta.SetSettlementWeightCommand{ProjectID: proj.ProjectID, PersonID: bob.PersonID, Weight: -1}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err := svc.SetWeight(ctx, projecta.SetSettlementWeightCommand{ProjectID: proj.ProjectID, PersonID: uuid.New()}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		stranger := transferCmd
+		stranger.FromID = uuid.New()
+		if _, err := svc.RecordTransfer(ctx, stranger); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		stranger = transferCmd
+		stranger.ToID = uuid.New()
+		if _, err := svc.RecordTransfer(ctx, stranger); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		self := transferCmd
+		self.ToID = self.FromID
+		if _, err := svc.RecordTransfer(ctx, self); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		editor := *proj
+		editor.Role = projecta.RoleEditor
+		svc := projecta.NewSettlementService(&mockSettlementRepo{}, &mockProjectRepo{project: &editor, members: members})
+
+		if _, err := svc.SetWeight(ctx, weightCmd); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected editors not to set weights, got %v", err)
+		}
+		if _, err := svc.RecordTransfer(ctx, transferCmd); err != nil {
+			t.Errorf("expected editors to record transfers, got %v", err)
+		}
+
+		viewer := *proj
+		viewer.Role = projecta.RoleViewer
+		svc = projecta.NewSettlementService(&mockSettlementRepo{}, &mockProjectRepo{project: &viewer, members: members})
+
+		if _, err := svc.Ledger(ctx, proj.ProjectID); err != nil {
+			t.Errorf("expected viewers to see the settlement, got %v", err)
+		}
+		if _, err := svc.SetWeight(ctx, weightCmd); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected forbidden error, got %v", err)
+		}
+		if _, err := svc.RecordTransfer(ctx, transferCmd); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected forbidden error, got %v", err)
+		}
+		if err := svc.RemoveTransfer(ctx, removeCmd); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected forbidden error, got %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		svc := projecta.NewSettlementService(&mockSettlementRepo{}, &mockProjectRepo{findErr: exceptions.NotFoundError})
+		if _, err := svc.Ledger(ctx, proj.ProjectID); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		svc = projecta.NewSettlementService(&mockSettlementRepo{}, &mockProjectRepo{findErr: dbErr})
+		if _, err := svc.Ledger(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		svc = projecta.NewSettlementService(&mockSettlementRepo{}, &mockProjectRepo{project: proj, membersErr: dbErr})
+		if _, err := svc.Ledger(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if _, err := svc.SetWeight(ctx, weightCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		svc = projecta.NewSettlementService(&mockSettlementRepo{findErr: dbErr, saveErr: dbErr, removeErr: dbErr}, &mockProjectRepo{project: proj, members: members})
+		if _, err := svc.Ledger(ctx, proj.ProjectID); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if _, err := svc.SetWeight(ctx, weightCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if _, err := svc.RecordTransfer(ctx, transferCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if err := svc.RemoveTransfer(ctx, removeCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		svc = projecta.NewSettlementService(&mockSettlementRepo{removeErr: exceptions.NotFoundError}, &mockProjectRepo{project: proj, members: members})
+		if err := svc.RemoveTransfer(ctx, removeCmd); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+}
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		currency string
+		expected int64
+	}{
+		{"1500", money.UAH, 150000},
+		{"1 500,50", money.UAH, 150050},
+		{"1,500.5", "usd", 150050},
+		{"1.500.000", money.EUR, 150000000},
+		{"1,500", money.UAH, 150000},
+		{"-12.34", money.UAH, -1234},
+		{",5", money.UAH, 50},
+		{"1'000", money.JPY, 1000},
+		{"1,500", "KWD", 1500},
+	} {
+		m, err := projecta.ParseMoney(tc.value, tc.currency)
+		if err != nil || m.Amount() != tc.expected {
+			t.Errorf("ParseMoney(%q, %s) = %v, %v, want %d", tc.value, tc.currency, m, err, tc.expected)
+		}
+	}
+
+	for _, value := range []string{"", "-", "abc", "1.5x", "1.2345", "1,50,0", "1..500", "1234,567.5", "99999999999999999999"} {
+		if _, err := projecta.ParseMoney(value, money.UAH); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for %q, got %v", value, err)
+		}
+	}
+
+	if _, err := projecta.ParseMoney("1", "XXX"); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error for unknown currency, got %v", err)
+	}
+}
+
+type mockImportDb struct {
+	err error
+	txs int
+}
+
+func (m *mockImportDb) Tx(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
+	m.txs++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return fn(ctx)
+}
+func (m *mockImportDb) Close()                         {}
+func (m *mockImportDb) Ping(ctx context.Context) error { return nil }
+
+func TestPaymentImportService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	materials, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Materials", "")
+	works, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Works", "")
+	tiles, _ := projecta.NewCostType(proj.ProjectID, materials, "Tiles", "")
+	tilesLabour, _ := projecta.NewCostType(proj.ProjectID, works, "Tiles", "")
+	paint, _ := projecta.NewCostType(proj.ProjectID, materials, "Paint", "")
+	dbErr := errors.New("db error")
+
+	type deps struct {
+		db         *mockImportDb
+		payments   *mockPaymentRepo
+		categories *mockCategoryRepo
+		types      *mockTypeRepo
+	}
+
+	newService := func() (*projecta.PaymentImportServiceImpl, deps) {
+		d := deps{
+			db:         &mockImportDb{},
+			payments:   &mockPaymentRepo{},
+			categories: &mockCategoryRepo{cats: []*projecta.CostCategory{materials, works}},
+			types:      &mockTypeRepo{types: []*projecta.CostType{tiles, tilesLabour, paint}},
+		}
+		svc := projecta.NewPaymentImportService(
+			d.db,
+			d.payments,
+			d.categories,
+			d.types,
+			&mockProjectRepo{project: proj},
+			&mockPeopleService{owner: owner},
+			&mockExchangeRates{rate: 40},
+			nil,
+		)
+		return svc, d
+	}
+
+	rows := []projecta.PaymentImportRow{
+		{Line: 2, Date: "2024-03-05", Amount: "1 500,50", Type: " paint ", Description: " Walls "},
+		{Line: 3, Date: "05.03.2024", Amount: "100", Currency: "usd", Type: "Tiles", Category: "works", Kind: "down_payment"},
+		{Line: 4, Date: "2024-03-06", Amount: "20", Type: "Grout", Category: "Consumables"},
+		{Line: 5, Date: "2024-03-07", Amount: "5", Type: "Grout", Category: "Consumables"},
+	}
+
+	t.Run("dry run reports the would-be payments and new types", func(t *testing.T) {
+		svc, d := newService()
+
+		report, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: rows, CreateMissingTypes: true, DryRun: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Committed || report.Invalid() != 0 || len(report.Lines) != 4 || d.db.txs != 0 || len(d.payments.saved) != 0 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		first := report.Lines[0].Payment
+		if first.Type != paint || first.Amount.Amount() != 150050 || first.Amount.Currency().Code != money.UAH || first.Description != "Walls" || first.Kind != projecta.UponCompletionPayment || first.Owner != owner {
+			t.Errorf("unexpected payment %+v", first)
+		}
+
+		second := report.Lines[1].Payment
+		if second.Type != tilesLabour || second.Kind != projecta.DownPayment || second.HomeAmount.Amount() != 400000 {
+			t.Errorf("unexpected payment %+v", second)
+		}
+
+		if len(report.NewCategories) != 1 || report.NewCategories[0].Name != "Consumables" || len(report.NewTypes) != 1 {
+			t.Fatalf("expected one new category and type, got %v %v", report.NewCategories, report.NewTypes)
+		}
+		if report.Lines[2].Payment.Type != report.Lines[3].Payment.Type || !report.Lines[2].NewType || !report.Lines[3].NewType || report.Lines[0].NewType {
+			t.Error("expected the rows to share the created type")
+		}
+	})
+
+	t.Run("commits every row in one transaction", func(t *testing.T) {
+		svc, d := newService()
+
+		report, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: rows, CreateMissingTypes: true})
+		if err != nil || !report.Committed {
+			t.Fatalf("unexpected result %+v, %v", report, err)
+		}
+		if d.db.txs != 1 || len(d.payments.saved) != 4 {
+			t.Errorf("expected 4 payments saved in one transaction, got %d in %d", len(d.payments.saved), d.db.txs)
+		}
+	})
+
+	t.Run("rows with errors keep everything from being saved", func(t *testing.T) {
+		svc, d := newService()
+
+		report, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: []projecta.PaymentImportRow{
+			rows[0],
+			{Line: 3, Date: "yesterday", Amount: "-5", Type: "Tiles", Kind: "LATER"},
+			{Line: 4, Date: "2024-03-05", Amount: "1", Currency: "XXX", Type: "Grout", Category: "Materials"},
+			{Line: 5, Date: "2024-03-05", Amount: "1", Type: "Grout"},
+			{Line: 6, Date: "2024-03-05", Amount: "abc", Type: ""},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Committed || d.db.txs != 0 || len(d.payments.saved) != 0 || report.Invalid() != 4 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if errs := report.Lines[1].Errors; len(errs) != 4 {
+			t.Errorf("expected date, amount, kind and ambiguous type errors, got %v", errs)
+		}
+		if errs := report.Lines[2].Errors; len(errs) != 2 {
+			t.Errorf("expected currency and unknown type errors, got %v", errs)
+		}
+		if errs := report.Lines[4].Errors; len(errs) != 2 {
+			t.Errorf("expected amount and missing type errors, got %v", errs)
+		}
+	})
+
+	t.Run("missing types need a valid category to be created", func(t *testing.T) {
+		svc, _ := newService()
+
+		report, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, CreateMissingTypes: true, Rows: []projecta.PaymentImportRow{
+			{Line: 2, Date: "2024-03-05", Amount: "1", Type: "Grout"},
+			{Line: 3, Date: "2024-03-05", Amount: "1", Type: "Grout", Category: "X"},
+			{Line: 4, Date: "2024-03-05", Amount: "1", Type: "Grout", Category: "materials"},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Invalid() != 2 || len(report.NewCategories) != 0 || len(report.NewTypes) != 1 || report.NewTypes[0].Category != materials {
+			t.Errorf("unexpected report %+v", report)
+		}
+	})
+
+	t.Run("conversion errors are reported per row", func(t *testing.T) {
+		svc := projecta.NewPaymentImportService(&mockImportDb{}, &mockPaymentRepo{}, &mockCategoryRepo{}, &mockTypeRepo{types: []*projecta.CostType{paint}},
+			&mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, &mockExchangeRates{rate: -1}, nil)
+
+		report, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: []projecta.PaymentImportRow{
+			{Line: 2, Date: "2024-03-05", Amount: "1", Currency: money.USD, Type: "Paint"},
+		}})
+		if err != nil || report.Invalid() != 1 {
+			t.Errorf("expected the conversion error on the row, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		command := projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: rows, CreateMissingTypes: true}
+
+		svc, _ := newService()
+		if _, err := svc.Import(context.Background(), command); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error without requester, got %v", err)
+		}
+		if _, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for no rows, got %v", err)
+		}
+		if _, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: make([]projecta.PaymentImportRow, projecta.MaxPaymentImportRows+1)}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for too many rows, got %v", err)
+		}
+
+		missing := projecta.NewPaymentImportService(&mockImportDb{}, &mockPaymentRepo{}, &mockCategoryRepo{}, &mockTypeRepo{},
+			&mockProjectRepo{findErr: exceptions.NewNotFoundException("not found", nil)}, &mockPeopleService{owner: owner}, nil, nil)
+		if _, err := missing.Import(ctx, command); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		noOwner := projecta.NewPaymentImportService(&mockImportDb{}, &mockPaymentRepo{}, &mockCategoryRepo{}, &mockTypeRepo{},
+			&mockProjectRepo{project: proj}, &mockPeopleService{err: dbErr}, nil, nil)
+		if _, err := noOwner.Import(ctx, command); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		for name, d := range map[string]deps{
+			"types":      {types: &mockTypeRepo{findErr: dbErr}},
+			"categories": {categories: &mockCategoryRepo{findErr: dbErr}},
+		} {
+			types, categories := d.types, d.categories
+			if types == nil {
+				types = &mockTypeRepo{}
+			}
+			if categories == nil {
+				categories = &mockCategoryRepo{}
+			}
+			svc := projecta.NewPaymentImportService(&mockImportDb{}, &mockPaymentRepo{}, categories, types,
+				&mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, nil, nil)
+			if _, err := svc.Import(ctx, command); !hasCode(err, exceptions.Internal) {
+				t.Errorf("expected internal error when finding %s fails, got %v", name, err)
+			}
+		}
+
+		for name, d := range map[string]deps{
+			"transaction": {db: &mockImportDb{err: dbErr}},
+			"category":    {categories: &mockCategoryRepo{cats: []*projecta.CostCategory{}, saveErr: dbErr}},
+			"type":        {types: &mockTypeRepo{types: []*projecta.CostType{tiles, paint}, saveErr: dbErr}},
+			"payment":     {payments: &mockPaymentRepo{saveErr: dbErr}},
+		} {
+			svc, defaults := newService()
+			if d.db == nil {
+				d.db = defaults.db
+			}
+			if d.payments == nil {
+				d.payments = defaults.payments
+			}
+			if d.categories == nil {
+				d.categories = defaults.categories
+			}
+			if d.types == nil {
+				d.types = defaults.types
+			}
+			svc = projecta.NewPaymentImportService(d.db, d.payments, d.categories, d.types,
+				&mockProjectRepo{project: proj}, &mockPeopleService{owner: owner}, nil, nil)
+			if _, err := svc.Import(ctx, projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, Rows: rows[2:], CreateMissingTypes: true}); err == nil {
+				t.Errorf("expected an error when saving the %s fails", name)
+			}
+		}
+	})
+}
+
+func TestRecurrence(t *testing.T) {
+	for rule, expected := range map[string]projecta.Recurrence{
+		"FREQ=DAILY":                              {Frequency: projecta.Daily, Interval: 1},
+		"rrule:freq=weekly;interval=2":            {Frequency: projecta.Weekly, Interval: 2},
+		" RRULE:FREQ=MONTHLY;INTERVAL=3;COUNT=4 ": {Frequency: projecta.Monthly, Interval: 3, Count: 4},
+		"COUNT=2;FREQ=YEARLY":                     {Frequency: projecta.Yearly, Interval: 1, Count: 2},
+	} {
+		if r, err := projecta.ParseRecurrence(rule); err != nil || r != expected {
+			t.Errorf("ParseRecurrence(%q) = %+v, %v", rule, r, err)
+		}
+	}
+
+	for _, rule := range []string{"", "FREQ", "FREQ=HOURLY", "INTERVAL=2", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;COUNT=x", "FREQ=WEEKLY;BYDAY=MO"} {
+		if _, err := projecta.ParseRecurrence(rule); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("ParseRecurrence(%q): expected validation error, got %v", rule, err)
+		}
+	}
+
+	if s := (projecta.Recurrence{Frequency: projecta.Monthly, Interval: 3, Count: 4}).String(); s != "FREQ=MONTHLY;INTERVAL=3;COUNT=4" {
+		t.Errorf("unexpected rule %s", s)
+	}
+
+	if s := (projecta.Recurrence{Frequency: projecta.Weekly, Interval: 1}).String(); s != "FREQ=WEEKLY;INTERVAL=1" {
+		t.Errorf("unexpected rule %s", s)
+	}
+
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		recurrence projecta.Recurrence
+		start      time.Time
+		n          int
+		expected   time.Time
+	}{
+		{projecta.Recurrence{Frequency: projecta.Daily, Interval: 2}, day(2026, time.March, 30), 1, day(2026, time.April, 1)},
+		{projecta.Recurrence{Frequency: projecta.Weekly, Interval: 1}, day(2026, time.March, 2), 2, day(2026, time.March, 16)},
+		{projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}, day(2026, time.January, 31), 1, day(2026, time.February, 28)},
+		{projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}, day(2026, time.January, 31), 2, day(2026, time.March, 31)},
+		{projecta.Recurrence{Frequency: projecta.Monthly, Interval: 3}, day(2026, time.January, 31), 1, day(2026, time.April, 30)},
+		{projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}, day(2026, time.November, 15), 2, day(2027, time.January, 15)},
+		{projecta.Recurrence{Frequency: projecta.Yearly, Interval: 1}, day(2024, time.February, 29), 1, day(2025, time.February, 28)},
+		{projecta.Recurrence{Frequency: projecta.Yearly, Interval: 1}, day(2024, time.February, 29), 4, day(2028, time.February, 29)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.recurrence.Occurrence(tt.start, tt.n); !got.Equal(tt.expected) {
+			t.Errorf("%s from %v, occurrence %d: expected %v, got %v", tt.recurrence, tt.start, tt.n, tt.expected, got)
+		}
+	}
+}
+
+func TestRecurringPayment(t *testing.T) {
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	category, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Services", "")
+	rent, _ := projecta.NewCostType(proj.ProjectID, category, "Rent", "")
+	monthly := projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}
+	start := time.Date(2026, time.January, 31, 15, 30, 0, 0, time.UTC)
+
+	build := func(amount *money.Money, kind projecta.PaymentKind, costType *projecta.CostType, start, end time.Time) (*projecta.RecurringPayment, error) {
+		return projecta.NewRecurringPayment(uuid.New(), proj, owner, costType, " Rent ", amount, kind, monthly, start, end)
+	}
+
+	for name, tt := range map[string]struct {
+		amount   *money.Money
+		kind     projecta.PaymentKind
+		costType *projecta.CostType
+		start    time.Time
+		end      time.Time
+	}{
+		"no type":          {money.New(100, money.UAH), projecta.UponCompletionPayment, nil, start, time.Time{}},
+		"no amount":        {money.New(0, money.UAH), projecta.UponCompletionPayment, rent, start, time.Time{}},
+		"unknown currency": {money.New(100, "XXXX"), projecta.UponCompletionPayment, rent, start, time.Time{}},
+		"invalid kind":     {money.New(100, money.UAH), "LATER", rent, start, time.Time{}},
+		"no start":         {money.New(100, money.UAH), projecta.UponCompletionPayment, rent, time.Time{}, time.Time{}},
+		"end before start": {money.New(100, money.UAH), projecta.UponCompletionPayment, rent, start, start.AddDate(0, 0, -1)},
+	} {
+		if _, err := build(tt.amount, tt.kind, tt.costType, tt.start, tt.end); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+
+	p, err := build(money.New(1500000, money.UAH), projecta.UponCompletionPayment, rent, start, time.Date(2026, time.April, 30, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Description != "Rent" || !p.StartDate.Equal(time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)) || !p.EndDate.Equal(time.Date(2026, time.April, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected recurring payment %+v", p)
+	}
+
+	if next, ok := p.NextOccurrence(); !ok || !next.Equal(p.StartDate) {
+		t.Errorf("expected the start date to be the first occurrence, got %v, %v", next, ok)
+	}
+
+	if date, ok := p.Occurrence(3); !ok || projecta.OccurrenceKey(date) != "2026-04-30" {
+		t.Errorf("expected the last occurrence on the end date, got %v, %v", date, ok)
+	}
+
+	if _, ok := p.Occurrence(4); ok {
+		t.Error("expected no occurrence after the end date")
+	}
+
+	if _, ok := p.Occurrence(-1); ok {
+		t.Error("expected no occurrence before the start")
+	}
+
+	p.Recurrence.Count = 2
+	if _, ok := p.Occurrence(2); ok {
+		t.Error("expected no occurrence past the count")
+	}
+	p.Recurrence.Count = 0
+
+	p.Processed = 1
+	if err = p.EnsurePending(time.Date(2026, time.February, 28, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("expected the next occurrence to be pending, got %v", err)
+	}
+	if err = p.EnsurePending(time.Date(2026, time.April, 30, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("expected a later occurrence to be pending, got %v", err)
+	}
+	for _, date := range []time.Time{p.StartDate, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.May, 31, 0, 0, 0, 0, time.UTC)} {
+		if err = p.EnsurePending(date); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("%v: expected validation error, got %v", date, err)
+		}
+	}
+
+	march := time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)
+	payment := p.Payment(uuid.New(), march, nil)
+	if payment.Amount != p.Amount || payment.Description != "Rent" || !payment.Date.Equal(march) || payment.Type != rent || payment.Owner != owner || payment.Project != proj {
+		t.Errorf("unexpected payment %+v", payment)
+	}
+
+	paidOn := time.Date(2026, time.April, 2, 10, 0, 0, 0, time.UTC)
+	payment = p.Payment(uuid.New(), march, &projecta.RecurringOccurrence{Status: projecta.OccurrenceEdited, Amount: money.New(1600000, money.UAH), Description: "Rent and utilities", PaymentDate: paidOn})
+	if payment.Amount.Amount() != 1600000 || payment.Description != "Rent and utilities" || !payment.Date.Equal(paidOn) {
+		t.Errorf("unexpected edited payment %+v", payment)
+	}
+
+	skipped := &projecta.RecurringOccurrence{RecurringID: p.ID, Date: march, Status: projecta.OccurrenceSkipped}
+	upcoming := p.Upcoming(12, []*projecta.RecurringOccurrence{skipped})
+	if len(upcoming) != 3 || upcoming[0].Status != projecta.OccurrencePending || upcoming[1] != skipped || projecta.OccurrenceKey(upcoming[2].Date) != "2026-04-30" {
+		t.Errorf("unexpected upcoming occurrences %v", upcoming)
+	}
+	if upcoming = p.Upcoming(1, nil); len(upcoming) != 1 {
+		t.Errorf("expected the upcoming occurrences to be limited, got %v", upcoming)
+	}
+
+	paymentID := uuid.New()
+	skipped.Book(paymentID)
+	if skipped.Status != projecta.OccurrenceBooked || skipped.PaymentID != paymentID {
+		t.Errorf("unexpected booked occurrence %+v", skipped)
+	}
+
+	for value, expected := range map[string]projecta.OccurrenceStatus{"pending": projecta.OccurrencePending, "EDITED": projecta.OccurrenceEdited, "Skipped": projecta.OccurrenceSkipped, "booked": projecta.OccurrenceBooked} {
+		if status, err := projecta.ToOccurrenceStatus(value); err != nil || status != expected || status.String() != strings.ToUpper(value) {
+			t.Errorf("ToOccurrenceStatus(%q) = %v, %v", value, status, err)
+		}
+	}
+	if _, err = projecta.ToOccurrenceStatus("lost"); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	if date, err := projecta.ParseOccurrenceDate("2026-03-31"); err != nil || !date.Equal(march) {
+		t.Errorf("unexpected date %v, %v", date, err)
+	}
+	if _, err = projecta.ParseOccurrenceDate("31.03.2026"); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	if col := projecta.NewRecurringPaymentCollection(2); col.Total() != 2 {
+		t.Errorf("recurring payment collection total mismatch")
+	}
+}
+
+type mockRecurringRepo struct {
+	payment           *projecta.RecurringPayment
+	due               []*projecta.RecurringPayment
+	occurrences       []*projecta.RecurringOccurrence
+	findErr           error
+	findOneErr        error
+	dueErr            error
+	saveErr           error
+	removeErr         error
+	occurrencesErr    error
+	saveOccurrenceErr error
+	lockErr           error
+	busy              bool
+	requesters        []uuid.UUID
+	saved             []*projecta.RecurringPayment
+	savedOccurrences  []*projecta.RecurringOccurrence
+}
+
+func (m *mockRecurringRepo) Find(ctx context.Context, filter projecta.RecurringPaymentCollectionFilter) (*projecta.RecurringPaymentCollection, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	col := projecta.NewRecurringPaymentCollection(1)
+	col.Add(m.payment)
+	return col, nil
+}
+func (m *mockRecurringRepo) FindOne(ctx context.Context, filter projecta.RecurringPaymentFilter) (*projecta.RecurringPayment, error) {
+	if id, ok := ctx.Value(core.RequesterIDContextKey).(uuid.UUID); ok {
+		m.requesters = append(m.requesters, id)
+	}
+	if m.findOneErr != nil {
+		return nil, m.findOneErr
+	}
+	return m.payment, nil
+}
+func (m *mockRecurringRepo) FindDue(ctx context.Context, until time.Time) ([]*projecta.RecurringPayment, error) {
+	return m.due, m.dueErr
+}
+func (m *mockRecurringRepo) Save(ctx context.Context, p *projecta.RecurringPayment) error {
+	if m.saveErr == nil {
+		m.saved = append(m.saved, p)
+	}
+	return m.saveErr
+}
+func (m *mockRecurringRepo) Remove(ctx context.Context, p *projecta.RecurringPayment) error {
+	return m.removeErr
+}
+func (m *mockRecurringRepo) FindOccurrences(ctx context.Context, recurringID uuid.UUID, from time.Time) ([]*projecta.RecurringOccurrence, error) {
+	return m.occurrences, m.occurrencesErr
+}
+func (m *mockRecurringRepo) SaveOccurrence(ctx context.Context, occurrence *projecta.RecurringOccurrence) error {
+	if m.saveOccurrenceErr == nil {
+		m.savedOccurrences = append(m.savedOccurrences, occurrence)
+	}
+	return m.saveOccurrenceErr
+}
+func (m *mockRecurringRepo) Lock(ctx context.Context, recurringID uuid.UUID) (bool, error) {
+	return !m.busy, m.lockErr
+}
+
+type recordingLogger struct {
+	infos  int
+	errors []error
+}
+
+func (l *recordingLogger) Info(string, map[string]any) {
+	l.infos++
+}
+
+func (l *recordingLogger) Error(_ string, err error, _ map[string]any) {
+	l.errors = append(l.errors, err)
+}
+
+func TestRecurringPaymentService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	category, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Services", "")
+	rent, _ := projecta.NewCostType(proj.ProjectID, category, "Rent", "")
+	dbErr := errors.New("db error")
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, time.April, 15, 12, 0, 0, 0, time.UTC)
+
+	newSchedule := func() *projecta.RecurringPayment {
+		p, _ := projecta.NewRecurringPayment(uuid.New(), proj, owner, rent, "Rent", money.New(1000, money.USD), projecta.UponCompletionPayment, projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}, start, time.Time{})
+		return p
+	}
+
+	type deps struct {
+		db        *mockImportDb
+		recurring *mockRecurringRepo
+		payments  *mockPaymentRepo
+		projects  *mockProjectRepo
+		types     *mockTypeRepo
+		people    *mockPeopleService
+	}
+
+	newService := func(schedule *projecta.RecurringPayment) (*projecta.RecurringPaymentServiceImpl, deps) {
+		d := deps{
+			db:        &mockImportDb{},
+			recurring: &mockRecurringRepo{payment: schedule},
+			payments:  &mockPaymentRepo{},
+			projects:  &mockProjectRepo{project: proj},
+			types:     &mockTypeRepo{costType: rent},
+			people:    &mockPeopleService{owner: owner},
+		}
+		svc := projecta.NewRecurringPaymentService(d.db, d.recurring, d.payments, d.types, d.projects, d.people, &mockExchangeRates{rate: 40}, nil)
+		return svc, d
+	}
+
+	createCmd := projecta.CreateRecurringPaymentCommand{
+		ProjectID:   proj.ProjectID,
+		TypeID:      rent.ID,
+		Description: "Rent",
+		Amount:      money.New(1000, money.USD),
+		Kind:        projecta.UponCompletionPayment,
+		Recurrence:  projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1},
+		StartDate:   start,
+	}
+
+	t.Run("create", func(t *testing.T) {
+		svc, d := newService(nil)
+
+		p, err := svc.Create(ctx, createCmd)
+		if err != nil || p.Owner != owner || p.Type != rent || p.Project != proj || len(d.recurring.saved) != 1 {
+			t.Fatalf("unexpected recurring payment %v, %v", p, err)
+		}
+
+		if _, err = svc.Create(context.Background(), createCmd); !hasCode(err, exceptions.Unauthorized) {
+			t.Errorf("expected unauthorized error, got %v", err)
+		}
+
+		invalid := createCmd
+		invalid.Amount = money.New(0, money.USD)
+		if _, err = svc.Create(ctx, invalid); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		for name, tt := range map[string]struct {
+			code  exceptions.ErrorCode
+			setup func(d deps)
+		}{
+			"project not found": {exceptions.NotFound, func(d deps) { d.projects.findErr = exceptions.NewNotFoundException("not found", nil) }},
+			"owner error":       {exceptions.Internal, func(d deps) { d.people.err = dbErr }},
+			"type error":        {exceptions.ValidationFailed, func(d deps) { d.types.findOneErr = dbErr }},
+			"save error":        {exceptions.Internal, func(d deps) { d.recurring.saveErr = dbErr }},
+		} {
+			svc, d := newService(nil)
+			tt.setup(d)
+
+			if _, err = svc.Create(ctx, createCmd); !hasCode(err, tt.code) {
+				t.Errorf("%s: expected %v, got %v", name, tt.code, err)
+			}
+		}
+	})
+
+	t.Run("find and remove", func(t *testing.T) {
+		schedule := newSchedule()
+		svc, d := newService(schedule)
+		filter := projecta.RecurringPaymentFilter{ProjectID: proj.ProjectID, RecurringID: schedule.ID}
+		remove := projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: schedule.ID}
+
+		if col, err := svc.Find(ctx, projecta.RecurringPaymentCollectionFilter{ProjectID: proj.ProjectID}); err != nil || col.Elements()[0] != schedule {
+			t.Errorf("unexpected collection %v, %v", col, err)
+		}
+
+		if p, err := svc.FindOne(ctx, filter); err != nil || p != schedule {
+			t.Errorf("unexpected recurring payment %v, %v", p, err)
+		}
+
+		if err := svc.Remove(ctx, remove); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		d.recurring.removeErr = dbErr
+		if err := svc.Remove(ctx, remove); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		d.recurring.findErr = dbErr
+		if _, err := svc.Find(ctx, projecta.RecurringPaymentCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		d.recurring.findOneErr = exceptions.NewNotFoundException("not found", nil)
+		if _, err := svc.FindOne(ctx, filter); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := svc.Remove(ctx, remove); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		d.recurring.findOneErr = dbErr
+		if _, err := svc.FindOne(ctx, filter); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		d.projects.findErr = dbErr
+		if err := svc.Remove(ctx, remove); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("upcoming", func(t *testing.T) {
+		schedule := newSchedule()
+		svc, d := newService(schedule)
+		filter := projecta.RecurringPaymentFilter{ProjectID: proj.ProjectID, RecurringID: schedule.ID}
+		d.recurring.occurrences = []*projecta.RecurringOccurrence{{RecurringID: schedule.ID, Date: time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC), Status: projecta.OccurrenceSkipped}}
+
+		upcoming, err := svc.Upcoming(ctx, filter)
+		if err != nil || len(upcoming) != 12 || upcoming[1].Status != projecta.OccurrenceSkipped {
+			t.Fatalf("unexpected upcoming occurrences %v, %v", upcoming, err)
+		}
+
+		schedule.Recurrence.Count, schedule.Processed = 2, 2
+		if upcoming, err = svc.Upcoming(ctx, filter); err != nil || len(upcoming) != 0 {
+			t.Errorf("expected no upcoming occurrences of a finished schedule, got %v, %v", upcoming, err)
+		}
+		schedule.Recurrence.Count, schedule.Processed = 0, 0
+
+		d.recurring.occurrencesErr = dbErr
+		if _, err = svc.Upcoming(ctx, filter); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		d.recurring.findOneErr = dbErr
+		if _, err = svc.Upcoming(ctx, filter); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("skip and edit a single occurrence", func(t *testing.T) {
+		schedule := newSchedule()
+		svc, d := newService(schedule)
+		february := time.Date(2026, time.February, 15, 8, 0, 0, 0, time.UTC)
+
+		if err := svc.SkipOccurrence(ctx, projecta.SkipOccurrenceCommand{ProjectID: proj.ProjectID, RecurringID: schedule.ID, Date: february}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if o := d.recurring.savedOccurrences[0]; o.Status != projecta.OccurrenceSkipped || projecta.OccurrenceKey(o.Date) != "2026-02-15" || !o.Date.Equal(time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected skipped occurrence %+v", o)
+		}
+
+		edit := projecta.EditOccurrenceCommand{ProjectID: proj.ProjectID, RecurringID: schedule.ID, Date: february, Amount: money.New(1200, money.USD), Description: "Rent and utilities"}
+		o, err := svc.EditOccurrence(ctx, edit)
+		if err != nil || o.Status != projecta.OccurrenceEdited || o.Amount.Amount() != 1200 || o.Description != "Rent and utilities" || o.RecurringID != schedule.ID {
+			t.Errorf("unexpected edited occurrence %+v, %v", o, err)
+		}
+
+		invalid := edit
+		invalid.Amount = money.New(-1, money.USD)
+		if _, err = svc.EditOccurrence(ctx, invalid); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		invalid = edit
+		invalid.Date = february.AddDate(0, 0, 1)
+		if _, err = svc.EditOccurrence(ctx, invalid); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for a date off the schedule, got %v", err)
+		}
+
+		d.recurring.saveOccurrenceErr = dbErr
+		if _, err = svc.EditOccurrence(ctx, edit); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		d.recurring.findOneErr = exceptions.NewNotFoundException("not found", nil)
+		if err = svc.SkipOccurrence(ctx, projecta.SkipOccurrenceCommand{ProjectID: proj.ProjectID, RecurringID: schedule.ID, Date: february}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		d.projects.findErr = dbErr
+		if _, err = svc.EditOccurrence(ctx, edit); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("materialise books the due occurrences once", func(t *testing.T) {
+		schedule := newSchedule()
+		svc, d := newService(schedule)
+		d.recurring.due = []*projecta.RecurringPayment{schedule}
+		paidOn := time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC)
+		d.recurring.occurrences = []*projecta.RecurringOccurrence{
+			{RecurringID: schedule.ID, Date: time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC), Status: projecta.OccurrenceSkipped},
+			{RecurringID: schedule.ID, Date: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC), Status: projecta.OccurrenceEdited, Amount: money.New(1200, money.USD), PaymentDate: paidOn},
+		}
+
+		booked, err := svc.Materialise(context.Background(), now)
+		if err != nil || booked != 3 {
+			t.Fatalf("expected 3 payments booked, got %d, %v", booked, err)
+		}
+
+		if schedule.Processed != 4 || len(d.recurring.saved) != 1 {
+			t.Errorf("expected the 4 due occurrences to be processed, got %d", schedule.Processed)
+		}
+		if next, _ := schedule.NextOccurrence(); projecta.OccurrenceKey(next) != "2026-05-15" {
+			t.Errorf("unexpected next occurrence %v", next)
+		}
+
+		if len(d.payments.saved) != 3 {
+			t.Fatalf("unexpected payments %v", d.payments.saved)
+		}
+		if p := d.payments.saved[0]; projecta.OccurrenceKey(p.Date) != "2026-01-15" || p.Amount.Amount() != 1000 || p.Owner != owner || p.HomeAmount.Amount() != 40000 {
+			t.Errorf("unexpected first payment %+v", p)
+		}
+		if p := d.payments.saved[1]; !p.Date.Equal(paidOn) || p.Amount.Amount() != 1200 || p.Description != "Rent" {
+			t.Errorf("unexpected edited payment %+v", p)
+		}
+
+		if len(d.recurring.savedOccurrences) != 3 || d.recurring.savedOccurrences[1].Status != projecta.OccurrenceBooked || d.recurring.savedOccurrences[1].PaymentID != d.payments.saved[1].ID {
+			t.Errorf("unexpected occurrences %v", d.recurring.savedOccurrences)
+		}
+
+		if len(d.recurring.requesters) != 1 || d.recurring.requesters[0] != owner.PersonID {
+			t.Errorf("expected the payments to be booked on behalf of the owner, got %v", d.recurring.requesters)
+		}
+
+		// nothing is due any more
+		d.recurring.occurrences = nil
+		if booked, err = svc.Materialise(context.Background(), now); err != nil || booked != 0 || len(d.payments.saved) != 3 {
+			t.Errorf("expected nothing booked twice, got %d, %v", booked, err)
+		}
+	})
+
+	t.Run("materialise leaves schedules locked by another replica", func(t *testing.T) {
+		schedule := newSchedule()
+		svc, d := newService(schedule)
+		d.recurring.due = []*projecta.RecurringPayment{schedule}
+		d.recurring.busy = true
+
+		if booked, err := svc.Materialise(context.Background(), now); err != nil || booked != 0 || len(d.payments.saved) != 0 || len(d.recurring.saved) != 0 {
+			t.Errorf("expected nothing booked, got %d, %v", booked, err)
+		}
+	})
+
+	t.Run("materialise skips finished schedules", func(t *testing.T) {
+		schedule := newSchedule()
+		schedule.Recurrence.Count, schedule.Processed = 1, 1
+		svc, d := newService(schedule)
+		d.recurring.due = []*projecta.RecurringPayment{schedule}
+
+		if booked, err := svc.Materialise(context.Background(), now); err != nil || booked != 0 || len(d.recurring.saved) != 0 {
+			t.Errorf("expected nothing booked, got %d, %v", booked, err)
+		}
+	})
+
+	t.Run("materialise reports the failed schedules", func(t *testing.T) {
+		svc, d := newService(nil)
+		d.recurring.dueErr = dbErr
+		if _, err := svc.Materialise(context.Background(), now); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		for name, setup := range map[string]func(d deps){
+			"lock":             func(d deps) { d.recurring.lockErr = dbErr },
+			"find":             func(d deps) { d.recurring.findOneErr = dbErr },
+			"archived project": func(d deps) { d.projects.project = &projecta.Project{ProjectID: proj.ProjectID, ArchivedAt: now} },
+			"occurrences":      func(d deps) { d.recurring.occurrencesErr = dbErr },
+			"payment":          func(d deps) { d.payments.saveErr = dbErr },
+			"occurrence":       func(d deps) { d.recurring.saveOccurrenceErr = dbErr },
+			"schedule":         func(d deps) { d.recurring.saveErr = dbErr },
+			"transaction":      func(d deps) { d.db.err = dbErr },
+		} {
+			schedule := newSchedule()
+			svc, d := newService(schedule)
+			d.recurring.due = []*projecta.RecurringPayment{schedule, schedule}
+			setup(d)
+
+			if _, err := svc.Materialise(context.Background(), now); !hasCode(err, exceptions.Internal) || d.db.txs != 2 {
+				t.Errorf("%s: expected internal error after trying every schedule, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestRecurringPaymentScheduler(t *testing.T) {
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	run := func(repo *mockRecurringRepo) *recordingLogger {
+		logger := &recordingLogger{}
+		svc := projecta.NewRecurringPaymentService(&mockImportDb{}, repo, &mockPaymentRepo{}, &mockTypeRepo{}, &mockProjectRepo{project: proj}, &mockPeopleService{}, &mockExchangeRates{rate: 1}, nil)
+		scheduler := projecta.NewRecurringPaymentScheduler(svc, logger, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+
+		go func() {
+			scheduler.Run(ctx)
+			close(done)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		<-done
+
+		return logger
+	}
+
+	if logger := run(&mockRecurringRepo{dueErr: errors.New("db error")}); len(logger.errors) < 2 {
+		t.Errorf("expected the scheduler to keep running, got %d attempts", len(logger.errors))
+	}
+
+	category, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Services", "")
+	rent, _ := projecta.NewCostType(proj.ProjectID, category, "Rent", "")
+	schedule, _ := projecta.NewRecurringPayment(uuid.New(), proj, owner, rent, "Rent", money.New(1000, money.UAH), projecta.UponCompletionPayment, projecta.Recurrence{Frequency: projecta.Daily, Interval: 1, Count: 1}, time.Now(), time.Time{})
+	repo := &mockRecurringRepo{payment: schedule, due: []*projecta.RecurringPayment{schedule}}
+
+	if logger := run(repo); logger.infos != 1 || len(logger.errors) != 0 {
+		t.Errorf("expected the booking to be logged once, got %d, %v", logger.infos, logger.errors)
+	}
+
+	if projecta.NewRecurringPaymentScheduler(nil, &recordingLogger{}, 0) == nil {
+		t.Error("expected a scheduler with the default interval")
+	}
+}
+
+type mockLoanRepo struct {
+	loan             *projecta.Loan
+	findErr          error
+	findOneErr       error
+	saveErr          error
+	removeErr        error
+	payments         []*projecta.LoanPayment
+	paymentsErr      error
+	savePaymentErr   error
+	removePaymentErr error
+	saved            []*projecta.Loan
+}
+
+func (m *mockLoanRepo) Find(ctx context.Context, filter projecta.LoanCollectionFilter) (*projecta.LoanCollection, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	col := projecta.NewLoanCollection(1)
+	col.Add(m.loan)
+	return col, nil
+}
+func (m *mockLoanRepo) FindOne(ctx context.Context, filter projecta.LoanFilter) (*projecta.Loan, error) {
+	if m.findOneErr != nil {
+		return nil, m.findOneErr
+	}
+	return m.loan, nil
+}
+func (m *mockLoanRepo) Save(ctx context.Context, loan *projecta.Loan) error {
+	if m.saveErr == nil {
+		m.saved = append(m.saved, loan)
+	}
+	return m.saveErr
+}
+func (m *mockLoanRepo) Remove(ctx context.Context, loan *projecta.Loan) error {
+	return m.removeErr
+}
+func (m *mockLoanRepo) FindPayments(ctx context.Context, loanID uuid.UUID) ([]*projecta.LoanPayment, error) {
+	if m.paymentsErr != nil {
+		return nil, m.paymentsErr
+	}
+	payments := make([]*projecta.LoanPayment, 0, len(m.payments))
+	for _, p := range m.payments {
+		copied := *p
+		payments = append(payments, &copied)
+	}
+	return payments, nil
+}
+func (m *mockLoanRepo) SavePayment(ctx context.Context, payment *projecta.LoanPayment) error {
+	if m.savePaymentErr != nil {
+		return m.savePaymentErr
+	}
+	for i, p := range m.payments {
+		if p.PaymentID == payment.PaymentID {
+			m.payments[i] = payment
+			return nil
+		}
+	}
+	m.payments = append(m.payments, payment)
+	return nil
+}
+func (m *mockLoanRepo) RemovePayment(ctx context.Context, loanID uuid.UUID, paymentID uuid.UUID) error {
+	return m.removePaymentErr
+}
+
+func TestLoan(t *testing.T) {
+	projectID := uuid.New()
+	start := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Validates the terms", func(t *testing.T) {
+		for name, build := range map[string]func() (*projecta.Loan, error){
+			"no principal": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", nil, 10, 12, projecta.AnnuitySchedule, start)
+			},
+			"zero principal": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(0, "UAH"), 10, 12, projecta.AnnuitySchedule, start)
+			},
+			"unknown currency": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(100, "XYZ"), 10, 12, projecta.AnnuitySchedule, start)
+			},
+			"negative rate": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(100, "UAH"), -1, 12, projecta.AnnuitySchedule, start)
+			},
+			"no term": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(100, "UAH"), 10, 0, projecta.AnnuitySchedule, start)
+			},
+			"term too long": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(100, "UAH"), 10, 601, projecta.AnnuitySchedule, start)
+			},
+			"unknown schedule": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(100, "UAH"), 10, 12, "BALLOON", start)
+			},
+			"no start date": func() (*projecta.Loan, error) {
+				return projecta.NewLoan(uuid.New(), projectID, "", money.New(100, "UAH"), 10, 12, projecta.AnnuitySchedule, time.Time{})
+			},
+		} {
+			if _, err := build(); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		if s, err := projecta.ToLoanSchedule("differentiated"); err != nil || s != projecta.DifferentiatedSchedule || s.String() != "DIFFERENTIATED" {
+			t.Errorf("unexpected schedule %v: %v", s, err)
+		}
+	})
+
+	t.Run("Annuity schedule", func(t *testing.T) {
+		loan, err := projecta.NewLoan(uuid.New(), projectID, "Bank loan", money.New(1200000, "UAH"), 12, 12, projecta.AnnuitySchedule, start)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		installments := loan.Amortization()
+		if len(installments) != 12 {
+			t.Fatalf("expected 12 installments, got %d", len(installments))
+		}
+
+		first := installments[0]
+		if first.Number != 1 || first.Amount.Amount() != 106619 || first.Interest.Amount() != 12000 || first.Principal.Amount() != 94619 || first.Balance.Amount() != 1105381 {
+			t.Errorf("unexpected first installment %+v", first)
+		}
+		if !first.DueDate.Equal(time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)) || !installments[1].DueDate.Equal(time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected due dates %v, %v", first.DueDate, installments[1].DueDate)
+		}
+
+		var principal int64
+		for _, installment := range installments[:11] {
+			principal += installment.Principal.Amount()
+			if installment.Amount.Amount() != 106619 {
+				t.Errorf("expected equal installments, got %v", installment.Amount.Amount())
+			}
+		}
+		last := installments[11]
+		if principal+last.Principal.Amount() != 1200000 || last.Balance.Amount() != 0 || last.Amount.Currency().Code != "UAH" {
+			t.Errorf("expected the principal repaid, got %v left", last.Balance.Amount())
+		}
+	})
+
+	t.Run("Differentiated schedule", func(t *testing.T) {
+		loan, _ := projecta.NewLoan(uuid.New(), projectID, "", money.New(1200000, "UAH"), 12, 12, projecta.DifferentiatedSchedule, start)
+
+		var interest int64
+		for i, installment := range loan.Amortization() {
+			interest += installment.Interest.Amount()
+			if installment.Principal.Amount() != 100000 || installment.Interest.Amount() != int64(12000-1000*i) {
+				t.Errorf("unexpected installment %d: %v + %v", installment.Number, installment.Principal.Amount(), installment.Interest.Amount())
+			}
+		}
+		if interest != 78000 {
+			t.Errorf("expected 78000 interest, got %d", interest)
+		}
+	})
+
+	t.Run("Interest free loan", func(t *testing.T) {
+		loan, _ := projecta.NewLoan(uuid.New(), projectID, "", money.New(1000, "UAH"), 0, 3, projecta.AnnuitySchedule, start)
+		installments := loan.Amortization()
+		if installments[0].Amount.Amount() != 333 || installments[2].Amount.Amount() != 334 || installments[2].Interest.Amount() != 0 {
+			t.Errorf("unexpected installments %v, %v", installments[0].Amount, installments[2].Amount)
+		}
+	})
+}
+
+func TestLoanReport(t *testing.T) {
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	loan, _ := projecta.NewLoan(uuid.New(), uuid.New(), "", money.New(1200000, "UAH"), 12, 12, projecta.DifferentiatedSchedule, start)
+	pay := func(installment int, amount int64) *projecta.LoanPayment {
+		return &projecta.LoanPayment{LoanID: loan.ID, PaymentID: uuid.New(), Installment: installment, Amount: money.New(amount, "UAH"), Date: start}
+	}
+
+	t.Run("Splits the payments into interest and principal", func(t *testing.T) {
+		partial, rest := pay(2, 5000), pay(2, 10000)
+		report := projecta.NewLoanReport(loan, []*projecta.LoanPayment{pay(1, 112000), partial, rest})
+
+		if report.PrincipalPaid.Amount() != 104000 || report.InterestPaid.Amount() != 23000 || report.Outstanding.Amount() != 1096000 {
+			t.Errorf("unexpected totals %v %v %v", report.PrincipalPaid, report.InterestPaid, report.Outstanding)
+		}
+		if partial.Interest.Amount() != 5000 || partial.Principal.Amount() != 0 || rest.Interest.Amount() != 6000 || rest.Principal.Amount() != 4000 {
+			t.Errorf("unexpected split %v/%v and %v/%v", partial.Interest, partial.Principal, rest.Interest, rest.Principal)
+		}
+		if !report.Lines[0].IsPaid() || report.Lines[1].IsPaid() || report.Lines[1].Paid.Amount() != 15000 || len(report.Lines[1].Payments) != 2 {
+			t.Errorf("unexpected lines %+v", report.Lines[1])
+		}
+		if report.NextInstallment() != 2 {
+			t.Errorf("expected installment 2 next, got %d", report.NextInstallment())
+		}
+	})
+
+	t.Run("Payments past the term count against the last installment", func(t *testing.T) {
+		report := projecta.NewLoanReport(loan, []*projecta.LoanPayment{pay(99, 1000)})
+		if report.Lines[11].Paid.Amount() != 1000 || report.Lines[11].InterestPaid.Amount() != 1000 {
+			t.Errorf("unexpected last line %+v", report.Lines[11])
+		}
+	})
+
+	t.Run("Repaid loan", func(t *testing.T) {
+		report := projecta.NewLoanReport(loan, []*projecta.LoanPayment{pay(1, 1300000)})
+		if report.Outstanding.Amount() != 0 || report.NextInstallment() != 0 {
+			t.Errorf("expected the loan repaid, got %v outstanding", report.Outstanding)
+		}
+	})
+
+	t.Run("Prepaid principal leaves the later installments open", func(t *testing.T) {
+		report := projecta.NewLoanReport(loan, []*projecta.LoanPayment{pay(1, 1100000)})
+		if report.NextInstallment() != 2 || report.Outstanding.Amount() != 112000 {
+			t.Errorf("unexpected report %d %v", report.NextInstallment(), report.Outstanding)
+		}
+	})
+}
+
+func TestLoanService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	newLoan := func() *projecta.Loan {
+		loan, _ := projecta.NewLoan(uuid.New(), proj.ProjectID, "Bank loan", money.New(1200000, "UAH"), 12, 12, projecta.DifferentiatedSchedule, start)
+		return loan
+	}
+	creditPayment := func(amount int64, currency string) *projecta.Payment {
+		return projecta.NewPayment(uuid.New(), proj, owner, costType, "Installment", money.New(amount, currency), start.AddDate(0, 1, 0), projecta.CreditPayment)
+	}
+	createCmd := projecta.CreateLoanCommand{
+		ProjectID:  proj.ProjectID,
+		Principal:  money.New(1200000, "UAH"),
+		AnnualRate: 12,
+		Term:       12,
+		Schedule:   projecta.AnnuitySchedule,
+		StartDate:  start,
+	}
+
+	t.Run("Create, find and remove", func(t *testing.T) {
+		repo := &mockLoanRepo{loan: newLoan()}
+		svc := projecta.NewLoanService(repo, &mockPaymentRepo{}, &mockProjectRepo{project: proj})
+
+		loan, err := svc.Create(ctx, createCmd)
+		if err != nil || loan.ProjectID != proj.ProjectID || len(repo.saved) != 1 {
+			t.Fatalf("unexpected loan %+v: %v", loan, err)
+		}
+
+		if _, err = svc.Create(ctx, projecta.CreateLoanCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		if col, err := svc.Find(ctx, projecta.LoanCollectionFilter{ProjectID: proj.ProjectID}); err != nil || col.Total() != 1 {
+			t.Errorf("unexpected loans %v: %v", col, err)
+		}
+
+		if err = svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: repo.loan.ID}); err != nil {
+			t.Errorf("Remove error: %v", err)
+		}
+	})
+
+	t.Run("Repository failures", func(t *testing.T) {
+		projects := &mockProjectRepo{project: proj}
+		failing := projecta.NewLoanService(&mockLoanRepo{loan: newLoan(), findErr: errors.New("db"), findOneErr: errors.New("db"), saveErr: errors.New("db")}, &mockPaymentRepo{}, projects)
+
+		if _, err := failing.Create(ctx, createCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on create, got %v", err)
+		}
+		if _, err := failing.Find(ctx, projecta.LoanCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find, got %v", err)
+		}
+		if _, err := failing.FindOne(ctx, projecta.LoanFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find one, got %v", err)
+		}
+
+		missing := projecta.NewLoanService(&mockLoanRepo{findOneErr: exceptions.NotFoundError}, &mockPaymentRepo{}, projects)
+		if _, err := missing.Report(ctx, projecta.LoanFilter{}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := missing.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on remove, got %v", err)
+		}
+		if err := missing.Update(ctx, projecta.UpdateLoanCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on update, got %v", err)
+		}
+		if _, err := missing.LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on link, got %v", err)
+		}
+		if err := missing.UnlinkPayment(ctx, projecta.UnlinkLoanPaymentCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on unlink, got %v", err)
+		}
+
+		removeErr := projecta.NewLoanService(&mockLoanRepo{loan: newLoan(), removeErr: errors.New("db"), paymentsErr: errors.New("db")}, &mockPaymentRepo{}, projects)
+		if err := removeErr.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on remove, got %v", err)
+		}
+		if _, err := removeErr.Report(ctx, projecta.LoanFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on report, got %v", err)
+		}
+	})
+
+	t.Run("Update keeps the booked payments valid", func(t *testing.T) {
+		loan := newLoan()
+		repo := &mockLoanRepo{loan: loan, payments: []*projecta.LoanPayment{{LoanID: loan.ID, PaymentID: uuid.New(), Installment: 6, Amount: money.New(100, "UAH")}}}
+		svc := projecta.NewLoanService(repo, &mockPaymentRepo{}, &mockProjectRepo{project: proj})
+		cmd := projecta.UpdateLoanCommand{
+			ProjectID:  proj.ProjectID,
+			ID:         loan.ID,
+			Principal:  money.New(1500000, "UAH"),
+			AnnualRate: 10,
+			Term:       24,
+			Schedule:   projecta.AnnuitySchedule,
+			StartDate:  start,
+		}
+
+		if err := svc.Update(ctx, cmd); err != nil || len(repo.saved) != 1 || repo.saved[0].ID != loan.ID || repo.saved[0].Term != 24 {
+			t.Fatalf("unexpected update: %v", err)
+		}
+
+		shorter := cmd
+		shorter.Term = 5
+		if err := svc.Update(ctx, shorter); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected term error, got %v", err)
+		}
+
+		otherCurrency := cmd
+		otherCurrency.Principal = money.New(30000, "USD")
+		if err := svc.Update(ctx, otherCurrency); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected currency error, got %v", err)
+		}
+
+		invalid := cmd
+		invalid.Term = 0
+		if err := svc.Update(ctx, invalid); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		repo.paymentsErr = errors.New("db")
+		if err := svc.Update(ctx, cmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		repo.paymentsErr, repo.saveErr = nil, errors.New("db")
+		if err := svc.Update(ctx, cmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on save, got %v", err)
+		}
+	})
+
+	t.Run("Links credit payments to installments", func(t *testing.T) {
+		loan := newLoan()
+		repo := &mockLoanRepo{loan: loan}
+		first := creditPayment(112000, "UAH")
+		svc := projecta.NewLoanService(repo, &mockPaymentRepo{pay: first}, &mockProjectRepo{project: proj})
+
+		linked, err := svc.LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, PaymentID: first.ID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if linked.Installment != 1 || linked.Interest.Amount() != 12000 || linked.Principal.Amount() != 100000 {
+			t.Errorf("unexpected link %+v", linked)
+		}
+
+		// linking the same payment again moves it rather than counting it twice
+		linked, err = svc.LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, PaymentID: first.ID})
+		if err != nil || linked.Installment != 1 || len(repo.payments) != 1 {
+			t.Errorf("expected the payment kept on installment 1, got %+v: %v", linked, err)
+		}
+
+		second := creditPayment(50000, "UAH")
+		svc = projecta.NewLoanService(repo, &mockPaymentRepo{pay: second}, &mockProjectRepo{project: proj})
+		linked, err = svc.LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, PaymentID: second.ID})
+		if err != nil || linked.Installment != 2 || linked.Interest.Amount() != 11000 || linked.Principal.Amount() != 39000 {
+			t.Errorf("expected installment 2, got %+v: %v", linked, err)
+		}
+
+		report, err := svc.Report(ctx, projecta.LoanFilter{LoanID: loan.ID, ProjectID: proj.ProjectID})
+		if err != nil || report.Outstanding.Amount() != 1061000 || report.InterestPaid.Amount() != 23000 {
+			t.Errorf("unexpected report %+v: %v", report, err)
+		}
+
+		if err = svc.UnlinkPayment(ctx, projecta.UnlinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, PaymentID: second.ID}); err != nil {
+			t.Errorf("Unlink error: %v", err)
+		}
+	})
+
+	t.Run("A payment not paid yet repays nothing", func(t *testing.T) {
+		loan := newLoan()
+		planned := creditPayment(112000, "UAH")
+		planned.Status, planned.DueDate = projecta.PaymentPlanned, start.AddDate(0, 1, 0)
+		// the repository only returns the paid payments
+		repo := &mockLoanRepo{loan: loan, paymentsErr: nil}
+		svc := projecta.NewLoanService(&plannedLoanRepo{repo}, &mockPaymentRepo{pay: planned}, &mockProjectRepo{project: proj})
+
+		linked, err := svc.LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, PaymentID: planned.ID, Installment: 3})
+		if err != nil || linked.Installment != 3 || linked.Principal.Amount() != 0 || linked.Interest.Amount() != 0 {
+			t.Errorf("unexpected link %+v: %v", linked, err)
+		}
+	})
+
+	t.Run("Link failures", func(t *testing.T) {
+		loan := newLoan()
+		link := func(repo *mockLoanRepo, payments *mockPaymentRepo, installment int) error {
+			_, err := projecta.NewLoanService(repo, payments, &mockProjectRepo{project: proj}).LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, Installment: installment})
+			return err
+		}
+
+		cases := map[string]struct {
+			repo        *mockLoanRepo
+			payments    *mockPaymentRepo
+			installment int
+			code        exceptions.ErrorCode
+		}{
+			"payment not found":      {&mockLoanRepo{loan: loan}, &mockPaymentRepo{findOneErr: exceptions.NotFoundError}, 1, exceptions.NotFound},
+			"payment lookup failed":  {&mockLoanRepo{loan: loan}, &mockPaymentRepo{findOneErr: errors.New("db")}, 1, exceptions.Internal},
+			"not a credit payment":   {&mockLoanRepo{loan: loan}, &mockPaymentRepo{pay: projecta.NewPayment(uuid.New(), proj, owner, costType, "Tiles", money.New(100, "UAH"), start, projecta.DownPayment)}, 1, exceptions.ValidationFailed},
+			"other currency":         {&mockLoanRepo{loan: loan}, &mockPaymentRepo{pay: creditPayment(100, "USD")}, 1, exceptions.ValidationFailed},
+			"out of the term":        {&mockLoanRepo{loan: loan}, &mockPaymentRepo{pay: creditPayment(100, "UAH")}, 13, exceptions.ValidationFailed},
+			"payments lookup failed": {&mockLoanRepo{loan: loan, paymentsErr: errors.New("db")}, &mockPaymentRepo{pay: creditPayment(100, "UAH")}, 0, exceptions.Internal},
+			"save failed":            {&mockLoanRepo{loan: loan, savePaymentErr: errors.New("db")}, &mockPaymentRepo{pay: creditPayment(100, "UAH")}, 1, exceptions.Internal},
+			"repaid already":         {&mockLoanRepo{loan: loan, payments: []*projecta.LoanPayment{{LoanID: loan.ID, PaymentID: uuid.New(), Installment: 1, Amount: money.New(1300000, "UAH")}}}, &mockPaymentRepo{pay: creditPayment(100, "UAH")}, 0, exceptions.ValidationFailed},
+		}
+		for name, c := range cases {
+			if err := link(c.repo, c.payments, c.installment); !hasCode(err, c.code) {
+				t.Errorf("%s: expected %s, got %v", name, c.code, err)
+			}
+		}
+
+		refetchErr := &failingAfterSaveLoanRepo{mockLoanRepo{loan: loan}}
+		_, err := projecta.NewLoanService(refetchErr, &mockPaymentRepo{pay: creditPayment(100, "UAH")}, &mockProjectRepo{project: proj}).LinkPayment(ctx, projecta.LinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID, Installment: 1})
+		if !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error after save, got %v", err)
+		}
+
+		unlink := func(removeErr error) error {
+			return projecta.NewLoanService(&mockLoanRepo{loan: loan, removePaymentErr: removeErr}, &mockPaymentRepo{}, &mockProjectRepo{project: proj}).UnlinkPayment(ctx, projecta.UnlinkLoanPaymentCommand{ProjectID: proj.ProjectID, LoanID: loan.ID})
+		}
+		if err = unlink(exceptions.NewNotFoundException("loan payment not found", nil)); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on unlink, got %v", err)
+		}
+		if err = unlink(errors.New("db")); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on unlink, got %v", err)
+		}
+	})
+}
+
+// plannedLoanRepo books payments without returning them, the way the
+// repository leaves out the payments not paid yet.
+type plannedLoanRepo struct {
+	*mockLoanRepo
+}
+
+func (m *plannedLoanRepo) FindPayments(ctx context.Context, loanID uuid.UUID) ([]*projecta.LoanPayment, error) {
+	return nil, nil
+}
+
+// failingAfterSaveLoanRepo fails to read the payments back once one is booked.
+type failingAfterSaveLoanRepo struct {
+	mockLoanRepo
+}
+
+func (m *failingAfterSaveLoanRepo) SavePayment(ctx context.Context, payment *projecta.LoanPayment) error {
+	m.paymentsErr = errors.New("db")
+	return nil
+}
+
+type mockVendorRepo struct {
+	vendor     *projecta.Vendor
+	findErr    error
+	findOneErr error
+	saveErr    error
+	removeErr  error
+	linkErr    error
+	countErr   error
+	contracts  int
+	dropErr    error
+	totals     []*projecta.VendorSubtotal
+	saved      []*projecta.Vendor
+	linked     []uuid.UUID
+}
+
+func (m *mockVendorRepo) Find(ctx context.Context, filter projecta.VendorCollectionFilter) (*projecta.VendorCollection, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	col := projecta.NewVendorCollection(1)
+	col.Add(m.vendor)
+	return col, nil
+}
+func (m *mockVendorRepo) FindOne(ctx context.Context, filter projecta.VendorFilter) (*projecta.Vendor, error) {
+	if m.findOneErr != nil {
+		return nil, m.findOneErr
+	}
+	return m.vendor, nil
+}
+func (m *mockVendorRepo) Save(ctx context.Context, vendor *projecta.Vendor) error {
+	if m.saveErr == nil {
+		m.saved = append(m.saved, vendor)
+	}
+	return m.saveErr
+}
+func (m *mockVendorRepo) Remove(ctx context.Context, vendor *projecta.Vendor) error {
+	return m.removeErr
+}
+func (m *mockVendorRepo) CountContracts(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID) (int, error) {
+	return m.contracts, m.countErr
+}
+func (m *mockVendorRepo) RemoveContracts(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID) error {
+	return m.dropErr
+}
+func (m *mockVendorRepo) link(resourceID uuid.UUID) error {
+	if m.linkErr == nil {
+		m.linked = append(m.linked, resourceID)
+	}
+	return m.linkErr
+}
+func (m *mockVendorRepo) LinkPayment(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, paymentID uuid.UUID) error {
+	return m.link(paymentID)
+}
+func (m *mockVendorRepo) UnlinkPayment(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, paymentID uuid.UUID) error {
+	return m.link(paymentID)
+}
+func (m *mockVendorRepo) LinkAsset(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, assetID uuid.UUID) error {
+	return m.link(assetID)
+}
+func (m *mockVendorRepo) UnlinkAsset(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, assetID uuid.UUID) error {
+	return m.link(assetID)
+}
+func (m *mockVendorRepo) Totals(ctx context.Context, vendorID uuid.UUID) ([]*projecta.VendorSubtotal, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	return m.totals, nil
+}
+
+type mockContractRepo struct {
+	contract    *projecta.Contract
+	findErr     error
+	findOneErr  error
+	saveErr     error
+	removeErr   error
+	payments    []*projecta.ContractPayment
+	paymentsErr error
+	linkErr     error
+	saved       []*projecta.Contract
+	linked      []uuid.UUID
+}
+
+func (m *mockContractRepo) Find(ctx context.Context, filter projecta.ContractCollectionFilter) (*projecta.ContractCollection, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	col := projecta.NewContractCollection(1)
+	col.Add(m.contract)
+	return col, nil
+}
+func (m *mockContractRepo) FindOne(ctx context.Context, filter projecta.ContractFilter) (*projecta.Contract, error) {
+	if m.findOneErr != nil {
+		return nil, m.findOneErr
+	}
+	return m.contract, nil
+}
+func (m *mockContractRepo) Save(ctx context.Context, contract *projecta.Contract) error {
+	if m.saveErr == nil {
+		m.saved = append(m.saved, contract)
+	}
+	return m.saveErr
+}
+func (m *mockContractRepo) Remove(ctx context.Context, contract *projecta.Contract) error {
+	return m.removeErr
+}
+func (m *mockContractRepo) FindPayments(ctx context.Context, contractID uuid.UUID) ([]*projecta.ContractPayment, error) {
+	return m.payments, m.paymentsErr
+}
+func (m *mockContractRepo) LinkPayment(ctx context.Context, contract *projecta.Contract, paymentID uuid.UUID) error {
+	if m.linkErr == nil {
+		m.linked = append(m.linked, paymentID)
+	}
+	return m.linkErr
+}
+func (m *mockContractRepo) UnlinkPayment(ctx context.Context, contractID uuid.UUID, paymentID uuid.UUID) error {
+	return m.linkErr
+}
+
+func TestVendor(t *testing.T) {
+	ownerID, projectID := uuid.New(), uuid.New()
+
+	t.Run("Validates the details", func(t *testing.T) {
+		cases := map[string]struct {
+			name  string
+			email string
+			iban  string
+		}{
+			"no name":           {"  ", "", ""},
+			"invalid email":     {"Electrician", "not an email", ""},
+			"short IBAN":        {"Electrician", "", "UA21"},
+			"wrong check digit": {"Electrician", "", "UA213223130000026007233566002"},
+			"bad country code":  {"Electrician", "", "1A213223130000026007233566001"},
+			"bad characters":    {"Electrician", "", "UA21322313000002600723356600-"},
+		}
+		for name, c := range cases {
+			if _, err := projecta.NewVendor(uuid.New(), ownerID, projectID, c.name, "", "", c.email, "", c.iban); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Normalizes the details", func(t *testing.T) {
+		v, err := projecta.NewVendor(uuid.New(), ownerID, projectID, " Sparks Ltd ", " 12345678 ", " Ivan ", " ivan@sparks.ua ", " +380 ", "gb82 west 1234 5698 7654 32")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.Name != "Sparks Ltd" || v.TaxID != "12345678" || v.ContactName != "Ivan" || v.Email != "ivan@sparks.ua" || v.Phone != "+380" || v.IBAN != "GB82WEST12345698765432" {
+			t.Errorf("unexpected vendor %+v", v)
+		}
+		if v.IsShared() {
+			t.Error("expected a project vendor")
+		}
+
+		shared, err := projecta.NewVendor(uuid.New(), ownerID, uuid.Nil, "Hardware store", "", "", "", "", "UA213223130000026007233566001")
+		if err != nil || !shared.IsShared() {
+			t.Errorf("expected a shared vendor, got %+v: %v", shared, err)
+		}
+	})
+
+	t.Run("Report sums the amounts per currency", func(t *testing.T) {
+		v, _ := projecta.NewVendor(uuid.New(), ownerID, projectID, "Electrician", "", "", "", "", "")
+		report := projecta.NewVendorReport(v, []*projecta.VendorSubtotal{
+			{Amount: money.New(1000, "UAH"), Count: 2, Status: projecta.PaymentPaid},
+			{Amount: money.New(50, "USD"), Count: 1, Status: projecta.PaymentPaid},
+			{Amount: money.New(500, "UAH"), Count: 1, Status: projecta.PaymentPaid},
+			{Amount: money.New(300, "UAH"), Count: 1, Status: projecta.PaymentPlanned},
+			{Amount: money.New(200, "UAH"), Count: 1, Status: projecta.PaymentDue},
+			{Amount: money.New(900, "UAH"), Count: 1, Status: projecta.PaymentCancelled},
+			{Amount: money.New(7000, "UAH"), Count: 1},
+		})
+
+		if report.Payments != 4 || len(report.Paid) != 2 || report.Paid[0].Amount() != 1500 || report.Paid[1].Currency().Code != "USD" {
+			t.Errorf("unexpected paid %v (%d payments)", report.Paid, report.Payments)
+		}
+		if len(report.Committed) != 1 || report.Committed[0].Amount() != 500 {
+			t.Errorf("unexpected committed %v", report.Committed)
+		}
+		if len(report.Assets) != 1 || report.Assets[0].Amount() != 7000 {
+			t.Errorf("unexpected assets %v", report.Assets)
+		}
+	})
+}
+
+func TestContract(t *testing.T) {
+	projectID, vendorID := uuid.New(), uuid.New()
+	signedAt := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	stage := func(kind projecta.PaymentKind, amount int64, currency string) *projecta.ContractStage {
+		return &projecta.ContractStage{Kind: kind, Amount: money.New(amount, currency)}
+	}
+
+	t.Run("Validates the terms", func(t *testing.T) {
+		cases := map[string]struct {
+			title    string
+			vendorID uuid.UUID
+			total    *money.Money
+			stages   []*projecta.ContractStage
+		}{
+			"no title":         {" ", vendorID, money.New(1000, "UAH"), nil},
+			"no vendor":        {"Wiring", uuid.Nil, money.New(1000, "UAH"), nil},
+			"no total":         {"Wiring", vendorID, nil, nil},
+			"negative total":   {"Wiring", vendorID, money.New(-1000, "UAH"), nil},
+			"unknown currency": {"Wiring", vendorID, money.New(1000, "XYZ"), nil},
+			"credit stage":     {"Wiring", vendorID, money.New(1000, "UAH"), []*projecta.ContractStage{stage(projecta.CreditPayment, 1000, "UAH")}},
+			"stage twice": {"Wiring", vendorID, money.New(1000, "UAH"), []*projecta.ContractStage{
+				stage(projecta.DownPayment, 500, "UAH"), stage(projecta.DownPayment, 500, "UAH"),
+			}},
+			"stage currency": {"Wiring", vendorID, money.New(1000, "UAH"), []*projecta.ContractStage{stage(projecta.UponCompletionPayment, 1000, "USD")}},
+			"stages short": {"Wiring", vendorID, money.New(1000, "UAH"), []*projecta.ContractStage{
+				stage(projecta.DownPayment, 300, "UAH"), stage(projecta.UponCompletionPayment, 600, "UAH"),
+			}},
+		}
+		for name, c := range cases {
+			if _, err := projecta.NewContract(uuid.New(), projectID, c.vendorID, c.title, c.total, c.stages, signedAt); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Orders the stages", func(t *testing.T) {
+		c, err := projecta.NewContract(uuid.New(), projectID, vendorID, " Wiring ", money.New(1000, "UAH"), []*projecta.ContractStage{
+			stage(projecta.UponCompletionPayment, 700, "UAH"), stage(projecta.DownPayment, 300, "UAH"),
+		}, signedAt)
+		if err != nil || c.Title != "Wiring" || len(c.Stages) != 2 || c.Stages[0].Kind != projecta.DownPayment {
+			t.Fatalf("unexpected contract %+v: %v", c, err)
+		}
+		if c.Stage(projecta.CreditPayment) != nil || c.Stage(projecta.UponCompletionPayment).Amount.Amount() != 700 {
+			t.Errorf("unexpected stages %+v", c.Stages)
+		}
+
+		whole, _ := projecta.NewContract(uuid.New(), projectID, vendorID, "Wiring", money.New(1000, "UAH"), nil, time.Time{})
+		if len(whole.Stages) != 1 || whole.Stage(projecta.UponCompletionPayment).Amount.Amount() != 1000 {
+			t.Errorf("expected the total paid upon completion, got %+v", whole.Stages)
+		}
+	})
+
+	t.Run("Report tells paid and remaining per stage", func(t *testing.T) {
+		c, _ := projecta.NewContract(uuid.New(), projectID, vendorID, "Wiring", money.New(1000, "UAH"), []*projecta.ContractStage{
+			stage(projecta.DownPayment, 300, "UAH"), stage(projecta.UponCompletionPayment, 700, "UAH"),
+		}, signedAt)
+		payment := func(kind projecta.PaymentKind, status projecta.PaymentStatus, amount int64, currency string) *projecta.ContractPayment {
+			return &projecta.ContractPayment{ContractID: c.ID, PaymentID: uuid.New(), Kind: kind, Status: status, Amount: money.New(amount, currency)}
+		}
+
+		report := projecta.NewContractReport(c, []*projecta.ContractPayment{
+			payment(projecta.DownPayment, projecta.PaymentPaid, 400, "UAH"),
+			payment(projecta.UponCompletionPayment, projecta.PaymentPaid, 200, "UAH"),
+			payment(projecta.UponCompletionPayment, projecta.PaymentPlanned, 500, "UAH"),
+			payment(projecta.UponCompletionPayment, projecta.PaymentCancelled, 500, "UAH"),
+			payment(projecta.UponCompletionPayment, projecta.PaymentPaid, 100, "USD"),
+			payment(projecta.CreditPayment, projecta.PaymentPaid, 100, "UAH"),
+		})
+
+		down, completion := report.Stages[0], report.Stages[1]
+		if down.Paid.Amount() != 400 || down.Remaining.Amount() != 0 {
+			t.Errorf("unexpected down payment stage %+v", down)
+		}
+		if completion.Paid.Amount() != 200 || completion.Committed.Amount() != 500 || completion.Remaining.Amount() != 500 {
+			t.Errorf("unexpected completion stage %+v", completion)
+		}
+		if report.Paid.Amount() != 600 || report.Committed.Amount() != 500 || report.Remaining.Amount() != 500 {
+			t.Errorf("unexpected totals %v %v %v", report.Paid, report.Committed, report.Remaining)
+		}
+	})
+}
+
+func TestVendorService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	projects := &mockProjectRepo{project: proj}
+
+	newVendor := func(ownerID uuid.UUID, projectID uuid.UUID) *projecta.Vendor {
+		v, _ := projecta.NewVendor(uuid.New(), ownerID, projectID, "Electrician", "", "", "", "", "")
+		return v
+	}
+	newPayment := func(vendorID uuid.UUID, contractID uuid.UUID) *projecta.Payment {
+		p := projecta.NewPayment(uuid.New(), proj, owner, costType, "Wiring", money.New(1000, "UAH"), time.Now(), projecta.DownPayment)
+		p.VendorID, p.ContractID = vendorID, contractID
+		return p
+	}
+
+	t.Run("Create, find, update and remove", func(t *testing.T) {
+		repo := &mockVendorRepo{vendor: newVendor(requesterID, proj.ProjectID)}
+		svc := projecta.NewVendorService(&mockImportDb{}, repo, &mockPaymentRepo{}, projects)
+
+		v, err := svc.Create(ctx, projecta.CreateVendorCommand{ProjectID: proj.ProjectID, Name: "Electrician"})
+		if err != nil || v.ProjectID != proj.ProjectID || v.OwnerID != requesterID || len(repo.saved) != 1 {
+			t.Fatalf("unexpected vendor %+v: %v", v, err)
+		}
+
+		shared, err := svc.Create(ctx, projecta.CreateVendorCommand{ProjectID: proj.ProjectID, Name: "Hardware store", Shared: true})
+		if err != nil || !shared.IsShared() {
+			t.Errorf("expected a shared vendor, got %+v: %v", shared, err)
+		}
+
+		if _, err = svc.Create(ctx, projecta.CreateVendorCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		if _, err = svc.Create(context.Background(), projecta.CreateVendorCommand{ProjectID: proj.ProjectID, Name: "Electrician"}); !hasCode(err, exceptions.Unauthorized) {
+			t.Errorf("expected unauthorized error, got %v", err)
+		}
+
+		if col, err := svc.Find(ctx, projecta.VendorCollectionFilter{ProjectID: proj.ProjectID}); err != nil || col.Total() != 1 {
+			t.Errorf("unexpected vendors %v: %v", col, err)
+		}
+
+		if err = svc.Update(ctx, projecta.UpdateVendorCommand{ProjectID: proj.ProjectID, ID: repo.vendor.ID, Name: "Sparks Ltd"}); err != nil || repo.saved[len(repo.saved)-1].Name != "Sparks Ltd" {
+			t.Errorf("Update error: %v", err)
+		}
+
+		if err = svc.Update(ctx, projecta.UpdateVendorCommand{ProjectID: proj.ProjectID, ID: repo.vendor.ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error on update, got %v", err)
+		}
+
+		if err = svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: repo.vendor.ID}); err != nil {
+			t.Errorf("Remove error: %v", err)
+		}
+	})
+
+	t.Run("Only the person sharing a vendor changes it", func(t *testing.T) {
+		svc := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{vendor: newVendor(uuid.New(), uuid.Nil)}, &mockPaymentRepo{pay: newPayment(uuid.Nil, uuid.Nil)}, projects)
+
+		if err := svc.Update(ctx, projecta.UpdateVendorCommand{ProjectID: proj.ProjectID, Name: "Renamed"}); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected forbidden error on update, got %v", err)
+		}
+		if err := svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Forbidden) {
+			t.Errorf("expected forbidden error on remove, got %v", err)
+		}
+
+		// the payments of the project are linked to a shared vendor all the same
+		if err := svc.LinkPayment(ctx, projecta.LinkVendorResourceCommand{ProjectID: proj.ProjectID}); err != nil {
+			t.Errorf("LinkPayment error: %v", err)
+		}
+	})
+
+	t.Run("Repository failures", func(t *testing.T) {
+		failing := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{vendor: newVendor(requesterID, proj.ProjectID), findErr: errors.New("db"), findOneErr: errors.New("db"), saveErr: errors.New("db")}, &mockPaymentRepo{}, projects)
+
+		if _, err := failing.Create(ctx, projecta.CreateVendorCommand{ProjectID: proj.ProjectID, Name: "Electrician"}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on create, got %v", err)
+		}
+		if _, err := failing.Find(ctx, projecta.VendorCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find, got %v", err)
+		}
+		if _, err := failing.FindOne(ctx, projecta.VendorFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find one, got %v", err)
+		}
+
+		missing := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{findOneErr: exceptions.NotFoundError}, &mockPaymentRepo{}, projects)
+		if _, err := missing.Report(ctx, projecta.VendorFilter{}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := missing.Update(ctx, projecta.UpdateVendorCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on update, got %v", err)
+		}
+		for name, link := range map[string]func(context.Context, projecta.LinkVendorResourceCommand) error{
+			"link payment":   missing.LinkPayment,
+			"unlink payment": missing.UnlinkPayment,
+			"link asset":     missing.LinkAsset,
+			"unlink asset":   missing.UnlinkAsset,
+		} {
+			if err := link(ctx, projecta.LinkVendorResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+
+		saveErr := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{vendor: newVendor(requesterID, proj.ProjectID), saveErr: errors.New("db"), removeErr: errors.New("db"), findErr: errors.New("db")}, &mockPaymentRepo{}, projects)
+		if err := saveErr.Update(ctx, projecta.UpdateVendorCommand{ProjectID: proj.ProjectID, Name: "Renamed"}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on update, got %v", err)
+		}
+		if err := saveErr.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on remove, got %v", err)
+		}
+		if _, err := saveErr.Report(ctx, projecta.VendorFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on report, got %v", err)
+		}
+
+		for name, repo := range map[string]*mockVendorRepo{
+			"count":            {vendor: newVendor(requesterID, uuid.Nil), countErr: errors.New("db")},
+			"remove contracts": {vendor: newVendor(requesterID, uuid.Nil), dropErr: errors.New("db")},
+		} {
+			svc := projecta.NewVendorService(&mockImportDb{}, repo, &mockPaymentRepo{}, projects)
+			if err := svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+				t.Errorf("%s: expected internal error on remove, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Keeps a shared vendor under contract in other projects", func(t *testing.T) {
+		svc := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{vendor: newVendor(requesterID, uuid.Nil), contracts: 2}, &mockPaymentRepo{}, projects)
+
+		if err := svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("Links payments and assets", func(t *testing.T) {
+		v := newVendor(requesterID, proj.ProjectID)
+		repo := &mockVendorRepo{vendor: v, totals: []*projecta.VendorSubtotal{{Amount: money.New(1000, "UAH"), Count: 1, Status: projecta.PaymentPaid}}}
+		payment := newPayment(uuid.Nil, uuid.Nil)
+		svc := projecta.NewVendorService(&mockImportDb{}, repo, &mockPaymentRepo{pay: payment}, projects)
+		cmd := projecta.LinkVendorResourceCommand{ProjectID: proj.ProjectID, VendorID: v.ID, ResourceID: payment.ID}
+
+		if err := svc.LinkPayment(ctx, cmd); err != nil {
+			t.Errorf("LinkPayment error: %v", err)
+		}
+		if err := svc.UnlinkPayment(ctx, cmd); err != nil {
+			t.Errorf("UnlinkPayment error: %v", err)
+		}
+		if err := svc.LinkAsset(ctx, cmd); err != nil {
+			t.Errorf("LinkAsset error: %v", err)
+		}
+		if err := svc.UnlinkAsset(ctx, cmd); err != nil {
+			t.Errorf("UnlinkAsset error: %v", err)
+		}
+		if len(repo.linked) != 4 {
+			t.Errorf("expected 4 links, got %d", len(repo.linked))
+		}
+
+		report, err := svc.Report(ctx, projecta.VendorFilter{ProjectID: proj.ProjectID, VendorID: v.ID})
+		if err != nil || report.Payments != 1 || report.Paid[0].Amount() != 1000 {
+			t.Errorf("unexpected report %+v: %v", report, err)
+		}
+	})
+
+	t.Run("Link failures", func(t *testing.T) {
+		v := newVendor(requesterID, proj.ProjectID)
+		cmd := projecta.LinkVendorResourceCommand{ProjectID: proj.ProjectID, VendorID: v.ID}
+		link := func(repo *mockVendorRepo, payments *mockPaymentRepo) error {
+			return projecta.NewVendorService(&mockImportDb{}, repo, payments, projects).LinkPayment(ctx, cmd)
+		}
+
+		cases := map[string]struct {
+			repo     *mockVendorRepo
+			payments *mockPaymentRepo
+			code     exceptions.ErrorCode
+		}{
+			"payment not found":     {&mockVendorRepo{vendor: v}, &mockPaymentRepo{findOneErr: exceptions.NotFoundError}, exceptions.NotFound},
+			"payment lookup failed": {&mockVendorRepo{vendor: v}, &mockPaymentRepo{findOneErr: errors.New("db")}, exceptions.Internal},
+			"other contract vendor": {&mockVendorRepo{vendor: v}, &mockPaymentRepo{pay: newPayment(uuid.New(), uuid.New())}, exceptions.ValidationFailed},
+			"link failed":           {&mockVendorRepo{vendor: v, linkErr: errors.New("db")}, &mockPaymentRepo{pay: newPayment(v.ID, uuid.New())}, exceptions.Internal},
+		}
+		for name, c := range cases {
+			if err := link(c.repo, c.payments); !hasCode(err, c.code) {
+				t.Errorf("%s: expected %s, got %v", name, c.code, err)
+			}
+		}
+
+		notLinked := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{vendor: v, linkErr: exceptions.NewNotFoundException("asset not found", nil)}, &mockPaymentRepo{}, projects)
+		if err := notLinked.LinkAsset(ctx, cmd); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on link asset, got %v", err)
+		}
+
+		failing := projecta.NewVendorService(&mockImportDb{}, &mockVendorRepo{vendor: v, linkErr: errors.New("db")}, &mockPaymentRepo{}, projects)
+		if err := failing.UnlinkAsset(ctx, cmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on unlink asset, got %v", err)
+		}
+	})
+}
+
+func TestContractService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	projects := &mockProjectRepo{project: proj}
+	vendor, _ := projecta.NewVendor(uuid.New(), requesterID, proj.ProjectID, "Electrician", "", "", "", "", "")
+	vendors := &mockVendorRepo{vendor: vendor}
+
+	newContract := func() *projecta.Contract {
+		c, _ := projecta.NewContract(uuid.New(), proj.ProjectID, vendor.ID, "Wiring", money.New(1000, "UAH"), []*projecta.ContractStage{
+			{Kind: projecta.DownPayment, Amount: money.New(300, "UAH")},
+			{Kind: projecta.UponCompletionPayment, Amount: money.New(700, "UAH")},
+		}, time.Now())
+		return c
+	}
+	newPayment := func(kind projecta.PaymentKind, currency string, vendorID uuid.UUID) *projecta.Payment {
+		p := projecta.NewPayment(uuid.New(), proj, owner, costType, "Wiring", money.New(300, currency), time.Now(), kind)
+		p.VendorID = vendorID
+		return p
+	}
+	createCmd := projecta.CreateContractCommand{
+		ProjectID: proj.ProjectID,
+		VendorID:  vendor.ID,
+		Title:     "Wiring",
+		Total:     money.New(1000, "UAH"),
+	}
+	updateCmd := func(c *projecta.Contract) projecta.UpdateContractCommand {
+		return projecta.UpdateContractCommand{
+			ProjectID: proj.ProjectID,
+			ID:        c.ID,
+			VendorID:  c.VendorID,
+			Title:     "Wiring and lights",
+			Total:     money.New(1200, "UAH"),
+			Stages: []*projecta.ContractStage{
+				{Kind: projecta.DownPayment, Amount: money.New(300, "UAH")},
+				{Kind: projecta.UponCompletionPayment, Amount: money.New(900, "UAH")},
+			},
+		}
+	}
+
+	t.Run("Create, find and remove", func(t *testing.T) {
+		repo := &mockContractRepo{contract: newContract()}
+		svc := projecta.NewContractService(repo, vendors, &mockPaymentRepo{}, projects)
+
+		c, err := svc.Create(ctx, createCmd)
+		if err != nil || c.ProjectID != proj.ProjectID || len(c.Stages) != 1 || len(repo.saved) != 1 {
+			t.Fatalf("unexpected contract %+v: %v", c, err)
+		}
+
+		if _, err = svc.Create(ctx, projecta.CreateContractCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		if col, err := svc.Find(ctx, projecta.ContractCollectionFilter{ProjectID: proj.ProjectID}); err != nil || col.Total() != 1 {
+			t.Errorf("unexpected contracts %v: %v", col, err)
+		}
+
+		if err = svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: repo.contract.ID}); err != nil {
+			t.Errorf("Remove error: %v", err)
+		}
+	})
+
+	t.Run("Vendor must be one of the project", func(t *testing.T) {
+		missing := projecta.NewContractService(&mockContractRepo{}, &mockVendorRepo{findOneErr: exceptions.NotFoundError}, &mockPaymentRepo{}, projects)
+		if _, err := missing.Create(ctx, createCmd); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		failing := projecta.NewContractService(&mockContractRepo{}, &mockVendorRepo{findOneErr: errors.New("db")}, &mockPaymentRepo{}, projects)
+		if _, err := failing.Create(ctx, createCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		c := newContract()
+		cmd := updateCmd(c)
+		cmd.VendorID = uuid.New()
+		if err := projecta.NewContractService(&mockContractRepo{contract: c}, &mockVendorRepo{findOneErr: exceptions.NotFoundError}, &mockPaymentRepo{}, projects).Update(ctx, cmd); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error on update, got %v", err)
+		}
+	})
+
+	t.Run("Repository failures", func(t *testing.T) {
+		failing := projecta.NewContractService(&mockContractRepo{contract: newContract(), findErr: errors.New("db"), findOneErr: errors.New("db"), saveErr: errors.New("db")}, vendors, &mockPaymentRepo{}, projects)
+
+		if _, err := failing.Create(ctx, createCmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on create, got %v", err)
+		}
+		if _, err := failing.Find(ctx, projecta.ContractCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find, got %v", err)
+		}
+		if _, err := failing.FindOne(ctx, projecta.ContractFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find one, got %v", err)
+		}
+
+		missing := projecta.NewContractService(&mockContractRepo{findOneErr: exceptions.NotFoundError}, vendors, &mockPaymentRepo{}, projects)
+		if _, err := missing.Report(ctx, projecta.ContractFilter{}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := missing.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on remove, got %v", err)
+		}
+		if err := missing.Update(ctx, projecta.UpdateContractCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on update, got %v", err)
+		}
+		if err := missing.LinkPayment(ctx, projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on link, got %v", err)
+		}
+		if err := missing.UnlinkPayment(ctx, projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on unlink, got %v", err)
+		}
+
+		removeErr := projecta.NewContractService(&mockContractRepo{contract: newContract(), removeErr: errors.New("db"), paymentsErr: errors.New("db")}, vendors, &mockPaymentRepo{}, projects)
+		if err := removeErr.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on remove, got %v", err)
+		}
+		if _, err := removeErr.Report(ctx, projecta.ContractFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on report, got %v", err)
+		}
+	})
+
+	t.Run("Update keeps the payments made valid", func(t *testing.T) {
+		c := newContract()
+		repo := &mockContractRepo{contract: c, payments: []*projecta.ContractPayment{{ContractID: c.ID, PaymentID: uuid.New(), Kind: projecta.DownPayment, Status: projecta.PaymentPaid, Amount: money.New(300, "UAH")}}}
+		svc := projecta.NewContractService(repo, vendors, &mockPaymentRepo{}, projects)
+		cmd := updateCmd(c)
+
+		if err := svc.Update(ctx, cmd); err != nil || len(repo.saved) != 1 || repo.saved[0].ID != c.ID || repo.saved[0].Total.Amount() != 1200 {
+			t.Fatalf("unexpected update: %v", err)
+		}
+
+		otherVendor := cmd
+		otherVendor.VendorID = uuid.New()
+		if err := svc.Update(ctx, otherVendor); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected vendor error, got %v", err)
+		}
+
+		otherCurrency := cmd
+		otherCurrency.Total, otherCurrency.Stages = money.New(1000, "USD"), nil
+		if err := svc.Update(ctx, otherCurrency); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected currency error, got %v", err)
+		}
+
+		droppedStage := cmd
+		droppedStage.Stages = nil
+		if err := svc.Update(ctx, droppedStage); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected stage error, got %v", err)
+		}
+
+		invalid := cmd
+		invalid.Title = ""
+		if err := svc.Update(ctx, invalid); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		repo.paymentsErr = errors.New("db")
+		if err := svc.Update(ctx, cmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+
+		repo.paymentsErr, repo.saveErr = nil, errors.New("db")
+		if err := svc.Update(ctx, cmd); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on save, got %v", err)
+		}
+	})
+
+	t.Run("Links payments to the stages", func(t *testing.T) {
+		c := newContract()
+		payment := newPayment(projecta.DownPayment, "UAH", uuid.Nil)
+		repo := &mockContractRepo{contract: c, payments: []*projecta.ContractPayment{{ContractID: c.ID, PaymentID: payment.ID, Kind: projecta.DownPayment, Status: projecta.PaymentPaid, Amount: payment.Amount}}}
+		svc := projecta.NewContractService(repo, vendors, &mockPaymentRepo{pay: payment}, projects)
+		cmd := projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID, ContractID: c.ID, PaymentID: payment.ID}
+
+		if err := svc.LinkPayment(ctx, cmd); err != nil || len(repo.linked) != 1 {
+			t.Errorf("LinkPayment error: %v", err)
+		}
+
+		report, err := svc.Report(ctx, projecta.ContractFilter{ProjectID: proj.ProjectID, ContractID: c.ID})
+		if err != nil || report.Paid.Amount() != 300 || report.Remaining.Amount() != 700 {
+			t.Errorf("unexpected report %+v: %v", report, err)
+		}
+
+		if err = svc.UnlinkPayment(ctx, cmd); err != nil {
+			t.Errorf("UnlinkPayment error: %v", err)
+		}
+	})
+
+	t.Run("Link failures", func(t *testing.T) {
+		c, _ := projecta.NewContract(uuid.New(), proj.ProjectID, vendor.ID, "Wiring", money.New(1000, "UAH"), nil, time.Time{})
+		link := func(repo *mockContractRepo, payments *mockPaymentRepo) error {
+			return projecta.NewContractService(repo, vendors, payments, projects).LinkPayment(ctx, projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID, ContractID: c.ID})
+		}
+
+		cases := map[string]struct {
+			repo     *mockContractRepo
+			payments *mockPaymentRepo
+			code     exceptions.ErrorCode
+		}{
+			"payment not found":     {&mockContractRepo{contract: c}, &mockPaymentRepo{findOneErr: exceptions.NotFoundError}, exceptions.NotFound},
+			"payment lookup failed": {&mockContractRepo{contract: c}, &mockPaymentRepo{findOneErr: errors.New("db")}, exceptions.Internal},
+			"other currency":        {&mockContractRepo{contract: c}, &mockPaymentRepo{pay: newPayment(projecta.UponCompletionPayment, "USD", uuid.Nil)}, exceptions.ValidationFailed},
+			"no stage of the kind":  {&mockContractRepo{contract: c}, &mockPaymentRepo{pay: newPayment(projecta.DownPayment, "UAH", uuid.Nil)}, exceptions.ValidationFailed},
+			"other vendor":          {&mockContractRepo{contract: c}, &mockPaymentRepo{pay: newPayment(projecta.UponCompletionPayment, "UAH", uuid.New())}, exceptions.ValidationFailed},
+			"link failed":           {&mockContractRepo{contract: c, linkErr: errors.New("db")}, &mockPaymentRepo{pay: newPayment(projecta.UponCompletionPayment, "UAH", vendor.ID)}, exceptions.Internal},
+		}
+		for name, tc := range cases {
+			if err := link(tc.repo, tc.payments); !hasCode(err, tc.code) {
+				t.Errorf("%s: expected %s, got %v", name, tc.code, err)
+			}
+		}
+
+		unlink := func(linkErr error) error {
+			return projecta.NewContractService(&mockContractRepo{contract: c, linkErr: linkErr}, vendors, &mockPaymentRepo{}, projects).UnlinkPayment(ctx, projecta.LinkContractPaymentCommand{ProjectID: proj.ProjectID, ContractID: c.ID})
+		}
+		if err := unlink(exceptions.NewNotFoundException("contract payment not found", nil)); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on unlink, got %v", err)
+		}
+		if err := unlink(errors.New("db")); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on unlink, got %v", err)
+		}
+	})
+}
+
+type mockEstimateRepo struct {
+	estimate       *projecta.Estimate
+	findErr        error
+	findOneErr     error
+	saveErr        error
+	removeErr      error
+	saveQuoteErr   error
+	removeQuoteErr error
+	saved          []*projecta.Estimate
+	savedQuotes    []*projecta.Quote
+}
+
+func (m *mockEstimateRepo) Find(ctx context.Context, filter projecta.EstimateCollectionFilter) (*projecta.EstimateCollection, error) {
+	if m.findErr != nil {
+		return nil, m.findErr
+	}
+	col := projecta.NewEstimateCollection(1)
+	col.Add(m.estimate)
+	return col, nil
+}
+func (m *mockEstimateRepo) FindOne(ctx context.Context, filter projecta.EstimateFilter) (*projecta.Estimate, error) {
+	if m.findOneErr != nil {
+		return nil, m.findOneErr
+	}
+	return m.estimate, nil
+}
+func (m *mockEstimateRepo) Save(ctx context.Context, estimate *projecta.Estimate) error {
+	if m.saveErr == nil {
+		m.saved = append(m.saved, estimate)
+	}
+	return m.saveErr
+}
+func (m *mockEstimateRepo) Remove(ctx context.Context, estimate *projecta.Estimate) error {
+	return m.removeErr
+}
+func (m *mockEstimateRepo) SaveQuote(ctx context.Context, quote *projecta.Quote) error {
+	if m.saveQuoteErr == nil {
+		m.savedQuotes = append(m.savedQuotes, quote)
+	}
+	return m.saveQuoteErr
+}
+func (m *mockEstimateRepo) RemoveQuote(ctx context.Context, quote *projecta.Quote) error {
+	return m.removeQuoteErr
+}
+
+func TestEstimate(t *testing.T) {
+	typeID, vendorID := uuid.New(), uuid.New()
+	item := func(quantity float64, price *money.Money) *projecta.EstimateItem {
+		return &projecta.EstimateItem{TypeID: typeID, Quantity: quantity, UnitPrice: price}
+	}
+
+	t.Run("Quote validation", func(t *testing.T) {
+		for name, items := range map[string][]*projecta.EstimateItem{
+			"no items":          nil,
+			"no cost type":      {{Quantity: 1, UnitPrice: money.New(1, "UAH")}},
+			"zero quantity":     {item(0, money.New(1, "UAH"))},
+			"nan quantity":      {item(math.NaN(), money.New(1, "UAH"))},
+			"infinite quantity": {item(math.Inf(1), money.New(1, "UAH"))},
+			"no price":          {item(1, nil)},
+			"negative price":    {item(1, money.New(-1, "UAH"))},
+			"unknown currency":  {item(1, money.New(1, "XYZ"))},
+		} {
+			if _, err := projecta.NewQuote(uuid.New(), uuid.New(), vendorID, "", items); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		if _, err := projecta.NewQuote(uuid.New(), uuid.New(), uuid.Nil, "", []*projecta.EstimateItem{item(1, money.New(1, "UAH"))}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected vendor validation error, got %v", err)
+		}
+
+		q, err := projecta.NewQuote(uuid.New(), uuid.New(), vendorID, " Copper ", []*projecta.EstimateItem{
+			{TypeID: typeID, Description: " Cable ", Quantity: 12.5, UnitPrice: money.New(333, "UAH")},
+			item(2, money.New(1000, "USD")),
+			item(0.5, money.New(101, "UAH")),
+		})
+		if err != nil || q.Note != "Copper" || q.Items[0].Description != "Cable" {
+			t.Fatalf("unexpected quote %+v: %v", q, err)
+		}
+		if amount := q.Items[0].Amount(); amount.Amount() != 4163 || amount.Currency().Code != "UAH" {
+			t.Errorf("expected the amount rounded to 4163 UAH, got %v", amount.Display())
+		}
+		// 2.675 is slightly less as a binary fraction, the half is kept exact
+		if amount := item(2.675, money.New(100, "UAH")).Amount(); amount.Amount() != 268 {
+			t.Errorf("expected the half rounded up to 268, got %d", amount.Amount())
+		}
+
+		totals := q.Totals()
+		if len(totals) != 2 || totals[0].Currency().Code != "UAH" || totals[0].Amount() != 4214 || totals[1].Currency().Code != "USD" || totals[1].Amount() != 2000 {
+			t.Errorf("unexpected totals %v", totals)
+		}
+	})
+
+	t.Run("Accepting a quote closes the estimate", func(t *testing.T) {
+		if _, err := projecta.NewEstimate(uuid.New(), uuid.New(), "  "); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		e, _ := projecta.NewEstimate(uuid.New(), uuid.New(), " Wiring ")
+		q, _ := projecta.NewQuote(uuid.New(), e.ID, vendorID, "", []*projecta.EstimateItem{item(1, money.New(1, "UAH"))})
+		e.Quotes = append(e.Quotes, q)
+
+		if e.Title != "Wiring" || e.IsAccepted() || e.EnsureOpen() != nil || e.Quote(q.ID) != q || e.Quote(uuid.New()) != nil {
+			t.Fatalf("unexpected estimate %+v", e)
+		}
+		if err := e.Accept(uuid.New()); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := e.Accept(q.ID); err != nil || !e.IsAccepted() || e.AcceptedQuoteID != q.ID {
+			t.Fatalf("unexpected acceptance %+v: %v", e, err)
+		}
+		if err := e.EnsureOpen(); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if err := e.Accept(q.ID); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error on second acceptance, got %v", err)
+		}
+	})
+
+	t.Run("Comparison ranks the quotes from the cheapest", func(t *testing.T) {
+		e, _ := projecta.NewEstimate(uuid.New(), uuid.New(), "Wiring")
+		for i := 0; i < 4; i++ {
+			q, _ := projecta.NewQuote(uuid.New(), e.ID, vendorID, "", []*projecta.EstimateItem{item(1, money.New(1, "UAH"))})
+			e.Quotes = append(e.Quotes, q)
+		}
+		totals := map[uuid.UUID]int64{e.Quotes[0].ID: 500, e.Quotes[1].ID: 300, e.Quotes[2].ID: 500, e.Quotes[3].ID: 700}
+
+		comparison := projecta.NewEstimateComparison(e, "UAH", totals)
+		if comparison.Currency != "UAH" || comparison.Estimate != e || len(comparison.Quotes) != 4 {
+			t.Fatalf("unexpected comparison %+v", comparison)
+		}
+
+		expected := []struct {
+			quote      *projecta.Quote
+			rank       int
+			difference int64
+		}{
+			{e.Quotes[1], 1, 0},
+			{e.Quotes[0], 2, 200},
+			{e.Quotes[2], 2, 200},
+			{e.Quotes[3], 4, 400},
+		}
+		for i, want := range expected {
+			if got := comparison.Quotes[i]; got.Quote != want.quote || got.Rank != want.rank || got.Difference != want.difference {
+				t.Errorf("%d: unexpected ranked quote %+v", i, got)
+			}
+		}
+
+		if empty := projecta.NewEstimateComparison(&projecta.Estimate{}, "UAH", nil); len(empty.Quotes) != 0 {
+			t.Errorf("unexpected comparison %+v", empty)
+		}
+	})
+
+	t.Run("Quote targets", func(t *testing.T) {
+		if target, err := projecta.ToQuoteTarget("contract"); err != nil || target != projecta.QuoteToContract || target.String() != "CONTRACT" {
+			t.Errorf("unexpected target %v: %v", target, err)
+		}
+		if target, err := projecta.ToQuoteTarget("PAYMENTS"); err != nil || target != projecta.QuoteToPayments {
+			t.Errorf("unexpected target %v: %v", target, err)
+		}
+		if _, err := projecta.ToQuoteTarget("invoice"); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+}
+
+func TestEstimateService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Works", "")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Wiring", "")
+	vendor, _ := projecta.NewVendor(uuid.New(), requesterID, proj.ProjectID, "Electrician", "", "", "", "", "")
+	projects := &mockProjectRepo{project: proj}
+	dueDate := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	items := func(prices ...*money.Money) []*projecta.EstimateItem {
+		list := make([]*projecta.EstimateItem, 0, len(prices))
+		for i, price := range prices {
+			list = append(list, &projecta.EstimateItem{TypeID: costType.ID, Description: []string{"Cable", ""}[i%2], Quantity: 2, UnitPrice: price})
+		}
+		return list
+	}
+	newEstimate := func(prices ...*money.Money) *projecta.Estimate {
+		e, _ := projecta.NewEstimate(uuid.New(), proj.ProjectID, "Wiring")
+		q, _ := projecta.NewQuote(uuid.New(), e.ID, vendor.ID, "", items(prices...))
+		e.Quotes = append(e.Quotes, q)
+		return e
+	}
+
+	type deps struct {
+		db        *mockImportDb
+		estimates *mockEstimateRepo
+		vendors   *mockVendorRepo
+		types     *mockTypeRepo
+		contracts *mockContractRepo
+		payments  *mockPaymentRepo
+		people    *mockPeopleService
+	}
+
+	newService := func(e *projecta.Estimate) (*projecta.EstimateServiceImpl, deps) {
+		d := deps{
+			db:        &mockImportDb{},
+			estimates: &mockEstimateRepo{estimate: e},
+			vendors:   &mockVendorRepo{vendor: vendor},
+			types:     &mockTypeRepo{costType: costType},
+			contracts: &mockContractRepo{},
+			payments:  &mockPaymentRepo{},
+			people:    &mockPeopleService{owner: owner},
+		}
+		svc := projecta.NewEstimateService(d.db, d.estimates, d.vendors, d.types, d.contracts, d.payments, projects, d.people, &mockExchangeRates{rate: 40}, nil)
+		return svc, d
+	}
+
+	t.Run("Create, find, update and remove", func(t *testing.T) {
+		svc, d := newService(newEstimate(money.New(100, "UAH")))
+
+		e, err := svc.Create(ctx, projecta.CreateEstimateCommand{ProjectID: proj.ProjectID, Title: "Wiring"})
+		if err != nil || e.ProjectID != proj.ProjectID || len(d.estimates.saved) != 1 {
+			t.Fatalf("unexpected estimate %+v: %v", e, err)
+		}
+		if _, err = svc.Create(ctx, projecta.CreateEstimateCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		if col, err := svc.Find(ctx, projecta.EstimateCollectionFilter{ProjectID: proj.ProjectID}); err != nil || col.Total() != 1 {
+			t.Errorf("unexpected estimates %v: %v", col, err)
+		}
+
+		existing := d.estimates.estimate
+		if err = svc.Update(ctx, projecta.UpdateEstimateCommand{ProjectID: proj.ProjectID, ID: existing.ID, Title: "Rewiring"}); err != nil {
+			t.Fatalf("Update error: %v", err)
+		}
+		if updated := d.estimates.saved[1]; updated.Title != "Rewiring" || len(updated.Quotes) != 1 {
+			t.Errorf("expected the quotes to be kept, got %+v", updated)
+		}
+		if err = svc.Update(ctx, projecta.UpdateEstimateCommand{ProjectID: proj.ProjectID, ID: existing.ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		if err = svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: existing.ID}); err != nil {
+			t.Errorf("Remove error: %v", err)
+		}
+	})
+
+	t.Run("Repository errors", func(t *testing.T) {
+		dbErr := errors.New("db")
+		e := newEstimate(money.New(100, "UAH"))
+		q := e.Quotes[0]
+
+		svc, d := newService(e)
+		d.estimates.findErr, d.estimates.saveErr, d.estimates.removeErr, d.estimates.removeQuoteErr = dbErr, dbErr, dbErr, dbErr
+
+		if _, err := svc.Find(ctx, projecta.EstimateCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on find, got %v", err)
+		}
+		if _, err := svc.Create(ctx, projecta.CreateEstimateCommand{ProjectID: proj.ProjectID, Title: "Wiring"}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on create, got %v", err)
+		}
+		if err := svc.Update(ctx, projecta.UpdateEstimateCommand{ProjectID: proj.ProjectID, ID: e.ID, Title: "Wiring"}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on update, got %v", err)
+		}
+		if err := svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID, ResourceID: e.ID}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on remove, got %v", err)
+		}
+		if err := svc.RemoveQuote(ctx, projecta.RemoveQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: q.ID}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error on remove quote, got %v", err)
+		}
+
+		d.estimates.findOneErr = exceptions.NotFoundError
+		if _, err := svc.FindOne(ctx, projecta.EstimateFilter{}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err := svc.Remove(ctx, projecta.RemoveProjectResourceCommand{ProjectID: proj.ProjectID}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error on remove, got %v", err)
+		}
+		d.estimates.findOneErr = dbErr
+		if _, err := svc.FindOne(ctx, projecta.EstimateFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Quotes", func(t *testing.T) {
+		e := newEstimate(money.New(100, "UAH"))
+		svc, d := newService(e)
+		command := projecta.CreateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, VendorID: vendor.ID, Items: items(money.New(100, "UAH"), money.New(5, "USD"))}
+
+		q, err := svc.AddQuote(ctx, command)
+		if err != nil || q.EstimateID != e.ID || len(d.estimates.savedQuotes) != 1 || d.db.txs != 1 {
+			t.Fatalf("unexpected quote %+v: %v", q, err)
+		}
+		if _, err = svc.AddQuote(ctx, projecta.CreateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, VendorID: vendor.ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		existing := e.Quotes[0]
+		update := projecta.UpdateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, ID: existing.ID, VendorID: vendor.ID, Note: "Copper", Items: items(money.New(200, "UAH"))}
+		if err = svc.UpdateQuote(ctx, update); err != nil || d.estimates.savedQuotes[1].ID != existing.ID || d.estimates.savedQuotes[1].Note != "Copper" {
+			t.Fatalf("unexpected update: %v", err)
+		}
+		if err = svc.UpdateQuote(ctx, projecta.UpdateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, ID: existing.ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if err = svc.UpdateQuote(ctx, projecta.UpdateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, ID: uuid.New()}); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		if err = svc.RemoveQuote(ctx, projecta.RemoveQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: existing.ID}); err != nil {
+			t.Errorf("RemoveQuote error: %v", err)
+		}
+		d.estimates.saveQuoteErr = errors.New("db")
+		if _, err = svc.AddQuote(ctx, command); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Quotes of an accepted estimate cannot change", func(t *testing.T) {
+		e := newEstimate(money.New(100, "UAH"))
+		_ = e.Accept(e.Quotes[0].ID)
+		svc, _ := newService(e)
+
+		if _, err := svc.AddQuote(ctx, projecta.CreateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, VendorID: vendor.ID, Items: items(money.New(1, "UAH"))}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error on add, got %v", err)
+		}
+		if err := svc.RemoveQuote(ctx, projecta.RemoveQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error on remove, got %v", err)
+		}
+	})
+
+	t.Run("Vendor and cost types must be of the project", func(t *testing.T) {
+		e := newEstimate(money.New(100, "UAH"))
+		command := projecta.CreateQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, VendorID: vendor.ID, Items: items(money.New(1, "UAH"))}
+
+		for name, tc := range map[string]struct {
+			setup func(d deps)
+			code  exceptions.ErrorCode
+		}{
+			"missing vendor":    {func(d deps) { d.vendors.findOneErr = exceptions.NotFoundError }, exceptions.ValidationFailed},
+			"failing vendor":    {func(d deps) { d.vendors.findOneErr = errors.New("db") }, exceptions.Internal},
+			"missing cost type": {func(d deps) { d.types.findOneErr = exceptions.NotFoundError }, exceptions.ValidationFailed},
+			"failing cost type": {func(d deps) { d.types.findOneErr = errors.New("db") }, exceptions.Internal},
+			"failing estimate":  {func(d deps) { d.estimates.findOneErr = errors.New("db") }, exceptions.Internal},
+		} {
+			svc, d := newService(e)
+			tc.setup(d)
+			if _, err := svc.AddQuote(ctx, command); !hasCode(err, tc.code) {
+				t.Errorf("%s: expected %v, got %v", name, tc.code, err)
+			}
+			if len(d.estimates.savedQuotes) != 0 {
+				t.Errorf("%s: unexpected saved quotes", name)
+			}
+		}
+	})
+
+	t.Run("Accept into a contract", func(t *testing.T) {
+		e := newEstimate(money.New(300, "UAH"), money.New(200, "UAH"))
+		q := e.Quotes[0]
+		svc, d := newService(e)
+		signedAt := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+		acceptance, err := svc.Accept(ctx, projecta.AcceptQuoteCommand{
+			ProjectID:   proj.ProjectID,
+			EstimateID:  e.ID,
+			QuoteID:     q.ID,
+			Target:      projecta.QuoteToContract,
+			DownPayment: money.New(400, "UAH"),
+			SignedAt:    signedAt,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		c := acceptance.Contract
+		if c == nil || c.VendorID != vendor.ID || c.Title != "Wiring" || c.Total.Amount() != 1000 || len(c.Stages) != 2 || c.Stage(projecta.UponCompletionPayment).Amount.Amount() != 600 || !c.SignedAt.Equal(signedAt) {
+			t.Fatalf("unexpected contract %+v", c)
+		}
+		if e.AcceptedQuoteID != q.ID || len(d.contracts.saved) != 1 || len(d.estimates.saved) != 1 || d.db.txs != 1 || len(acceptance.Payments) != 0 {
+			t.Errorf("expected the contract and the estimate saved together, got %+v", d)
+		}
+
+		e = newEstimate(money.New(300, "UAH"))
+		svc, d = newService(e)
+		acceptance, err = svc.Accept(ctx, projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToContract})
+		if err != nil || len(acceptance.Contract.Stages) != 1 || acceptance.Contract.Stages[0].Kind != projecta.UponCompletionPayment {
+			t.Errorf("expected the total paid upon completion, got %+v: %v", acceptance, err)
+		}
+
+		e = newEstimate(money.New(300, "UAH"))
+		svc, _ = newService(e)
+		acceptance, err = svc.Accept(ctx, projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToContract, DownPayment: money.New(600, "UAH")})
+		if err != nil || len(acceptance.Contract.Stages) != 1 || acceptance.Contract.Stages[0].Kind != projecta.DownPayment {
+			t.Errorf("expected the total paid upfront, got %+v: %v", acceptance, err)
+		}
+
+		for name, tc := range map[string]struct {
+			estimate *projecta.Estimate
+			command  func(e *projecta.Estimate) projecta.AcceptQuoteCommand
+		}{
+			"several currencies": {newEstimate(money.New(300, "UAH"), money.New(5, "USD")), func(e *projecta.Estimate) projecta.AcceptQuoteCommand {
+				return projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToContract}
+			}},
+			"down payment over the total": {newEstimate(money.New(300, "UAH")), func(e *projecta.Estimate) projecta.AcceptQuoteCommand {
+				return projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToContract, DownPayment: money.New(700, "UAH")}
+			}},
+			"down payment in another currency": {newEstimate(money.New(300, "UAH")), func(e *projecta.Estimate) projecta.AcceptQuoteCommand {
+				return projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToContract, DownPayment: money.New(100, "USD")}
+			}},
+			"unknown target": {newEstimate(money.New(300, "UAH")), func(e *projecta.Estimate) projecta.AcceptQuoteCommand {
+				return projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: "INVOICE"}
+			}},
+		} {
+			svc, d := newService(tc.estimate)
+			if _, err := svc.Accept(ctx, tc.command(tc.estimate)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+			if d.db.txs != 0 {
+				t.Errorf("%s: unexpected transaction", name)
+			}
+		}
+	})
+
+	t.Run("Accept into planned payments", func(t *testing.T) {
+		e := newEstimate(money.New(300, "UAH"), money.New(5, "USD"))
+		q := e.Quotes[0]
+		svc, d := newService(e)
+		command := projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: q.ID, Target: projecta.QuoteToPayments, DueDate: dueDate}
+
+		acceptance, err := svc.Accept(ctx, command)
+		if err != nil || acceptance.Contract != nil || len(acceptance.Payments) != 2 || len(d.payments.saved) != 2 || len(d.estimates.saved) != 1 || d.db.txs != 1 {
+			t.Fatalf("unexpected acceptance %+v: %v", acceptance, err)
+		}
+
+		first, second := acceptance.Payments[0], acceptance.Payments[1]
+		if first.Description != "Cable" || first.Amount.Amount() != 600 || first.Status != projecta.PaymentPlanned || !first.DueDate.Equal(dueDate) || first.VendorID != vendor.ID || first.Owner != owner {
+			t.Errorf("unexpected first payment %+v", first)
+		}
+		if second.Description != "Wiring" || second.Amount.Currency().Code != "USD" || second.HomeAmount == nil || second.HomeAmount.Amount() != 400 {
+			t.Errorf("unexpected second payment %+v", second)
+		}
+
+		if _, err = svc.Accept(ctx, command); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error on second acceptance, got %v", err)
+		}
+		if _, err = svc.Accept(ctx, projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: uuid.New()}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		for name, tc := range map[string]struct {
+			setup   func(d deps)
+			command func(c projecta.AcceptQuoteCommand) projecta.AcceptQuoteCommand
+			ctx     context.Context
+			code    exceptions.ErrorCode
+		}{
+			"no due date": {nil, func(c projecta.AcceptQuoteCommand) projecta.AcceptQuoteCommand {
+				c.DueDate = time.Time{}
+				return c
+			}, ctx, exceptions.ValidationFailed},
+			"unknown quote": {nil, func(c projecta.AcceptQuoteCommand) projecta.AcceptQuoteCommand {
+				c.QuoteID = uuid.New()
+				return c
+			}, ctx, exceptions.NotFound},
+			"failing owner":     {func(d deps) { d.people.err = errors.New("db") }, nil, ctx, exceptions.Internal},
+			"missing cost type": {func(d deps) { d.types.findOneErr = exceptions.NotFoundError }, nil, ctx, exceptions.ValidationFailed},
+			"failing payment":   {func(d deps) { d.payments.saveErr = errors.New("db") }, nil, ctx, exceptions.Internal},
+			"failing estimate":  {func(d deps) { d.estimates.saveErr = errors.New("db") }, nil, ctx, exceptions.Internal},
+		} {
+			e := newEstimate(money.New(300, "UAH"), money.New(5, "USD"))
+			svc, d := newService(e)
+			if tc.setup != nil {
+				tc.setup(d)
+			}
+			accept := projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToPayments, DueDate: dueDate}
+			if tc.command != nil {
+				accept = tc.command(accept)
+			}
+			if _, err := svc.Accept(tc.ctx, accept); !hasCode(err, tc.code) {
+				t.Errorf("%s: expected %v, got %v", name, tc.code, err)
+			}
+		}
+	})
+
+	t.Run("Payments stay unconverted without a rate", func(t *testing.T) {
+		e := newEstimate(money.New(5, "USD"))
+		d := deps{db: &mockImportDb{}, estimates: &mockEstimateRepo{estimate: e}, payments: &mockPaymentRepo{}}
+		svc := projecta.NewEstimateService(d.db, d.estimates, &mockVendorRepo{vendor: vendor}, &mockTypeRepo{costType: costType}, &mockContractRepo{}, d.payments, projects, &mockPeopleService{owner: owner}, &mockExchangeRates{err: errors.New("no rates")}, nil)
+
+		acceptance, err := svc.Accept(ctx, projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToPayments, DueDate: dueDate})
+		if err != nil || len(acceptance.Payments) != 1 || acceptance.Payments[0].HomeAmount != nil {
+			t.Errorf("unexpected acceptance %+v: %v", acceptance, err)
+		}
+	})
+
+	t.Run("Contract save failure rolls the acceptance back", func(t *testing.T) {
+		e := newEstimate(money.New(300, "UAH"))
+		svc, d := newService(e)
+		d.contracts.saveErr = errors.New("db")
+
+		if _, err := svc.Accept(ctx, projecta.AcceptQuoteCommand{ProjectID: proj.ProjectID, EstimateID: e.ID, QuoteID: e.Quotes[0].ID, Target: projecta.QuoteToContract}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+		if len(d.estimates.saved) != 0 {
+			t.Error("expected the estimate not to be saved")
+		}
+	})
+}
+
+func TestTags(t *testing.T) {
+	for name, want := range map[string]string{
+		"Kitchen":             "kitchen",
+		"  Tax   Deductible ": "tax deductible",
+		"ДИТЯЧА":              "дитяча",
+	} {
+		if got, err := projecta.NormalizeTag(name); err != nil || got != want {
+			t.Errorf("NormalizeTag(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	for _, name := range []string{"", "   ", "a,b", "\xff", strings.Repeat("я", projecta.MaxTagLength+1)} {
+		if _, err := projecta.NormalizeTag(name); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for %q, got %v", name, err)
+		}
+	}
+	if _, err := projecta.NormalizeTag(strings.Repeat("я", projecta.MaxTagLength)); err != nil {
+		t.Errorf("expected a tag of %d characters to be kept, got %v", projecta.MaxTagLength, err)
+	}
+
+	tags, err := projecta.NormalizeTags([]string{"tax-deductible", "Kitchen", "kitchen "})
+	if err != nil || strings.Join(tags, "|") != "kitchen|tax-deductible" {
+		t.Errorf("unexpected tags %v, %v", tags, err)
+	}
+	if tags, err = projecta.NormalizeTags(nil); err != nil || len(tags) != 0 {
+		t.Errorf("expected no tags, got %v, %v", tags, err)
+	}
+	if _, err = projecta.NormalizeTags([]string{"kitchen", ""}); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	many := make([]string, 0, projecta.MaxTags+1)
+	for i := 0; i <= projecta.MaxTags; i++ {
+		many = append(many, "tag "+string(rune('a'+i)))
+	}
+	if _, err = projecta.NormalizeTags(many); !hasCode(err, exceptions.ValidationFailed) {
+		t.Errorf("expected too many tags error, got %v", err)
+	}
+	if _, err = projecta.NormalizeTags(append(many[:projecta.MaxTags], "TAG A")); err != nil {
+		t.Errorf("expected duplicates not to count, got %v", err)
+	}
+
+	if projecta.TaggedAsset.String() != "asset" {
+		t.Errorf("unexpected kind %s", projecta.TaggedAsset)
+	}
+}
+
+type mockTagRepo struct {
+	tags      []*projecta.Tag
+	subtotals []*projecta.TagSubtotal
+	filter    projecta.TagCollectionFilter
+	kind      projecta.TaggedKind
+	saved     []string
+	findErr   error
+	saveErr   error
+	totalsErr error
+}
+
+func (m *mockTagRepo) Find(_ context.Context, filter projecta.TagCollectionFilter) ([]*projecta.Tag, error) {
+	m.filter = filter
+	return m.tags, m.findErr
+}
+func (m *mockTagRepo) Save(_ context.Context, kind projecta.TaggedKind, _ uuid.UUID, _ uuid.UUID, tags []string) error {
+	m.kind, m.saved = kind, tags
+	return m.saveErr
+}
+func (m *mockTagRepo) Totals(_ context.Context, _ projecta.TagTotalsFilter) ([]*projecta.TagSubtotal, error) {
+	return m.subtotals, m.totalsErr
+}
+
+func TestTagService(t *testing.T) {
+	requesterID := uuid.New()
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	command := projecta.TagResourceCommand{ProjectID: proj.ProjectID, ResourceID: uuid.New(), Tags: []string{"Kitchen", "kitchen", "tax-deductible"}}
+
+	t.Run("Find", func(t *testing.T) {
+		repo := &mockTagRepo{tags: []*projecta.Tag{{Name: "kitchen", Payments: 1}}}
+		svc := projecta.NewTagService(&mockImportDb{}, repo, &mockProjectRepo{project: proj})
+
+		tags, err := svc.Find(ctx, projecta.TagCollectionFilter{ProjectID: proj.ProjectID, Name: " KIT"})
+		if err != nil || len(tags) != 1 || repo.filter.Name != "kit" || repo.filter.Limit != 10 {
+			t.Errorf("unexpected tags %v with %+v, %v", tags, repo.filter, err)
+		}
+		if _, err = svc.Find(ctx, projecta.TagCollectionFilter{Limit: 3}); err != nil || repo.filter.Limit != 3 {
+			t.Errorf("expected the limit to be kept, got %+v", repo.filter)
+		}
+
+		repo.findErr = errors.New("db")
+		if _, err = svc.Find(ctx, projecta.TagCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Tag payments and assets", func(t *testing.T) {
+		db, repo := &mockImportDb{}, &mockTagRepo{}
+		svc := projecta.NewTagService(db, repo, &mockProjectRepo{project: proj})
+
+		tags, err := svc.TagPayment(ctx, command)
+		if err != nil || strings.Join(tags, "|") != "kitchen|tax-deductible" || strings.Join(repo.saved, "|") != "kitchen|tax-deductible" || repo.kind != projecta.TaggedPayment || db.txs != 1 {
+			t.Errorf("unexpected tags %v saved as %v, %v", tags, repo.saved, err)
+		}
+
+		if tags, err = svc.TagAsset(ctx, projecta.TagResourceCommand{ProjectID: proj.ProjectID, ResourceID: uuid.New()}); err != nil || len(tags) != 0 || repo.kind != projecta.TaggedAsset {
+			t.Errorf("expected the tags of the asset removed, got %v, %v", tags, err)
+		}
+
+		if _, err = svc.TagPayment(ctx, projecta.TagResourceCommand{ProjectID: proj.ProjectID, Tags: []string{"a,b"}}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		repo.saveErr = exceptions.NewNotFoundException("asset not found", nil)
+		if _, err = svc.TagAsset(ctx, command); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		repo.saveErr = errors.New("db")
+		if _, err = svc.TagPayment(ctx, command); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Read-only and missing projects", func(t *testing.T) {
+		viewer, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+		viewer.Role = projecta.RoleViewer
+		repo := &mockTagRepo{}
+
+		if _, err := projecta.NewTagService(&mockImportDb{}, repo, &mockProjectRepo{project: viewer}).TagPayment(ctx, command); err == nil || repo.saved != nil {
+			t.Errorf("expected a viewer not to tag, got %v", err)
+		}
+
+		missing := &mockProjectRepo{findErr: exceptions.NewNotFoundException("project not found", nil)}
+		if _, err := projecta.NewTagService(&mockImportDb{}, repo, missing).TagAsset(ctx, command); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("Totals", func(t *testing.T) {
+		repo := &mockTagRepo{subtotals: []*projecta.TagSubtotal{{Tag: "kitchen", Kind: projecta.TaggedPayment, Amount: money.New(100, "UAH"), Count: 1}}}
+		svc := projecta.NewTagService(&mockImportDb{}, repo, &mockProjectRepo{project: proj})
+
+		if totals, err := svc.Totals(ctx, projecta.TagTotalsFilter{ProjectID: proj.ProjectID}); err != nil || len(totals) != 1 {
+			t.Errorf("unexpected totals %v, %v", totals, err)
+		}
+
+		repo.totalsErr = errors.New("db")
+		if _, err := svc.Totals(ctx, projecta.TagTotalsFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
 }
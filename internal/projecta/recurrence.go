@@ -0,0 +1,117 @@
+package projecta
+
+import (
+	"fmt"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+func (f Frequency) String() string {
+	return string(f)
+}
+
+// Recurrence is the subset of an iCalendar RRULE the payment schedules need:
+// FREQ, INTERVAL and COUNT, e.g. "FREQ=MONTHLY;INTERVAL=3;COUNT=4" for four
+// quarterly payments.
+type Recurrence struct {
+	Frequency Frequency
+	Interval  int
+	// Count limits the number of occurrences, zero means no limit.
+	Count int
+}
+
+// ParseRecurrence reads a recurrence rule, the "RRULE:" prefix is optional.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+
+	if rule == "" {
+		return r, exceptions.NewValidationException("recurrence rule is required", nil)
+	}
+
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+
+		if !ok {
+			return r, exceptions.NewValidationException(fmt.Sprintf("invalid recurrence rule part %q", part), nil)
+		}
+
+		switch name {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Frequency = f
+			default:
+				return r, exceptions.NewValidationException(fmt.Sprintf("unsupported recurrence frequency %q", value), nil)
+			}
+		case "INTERVAL", "COUNT":
+			n, err := strconv.Atoi(value)
+
+			if err != nil || n < 1 {
+				return r, exceptions.NewValidationException(fmt.Sprintf("recurrence %s must be a positive number", strings.ToLower(name)), err)
+			}
+
+			if name == "INTERVAL" {
+				r.Interval = n
+			} else {
+				r.Count = n
+			}
+		default:
+			return r, exceptions.NewValidationException(fmt.Sprintf("unsupported recurrence rule part %q", name), nil)
+		}
+	}
+
+	if r.Frequency == "" {
+		return r, exceptions.NewValidationException("recurrence frequency is required", nil)
+	}
+
+	return r, nil
+}
+
+func (r Recurrence) String() string {
+	rule := fmt.Sprintf("FREQ=%s;INTERVAL=%d", r.Frequency, r.Interval)
+
+	if r.Count > 0 {
+		rule += fmt.Sprintf(";COUNT=%d", r.Count)
+	}
+
+	return rule
+}
+
+// Occurrence returns the date of the n-th occurrence counting from zero, which
+// is start itself. Monthly and yearly schedules keep the day of start, moved to
+// the last day of shorter months, so a schedule starting on the 31st falls on
+// the 30th in April and on the 28th or 29th in February.
+func (r Recurrence) Occurrence(start time.Time, n int) time.Time {
+	step := n * max(r.Interval, 1)
+
+	switch r.Frequency {
+	case Weekly:
+		return start.AddDate(0, 0, 7*step)
+	case Monthly:
+		return addMonths(start, step)
+	case Yearly:
+		return addMonths(start, 12*step)
+	default:
+		return start.AddDate(0, 0, step)
+	}
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+
+	return time.Date(first.Year(), first.Month(), min(t.Day(), last), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
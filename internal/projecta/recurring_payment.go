@@ -0,0 +1,253 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"strings"
+	"time"
+)
+
+const occurrenceDateLayout = "2006-01-02"
+
+// RecurringPayment is a template the scheduler books payments from, one per
+// occurrence of its recurrence between StartDate and EndDate.
+type RecurringPayment struct {
+	ID          uuid.UUID
+	Project     *Project
+	Owner       *Owner
+	Type        *CostType
+	Description string
+	Amount      *money.Money
+	Kind        PaymentKind
+	Recurrence  Recurrence
+	StartDate   time.Time
+	// EndDate is the last day an occurrence may fall on, zero means the
+	// schedule runs until the recurrence count is reached or forever.
+	EndDate time.Time
+	// Processed is the number of occurrences already booked or skipped, the
+	// next one to materialise is the Processed-th.
+	Processed int
+}
+
+func NewRecurringPayment(
+	id uuid.UUID,
+	project *Project,
+	owner *Owner,
+	costType *CostType,
+	description string,
+	amount *money.Money,
+	kind PaymentKind,
+	recurrence Recurrence,
+	startDate time.Time,
+	endDate time.Time,
+) (*RecurringPayment, error) {
+	if costType == nil {
+		return nil, exceptions.NewValidationException("recurring payment type is required", nil)
+	}
+
+	if amount == nil || !amount.IsPositive() {
+		return nil, exceptions.NewValidationException("recurring payment amount must be greater than 0", nil)
+	}
+
+	if money.GetCurrency(amount.Currency().Code) == nil {
+		return nil, exceptions.NewValidationException("unknown recurring payment currency", nil)
+	}
+
+	if _, err := ToPaymentKind(kind.String()); err != nil {
+		return nil, err
+	}
+
+	if startDate.IsZero() {
+		return nil, exceptions.NewValidationException("recurring payment start date is required", nil)
+	}
+
+	startDate = truncateToDay(startDate)
+
+	if !endDate.IsZero() {
+		endDate = truncateToDay(endDate)
+
+		if endDate.Before(startDate) {
+			return nil, exceptions.NewValidationException("recurring payment end date is before its start date", nil)
+		}
+	}
+
+	return &RecurringPayment{
+		ID:          id,
+		Project:     project,
+		Owner:       owner,
+		Type:        costType,
+		Description: strings.TrimSpace(description),
+		Amount:      amount,
+		Kind:        kind,
+		Recurrence:  recurrence,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}, nil
+}
+
+// Occurrence returns the date of the n-th occurrence and false when the
+// schedule ends before it.
+func (p *RecurringPayment) Occurrence(n int) (time.Time, bool) {
+	if n < 0 || (p.Recurrence.Count > 0 && n >= p.Recurrence.Count) {
+		return time.Time{}, false
+	}
+
+	date := p.Recurrence.Occurrence(p.StartDate, n)
+
+	if !p.EndDate.IsZero() && date.After(p.EndDate) {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// NextOccurrence returns the date of the first occurrence not materialised
+// yet and false when the schedule is over.
+func (p *RecurringPayment) NextOccurrence() (time.Time, bool) {
+	return p.Occurrence(p.Processed)
+}
+
+// EnsurePending checks date is an occurrence of the schedule that has not been
+// materialised yet, so it can still be skipped or edited.
+func (p *RecurringPayment) EnsurePending(date time.Time) error {
+	date = truncateToDay(date)
+
+	for n := p.Processed; ; n++ {
+		occurrence, ok := p.Occurrence(n)
+
+		if !ok || occurrence.After(date) {
+			return exceptions.NewValidationException("date is not a pending occurrence of the recurring payment", nil)
+		}
+
+		if occurrence.Equal(date) {
+			return nil
+		}
+	}
+}
+
+// Payment builds the payment booked for the occurrence on date, taking the
+// changes made to that single occurrence into account.
+func (p *RecurringPayment) Payment(id uuid.UUID, date time.Time, occurrence *RecurringOccurrence) *Payment {
+	amount, description := p.Amount, p.Description
+
+	if occurrence != nil {
+		if occurrence.Amount != nil {
+			amount = occurrence.Amount
+		}
+
+		if occurrence.Description != "" {
+			description = occurrence.Description
+		}
+
+		if !occurrence.PaymentDate.IsZero() {
+			date = occurrence.PaymentDate
+		}
+	}
+
+	return NewPayment(id, p.Project, p.Owner, p.Type, description, amount, date, p.Kind)
+}
+
+// Upcoming lists up to limit occurrences not materialised yet, those changed
+// on their own as recorded and the rest as the template says.
+func (p *RecurringPayment) Upcoming(limit int, recorded []*RecurringOccurrence) []*RecurringOccurrence {
+	changes := make(map[string]*RecurringOccurrence, len(recorded))
+	for _, occurrence := range recorded {
+		changes[OccurrenceKey(occurrence.Date)] = occurrence
+	}
+
+	upcoming := make([]*RecurringOccurrence, 0, limit)
+
+	for n := p.Processed; len(upcoming) < limit; n++ {
+		date, ok := p.Occurrence(n)
+
+		if !ok {
+			break
+		}
+
+		occurrence, changed := changes[OccurrenceKey(date)]
+
+		if !changed {
+			occurrence = &RecurringOccurrence{RecurringID: p.ID, Date: date, Status: OccurrencePending}
+		}
+
+		upcoming = append(upcoming, occurrence)
+	}
+
+	return upcoming
+}
+
+type RecurringPaymentCollection = core.PaginatedCollection[*RecurringPayment]
+
+func NewRecurringPaymentCollection(total int) *RecurringPaymentCollection {
+	return core.NewPaginatedCollection[*RecurringPayment](total)
+}
+
+type OccurrenceStatus string
+
+const (
+	// OccurrencePending is an occurrence due to be booked as the template says.
+	OccurrencePending OccurrenceStatus = "PENDING"
+	// OccurrenceEdited is a pending occurrence booked with changed details.
+	OccurrenceEdited OccurrenceStatus = "EDITED"
+	// OccurrenceSkipped is an occurrence nothing gets booked for.
+	OccurrenceSkipped OccurrenceStatus = "SKIPPED"
+	// OccurrenceBooked is an occurrence the payment was booked for.
+	OccurrenceBooked OccurrenceStatus = "BOOKED"
+)
+
+func ToOccurrenceStatus(status string) (OccurrenceStatus, error) {
+	switch s := OccurrenceStatus(strings.ToUpper(status)); s {
+	case OccurrencePending, OccurrenceEdited, OccurrenceSkipped, OccurrenceBooked:
+		return s, nil
+	default:
+		return "", exceptions.NewValidationException("invalid occurrence status", nil)
+	}
+}
+
+func (s OccurrenceStatus) String() string {
+	return string(s)
+}
+
+// RecurringOccurrence records what happened, or is going to happen, to a
+// single occurrence of a recurring payment. Occurrences without a record are
+// booked as the template says.
+type RecurringOccurrence struct {
+	RecurringID uuid.UUID
+	Date        time.Time
+	Status      OccurrenceStatus
+	// Amount, Description and PaymentDate override the template for an edited
+	// occurrence, zero values keep the template ones.
+	Amount      *money.Money
+	Description string
+	PaymentDate time.Time
+	PaymentID   uuid.UUID
+}
+
+// Book marks the occurrence as materialised into the payment.
+func (o *RecurringOccurrence) Book(paymentID uuid.UUID) {
+	o.Status = OccurrenceBooked
+	o.PaymentID = paymentID
+}
+
+// OccurrenceKey identifies an occurrence of a schedule by its date.
+func OccurrenceKey(date time.Time) string {
+	return date.Format(occurrenceDateLayout)
+}
+
+// ParseOccurrenceDate reads an occurrence date in the YYYY-MM-DD form.
+func ParseOccurrenceDate(value string) (time.Time, error) {
+	date, err := time.Parse(occurrenceDateLayout, value)
+
+	if err != nil {
+		return time.Time{}, exceptions.NewValidationException("invalid occurrence date", err)
+	}
+
+	return date, nil
+}
+
+// truncateToDay drops the time of day, occurrences are calendar dates.
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
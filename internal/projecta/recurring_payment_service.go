@@ -0,0 +1,362 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"time"
+)
+
+const (
+	failedToCreateRecurringPayment = "failed to create recurring payment"
+	failedToFindRecurringPayment   = "failed to find recurring payment"
+	failedToRemoveRecurringPayment = "failed to remove recurring payment"
+	failedToChangeOccurrence       = "failed to change recurring payment occurrence"
+	failedToMaterialise            = "failed to materialise recurring payment"
+	// upcomingOccurrences is the number of occurrences listed ahead.
+	upcomingOccurrences = 12
+)
+
+type RecurringPaymentServiceImpl struct {
+	db         core.DbConnection
+	recurring  RecurringPaymentRepository
+	payments   PaymentRepository
+	types      TypeRepository
+	projects   ProjectRepository
+	people     PeopleService
+	rates      ExchangeRates
+	fixedRates FixedRateRepository
+}
+
+func NewRecurringPaymentService(
+	db core.DbConnection,
+	recurring RecurringPaymentRepository,
+	payments PaymentRepository,
+	types TypeRepository,
+	projects ProjectRepository,
+	people PeopleService,
+	rates ExchangeRates,
+	fixedRates FixedRateRepository,
+) *RecurringPaymentServiceImpl {
+	return &RecurringPaymentServiceImpl{
+		db:         db,
+		recurring:  recurring,
+		payments:   payments,
+		types:      types,
+		projects:   projects,
+		people:     people,
+		rates:      rates,
+		fixedRates: fixedRates,
+	}
+}
+
+func (s *RecurringPaymentServiceImpl) Find(ctx context.Context, filter RecurringPaymentCollectionFilter) (*RecurringPaymentCollection, error) {
+	collection, err := s.recurring.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindRecurringPayment, err)
+	}
+
+	return collection, nil
+}
+
+func (s *RecurringPaymentServiceImpl) FindOne(ctx context.Context, filter RecurringPaymentFilter) (*RecurringPayment, error) {
+	p, err := s.recurring.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToFindRecurringPayment, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindRecurringPayment, err)
+	}
+
+	return p, nil
+}
+
+// Upcoming lists the next occurrences of a recurring payment not materialised
+// yet, with the changes made to each of them.
+func (s *RecurringPaymentServiceImpl) Upcoming(ctx context.Context, filter RecurringPaymentFilter) ([]*RecurringOccurrence, error) {
+	p, err := s.FindOne(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	next, ok := p.NextOccurrence()
+
+	if !ok {
+		return []*RecurringOccurrence{}, nil
+	}
+
+	recorded, err := s.recurring.FindOccurrences(ctx, p.ID, next)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindRecurringPayment, err)
+	}
+
+	return p.Upcoming(upcomingOccurrences, recorded), nil
+}
+
+func (s *RecurringPaymentServiceImpl) Create(ctx context.Context, command CreateRecurringPaymentCommand) (*RecurringPayment, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToCreateRecurringPayment, err)
+	}
+
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.people.FindOwner(ctx, personID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateRecurringPayment, err)
+	}
+
+	costType, err := s.types.FindOne(ctx, TypeFilter{TypeID: command.TypeID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return nil, exceptions.NewValidationException(failedToCreateRecurringPayment, err)
+	}
+
+	p, err := NewRecurringPayment(
+		uuid.New(),
+		project,
+		owner,
+		costType,
+		command.Description,
+		command.Amount,
+		command.Kind,
+		command.Recurrence,
+		command.StartDate,
+		command.EndDate,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.recurring.Save(ctx, p); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateRecurringPayment, err)
+	}
+
+	return p, nil
+}
+
+// Remove stops the schedule, the payments it already booked are kept.
+func (s *RecurringPaymentServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	p, err := s.FindOne(ctx, RecurringPaymentFilter{RecurringID: command.ResourceID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.recurring.Remove(ctx, p); err != nil {
+		return exceptions.NewInternalException(failedToRemoveRecurringPayment, err)
+	}
+
+	return nil
+}
+
+// SkipOccurrence leaves a single pending occurrence out, nothing is booked for
+// it.
+func (s *RecurringPaymentServiceImpl) SkipOccurrence(ctx context.Context, command SkipOccurrenceCommand) error {
+	_, err := s.changeOccurrence(ctx, command.ProjectID, command.RecurringID, command.Date, func(p *RecurringPayment, date time.Time) (*RecurringOccurrence, error) {
+		return &RecurringOccurrence{
+			RecurringID: p.ID,
+			Date:        date,
+			Status:      OccurrenceSkipped,
+		}, nil
+	})
+
+	return err
+}
+
+// EditOccurrence changes the amount, description or payment date booked for a
+// single pending occurrence, the rest of the schedule stays as it is.
+func (s *RecurringPaymentServiceImpl) EditOccurrence(ctx context.Context, command EditOccurrenceCommand) (*RecurringOccurrence, error) {
+	return s.changeOccurrence(ctx, command.ProjectID, command.RecurringID, command.Date, func(p *RecurringPayment, date time.Time) (*RecurringOccurrence, error) {
+		if command.Amount != nil && !command.Amount.IsPositive() {
+			return nil, exceptions.NewValidationException("occurrence amount must be greater than 0", nil)
+		}
+
+		return &RecurringOccurrence{
+			RecurringID: p.ID,
+			Date:        date,
+			Status:      OccurrenceEdited,
+			Amount:      command.Amount,
+			Description: command.Description,
+			PaymentDate: command.PaymentDate,
+		}, nil
+	})
+}
+
+func (s *RecurringPaymentServiceImpl) changeOccurrence(
+	ctx context.Context,
+	projectID uuid.UUID,
+	recurringID uuid.UUID,
+	date time.Time,
+	change func(p *RecurringPayment, date time.Time) (*RecurringOccurrence, error),
+) (*RecurringOccurrence, error) {
+	if _, err := FindWritableProject(ctx, s.projects, projectID); err != nil {
+		return nil, err
+	}
+
+	p, err := s.FindOne(ctx, RecurringPaymentFilter{RecurringID: recurringID, ProjectID: projectID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	date = truncateToDay(date)
+
+	if err = p.EnsurePending(date); err != nil {
+		return nil, err
+	}
+
+	occurrence, err := change(p, date)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.recurring.SaveOccurrence(ctx, occurrence); err != nil {
+		return nil, exceptions.NewInternalException(failedToChangeOccurrence, err)
+	}
+
+	return occurrence, nil
+}
+
+// Materialise books the payments of every occurrence due by now. Each schedule
+// is handled in its own transaction under an advisory lock, so several
+// replicas may run it at once without booking an occurrence twice, and a
+// schedule that fails does not hold the others back. It returns the number of
+// payments booked along with the errors of the schedules that failed.
+func (s *RecurringPaymentServiceImpl) Materialise(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.recurring.FindDue(ctx, now)
+
+	if err != nil {
+		return 0, exceptions.NewInternalException(failedToMaterialise, err)
+	}
+
+	var (
+		booked int
+		errs   []error
+	)
+
+	for _, p := range due {
+		// the payments are booked on behalf of the person who set the schedule up
+		ownerCtx := context.WithValue(ctx, core.RequesterIDContextKey, p.Owner.PersonID)
+
+		count, err := s.db.Tx(ownerCtx, func(ctx context.Context) (any, error) {
+			return s.materialise(ctx, p.Project.ProjectID, p.ID, now)
+		})
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring payment %s: %w", p.ID, err))
+			continue
+		}
+
+		booked += count.(int)
+	}
+
+	if len(errs) > 0 {
+		return booked, exceptions.NewInternalException(failedToMaterialise, errors.Join(errs...))
+	}
+
+	return booked, nil
+}
+
+func (s *RecurringPaymentServiceImpl) materialise(ctx context.Context, projectID uuid.UUID, recurringID uuid.UUID, now time.Time) (int, error) {
+	locked, err := s.recurring.Lock(ctx, recurringID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !locked {
+		// another replica is on it
+		return 0, nil
+	}
+
+	// read the schedule again under the lock, it may have moved on meanwhile
+	p, err := s.recurring.FindOne(ctx, RecurringPaymentFilter{RecurringID: recurringID, ProjectID: projectID})
+
+	if err != nil {
+		return 0, err
+	}
+
+	project, err := FindWritableProject(ctx, s.projects, projectID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	p.Project = project
+
+	next, ok := p.NextOccurrence()
+
+	if !ok {
+		return 0, nil
+	}
+
+	recorded, err := s.recurring.FindOccurrences(ctx, p.ID, next)
+
+	if err != nil {
+		return 0, err
+	}
+
+	changes := make(map[string]*RecurringOccurrence, len(recorded))
+	for _, occurrence := range recorded {
+		changes[OccurrenceKey(occurrence.Date)] = occurrence
+	}
+
+	booked := 0
+
+	for date, ok := p.NextOccurrence(); ok && !date.After(now); date, ok = p.NextOccurrence() {
+		occurrence := changes[OccurrenceKey(date)]
+
+		if occurrence == nil {
+			occurrence = &RecurringOccurrence{RecurringID: p.ID, Date: date}
+		}
+
+		if occurrence.Status != OccurrenceSkipped {
+			payment := p.Payment(uuid.New(), date, occurrence)
+
+			if err = convertPayment(ctx, payment, 0, false, s.fixedRates, s.rates); err != nil {
+				return 0, err
+			}
+
+			if err = s.payments.Save(ctx, payment); err != nil {
+				return 0, err
+			}
+
+			occurrence.Book(payment.ID)
+
+			if err = s.recurring.SaveOccurrence(ctx, occurrence); err != nil {
+				return 0, err
+			}
+
+			booked++
+		}
+
+		p.Processed++
+	}
+
+	if err = s.recurring.Save(ctx, p); err != nil {
+		return 0, err
+	}
+
+	return booked, nil
+}
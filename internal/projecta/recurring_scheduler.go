@@ -0,0 +1,53 @@
+package projecta
+
+import (
+	"context"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"time"
+)
+
+const defaultSchedulerInterval = 15 * time.Minute
+
+// RecurringPaymentScheduler books the due occurrences of the recurring
+// payments in the background. Every web replica runs one, the service takes
+// care they do not book the same occurrence twice.
+type RecurringPaymentScheduler struct {
+	service  RecurringPaymentService
+	logger   core.Logger
+	interval time.Duration
+}
+
+func NewRecurringPaymentScheduler(service RecurringPaymentService, logger core.Logger, interval time.Duration) *RecurringPaymentScheduler {
+	if interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+
+	return &RecurringPaymentScheduler{
+		service:  service,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Run materialises the due occurrences right away and then every interval,
+// until ctx is done.
+func (s *RecurringPaymentScheduler) Run(ctx context.Context) {
+	for {
+		booked, err := s.service.Materialise(ctx, time.Now())
+
+		if err != nil {
+			s.logger.Error("failed to materialise recurring payments", err, map[string]any{"booked": booked})
+		} else if booked > 0 {
+			s.logger.Info("materialised recurring payments", map[string]any{"booked": booked})
+		}
+
+		timer := time.NewTimer(s.interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
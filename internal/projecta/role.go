@@ -0,0 +1,123 @@
+package projecta
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"time"
+)
+
+// ProjectRole defines what a person may do with a project. The owner role is
+// implied by project ownership, the other roles are granted through shares.
+type ProjectRole string
+
+const (
+	RoleOwner  ProjectRole = "OWNER"
+	RoleAdmin  ProjectRole = "ADMIN"
+	RoleEditor ProjectRole = "EDITOR"
+	RoleViewer ProjectRole = "VIEWER"
+)
+
+func ToProjectRole(role string) (ProjectRole, error) {
+	switch true {
+	case role == RoleOwner.String():
+		return RoleOwner, nil
+	case role == RoleAdmin.String():
+		return RoleAdmin, nil
+	case role == RoleEditor.String():
+		return RoleEditor, nil
+	case role == RoleViewer.String():
+		return RoleViewer, nil
+	default:
+		return "", exceptions.NewValidationException("invalid project role", nil)
+	}
+}
+
+func (r ProjectRole) String() string {
+	return string(r)
+}
+
+// CanEdit reports whether the role allows changing the project content.
+func (r ProjectRole) CanEdit() bool {
+	return r == RoleOwner || r == RoleAdmin || r == RoleEditor
+}
+
+// CanManage reports whether the role allows changing the project settings and
+// sharing it with other people.
+func (r ProjectRole) CanManage() bool {
+	return r == RoleOwner || r == RoleAdmin
+}
+
+// ShareLink grants the role to everyone who accepts its token. A link may stop
+// working after ExpiresAt or once it has been accepted MaxUses times; zero values
+// mean no limit.
+type ShareLink struct {
+	ID        uuid.UUID
+	Token     uuid.UUID
+	Project   *Project
+	Role      ProjectRole
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	MaxUses   int
+	Uses      int
+}
+
+func NewShareLink(
+	id uuid.UUID,
+	token uuid.UUID,
+	project *Project,
+	role ProjectRole,
+	createdBy uuid.UUID,
+	createdAt time.Time,
+	expiresAt time.Time,
+	maxUses int,
+) (*ShareLink, error) {
+	if project == nil {
+		return nil, exceptions.NewValidationException("share link project is required", nil)
+	}
+
+	if role != RoleAdmin && role != RoleEditor && role != RoleViewer {
+		return nil, exceptions.NewValidationException("share link role must be one of ADMIN, EDITOR or VIEWER", nil)
+	}
+
+	if !expiresAt.IsZero() && !expiresAt.After(createdAt) {
+		return nil, exceptions.NewValidationException("share link must expire in the future", nil)
+	}
+
+	if maxUses < 0 {
+		return nil, exceptions.NewValidationException("share link max uses must not be negative", nil)
+	}
+
+	return &ShareLink{
+		ID:        id,
+		Token:     token,
+		Project:   project,
+		Role:      role,
+		CreatedBy: createdBy,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+	}, nil
+}
+
+func (l *ShareLink) IsExpired(at time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !at.Before(l.ExpiresAt)
+}
+
+func (l *ShareLink) IsUsedUp() bool {
+	return l.MaxUses > 0 && l.Uses >= l.MaxUses
+}
+
+// EnsureUsable returns a forbidden exception when the link can no longer be
+// accepted.
+func (l *ShareLink) EnsureUsable(at time.Time) error {
+	if l.IsExpired(at) {
+		return exceptions.NewForbiddenException("share link has expired", nil)
+	}
+
+	if l.IsUsedUp() {
+		return exceptions.NewForbiddenException("share link has been used up", nil)
+	}
+
+	return nil
+}
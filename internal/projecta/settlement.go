@@ -0,0 +1,295 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// DefaultSettlementWeight is the weight of members nobody set a weight for, so
+// the costs are split equally unless told otherwise.
+const DefaultSettlementWeight = 1.0
+
+// SettlementWeight is the part of the project costs a member bears relative to
+// the other members, e.g. 2 for twice the share of a member weighing 1. A zero
+// weight keeps the member out of the split.
+type SettlementWeight struct {
+	ProjectID uuid.UUID
+	PersonID  uuid.UUID
+	Weight    float64
+}
+
+func NewSettlementWeight(projectID uuid.UUID, personID uuid.UUID, weight float64) (*SettlementWeight, error) {
+	if weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		return nil, exceptions.NewValidationException("settlement weight must not be negative", nil)
+	}
+
+	return &SettlementWeight{
+		ProjectID: projectID,
+		PersonID:  personID,
+		Weight:    weight,
+	}, nil
+}
+
+// SettlementTransfer is money a member handed to another one to settle up. It
+// only moves money between members, so it never counts as project spending.
+type SettlementTransfer struct {
+	ID          uuid.UUID
+	ProjectID   uuid.UUID
+	From        *Owner
+	To          *Owner
+	Amount      *money.Money
+	Date        time.Time
+	Description string
+}
+
+func NewSettlementTransfer(
+	id uuid.UUID,
+	projectID uuid.UUID,
+	from *Owner,
+	to *Owner,
+	amount *money.Money,
+	date time.Time,
+	description string,
+) (*SettlementTransfer, error) {
+	if from == nil || to == nil {
+		return nil, exceptions.NewValidationException("settlement transfer needs a sender and a recipient", nil)
+	}
+
+	if from.PersonID == to.PersonID {
+		return nil, exceptions.NewValidationException("settlement transfer sender and recipient must differ", nil)
+	}
+
+	if amount == nil || !amount.IsPositive() {
+		return nil, exceptions.NewValidationException("settlement transfer amount must be greater than 0", nil)
+	}
+
+	if money.GetCurrency(amount.Currency().Code) == nil {
+		return nil, exceptions.NewValidationException("unknown settlement transfer currency", nil)
+	}
+
+	return &SettlementTransfer{
+		ID:          id,
+		ProjectID:   projectID,
+		From:        from,
+		To:          to,
+		Amount:      amount,
+		Date:        date,
+		Description: description,
+	}, nil
+}
+
+// SettlementLedger holds what a settlement is computed from besides the
+// payments themselves.
+type SettlementLedger struct {
+	Project   *Project
+	Members   []*ProjectMember
+	Weights   []*SettlementWeight
+	Transfers []*SettlementTransfer
+}
+
+// MemberBalance is what a member put into the project against the share of the
+// costs they bear, in the project main currency.
+type MemberBalance struct {
+	PersonID    uuid.UUID
+	DisplayName string
+	Weight      float64
+	Paid        int64
+	Share       int64
+	Sent        int64
+	Received    int64
+}
+
+// Balance is positive when the member is owed money and negative when they owe.
+func (b *MemberBalance) Balance() int64 {
+	return b.Paid + b.Sent - b.Received - b.Share
+}
+
+// SuggestedTransfer is a payment that settles the balances of two members.
+type SuggestedTransfer struct {
+	From   *MemberBalance
+	To     *MemberBalance
+	Amount int64
+}
+
+// Settlement works out who owes whom. Every member bears a share of the paid
+// total proportional to their weight; people who paid without being members any
+// more, e.g. after leaving the project, get their money back but bear no share
+// unless a weight was set for them.
+type Settlement struct {
+	Total     int64
+	Balances  []*MemberBalance
+	Transfers []*SuggestedTransfer
+
+	weights map[uuid.UUID]float64
+	index   map[uuid.UUID]*MemberBalance
+}
+
+func NewSettlement(members []*ProjectMember, weights []*SettlementWeight) *Settlement {
+	s := &Settlement{
+		Balances:  make([]*MemberBalance, 0, len(members)),
+		Transfers: make([]*SuggestedTransfer, 0),
+		weights:   make(map[uuid.UUID]float64, len(weights)),
+		index:     make(map[uuid.UUID]*MemberBalance, len(members)),
+	}
+
+	for _, w := range weights {
+		s.weights[w.PersonID] = w.Weight
+	}
+
+	for _, m := range members {
+		s.balance(m.PersonID, m.DisplayName, DefaultSettlementWeight)
+	}
+
+	return s
+}
+
+// AddPaid counts amount as paid by owner for the project.
+func (s *Settlement) AddPaid(owner *Owner, amount int64) {
+	s.balance(owner.PersonID, owner.DisplayName, 0).Paid += amount
+	s.Total += amount
+}
+
+// AddTransfer counts amount as handed from one member to another.
+func (s *Settlement) AddTransfer(from *Owner, to *Owner, amount int64) {
+	s.balance(from.PersonID, from.DisplayName, 0).Sent += amount
+	s.balance(to.PersonID, to.DisplayName, 0).Received += amount
+}
+
+// Settle splits the total by the weights and suggests the transfers that even
+// out the balances, matching the biggest debtor with the biggest creditor until
+// everybody is square. That takes at most one transfer less than there are
+// members with a balance.
+func (s *Settlement) Settle() error {
+	if err := s.split(); err != nil {
+		return err
+	}
+
+	debtors := make([]*pendingBalance, 0)
+	creditors := make([]*pendingBalance, 0)
+
+	for _, b := range s.Balances {
+		switch balance := b.Balance(); {
+		case balance < 0:
+			debtors = append(debtors, &pendingBalance{member: b, amount: -balance})
+		case balance > 0:
+			creditors = append(creditors, &pendingBalance{member: b, amount: balance})
+		}
+	}
+
+	s.Transfers = make([]*SuggestedTransfer, 0)
+
+	for len(debtors) > 0 && len(creditors) > 0 {
+		sortPending(debtors)
+		sortPending(creditors)
+
+		debtor, creditor := debtors[0], creditors[0]
+		amount := min(debtor.amount, creditor.amount)
+
+		s.Transfers = append(s.Transfers, &SuggestedTransfer{
+			From:   debtor.member,
+			To:     creditor.member,
+			Amount: amount,
+		})
+
+		debtor.amount -= amount
+		creditor.amount -= amount
+
+		if debtor.amount == 0 {
+			debtors = debtors[1:]
+		}
+		if creditor.amount == 0 {
+			creditors = creditors[1:]
+		}
+	}
+
+	return nil
+}
+
+// split shares the total out in proportion to the weights, handing the minor
+// units lost to rounding to the largest remainders so the shares add up.
+func (s *Settlement) split() error {
+	totalWeight := new(big.Rat)
+
+	for _, b := range s.Balances {
+		totalWeight.Add(totalWeight, core.ExactDecimal(b.Weight))
+	}
+
+	if totalWeight.Sign() == 0 {
+		if s.Total != 0 {
+			return exceptions.NewValidationException("at least one member must bear the project costs", nil)
+		}
+
+		return nil
+	}
+
+	type remainder struct {
+		balance *MemberBalance
+		value   *big.Rat
+	}
+
+	remainders := make([]remainder, 0, len(s.Balances))
+	left := s.Total
+
+	for _, b := range s.Balances {
+		exact := new(big.Rat).Mul(big.NewRat(s.Total, 1), core.ExactDecimal(b.Weight))
+		exact.Quo(exact, totalWeight)
+
+		share := new(big.Int).Quo(exact.Num(), exact.Denom())
+		b.Share = share.Int64()
+		left -= b.Share
+
+		remainders = append(remainders, remainder{
+			balance: b,
+			value:   new(big.Rat).Sub(exact, new(big.Rat).SetInt(share)),
+		})
+	}
+
+	sort.SliceStable(remainders, func(i, j int) bool {
+		return remainders[i].value.Cmp(remainders[j].value) > 0
+	})
+
+	for i := 0; left > 0; i++ {
+		remainders[i%len(remainders)].balance.Share++
+		left--
+	}
+
+	return nil
+}
+
+func (s *Settlement) balance(personID uuid.UUID, displayName string, weight float64) *MemberBalance {
+	if b, ok := s.index[personID]; ok {
+		return b
+	}
+
+	if w, ok := s.weights[personID]; ok {
+		weight = w
+	}
+
+	b := &MemberBalance{
+		PersonID:    personID,
+		DisplayName: displayName,
+		Weight:      weight,
+	}
+
+	s.index[personID] = b
+	s.Balances = append(s.Balances, b)
+
+	return b
+}
+
+type pendingBalance struct {
+	member *MemberBalance
+	amount int64
+}
+
+func sortPending(balances []*pendingBalance) {
+	sort.SliceStable(balances, func(i, j int) bool {
+		return balances[i].amount > balances[j].amount
+	})
+}
@@ -0,0 +1,173 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToFindSettlement     = "failed to find settlement"
+	failedToSetWeight          = "failed to set settlement weight"
+	failedToRecordTransfer     = "failed to record settlement transfer"
+	failedToRemoveTransfer     = "failed to remove settlement transfer"
+	settlementMemberNotFound   = "project member not found"
+	settlementTransferNotFound = "settlement transfer not found"
+)
+
+type SettlementServiceImpl struct {
+	settlements SettlementRepository
+	projects    ProjectRepository
+}
+
+func NewSettlementService(settlements SettlementRepository, projects ProjectRepository) *SettlementServiceImpl {
+	return &SettlementServiceImpl{
+		settlements: settlements,
+		projects:    projects,
+	}
+}
+
+// Ledger loads the members of a project with their weights and the transfers
+// they recorded, everything but the payments a settlement is computed from.
+func (s *SettlementServiceImpl) Ledger(ctx context.Context, projectID uuid.UUID) (*SettlementLedger, error) {
+	project, err := s.projects.FindOne(ctx, ProjectFilter{ProjectID: projectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException("project not found", err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindSettlement, err)
+	}
+
+	members, err := s.projects.FindMembers(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindSettlement, err)
+	}
+
+	weights, err := s.settlements.FindWeights(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindSettlement, err)
+	}
+
+	transfers, err := s.settlements.FindTransfers(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindSettlement, err)
+	}
+
+	return &SettlementLedger{
+		Project:   project,
+		Members:   members,
+		Weights:   weights,
+		Transfers: transfers,
+	}, nil
+}
+
+// SetWeight changes the share of the costs a member bears. It changes what
+// everybody owes, so only the people managing the project may set it.
+func (s *SettlementServiceImpl) SetWeight(ctx context.Context, command SetSettlementWeightCommand) (*SettlementWeight, error) {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = project.EnsureManageable(); err != nil {
+		return nil, err
+	}
+
+	if _, err = s.findMember(ctx, project.ProjectID, command.PersonID); err != nil {
+		return nil, err
+	}
+
+	weight, err := NewSettlementWeight(project.ProjectID, command.PersonID, command.Weight)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.settlements.SaveWeight(ctx, weight); err != nil {
+		return nil, exceptions.NewInternalException(failedToSetWeight, err)
+	}
+
+	return weight, nil
+}
+
+// RecordTransfer books money handed from one member to another. Transfers
+// settle balances and are kept apart from the payments, so they never count as
+// project spending.
+func (s *SettlementServiceImpl) RecordTransfer(ctx context.Context, command RecordSettlementTransferCommand) (*SettlementTransfer, error) {
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.findMember(ctx, project.ProjectID, command.FromID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.findMember(ctx, project.ProjectID, command.ToID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := NewSettlementTransfer(
+		uuid.New(),
+		project.ProjectID,
+		&Owner{PersonID: from.PersonID, DisplayName: from.DisplayName},
+		&Owner{PersonID: to.PersonID, DisplayName: to.DisplayName},
+		command.Amount,
+		command.Date,
+		command.Description,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.settlements.SaveTransfer(ctx, transfer); err != nil {
+		return nil, exceptions.NewInternalException(failedToRecordTransfer, err)
+	}
+
+	return transfer, nil
+}
+
+func (s *SettlementServiceImpl) RemoveTransfer(ctx context.Context, command RemoveProjectResourceCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	if err := s.settlements.RemoveTransfer(ctx, command.ProjectID, command.ResourceID); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException(settlementTransferNotFound, err)
+		}
+
+		return exceptions.NewInternalException(failedToRemoveTransfer, err)
+	}
+
+	return nil
+}
+
+func (s *SettlementServiceImpl) findMember(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) (*ProjectMember, error) {
+	members, err := s.projects.FindMembers(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindSettlement, err)
+	}
+
+	for _, m := range members {
+		if m.PersonID == personID {
+			return m, nil
+		}
+	}
+
+	return nil, exceptions.NewNotFoundException(settlementMemberNotFound, nil)
+}
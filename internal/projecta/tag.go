@@ -0,0 +1,101 @@
+package projecta
+
+import (
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTagLength is the longest tag name kept, in characters.
+	MaxTagLength = 50
+	// MaxTags caps the tags of a payment or an asset.
+	MaxTags = 20
+)
+
+// TaggedKind is the kind of record a tag is put on.
+type TaggedKind string
+
+const (
+	TaggedPayment TaggedKind = "payment"
+	TaggedAsset   TaggedKind = "asset"
+)
+
+func (k TaggedKind) String() string {
+	return string(k)
+}
+
+// Tag is a free-form label of the payments and assets of a project, for the
+// views the category and cost type hierarchy does not cover, e.g. "kitchen" or
+// "tax-deductible".
+type Tag struct {
+	Name string
+	// Payments and Assets count the records carrying the tag.
+	Payments int
+	Assets   int
+}
+
+// NormalizeTag lower-cases the name and collapses its spaces, so "Kitchen " and
+// "kitchen" are the same tag. Commas are refused as they separate the tags of
+// a filter.
+func NormalizeTag(name string) (string, error) {
+	if !utf8.ValidString(name) || strings.Contains(name, ",") {
+		return "", exceptions.NewValidationException(fmt.Sprintf("invalid tag %q", name), nil)
+	}
+
+	name = foldTag(name)
+
+	if name == "" {
+		return "", exceptions.NewValidationException("tag name is required", nil)
+	}
+
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return "", exceptions.NewValidationException(fmt.Sprintf("tag must not be longer than %d characters", MaxTagLength), nil)
+	}
+
+	return name, nil
+}
+
+func foldTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// NormalizeTags normalizes the names and returns them sorted, without
+// duplicates.
+func NormalizeTags(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+
+	if len(tags) > MaxTags {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("no more than %d tags are allowed", MaxTags), nil)
+	}
+
+	return tags, nil
+}
+
+// TagSubtotal sums the paid payments, or the assets, carrying a tag in a
+// currency on the same day. Payments booked in the project main currency are
+// summed by their booked amount.
+type TagSubtotal struct {
+	Tag    string
+	Kind   TaggedKind
+	Amount *money.Money
+	Date   time.Time
+	Count  int
+}
@@ -0,0 +1,96 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToFindTags     = "failed to find tags"
+	failedToTag          = "failed to save tags"
+	failedToSumTags      = "failed to sum tags"
+	defaultTagsSuggested = 10
+)
+
+type TagServiceImpl struct {
+	db       core.DbConnection
+	tags     TagRepository
+	projects ProjectRepository
+}
+
+func NewTagService(
+	db core.DbConnection,
+	tags TagRepository,
+	projects ProjectRepository,
+) *TagServiceImpl {
+	return &TagServiceImpl{
+		db:       db,
+		tags:     tags,
+		projects: projects,
+	}
+}
+
+// Find suggests the tags of the project starting with the name typed so far.
+func (s *TagServiceImpl) Find(ctx context.Context, filter TagCollectionFilter) ([]*Tag, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTagsSuggested
+	}
+
+	filter.Name = foldTag(filter.Name)
+
+	tags, err := s.tags.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindTags, err)
+	}
+
+	return tags, nil
+}
+
+func (s *TagServiceImpl) TagPayment(ctx context.Context, command TagResourceCommand) ([]string, error) {
+	return s.tag(ctx, TaggedPayment, command, FailedToFindPayment)
+}
+
+func (s *TagServiceImpl) TagAsset(ctx context.Context, command TagResourceCommand) ([]string, error) {
+	return s.tag(ctx, TaggedAsset, command, "asset not found")
+}
+
+// tag replaces the tags of the payment or asset with the ones of the command,
+// all of them or none.
+func (s *TagServiceImpl) tag(ctx context.Context, kind TaggedKind, command TagResourceCommand, notFound string) ([]string, error) {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return nil, err
+	}
+
+	tags, err := NormalizeTags(command.Tags)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		return nil, s.tags.Save(ctx, kind, command.ProjectID, command.ResourceID, tags)
+	})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(notFound, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToTag, err)
+	}
+
+	return tags, nil
+}
+
+func (s *TagServiceImpl) Totals(ctx context.Context, filter TagTotalsFilter) ([]*TagSubtotal, error) {
+	totals, err := s.tags.Totals(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToSumTags, err)
+	}
+
+	return totals, nil
+}
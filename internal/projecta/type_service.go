@@ -39,6 +39,10 @@ func (s *TypeServiceImpl) Create(ctx context.Context, command CreateTypeCommand)
 		return nil, exceptions.NewValidationException(failedToCreateCostType, err)
 	}
 
+	if err = project.EnsureWritable(); err != nil {
+		return nil, err
+	}
+
 	category, err := s.categories.FindOne(ctx, CategoryFilter{CategoryID: command.CategoryID})
 
 	if err != nil {
@@ -66,6 +70,10 @@ func (s *TypeServiceImpl) Create(ctx context.Context, command CreateTypeCommand)
 }
 
 func (s *TypeServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
 	t, err := s.types.FindOne(ctx, TypeFilter{
 		TypeID:    command.ResourceID,
 		ProjectID: command.ProjectID,
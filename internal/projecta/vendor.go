@@ -0,0 +1,183 @@
+package projecta
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"math/big"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Vendor is a contractor, shop or service provider the project pays. A vendor
+// belongs to a single project, or with no ProjectID is shared across every
+// project its owner takes part in.
+type Vendor struct {
+	ID          uuid.UUID
+	OwnerID     uuid.UUID
+	ProjectID   uuid.UUID
+	Name        string
+	TaxID       string
+	ContactName string
+	Email       string
+	Phone       string
+	// IBAN is kept without spaces and in upper case.
+	IBAN string
+}
+
+func NewVendor(
+	id uuid.UUID,
+	ownerID uuid.UUID,
+	projectID uuid.UUID,
+	name string,
+	taxID string,
+	contactName string,
+	email string,
+	phone string,
+	iban string,
+) (*Vendor, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return nil, exceptions.NewValidationException("vendor name is required", nil)
+	}
+
+	email = strings.TrimSpace(email)
+
+	if email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return nil, exceptions.NewValidationException("invalid vendor email", err)
+		}
+	}
+
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+
+	if iban != "" && !isValidIBAN(iban) {
+		return nil, exceptions.NewValidationException("invalid vendor IBAN", nil)
+	}
+
+	return &Vendor{
+		ID:          id,
+		OwnerID:     ownerID,
+		ProjectID:   projectID,
+		Name:        name,
+		TaxID:       strings.TrimSpace(taxID),
+		ContactName: strings.TrimSpace(contactName),
+		Email:       email,
+		Phone:       strings.TrimSpace(phone),
+		IBAN:        iban,
+	}, nil
+}
+
+// IsShared reports whether the vendor is available in all the projects of its
+// owner rather than in a single one.
+func (v *Vendor) IsShared() bool {
+	return v.ProjectID == uuid.Nil
+}
+
+// isValidIBAN checks the ISO 13616 check digits: with the country code and
+// the check digits moved to the end and the letters replaced by 10 to 35, the
+// number leaves 1 divided by 97.
+func isValidIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	for i, r := range iban {
+		letter, digit := r >= 'A' && r <= 'Z', r >= '0' && r <= '9'
+
+		if (i < 2 && !letter) || (i >= 2 && i < 4 && !digit) || (!letter && !digit) {
+			return false
+		}
+	}
+
+	var digits strings.Builder
+
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' {
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+
+	return n.Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+type VendorCollection = core.PaginatedCollection[*Vendor]
+
+func NewVendorCollection(total int) *VendorCollection {
+	return core.NewPaginatedCollection[*Vendor](total)
+}
+
+// VendorSubtotal sums the payments of a status, or the assets, bought from a
+// vendor in a currency.
+type VendorSubtotal struct {
+	Amount *money.Money
+	Count  int
+	// Status of the payments summed, empty for the assets.
+	Status PaymentStatus
+}
+
+// VendorReport tells how much has been paid to a vendor, how much more the
+// projects have committed to pay and what the assets bought from it cost. The
+// amounts are kept per currency, as a shared vendor may be paid from projects
+// with different main currencies.
+type VendorReport struct {
+	Vendor    *Vendor
+	Paid      []*money.Money
+	Committed []*money.Money
+	Assets    []*money.Money
+	// Payments is the number of payments made.
+	Payments int
+}
+
+func NewVendorReport(vendor *Vendor, subtotals []*VendorSubtotal) *VendorReport {
+	paid, committed, assets := map[string]int64{}, map[string]int64{}, map[string]int64{}
+	report := &VendorReport{Vendor: vendor}
+
+	for _, s := range subtotals {
+		code := s.Amount.Currency().Code
+
+		switch {
+		case s.Status == "":
+			assets[code] += s.Amount.Amount()
+		case s.Status == PaymentPaid:
+			paid[code] += s.Amount.Amount()
+			report.Payments += s.Count
+		case s.Status.IsCommitted():
+			committed[code] += s.Amount.Amount()
+		}
+	}
+
+	report.Paid = toSortedAmounts(paid)
+	report.Committed = toSortedAmounts(committed)
+	report.Assets = toSortedAmounts(assets)
+
+	return report
+}
+
+// toSortedAmounts turns the sums per currency into amounts ordered by the
+// currency code.
+func toSortedAmounts(sums map[string]int64) []*money.Money {
+	codes := make([]string, 0, len(sums))
+
+	for code := range sums {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	amounts := make([]*money.Money, 0, len(codes))
+
+	for _, code := range codes {
+		amounts = append(amounts, money.New(sums[code], code))
+	}
+
+	return amounts
+}
@@ -0,0 +1,291 @@
+package projecta
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	failedToCreateVendor      = "failed to create vendor"
+	failedToUpdateVendor      = "failed to update vendor"
+	failedToFindVendor        = "failed to find vendor"
+	failedToRemoveVendor      = "failed to remove vendor"
+	failedToLinkVendor        = "failed to link vendor"
+	failedToBuildVendorReport = "failed to build vendor report"
+)
+
+type VendorServiceImpl struct {
+	db       core.DbConnection
+	vendors  VendorRepository
+	payments PaymentRepository
+	projects ProjectRepository
+}
+
+func NewVendorService(
+	db core.DbConnection,
+	vendors VendorRepository,
+	payments PaymentRepository,
+	projects ProjectRepository,
+) *VendorServiceImpl {
+	return &VendorServiceImpl{
+		db:       db,
+		vendors:  vendors,
+		payments: payments,
+		projects: projects,
+	}
+}
+
+func (s *VendorServiceImpl) Find(ctx context.Context, filter VendorCollectionFilter) (*VendorCollection, error) {
+	collection, err := s.vendors.Find(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindVendor, err)
+	}
+
+	return collection, nil
+}
+
+func (s *VendorServiceImpl) FindOne(ctx context.Context, filter VendorFilter) (*Vendor, error) {
+	v, err := s.vendors.FindOne(ctx, filter)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(failedToFindVendor, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToFindVendor, err)
+	}
+
+	return v, nil
+}
+
+func (s *VendorServiceImpl) Create(ctx context.Context, command CreateVendorCommand) (*Vendor, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToCreateVendor, err)
+	}
+
+	project, err := FindWritableProject(ctx, s.projects, command.ProjectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectID := project.ProjectID
+
+	if command.Shared {
+		projectID = uuid.Nil
+	}
+
+	v, err := NewVendor(
+		uuid.New(),
+		personID,
+		projectID,
+		command.Name,
+		command.TaxID,
+		command.ContactName,
+		command.Email,
+		command.Phone,
+		command.IBAN,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.vendors.Save(ctx, v); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateVendor, err)
+	}
+
+	return v, nil
+}
+
+// findWritableVendor loads a vendor of the project about to be changed. A
+// shared vendor may only be changed by the person who shared it, as it is used
+// by projects the others may know nothing about.
+func (s *VendorServiceImpl) findWritableVendor(ctx context.Context, projectID uuid.UUID, vendorID uuid.UUID) (*Vendor, error) {
+	if _, err := FindWritableProject(ctx, s.projects, projectID); err != nil {
+		return nil, err
+	}
+
+	v, err := s.FindOne(ctx, VendorFilter{VendorID: vendorID, ProjectID: projectID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if personID, _ := core.AuthGuard(ctx); v.IsShared() && v.OwnerID != personID {
+		return nil, exceptions.NewForbiddenException("only the person who shared the vendor may change it", nil)
+	}
+
+	return v, nil
+}
+
+func (s *VendorServiceImpl) Update(ctx context.Context, command UpdateVendorCommand) error {
+	v, err := s.findWritableVendor(ctx, command.ProjectID, command.ID)
+
+	if err != nil {
+		return err
+	}
+
+	updated, err := NewVendor(
+		v.ID,
+		v.OwnerID,
+		v.ProjectID,
+		command.Name,
+		command.TaxID,
+		command.ContactName,
+		command.Email,
+		command.Phone,
+		command.IBAN,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.vendors.Save(ctx, updated); err != nil {
+		return exceptions.NewInternalException(failedToUpdateVendor, err)
+	}
+
+	return nil
+}
+
+// Remove removes the vendor along with the contracts the project has with it.
+// The payments and assets linked to it are kept, unlinked. A shared vendor
+// still under contract in other projects is kept, their contracts are not
+// ours to remove.
+func (s *VendorServiceImpl) Remove(ctx context.Context, command RemoveProjectResourceCommand) error {
+	v, err := s.findWritableVendor(ctx, command.ProjectID, command.ResourceID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		contracts, err := s.vendors.CountContracts(ctx, v.ID, command.ProjectID)
+
+		if err != nil {
+			return nil, exceptions.NewInternalException(failedToRemoveVendor, err)
+		}
+
+		if contracts > 0 {
+			return nil, exceptions.NewValidationException("the vendor is under contract in other projects", nil)
+		}
+
+		if err = s.vendors.RemoveContracts(ctx, v.ID, command.ProjectID); err != nil {
+			return nil, exceptions.NewInternalException(failedToRemoveVendor, err)
+		}
+
+		if err = s.vendors.Remove(ctx, v); err != nil {
+			return nil, exceptions.NewInternalException(failedToRemoveVendor, err)
+		}
+
+		return nil, nil
+	})
+
+	return err
+}
+
+// findLinkableVendor loads a vendor the payments and assets of the project
+// may be linked to. Linking changes the project, not the vendor, so shared
+// vendors are open to all the members who may edit the project.
+func (s *VendorServiceImpl) findLinkableVendor(ctx context.Context, command LinkVendorResourceCommand) (*Vendor, error) {
+	if _, err := FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return nil, err
+	}
+
+	return s.FindOne(ctx, VendorFilter{VendorID: command.VendorID, ProjectID: command.ProjectID})
+}
+
+func (s *VendorServiceImpl) LinkPayment(ctx context.Context, command LinkVendorResourceCommand) error {
+	v, err := s.findLinkableVendor(ctx, command)
+
+	if err != nil {
+		return err
+	}
+
+	p, err := s.payments.FindOne(ctx, PaymentFilter{PaymentID: command.ResourceID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException(FailedToFindPayment, err)
+		}
+
+		return exceptions.NewInternalException(FailedToFindPayment, err)
+	}
+
+	if p.ContractID != uuid.Nil && p.VendorID != v.ID {
+		return exceptions.NewValidationException("payment is made under a contract with another vendor", nil)
+	}
+
+	if err = s.vendors.LinkPayment(ctx, v.ID, command.ProjectID, p.ID); err != nil {
+		return exceptions.NewInternalException(failedToLinkVendor, err)
+	}
+
+	return nil
+}
+
+func (s *VendorServiceImpl) UnlinkPayment(ctx context.Context, command LinkVendorResourceCommand) error {
+	v, err := s.findLinkableVendor(ctx, command)
+
+	if err != nil {
+		return err
+	}
+
+	return vendorLinkError(s.vendors.UnlinkPayment(ctx, v.ID, command.ProjectID, command.ResourceID), "payment is not linked to the vendor")
+}
+
+func (s *VendorServiceImpl) LinkAsset(ctx context.Context, command LinkVendorResourceCommand) error {
+	v, err := s.findLinkableVendor(ctx, command)
+
+	if err != nil {
+		return err
+	}
+
+	return vendorLinkError(s.vendors.LinkAsset(ctx, v.ID, command.ProjectID, command.ResourceID), "asset not found")
+}
+
+func (s *VendorServiceImpl) UnlinkAsset(ctx context.Context, command LinkVendorResourceCommand) error {
+	v, err := s.findLinkableVendor(ctx, command)
+
+	if err != nil {
+		return err
+	}
+
+	return vendorLinkError(s.vendors.UnlinkAsset(ctx, v.ID, command.ProjectID, command.ResourceID), "asset is not linked to the vendor")
+}
+
+// vendorLinkError maps the error of the repository linking or unlinking a
+// resource which may not be there.
+func vendorLinkError(err error, notFound string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, exceptions.NotFoundError) {
+		return exceptions.NewNotFoundException(notFound, err)
+	}
+
+	return exceptions.NewInternalException(failedToLinkVendor, err)
+}
+
+func (s *VendorServiceImpl) Report(ctx context.Context, filter VendorFilter) (*VendorReport, error) {
+	v, err := s.FindOne(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	subtotals, err := s.vendors.Totals(ctx, v.ID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToBuildVendorReport, err)
+	}
+
+	return NewVendorReport(v, subtotals), nil
+}
@@ -0,0 +1,30 @@
+package statement
+
+import (
+	"github.com/google/uuid"
+)
+
+// ImportCommand imports the spending of a statement into a project. Source
+// names the bank format the transactions were read from.
+type ImportCommand struct {
+	ProjectID    uuid.UUID
+	Source       string
+	Transactions []*Transaction
+}
+
+// ConfirmLineCommand books a line of the review queue as a payment of TypeID.
+// The description of the line is kept unless Description is set.
+type ConfirmLineCommand struct {
+	ProjectID   uuid.UUID
+	LineID      uuid.UUID
+	TypeID      uuid.UUID
+	Description string
+}
+
+type CreateRuleCommand struct {
+	ProjectID uuid.UUID
+	Field     RuleField
+	Pattern   string
+	TypeID    uuid.UUID
+	Priority  int
+}
@@ -0,0 +1,17 @@
+package statement
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+)
+
+type LineCollectionFilter struct {
+	core.Pagination
+	ProjectID uuid.UUID
+	Status    LineStatus
+}
+
+type LineFilter struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+}
@@ -0,0 +1,30 @@
+package statement
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type Service interface {
+	Import(ctx context.Context, command ImportCommand) (*Import, error)
+	Lines(ctx context.Context, filter LineCollectionFilter) (*LineCollection, error)
+	Confirm(ctx context.Context, command ConfirmLineCommand) (*projecta.Payment, error)
+	Dismiss(ctx context.Context, command projecta.RemoveProjectResourceCommand) error
+	Rules(ctx context.Context, projectID uuid.UUID) ([]*Rule, error)
+	CreateRule(ctx context.Context, command CreateRuleCommand) (*Rule, error)
+	RemoveRule(ctx context.Context, command projecta.RemoveProjectResourceCommand) error
+}
+
+type Repository interface {
+	FindLines(ctx context.Context, filter LineCollectionFilter) (*LineCollection, error)
+	FindLine(ctx context.Context, filter LineFilter) (*Line, error)
+	// FindExternalIDs picks the ids of the entries already imported into the
+	// project out of ids.
+	FindExternalIDs(ctx context.Context, projectID uuid.UUID, ids []string) ([]string, error)
+	SaveLine(ctx context.Context, line *Line) error
+	FindRules(ctx context.Context, projectID uuid.UUID) ([]*Rule, error)
+	SaveRule(ctx context.Context, rule *Rule) error
+	RemoveRule(ctx context.Context, projectID uuid.UUID, ruleID uuid.UUID) error
+}
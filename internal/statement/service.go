@@ -0,0 +1,285 @@
+package statement
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+const (
+	failedToImportStatement = "failed to import statement"
+	failedToFindLines       = "failed to find statement lines"
+	failedToReviewLine      = "failed to review statement line"
+	failedToFindRules       = "failed to find statement rules"
+	failedToCreateRule      = "failed to create statement rule"
+	failedToRemoveRule      = "failed to remove statement rule"
+	lineNotFound            = "statement line not found"
+	ruleNotFound            = "statement rule not found"
+)
+
+type ServiceImpl struct {
+	db         core.DbConnection
+	statements Repository
+	payments   projecta.PaymentService
+	types      projecta.TypeRepository
+	projects   projecta.ProjectRepository
+}
+
+func NewService(
+	db core.DbConnection,
+	statements Repository,
+	payments projecta.PaymentService,
+	types projecta.TypeRepository,
+	projects projecta.ProjectRepository,
+) *ServiceImpl {
+	return &ServiceImpl{
+		db:         db,
+		statements: statements,
+		payments:   payments,
+		types:      types,
+		projects:   projects,
+	}
+}
+
+// Import books the spending of a statement matched by the rules of the project
+// and puts the rest in the review queue, all in a single transaction. Entries
+// imported before are left out, so overlapping statements can be imported.
+func (s *ServiceImpl) Import(ctx context.Context, command ImportCommand) (*Import, error) {
+	if _, err := core.AuthGuard(ctx); err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToImportStatement, err)
+	}
+
+	if len(command.Transactions) == 0 {
+		return nil, exceptions.NewValidationException("statement has no transactions", nil)
+	}
+
+	if _, err := projecta.FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.statements.FindRules(ctx, command.ProjectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToImportStatement, err)
+	}
+
+	ids := make([]string, 0, len(command.Transactions))
+	for _, t := range command.Transactions {
+		ids = append(ids, t.ExternalID)
+	}
+
+	known, err := s.statements.FindExternalIDs(ctx, command.ProjectID, ids)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToImportStatement, err)
+	}
+
+	seen := make(map[string]bool, len(command.Transactions))
+	for _, id := range known {
+		seen[id] = true
+	}
+
+	report := &Import{}
+
+	_, err = s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		for _, t := range command.Transactions {
+			if seen[t.ExternalID] {
+				report.Duplicates++
+				continue
+			}
+
+			seen[t.ExternalID] = true
+
+			if !t.Amount.IsNegative() {
+				report.Skipped++
+				continue
+			}
+
+			line := NewLine(uuid.New(), command.ProjectID, command.Source, t)
+
+			if rule := MatchRule(rules, line); rule != nil {
+				if _, err := s.book(ctx, line, rule.Type.ID, line.PaymentDescription()); err != nil {
+					return nil, err
+				}
+
+				report.Booked = append(report.Booked, line)
+			} else {
+				report.Pending = append(report.Pending, line)
+			}
+
+			if err := s.statements.SaveLine(ctx, line); err != nil {
+				return nil, exceptions.NewInternalException(failedToImportStatement, err)
+			}
+		}
+
+		return nil, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ServiceImpl) Lines(ctx context.Context, filter LineCollectionFilter) (*LineCollection, error) {
+	lines, err := s.statements.FindLines(ctx, filter)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindLines, err)
+	}
+
+	return lines, nil
+}
+
+// Confirm books a line of the review queue as a payment.
+func (s *ServiceImpl) Confirm(ctx context.Context, command ConfirmLineCommand) (*projecta.Payment, error) {
+	line, err := s.findPendingLine(ctx, command.ProjectID, command.LineID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	description := command.Description
+	if description == "" {
+		description = line.PaymentDescription()
+	}
+
+	payment, err := s.db.Tx(ctx, func(ctx context.Context) (any, error) {
+		payment, err := s.book(ctx, line, command.TypeID, description)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = s.statements.SaveLine(ctx, line); err != nil {
+			return nil, exceptions.NewInternalException(failedToReviewLine, err)
+		}
+
+		return payment, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return payment.(*projecta.Payment), nil
+}
+
+// Dismiss takes a line out of the review queue without booking it, e.g. for a
+// spending that has nothing to do with the project.
+func (s *ServiceImpl) Dismiss(ctx context.Context, command projecta.RemoveProjectResourceCommand) error {
+	line, err := s.findPendingLine(ctx, command.ProjectID, command.ResourceID)
+
+	if err != nil {
+		return err
+	}
+
+	line.Dismiss()
+
+	if err = s.statements.SaveLine(ctx, line); err != nil {
+		return exceptions.NewInternalException(failedToReviewLine, err)
+	}
+
+	return nil
+}
+
+func (s *ServiceImpl) Rules(ctx context.Context, projectID uuid.UUID) ([]*Rule, error) {
+	rules, err := s.statements.FindRules(ctx, projectID)
+
+	if err != nil {
+		return nil, exceptions.NewInternalException(failedToFindRules, err)
+	}
+
+	return rules, nil
+}
+
+func (s *ServiceImpl) CreateRule(ctx context.Context, command CreateRuleCommand) (*Rule, error) {
+	if _, err := projecta.FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return nil, err
+	}
+
+	costType, err := s.types.FindOne(ctx, projecta.TypeFilter{TypeID: command.TypeID, ProjectID: command.ProjectID})
+
+	if err != nil {
+		return nil, exceptions.NewValidationException(failedToCreateRule, err)
+	}
+
+	rule, err := NewRule(uuid.New(), command.ProjectID, command.Field, command.Pattern, costType, command.Priority)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.statements.SaveRule(ctx, rule); err != nil {
+		return nil, exceptions.NewInternalException(failedToCreateRule, err)
+	}
+
+	return rule, nil
+}
+
+func (s *ServiceImpl) RemoveRule(ctx context.Context, command projecta.RemoveProjectResourceCommand) error {
+	if _, err := projecta.FindWritableProject(ctx, s.projects, command.ProjectID); err != nil {
+		return err
+	}
+
+	if err := s.statements.RemoveRule(ctx, command.ProjectID, command.ResourceID); err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return exceptions.NewNotFoundException(ruleNotFound, err)
+		}
+
+		return exceptions.NewInternalException(failedToRemoveRule, err)
+	}
+
+	return nil
+}
+
+// book turns the line into a payment of the person reviewing it.
+func (s *ServiceImpl) book(ctx context.Context, line *Line, typeID uuid.UUID, description string) (*projecta.Payment, error) {
+	payment, err := s.payments.Create(ctx, projecta.CreatePaymentCommand{
+		ProjectID:   line.ProjectID,
+		TypeID:      typeID,
+		Description: description,
+		Amount:      line.Amount,
+		PaymentDate: line.Date,
+		Kind:        projecta.UponCompletionPayment,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	line.Book(payment.ID)
+
+	return payment, nil
+}
+
+func (s *ServiceImpl) findPendingLine(ctx context.Context, projectID uuid.UUID, lineID uuid.UUID) (*Line, error) {
+	if _, err := core.AuthGuard(ctx); err != nil {
+		return nil, exceptions.NewUnauthorizedException(failedToReviewLine, err)
+	}
+
+	if _, err := projecta.FindWritableProject(ctx, s.projects, projectID); err != nil {
+		return nil, err
+	}
+
+	line, err := s.statements.FindLine(ctx, LineFilter{ID: lineID, ProjectID: projectID})
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return nil, exceptions.NewNotFoundException(lineNotFound, err)
+		}
+
+		return nil, exceptions.NewInternalException(failedToReviewLine, err)
+	}
+
+	if err = line.EnsurePending(); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
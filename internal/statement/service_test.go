@@ -0,0 +1,410 @@
+package statement_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+type mockDb struct {
+	txs int
+}
+
+func (m *mockDb) Tx(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
+	m.txs++
+	return fn(ctx)
+}
+func (m *mockDb) Close()                         {}
+func (m *mockDb) Ping(ctx context.Context) error { return nil }
+
+type mockStatementRepo struct {
+	lines       *statement.LineCollection
+	line        *statement.Line
+	rules       []*statement.Rule
+	known       []string
+	saved       []*statement.Line
+	savedRule   *statement.Rule
+	findErr     error
+	findLineErr error
+	knownErr    error
+	saveErr     error
+	rulesErr    error
+	removeErr   error
+}
+
+func (m *mockStatementRepo) FindLines(ctx context.Context, filter statement.LineCollectionFilter) (*statement.LineCollection, error) {
+	return m.lines, m.findErr
+}
+func (m *mockStatementRepo) FindLine(ctx context.Context, filter statement.LineFilter) (*statement.Line, error) {
+	if m.findLineErr != nil {
+		return nil, m.findLineErr
+	}
+	return m.line, nil
+}
+func (m *mockStatementRepo) FindExternalIDs(ctx context.Context, projectID uuid.UUID, ids []string) ([]string, error) {
+	return m.known, m.knownErr
+}
+func (m *mockStatementRepo) SaveLine(ctx context.Context, line *statement.Line) error {
+	m.saved = append(m.saved, line)
+	return m.saveErr
+}
+func (m *mockStatementRepo) FindRules(ctx context.Context, projectID uuid.UUID) ([]*statement.Rule, error) {
+	return m.rules, m.rulesErr
+}
+func (m *mockStatementRepo) SaveRule(ctx context.Context, rule *statement.Rule) error {
+	m.savedRule = rule
+	return m.saveErr
+}
+func (m *mockStatementRepo) RemoveRule(ctx context.Context, projectID uuid.UUID, ruleID uuid.UUID) error {
+	return m.removeErr
+}
+
+type mockPaymentService struct {
+	created []projecta.CreatePaymentCommand
+	err     error
+}
+
+func (m *mockPaymentService) FindOne(ctx context.Context, filter projecta.PaymentFilter) (*projecta.Payment, error) {
+	return nil, nil
+}
+func (m *mockPaymentService) Find(ctx context.Context, filter projecta.PaymentCollectionFilter) (*projecta.PaymentCollection, error) {
+	return nil, nil
+}
+func (m *mockPaymentService) Create(ctx context.Context, command projecta.CreatePaymentCommand) (*projecta.Payment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.created = append(m.created, command)
+	return &projecta.Payment{ID: uuid.New(), Amount: command.Amount, Description: command.Description, Date: command.PaymentDate, Kind: command.Kind}, nil
+}
+func (m *mockPaymentService) Update(ctx context.Context, command projecta.UpdatePaymentCommand) error {
+	return nil
+}
+func (m *mockPaymentService) MarkPaid(ctx context.Context, command projecta.MarkPaymentPaidCommand) (*projecta.Payment, error) {
+	return nil, nil
+}
+func (m *mockPaymentService) Remove(ctx context.Context, command projecta.RemovePaymentCommand) error {
+	return nil
+}
+func (m *mockPaymentService) Totals(ctx context.Context, filter projecta.PaymentTotalsFilter) ([]*projecta.PaymentSubtotal, error) {
+	return nil, nil
+}
+
+type mockTypeRepo struct {
+	costType *projecta.CostType
+	err      error
+}
+
+func (m *mockTypeRepo) Save(ctx context.Context, t *projecta.CostType) error   { return nil }
+func (m *mockTypeRepo) Remove(ctx context.Context, t *projecta.CostType) error { return nil }
+func (m *mockTypeRepo) Find(ctx context.Context, filter projecta.TypeCollectionFilter) (*projecta.CostTypeCollection, error) {
+	return nil, nil
+}
+func (m *mockTypeRepo) FindOne(ctx context.Context, filter projecta.TypeFilter) (*projecta.CostType, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.costType, nil
+}
+
+type mockProjectRepo struct {
+	project *projecta.Project
+	err     error
+}
+
+func (m *mockProjectRepo) Create(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Update(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Remove(ctx context.Context, p *projecta.Project) error { return nil }
+func (m *mockProjectRepo) Find(ctx context.Context, filter projecta.ProjectCollectionFilter) ([]*projecta.Project, error) {
+	return nil, nil
+}
+func (m *mockProjectRepo) FindOne(ctx context.Context, filter projecta.ProjectFilter) (*projecta.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.project, nil
+}
+func (m *mockProjectRepo) FindByShareToken(ctx context.Context, token uuid.UUID) (*projecta.Project, error) {
+	return m.project, m.err
+}
+func (m *mockProjectRepo) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, role projecta.ProjectRole) (bool, error) {
+	return m.err == nil, m.err
+}
+func (m *mockProjectRepo) FindShareLink(ctx context.Context, token uuid.UUID) (*projecta.ShareLink, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) CreateShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	return m.err
+}
+func (m *mockProjectRepo) FindShareLinks(ctx context.Context, project *projecta.Project) ([]*projecta.ShareLink, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) UseShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	return m.err
+}
+func (m *mockProjectRepo) RemoveShareLink(ctx context.Context, projectID uuid.UUID, linkID uuid.UUID) error {
+	return m.err
+}
+func (m *mockProjectRepo) FindMembers(ctx context.Context, projectID uuid.UUID) ([]*projecta.ProjectMember, error) {
+	return nil, m.err
+}
+func (m *mockProjectRepo) RemoveShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+	return m.err
+}
+
+func hasCode(err error, code exceptions.ErrorCode) bool {
+	var ex exceptions.Exception
+	return errors.As(err, &ex) && ex.Code == code
+}
+
+func TestStatementService(t *testing.T) {
+	requesterID := uuid.New()
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, requesterID)
+
+	now := time.Now()
+	owner := &projecta.Owner{PersonID: requesterID, DisplayName: "John Doe"}
+	project, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, now, now)
+	materials := &projecta.CostType{ID: uuid.New(), ProjectID: project.ProjectID, Name: "Materials"}
+	rule, _ := statement.NewRule(uuid.New(), project.ProjectID, statement.MatchCounterparty, "epicentr", materials, 0)
+	day := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	transaction := func(id string, amount int64, counterparty string) *statement.Transaction {
+		return &statement.Transaction{ExternalID: id, Date: day, Amount: money.New(amount, "UAH"), Description: "Card payment", Counterparty: counterparty}
+	}
+
+	pendingLine := func() *statement.Line {
+		return statement.NewLine(uuid.New(), project.ProjectID, "monobank", transaction("a1", -2500, "Silpo"))
+	}
+
+	newService := func(db *mockDb, repo *mockStatementRepo, payments *mockPaymentService, types *mockTypeRepo, projects *mockProjectRepo) *statement.ServiceImpl {
+		return statement.NewService(db, repo, payments, types, projects)
+	}
+
+	t.Run("Import books matched lines and queues the rest", func(t *testing.T) {
+		db := &mockDb{}
+		repo := &mockStatementRepo{rules: []*statement.Rule{rule}, known: []string{"a0"}}
+		payments := &mockPaymentService{}
+		svc := newService(db, repo, payments, &mockTypeRepo{}, &mockProjectRepo{project: project})
+
+		report, err := svc.Import(authedCtx, statement.ImportCommand{
+			ProjectID: project.ProjectID,
+			Source:    "monobank",
+			Transactions: []*statement.Transaction{
+				transaction("a0", -100, "Epicentr"),
+				transaction("a1", -2500, "TOV Epicentr K"),
+				transaction("a2", -300, "Nova Poshta"),
+				transaction("a2", -300, "Nova Poshta"),
+				transaction("a3", 5000, "Olena"),
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(report.Booked) != 1 || len(report.Pending) != 1 || report.Duplicates != 2 || report.Skipped != 1 || db.txs != 1 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		if booked := report.Booked[0]; booked.Status != statement.LineBooked || booked.PaymentID == uuid.Nil || booked.Source != "monobank" {
+			t.Errorf("unexpected booked line %+v", booked)
+		}
+
+		if len(payments.created) != 1 {
+			t.Fatalf("expected a single payment, got %d", len(payments.created))
+		}
+		if command := payments.created[0]; command.TypeID != materials.ID || command.Amount.Amount() != 2500 || command.Kind != projecta.UponCompletionPayment || !command.PaymentDate.Equal(day) {
+			t.Errorf("unexpected payment %+v", command)
+		}
+
+		if report.Pending[0].Status != statement.LinePending || len(repo.saved) != 2 {
+			t.Errorf("expected both lines saved, got %d", len(repo.saved))
+		}
+	})
+
+	t.Run("Import errors", func(t *testing.T) {
+		command := statement.ImportCommand{ProjectID: project.ProjectID, Transactions: []*statement.Transaction{transaction("a1", -2500, "Epicentr")}}
+		archived, _ := projecta.NewProject(uuid.New(), "Archived", "", owner, now, now)
+		archived.Archive(now)
+
+		cases := map[string]struct {
+			ctx      context.Context
+			command  statement.ImportCommand
+			repo     *mockStatementRepo
+			payments *mockPaymentService
+			projects *mockProjectRepo
+			code     exceptions.ErrorCode
+		}{
+			"unauthorized":      {context.Background(), command, &mockStatementRepo{}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Unauthorized},
+			"no transactions":   {authedCtx, statement.ImportCommand{ProjectID: project.ProjectID}, &mockStatementRepo{}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.ValidationFailed},
+			"project not found": {authedCtx, command, &mockStatementRepo{}, &mockPaymentService{}, &mockProjectRepo{err: exceptions.NewNotFoundException("not found", nil)}, exceptions.NotFound},
+			"archived project":  {authedCtx, command, &mockStatementRepo{}, &mockPaymentService{}, &mockProjectRepo{project: archived}, exceptions.Forbidden},
+			"rules error":       {authedCtx, command, &mockStatementRepo{rulesErr: errors.New("db error")}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Internal},
+			"known ids error":   {authedCtx, command, &mockStatementRepo{knownErr: errors.New("db error")}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Internal},
+			"payment error":     {authedCtx, command, &mockStatementRepo{rules: []*statement.Rule{rule}}, &mockPaymentService{err: exceptions.NewValidationException("invalid payment", nil)}, &mockProjectRepo{project: project}, exceptions.ValidationFailed},
+			"save error":        {authedCtx, command, &mockStatementRepo{saveErr: errors.New("db error")}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Internal},
+		}
+
+		for name, c := range cases {
+			svc := newService(&mockDb{}, c.repo, c.payments, &mockTypeRepo{}, c.projects)
+			if _, err := svc.Import(c.ctx, c.command); !hasCode(err, c.code) {
+				t.Errorf("%s: expected %v, got %v", name, c.code, err)
+			}
+		}
+	})
+
+	t.Run("Lines", func(t *testing.T) {
+		lines := statement.NewLineCollection(1)
+		lines.Add(pendingLine())
+
+		svc := newService(&mockDb{}, &mockStatementRepo{lines: lines}, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		if res, err := svc.Lines(authedCtx, statement.LineCollectionFilter{ProjectID: project.ProjectID}); err != nil || res.Total() != 1 {
+			t.Errorf("unexpected lines %v, %v", res, err)
+		}
+
+		svc = newService(&mockDb{}, &mockStatementRepo{findErr: errors.New("db error")}, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		if _, err := svc.Lines(authedCtx, statement.LineCollectionFilter{}); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+		line := pendingLine()
+		repo := &mockStatementRepo{line: line}
+		payments := &mockPaymentService{}
+		svc := newService(&mockDb{}, repo, payments, &mockTypeRepo{}, &mockProjectRepo{project: project})
+
+		payment, err := svc.Confirm(authedCtx, statement.ConfirmLineCommand{ProjectID: project.ProjectID, LineID: line.ID, TypeID: materials.ID})
+		if err != nil || payment.Description != "Card payment" || line.Status != statement.LineBooked || line.PaymentID != payment.ID || len(repo.saved) != 1 {
+			t.Fatalf("unexpected payment %+v, %v", payment, err)
+		}
+
+		line = pendingLine()
+		repo.line = line
+		if payment, err = svc.Confirm(authedCtx, statement.ConfirmLineCommand{ProjectID: project.ProjectID, LineID: line.ID, TypeID: materials.ID, Description: "Tiles"}); err != nil || payment.Description != "Tiles" {
+			t.Errorf("expected the description to be kept, got %+v, %v", payment, err)
+		}
+
+		if _, err = svc.Confirm(authedCtx, statement.ConfirmLineCommand{ProjectID: project.ProjectID, LineID: line.ID, TypeID: materials.ID}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected the line to be reviewed once, got %v", err)
+		}
+
+		cases := map[string]struct {
+			ctx      context.Context
+			repo     *mockStatementRepo
+			payments *mockPaymentService
+			projects *mockProjectRepo
+			code     exceptions.ErrorCode
+		}{
+			"unauthorized":      {context.Background(), &mockStatementRepo{line: pendingLine()}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Unauthorized},
+			"project not found": {authedCtx, &mockStatementRepo{line: pendingLine()}, &mockPaymentService{}, &mockProjectRepo{err: exceptions.NewNotFoundException("not found", nil)}, exceptions.NotFound},
+			"line not found":    {authedCtx, &mockStatementRepo{findLineErr: exceptions.NewNotFoundException("not found", nil)}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.NotFound},
+			"find line error":   {authedCtx, &mockStatementRepo{findLineErr: errors.New("db error")}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Internal},
+			"payment error":     {authedCtx, &mockStatementRepo{line: pendingLine()}, &mockPaymentService{err: exceptions.NewNotFoundException("type not found", nil)}, &mockProjectRepo{project: project}, exceptions.NotFound},
+			"save error":        {authedCtx, &mockStatementRepo{line: pendingLine(), saveErr: errors.New("db error")}, &mockPaymentService{}, &mockProjectRepo{project: project}, exceptions.Internal},
+		}
+
+		for name, c := range cases {
+			svc := newService(&mockDb{}, c.repo, c.payments, &mockTypeRepo{}, c.projects)
+			if _, err := svc.Confirm(c.ctx, statement.ConfirmLineCommand{ProjectID: project.ProjectID, TypeID: materials.ID}); !hasCode(err, c.code) {
+				t.Errorf("%s: expected %v, got %v", name, c.code, err)
+			}
+		}
+	})
+
+	t.Run("Dismiss", func(t *testing.T) {
+		line := pendingLine()
+		repo := &mockStatementRepo{line: line}
+		svc := newService(&mockDb{}, repo, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		command := projecta.RemoveProjectResourceCommand{ProjectID: project.ProjectID, ResourceID: line.ID}
+
+		if err := svc.Dismiss(authedCtx, command); err != nil || line.Status != statement.LineDismissed || len(repo.saved) != 1 {
+			t.Errorf("unexpected dismiss result %+v, %v", line, err)
+		}
+
+		if err := svc.Dismiss(authedCtx, command); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected the line to be reviewed once, got %v", err)
+		}
+
+		svc = newService(&mockDb{}, &mockStatementRepo{line: pendingLine(), saveErr: errors.New("db error")}, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		if err := svc.Dismiss(authedCtx, command); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("Rules", func(t *testing.T) {
+		svc := newService(&mockDb{}, &mockStatementRepo{rules: []*statement.Rule{rule}}, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		if rules, err := svc.Rules(authedCtx, project.ProjectID); err != nil || len(rules) != 1 {
+			t.Errorf("unexpected rules %v, %v", rules, err)
+		}
+
+		svc = newService(&mockDb{}, &mockStatementRepo{rulesErr: errors.New("db error")}, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		if _, err := svc.Rules(authedCtx, project.ProjectID); !hasCode(err, exceptions.Internal) {
+			t.Errorf("expected internal error, got %v", err)
+		}
+	})
+
+	t.Run("CreateRule", func(t *testing.T) {
+		repo := &mockStatementRepo{}
+		svc := newService(&mockDb{}, repo, &mockPaymentService{}, &mockTypeRepo{costType: materials}, &mockProjectRepo{project: project})
+		command := statement.CreateRuleCommand{ProjectID: project.ProjectID, Field: statement.MatchDescription, Pattern: "плитка", TypeID: materials.ID, Priority: 3}
+
+		created, err := svc.CreateRule(authedCtx, command)
+		if err != nil || created.Type != materials || created.Priority != 3 || repo.savedRule != created {
+			t.Errorf("unexpected rule %+v, %v", created, err)
+		}
+
+		cases := map[string]struct {
+			command  statement.CreateRuleCommand
+			repo     *mockStatementRepo
+			types    *mockTypeRepo
+			projects *mockProjectRepo
+			code     exceptions.ErrorCode
+		}{
+			"project not found": {command, &mockStatementRepo{}, &mockTypeRepo{costType: materials}, &mockProjectRepo{err: exceptions.NewNotFoundException("not found", nil)}, exceptions.NotFound},
+			"type not found":    {command, &mockStatementRepo{}, &mockTypeRepo{err: errors.New("no rows")}, &mockProjectRepo{project: project}, exceptions.ValidationFailed},
+			"invalid pattern":   {statement.CreateRuleCommand{ProjectID: project.ProjectID, Pattern: "("}, &mockStatementRepo{}, &mockTypeRepo{costType: materials}, &mockProjectRepo{project: project}, exceptions.ValidationFailed},
+			"save error":        {command, &mockStatementRepo{saveErr: errors.New("db error")}, &mockTypeRepo{costType: materials}, &mockProjectRepo{project: project}, exceptions.Internal},
+		}
+
+		for name, c := range cases {
+			svc := newService(&mockDb{}, c.repo, &mockPaymentService{}, c.types, c.projects)
+			if _, err := svc.CreateRule(authedCtx, c.command); !hasCode(err, c.code) {
+				t.Errorf("%s: expected %v, got %v", name, c.code, err)
+			}
+		}
+	})
+
+	t.Run("RemoveRule", func(t *testing.T) {
+		command := projecta.RemoveProjectResourceCommand{ProjectID: project.ProjectID, ResourceID: rule.ID}
+
+		svc := newService(&mockDb{}, &mockStatementRepo{}, &mockPaymentService{}, &mockTypeRepo{}, &mockProjectRepo{project: project})
+		if err := svc.RemoveRule(authedCtx, command); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		cases := map[string]struct {
+			repo     *mockStatementRepo
+			projects *mockProjectRepo
+			code     exceptions.ErrorCode
+		}{
+			"project not found": {&mockStatementRepo{}, &mockProjectRepo{err: exceptions.NewNotFoundException("not found", nil)}, exceptions.NotFound},
+			"rule not found":    {&mockStatementRepo{removeErr: exceptions.NewNotFoundException("not found", nil)}, &mockProjectRepo{project: project}, exceptions.NotFound},
+			"remove error":      {&mockStatementRepo{removeErr: errors.New("db error")}, &mockProjectRepo{project: project}, exceptions.Internal},
+		}
+
+		for name, c := range cases {
+			svc := newService(&mockDb{}, c.repo, &mockPaymentService{}, &mockTypeRepo{}, c.projects)
+			if err := svc.RemoveRule(authedCtx, command); !hasCode(err, c.code) {
+				t.Errorf("%s: expected %v, got %v", name, c.code, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package statement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+// Transaction is an entry of a bank statement as the bank wrote it. Amount is
+// negative for the money that left the account. ExternalID tells the entry
+// apart from the others of the account, so a statement imported twice does
+// not book anything twice.
+type Transaction struct {
+	ExternalID   string
+	Date         time.Time
+	Amount       *money.Money
+	Description  string
+	Counterparty string
+}
+
+type LineStatus string
+
+const (
+	// LinePending waits in the review queue for a type to be picked.
+	LinePending LineStatus = "PENDING"
+	// LineBooked became a payment.
+	LineBooked LineStatus = "BOOKED"
+	// LineDismissed was reviewed and left out of the project.
+	LineDismissed LineStatus = "DISMISSED"
+)
+
+func (s LineStatus) String() string {
+	return string(s)
+}
+
+func ToLineStatus(status string) (LineStatus, error) {
+	switch LineStatus(strings.ToUpper(status)) {
+	case LinePending:
+		return LinePending, nil
+	case LineBooked:
+		return LineBooked, nil
+	case LineDismissed:
+		return LineDismissed, nil
+	default:
+		return "", exceptions.NewValidationException(fmt.Sprintf("invalid statement line status %q", status), nil)
+	}
+}
+
+// Line is a spending of a statement imported into a project. Lines a rule
+// matched are booked as payments straight away, the others wait for review.
+type Line struct {
+	ID           uuid.UUID
+	ProjectID    uuid.UUID
+	Source       string
+	ExternalID   string
+	Date         time.Time
+	Amount       *money.Money
+	Description  string
+	Counterparty string
+	Status       LineStatus
+	PaymentID    uuid.UUID
+}
+
+func NewLine(id uuid.UUID, projectID uuid.UUID, source string, t *Transaction) *Line {
+	return &Line{
+		ID:           id,
+		ProjectID:    projectID,
+		Source:       source,
+		ExternalID:   t.ExternalID,
+		Date:         t.Date,
+		Amount:       t.Amount.Absolute(),
+		Description:  t.Description,
+		Counterparty: t.Counterparty,
+		Status:       LinePending,
+	}
+}
+
+// PaymentDescription is what the payment booked for the line is described as,
+// the counterparty when the bank left the description out.
+func (l *Line) PaymentDescription() string {
+	if l.Description != "" {
+		return l.Description
+	}
+
+	return l.Counterparty
+}
+
+// EnsurePending keeps a line from being reviewed twice.
+func (l *Line) EnsurePending() error {
+	if l.Status != LinePending {
+		return exceptions.NewValidationException("statement line is already reviewed", nil)
+	}
+
+	return nil
+}
+
+// Book marks the line as turned into the payment.
+func (l *Line) Book(paymentID uuid.UUID) {
+	l.Status = LineBooked
+	l.PaymentID = paymentID
+}
+
+func (l *Line) Dismiss() {
+	l.Status = LineDismissed
+}
+
+type LineCollection = core.PaginatedCollection[*Line]
+
+func NewLineCollection(total int) *LineCollection {
+	return core.NewPaginatedCollection[*Line](total)
+}
+
+// RuleField is the part of a statement entry a rule looks at.
+type RuleField string
+
+const (
+	MatchAny          RuleField = "ANY"
+	MatchCounterparty RuleField = "COUNTERPARTY"
+	MatchDescription  RuleField = "DESCRIPTION"
+)
+
+func (f RuleField) String() string {
+	return string(f)
+}
+
+func ToRuleField(field string) (RuleField, error) {
+	switch RuleField(strings.ToUpper(field)) {
+	case MatchAny, "":
+		return MatchAny, nil
+	case MatchCounterparty:
+		return MatchCounterparty, nil
+	case MatchDescription:
+		return MatchDescription, nil
+	default:
+		return "", exceptions.NewValidationException(fmt.Sprintf("invalid rule field %q", field), nil)
+	}
+}
+
+// Rule books the statement entries whose counterparty or description match
+// Pattern, a regular expression matched regardless of case, under Type. When
+// several rules match, the one of the highest Priority wins.
+type Rule struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+	Field     RuleField
+	Pattern   string
+	Type      *projecta.CostType
+	Priority  int
+	pattern   *regexp.Regexp
+}
+
+func NewRule(id uuid.UUID, projectID uuid.UUID, field RuleField, pattern string, costType *projecta.CostType, priority int) (*Rule, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, exceptions.NewValidationException("rule pattern is required", nil)
+	}
+
+	compiled, err := regexp.Compile("(?i)" + pattern)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("invalid rule pattern %q", pattern), err)
+	}
+
+	if costType == nil {
+		return nil, exceptions.NewValidationException("rule cost type is required", nil)
+	}
+
+	return &Rule{
+		ID:        id,
+		ProjectID: projectID,
+		Field:     field,
+		Pattern:   pattern,
+		Type:      costType,
+		Priority:  priority,
+		pattern:   compiled,
+	}, nil
+}
+
+func (r *Rule) Matches(l *Line) bool {
+	switch r.Field {
+	case MatchCounterparty:
+		return r.pattern.MatchString(l.Counterparty)
+	case MatchDescription:
+		return r.pattern.MatchString(l.Description)
+	default:
+		return r.pattern.MatchString(l.Counterparty) || r.pattern.MatchString(l.Description)
+	}
+}
+
+// MatchRule finds the rule to book the line with, nil when none matches.
+func MatchRule(rules []*Rule, l *Line) *Rule {
+	var found *Rule
+
+	for _, r := range rules {
+		if r.Matches(l) && (found == nil || r.Priority > found.Priority) {
+			found = r
+		}
+	}
+
+	return found
+}
+
+// Import reports a statement import. Booked lines matched a rule and became
+// payments, pending ones wait for review. Duplicates were imported before,
+// skipped ones brought money in rather than spending it.
+type Import struct {
+	Booked     []*Line
+	Pending    []*Line
+	Duplicates int
+	Skipped    int
+}
@@ -0,0 +1,135 @@
+package statement_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+func isValidationError(err error) bool {
+	var ex exceptions.Exception
+	return errors.As(err, &ex) && ex.Code == exceptions.ValidationFailed
+}
+
+func TestLine(t *testing.T) {
+	projectID := uuid.New()
+	day := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	line := statement.NewLine(uuid.New(), projectID, "monobank", &statement.Transaction{
+		ExternalID:   "a1",
+		Date:         day,
+		Amount:       money.New(-2500, "UAH"),
+		Counterparty: "TOV Epicentr K",
+	})
+
+	if line.Amount.Amount() != 2500 || line.Status != statement.LinePending || line.ProjectID != projectID || line.ExternalID != "a1" {
+		t.Errorf("unexpected line %+v", line)
+	}
+
+	if line.PaymentDescription() != "TOV Epicentr K" {
+		t.Errorf("expected the counterparty to describe the payment, got %q", line.PaymentDescription())
+	}
+
+	line.Description = "Tiles"
+	if line.PaymentDescription() != "Tiles" {
+		t.Errorf("expected the description to describe the payment, got %q", line.PaymentDescription())
+	}
+
+	if err := line.EnsurePending(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	paymentID := uuid.New()
+	line.Book(paymentID)
+	if line.Status != statement.LineBooked || line.PaymentID != paymentID {
+		t.Errorf("unexpected booked line %+v", line)
+	}
+
+	if err := line.EnsurePending(); !isValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	line.Dismiss()
+	if line.Status != statement.LineDismissed || line.Status.String() != "DISMISSED" {
+		t.Errorf("unexpected status %s", line.Status)
+	}
+
+	for value, expected := range map[string]statement.LineStatus{"pending": statement.LinePending, "BOOKED": statement.LineBooked, "Dismissed": statement.LineDismissed} {
+		if status, err := statement.ToLineStatus(value); err != nil || status != expected {
+			t.Errorf("ToLineStatus(%q) = %v, %v", value, status, err)
+		}
+	}
+
+	if _, err := statement.ToLineStatus("lost"); !isValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestRule(t *testing.T) {
+	projectID := uuid.New()
+	materials := &projecta.CostType{ID: uuid.New(), Name: "Materials"}
+	delivery := &projecta.CostType{ID: uuid.New(), Name: "Delivery"}
+
+	for value, expected := range map[string]statement.RuleField{"": statement.MatchAny, "any": statement.MatchAny, "Counterparty": statement.MatchCounterparty, "DESCRIPTION": statement.MatchDescription} {
+		if field, err := statement.ToRuleField(value); err != nil || field != expected {
+			t.Errorf("ToRuleField(%q) = %v, %v", value, field, err)
+		}
+	}
+
+	if _, err := statement.ToRuleField("amount"); !isValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	if statement.MatchCounterparty.String() != "COUNTERPARTY" {
+		t.Error("unexpected field name")
+	}
+
+	for name, build := range map[string]func() (*statement.Rule, error){
+		"empty pattern": func() (*statement.Rule, error) {
+			return statement.NewRule(uuid.New(), projectID, statement.MatchAny, " ", materials, 0)
+		},
+		"invalid pattern": func() (*statement.Rule, error) {
+			return statement.NewRule(uuid.New(), projectID, statement.MatchAny, "(", materials, 0)
+		},
+		"no type": func() (*statement.Rule, error) {
+			return statement.NewRule(uuid.New(), projectID, statement.MatchAny, "x", nil, 0)
+		},
+	} {
+		if _, err := build(); !isValidationError(err) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+
+	byCounterparty, _ := statement.NewRule(uuid.New(), projectID, statement.MatchCounterparty, "epicentr", materials, 0)
+	byDescription, _ := statement.NewRule(uuid.New(), projectID, statement.MatchDescription, "доставка|delivery", delivery, 5)
+	anywhere, _ := statement.NewRule(uuid.New(), projectID, statement.MatchAny, "нова пошта", delivery, 1)
+
+	epicentr := &statement.Line{Counterparty: "TOV EPICENTR K", Description: "Плитка"}
+	withDelivery := &statement.Line{Counterparty: "TOV Epicentr K", Description: "Доставка плитки"}
+	novaPoshta := &statement.Line{Description: "Нова Пошта"}
+	other := &statement.Line{Counterparty: "Silpo", Description: "Epicentr gift card"}
+
+	if !byCounterparty.Matches(epicentr) || byCounterparty.Matches(other) || !byDescription.Matches(withDelivery) || byDescription.Matches(epicentr) || !anywhere.Matches(novaPoshta) {
+		t.Error("unexpected rule matches")
+	}
+
+	rules := []*statement.Rule{byCounterparty, byDescription, anywhere}
+
+	if rule := statement.MatchRule(rules, epicentr); rule != byCounterparty {
+		t.Errorf("expected the counterparty rule, got %v", rule)
+	}
+
+	if rule := statement.MatchRule(rules, withDelivery); rule != byDescription {
+		t.Errorf("expected the rule of the highest priority, got %v", rule)
+	}
+
+	if rule := statement.MatchRule(rules, other); rule != nil {
+		t.Errorf("expected no rule, got %v", rule)
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS projecta_budgets;
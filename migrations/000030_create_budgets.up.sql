@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS projecta_budgets
+(
+    budget_id   UUID        PRIMARY KEY NOT NULL,
+    project_id  UUID        NOT NULL,
+    category_id UUID        NOT NULL,
+    type_id     UUID,
+    amount      BIGINT      NOT NULL CHECK (amount > 0),
+    currency    CHAR(3)     NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMP   NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMP,
+    CONSTRAINT projecta_budgets_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_budgets_category_id_fk FOREIGN KEY (category_id) REFERENCES projecta_cost_categories(category_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_budgets_type_id_fk FOREIGN KEY (type_id) REFERENCES projecta_cost_types(type_id) ON DELETE CASCADE
+);
+
+-- a category (or a category/type pair) can only be budgeted once per project
+CREATE UNIQUE INDEX IF NOT EXISTS projecta_budgets_scope_unique
+    ON projecta_budgets (project_id, category_id, COALESCE(type_id, '00000000-0000-0000-0000-000000000000'::UUID));
+
+CREATE TRIGGER update_timestamp_trigger
+    BEFORE UPDATE
+    ON projecta_budgets
+    FOR EACH ROW
+EXECUTE FUNCTION update_timestamp_trigger_function('updated_at');
@@ -0,0 +1,2 @@
+ALTER TABLE projecta_projects
+    DROP COLUMN archived_at;
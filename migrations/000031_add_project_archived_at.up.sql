@@ -0,0 +1,2 @@
+ALTER TABLE projecta_projects
+    ADD COLUMN archived_at TIMESTAMP NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS projecta_share_links;
+
+ALTER TABLE projecta_project_shares
+    DROP COLUMN IF EXISTS role;
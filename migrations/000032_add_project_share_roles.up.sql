@@ -0,0 +1,16 @@
+-- existing members were granted full access, so they keep editing rights
+ALTER TABLE projecta_project_shares
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'EDITOR'
+        CONSTRAINT projecta_project_shares_role_check CHECK (role IN ('ADMIN', 'EDITOR', 'VIEWER'));
+
+CREATE TABLE IF NOT EXISTS projecta_share_links
+(
+    link_id     UUID        PRIMARY KEY NOT NULL,
+    project_id  UUID        NOT NULL,
+    token       UUID        NOT NULL UNIQUE,
+    role        VARCHAR(16) NOT NULL CHECK (role IN ('ADMIN', 'EDITOR', 'VIEWER')),
+    created_by  UUID        NOT NULL,
+    created_at  TIMESTAMP   NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_share_links_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_share_links_created_by_fk FOREIGN KEY (created_by) REFERENCES people(person_id) ON DELETE CASCADE
+);
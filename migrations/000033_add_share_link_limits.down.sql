@@ -0,0 +1,4 @@
+ALTER TABLE projecta_share_links
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS max_uses,
+    DROP COLUMN IF EXISTS uses;
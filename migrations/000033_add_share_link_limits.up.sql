@@ -0,0 +1,5 @@
+-- a zero max_uses means the link can be accepted any number of times
+ALTER TABLE projecta_share_links
+    ADD COLUMN expires_at TIMESTAMP NULL,
+    ADD COLUMN max_uses   INT       NOT NULL DEFAULT 0 CHECK (max_uses >= 0),
+    ADD COLUMN uses       INT       NOT NULL DEFAULT 0;
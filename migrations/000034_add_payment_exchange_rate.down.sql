@@ -0,0 +1,5 @@
+ALTER TABLE projecta_payments
+    DROP COLUMN IF EXISTS home_amount,
+    DROP COLUMN IF EXISTS home_currency,
+    DROP COLUMN IF EXISTS exchange_rate,
+    DROP COLUMN IF EXISTS manual_rate;
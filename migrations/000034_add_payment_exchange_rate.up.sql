@@ -0,0 +1,6 @@
+-- the conversion into the project main currency captured when the payment was booked
+ALTER TABLE projecta_payments
+    ADD COLUMN home_amount   BIGINT         NULL,
+    ADD COLUMN home_currency CHAR(3)        NULL,
+    ADD COLUMN exchange_rate NUMERIC(20, 10) NULL CHECK (exchange_rate > 0),
+    ADD COLUMN manual_rate   BOOLEAN        NOT NULL DEFAULT FALSE;
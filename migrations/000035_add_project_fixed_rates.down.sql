@@ -0,0 +1,14 @@
+ALTER TABLE projecta_currencies
+    DROP CONSTRAINT IF EXISTS projecta_currencies_exchange_rate_check,
+    DROP CONSTRAINT IF EXISTS projecta_currencies_pkey,
+    DROP COLUMN IF EXISTS base_currency;
+
+-- a currency had a single rate before the rates were fixed per project, so a
+-- currency fixed on several projects keeps the rate of one of them only
+DELETE FROM projecta_currencies AS duplicate
+USING projecta_currencies AS kept
+WHERE duplicate.code = kept.code
+  AND duplicate.project_id > kept.project_id;
+
+ALTER TABLE projecta_currencies
+    ADD CONSTRAINT projecta_currencies_pkey PRIMARY KEY (code);
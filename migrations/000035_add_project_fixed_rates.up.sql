@@ -0,0 +1,15 @@
+-- rates are fixed per project, so the same currency may be fixed on many projects
+ALTER TABLE projecta_currencies
+    DROP CONSTRAINT IF EXISTS projecta_currencies_pkey;
+
+ALTER TABLE projecta_currencies
+    ADD COLUMN base_currency CHAR(3) NOT NULL DEFAULT 'UAH';
+
+UPDATE projecta_currencies
+SET base_currency = projecta_projects.main_currency
+FROM projecta_projects
+WHERE projecta_projects.project_id = projecta_currencies.project_id;
+
+ALTER TABLE projecta_currencies
+    ADD CONSTRAINT projecta_currencies_pkey PRIMARY KEY (project_id, code),
+    ADD CONSTRAINT projecta_currencies_exchange_rate_check CHECK (exchange_rate > 0);
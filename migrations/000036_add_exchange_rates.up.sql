@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS exchange_rates
+(
+    source        VARCHAR(16)     NOT NULL,
+    rate_date     DATE            NOT NULL,
+    code          CHAR(3)         NOT NULL,
+    base_currency CHAR(3)         NOT NULL,
+    exchange_rate NUMERIC(20, 10) NOT NULL CHECK (exchange_rate > 0),
+    fetched_at    TIMESTAMP       NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (source, rate_date, code)
+);
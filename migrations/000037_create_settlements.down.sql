@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS projecta_settlement_transfers;
+DROP TABLE IF EXISTS projecta_settlement_weights;
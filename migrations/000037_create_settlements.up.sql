@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS projecta_settlement_weights
+(
+    project_id UUID           NOT NULL,
+    person_id  UUID           NOT NULL,
+    weight     NUMERIC(10, 4) NOT NULL CHECK (weight >= 0),
+    PRIMARY KEY (project_id, person_id),
+    CONSTRAINT projecta_settlement_weights_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_settlement_weights_person_id_fk FOREIGN KEY (person_id) REFERENCES people(person_id) ON DELETE CASCADE
+);
+
+-- money members hand each other to settle up, kept apart from the payments so
+-- it never counts as project spending
+CREATE TABLE IF NOT EXISTS projecta_settlement_transfers
+(
+    transfer_id    UUID      PRIMARY KEY NOT NULL,
+    project_id     UUID      NOT NULL,
+    from_person_id UUID      NOT NULL,
+    to_person_id   UUID      NOT NULL,
+    amount         BIGINT    NOT NULL CHECK (amount > 0),
+    currency       CHAR(3)   NOT NULL,
+    transfer_date  TIMESTAMP NOT NULL,
+    description    TEXT,
+    created_at     TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_settlement_transfers_parties_check CHECK (from_person_id <> to_person_id),
+    CONSTRAINT projecta_settlement_transfers_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_settlement_transfers_from_person_id_fk FOREIGN KEY (from_person_id) REFERENCES people(person_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_settlement_transfers_to_person_id_fk FOREIGN KEY (to_person_id) REFERENCES people(person_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_settlement_transfers_project_id_idx
+    ON projecta_settlement_transfers (project_id);
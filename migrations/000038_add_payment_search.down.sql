@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS projecta_payments_search_vector_idx;
+
+ALTER TABLE projecta_payments
+    DROP COLUMN IF EXISTS search_vector;
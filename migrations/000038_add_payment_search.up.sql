@@ -0,0 +1,19 @@
+-- Postgres ships no Ukrainian text search configuration. Fall back to one that
+-- only lowercases the words, so a server with Ukrainian hunspell dictionaries
+-- installed may ALTER it to stem them as well.
+DO
+$$
+    BEGIN
+        IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'ukrainian') THEN
+            CREATE TEXT SEARCH CONFIGURATION ukrainian (COPY = simple);
+        END IF;
+    END
+$$;
+
+ALTER TABLE projecta_payments
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('english'::REGCONFIG, COALESCE(description, '')) ||
+        to_tsvector('ukrainian'::REGCONFIG, COALESCE(description, ''))
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS projecta_payments_search_vector_idx ON projecta_payments USING GIN (search_vector);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS projecta_statement_lines;
+DROP TABLE IF EXISTS projecta_statement_rules;
@@ -0,0 +1,41 @@
+-- rules booking statement entries under a cost type by their counterparty or
+-- description
+CREATE TABLE IF NOT EXISTS projecta_statement_rules
+(
+    rule_id    UUID         PRIMARY KEY NOT NULL,
+    project_id UUID         NOT NULL,
+    field      VARCHAR(20)  NOT NULL DEFAULT 'ANY',
+    pattern    VARCHAR(255) NOT NULL,
+    type_id    UUID         NOT NULL,
+    priority   INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_statement_rules_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_statement_rules_type_id_fk FOREIGN KEY (type_id) REFERENCES projecta_cost_types(type_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_statement_rules_project_id_idx
+    ON projecta_statement_rules (project_id);
+
+-- spending imported from bank statements, external_id keeps an entry from
+-- being imported twice
+CREATE TABLE IF NOT EXISTS projecta_statement_lines
+(
+    line_id      UUID         PRIMARY KEY NOT NULL,
+    project_id   UUID         NOT NULL,
+    source       VARCHAR(20)  NOT NULL,
+    external_id  VARCHAR(255) NOT NULL,
+    booked_at    TIMESTAMP    NOT NULL,
+    amount       BIGINT       NOT NULL CHECK (amount >= 0),
+    currency     CHAR(3)      NOT NULL,
+    description  TEXT,
+    counterparty TEXT,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'PENDING',
+    payment_id   UUID,
+    created_at   TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_statement_lines_external_id_key UNIQUE (project_id, external_id),
+    CONSTRAINT projecta_statement_lines_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_statement_lines_payment_id_fk FOREIGN KEY (payment_id) REFERENCES projecta_payments(payment_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS projecta_statement_lines_status_idx
+    ON projecta_statement_lines (project_id, status);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS projecta_recurring_occurrences;
+DROP TABLE IF EXISTS projecta_recurring_payments;
@@ -0,0 +1,48 @@
+-- templates the scheduler books payments from, next_date is the date of the
+-- next occurrence to materialise and NULL once the schedule is over
+CREATE TABLE IF NOT EXISTS projecta_recurring_payments
+(
+    recurring_id UUID         PRIMARY KEY NOT NULL,
+    project_id   UUID         NOT NULL,
+    owner_id     UUID         NOT NULL,
+    type_id      UUID         NOT NULL,
+    description  TEXT,
+    amount       BIGINT       NOT NULL CHECK (amount > 0),
+    currency     CHAR(3)      NOT NULL,
+    kind         expense_kind NOT NULL DEFAULT 'UPON_COMPLETION',
+    recurrence   VARCHAR(100) NOT NULL,
+    start_date   DATE         NOT NULL,
+    end_date     DATE,
+    processed    INTEGER      NOT NULL DEFAULT 0,
+    next_date    DATE,
+    created_at   TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    updated_at   TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_recurring_payments_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_recurring_payments_owner_id_fk FOREIGN KEY (owner_id) REFERENCES people(person_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_recurring_payments_type_id_fk FOREIGN KEY (type_id) REFERENCES projecta_cost_types(type_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_recurring_payments_project_id_idx
+    ON projecta_recurring_payments (project_id);
+
+CREATE INDEX IF NOT EXISTS projecta_recurring_payments_next_date_idx
+    ON projecta_recurring_payments (next_date)
+    WHERE next_date IS NOT NULL;
+
+-- single occurrences skipped, edited or booked, the primary key keeps an
+-- occurrence from being booked twice
+CREATE TABLE IF NOT EXISTS projecta_recurring_occurrences
+(
+    recurring_id    UUID        NOT NULL,
+    occurrence_date DATE        NOT NULL,
+    status          VARCHAR(20) NOT NULL,
+    amount          BIGINT CHECK (amount > 0),
+    currency        CHAR(3),
+    description     TEXT,
+    payment_date    TIMESTAMP,
+    payment_id      UUID,
+    updated_at      TIMESTAMP   NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (recurring_id, occurrence_date),
+    CONSTRAINT projecta_recurring_occurrences_recurring_id_fk FOREIGN KEY (recurring_id) REFERENCES projecta_recurring_payments(recurring_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_recurring_occurrences_payment_id_fk FOREIGN KEY (payment_id) REFERENCES projecta_payments(payment_id) ON DELETE SET NULL
+);
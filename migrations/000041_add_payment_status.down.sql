@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS projecta_payments_committed_idx;
+
+ALTER TABLE projecta_payments
+    DROP CONSTRAINT IF EXISTS projecta_payments_due_date_check,
+    DROP COLUMN IF EXISTS due_date,
+    DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS payment_status;
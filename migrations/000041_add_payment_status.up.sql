@@ -0,0 +1,15 @@
+CREATE TYPE payment_status AS ENUM (
+    'PLANNED',
+    'DUE',
+    'PAID',
+    'CANCELLED'
+);
+
+-- every payment booked so far has been made
+ALTER TABLE projecta_payments
+    ADD COLUMN status   payment_status NOT NULL DEFAULT 'PAID',
+    ADD COLUMN due_date DATE           NULL,
+    ADD CONSTRAINT projecta_payments_due_date_check CHECK (status NOT IN ('PLANNED', 'DUE') OR due_date IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS projecta_payments_committed_idx ON projecta_payments (project_id, due_date)
+    WHERE status IN ('PLANNED', 'DUE');
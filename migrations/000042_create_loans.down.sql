@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS projecta_loan_payments;
+DROP TABLE IF EXISTS projecta_loans;
+DROP TYPE IF EXISTS loan_schedule;
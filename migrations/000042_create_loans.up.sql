@@ -0,0 +1,40 @@
+CREATE TYPE loan_schedule AS ENUM ('ANNUITY', 'DIFFERENTIATED');
+
+CREATE TABLE IF NOT EXISTS projecta_loans
+(
+    loan_id     UUID          PRIMARY KEY NOT NULL,
+    project_id  UUID          NOT NULL,
+    description TEXT,
+    principal   BIGINT        NOT NULL CHECK (principal > 0),
+    currency    CHAR(3)       NOT NULL,
+    annual_rate NUMERIC(7, 4) NOT NULL CHECK (annual_rate >= 0),
+    term        INTEGER       NOT NULL CHECK (term BETWEEN 1 AND 600),
+    schedule    loan_schedule NOT NULL,
+    start_date  DATE          NOT NULL,
+    created_at  TIMESTAMP     NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMP,
+    CONSTRAINT projecta_loans_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_loans_project_id_idx
+    ON projecta_loans (project_id);
+
+CREATE TRIGGER update_timestamp_trigger
+    BEFORE UPDATE
+    ON projecta_loans
+    FOR EACH ROW
+EXECUTE FUNCTION update_timestamp_trigger_function('updated_at');
+
+-- credit payments booked against the installments, a payment repays a single
+-- installment of a single loan
+CREATE TABLE IF NOT EXISTS projecta_loan_payments
+(
+    payment_id  UUID    PRIMARY KEY NOT NULL,
+    loan_id     UUID    NOT NULL,
+    installment INTEGER NOT NULL CHECK (installment > 0),
+    CONSTRAINT projecta_loan_payments_payment_id_fk FOREIGN KEY (payment_id) REFERENCES projecta_payments(payment_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_loan_payments_loan_id_fk FOREIGN KEY (loan_id) REFERENCES projecta_loans(loan_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_loan_payments_loan_id_idx
+    ON projecta_loan_payments (loan_id, installment);
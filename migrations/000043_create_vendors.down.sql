@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS projecta_assets_vendor_id_idx;
+ALTER TABLE projecta_assets DROP COLUMN IF EXISTS vendor_id;
+
+DROP INDEX IF EXISTS projecta_payments_contract_id_idx;
+DROP INDEX IF EXISTS projecta_payments_vendor_id_idx;
+ALTER TABLE projecta_payments
+    DROP COLUMN IF EXISTS contract_id,
+    DROP COLUMN IF EXISTS vendor_id;
+
+DROP TABLE IF EXISTS projecta_contracts;
+DROP TABLE IF EXISTS projecta_vendors;
@@ -0,0 +1,75 @@
+-- a vendor with no project is shared across the projects of its owner
+CREATE TABLE IF NOT EXISTS projecta_vendors
+(
+    vendor_id    UUID         PRIMARY KEY NOT NULL,
+    owner_id     UUID         NOT NULL,
+    project_id   UUID,
+    name         VARCHAR(255) NOT NULL,
+    tax_id       VARCHAR(32),
+    contact_name VARCHAR(255),
+    email        VARCHAR(255),
+    phone        VARCHAR(32),
+    iban         VARCHAR(34),
+    created_at   TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    updated_at   TIMESTAMP,
+    CONSTRAINT projecta_vendors_owner_id_fk FOREIGN KEY (owner_id) REFERENCES people(person_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_vendors_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_vendors_project_id_idx
+    ON projecta_vendors (project_id);
+
+CREATE INDEX IF NOT EXISTS projecta_vendors_owner_id_idx
+    ON projecta_vendors (owner_id) WHERE project_id IS NULL;
+
+CREATE TRIGGER update_timestamp_trigger
+    BEFORE UPDATE
+    ON projecta_vendors
+    FOR EACH ROW
+EXECUTE FUNCTION update_timestamp_trigger_function('updated_at');
+
+-- the stages of a contract add up to its total
+CREATE TABLE IF NOT EXISTS projecta_contracts
+(
+    contract_id     UUID         PRIMARY KEY NOT NULL,
+    project_id      UUID         NOT NULL,
+    vendor_id       UUID         NOT NULL,
+    title           VARCHAR(255) NOT NULL,
+    currency        CHAR(3)      NOT NULL,
+    down_payment    BIGINT       NOT NULL DEFAULT 0 CHECK (down_payment >= 0),
+    upon_completion BIGINT       NOT NULL DEFAULT 0 CHECK (upon_completion >= 0),
+    signed_at       DATE,
+    created_at      TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    updated_at      TIMESTAMP,
+    CONSTRAINT projecta_contracts_total_check CHECK (down_payment + upon_completion > 0),
+    CONSTRAINT projecta_contracts_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_contracts_vendor_id_fk FOREIGN KEY (vendor_id) REFERENCES projecta_vendors(vendor_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_contracts_project_id_idx
+    ON projecta_contracts (project_id, vendor_id);
+
+CREATE TRIGGER update_timestamp_trigger
+    BEFORE UPDATE
+    ON projecta_contracts
+    FOR EACH ROW
+EXECUTE FUNCTION update_timestamp_trigger_function('updated_at');
+
+ALTER TABLE projecta_payments
+    ADD COLUMN IF NOT EXISTS vendor_id UUID
+        CONSTRAINT projecta_payments_vendor_id_fk REFERENCES projecta_vendors(vendor_id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS contract_id UUID
+        CONSTRAINT projecta_payments_contract_id_fk REFERENCES projecta_contracts(contract_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS projecta_payments_vendor_id_idx
+    ON projecta_payments (vendor_id) WHERE vendor_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS projecta_payments_contract_id_idx
+    ON projecta_payments (contract_id) WHERE contract_id IS NOT NULL;
+
+ALTER TABLE projecta_assets
+    ADD COLUMN IF NOT EXISTS vendor_id UUID
+        CONSTRAINT projecta_assets_vendor_id_fk REFERENCES projecta_vendors(vendor_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS projecta_assets_vendor_id_idx
+    ON projecta_assets (vendor_id) WHERE vendor_id IS NOT NULL;
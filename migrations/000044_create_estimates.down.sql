@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS projecta_quote_items;
+DROP TABLE IF EXISTS projecta_quotes;
+DROP TABLE IF EXISTS projecta_estimates;
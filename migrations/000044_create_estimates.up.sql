@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS projecta_estimates
+(
+    estimate_id       UUID         PRIMARY KEY NOT NULL,
+    project_id        UUID         NOT NULL,
+    title             VARCHAR(255) NOT NULL,
+    -- the quote hired, not referenced so the quotes may be saved after it
+    accepted_quote_id UUID,
+    created_at        TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    updated_at        TIMESTAMP,
+    CONSTRAINT projecta_estimates_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_estimates_project_id_idx
+    ON projecta_estimates (project_id);
+
+CREATE TRIGGER update_timestamp_trigger
+    BEFORE UPDATE
+    ON projecta_estimates
+    FOR EACH ROW
+EXECUTE FUNCTION update_timestamp_trigger_function('updated_at');
+
+CREATE TABLE IF NOT EXISTS projecta_quotes
+(
+    quote_id    UUID      PRIMARY KEY NOT NULL,
+    estimate_id UUID      NOT NULL,
+    vendor_id   UUID      NOT NULL,
+    note        TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    updated_at  TIMESTAMP,
+    CONSTRAINT projecta_quotes_estimate_id_fk FOREIGN KEY (estimate_id) REFERENCES projecta_estimates(estimate_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_quotes_vendor_id_fk FOREIGN KEY (vendor_id) REFERENCES projecta_vendors(vendor_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_quotes_estimate_id_idx
+    ON projecta_quotes (estimate_id);
+
+CREATE TRIGGER update_timestamp_trigger
+    BEFORE UPDATE
+    ON projecta_quotes
+    FOR EACH ROW
+EXECUTE FUNCTION update_timestamp_trigger_function('updated_at');
+
+-- the items are replaced as a whole whenever the quote is saved
+CREATE TABLE IF NOT EXISTS projecta_quote_items
+(
+    quote_id    UUID           NOT NULL,
+    position    INTEGER        NOT NULL,
+    type_id     UUID           NOT NULL,
+    description TEXT,
+    quantity    NUMERIC(14, 3) NOT NULL CHECK (quantity > 0),
+    unit_price  BIGINT         NOT NULL CHECK (unit_price > 0),
+    currency    CHAR(3)        NOT NULL,
+    PRIMARY KEY (quote_id, position),
+    CONSTRAINT projecta_quote_items_quote_id_fk FOREIGN KEY (quote_id) REFERENCES projecta_quotes(quote_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_quote_items_type_id_fk FOREIGN KEY (type_id) REFERENCES projecta_cost_types(type_id) ON DELETE CASCADE
+);
@@ -0,0 +1,29 @@
+-- files of several attachments with the same content are stored once, under
+-- the project and the checksum; the files of attachments removed along with
+-- their payment, asset or project stay in the storage
+CREATE TABLE IF NOT EXISTS projecta_attachments
+(
+    attachment_id UUID         PRIMARY KEY NOT NULL,
+    project_id    UUID         NOT NULL,
+    payment_id    UUID,
+    asset_id      UUID,
+    file_name     VARCHAR(255) NOT NULL,
+    content_type  VARCHAR(100) NOT NULL,
+    size          BIGINT       NOT NULL CHECK (size > 0),
+    checksum      CHAR(64)     NOT NULL,
+    uploaded_by   UUID         NOT NULL,
+    uploaded_at   TIMESTAMP    NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_attachments_target_check CHECK ((payment_id IS NULL) <> (asset_id IS NULL)),
+    CONSTRAINT projecta_attachments_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_attachments_payment_id_fk FOREIGN KEY (payment_id) REFERENCES projecta_payments(payment_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_attachments_asset_id_fk FOREIGN KEY (asset_id) REFERENCES projecta_assets(asset_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_attachments_payment_id_idx
+    ON projecta_attachments (payment_id) WHERE payment_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS projecta_attachments_asset_id_idx
+    ON projecta_attachments (asset_id) WHERE asset_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS projecta_attachments_checksum_idx
+    ON projecta_attachments (project_id, checksum);
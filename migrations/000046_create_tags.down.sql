@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS projecta_asset_tags;
+DROP TABLE IF EXISTS projecta_payment_tags;
+DROP TABLE IF EXISTS projecta_tags;
@@ -0,0 +1,35 @@
+-- tags are kept per project and lower-cased, so the same name is one tag
+CREATE TABLE IF NOT EXISTS projecta_tags
+(
+    tag_id     UUID        PRIMARY KEY NOT NULL,
+    project_id UUID        NOT NULL,
+    name       VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_tags_name_check CHECK (name = lower(name) AND name <> ''),
+    CONSTRAINT projecta_tags_project_id_fk FOREIGN KEY (project_id) REFERENCES projecta_projects(project_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_tags_project_id_name_key UNIQUE (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS projecta_payment_tags
+(
+    payment_id UUID NOT NULL,
+    tag_id     UUID NOT NULL,
+    CONSTRAINT projecta_payment_tags_pk PRIMARY KEY (payment_id, tag_id),
+    CONSTRAINT projecta_payment_tags_payment_id_fk FOREIGN KEY (payment_id) REFERENCES projecta_payments(payment_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_payment_tags_tag_id_fk FOREIGN KEY (tag_id) REFERENCES projecta_tags(tag_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_payment_tags_tag_id_idx
+    ON projecta_payment_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS projecta_asset_tags
+(
+    asset_id UUID NOT NULL,
+    tag_id   UUID NOT NULL,
+    CONSTRAINT projecta_asset_tags_pk PRIMARY KEY (asset_id, tag_id),
+    CONSTRAINT projecta_asset_tags_asset_id_fk FOREIGN KEY (asset_id) REFERENCES projecta_assets(asset_id) ON DELETE CASCADE,
+    CONSTRAINT projecta_asset_tags_tag_id_fk FOREIGN KEY (tag_id) REFERENCES projecta_tags(tag_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS projecta_asset_tags_tag_id_idx
+    ON projecta_asset_tags (tag_id);
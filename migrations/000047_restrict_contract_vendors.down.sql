@@ -0,0 +1,3 @@
+ALTER TABLE projecta_contracts
+    DROP CONSTRAINT IF EXISTS projecta_contracts_vendor_id_fk,
+    ADD CONSTRAINT projecta_contracts_vendor_id_fk FOREIGN KEY (vendor_id) REFERENCES projecta_vendors(vendor_id) ON DELETE CASCADE;
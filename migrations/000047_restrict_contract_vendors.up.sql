@@ -0,0 +1,6 @@
+-- a shared vendor may be under contract in several projects, removing it must
+-- not take the contracts of the other projects along; the check is deferred to
+-- the end of the statement so deleting a person or a project still cascades
+ALTER TABLE projecta_contracts
+    DROP CONSTRAINT IF EXISTS projecta_contracts_vendor_id_fk,
+    ADD CONSTRAINT projecta_contracts_vendor_id_fk FOREIGN KEY (vendor_id) REFERENCES projecta_vendors(vendor_id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS release_attachment_file_trigger ON projecta_attachments;
+DROP FUNCTION IF EXISTS release_attachment_file_trigger_function();
+DROP TABLE IF EXISTS projecta_attachment_releases;
@@ -0,0 +1,27 @@
+-- the files of removed attachments are released for the storage to be swept,
+-- also when the attachments are removed along with their payment, asset or
+-- project; a released file is removed unless another attachment shares it
+CREATE TABLE IF NOT EXISTS projecta_attachment_releases
+(
+    project_id  UUID      NOT NULL,
+    checksum    CHAR(64)  NOT NULL,
+    released_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT projecta_attachment_releases_pk PRIMARY KEY (project_id, checksum)
+);
+
+CREATE OR REPLACE FUNCTION release_attachment_file_trigger_function()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO projecta_attachment_releases (project_id, checksum)
+    VALUES (OLD.project_id, OLD.checksum)
+    ON CONFLICT (project_id, checksum) DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER release_attachment_file_trigger
+    AFTER DELETE
+    ON projecta_attachments
+    FOR EACH ROW
+EXECUTE FUNCTION release_attachment_file_trigger_function();
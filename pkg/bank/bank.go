@@ -0,0 +1,63 @@
+package bank
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+// Format is the file format a bank statement is read from.
+type Format string
+
+const (
+	// FormatMonobank is the statement JSON of the Monobank API.
+	FormatMonobank Format = "monobank"
+	// FormatPrivatBank is the CSV card statement exported from Privat24.
+	FormatPrivatBank Format = "privatbank"
+	// FormatCAMT053 is the ISO 20022 bank to customer statement XML.
+	FormatCAMT053 Format = "camt053"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "monobank":
+		return FormatMonobank, nil
+	case "privatbank":
+		return FormatPrivatBank, nil
+	case "camt053", "camt.053":
+		return FormatCAMT053, nil
+	default:
+		return "", exceptions.NewValidationException(fmt.Sprintf("unknown statement format: %s", value), nil)
+	}
+}
+
+func (f Format) String() string {
+	return string(f)
+}
+
+// Parse reads the transactions of a statement written in format.
+func Parse(format Format, r io.Reader) ([]*statement.Transaction, error) {
+	switch format {
+	case FormatMonobank:
+		return ParseMonobank(r)
+	case FormatPrivatBank:
+		return ParsePrivatBank(r)
+	case FormatCAMT053:
+		return ParseCAMT053(r)
+	default:
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unknown statement format: %s", format), nil)
+	}
+}
+
+// fingerprint makes up an id for the entries a bank writes none for, out of
+// everything the bank tells about them.
+func fingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x1f")))
+
+	return hex.EncodeToString(sum[:16])
+}
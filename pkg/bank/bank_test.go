@@ -0,0 +1,185 @@
+package bank
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read(_ []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func isValidationError(err error) bool {
+	var ex exceptions.Exception
+	return errors.As(err, &ex) && ex.Code == exceptions.ValidationFailed
+}
+
+func parseFixture(t *testing.T, format Format, name string) []*statement.Transaction {
+	t.Helper()
+
+	file, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	transactions, err := Parse(format, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return transactions
+}
+
+func assertTransaction(t *testing.T, tr *statement.Transaction, id string, date time.Time, amount int64, currency string, description string, counterparty string) {
+	t.Helper()
+
+	if tr.ExternalID != id && id != "" {
+		t.Errorf("expected id %s, got %s", id, tr.ExternalID)
+	}
+	if !tr.Date.Equal(date) {
+		t.Errorf("expected date %v, got %v", date, tr.Date)
+	}
+	if tr.Amount.Amount() != amount || tr.Amount.Currency().Code != currency {
+		t.Errorf("expected %d %s, got %d %s", amount, currency, tr.Amount.Amount(), tr.Amount.Currency().Code)
+	}
+	if tr.Description != description || tr.Counterparty != counterparty {
+		t.Errorf("expected %q from %q, got %q from %q", description, counterparty, tr.Description, tr.Counterparty)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for value, expected := range map[string]Format{"monobank": FormatMonobank, " PrivatBank ": FormatPrivatBank, "camt053": FormatCAMT053, "CAMT.053": FormatCAMT053} {
+		if format, err := ParseFormat(value); err != nil || format != expected {
+			t.Errorf("ParseFormat(%q) = %v, %v", value, format, err)
+		}
+	}
+
+	if _, err := ParseFormat("ofx"); !isValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	if _, err := Parse(Format("ofx"), strings.NewReader("")); !isValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	if FormatCAMT053.String() != "camt053" {
+		t.Error("unexpected format name")
+	}
+}
+
+func TestParseMonobank(t *testing.T) {
+	transactions := parseFixture(t, FormatMonobank, "monobank.json")
+
+	if len(transactions) != 4 {
+		t.Fatalf("expected 4 transactions, got %d", len(transactions))
+	}
+
+	assertTransaction(t, transactions[0], "ZuHWzqkKGVo=", time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC), -245050, "UAH", "Епіцентр плитка", "ТОВ Епіцентр К")
+	assertTransaction(t, transactions[1], "aG5sjdA7Yxw=", time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC), -11000, "EUR", "IKEA", "")
+	assertTransaction(t, transactions[2], "Pq71kfS0aLc=", time.Date(2026, 3, 3, 8, 30, 0, 0, time.UTC), 500000, "UAH", "Від: Олена", "")
+	assertTransaction(t, transactions[3], "", time.Date(2026, 3, 4, 8, 30, 0, 0, time.UTC), -8500, "UAH", "Нова пошта", "")
+
+	if id := transactions[3].ExternalID; len(id) != 32 || id != parseFixture(t, FormatMonobank, "monobank.json")[3].ExternalID {
+		t.Errorf("expected a stable fingerprint, got %q", id)
+	}
+
+	for _, content := range []string{`{"id": "x"}`, `[{"id": "x", "currencyCode": 1}]`} {
+		if _, err := ParseMonobank(strings.NewReader(content)); !isValidationError(err) {
+			t.Errorf("expected validation error for %s, got %v", content, err)
+		}
+	}
+}
+
+func TestParsePrivatBank(t *testing.T) {
+	transactions := parseFixture(t, FormatPrivatBank, "privatbank.csv")
+
+	if len(transactions) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(transactions))
+	}
+
+	assertTransaction(t, transactions[0], "", time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC), -125050, "UAH", "Леруа Мерлен, фарба", "")
+	assertTransaction(t, transactions[1], "", time.Date(2026, 3, 2, 18, 40, 0, 0, time.UTC), 300000, "UAH", "Переказ від Олени", "")
+	assertTransaction(t, transactions[2], "", time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC), -4000, "USD", "Amazon", "")
+
+	if transactions[0].ExternalID == transactions[1].ExternalID {
+		t.Error("expected distinct fingerprints")
+	}
+
+	transactions, err := ParsePrivatBank(strings.NewReader("\ufeffДата,Опис операції,Сума в валюті транзакції,Валюта транзакції\n05.03.2026,\"Цемент, 10 мішків\",\"-1,200.00\",UAH\n"))
+	if err != nil || len(transactions) != 1 {
+		t.Fatalf("unexpected result: %v, %v", transactions, err)
+	}
+	assertTransaction(t, transactions[0], "", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), -120000, "UAH", "Цемент, 10 мішків", "")
+
+	once := "Дата;Опис операції;Сума в валюті транзакції;Валюта транзакції\n05.03.2026 10:15;Кава;-65;UAH\n"
+	twice := once + "05.03.2026 10:15;Кава;-65;UAH\n"
+	transactions, err = ParsePrivatBank(strings.NewReader(twice))
+	if err != nil || len(transactions) != 2 || transactions[0].ExternalID == transactions[1].ExternalID {
+		t.Fatalf("expected identical purchases to get distinct fingerprints, got %v, %v", transactions, err)
+	}
+	if again, _ := ParsePrivatBank(strings.NewReader(twice)); again[0].ExternalID != transactions[0].ExternalID || again[1].ExternalID != transactions[1].ExternalID {
+		t.Error("expected stable fingerprints")
+	}
+	if single, _ := ParsePrivatBank(strings.NewReader(once)); len(single) != 1 || single[0].ExternalID != transactions[0].ExternalID {
+		t.Error("expected the first of identical purchases to keep its fingerprint")
+	}
+
+	for name, content := range map[string]string{
+		"no table":       "Виписка\n",
+		"invalid date":   "Дата;Опис операції;Сума в валюті транзакції;Валюта транзакції\n2026-03-05;Цемент;-100;UAH\n",
+		"invalid amount": "Дата;Опис операції;Сума в валюті транзакції;Валюта транзакції\n05.03.2026;Цемент;сто;UAH\n",
+	} {
+		if _, err := ParsePrivatBank(strings.NewReader(content)); !isValidationError(err) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+
+	if _, err := ParsePrivatBank(failingReader{}); !isValidationError(err) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestParseCAMT053(t *testing.T) {
+	transactions := parseFixture(t, FormatCAMT053, "camt053.xml")
+
+	if len(transactions) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(transactions))
+	}
+
+	assertTransaction(t, transactions[0], "REF-0001", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), -1850000, "UAH", "Електромонтажні роботи рахунок 17", "ФОП Коваленко")
+	assertTransaction(t, transactions[1], "2", time.Date(2026, 3, 6, 7, 30, 0, 0, time.UTC), 5000000, "UAH", "", "Олена Петренко")
+	assertTransaction(t, transactions[2], "", time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), -32040, "UAH", "Комісія банку за обслуговування рахунку", "")
+
+	if len(transactions[2].ExternalID) != 32 {
+		t.Errorf("expected a fingerprint, got %q", transactions[2].ExternalID)
+	}
+
+	entry := func(inner string) string {
+		return `<Document><BkToCstmrStmt><Stmt><Ntry>` + inner + `</Ntry></Stmt></BkToCstmrStmt></Document>`
+	}
+
+	transactions, err := ParseCAMT053(strings.NewReader(entry(`<Amt Ccy="EUR">10</Amt><CdtDbtInd>DBIT</CdtDbtInd><BookgDt><DtTm>2026-03-07T10:00:00</DtTm></BookgDt>`)))
+	if err != nil || len(transactions) != 1 {
+		t.Fatalf("unexpected result: %v, %v", transactions, err)
+	}
+	assertTransaction(t, transactions[0], "", time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC), -1000, "EUR", "", "")
+
+	for name, content := range map[string]string{
+		"not xml":        "{}",
+		"invalid amount": entry(`<Amt Ccy="UAH">ten</Amt><BookgDt><Dt>2026-03-05</Dt></BookgDt>`),
+		"no date":        entry(`<Amt Ccy="UAH">10.00</Amt>`),
+	} {
+		if _, err := ParseCAMT053(strings.NewReader(content)); !isValidationError(err) {
+			t.Errorf("%s: expected validation error, got %v", name, err)
+		}
+	}
+}
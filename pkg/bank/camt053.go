@@ -0,0 +1,162 @@
+package bank
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+const camtDebit = "DBIT"
+
+// camtDocument is the part of an ISO 20022 camt.053 document the transactions
+// are read from. Elements are matched by local name, so every version of the
+// message is read alike.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	Indicator          string   `xml:"CdtDbtInd"`
+	BookingDate        camtDate `xml:"BookgDt"`
+	ValueDate          camtDate `xml:"ValDt"`
+	ServicerRef        string   `xml:"AcctSvcrRef"`
+	EntryRef           string   `xml:"NtryRef"`
+	AdditionalInfo     string   `xml:"AddtlNtryInf"`
+	TransactionDetails []struct {
+		Remittance []string  `xml:"RmtInf>Ustrd"`
+		Creditor   camtParty `xml:"RltdPties>Cdtr"`
+		Debtor     camtParty `xml:"RltdPties>Dbtr"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// camtParty holds the name of a party the way both the older versions of the
+// message write it and the newer ones, which wrap it in Pty.
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+// ParseCAMT053 reads an ISO 20022 bank to customer statement. Entries are taken
+// in the currency of the account, debits as the money that left it.
+func ParseCAMT053(r io.Reader) ([]*statement.Transaction, error) {
+	var document camtDocument
+
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, exceptions.NewValidationException("invalid camt.053 statement", err)
+	}
+
+	transactions := make([]*statement.Transaction, 0)
+
+	for _, stmt := range document.Statements {
+		for i, entry := range stmt.Entries {
+			t, err := entry.transaction()
+
+			if err != nil {
+				return nil, exceptions.NewValidationException(fmt.Sprintf("invalid entry %d of camt.053 statement", i+1), err)
+			}
+
+			transactions = append(transactions, t)
+		}
+	}
+
+	return transactions, nil
+}
+
+func (e camtEntry) transaction() (*statement.Transaction, error) {
+	amount, err := projecta.ParseMoney(e.Amount.Value, e.Amount.Currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := e.BookingDate.parse()
+
+	if err != nil {
+		if date, err = e.ValueDate.parse(); err != nil {
+			return nil, err
+		}
+	}
+
+	debit := strings.EqualFold(strings.TrimSpace(e.Indicator), camtDebit)
+
+	if debit {
+		amount = amount.Negative()
+	}
+
+	description := make([]string, 0)
+	counterparty := ""
+
+	for _, details := range e.TransactionDetails {
+		for _, line := range details.Remittance {
+			if line = strings.TrimSpace(line); line != "" {
+				description = append(description, line)
+			}
+		}
+
+		party := details.Debtor
+		if debit {
+			party = details.Creditor
+		}
+
+		if counterparty == "" {
+			counterparty = party.name()
+		}
+	}
+
+	if len(description) == 0 && strings.TrimSpace(e.AdditionalInfo) != "" {
+		description = append(description, strings.TrimSpace(e.AdditionalInfo))
+	}
+
+	id := strings.TrimSpace(e.ServicerRef)
+	if id == "" {
+		id = strings.TrimSpace(e.EntryRef)
+	}
+	if id == "" {
+		id = fingerprint(e.BookingDate.Date, e.BookingDate.DateTime, e.Indicator, e.Amount.Value, e.Amount.Currency, strings.Join(description, " "), counterparty)
+	}
+
+	return &statement.Transaction{
+		ExternalID:   id,
+		Date:         date,
+		Amount:       amount,
+		Description:  strings.Join(description, " "),
+		Counterparty: counterparty,
+	}, nil
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if value := strings.TrimSpace(d.DateTime); value != "" {
+		if date, err := time.Parse(time.RFC3339, value); err == nil {
+			return date.UTC(), nil
+		}
+
+		return time.Parse("2006-01-02T15:04:05", value)
+	}
+
+	return time.Parse(time.DateOnly, strings.TrimSpace(d.Date))
+}
+
+func (p camtParty) name() string {
+	if name := strings.TrimSpace(p.PartyName); name != "" {
+		return name
+	}
+
+	return strings.TrimSpace(p.Name)
+}
@@ -0,0 +1,68 @@
+package bank
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+// monobankItem is an entry of the statement the Monobank API returns from
+// /personal/statement. Amounts are in minor units, OperationAmount in the
+// currency of CurrencyCode, a numeric ISO 4217 code.
+type monobankItem struct {
+	ID              string `json:"id"`
+	Time            int64  `json:"time"`
+	Description     string `json:"description"`
+	Comment         string `json:"comment"`
+	OperationAmount int64  `json:"operationAmount"`
+	CurrencyCode    int    `json:"currencyCode"`
+	CounterName     string `json:"counterName"`
+}
+
+// ParseMonobank reads a Monobank statement. Entries are taken in the currency
+// they were made in, a purchase abroad is booked in the currency paid.
+func ParseMonobank(r io.Reader) ([]*statement.Transaction, error) {
+	var items []monobankItem
+
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, exceptions.NewValidationException("invalid Monobank statement", err)
+	}
+
+	transactions := make([]*statement.Transaction, 0, len(items))
+
+	for _, item := range items {
+		code := fmt.Sprintf("%03d", item.CurrencyCode)
+		currency := money.GetCurrencyByNumericCode(code)
+
+		if currency == nil {
+			return nil, exceptions.NewValidationException(fmt.Sprintf("unknown currency code %s in Monobank statement", code), nil)
+		}
+
+		id := item.ID
+		if id == "" {
+			id = fingerprint(strconv.FormatInt(item.Time, 10), strconv.FormatInt(item.OperationAmount, 10), code, item.Description)
+		}
+
+		description := strings.TrimSpace(item.Description)
+		if comment := strings.TrimSpace(item.Comment); comment != "" {
+			description = strings.TrimSpace(description + " " + comment)
+		}
+
+		transactions = append(transactions, &statement.Transaction{
+			ExternalID:   id,
+			Date:         time.Unix(item.Time, 0).UTC(),
+			Amount:       money.New(item.OperationAmount, currency.Code),
+			Description:  description,
+			Counterparty: strings.TrimSpace(item.CounterName),
+		})
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,182 @@
+package bank
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+)
+
+// the columns of a Privat24 card statement the transactions are read from
+const (
+	privatBankDate        = "дата"
+	privatBankCard        = "картка"
+	privatBankDescription = "опис операції"
+	privatBankAmount      = "сума в валюті транзакції"
+	privatBankCurrency    = "валюта транзакції"
+)
+
+var privatBankDateLayouts = []string{
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// ParsePrivatBank reads a card statement exported from Privat24 as CSV. The
+// title lines above the table are skipped, the columns are found by name and
+// separated by semicolons or commas. Entries are taken in the currency they
+// were made in.
+func ParsePrivatBank(r io.Reader) ([]*statement.Transaction, error) {
+	content, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException("failed to read PrivatBank statement", err)
+	}
+
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = privatBankDelimiter(content)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var columns map[string]int
+
+	for columns == nil {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			return nil, exceptions.NewValidationException("no transactions table in PrivatBank statement", nil)
+		}
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid PrivatBank statement", err)
+		}
+
+		columns = privatBankColumns(record)
+	}
+
+	transactions := make([]*statement.Transaction, 0)
+	// identical entries, e.g. two equal purchases within a minute, are told
+	// apart by their position among the ones before
+	seen := make(map[string]int)
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid PrivatBank statement", err)
+		}
+
+		field := func(name string) string {
+			if index := columns[name]; index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+
+		if field(privatBankDate) == "" && field(privatBankAmount) == "" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		date, err := parsePrivatBankDate(field(privatBankDate))
+
+		if err != nil {
+			return nil, exceptions.NewValidationException(fmt.Sprintf("invalid date on line %d of PrivatBank statement", line), err)
+		}
+
+		amount, err := projecta.ParseMoney(field(privatBankAmount), field(privatBankCurrency))
+
+		if err != nil {
+			return nil, exceptions.NewValidationException(fmt.Sprintf("invalid amount on line %d of PrivatBank statement", line), err)
+		}
+
+		id := fingerprint(field(privatBankCard), field(privatBankDate), field(privatBankAmount), field(privatBankCurrency), field(privatBankDescription))
+
+		// the first of them keeps the id it has always had, so statements
+		// imported before are still recognised
+		if seen[id]++; seen[id] > 1 {
+			id = fingerprint(id, strconv.Itoa(seen[id]))
+		}
+
+		transactions = append(transactions, &statement.Transaction{
+			ExternalID:  id,
+			Date:        date,
+			Amount:      amount,
+			Description: field(privatBankDescription),
+		})
+	}
+
+	return transactions, nil
+}
+
+// privatBankColumns finds the columns in the header of the table, nil when
+// record is not the header.
+func privatBankColumns(record []string) map[string]int {
+	columns := make(map[string]int, len(record))
+
+	for i, name := range record {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{privatBankDate, privatBankDescription, privatBankAmount, privatBankCurrency} {
+		if _, ok := columns[required]; !ok {
+			return nil
+		}
+	}
+
+	if _, ok := columns[privatBankCard]; !ok {
+		columns[privatBankCard] = len(record)
+	}
+
+	return columns
+}
+
+// privatBankDelimiter tells the separator by the header line, Privat24 writes
+// semicolons or commas depending on the locale of the export.
+func privatBankDelimiter(content []byte) rune {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	for scanner.Scan() {
+		line := strings.ToLower(scanner.Text())
+
+		if strings.Contains(line, privatBankAmount) {
+			if strings.Count(line, ";") > strings.Count(line, ",") {
+				return ';'
+			}
+
+			break
+		}
+	}
+
+	return ','
+}
+
+func parsePrivatBankDate(value string) (time.Time, error) {
+	var err error
+
+	for _, layout := range privatBankDateLayouts {
+		var date time.Time
+
+		if date, err = time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, err
+}
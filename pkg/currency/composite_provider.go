@@ -0,0 +1,68 @@
+package currency
+
+import (
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// CompositeRateProvider tries its providers in order and returns the result of
+// the first one that succeeds, e.g. to fall back to ECB or a rates file while
+// bank.gov.ua is down.
+type CompositeRateProvider struct {
+	providers []RateProvider
+}
+
+func NewCompositeRateProvider(providers ...RateProvider) *CompositeRateProvider {
+	return &CompositeRateProvider{
+		providers: providers,
+	}
+}
+
+func (p *CompositeRateProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
+	return firstSuccessful(p.providers, func(provider RateProvider) (Currency, error) {
+		return provider.Convert(currencyA, currencyB)
+	})
+}
+
+func (p *CompositeRateProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	return firstSuccessful(p.providers, func(provider RateProvider) (Currency, error) {
+		return provider.ConvertAt(currencyA, currencyB, date)
+	})
+}
+
+func (p *CompositeRateProvider) RateAt(from string, to string, date time.Time) (float64, error) {
+	return firstSuccessful(p.providers, func(provider RateProvider) (float64, error) {
+		return provider.RateAt(from, to, date)
+	})
+}
+
+// Rounding returns the rounding mode of the first provider.
+func (p *CompositeRateProvider) Rounding() RoundingMode {
+	if len(p.providers) == 0 {
+		return RoundHalfUp
+	}
+
+	return p.providers[0].Rounding()
+}
+
+// Book books with the rounding mode of the first provider.
+func (p *CompositeRateProvider) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return p.Rounding().Book(amount, to, rate)
+}
+
+// firstSuccessful returns the error of the last provider when all of them fail.
+func firstSuccessful[T any](providers []RateProvider, call func(provider RateProvider) (T, error)) (T, error) {
+	var zero T
+	err := error(exceptions.NewInternalException("no exchange rate providers configured", nil))
+
+	for _, provider := range providers {
+		var result T
+		if result, err = call(provider); err == nil {
+			return result, nil
+		}
+	}
+
+	return zero, err
+}
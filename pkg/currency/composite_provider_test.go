@@ -0,0 +1,86 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+type stubRateProvider struct {
+	stubProvider
+	rate     float64
+	rounding RoundingMode
+}
+
+func (s *stubRateProvider) Rounding() RoundingMode {
+	return s.rounding
+}
+
+func (s *stubRateProvider) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return s.rounding.Book(amount, to, rate)
+}
+
+func (s *stubRateProvider) RateAt(from string, to string, date time.Time) (float64, error) {
+	s.dates = append(s.dates, date)
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.rate, nil
+}
+
+func TestCompositeRateProvider(t *testing.T) {
+	paidAt := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	from := Currency{Amount: 100, Code: "USD"}
+	to := Currency{Code: "UAH"}
+
+	t.Run("Falls back to the next provider", func(t *testing.T) {
+		down := &stubRateProvider{stubProvider: stubProvider{err: errors.New("bank.gov.ua is down")}}
+		backup := &stubRateProvider{rate: 41}
+		unused := &stubRateProvider{rate: 42}
+		provider := NewCompositeRateProvider(down, backup, unused)
+
+		if res, err := provider.Convert(from, to); err != nil || res.Amount != 4000 {
+			t.Errorf("expected 4000, got %v, %v", res, err)
+		}
+
+		if res, err := provider.ConvertAt(from, to, paidAt); err != nil || res.Amount != 4000 {
+			t.Errorf("expected 4000, got %v, %v", res, err)
+		}
+
+		if rate, err := provider.RateAt("USD", "UAH", paidAt); err != nil || rate != 41 {
+			t.Errorf("expected 41, got %v, %v", rate, err)
+		}
+
+		if len(down.dates) != 3 || len(backup.dates) != 3 || len(unused.dates) != 0 {
+			t.Errorf("unexpected calls: %d, %d, %d", len(down.dates), len(backup.dates), len(unused.dates))
+		}
+
+		if !backup.dates[1].Equal(paidAt) || !backup.dates[2].Equal(paidAt) {
+			t.Errorf("expected the date to be passed on, got %v", backup.dates)
+		}
+	})
+
+	t.Run("Returns the error of the last provider", func(t *testing.T) {
+		last := errors.New("no rates file")
+		provider := NewCompositeRateProvider(
+			&stubRateProvider{stubProvider: stubProvider{err: errors.New("bank.gov.ua is down")}},
+			&stubRateProvider{stubProvider: stubProvider{err: last}},
+		)
+
+		if _, err := provider.ConvertAt(from, to, paidAt); !errors.Is(err, last) {
+			t.Errorf("expected the last error, got %v", err)
+		}
+
+		if _, err := provider.RateAt("USD", "UAH", paidAt); !errors.Is(err, last) {
+			t.Errorf("expected the last error, got %v", err)
+		}
+	})
+
+	t.Run("Fails without providers", func(t *testing.T) {
+		if _, err := NewCompositeRateProvider().Convert(from, to); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -1,16 +1,21 @@
 package currency
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Currency struct {
 	Amount int64  `json:"amount"` // Amount in smallest monetary units (e.g. cents, kopiyok)
 	Code   string `json:"code"`   // Currency ISO code e.g. "UAH", "USD", "EUR", "PLN"
+	Scale  int    `json:"scale"`  // Number of minor unit digits per ISO 4217, e.g. 2 for UAH, 0 for JPY
 }
 
 func NewCurrency(amount int64, code string) Currency {
 	return Currency{
 		Amount: amount,
 		Code:   code,
+		Scale:  MinorUnits(code),
 	}
 }
 
@@ -20,4 +25,6 @@ func (c Currency) String() string {
 
 type CurrencyRateProvider interface {
 	Convert(currencyA Currency, currencyB Currency) (Currency, error)
+	// ConvertAt converts using the rates in effect on the given date.
+	ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error)
 }
@@ -0,0 +1,72 @@
+package currency
+
+import (
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// maxHistoryDays is how many past days of rates are kept in memory, the ones
+// cached first are forgotten first.
+const maxHistoryDays = 366
+
+// dayCache keeps the rates of past days, which never change. The lookups of the
+// same day share one load, made without holding the lock so the other days are
+// not held up by a slow one.
+type dayCache[V any] struct {
+	mu    sync.RWMutex
+	days  map[string]V
+	order []string
+	loads singleflight.Group
+}
+
+func newDayCache[V any]() *dayCache[V] {
+	return &dayCache[V]{days: make(map[string]V)}
+}
+
+// get returns the cached value of day, loading it when missing. Failed loads
+// are not cached.
+func (c *dayCache[V]) get(day string, load func() (V, error)) (V, error) {
+	c.mu.RLock()
+	value, ok := c.days[day]
+	c.mu.RUnlock()
+
+	if ok {
+		return value, nil
+	}
+
+	loaded, err, _ := c.loads.Do(day, func() (any, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		c.remember(day, value)
+
+		return value, nil
+	})
+
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return loaded.(V), nil
+}
+
+// remember caches the value of day, forgetting the day cached first once the
+// cache is full.
+func (c *dayCache[V]) remember(day string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, known := c.days[day]; !known {
+		c.order = append(c.order, day)
+	}
+	c.days[day] = value
+
+	for len(c.order) > maxHistoryDays {
+		delete(c.days, c.order[0])
+		c.order = c.order[1:]
+	}
+}
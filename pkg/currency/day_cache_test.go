@@ -0,0 +1,58 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDayCache(t *testing.T) {
+	t.Run("Loads a day once", func(t *testing.T) {
+		cache := newDayCache[int]()
+		loads := 0
+		load := func() (int, error) {
+			loads++
+			return 40, nil
+		}
+
+		for i := 0; i < 3; i++ {
+			if value, err := cache.get("20240304", load); err != nil || value != 40 {
+				t.Errorf("expected 40, got %v, %v", value, err)
+			}
+		}
+
+		if loads != 1 {
+			t.Errorf("expected a single load, got %d", loads)
+		}
+	})
+
+	t.Run("Does not cache failed loads", func(t *testing.T) {
+		cache := newDayCache[int]()
+
+		if _, err := cache.get("20240304", func() (int, error) { return 0, errors.New("unavailable") }); err == nil {
+			t.Error("expected error, got nil")
+		}
+
+		if value, err := cache.get("20240304", func() (int, error) { return 41, nil }); err != nil || value != 41 {
+			t.Errorf("expected the day to be loaded again, got %v, %v", value, err)
+		}
+	})
+
+	t.Run("Forgets the days cached first once full", func(t *testing.T) {
+		cache := newDayCache[int]()
+		for i := 0; i <= maxHistoryDays; i++ {
+			cache.remember(fmt.Sprintf("day%d", i), 40)
+		}
+		cache.remember("day1", 41)
+
+		if len(cache.days) != maxHistoryDays || len(cache.order) != maxHistoryDays {
+			t.Errorf("expected %d cached days, got %d", maxHistoryDays, len(cache.days))
+		}
+		if _, ok := cache.days["day0"]; ok {
+			t.Error("expected the day cached first to be forgotten")
+		}
+		if cache.days["day1"] != 41 {
+			t.Errorf("expected the value of a cached day to be replaced, got %v", cache.days["day1"])
+		}
+	})
+}
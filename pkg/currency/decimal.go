@@ -0,0 +1,101 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// RoundingMode decides how converted amounts are rounded to the minor unit of
+// the target currency.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, as banks do on statements.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbour, also known as banker's
+	// rounding, so rounding errors cancel out over many amounts.
+	RoundHalfEven
+)
+
+const defaultScale = 2
+
+// ParseRoundingMode reads a rounding mode from configuration, "half_up" or
+// "half_even".
+func ParseRoundingMode(value string) (RoundingMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "half_up", "":
+		return RoundHalfUp, nil
+	case "half_even", "bankers":
+		return RoundHalfEven, nil
+	default:
+		return RoundHalfUp, exceptions.NewValidationException(fmt.Sprintf("unknown rounding mode: %s", value), nil)
+	}
+}
+
+// Round rounds value to the closest integer according to the mode.
+func (m RoundingMode) Round(value *big.Rat) int64 {
+	return core.RoundRat(value, m == RoundHalfEven)
+}
+
+// Book converts amount into to at the quoted rate, shifting it between the minor
+// units of both currencies and rounding once according to the mode.
+func (m RoundingMode) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	if amount == nil {
+		return nil, exceptions.NewValidationException("amount is required", nil)
+	}
+
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return nil, exceptions.NewValidationException("exchange rate must be a positive number", nil)
+	}
+
+	booked := convertAmount(Currency{Amount: amount.Amount(), Code: amount.Currency().Code}, to, core.ExactDecimal(rate), m)
+
+	return money.New(booked.Amount, booked.Code), nil
+}
+
+// MinorUnits returns the number of decimal places of the currency as defined by
+// ISO 4217, e.g. 2 for UAH and 0 for JPY. Unknown currencies get 2.
+func MinorUnits(code string) int {
+	if c := money.GetCurrency(normalizeCode(code)); c != nil {
+		return c.Fraction
+	}
+
+	return defaultScale
+}
+
+// convertAmount converts currencyA into code at rate, shifting the amount
+// between the minor units of both currencies before rounding once.
+func convertAmount(currencyA Currency, code string, rate *big.Rat, mode RoundingMode) Currency {
+	code = normalizeCode(code)
+
+	value := new(big.Rat).SetInt64(currencyA.Amount)
+	value.Mul(value, rate)
+	value.Mul(value, scaleShift(MinorUnits(currencyA.Code), MinorUnits(code)))
+
+	return Currency{
+		Amount: mode.Round(value),
+		Code:   code,
+		Scale:  MinorUnits(code),
+	}
+}
+
+func scaleShift(from int, to int) *big.Rat {
+	exp := big.NewInt(int64(to - from))
+	if exp.Sign() < 0 {
+		exp.Neg(exp)
+	}
+
+	factor := new(big.Int).Exp(big.NewInt(10), exp, nil)
+
+	if to < from {
+		return new(big.Rat).SetFrac(big.NewInt(1), factor)
+	}
+
+	return new(big.Rat).SetInt(factor)
+}
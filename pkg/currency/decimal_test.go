@@ -0,0 +1,173 @@
+package currency
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+)
+
+func TestRoundingMode(t *testing.T) {
+	tests := []struct {
+		value    *big.Rat
+		halfUp   int64
+		halfEven int64
+	}{
+		{big.NewRat(5, 2), 3, 2},
+		{big.NewRat(7, 2), 4, 4},
+		{big.NewRat(-5, 2), -3, -2},
+		{big.NewRat(-7, 2), -4, -4},
+		{big.NewRat(12, 5), 2, 2},
+		{big.NewRat(13, 5), 3, 3},
+		{big.NewRat(-13, 5), -3, -3},
+		{big.NewRat(4, 1), 4, 4},
+	}
+
+	for _, tt := range tests {
+		if got := RoundHalfUp.Round(tt.value); got != tt.halfUp {
+			t.Errorf("half up of %s: expected %d, got %d", tt.value, tt.halfUp, got)
+		}
+		if got := RoundHalfEven.Round(tt.value); got != tt.halfEven {
+			t.Errorf("half even of %s: expected %d, got %d", tt.value, tt.halfEven, got)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for value, expected := range map[string]RoundingMode{"": RoundHalfUp, "half_up": RoundHalfUp, "HALF_EVEN": RoundHalfEven, "bankers": RoundHalfEven} {
+		if mode, err := ParseRoundingMode(value); err != nil || mode != expected {
+			t.Errorf("%q: expected %v, got %v, %v", value, expected, mode, err)
+		}
+	}
+
+	if _, err := ParseRoundingMode("ceiling"); err == nil {
+		t.Error("expected error for an unknown rounding mode")
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	for code, expected := range map[string]int{"UAH": 2, "jpy": 0, "BHD": 3, "XYZ": 2} {
+		if got := MinorUnits(code); got != expected {
+			t.Errorf("%s: expected %d, got %d", code, expected, got)
+		}
+	}
+
+	if c := NewCurrency(100, "JPY"); c.Scale != 0 {
+		t.Errorf("expected JPY to have no minor units, got %d", c.Scale)
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     Currency
+		to       string
+		rate     *big.Rat
+		mode     RoundingMode
+		expected Currency
+	}{
+		{"Exact published rate", NewCurrency(1, "USD"), "UAH", core.ExactDecimal(41.4567), RoundHalfUp, Currency{Amount: 41, Code: "UAH", Scale: 2}},
+		{"Large amount stays exact", NewCurrency(123456789012345, "USD"), "UAH", core.ExactDecimal(41.4567), RoundHalfUp, Currency{Amount: 5118111065048083, Code: "UAH", Scale: 2}},
+		{"Half of a minor unit is not lost to binary fractions", NewCurrency(100, "USD"), "UAH", core.ExactDecimal(0.285), RoundHalfUp, Currency{Amount: 29, Code: "UAH", Scale: 2}},
+		{"Half up", NewCurrency(1, "USD"), "UAH", core.ExactDecimal(2.5), RoundHalfUp, Currency{Amount: 3, Code: "UAH", Scale: 2}},
+		{"Half even", NewCurrency(1, "USD"), "UAH", core.ExactDecimal(2.5), RoundHalfEven, Currency{Amount: 2, Code: "UAH", Scale: 2}},
+		{"Into a currency without minor units", NewCurrency(10000, "USD"), "jpy", core.ExactDecimal(150.255), RoundHalfUp, Currency{Amount: 15026, Code: "JPY", Scale: 0}},
+		{"From a currency without minor units", NewCurrency(1000, "JPY"), "UAH", core.ExactDecimal(0.27), RoundHalfUp, Currency{Amount: 27000, Code: "UAH", Scale: 2}},
+		{"Into a currency with three minor units", NewCurrency(100, "USD"), "BHD", core.ExactDecimal(0.377), RoundHalfUp, Currency{Amount: 377, Code: "BHD", Scale: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertAmount(tt.from, tt.to, tt.rate, tt.mode); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBook(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   *money.Money
+		to       string
+		rate     float64
+		mode     RoundingMode
+		expected int64
+	}{
+		{"Exact published rate", money.New(100, money.USD), money.UAH, 0.285, RoundHalfUp, 29},
+		{"Negative amounts round away from zero", money.New(-100, money.USD), money.UAH, 0.285, RoundHalfUp, -29},
+		{"Half even", money.New(1, money.USD), money.UAH, 2.5, RoundHalfEven, 2},
+		{"From a currency without minor units", money.New(1000, money.JPY), money.UAH, 0.27, RoundHalfUp, 27000},
+		{"Into a currency without minor units", money.New(10000, money.USD), money.JPY, 150.255, RoundHalfUp, 15026},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booked, err := tt.mode.Book(tt.amount, tt.to, tt.rate)
+			if err != nil || booked.Amount() != tt.expected || booked.Currency().Code != tt.to {
+				t.Errorf("expected %d %s, got %v, %v", tt.expected, tt.to, booked, err)
+			}
+		})
+	}
+
+	for _, rate := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if _, err := RoundHalfUp.Book(money.New(100, money.USD), money.UAH, rate); err == nil {
+			t.Errorf("expected rate %v to be rejected", rate)
+		}
+	}
+
+	if _, err := RoundHalfUp.Book(nil, money.UAH, 41); err == nil {
+		t.Error("expected a missing amount to be rejected")
+	}
+
+	stored := NewStoredRateProvider(StoredRateProviderOptions{Rounding: RoundHalfEven})
+	static, _ := ParseStaticRates([]byte("base: UAH"), RoundHalfEven)
+	providers := []RateProvider{
+		NewNBUCurrencyRateProvider(NBUCurrencyRateProviderOptions{Rounding: RoundHalfEven}),
+		NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{Rounding: RoundHalfEven}),
+		stored,
+		static,
+		NewCompositeRateProvider(stored),
+	}
+
+	for _, provider := range providers {
+		if booked, err := provider.Book(money.New(1, money.USD), money.UAH, 2.5); err != nil || booked.Amount() != 2 {
+			t.Errorf("%T: expected half even booking, got %v, %v", provider, booked, err)
+		}
+	}
+}
+
+func TestCrossRate(t *testing.T) {
+	rate, err := crossRate(map[string]float64{"USD": 0.3, "EUR": 0.1}, "USD", "EUR")
+	if err != nil || rate.Cmp(big.NewRat(3, 1)) != 0 {
+		t.Errorf("expected an exact cross rate of 3, got %s, %v", rate, err)
+	}
+}
+
+func TestProviderRounding(t *testing.T) {
+	nbu := NewNBUCurrencyRateProvider(NBUCurrencyRateProviderOptions{Rounding: RoundHalfEven})
+	ecb := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{Rounding: RoundHalfEven})
+	stored := NewStoredRateProvider(StoredRateProviderOptions{Rounding: RoundHalfEven})
+	static, _ := ParseStaticRates([]byte("base: UAH"), RoundHalfEven)
+
+	for _, provider := range []RateProvider{nbu, ecb, stored, static, NewCompositeRateProvider(stored)} {
+		if provider.Rounding() != RoundHalfEven {
+			t.Errorf("%T: expected half even rounding", provider)
+		}
+	}
+
+	if NewCompositeRateProvider().Rounding() != RoundHalfUp {
+		t.Error("expected half up rounding without providers")
+	}
+
+	fixed := NewFixedRateProvider(nbu, FixedRate{Currency: "USD", Base: "UAH", Rate: 2.5})
+	if res, err := fixed.Convert(Currency{Amount: 1, Code: "USD"}, Currency{Code: "UAH"}); err != nil || res.Amount != 2 {
+		t.Errorf("expected the fixed rate to round like the next provider, got %v, %v", res, err)
+	}
+
+	if roundingOf(&stubProvider{}) != RoundHalfUp {
+		t.Error("expected half up rounding by default")
+	}
+}
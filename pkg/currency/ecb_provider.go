@@ -0,0 +1,326 @@
+package currency
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	defaultECBAPIURL     = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	defaultECBHistoryURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+	ecbDateLayout        = "2006-01-02"
+)
+
+type ecbEnvelope struct {
+	Days []ecbDay `xml:"Cube>Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string  `xml:"currency,attr"`
+	Rate     float64 `xml:"rate,attr"`
+}
+
+type ECBCurrencyRateProviderOptions struct {
+	APIURL              string
+	HistoryURL          string
+	SupportedCurrencies []string
+	CacheTTL            time.Duration
+	HTTPClient          *http.Client
+	Rounding            RoundingMode
+}
+
+// ECBCurrencyRateProvider converts with the euro reference rates of the
+// European Central Bank. The ECB quotes every currency per one euro and does not
+// publish rates on weekends and holidays, when the rates of the previous
+// working day apply.
+type ECBCurrencyRateProvider struct {
+	apiURL              string
+	historyURL          string
+	supportedCurrencies map[string]bool
+	cacheTTL            time.Duration
+	httpClient          *http.Client
+	rounding            RoundingMode
+
+	mu                 sync.RWMutex
+	rates              map[string]float64
+	lastFetched        time.Time
+	history            map[string]map[string]float64
+	historyDays        []string
+	historyLastFetched time.Time
+}
+
+func NewECBCurrencyRateProvider(opts ECBCurrencyRateProviderOptions) *ECBCurrencyRateProvider {
+	apiURL := opts.APIURL
+	if apiURL == "" {
+		apiURL = defaultECBAPIURL
+	}
+
+	historyURL := opts.HistoryURL
+	if historyURL == "" {
+		historyURL = defaultECBHistoryURL
+	}
+
+	ttl := opts.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	// no supported list means every currency the ECB publishes
+	var supported map[string]bool
+	if len(opts.SupportedCurrencies) > 0 {
+		supported = map[string]bool{"EUR": true}
+		for _, c := range opts.SupportedCurrencies {
+			supported[normalizeCode(c)] = true
+		}
+	}
+
+	return &ECBCurrencyRateProvider{
+		apiURL:              apiURL,
+		historyURL:          historyURL,
+		supportedCurrencies: supported,
+		cacheTTL:            ttl,
+		httpClient:          client,
+		rounding:            opts.Rounding,
+		rates:               make(map[string]float64),
+		history:             make(map[string]map[string]float64),
+	}
+}
+
+func (p *ECBCurrencyRateProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
+	rate, err := p.rate(currencyA.Code, currencyB.Code, p.getRates)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	return convertAmount(currencyA, currencyB.Code, rate, p.rounding), nil
+}
+
+// ConvertAt converts using the reference rates of the day of date. The zero
+// time, today and future dates use the current rates.
+func (p *ECBCurrencyRateProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	rate, err := p.rateAt(currencyA.Code, currencyB.Code, date)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	return convertAmount(currencyA, currencyB.Code, rate, p.rounding), nil
+}
+
+// RateAt returns the rate to convert one unit of from into to, using the
+// reference rates of the day of date.
+func (p *ECBCurrencyRateProvider) RateAt(from string, to string, date time.Time) (float64, error) {
+	return rateValue(p.rateAt(from, to, date))
+}
+
+func (p *ECBCurrencyRateProvider) Rounding() RoundingMode {
+	return p.rounding
+}
+
+func (p *ECBCurrencyRateProvider) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return p.rounding.Book(amount, to, rate)
+}
+
+func (p *ECBCurrencyRateProvider) rateAt(from string, to string, date time.Time) (*big.Rat, error) {
+	if !isPastDay(date) {
+		return p.rate(from, to, p.getRates)
+	}
+
+	return p.rate(from, to, func() (map[string]float64, error) {
+		return p.getRatesAt(date.UTC().Format(ecbDateLayout))
+	})
+}
+
+func (p *ECBCurrencyRateProvider) rate(from string, to string, loadRates func() (map[string]float64, error)) (*big.Rat, error) {
+	codeA := normalizeCode(from)
+	codeB := normalizeCode(to)
+
+	if p.supportedCurrencies != nil && !p.supportedCurrencies[codeA] {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unsupported source currency: %s", codeA), nil)
+	}
+	if p.supportedCurrencies != nil && !p.supportedCurrencies[codeB] {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unsupported target currency: %s", codeB), nil)
+	}
+
+	if codeA == codeB {
+		return big.NewRat(1, 1), nil
+	}
+
+	rates, err := loadRates()
+	if err != nil {
+		return nil, err
+	}
+
+	// the rates are quoted in units of each currency per one euro, the other
+	// way round than crossRate expects
+	return crossRate(rates, codeB, codeA)
+}
+
+func (p *ECBCurrencyRateProvider) getRates() (map[string]float64, error) {
+	p.mu.RLock()
+	if len(p.rates) > 0 && time.Since(p.lastFetched) <= p.cacheTTL {
+		defer p.mu.RUnlock()
+		return p.rates, nil
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Double check inside write lock
+	if len(p.rates) > 0 && time.Since(p.lastFetched) <= p.cacheTTL {
+		return p.rates, nil
+	}
+
+	days, err := p.fetchRatesFromECB(p.apiURL)
+	if err == nil && len(days) == 0 {
+		err = exceptions.NewInternalException("ECB returned no exchange rates", nil)
+	}
+	if err != nil {
+		// If fetch fails but we have stale rates in cache, fallback to stale rates
+		if len(p.rates) > 0 {
+			return p.rates, nil
+		}
+		return nil, err
+	}
+
+	p.rates = days[len(days)-1].rates
+	p.lastFetched = time.Now()
+	return p.rates, nil
+}
+
+// getRatesAt returns the rates of the last working day up to day.
+func (p *ECBCurrencyRateProvider) getRatesAt(day string) (map[string]float64, error) {
+	p.mu.RLock()
+	rates, ok := p.findHistoricalRates(day)
+	p.mu.RUnlock()
+
+	if ok {
+		return rates, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Double check inside write lock
+	if rates, ok = p.findHistoricalRates(day); ok {
+		return rates, nil
+	}
+
+	days, err := p.fetchRatesFromECB(p.historyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range days {
+		if _, known := p.history[d.day]; !known {
+			p.historyDays = append(p.historyDays, d.day)
+		}
+		p.history[d.day] = d.rates
+	}
+	sort.Strings(p.historyDays)
+	p.historyLastFetched = time.Now()
+
+	if rates, ok = p.findHistoricalRates(day); ok {
+		return rates, nil
+	}
+
+	return nil, exceptions.NewInternalException(fmt.Sprintf("no ECB exchange rates for %s", day), nil)
+}
+
+// findHistoricalRates must be called holding the lock. Days after the newest
+// known one are only trusted until the history gets stale, as the ECB might
+// have published their rates since.
+func (p *ECBCurrencyRateProvider) findHistoricalRates(day string) (map[string]float64, bool) {
+	i := sort.SearchStrings(p.historyDays, day)
+
+	if i < len(p.historyDays) && p.historyDays[i] == day {
+		return p.history[day], true
+	}
+
+	if i == 0 {
+		return nil, false
+	}
+
+	if i == len(p.historyDays) && time.Since(p.historyLastFetched) > p.cacheTTL {
+		return nil, false
+	}
+
+	return p.history[p.historyDays[i-1]], true
+}
+
+type ecbDayRates struct {
+	day   string
+	rates map[string]float64
+}
+
+// fetchRatesFromECB returns the published days sorted from the oldest, with the
+// rates as published, in units of each currency per one euro.
+func (p *ECBCurrencyRateProvider) fetchRatesFromECB(url string) ([]ecbDayRates, error) {
+	resp, err := p.httpClient.Get(url)
+	if err != nil {
+		return nil, exceptions.NewInternalException("failed to fetch ECB exchange rates", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, exceptions.NewInternalException(fmt.Sprintf("ECB API returned status %d: %s", resp.StatusCode, string(bodyBytes)), nil)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, exceptions.NewInternalException("failed to decode ECB exchange rates response", err)
+	}
+
+	days := make([]ecbDayRates, 0, len(envelope.Days))
+
+	for _, d := range envelope.Days {
+		rates := map[string]float64{"EUR": 1.0} // EUR is always 1.0
+
+		for _, r := range d.Rates {
+			code := normalizeCode(r.Currency)
+			if r.Rate > 0 && (p.supportedCurrencies == nil || p.supportedCurrencies[code]) {
+				rates[code] = r.Rate
+			}
+		}
+
+		days = append(days, ecbDayRates{day: d.Time, rates: rates})
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].day < days[j].day
+	})
+
+	return days, nil
+}
+
+// isPastDay reports whether date falls on a UTC day that is already over.
+func isPastDay(date time.Time) bool {
+	if date.IsZero() {
+		return false
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	return date.UTC().Before(today)
+}
@@ -0,0 +1,244 @@
+package currency
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const ecbDailyResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-07-24">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="PLN" rate="4.00"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbHistoryResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-03-06">
+			<Cube currency="USD" rate="1.10"/>
+		</Cube>
+		<Cube time="2024-03-01">
+			<Cube currency="USD" rate="1.00"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func newECBServer(t *testing.T, requests *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path)
+		w.Header().Set("Content-Type", "text/xml")
+
+		switch r.URL.Path {
+		case "/daily":
+			fmt.Fprintln(w, ecbDailyResponse)
+		case "/history":
+			fmt.Fprintln(w, ecbHistoryResponse)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestECBCurrencyRateProvider_Convert(t *testing.T) {
+	var requests []string
+	server := newECBServer(t, &requests)
+
+	provider := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{
+		APIURL:              server.URL + "/daily",
+		HistoryURL:          server.URL + "/history",
+		SupportedCurrencies: []string{"USD", "PLN"},
+		CacheTTL:            time.Minute,
+	})
+
+	tests := []struct {
+		name      string
+		from      Currency
+		to        Currency
+		expected  int64
+		expectErr bool
+	}{
+		{"Same currency", Currency{Amount: 100, Code: "USD"}, Currency{Code: "USD"}, 100, false},
+		{"EUR to USD (100 EUR @ 1.25)", Currency{Amount: 10000, Code: "EUR"}, Currency{Code: "USD"}, 12500, false},
+		{"USD to EUR (125 USD @ 1.25)", Currency{Amount: 12500, Code: "usd"}, Currency{Code: "EUR"}, 10000, false},
+		{"USD to PLN (cross rate 4.00/1.25)", Currency{Amount: 12500, Code: "USD"}, Currency{Code: "PLN"}, 40000, false},
+		{"Unsupported source currency", Currency{Amount: 100, Code: "GBP"}, Currency{Code: "EUR"}, 0, true},
+		{"Unsupported target currency", Currency{Amount: 100, Code: "EUR"}, Currency{Code: "UAH"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := provider.Convert(tt.from, tt.to)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Amount != tt.expected {
+				t.Errorf("expected amount %d, got %d", tt.expected, result.Amount)
+			}
+		})
+	}
+
+	if len(requests) != 1 {
+		t.Errorf("expected the daily rates to be cached, got requests %v", requests)
+	}
+}
+
+func TestECBCurrencyRateProvider_ConvertAt(t *testing.T) {
+	var requests []string
+	server := newECBServer(t, &requests)
+
+	provider := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{
+		APIURL:     server.URL + "/daily",
+		HistoryURL: server.URL + "/history",
+	})
+
+	from := Currency{Amount: 10000, Code: "EUR"}
+	to := Currency{Code: "USD"}
+
+	t.Run("Uses rates of the given day", func(t *testing.T) {
+		res, err := provider.ConvertAt(from, to, time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC))
+		if err != nil || res.Amount != 11000 {
+			t.Errorf("expected 11000, got %v, %v", res, err)
+		}
+	})
+
+	t.Run("Uses rates of the previous working day", func(t *testing.T) {
+		res, err := provider.ConvertAt(from, to, time.Date(2024, time.March, 3, 12, 0, 0, 0, time.UTC))
+		if err != nil || res.Amount != 10000 {
+			t.Errorf("expected 10000, got %v, %v", res, err)
+		}
+
+		if len(requests) != 1 {
+			t.Errorf("expected the history to be cached, got requests %v", requests)
+		}
+	})
+
+	t.Run("Uses current rates for zero, today and future dates", func(t *testing.T) {
+		for _, date := range []time.Time{{}, time.Now(), time.Now().AddDate(0, 0, 2)} {
+			rate, err := provider.RateAt("EUR", "USD", date)
+			if err != nil || rate != 1.25 {
+				t.Errorf("expected 1.25 for %v, got %v, %v", date, rate, err)
+			}
+		}
+	})
+
+	t.Run("Returns an error before the history starts", func(t *testing.T) {
+		if _, err := provider.ConvertAt(from, to, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("Refetches the history once stale", func(t *testing.T) {
+		p := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{
+			APIURL:     server.URL + "/daily",
+			HistoryURL: server.URL + "/history",
+			CacheTTL:   time.Millisecond,
+		})
+		requests = nil
+
+		for i := 0; i < 2; i++ {
+			if _, err := p.RateAt("EUR", "USD", time.Date(2024, time.March, 8, 0, 0, 0, 0, time.UTC)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		if len(requests) != 2 {
+			t.Errorf("expected the history to be fetched twice, got requests %v", requests)
+		}
+	})
+}
+
+func TestECBCurrencyRateProvider_ErrorHandling(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		provider := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{})
+		if provider.apiURL == "" || provider.historyURL == "" || provider.cacheTTL <= 0 {
+			t.Errorf("expected defaults, got %+v", provider)
+		}
+	})
+
+	t.Run("HTTP 500 Server Error", func(t *testing.T) {
+		provider := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{
+			APIURL:     "http://127.0.0.1:1/unreachable",
+			HistoryURL: "http://127.0.0.1:1/unreachable",
+		})
+
+		if _, err := provider.Convert(Currency{Amount: 100, Code: "EUR"}, Currency{Code: "USD"}); err == nil {
+			t.Error("expected error when ECB is unreachable")
+		}
+
+		if _, err := provider.RateAt("EUR", "USD", time.Date(2024, time.March, 6, 0, 0, 0, 0, time.UTC)); err == nil {
+			t.Error("expected error when ECB is unreachable")
+		}
+	})
+
+	t.Run("Invalid responses", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"invalid XML": "not xml",
+			"no rates":    `<Envelope><Cube></Cube></Envelope>`,
+		} {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/error" {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				fmt.Fprint(w, body)
+			}))
+
+			for _, url := range []string{server.URL, server.URL + "/error"} {
+				provider := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{APIURL: url})
+				if _, err := provider.Convert(Currency{Amount: 100, Code: "EUR"}, Currency{Code: "USD"}); err == nil {
+					t.Errorf("%s: expected error from %s", name, url)
+				}
+			}
+
+			server.Close()
+		}
+	})
+
+	t.Run("Fallback to stale rates when API fails", func(t *testing.T) {
+		status := http.StatusOK
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			fmt.Fprintln(w, ecbDailyResponse)
+		}))
+		defer server.Close()
+
+		provider := NewECBCurrencyRateProvider(ECBCurrencyRateProviderOptions{
+			APIURL:   server.URL,
+			CacheTTL: time.Millisecond,
+		})
+
+		if _, err := provider.Convert(Currency{Amount: 100, Code: "EUR"}, Currency{Code: "USD"}); err != nil {
+			t.Fatalf("unexpected error on initial fetch: %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		status = http.StatusInternalServerError
+
+		res, err := provider.Convert(Currency{Amount: 100, Code: "EUR"}, Currency{Code: "USD"})
+		if err != nil || res.Amount != 125 {
+			t.Errorf("expected fallback to stale rates, got %v, %v", res, err)
+		}
+	})
+}
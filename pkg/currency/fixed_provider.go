@@ -0,0 +1,85 @@
+package currency
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// FixedRate is the amount of Base paid for one unit of Currency.
+type FixedRate struct {
+	Currency string
+	Base     string
+	Rate     float64
+}
+
+// FixedRateProvider converts with rates agreed upfront, e.g. in a contract, and
+// falls back to another provider for the pairs none of them covers. Fixed rates
+// do not change over time, so they apply to any date. Amounts are rounded the
+// same way the next provider does.
+type FixedRateProvider struct {
+	next     CurrencyRateProvider
+	rates    []FixedRate
+	rounding RoundingMode
+}
+
+func NewFixedRateProvider(next CurrencyRateProvider, rates ...FixedRate) *FixedRateProvider {
+	return &FixedRateProvider{
+		next:     next,
+		rates:    rates,
+		rounding: roundingOf(next),
+	}
+}
+
+func (p *FixedRateProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
+	if converted, ok := p.convertFixed(currencyA, currencyB); ok {
+		return converted, nil
+	}
+
+	if p.next == nil {
+		return Currency{}, p.missingRate(currencyA, currencyB)
+	}
+
+	return p.next.Convert(currencyA, currencyB)
+}
+
+func (p *FixedRateProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	if converted, ok := p.convertFixed(currencyA, currencyB); ok {
+		return converted, nil
+	}
+
+	if p.next == nil {
+		return Currency{}, p.missingRate(currencyA, currencyB)
+	}
+
+	return p.next.ConvertAt(currencyA, currencyB, date)
+}
+
+func (p *FixedRateProvider) convertFixed(currencyA Currency, currencyB Currency) (Currency, bool) {
+	codeA := normalizeCode(currencyA.Code)
+	codeB := normalizeCode(currencyB.Code)
+
+	for _, r := range p.rates {
+		var rate *big.Rat
+
+		switch {
+		case r.Currency == codeA && r.Base == codeB:
+			rate = core.ExactDecimal(r.Rate)
+		case r.Base == codeA && r.Currency == codeB && r.Rate > 0:
+			rate = new(big.Rat).Inv(core.ExactDecimal(r.Rate))
+		default:
+			continue
+		}
+
+		return convertAmount(currencyA, codeB, rate, p.rounding), true
+	}
+
+	return Currency{}, false
+}
+
+func (p *FixedRateProvider) missingRate(currencyA Currency, currencyB Currency) error {
+	return exceptions.NewInternalException(fmt.Sprintf("no rate to convert %s to %s", currencyA.Code, currencyB.Code), nil)
+}
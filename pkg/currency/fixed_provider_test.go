@@ -0,0 +1,79 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubProvider struct {
+	err   error
+	dates []time.Time
+}
+
+func (s *stubProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
+	return s.ConvertAt(currencyA, currencyB, time.Time{})
+}
+
+func (s *stubProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	s.dates = append(s.dates, date)
+	if s.err != nil {
+		return Currency{}, s.err
+	}
+	return Currency{Amount: currencyA.Amount * 40, Code: currencyB.Code}, nil
+}
+
+func TestFixedRateProvider(t *testing.T) {
+	next := &stubProvider{}
+	provider := NewFixedRateProvider(next, FixedRate{Currency: "USD", Base: "UAH", Rate: 41.5})
+	paidAt := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from     Currency
+		to       Currency
+		expected Currency
+	}{
+		{"Fixed pair", Currency{Amount: 10000, Code: "USD"}, Currency{Code: "UAH"}, Currency{Amount: 415000, Code: "UAH"}},
+		{"Inverse of the fixed pair", Currency{Amount: 415000, Code: "uah"}, Currency{Code: "usd"}, Currency{Amount: 10000, Code: "USD"}},
+		{"Other pair falls back", Currency{Amount: 100, Code: "EUR"}, Currency{Code: "UAH"}, Currency{Amount: 4000, Code: "UAH"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, convert := range []func() (Currency, error){
+				func() (Currency, error) { return provider.Convert(tt.from, tt.to) },
+				func() (Currency, error) { return provider.ConvertAt(tt.from, tt.to, paidAt) },
+			} {
+				result, err := convert()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if result.Amount != tt.expected.Amount || result.Code != tt.expected.Code {
+					t.Errorf("expected %v, got %v", tt.expected, result)
+				}
+			}
+		})
+	}
+
+	if len(next.dates) != 2 || !next.dates[1].Equal(paidAt) {
+		t.Errorf("expected fallback conversions to keep the date, got %v", next.dates)
+	}
+
+	t.Run("Fallback errors are returned", func(t *testing.T) {
+		failing := NewFixedRateProvider(&stubProvider{err: errors.New("rates are down")})
+		if _, err := failing.Convert(Currency{Amount: 1, Code: "EUR"}, Currency{Code: "UAH"}); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("Missing fallback", func(t *testing.T) {
+		standalone := NewFixedRateProvider(nil, FixedRate{Currency: "USD", Base: "UAH", Rate: 41.5})
+		if _, err := standalone.Convert(Currency{Amount: 1, Code: "EUR"}, Currency{Code: "UAH"}); err == nil {
+			t.Error("expected error, got nil")
+		}
+		if _, err := standalone.ConvertAt(Currency{Amount: 1, Code: "EUR"}, Currency{Code: "UAH"}, paidAt); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -4,18 +4,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math"
+	"math/big"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Rhymond/go-money"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 )
 
 const (
 	defaultNBUAPIURL = "https://bank.gov.ua/NBUStatService/v1/statdirectory/exchange?json"
 	defaultTTL       = 30 * time.Minute
+	nbuDateLayout    = "02.01.2006"
+	NBURatesSource   = "nbu"
 )
 
 type nbuRateItem struct {
@@ -31,6 +34,7 @@ type NBUCurrencyRateProviderOptions struct {
 	SupportedCurrencies []string
 	CacheTTL           time.Duration
 	HTTPClient         *http.Client
+	Rounding           RoundingMode
 }
 
 type NBUCurrencyRateProvider struct {
@@ -38,10 +42,12 @@ type NBUCurrencyRateProvider struct {
 	supportedCurrencies map[string]bool
 	cacheTTL            time.Duration
 	httpClient          *http.Client
+	rounding            RoundingMode
 
 	mu          sync.RWMutex
 	rates       map[string]float64
 	lastFetched time.Time
+	history     *dayCache[map[string]float64]
 }
 
 func NewNBUCurrencyRateProvider(opts NBUCurrencyRateProviderOptions) *NBUCurrencyRateProvider {
@@ -75,52 +81,81 @@ func NewNBUCurrencyRateProvider(opts NBUCurrencyRateProviderOptions) *NBUCurrenc
 		supportedCurrencies: supported,
 		cacheTTL:            ttl,
 		httpClient:          client,
+		rounding:            opts.Rounding,
 		rates:               make(map[string]float64),
+		history:             newDayCache[map[string]float64](),
 	}
 }
 
 func (p *NBUCurrencyRateProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
-	codeA := strings.ToUpper(strings.TrimSpace(currencyA.Code))
-	codeB := strings.ToUpper(strings.TrimSpace(currencyB.Code))
+	return p.convert(currencyA, currencyB, p.getRates)
+}
 
-	if !p.supportedCurrencies[codeA] {
-		return Currency{}, exceptions.NewValidationException(fmt.Sprintf("unsupported source currency: %s", codeA), nil)
-	}
-	if !p.supportedCurrencies[codeB] {
-		return Currency{}, exceptions.NewValidationException(fmt.Sprintf("unsupported target currency: %s", codeB), nil)
+// ConvertAt converts using the official rates published for the day of date.
+// Rates of past days never change, so they are cached, up to a year of days.
+// The zero time, today and future dates use the current rates.
+func (p *NBUCurrencyRateProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	day, ok := historicalDay(date)
+	if !ok {
+		return p.Convert(currencyA, currencyB)
 	}
 
-	if codeA == codeB {
-		return Currency{Amount: currencyA.Amount, Code: codeB}, nil
+	return p.convert(currencyA, currencyB, func() (map[string]float64, error) {
+		return p.getRatesAt(day)
+	})
+}
+
+// RateAt returns the rate to convert one unit of from into to, using the
+// official rates published for the day of date.
+func (p *NBUCurrencyRateProvider) RateAt(from string, to string, date time.Time) (float64, error) {
+	day, ok := historicalDay(date)
+	if !ok {
+		return rateValue(p.rate(from, to, p.getRates))
 	}
 
-	rates, err := p.getRates()
+	return rateValue(p.rate(from, to, func() (map[string]float64, error) {
+		return p.getRatesAt(day)
+	}))
+}
+
+func (p *NBUCurrencyRateProvider) Rounding() RoundingMode {
+	return p.rounding
+}
+
+func (p *NBUCurrencyRateProvider) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return p.rounding.Book(amount, to, rate)
+}
+
+func (p *NBUCurrencyRateProvider) convert(currencyA Currency, currencyB Currency, loadRates func() (map[string]float64, error)) (Currency, error) {
+	rate, err := p.rate(currencyA.Code, currencyB.Code, loadRates)
 	if err != nil {
 		return Currency{}, err
 	}
 
-	rateA, okA := rates[codeA]
-	rateB, okB := rates[codeB]
+	return convertAmount(currencyA, currencyB.Code, rate, p.rounding), nil
+}
+
+func (p *NBUCurrencyRateProvider) rate(from string, to string, loadRates func() (map[string]float64, error)) (*big.Rat, error) {
+	codeA := strings.ToUpper(strings.TrimSpace(from))
+	codeB := strings.ToUpper(strings.TrimSpace(to))
 
-	if !okA {
-		return Currency{}, exceptions.NewInternalException(fmt.Sprintf("rate for currency %s not found", codeA), nil)
+	if !p.supportedCurrencies[codeA] {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unsupported source currency: %s", codeA), nil)
 	}
-	if !okB {
-		return Currency{}, exceptions.NewInternalException(fmt.Sprintf("rate for currency %s not found", codeB), nil)
+	if !p.supportedCurrencies[codeB] {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("unsupported target currency: %s", codeB), nil)
 	}
 
-	if rateB <= 0 {
-		return Currency{}, exceptions.NewInternalException(fmt.Sprintf("invalid rate for currency %s", codeB), nil)
+	if codeA == codeB {
+		return big.NewRat(1, 1), nil
 	}
 
-	// Formula: amount * (rateA / rateB)
-	converted := float64(currencyA.Amount) * (rateA / rateB)
-	resultAmount := int64(math.Round(converted))
+	rates, err := loadRates()
+	if err != nil {
+		return nil, err
+	}
 
-	return Currency{
-		Amount: resultAmount,
-		Code:   codeB,
-	}, nil
+	return crossRate(rates, codeA, codeB)
 }
 
 func (p *NBUCurrencyRateProvider) getRates() (map[string]float64, error) {
@@ -139,7 +174,7 @@ func (p *NBUCurrencyRateProvider) getRates() (map[string]float64, error) {
 		return p.rates, nil
 	}
 
-	rates, err := p.fetchRatesFromNBU()
+	rates, err := p.fetchRatesFromNBU(p.apiURL)
 	if err != nil {
 		// If fetch fails but we have stale rates in cache, fallback to stale rates
 		if len(p.rates) > 0 {
@@ -180,8 +215,87 @@ func (p *NBUCurrencyRateProvider) isCacheValid() bool {
 	return true
 }
 
-func (p *NBUCurrencyRateProvider) fetchRatesFromNBU() (map[string]float64, error) {
-	resp, err := p.httpClient.Get(p.apiURL)
+func (p *NBUCurrencyRateProvider) getRatesAt(day string) (map[string]float64, error) {
+	return p.history.get(day, func() (map[string]float64, error) {
+		return p.fetchRatesFromNBU(p.historicalURL(day))
+	})
+}
+
+func (p *NBUCurrencyRateProvider) historicalURL(day string) string {
+	separator := "?"
+	if strings.Contains(p.apiURL, "?") {
+		separator = "&"
+	}
+
+	return p.apiURL + separator + "date=" + day
+}
+
+// historicalDay returns the NBU date parameter for the Kyiv day of date, as long
+// as that day is already over.
+func historicalDay(date time.Time) (string, bool) {
+	if date.IsZero() {
+		return "", false
+	}
+
+	loc := kyivLocation()
+	day := date.In(loc)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if !day.Before(today) {
+		return "", false
+	}
+
+	return day.Format("20060102"), true
+}
+
+func kyivLocation() *time.Location {
+	loc, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// kyivDay returns the Kyiv calendar day of date as a UTC midnight.
+func kyivDay(date time.Time) time.Time {
+	y, m, d := date.In(kyivLocation()).Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// FetchRates downloads the official rates published for the Kyiv day of date,
+// or the current ones for the zero time, today and future dates. Unlike the
+// conversions it returns every published currency.
+func (p *NBUCurrencyRateProvider) FetchRates(date time.Time) (*DailyRates, error) {
+	url := p.apiURL
+	if day, ok := historicalDay(date); ok {
+		url = p.historicalURL(day)
+	}
+
+	return p.fetchDailyRates(url)
+}
+
+func (p *NBUCurrencyRateProvider) fetchRatesFromNBU(url string) (map[string]float64, error) {
+	daily, err := p.fetchDailyRates(url)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]float64)
+
+	for code, rate := range daily.Rates {
+		if p.supportedCurrencies[code] {
+			rates[code] = rate
+		}
+	}
+
+	return rates, nil
+}
+
+func (p *NBUCurrencyRateProvider) fetchDailyRates(url string) (*DailyRates, error) {
+	resp, err := p.httpClient.Get(url)
 	if err != nil {
 		return nil, exceptions.NewInternalException("failed to fetch NBU exchange rates", err)
 	}
@@ -197,15 +311,22 @@ func (p *NBUCurrencyRateProvider) fetchRatesFromNBU() (map[string]float64, error
 		return nil, exceptions.NewInternalException("failed to decode NBU exchange rates response", err)
 	}
 
-	rates := make(map[string]float64)
-	rates["UAH"] = 1.0 // UAH is always 1.0
+	daily := &DailyRates{
+		Source: NBURatesSource,
+		Base:   "UAH",
+		Day:    kyivDay(time.Now()),
+		Rates:  map[string]float64{"UAH": 1.0}, // UAH is always 1.0
+	}
 
 	for _, item := range items {
-		code := strings.ToUpper(strings.TrimSpace(item.CC))
-		if p.supportedCurrencies[code] {
-			rates[code] = item.Rate
+		if day, err := time.Parse(nbuDateLayout, item.ExchangeDate); err == nil {
+			daily.Day = day
+		}
+
+		if code := normalizeCode(item.CC); code != "" && item.Rate > 0 {
+			daily.Rates[code] = item.Rate
 		}
 	}
 
-	return rates, nil
+	return daily, nil
 }
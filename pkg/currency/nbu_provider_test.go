@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -232,3 +234,165 @@ func TestNBUCurrencyRateProvider_ErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestNBUCurrencyRateProvider_ConvertAt(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Query().Get("date") {
+		case "":
+			fmt.Fprintln(w, `[{"r030":840,"txt":"Долар США","rate":40.00,"cc":"USD","exchangedate":"26.07.2026"}]`)
+		case "20240305":
+			fmt.Fprintln(w, `[{"r030":840,"txt":"Долар США","rate":38.00,"cc":"USD","exchangedate":"05.03.2024"}]`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewNBUCurrencyRateProvider(NBUCurrencyRateProviderOptions{
+		APIURL:              server.URL + "?json",
+		SupportedCurrencies: []string{"UAH", "USD"},
+	})
+
+	from := Currency{Amount: 10000, Code: "USD"}
+	to := Currency{Code: "UAH"}
+
+	t.Run("Uses rates of the given day", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			res, err := provider.ConvertAt(from, to, time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Amount != 380000 {
+				t.Errorf("expected 380000, got %d", res.Amount)
+			}
+		}
+
+		if len(requests) != 1 || requests[0] != "json&date=20240305" {
+			t.Errorf("expected a single dated request, got %v", requests)
+		}
+	})
+
+	t.Run("Uses current rates for zero, today and future dates", func(t *testing.T) {
+		for _, date := range []time.Time{{}, time.Now(), time.Now().AddDate(0, 0, 2)} {
+			res, err := provider.ConvertAt(from, to, date)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Amount != 400000 {
+				t.Errorf("expected 400000 for %v, got %d", date, res.Amount)
+			}
+		}
+	})
+
+	t.Run("Returns the rate of the given day", func(t *testing.T) {
+		rate, err := provider.RateAt("usd", "UAH", time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC))
+		if err != nil || rate != 38 {
+			t.Errorf("expected 38, got %v, %v", rate, err)
+		}
+
+		if rate, err = provider.RateAt("USD", "UAH", time.Time{}); err != nil || rate != 40 {
+			t.Errorf("expected 40, got %v, %v", rate, err)
+		}
+
+		if rate, err = provider.RateAt("UAH", "UAH", time.Time{}); err != nil || rate != 1 {
+			t.Errorf("expected 1, got %v, %v", rate, err)
+		}
+
+		if _, err = provider.RateAt("GBP", "UAH", time.Time{}); err == nil {
+			t.Error("expected unsupported currency error, got nil")
+		}
+	})
+
+	t.Run("Returns an error when historical rates are unavailable", func(t *testing.T) {
+		if _, err := provider.ConvertAt(from, to, time.Date(2023, time.January, 2, 12, 0, 0, 0, time.UTC)); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("Shares one request between concurrent lookups of a day", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			<-release
+			fmt.Fprintln(w, `[{"r030":840,"txt":"Долар США","rate":38.00,"cc":"USD","exchangedate":"05.03.2024"}]`)
+		}))
+		defer slow.Close()
+
+		p := NewNBUCurrencyRateProvider(NBUCurrencyRateProviderOptions{APIURL: slow.URL, SupportedCurrencies: []string{"UAH", "USD"}})
+		p.history.remember("20240304", map[string]float64{"UAH": 1, "USD": 37})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if rate, err := p.RateAt("USD", "UAH", time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)); err != nil || rate != 38 {
+					t.Errorf("expected 38, got %v, %v", rate, err)
+				}
+			}()
+		}
+
+		// a cached day is served while the request is still on its way
+		if rate, err := p.RateAt("USD", "UAH", time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)); err != nil || rate != 37 {
+			t.Errorf("expected 37 while fetching another day, got %v, %v", rate, err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("expected a single request, got %d", calls.Load())
+		}
+	})
+
+	t.Run("Appends the date as the first query parameter", func(t *testing.T) {
+		p := NewNBUCurrencyRateProvider(NBUCurrencyRateProviderOptions{APIURL: "http://example.com/exchange"})
+		if got := p.historicalURL("20240305"); got != "http://example.com/exchange?date=20240305" {
+			t.Errorf("unexpected historical URL %s", got)
+		}
+	})
+}
+
+func TestNBUCurrencyRateProvider_FetchRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Query().Get("date") == "20240305" {
+			fmt.Fprintln(w, `[{"r030":840,"txt":"Долар США","rate":38.00,"cc":"USD","exchangedate":"05.03.2024"},{"r030":826,"txt":"Фунт","rate":48.00,"cc":"GBP","exchangedate":"05.03.2024"}]`)
+			return
+		}
+
+		fmt.Fprintln(w, `[]`)
+	}))
+	defer server.Close()
+
+	provider := NewNBUCurrencyRateProvider(NBUCurrencyRateProviderOptions{
+		APIURL:              server.URL + "?json",
+		SupportedCurrencies: []string{"UAH", "USD"},
+	})
+
+	rates, err := provider.FetchRates(time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rates.Source != NBURatesSource || rates.Base != "UAH" || !rates.Day.Equal(time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected rates header %+v", rates)
+	}
+
+	if rates.Rates["USD"] != 38 || rates.Rates["GBP"] != 48 || rates.Rates["UAH"] != 1 {
+		t.Errorf("expected every published currency, got %v", rates.Rates)
+	}
+
+	if rates, err = provider.FetchRates(time.Time{}); err != nil || !rates.Day.Equal(kyivDay(time.Now())) {
+		t.Errorf("expected today's rates without an exchange date, got %+v, %v", rates, err)
+	}
+}
@@ -0,0 +1,73 @@
+package currency
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// RateProvider is a CurrencyRateProvider that also quotes the rates it converts
+// with, so it can be used to book payments.
+type RateProvider interface {
+	CurrencyRateProvider
+	RateAt(from string, to string, date time.Time) (float64, error)
+	// Rounding returns how the provider rounds converted amounts.
+	Rounding() RoundingMode
+	// Book converts amount into to at the quoted rate, rounded the way the
+	// provider rounds.
+	Book(amount *money.Money, to string, rate float64) (*money.Money, error)
+}
+
+// roundingOf returns the rounding mode of provider, RoundHalfUp when it does
+// not tell.
+func roundingOf(provider CurrencyRateProvider) RoundingMode {
+	if p, ok := provider.(interface{ Rounding() RoundingMode }); ok {
+		return p.Rounding()
+	}
+
+	return RoundHalfUp
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// crossRate returns the exact rate to convert one unit of from into to, given
+// rates that quote every currency against the same base.
+func crossRate(rates map[string]float64, from string, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	rateA, okA := rates[from]
+	rateB, okB := rates[to]
+
+	if !okA {
+		return nil, exceptions.NewInternalException(fmt.Sprintf("rate for currency %s not found", from), nil)
+	}
+	if !okB {
+		return nil, exceptions.NewInternalException(fmt.Sprintf("rate for currency %s not found", to), nil)
+	}
+
+	if rateB <= 0 {
+		return nil, exceptions.NewInternalException(fmt.Sprintf("invalid rate for currency %s", to), nil)
+	}
+
+	return new(big.Rat).Quo(core.ExactDecimal(rateA), core.ExactDecimal(rateB)), nil
+}
+
+// rateValue returns the rate as quoted by RateAt.
+func rateValue(rate *big.Rat, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+
+	value, _ := rate.Float64()
+
+	return value, nil
+}
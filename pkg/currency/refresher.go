@@ -0,0 +1,81 @@
+package currency
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/core"
+)
+
+const (
+	defaultRefreshRetry = 5 * time.Minute
+	// the NBU publishes the rates at 15:30 Kyiv time, give it a few minutes
+	publicationHour   = 15
+	publicationMinute = 35
+)
+
+// RateRefresher keeps a RateStore up to date in the background, so user
+// requests never wait for the rates to be downloaded.
+type RateRefresher struct {
+	fetcher RatesFetcher
+	store   RateStore
+	logger  core.Logger
+	retry   time.Duration
+}
+
+func NewRateRefresher(fetcher RatesFetcher, store RateStore, logger core.Logger, retry time.Duration) *RateRefresher {
+	if retry <= 0 {
+		retry = defaultRefreshRetry
+	}
+
+	return &RateRefresher{
+		fetcher: fetcher,
+		store:   store,
+		logger:  logger,
+		retry:   retry,
+	}
+}
+
+// Refresh downloads and stores the current rates.
+func (r *RateRefresher) Refresh(ctx context.Context) error {
+	rates, err := r.fetcher.FetchRates(time.Time{})
+	if err != nil {
+		return err
+	}
+
+	return r.store.Save(ctx, rates)
+}
+
+// Run refreshes the rates right away and then after every publication, retrying
+// failed attempts, until ctx is done.
+func (r *RateRefresher) Run(ctx context.Context) {
+	for {
+		wait := time.Until(nextPublication(time.Now()))
+
+		if err := r.Refresh(ctx); err != nil {
+			r.logger.Error("failed to refresh exchange rates", err, map[string]any{"retry_in": r.retry})
+			wait = min(wait, r.retry)
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// nextPublication returns the next time the NBU rates are expected after now.
+func nextPublication(now time.Time) time.Time {
+	local := now.In(kyivLocation())
+	next := time.Date(local.Year(), local.Month(), local.Day(), publicationHour, publicationMinute, 0, 0, local.Location())
+
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
@@ -0,0 +1,73 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	errors []error
+}
+
+func (l *recordingLogger) Info(string, map[string]any) {}
+
+func (l *recordingLogger) Error(_ string, err error, _ map[string]any) {
+	l.errors = append(l.errors, err)
+}
+
+func TestRateRefresher(t *testing.T) {
+	t.Run("Refresh stores the current rates", func(t *testing.T) {
+		store := &memoryRateStore{}
+		fetcher := &stubFetcher{rates: nbuDay(kyivDay(time.Now()), 40)}
+
+		if err := NewRateRefresher(fetcher, store, &recordingLogger{}, 0).Refresh(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(store.days) != 1 || len(fetcher.dates) != 1 || !fetcher.dates[0].IsZero() {
+			t.Errorf("expected the current rates to be stored, got %v, %v", store.days, fetcher.dates)
+		}
+	})
+
+	t.Run("Run retries failed refreshes until stopped", func(t *testing.T) {
+		logger := &recordingLogger{}
+		fetcher := &stubFetcher{err: errors.New("bank.gov.ua is down")}
+		refresher := NewRateRefresher(fetcher, &memoryRateStore{}, logger, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+
+		go func() {
+			refresher.Run(ctx)
+			close(done)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		<-done
+
+		if len(logger.errors) < 2 {
+			t.Errorf("expected the refresh to be retried, got %d attempts", len(logger.errors))
+		}
+	})
+
+	t.Run("Next publication is at 15:35 Kyiv time", func(t *testing.T) {
+		kyiv := kyivLocation()
+		tests := []struct {
+			now      time.Time
+			expected time.Time
+		}{
+			{time.Date(2026, time.March, 5, 10, 0, 0, 0, kyiv), time.Date(2026, time.March, 5, 15, 35, 0, 0, kyiv)},
+			{time.Date(2026, time.March, 5, 15, 35, 0, 0, kyiv), time.Date(2026, time.March, 6, 15, 35, 0, 0, kyiv)},
+			{time.Date(2026, time.March, 5, 22, 0, 0, 0, time.UTC), time.Date(2026, time.March, 6, 15, 35, 0, 0, kyiv)},
+		}
+
+		for _, tt := range tests {
+			if got := nextPublication(tt.now); !got.Equal(tt.expected) {
+				t.Errorf("next publication after %v: expected %v, got %v", tt.now, tt.expected, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package currency
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gopkg.in/yaml.v3"
+)
+
+// staticRatesFile is the layout of a rates file. Rates are the amount of base
+// paid for one unit of each currency; history holds the rates of past days keyed
+// by their date. JSON files work as well, as YAML is a superset of JSON.
+//
+//	base: UAH
+//	rates:
+//	  USD: 41.5
+//	  EUR: 45.2
+//	history:
+//	  "2026-01-15":
+//	    USD: 42.1
+type staticRatesFile struct {
+	Base    string                        `yaml:"base"`
+	Rates   map[string]float64            `yaml:"rates"`
+	History map[string]map[string]float64 `yaml:"history"`
+}
+
+// StaticRateProvider converts with rates read from a file, for deployments that
+// cannot reach the bank APIs. A date within the history converts with the rates
+// of the closest day before it, any other date with the current rates.
+type StaticRateProvider struct {
+	rounding RoundingMode
+	rates    map[string]float64
+	history  map[string]map[string]float64
+	days     []string
+}
+
+func NewStaticRateProvider(path string, rounding RoundingMode) (*StaticRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, exceptions.NewInternalException("failed to read exchange rates file", err)
+	}
+
+	return ParseStaticRates(data, rounding)
+}
+
+// ParseStaticRates builds a StaticRateProvider from the contents of a rates file.
+func ParseStaticRates(data []byte, rounding RoundingMode) (*StaticRateProvider, error) {
+	var file staticRatesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, exceptions.NewValidationException("failed to parse exchange rates file", err)
+	}
+
+	base := normalizeCode(file.Base)
+	if base == "" {
+		return nil, exceptions.NewValidationException("exchange rates file must define the base currency", nil)
+	}
+
+	rates, err := staticRates(base, file.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &StaticRateProvider{
+		rounding: rounding,
+		rates:    rates,
+		history:  make(map[string]map[string]float64, len(file.History)),
+	}
+
+	for day, dayRates := range file.History {
+		if _, err = time.Parse(ecbDateLayout, day); err != nil {
+			return nil, exceptions.NewValidationException(fmt.Sprintf("invalid exchange rates date: %s", day), err)
+		}
+
+		if p.history[day], err = staticRates(base, dayRates); err != nil {
+			return nil, err
+		}
+
+		p.days = append(p.days, day)
+	}
+
+	sort.Strings(p.days)
+
+	return p, nil
+}
+
+func staticRates(base string, values map[string]float64) (map[string]float64, error) {
+	rates := map[string]float64{base: 1.0}
+
+	for code, rate := range values {
+		if rate <= 0 {
+			return nil, exceptions.NewValidationException(fmt.Sprintf("invalid rate for currency %s", code), nil)
+		}
+
+		rates[normalizeCode(code)] = rate
+	}
+
+	return rates, nil
+}
+
+func (p *StaticRateProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
+	return p.ConvertAt(currencyA, currencyB, time.Time{})
+}
+
+func (p *StaticRateProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	rate, err := p.rateAt(currencyA.Code, currencyB.Code, date)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	return convertAmount(currencyA, currencyB.Code, rate, p.rounding), nil
+}
+
+// RateAt returns the rate to convert one unit of from into to on the given date.
+// Days missing a currency fall back to its current rate.
+func (p *StaticRateProvider) RateAt(from string, to string, date time.Time) (float64, error) {
+	return rateValue(p.rateAt(from, to, date))
+}
+
+func (p *StaticRateProvider) Rounding() RoundingMode {
+	return p.rounding
+}
+
+func (p *StaticRateProvider) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return p.rounding.Book(amount, to, rate)
+}
+
+func (p *StaticRateProvider) rateAt(from string, to string, date time.Time) (*big.Rat, error) {
+	codeA := normalizeCode(from)
+	codeB := normalizeCode(to)
+
+	if rates, ok := p.ratesAt(date); ok {
+		if rate, err := crossRate(rates, codeA, codeB); err == nil {
+			return rate, nil
+		}
+	}
+
+	return crossRate(p.rates, codeA, codeB)
+}
+
+func (p *StaticRateProvider) ratesAt(date time.Time) (map[string]float64, bool) {
+	if date.IsZero() {
+		return nil, false
+	}
+
+	i := sort.SearchStrings(p.days, date.Format(ecbDateLayout))
+
+	if i < len(p.days) && p.days[i] == date.Format(ecbDateLayout) {
+		return p.history[p.days[i]], true
+	}
+
+	if i == 0 || i == len(p.days) {
+		return nil, false
+	}
+
+	return p.history[p.days[i-1]], true
+}
@@ -0,0 +1,104 @@
+package currency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const staticRatesYAML = `
+base: uah
+rates:
+  USD: 40
+  EUR: 44
+history:
+  "2024-03-01":
+    USD: 38
+  "2024-03-05":
+    USD: 39
+`
+
+func TestStaticRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.yaml")
+	if err := os.WriteFile(path, []byte(staticRatesYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewStaticRateProvider(path, RoundHalfUp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("Converts with the current rates", func(t *testing.T) {
+		res, err := provider.Convert(Currency{Amount: 11000, Code: "USD"}, Currency{Code: "eur"})
+		if err != nil || res != (Currency{Amount: 10000, Code: "EUR", Scale: 2}) {
+			t.Errorf("expected 10000 EUR, got %v, %v", res, err)
+		}
+
+		if _, err = provider.Convert(Currency{Amount: 100, Code: "GBP"}, Currency{Code: "UAH"}); err == nil {
+			t.Error("expected error for a currency missing from the file")
+		}
+	})
+
+	t.Run("Converts with the rates of the closest past day", func(t *testing.T) {
+		tests := []struct {
+			date     time.Time
+			from     string
+			expected float64
+		}{
+			{time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), "USD", 38},
+			{time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC), "USD", 38},
+			{time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC), "USD", 39},
+			{time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC), "EUR", 44},
+			{time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), "USD", 40},
+			{time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), "USD", 40},
+			{time.Time{}, "USD", 40},
+		}
+
+		for _, tt := range tests {
+			rate, err := provider.RateAt(tt.from, "UAH", tt.date)
+			if err != nil || rate != tt.expected {
+				t.Errorf("%s on %v: expected %v, got %v, %v", tt.from, tt.date, tt.expected, rate, err)
+			}
+		}
+
+		res, err := provider.ConvertAt(Currency{Amount: 100, Code: "USD"}, Currency{Code: "UAH"}, time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC))
+		if err != nil || res.Amount != 3800 {
+			t.Errorf("expected 3800, got %v, %v", res, err)
+		}
+
+		if _, err = provider.ConvertAt(Currency{Amount: 100, Code: "GBP"}, Currency{Code: "UAH"}, time.Time{}); err == nil {
+			t.Error("expected error for a currency missing from the file")
+		}
+	})
+
+	t.Run("Reads JSON files", func(t *testing.T) {
+		p, err := ParseStaticRates([]byte(`{"base": "EUR", "rates": {"USD": 0.8}}`), RoundHalfEven)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if rate, err := p.RateAt("EUR", "USD", time.Time{}); err != nil || rate != 1.25 {
+			t.Errorf("expected 1.25, got %v, %v", rate, err)
+		}
+	})
+
+	t.Run("Rejects invalid files", func(t *testing.T) {
+		if _, err := NewStaticRateProvider(filepath.Join(t.TempDir(), "missing.yaml"), RoundHalfUp); err == nil {
+			t.Error("expected error for a missing file")
+		}
+
+		for name, data := range map[string]string{
+			"invalid YAML":         "base: [UAH",
+			"missing base":         "rates:\n  USD: 40",
+			"non positive rate":    "base: UAH\nrates:\n  USD: 0",
+			"invalid history date": "base: UAH\nhistory:\n  yesterday:\n    USD: 40",
+			"invalid history rate": "base: UAH\nhistory:\n  \"2024-03-01\":\n    USD: -1",
+		} {
+			if _, err := ParseStaticRates([]byte(data), RoundHalfUp); err == nil {
+				t.Errorf("%s: expected error, got nil", name)
+			}
+		}
+	})
+}
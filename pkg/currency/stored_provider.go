@@ -0,0 +1,215 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"golang.org/x/sync/singleflight"
+)
+
+// DailyRates are the rates a source published for a day, expressed as the
+// amount of Base paid for one unit of each currency.
+type DailyRates struct {
+	Source string
+	Base   string
+	Day    time.Time
+	Rates  map[string]float64
+}
+
+// RatesFetcher downloads the rates of the day of date, or the current rates for
+// the zero time.
+type RatesFetcher interface {
+	FetchRates(date time.Time) (*DailyRates, error)
+}
+
+// RateStore keeps the published rates, shared by every replica of the service.
+type RateStore interface {
+	// FindLatest returns the rates of the newest day on or before day, or a
+	// not found error.
+	FindLatest(ctx context.Context, source string, day time.Time) (*DailyRates, error)
+	Save(ctx context.Context, rates *DailyRates) error
+}
+
+type StoredRateProviderOptions struct {
+	Source   string
+	Store    RateStore
+	Fetcher  RatesFetcher
+	CacheTTL time.Duration
+	Timeout  time.Duration
+	Rounding RoundingMode
+}
+
+// StoredRateProvider serves the rates kept in a RateStore by the RateRefresher.
+// Rates missing from the store, e.g. on a cold start or for a day nobody asked
+// for yet, are downloaded by the fetcher and stored for everyone else.
+type StoredRateProvider struct {
+	source   string
+	store    RateStore
+	fetcher  RatesFetcher
+	cacheTTL time.Duration
+	timeout  time.Duration
+	rounding RoundingMode
+
+	mu          sync.RWMutex
+	current     *DailyRates
+	lastFetched time.Time
+	history     *dayCache[*DailyRates]
+	refreshes   singleflight.Group
+}
+
+func NewStoredRateProvider(opts StoredRateProviderOptions) *StoredRateProvider {
+	ttl := opts.CacheTTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &StoredRateProvider{
+		source:   opts.Source,
+		store:    opts.Store,
+		fetcher:  opts.Fetcher,
+		cacheTTL: ttl,
+		timeout:  timeout,
+		rounding: opts.Rounding,
+		history:  newDayCache[*DailyRates](),
+	}
+}
+
+func (p *StoredRateProvider) Convert(currencyA Currency, currencyB Currency) (Currency, error) {
+	return p.ConvertAt(currencyA, currencyB, time.Time{})
+}
+
+func (p *StoredRateProvider) ConvertAt(currencyA Currency, currencyB Currency, date time.Time) (Currency, error) {
+	rate, err := p.rateAt(currencyA.Code, currencyB.Code, date)
+	if err != nil {
+		return Currency{}, err
+	}
+
+	return convertAmount(currencyA, currencyB.Code, rate, p.rounding), nil
+}
+
+// RateAt returns the rate to convert one unit of from into to, using the rates
+// of the Kyiv day of date. The zero time, today and future dates use the newest
+// stored rates.
+func (p *StoredRateProvider) RateAt(from string, to string, date time.Time) (float64, error) {
+	return rateValue(p.rateAt(from, to, date))
+}
+
+func (p *StoredRateProvider) Rounding() RoundingMode {
+	return p.rounding
+}
+
+func (p *StoredRateProvider) Book(amount *money.Money, to string, rate float64) (*money.Money, error) {
+	return p.rounding.Book(amount, to, rate)
+}
+
+func (p *StoredRateProvider) rateAt(from string, to string, date time.Time) (*big.Rat, error) {
+	codeA := normalizeCode(from)
+	codeB := normalizeCode(to)
+
+	if codeA == codeB {
+		return big.NewRat(1, 1), nil
+	}
+
+	var (
+		daily *DailyRates
+		err   error
+	)
+
+	if _, ok := historicalDay(date); ok {
+		daily, err = p.ratesAt(kyivDay(date))
+	} else {
+		daily, err = p.currentRates()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return crossRate(daily.Rates, codeA, codeB)
+}
+
+func (p *StoredRateProvider) currentRates() (*DailyRates, error) {
+	p.mu.RLock()
+	current, lastFetched := p.current, p.lastFetched
+	p.mu.RUnlock()
+
+	if current != nil && time.Since(lastFetched) <= p.cacheTTL {
+		return current, nil
+	}
+
+	// the lookups share one load, made without holding the lock so the past
+	// days are not held up by it
+	daily, err, _ := p.refreshes.Do("current", func() (any, error) {
+		daily, err := p.load(kyivDay(time.Now()), time.Time{})
+		if err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		p.current = daily
+		p.lastFetched = time.Now()
+		p.mu.Unlock()
+
+		return daily, nil
+	})
+
+	if err != nil {
+		// If the store is unavailable but we have stale rates in cache, fallback to stale rates
+		if current != nil {
+			return current, nil
+		}
+		return nil, err
+	}
+
+	return daily.(*DailyRates), nil
+}
+
+// ratesAt returns the rates of a past day. They never change, so they are
+// cached for the lifetime of the provider.
+func (p *StoredRateProvider) ratesAt(day time.Time) (*DailyRates, error) {
+	return p.history.get(day.Format("20060102"), func() (*DailyRates, error) {
+		return p.load(day, day)
+	})
+}
+
+// load reads the rates of day from the store. A past day must be stored as is,
+// while for today the newest stored rates will do. Anything else is fetched and
+// stored.
+func (p *StoredRateProvider) load(day time.Time, fetchDate time.Time) (*DailyRates, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	daily, err := p.store.FindLatest(ctx, p.source, day)
+
+	if err == nil && (fetchDate.IsZero() || daily.Day.Equal(day)) {
+		return daily, nil
+	}
+
+	if err != nil && !errors.Is(err, exceptions.NotFoundError) {
+		return nil, exceptions.NewInternalException("failed to read stored exchange rates", err)
+	}
+
+	if p.fetcher == nil {
+		return nil, exceptions.NewNotFoundException("no stored exchange rates", err)
+	}
+
+	fetched, err := p.fetcher.FetchRates(fetchDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// storing is best effort, the next request will fetch again
+	_ = p.store.Save(ctx, fetched)
+
+	return fetched, nil
+}
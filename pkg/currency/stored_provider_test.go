@@ -0,0 +1,260 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+type memoryRateStore struct {
+	days    []*DailyRates
+	findErr error
+	saveErr error
+	finds   int
+}
+
+func (s *memoryRateStore) FindLatest(_ context.Context, source string, day time.Time) (*DailyRates, error) {
+	s.finds++
+	if s.findErr != nil {
+		return nil, s.findErr
+	}
+
+	var latest *DailyRates
+	for _, d := range s.days {
+		if d.Source == source && !d.Day.After(day) && (latest == nil || d.Day.After(latest.Day)) {
+			latest = d
+		}
+	}
+
+	if latest == nil {
+		return nil, exceptions.NewNotFoundException("exchange rates not found", nil)
+	}
+
+	return latest, nil
+}
+
+func (s *memoryRateStore) Save(_ context.Context, rates *DailyRates) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.days = append(s.days, rates)
+	return nil
+}
+
+type stubFetcher struct {
+	rates *DailyRates
+	err   error
+	dates []time.Time
+}
+
+func (f *stubFetcher) FetchRates(date time.Time) (*DailyRates, error) {
+	f.dates = append(f.dates, date)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.rates, nil
+}
+
+// blockingFetcher holds every fetch until released.
+type blockingFetcher struct {
+	rates   *DailyRates
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (f *blockingFetcher) FetchRates(time.Time) (*DailyRates, error) {
+	f.calls.Add(1)
+	<-f.release
+	return f.rates, nil
+}
+
+func nbuDay(day time.Time, usd float64) *DailyRates {
+	return &DailyRates{
+		Source: NBURatesSource,
+		Base:   "UAH",
+		Day:    day,
+		Rates:  map[string]float64{"UAH": 1, "USD": usd, "EUR": 44},
+	}
+}
+
+func TestStoredRateProvider(t *testing.T) {
+	today := kyivDay(time.Now())
+	paidOn := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Serves the newest stored rates", func(t *testing.T) {
+		store := &memoryRateStore{days: []*DailyRates{nbuDay(today.AddDate(0, 0, -1), 40), nbuDay(paidOn, 38)}}
+		fetcher := &stubFetcher{}
+		provider := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: store, Fetcher: fetcher})
+
+		res, err := provider.Convert(Currency{Amount: 100, Code: "USD"}, Currency{Code: "uah"})
+		if err != nil || res != (Currency{Amount: 4000, Code: "UAH", Scale: 2}) {
+			t.Errorf("expected 4000 UAH, got %v, %v", res, err)
+		}
+
+		if rate, err := provider.RateAt("EUR", "EUR", time.Time{}); err != nil || rate != 1 {
+			t.Errorf("expected 1, got %v, %v", rate, err)
+		}
+
+		if _, err = provider.Convert(Currency{Amount: 100, Code: "GBP"}, Currency{Code: "UAH"}); err == nil {
+			t.Error("expected error for a currency missing from the rates")
+		}
+
+		if store.finds != 1 || len(fetcher.dates) != 0 {
+			t.Errorf("expected the rates to be read once from the store, got %d reads and %d fetches", store.finds, len(fetcher.dates))
+		}
+	})
+
+	t.Run("Serves the rates of a past day", func(t *testing.T) {
+		store := &memoryRateStore{days: []*DailyRates{nbuDay(paidOn, 38)}}
+		provider := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: store})
+
+		for i := 0; i < 2; i++ {
+			res, err := provider.ConvertAt(Currency{Amount: 100, Code: "USD"}, Currency{Code: "UAH"}, paidOn.Add(12*time.Hour))
+			if err != nil || res.Amount != 3800 {
+				t.Errorf("expected 3800, got %v, %v", res, err)
+			}
+		}
+
+		if store.finds != 1 {
+			t.Errorf("expected past rates to be cached, got %d reads", store.finds)
+		}
+	})
+
+	t.Run("Fetches and stores missing rates", func(t *testing.T) {
+		store := &memoryRateStore{days: []*DailyRates{nbuDay(paidOn.AddDate(0, 0, -3), 37)}}
+		fetcher := &stubFetcher{rates: nbuDay(paidOn, 38)}
+		provider := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: store, Fetcher: fetcher})
+
+		rate, err := provider.RateAt("USD", "UAH", paidOn)
+		if err != nil || rate != 38 {
+			t.Errorf("expected 38, got %v, %v", rate, err)
+		}
+
+		if len(fetcher.dates) != 1 || !fetcher.dates[0].Equal(paidOn) || len(store.days) != 2 {
+			t.Errorf("expected the day to be fetched and stored, got %v, %d", fetcher.dates, len(store.days))
+		}
+
+		cold := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: &memoryRateStore{}, Fetcher: &stubFetcher{rates: nbuDay(today, 41)}})
+		if rate, err = cold.RateAt("USD", "UAH", time.Time{}); err != nil || rate != 41 {
+			t.Errorf("expected 41 on a cold start, got %v, %v", rate, err)
+		}
+	})
+
+	t.Run("Falls back to stale rates when the store fails", func(t *testing.T) {
+		store := &memoryRateStore{days: []*DailyRates{nbuDay(today, 40)}}
+		provider := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: store, CacheTTL: time.Millisecond})
+
+		if _, err := provider.RateAt("USD", "UAH", time.Time{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		store.findErr = errors.New("db error")
+
+		if rate, err := provider.RateAt("USD", "UAH", time.Time{}); err != nil || rate != 40 {
+			t.Errorf("expected the stale rate, got %v, %v", rate, err)
+		}
+	})
+
+	t.Run("Shares one load between concurrent lookups of a past day", func(t *testing.T) {
+		store := &memoryRateStore{days: []*DailyRates{nbuDay(paidOn.AddDate(0, 0, -1), 37)}}
+		fetcher := &blockingFetcher{rates: nbuDay(paidOn, 38), release: make(chan struct{})}
+		provider := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: store, Fetcher: fetcher})
+
+		if rate, err := provider.RateAt("USD", "UAH", paidOn.AddDate(0, 0, -1)); err != nil || rate != 37 {
+			t.Fatalf("expected 37, got %v, %v", rate, err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if rate, err := provider.RateAt("USD", "UAH", paidOn); err != nil || rate != 38 {
+					t.Errorf("expected 38, got %v, %v", rate, err)
+				}
+			}()
+		}
+
+		// a cached day is served while another one is being fetched
+		if rate, err := provider.RateAt("USD", "UAH", paidOn.AddDate(0, 0, -1)); err != nil || rate != 37 {
+			t.Errorf("expected 37 while fetching another day, got %v, %v", rate, err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(fetcher.release)
+		wg.Wait()
+
+		if fetcher.calls.Load() != 1 {
+			t.Errorf("expected a single fetch, got %d", fetcher.calls.Load())
+		}
+	})
+
+	t.Run("Shares one load between concurrent lookups of the current rates", func(t *testing.T) {
+		store := &memoryRateStore{days: []*DailyRates{nbuDay(paidOn, 38)}}
+		fetcher := &blockingFetcher{rates: nbuDay(today, 41), release: make(chan struct{})}
+		provider := NewStoredRateProvider(StoredRateProviderOptions{Source: NBURatesSource, Store: store, Fetcher: fetcher})
+
+		if rate, err := provider.RateAt("USD", "UAH", paidOn); err != nil || rate != 38 {
+			t.Fatalf("expected 38, got %v, %v", rate, err)
+		}
+
+		// nothing is stored anymore, so the current rates are fetched
+		store.days = nil
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if rate, err := provider.RateAt("USD", "UAH", time.Time{}); err != nil || rate != 41 {
+					t.Errorf("expected 41, got %v, %v", rate, err)
+				}
+			}()
+		}
+
+		// a cached day is served while the current rates are being fetched
+		if rate, err := provider.RateAt("USD", "UAH", paidOn); err != nil || rate != 38 {
+			t.Errorf("expected 38 while fetching the current rates, got %v, %v", rate, err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(fetcher.release)
+		wg.Wait()
+
+		if fetcher.calls.Load() != 1 {
+			t.Errorf("expected a single fetch, got %d", fetcher.calls.Load())
+		}
+	})
+
+	t.Run("Returns errors when no rates are available", func(t *testing.T) {
+		tests := map[string]*StoredRateProvider{
+			"store error": NewStoredRateProvider(StoredRateProviderOptions{
+				Store:   &memoryRateStore{findErr: errors.New("db error")},
+				Fetcher: &stubFetcher{rates: nbuDay(today, 40)},
+			}),
+			"nothing stored without fetcher": NewStoredRateProvider(StoredRateProviderOptions{
+				Store: &memoryRateStore{},
+			}),
+			"fetch error": NewStoredRateProvider(StoredRateProviderOptions{
+				Store:   &memoryRateStore{saveErr: errors.New("db error")},
+				Fetcher: &stubFetcher{err: errors.New("bank.gov.ua is down")},
+			}),
+		}
+
+		for name, provider := range tests {
+			if _, err := provider.ConvertAt(Currency{Amount: 100, Code: "USD"}, Currency{Code: "UAH"}, paidOn); err == nil {
+				t.Errorf("%s: expected error, got nil", name)
+			}
+
+			if _, err := provider.RateAt("USD", "UAH", time.Time{}); err == nil {
+				t.Errorf("%s: expected error, got nil", name)
+			}
+		}
+	})
+}
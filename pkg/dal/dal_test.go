@@ -14,9 +14,13 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
 	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/people"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
 )
 
 type mockRow struct {
@@ -28,6 +32,10 @@ func (m *mockRow) Scan(dest ...any) error {
 	if m.err != nil {
 		return m.err
 	}
+	// pgx refuses to scan a row into fewer destinations than its columns
+	if len(dest) == 0 && len(m.row) > 0 {
+		return errors.New("number of field descriptions must equal number of destinations")
+	}
 	for i, v := range dest {
 		if i >= len(m.row) {
 			break
@@ -70,6 +78,19 @@ func (m *mockRow) Scan(dest ...any) error {
 		case *types.NullString:
 			d.String = val.(string)
 			d.Valid = true
+		case *types.NullTime:
+			d.Time = val.(time.Time)
+			d.Valid = true
+		case *types.NullInt64:
+			d.Int64 = val.(int64)
+			d.Valid = true
+		case *types.NullFloat64:
+			d.Float64 = val.(float64)
+			d.Valid = true
+		case *float64:
+			*d = val.(float64)
+		case *[]string:
+			*d = val.([]string)
 		}
 	}
 	return nil
@@ -139,6 +160,19 @@ func (m *mockRows) Scan(dest ...any) error {
 		case *types.NullString:
 			target.String = val.(string)
 			target.Valid = true
+		case *types.NullTime:
+			target.Time = val.(time.Time)
+			target.Valid = true
+		case *types.NullInt64:
+			target.Int64 = val.(int64)
+			target.Valid = true
+		case *types.NullFloat64:
+			target.Float64 = val.(float64)
+			target.Valid = true
+		case *float64:
+			*target = val.(float64)
+		case *[]string:
+			*target = val.([]string)
 		}
 	}
 	return nil
@@ -157,6 +191,7 @@ type mockPgDb struct {
 	countErr   error
 	isNotFound bool
 	zeroTotal  bool
+	queries    []string
 }
 
 func (m *mockPgDb) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
@@ -170,6 +205,7 @@ func (m *mockPgDb) Exec(ctx context.Context, sql string, arguments ...interface{
 }
 
 func (m *mockPgDb) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	m.queries = append(m.queries, sql)
 	if m.queryErr != nil {
 		return nil, m.queryErr
 	}
@@ -177,13 +213,14 @@ func (m *mockPgDb) Query(ctx context.Context, sql string, args ...interface{}) (
 }
 
 func (m *mockPgDb) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	m.queries = append(m.queries, sql)
 	if m.rowErr != nil {
 		return &mockRow{err: m.rowErr}
 	}
 	if m.isNotFound {
 		return &mockRow{err: pgx.ErrNoRows}
 	}
-	if strings.Contains(sql, "COUNT") || strings.Contains(sql, "count") || strings.Contains(sql, "1 as exists") {
+	if strings.Contains(sql, "COUNT(") || strings.Contains(sql, "count(") || strings.Contains(sql, "1 as exists") {
 		if m.countErr != nil {
 			return &mockRow{err: m.countErr}
 		}
@@ -404,6 +441,192 @@ func TestPgProjectRepository(t *testing.T) {
 		if err != nil {
 			t.Errorf("Remove error: %v", err)
 		}
+
+		// Remove zero rows affected error
+		ctxZero := withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")})
+		if err = repo.Remove(ctxZero, proj); err == nil {
+			t.Errorf("expected remove error on 0 rows affected")
+		}
+
+		ctxExecErr := withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")})
+		if err = repo.Remove(ctxExecErr, proj); err == nil {
+			t.Errorf("expected remove exec error")
+		}
+	})
+
+	t.Run("Archived projects", func(t *testing.T) {
+		archivedAt := now.Add(-time.Hour).Truncate(time.Second)
+		row := []any{pID.String(), "Project A", "Desc", ownerID.String(), now, now, "John", "Doe", "J.D.", uuid.New().String(), "UAH", archivedAt}
+		ctx := withMockDb(authedCtx, &mockPgDb{rowVal: row, rowsData: [][]any{row}})
+
+		p, err := repo.FindOne(ctx, projecta.ProjectFilter{ProjectID: pID})
+		if err != nil || !p.IsArchived() || !p.ArchivedAt.Equal(archivedAt) {
+			t.Errorf("expected archived project from FindOne, got %v", err)
+		}
+
+		projects, err := repo.Find(ctx, projecta.ProjectCollectionFilter{Archived: true})
+		if err != nil || len(projects) != 1 || !projects[0].IsArchived() {
+			t.Errorf("expected archived project from Find, got %v", err)
+		}
+
+		p, err = repo.FindByShareToken(ctx, uuid.New())
+		if err != nil || !p.IsArchived() {
+			t.Errorf("expected archived project from FindByShareToken, got %v", err)
+		}
+
+		p.Archive(archivedAt)
+		if err = repo.Update(ctx, p); err != nil {
+			t.Errorf("Update archived project error: %v", err)
+		}
+	})
+
+	t.Run("Project roles", func(t *testing.T) {
+		memberID := uuid.New()
+		memberCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, memberID)
+		row := []any{pID.String(), "Project A", "Desc", ownerID.String(), now, now, "John", "Doe", "J.D.", uuid.New().String(), "UAH", nil, "VIEWER"}
+		ctx := withMockDb(memberCtx, &mockPgDb{rowVal: row, rowsData: [][]any{row}})
+
+		p, err := repo.FindOne(ctx, projecta.ProjectFilter{ProjectID: pID})
+		if err != nil || p.Role != projecta.RoleViewer || !p.IsShared {
+			t.Errorf("expected viewer role from FindOne, got %v", err)
+		}
+
+		projects, err := repo.Find(ctx, projecta.ProjectCollectionFilter{})
+		if err != nil || len(projects) != 1 || projects[0].Role != projecta.RoleViewer {
+			t.Errorf("expected viewer role from Find, got %v", err)
+		}
+
+		ownerCtx := withMockDb(authedCtx, &mockPgDb{rowVal: row})
+		if p, err = repo.FindOne(ownerCtx, projecta.ProjectFilter{ProjectID: pID}); err != nil || p.Role != projecta.RoleOwner {
+			t.Errorf("expected owner role for the project owner, got %v", err)
+		}
+
+		unknown := append(append([]any{}, row[:12]...), "")
+		unknownCtx := withMockDb(memberCtx, &mockPgDb{rowVal: unknown})
+		if p, err = repo.FindOne(unknownCtx, projecta.ProjectFilter{ProjectID: pID}); err != nil || p.Role != projecta.RoleViewer {
+			t.Errorf("expected unknown role to fall back to viewer, got %v", err)
+		}
+	})
+
+	t.Run("Share links", func(t *testing.T) {
+		linkID := uuid.New()
+		token := uuid.New()
+		expiresAt := now.Add(time.Hour)
+		linkRow := []any{linkID.String(), token.String(), "EDITOR", ownerID.String(), now, expiresAt, 5, 2}
+		row := append([]any{pID.String(), "Project A", "Desc", ownerID.String(), now, now, "John", "Doe", "J.D.", uuid.New().String(), "UAH", nil}, linkRow...)
+		ctx := withMockDb(authedCtx, &mockPgDb{rowVal: row, rowsData: [][]any{linkRow}})
+
+		link, err := repo.FindShareLink(ctx, token)
+		if err != nil || link.ID != linkID || link.Token != token || link.Role != projecta.RoleEditor || link.Project.ProjectID != pID {
+			t.Fatalf("FindShareLink error: %v", err)
+		}
+		if !link.ExpiresAt.Equal(expiresAt) || link.MaxUses != 5 || link.Uses != 2 {
+			t.Errorf("expected link limits to be loaded, got %+v", link)
+		}
+
+		links, err := repo.FindShareLinks(ctx, proj)
+		if err != nil || len(links) != 1 || links[0].ID != linkID || links[0].Project != proj {
+			t.Errorf("FindShareLinks error: %v", err)
+		}
+
+		if _, err = repo.FindShareLinks(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), proj); err == nil {
+			t.Errorf("expected FindShareLinks query error")
+		}
+		if _, err = repo.FindShareLinks(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"bad-uuid"}}}), proj); err == nil {
+			t.Errorf("expected FindShareLinks parse error")
+		}
+
+		if _, err = repo.FindShareLink(ctx, uuid.Nil); err == nil {
+			t.Errorf("expected error for empty token")
+		}
+
+		ctxNotFound := withMockDb(authedCtx, &mockPgDb{rowErr: pgx.ErrNoRows})
+		if _, err = repo.FindShareLink(ctxNotFound, token); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		ctxRowErr := withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")})
+		if _, err = repo.FindShareLink(ctxRowErr, token); err == nil {
+			t.Errorf("expected row error")
+		}
+
+		for i, value := range map[int]string{12: "bad-uuid", 13: "bad-uuid", 14: "OWNERISH", 15: "bad-uuid"} {
+			broken := append([]any{}, row...)
+			broken[i] = value
+			ctxBroken := withMockDb(authedCtx, &mockPgDb{rowVal: broken})
+			if _, err = repo.FindShareLink(ctxBroken, token); err == nil {
+				t.Errorf("expected error for invalid column %d", i)
+			}
+		}
+
+		if err = repo.CreateShareLink(withMockDb(authedCtx, &mockPgDb{}), link); err != nil {
+			t.Errorf("CreateShareLink error: %v", err)
+		}
+
+		if err = repo.UseShareLink(withMockDb(authedCtx, &mockPgDb{}), link); err != nil || link.Uses != 3 {
+			t.Errorf("UseShareLink error: %v", err)
+		}
+		if err = repo.UseShareLink(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("UPDATE 0")}), link); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected used up link to be not found, got %v", err)
+		}
+		if err = repo.UseShareLink(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), link); err == nil {
+			t.Errorf("expected UseShareLink exec error")
+		}
+
+		if err = repo.RemoveShareLink(withMockDb(authedCtx, &mockPgDb{}), pID, linkID); err != nil {
+			t.Errorf("RemoveShareLink error: %v", err)
+		}
+		if err = repo.RemoveShareLink(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), pID, linkID); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected missing link to be not found, got %v", err)
+		}
+		if err = repo.RemoveShareLink(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), pID, linkID); err == nil {
+			t.Errorf("expected RemoveShareLink exec error")
+		}
+	})
+
+	t.Run("Project members", func(t *testing.T) {
+		memberID := uuid.New()
+		ctx := withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{
+			{ownerID.String(), "John", "Doe", "J.D.", "OWNER", now, 0},
+			{memberID.String(), "Jane", "Roe", nil, "VIEWER", now, 1},
+		}})
+
+		members, err := repo.FindMembers(ctx, pID)
+		if err != nil || len(members) != 2 {
+			t.Fatalf("FindMembers error: %v", err)
+		}
+		if !members[0].IsOwner() || members[0].DisplayName != "J.D." {
+			t.Errorf("expected owner first, got %+v", members[0])
+		}
+		if members[1].Role != projecta.RoleViewer || members[1].DisplayName != "Jane Roe" {
+			t.Errorf("expected viewer with full name, got %+v", members[1])
+		}
+
+		if _, err = repo.FindMembers(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), pID); err == nil {
+			t.Errorf("expected FindMembers query error")
+		}
+		if _, err = repo.FindMembers(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{memberID.String(), "J", "Roe", nil, "VIEWER", now, 1}}}), pID); err == nil {
+			t.Errorf("expected FindMembers invalid person error")
+		}
+		if _, err = repo.FindMembers(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{memberID.String(), "Jane", "Roe", nil, "GUEST", now, 1}}}), pID); err == nil {
+			t.Errorf("expected FindMembers invalid role error")
+		}
+
+		if created, err := repo.CreateShareRecord(withMockDb(authedCtx, &mockPgDb{}), pID, memberID, projecta.RoleViewer); err != nil || !created {
+			t.Errorf("CreateShareRecord error: %v", err)
+		}
+		if created, err := repo.CreateShareRecord(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("INSERT 0 0")}), pID, memberID, projecta.RoleViewer); err != nil || created {
+			t.Errorf("expected CreateShareRecord to report an existing member, got %v", err)
+		}
+		if err = repo.RemoveShareRecord(withMockDb(authedCtx, &mockPgDb{}), pID, memberID); err != nil {
+			t.Errorf("RemoveShareRecord error: %v", err)
+		}
+		if err = repo.RemoveShareRecord(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), pID, memberID); err == nil {
+			t.Errorf("expected RemoveShareRecord error on 0 rows affected")
+		}
+		if err = repo.RemoveShareRecord(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), pID, memberID); err == nil {
+			t.Errorf("expected RemoveShareRecord exec error")
+		}
 	})
 
 	t.Run("Find collection success and errors", func(t *testing.T) {
@@ -475,6 +698,14 @@ func TestPgCategoryTypePaymentAssetRepositories(t *testing.T) {
 			t.Errorf("Save category error: %v", err)
 		}
 
+		existing := &sequencedPgDb{}
+		if err = catRepo.Save(withMockDb(authedCtx, existing), cat); err != nil {
+			t.Errorf("Save category update branch error: %v", err)
+		}
+		if len(existing.queries) != 2 || !strings.Contains(existing.queries[1], "UPDATE projecta_cost_categories") {
+			t.Errorf("expected the existing category updated, got %v", existing.queries)
+		}
+
 		// Save calling create when not found
 		mockDbCreate := &mockPgDb{isNotFound: true}
 		ctxCreate := withMockDb(authedCtx, mockDbCreate)
@@ -633,6 +864,39 @@ func TestPgCategoryTypePaymentAssetRepositories(t *testing.T) {
 			t.Errorf("Find payment collection error: %v", err)
 		}
 
+		// Find narrowed by the search filters
+		mockDbSearch := &mockPgDb{}
+		_, err = payRepo.Find(withMockDb(authedCtx, mockDbSearch), projecta.PaymentCollectionFilter{
+			ProjectID: pID,
+			Kind:      projecta.DownPayment,
+			OwnerID:   uuid.New(),
+			From:      time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+			To:        time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC),
+			AmountMin: 50000,
+			AmountMax: 200000,
+			Currency:  "UAH",
+			Query:     "plumber",
+		})
+		if err != nil || len(mockDbSearch.queries) != 2 {
+			t.Fatalf("Find searched payments error: %v", err)
+		}
+		for _, clause := range []string{
+			"projecta_payments.kind = $",
+			"projecta_payments.owner_id = $",
+			"COALESCE(projecta_payments.payment_date, projecta_payments.created_at)::DATE >= $",
+			"COALESCE(projecta_payments.payment_date, projecta_payments.created_at)::DATE <= $",
+			"projecta_payments.amount >= $",
+			"projecta_payments.amount <= $",
+			"projecta_payments.currency = $",
+			"projecta_payments.search_vector @@ (websearch_to_tsquery('english', $",
+		} {
+			for _, sql := range mockDbSearch.queries {
+				if !strings.Contains(sql, clause) {
+					t.Errorf("expected %q in %s", clause, sql)
+				}
+			}
+		}
+
 		// Find zero total
 		mockDbZero := &mockPgDb{zeroTotal: true}
 		ctxZero := withMockDb(authedCtx, mockDbZero)
@@ -646,6 +910,100 @@ func TestPgCategoryTypePaymentAssetRepositories(t *testing.T) {
 			t.Errorf("Remove payment error: %v", err)
 		}
 
+		// booked conversion is read back along with the project main currency
+		convertedRow := []any{
+			payID.String(), pID.String(), "Project", catID.String(), "Cat",
+			typeID.String(), "Type", int64(100), "USD", "Payment",
+			ownerID.String(), "John", "J.D.", now, "DOWN_PAYMENT",
+			"EUR", int64(92), "EUR", 0.92, true,
+		}
+		ctxConverted := withMockDb(authedCtx, &mockPgDb{rowVal: convertedRow, rowsData: [][]any{convertedRow}})
+		converted, err := payRepo.FindOne(ctxConverted, projecta.PaymentFilter{PaymentID: payID})
+		if err != nil || converted.Project.MainCurrency != "EUR" || converted.HomeAmount.Amount() != 92 || converted.ExchangeRate != 0.92 || !converted.ManualRate {
+			t.Errorf("expected booked conversion, got %v", err)
+		}
+		convertedCols, err := payRepo.Find(ctxConverted, projecta.PaymentCollectionFilter{ProjectID: pID})
+		if err != nil || convertedCols.Elements()[0].HomeAmount.Currency().Code != "EUR" {
+			t.Errorf("expected booked conversion in collection, got %v", err)
+		}
+
+		// planned payments are read back with the day they are due
+		dueAt := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+		plannedRow := append(append([]any{}, convertedRow...), "PLANNED", dueAt)
+		ctxPlanned := withMockDb(authedCtx, &mockPgDb{rowVal: plannedRow, rowsData: [][]any{plannedRow}})
+		planned, err := payRepo.FindOne(ctxPlanned, projecta.PaymentFilter{PaymentID: payID})
+		if err != nil || planned.Status != projecta.PaymentPlanned || !planned.DueDate.Equal(dueAt) {
+			t.Errorf("expected planned payment, got %v", err)
+		}
+		plannedCols, err := payRepo.Find(ctxPlanned, projecta.PaymentCollectionFilter{ProjectID: pID, Status: projecta.PaymentPlanned})
+		if err != nil || plannedCols.Elements()[0].Status != projecta.PaymentPlanned {
+			t.Errorf("expected planned payment in collection, got %v", err)
+		}
+		if err = payRepo.Save(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("UPDATE 0")}), planned); err != nil {
+			t.Errorf("Save planned payment error: %v", err)
+		}
+
+		// payments made under a contract are read back with it and its vendor
+		vendorID, contractID := uuid.New(), uuid.New()
+		vendorRow := append(append([]any{}, plannedRow...), vendorID.String(), contractID.String())
+		ctxVendor := withMockDb(authedCtx, &mockPgDb{rowVal: vendorRow, rowsData: [][]any{vendorRow}})
+		contracted, err := payRepo.FindOne(ctxVendor, projecta.PaymentFilter{PaymentID: payID})
+		if err != nil || contracted.VendorID != vendorID || contracted.ContractID != contractID {
+			t.Errorf("expected vendor and contract, got %v", err)
+		}
+		if planned.VendorID != uuid.Nil || planned.ContractID != uuid.Nil {
+			t.Errorf("expected no vendor, got %s", planned.VendorID)
+		}
+		mockDbVendor := &mockPgDb{rowsData: [][]any{vendorRow}}
+		vendorCols, err := payRepo.Find(withMockDb(authedCtx, mockDbVendor), projecta.PaymentCollectionFilter{ProjectID: pID, VendorID: vendorID, ContractID: contractID})
+		if err != nil || vendorCols.Elements()[0].ContractID != contractID {
+			t.Errorf("expected contract in collection, got %v", err)
+		}
+		for _, sql := range mockDbVendor.queries {
+			if !strings.Contains(sql, "projecta_payments.vendor_id = $") || !strings.Contains(sql, "projecta_payments.contract_id = $") {
+				t.Errorf("expected vendor and contract filters in %s", sql)
+			}
+		}
+		if err = payRepo.Save(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("UPDATE 0")}), contracted); err != nil {
+			t.Errorf("Save contracted payment error: %v", err)
+		}
+
+		taggedRow := append(append([]any{}, vendorRow...), []string{"kitchen", "tax-deductible"})
+		mockDbTagged := &mockPgDb{rowVal: taggedRow, rowsData: [][]any{taggedRow}}
+		tagged, err := payRepo.FindOne(withMockDb(authedCtx, mockDbTagged), projecta.PaymentFilter{PaymentID: payID})
+		if err != nil || len(tagged.Tags) != 2 || tagged.Tags[0] != "kitchen" {
+			t.Errorf("expected payment tags, got %v, %v", tagged, err)
+		}
+		taggedCols, err := payRepo.Find(withMockDb(authedCtx, mockDbTagged), projecta.PaymentCollectionFilter{ProjectID: pID, Tags: []string{"kitchen", "tax-deductible"}})
+		if err != nil || len(taggedCols.Elements()[0].Tags) != 2 {
+			t.Errorf("expected payment tags in collection, got %v", err)
+		}
+		for _, sql := range mockDbTagged.queries[1:] {
+			if strings.Count(sql, "projecta_tags.name = $") != 2 {
+				t.Errorf("expected a filter per tag in %s", sql)
+			}
+		}
+		if sql := mockDbTagged.queries[len(mockDbTagged.queries)-1]; !strings.Contains(sql, "ORDER BY projecta_payments.payment_date DESC, projecta_payments.payment_id") {
+			t.Errorf("expected the id to break ties in %s", sql)
+		}
+
+		mockDbStatus := &mockPgDb{}
+		if _, err = payRepo.Find(withMockDb(authedCtx, mockDbStatus), projecta.PaymentCollectionFilter{ProjectID: pID, Status: projecta.PaymentDue}); err != nil {
+			t.Fatalf("Find payments by status error: %v", err)
+		}
+		for _, sql := range mockDbStatus.queries {
+			if !strings.Contains(sql, "projecta_payments.status = $") {
+				t.Errorf("expected status filter in %s", sql)
+			}
+		}
+
+		if err = payRepo.Save(ctxConverted, converted); err != nil {
+			t.Errorf("Save converted payment error: %v", err)
+		}
+		if err = payRepo.Save(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("UPDATE 0")}), converted); err != nil {
+			t.Errorf("Save converted payment create branch error: %v", err)
+		}
+
 		// Remove exec error
 		mockDbRemErr := &mockPgDb{execErr: errors.New("exec error")}
 		ctxRemErr := withMockDb(authedCtx, mockDbRemErr)
@@ -710,6 +1068,32 @@ func TestPgCategoryTypePaymentAssetRepositories(t *testing.T) {
 			t.Errorf("FindOne asset error: %v", err)
 		}
 
+		vendorID := uuid.New()
+		vendorRow := append(append([]any{}, mockDb.rowVal...), vendorID.String())
+		ctxVendor := withMockDb(authedCtx, &mockPgDb{rowVal: vendorRow, rowsData: [][]any{vendorRow}})
+		if a, err = astRepo.FindOne(ctxVendor, asset.Filter{ID: astID}); err != nil || a.VendorID() != vendorID {
+			t.Errorf("expected asset vendor, got %v", err)
+		}
+		if vendorAssets, err := astRepo.Find(ctxVendor, asset.CollectionFilter{ProjectID: pID}); err != nil || vendorAssets.Elements()[0].VendorID() != vendorID {
+			t.Errorf("expected asset vendor in collection, got %v", err)
+		}
+
+		taggedRow := append(append([]any{}, vendorRow...), []string{"kitchen"})
+		mockDbTagged := &mockPgDb{rowVal: taggedRow, rowsData: [][]any{taggedRow}}
+		if a, err = astRepo.FindOne(withMockDb(authedCtx, mockDbTagged), asset.Filter{ID: astID}); err != nil || len(a.Tags()) != 1 {
+			t.Errorf("expected asset tags, got %v", err)
+		}
+		taggedAssets, err := astRepo.Find(withMockDb(authedCtx, mockDbTagged), asset.CollectionFilter{ProjectID: pID, Tags: []string{"kitchen"}})
+		if err != nil || taggedAssets.Elements()[0].Tags()[0] != "kitchen" {
+			t.Errorf("expected asset tags in collection, got %v", err)
+		}
+		if sql := mockDbTagged.queries[1]; !strings.Contains(sql, "EXISTS (SELECT 1 FROM projecta_asset_tags") {
+			t.Errorf("expected tag filter in %s", sql)
+		}
+		if sql := mockDbTagged.queries[len(mockDbTagged.queries)-1]; !strings.Contains(sql, "ORDER BY projecta_assets.acquired_at DESC, projecta_assets.asset_id") {
+			t.Errorf("expected the id to break ties in %s", sql)
+		}
+
 		// FindOne non-ErrAssetNotFound error
 		mockDbOtherErr := &mockPgDb{rowErr: errors.New("db failure")}
 		ctxOtherErr := withMockDb(authedCtx, mockDbOtherErr)
@@ -856,3 +1240,1613 @@ func TestPgCategoryTypePaymentAssetRepositories(t *testing.T) {
 		}
 	})
 }
+
+func TestPgBudgetRepository(t *testing.T) {
+	budgetRepo := NewPgBudgetRepository(&PgDbConnection{})
+
+	pID := uuid.New()
+	catID := uuid.New()
+	typeID := uuid.New()
+	budgetID := uuid.New()
+	ownerID := uuid.New()
+
+	cat, _ := projecta.NewCostCategory(catID, pID, "Category", "Desc")
+	costType := &projecta.CostType{ID: typeID, ProjectID: pID, Category: cat, Name: "Type"}
+	categoryBudget, _ := projecta.NewBudget(budgetID, pID, cat, nil, money.New(1000, "UAH"), "Desc")
+	typeBudget, _ := projecta.NewBudget(uuid.New(), pID, cat, costType, money.New(500, "UAH"), "")
+
+	row := []any{budgetID.String(), pID.String(), catID.String(), "Category", typeID.String(), "Type", int64(1000), "UAH", "Desc"}
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, ownerID)
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := budgetRepo.FindOne(ctx, projecta.BudgetFilter{}); err == nil {
+			t.Errorf("expected FindOne auth error")
+		}
+		if _, err := budgetRepo.Find(ctx, projecta.BudgetCollectionFilter{}); err == nil {
+			t.Errorf("expected Find auth error")
+		}
+		if _, err := budgetRepo.Report(ctx, pID); err == nil {
+			t.Errorf("expected Report auth error")
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		ctx := withMockDb(authedCtx, &mockPgDb{rowVal: row})
+
+		b, err := budgetRepo.FindOne(ctx, projecta.BudgetFilter{BudgetID: budgetID, ProjectID: pID})
+		if err != nil || b.ID != budgetID || b.Type == nil || b.Type.ID != typeID || b.Amount.Amount() != 1000 {
+			t.Errorf("FindOne budget error: %v", err)
+		}
+
+		ctxNotFound := withMockDb(authedCtx, &mockPgDb{isNotFound: true})
+		if _, err = budgetRepo.FindOne(ctxNotFound, projecta.BudgetFilter{BudgetID: budgetID}); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		ctxErr := withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")})
+		if _, err = budgetRepo.FindOne(ctxErr, projecta.BudgetFilter{BudgetID: budgetID}); err == nil {
+			t.Errorf("expected FindOne db error")
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		categoryRow := []any{budgetID.String(), pID.String(), catID.String(), "Category", "", "", int64(1000), "UAH", ""}
+		ctx := withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{row, categoryRow}})
+
+		col, err := budgetRepo.Find(ctx, projecta.BudgetCollectionFilter{ProjectID: pID, CategoryID: catID})
+		if err != nil || len(col.Elements()) != 2 || col.Elements()[1].Type != nil {
+			t.Errorf("Find budgets error: %v", err)
+		}
+
+		ctxZero := withMockDb(authedCtx, &mockPgDb{zeroTotal: true})
+		col, err = budgetRepo.Find(ctxZero, projecta.BudgetCollectionFilter{ProjectID: pID})
+		if err != nil || col.Total() != 0 {
+			t.Errorf("Find budgets zero total error: %v", err)
+		}
+
+		ctxCountErr := withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")})
+		if _, err = budgetRepo.Find(ctxCountErr, projecta.BudgetCollectionFilter{ProjectID: pID}); err == nil {
+			t.Errorf("expected Find count error")
+		}
+
+		ctxQueryErr := withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")})
+		if _, err = budgetRepo.Find(ctxQueryErr, projecta.BudgetCollectionFilter{ProjectID: pID}); err == nil {
+			t.Errorf("expected Find query error")
+		}
+
+		ctxInvalid := withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}})
+		if _, err = budgetRepo.Find(ctxInvalid, projecta.BudgetCollectionFilter{ProjectID: pID}); err == nil {
+			t.Errorf("expected Find mapping error")
+		}
+	})
+
+	t.Run("Save and Remove", func(t *testing.T) {
+		ctx := withMockDb(authedCtx, &mockPgDb{})
+
+		existing := &sequencedPgDb{}
+		if err := budgetRepo.Save(withMockDb(authedCtx, existing), categoryBudget); err != nil {
+			t.Errorf("Save budget update branch error: %v", err)
+		}
+		if len(existing.queries) != 2 || !strings.Contains(existing.queries[1], "UPDATE projecta_budgets") {
+			t.Errorf("expected the existing budget updated, got %v", existing.queries)
+		}
+
+		ctxCreate := withMockDb(authedCtx, &mockPgDb{isNotFound: true})
+		if err := budgetRepo.Save(ctxCreate, typeBudget); err != nil {
+			t.Errorf("Save budget create branch error: %v", err)
+		}
+
+		ctxRowErr := withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("row error")})
+		if err := budgetRepo.Save(ctxRowErr, categoryBudget); err == nil {
+			t.Errorf("expected Save budget row error")
+		}
+
+		if err := budgetRepo.Remove(ctx, categoryBudget); err != nil {
+			t.Errorf("Remove budget error: %v", err)
+		}
+
+		ctxZero := withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")})
+		if err := budgetRepo.Remove(ctxZero, categoryBudget); err == nil {
+			t.Errorf("expected Remove budget error on 0 rows affected")
+		}
+
+		ctxExecErr := withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")})
+		if err := budgetRepo.Remove(ctxExecErr, categoryBudget); err == nil {
+			t.Errorf("expected Remove budget exec error")
+		}
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		otherID := uuid.New()
+		day := time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)
+		db := &mockPgDb{rowsData: [][]any{
+			append(append([]any{}, row...), "UAH", day, int64(700), 2),
+			append(append([]any{}, row...), "USD", day.AddDate(0, 0, 1), int64(10), 1),
+			{otherID.String(), pID.String(), catID.String(), "Category", "", "", int64(300), "UAH", "", "", nil, int64(0), 0},
+		}}
+
+		lines, err := budgetRepo.Report(withMockDb(authedCtx, db), pID)
+		if err != nil || len(lines) != 2 {
+			t.Fatalf("Report error: %v", err)
+		}
+		if len(lines[0].Actual) != 2 || lines[0].Actual[1].Amount.Currency().Code != "USD" || !lines[0].Actual[1].Date.Equal(day.AddDate(0, 0, 1)) || lines[0].Actual[0].Count != 2 {
+			t.Errorf("expected actual amounts per day and currency for the first line")
+		}
+		for _, want := range []string{"projecta_payments.home_amount IS NOT NULL", "GROUP BY 1, 2", "spent.day"} {
+			if !strings.Contains(db.queries[0], want) {
+				t.Errorf("expected %q in %s", want, db.queries[0])
+			}
+		}
+		if lines[1].Budget.ID != otherID || len(lines[1].Actual) != 0 {
+			t.Errorf("expected empty actual amounts for a budget without payments")
+		}
+
+		ctxQueryErr := withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")})
+		if _, err = budgetRepo.Report(ctxQueryErr, pID); err == nil {
+			t.Errorf("expected Report query error")
+		}
+
+		ctxInvalid := withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}})
+		if _, err = budgetRepo.Report(ctxInvalid, pID); err == nil {
+			t.Errorf("expected Report mapping error")
+		}
+	})
+
+	t.Run("toBudget", func(t *testing.T) {
+		if _, err := toBudget("invalid", pID.String(), catID.String(), "", "", "", 1, "UAH", ""); err == nil {
+			t.Errorf("expected budget id parse error")
+		}
+		if _, err := toBudget(budgetID.String(), "invalid", catID.String(), "", "", "", 1, "UAH", ""); err == nil {
+			t.Errorf("expected project id parse error")
+		}
+		if _, err := toBudget(budgetID.String(), pID.String(), "invalid", "", "", "", 1, "UAH", ""); err == nil {
+			t.Errorf("expected category id parse error")
+		}
+		if _, err := toBudget(budgetID.String(), pID.String(), catID.String(), "", "invalid", "", 1, "UAH", ""); err == nil {
+			t.Errorf("expected type id parse error")
+		}
+	})
+}
+
+func TestPgFixedRateRepository(t *testing.T) {
+	repo := NewPgFixedRateRepository(&PgDbConnection{})
+	ctx := context.Background()
+	projectID := uuid.New()
+
+	rates, err := repo.Find(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{"USD", "UAH", 41.5}}}), projectID)
+	if err != nil || len(rates) != 1 || rates[0].Currency != "USD" || rates[0].BaseCurrency != "UAH" || rates[0].Rate != 41.5 || rates[0].ProjectID != projectID {
+		t.Errorf("unexpected fixed rates %v, %v", rates, err)
+	}
+
+	if _, err = repo.Find(withMockDb(ctx, &mockPgDb{queryErr: errors.New("db error")}), projectID); err == nil {
+		t.Error("expected query error")
+	}
+
+	if _, err = repo.Find(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{"USD", "USD", 41.5}}}), projectID); err == nil {
+		t.Error("expected invalid fixed rate error")
+	}
+
+	rate, _ := projecta.NewFixedRate(projectID, "USD", "UAH", 41.5)
+	if err = repo.Save(withMockDb(ctx, &mockPgDb{}), rate); err != nil {
+		t.Errorf("Save fixed rate error: %v", err)
+	}
+
+	if err = repo.Remove(withMockDb(ctx, &mockPgDb{}), projectID, "USD"); err != nil {
+		t.Errorf("Remove fixed rate error: %v", err)
+	}
+
+	err = repo.Remove(withMockDb(ctx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), projectID, "USD")
+	if !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err = repo.Remove(withMockDb(ctx, &mockPgDb{execErr: errors.New("db error")}), projectID, "USD"); err == nil {
+		t.Error("expected exec error")
+	}
+}
+
+func TestPgSettlementRepository(t *testing.T) {
+	repo := NewPgSettlementRepository(&PgDbConnection{})
+	ctx := context.Background()
+	projectID := uuid.New()
+	fromID, toID := uuid.New(), uuid.New()
+	day := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	weights, err := repo.FindWeights(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{fromID.String(), 1.5}}}), projectID)
+	if err != nil || len(weights) != 1 || weights[0].PersonID != fromID || weights[0].Weight != 1.5 || weights[0].ProjectID != projectID {
+		t.Errorf("unexpected weights %v, %v", weights, err)
+	}
+
+	if _, err = repo.FindWeights(withMockDb(ctx, &mockPgDb{queryErr: errors.New("db error")}), projectID); err == nil {
+		t.Error("expected query error")
+	}
+
+	if _, err = repo.FindWeights(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{fromID.String(), -1.0}}}), projectID); err == nil {
+		t.Error("expected invalid weight error")
+	}
+
+	weight, _ := projecta.NewSettlementWeight(projectID, fromID, 2)
+	if err = repo.SaveWeight(withMockDb(ctx, &mockPgDb{}), weight); err != nil {
+		t.Errorf("SaveWeight error: %v", err)
+	}
+
+	transfers, err := repo.FindTransfers(withMockDb(ctx, &mockPgDb{rowsData: [][]any{
+		{uuid.New().String(), fromID.String(), "Bob", "Bobby", toID.String(), "Alice", "", int64(500), "UAH", day, nil},
+	}}), projectID)
+	if err != nil || len(transfers) != 1 {
+		t.Fatalf("unexpected transfers %v, %v", transfers, err)
+	}
+	if tr := transfers[0]; tr.From.PersonID != fromID || tr.From.DisplayName != "Bobby" || tr.To.FirstName != "Alice" || tr.Amount.Amount() != 500 || !tr.Date.Equal(day) {
+		t.Errorf("unexpected transfer %+v", tr)
+	}
+
+	if _, err = repo.FindTransfers(withMockDb(ctx, &mockPgDb{queryErr: errors.New("db error")}), projectID); err == nil {
+		t.Error("expected query error")
+	}
+
+	if _, err = repo.FindTransfers(withMockDb(ctx, &mockPgDb{rowsData: [][]any{
+		{uuid.New().String(), fromID.String(), "Bob", "", fromID.String(), "Bob", "", int64(500), "UAH", day, nil},
+	}}), projectID); err == nil {
+		t.Error("expected invalid transfer error")
+	}
+
+	transfer, _ := projecta.NewSettlementTransfer(uuid.New(), projectID, &projecta.Owner{PersonID: fromID}, &projecta.Owner{PersonID: toID}, money.New(500, "UAH"), day, "")
+	if err = repo.SaveTransfer(withMockDb(ctx, &mockPgDb{}), transfer); err != nil {
+		t.Errorf("SaveTransfer error: %v", err)
+	}
+
+	if err = repo.RemoveTransfer(withMockDb(ctx, &mockPgDb{}), projectID, transfer.ID); err != nil {
+		t.Errorf("RemoveTransfer error: %v", err)
+	}
+
+	err = repo.RemoveTransfer(withMockDb(ctx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), projectID, transfer.ID)
+	if !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err = repo.RemoveTransfer(withMockDb(ctx, &mockPgDb{execErr: errors.New("db error")}), projectID, transfer.ID); err == nil {
+		t.Error("expected exec error")
+	}
+}
+
+func TestPgTotals(t *testing.T) {
+	ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	projectID := uuid.New()
+	day := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Payments", func(t *testing.T) {
+		repo := NewPgPaymentRepository(&PgDbConnection{})
+
+		if _, err := repo.Totals(context.Background(), projecta.PaymentTotalsFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected unauthorized error")
+		}
+
+		totals, err := repo.Totals(withMockDb(ctx, &mockPgDb{rowsData: [][]any{
+			{"UAH", day, int64(3910), 2},
+			{"USD", day, int64(100), 1},
+		}}), projecta.PaymentTotalsFilter{ProjectID: projectID, From: day, To: day.AddDate(0, 1, 0)})
+		if err != nil || len(totals) != 2 {
+			t.Fatalf("unexpected totals %v, %v", totals, err)
+		}
+		if totals[0].Amount.Amount() != 3910 || totals[0].Count != 2 || totals[0].Category != nil || totals[0].Owner != nil {
+			t.Errorf("unexpected booked subtotal %+v", totals[0])
+		}
+		if totals[1].Amount.Currency().Code != "USD" || !totals[1].Date.Equal(day) {
+			t.Errorf("unexpected subtotal %+v", totals[1])
+		}
+
+		categoryID, typeID := uuid.New(), uuid.New()
+		totals, err = repo.Totals(withMockDb(ctx, &mockPgDb{rowsData: [][]any{
+			{"EUR", day, categoryID.String(), "Materials", typeID.String(), "Bricks", "DOWN_PAYMENT", int64(500), 3},
+		}}), projecta.PaymentTotalsFilter{
+			ProjectID: projectID,
+			GroupBy:   []projecta.PaymentGrouping{projecta.GroupByCategory, projecta.GroupByType, projecta.GroupByKind},
+		})
+		if err != nil || len(totals) != 1 {
+			t.Fatalf("unexpected grouped totals %v, %v", totals, err)
+		}
+		grouped := totals[0]
+		if grouped.Category.ID != categoryID || grouped.Type.ID != typeID || grouped.Type.Category != grouped.Category || grouped.Kind != projecta.DownPayment || grouped.Amount.Amount() != 500 || grouped.Count != 3 {
+			t.Errorf("unexpected grouped subtotal %+v", grouped)
+		}
+
+		ownerID := uuid.New()
+		totals, err = repo.Totals(withMockDb(ctx, &mockPgDb{rowsData: [][]any{
+			{"UAH", day, ownerID.String(), "John", "J.D.", int64(700), 1},
+		}}), projecta.PaymentTotalsFilter{ProjectID: projectID, GroupBy: []projecta.PaymentGrouping{projecta.GroupByOwner}})
+		if err != nil || len(totals) != 1 || totals[0].Owner.PersonID != ownerID || totals[0].Owner.DisplayName != "J.D." || totals[0].Amount.Amount() != 700 {
+			t.Errorf("unexpected owner totals %v, %v", totals, err)
+		}
+
+		if _, err = repo.Totals(withMockDb(ctx, &mockPgDb{queryErr: errors.New("db error")}), projecta.PaymentTotalsFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+
+		paidDb, committedDb := &mockPgDb{}, &mockPgDb{}
+		if _, err = repo.Totals(withMockDb(ctx, paidDb), projecta.PaymentTotalsFilter{ProjectID: projectID}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(paidDb.queries[0], "projecta_payments.status = $") {
+			t.Errorf("expected paid payments only in %s", paidDb.queries[0])
+		}
+		if _, err = repo.Totals(withMockDb(ctx, committedDb), projecta.PaymentTotalsFilter{ProjectID: projectID, Committed: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(committedDb.queries[0], "projecta_payments.status IN ($") {
+			t.Errorf("expected committed payments in %s", committedDb.queries[0])
+		}
+	})
+
+	t.Run("Assets", func(t *testing.T) {
+		repo := NewPgAssetRepository(&PgDbConnection{})
+
+		totals, err := repo.Totals(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{"USD", day, int64(1500), 2}}}), asset.TotalsFilter{ProjectID: projectID, OwnerID: uuid.New()})
+		if err != nil || len(totals) != 1 || totals[0].Amount.Amount() != 1500 || totals[0].Amount.Currency().Code != "USD" || !totals[0].Date.Equal(day) || totals[0].Count != 2 {
+			t.Errorf("unexpected asset totals %v, %v", totals, err)
+		}
+
+		if _, err = repo.Totals(withMockDb(ctx, &mockPgDb{queryErr: errors.New("db error")}), asset.TotalsFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+	})
+}
+
+func TestPgExchangeRateRepository(t *testing.T) {
+	repo := NewPgExchangeRateRepository(&PgDbConnection{})
+	ctx := context.Background()
+	day := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	rates, err := repo.FindLatest(withMockDb(ctx, &mockPgDb{rowsData: [][]any{
+		{day, "USD", "UAH", 38.5},
+		{day, "EUR", "UAH", 41.25},
+	}}), "nbu", day)
+	if err != nil || !rates.Day.Equal(day) || rates.Base != "UAH" || rates.Source != "nbu" {
+		t.Fatalf("unexpected rates %+v, %v", rates, err)
+	}
+
+	if rates.Rates["USD"] != 38.5 || rates.Rates["EUR"] != 41.25 || rates.Rates["UAH"] != 1 {
+		t.Errorf("unexpected rates %v", rates.Rates)
+	}
+
+	if _, err = repo.FindLatest(withMockDb(ctx, &mockPgDb{}), "nbu", day); !errors.Is(err, exceptions.NotFoundError) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if _, err = repo.FindLatest(withMockDb(ctx, &mockPgDb{queryErr: errors.New("db error")}), "nbu", day); err == nil {
+		t.Error("expected query error")
+	}
+
+	if err = repo.Save(withMockDb(ctx, &mockPgDb{}), rates); err != nil {
+		t.Errorf("Save exchange rates error: %v", err)
+	}
+
+	if err = repo.Save(withMockDb(ctx, &mockPgDb{execErr: errors.New("db error")}), rates); err == nil {
+		t.Error("expected exec error")
+	}
+
+	empty := &currency.DailyRates{Source: "nbu", Base: "UAH", Day: day, Rates: map[string]float64{"UAH": 1}}
+	if err = repo.Save(withMockDb(ctx, &mockPgDb{execErr: errors.New("db error")}), empty); err != nil {
+		t.Errorf("expected nothing to be saved, got %v", err)
+	}
+}
+
+func TestPgStatementRepository(t *testing.T) {
+	repo := NewPgStatementRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	projectID := uuid.New()
+	lineID, paymentID, typeID, categoryID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	day := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	lineRow := []any{lineID.String(), projectID.String(), "monobank", "ext-1", day, int64(2500), "UAH", "Epicentr", "TOV Epicentr K", "BOOKED", paymentID.String()}
+	pendingRow := []any{uuid.New().String(), projectID.String(), "monobank", "ext-2", day, int64(100), "UAH", "", "", "PENDING", ""}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.FindLines(ctx, statement.LineCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected FindLines auth error")
+		}
+		if _, err := repo.FindLine(ctx, statement.LineFilter{ID: lineID, ProjectID: projectID}); err == nil {
+			t.Error("expected FindLine auth error")
+		}
+		if _, err := repo.FindRules(ctx, projectID); err == nil {
+			t.Error("expected FindRules auth error")
+		}
+	})
+
+	t.Run("FindLines", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{lineRow, pendingRow}}
+		lines, err := repo.FindLines(withMockDb(authedCtx, db), statement.LineCollectionFilter{ProjectID: projectID, Status: statement.LinePending})
+		if err != nil || lines.Total() != 1 || len(lines.Elements()) != 2 {
+			t.Fatalf("unexpected lines %v, %v", lines, err)
+		}
+		if l := lines.Elements()[0]; l.ID != lineID || l.PaymentID != paymentID || l.Status != statement.LineBooked || l.Amount.Amount() != 2500 || !l.Date.Equal(day) {
+			t.Errorf("unexpected line %+v", l)
+		}
+		if l := lines.Elements()[1]; l.PaymentID != uuid.Nil || l.Status != statement.LinePending {
+			t.Errorf("unexpected pending line %+v", l)
+		}
+		if !strings.Contains(db.queries[0], "projecta_statement_lines.status = $") {
+			t.Errorf("expected status filter in %s", db.queries[0])
+		}
+
+		lines, err = repo.FindLines(withMockDb(authedCtx, &mockPgDb{zeroTotal: true}), statement.LineCollectionFilter{ProjectID: projectID})
+		if err != nil || lines.Total() != 0 {
+			t.Errorf("unexpected empty lines %v, %v", lines, err)
+		}
+
+		if _, err = repo.FindLines(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), statement.LineCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected count error")
+		}
+		if _, err = repo.FindLines(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), statement.LineCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.FindLines(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}}), statement.LineCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected mapping error")
+		}
+	})
+
+	t.Run("FindLine", func(t *testing.T) {
+		line, err := repo.FindLine(withMockDb(authedCtx, &mockPgDb{rowVal: lineRow}), statement.LineFilter{ID: lineID, ProjectID: projectID})
+		if err != nil || line.ID != lineID || line.Counterparty != "TOV Epicentr K" {
+			t.Errorf("unexpected line %v, %v", line, err)
+		}
+
+		_, err = repo.FindLine(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), statement.LineFilter{ID: lineID, ProjectID: projectID})
+		if !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		if _, err = repo.FindLine(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), statement.LineFilter{ID: lineID, ProjectID: projectID}); err == nil {
+			t.Error("expected db error")
+		}
+	})
+
+	t.Run("FindExternalIDs", func(t *testing.T) {
+		ids, err := repo.FindExternalIDs(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"ext-1"}}}), projectID, []string{"ext-1", "ext-3"})
+		if err != nil || len(ids) != 1 || ids[0] != "ext-1" {
+			t.Errorf("unexpected ids %v, %v", ids, err)
+		}
+
+		db := &mockPgDb{}
+		if ids, err = repo.FindExternalIDs(withMockDb(authedCtx, db), projectID, nil); err != nil || len(ids) != 0 || len(db.queries) != 0 {
+			t.Errorf("expected no query for no ids, got %v, %v", ids, err)
+		}
+
+		if _, err = repo.FindExternalIDs(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projectID, []string{"ext-1"}); err == nil {
+			t.Error("expected query error")
+		}
+	})
+
+	t.Run("SaveLine", func(t *testing.T) {
+		line := statement.NewLine(lineID, projectID, "camt053", &statement.Transaction{ExternalID: "ext-1", Date: day, Amount: money.New(-2500, "UAH")})
+		if err := repo.SaveLine(withMockDb(authedCtx, &mockPgDb{}), line); err != nil {
+			t.Errorf("SaveLine pending error: %v", err)
+		}
+
+		line.Book(paymentID)
+		if err := repo.SaveLine(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), line); err == nil {
+			t.Error("expected exec error")
+		}
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		ruleID := uuid.New()
+		ruleRow := []any{ruleID.String(), "COUNTERPARTY", "epicentr", 10, typeID.String(), "Materials", "", categoryID.String(), "Renovation"}
+
+		rules, err := repo.FindRules(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{ruleRow}}), projectID)
+		if err != nil || len(rules) != 1 {
+			t.Fatalf("unexpected rules %v, %v", rules, err)
+		}
+		if r := rules[0]; r.ID != ruleID || r.Field != statement.MatchCounterparty || r.Priority != 10 || r.Type.ID != typeID || r.Type.Category.Name != "Renovation" {
+			t.Errorf("unexpected rule %+v", r)
+		}
+
+		if _, err = repo.FindRules(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projectID); err == nil {
+			t.Error("expected query error")
+		}
+
+		for _, row := range [][]any{
+			{"invalid", "ANY", "x", 0, typeID.String(), "", "", categoryID.String(), ""},
+			{ruleID.String(), "AMOUNT", "x", 0, typeID.String(), "", "", categoryID.String(), ""},
+			{ruleID.String(), "ANY", "(", 0, typeID.String(), "", "", categoryID.String(), ""},
+		} {
+			if _, err = repo.FindRules(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{row}}), projectID); err == nil {
+				t.Errorf("expected mapping error for %v", row)
+			}
+		}
+
+		if err = repo.SaveRule(withMockDb(authedCtx, &mockPgDb{}), rules[0]); err != nil {
+			t.Errorf("SaveRule error: %v", err)
+		}
+
+		if err = repo.RemoveRule(withMockDb(authedCtx, &mockPgDb{}), projectID, ruleID); err != nil {
+			t.Errorf("RemoveRule error: %v", err)
+		}
+
+		err = repo.RemoveRule(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), projectID, ruleID)
+		if !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		if err = repo.RemoveRule(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("db error")}), projectID, ruleID); err == nil {
+			t.Error("expected exec error")
+		}
+	})
+
+	t.Run("toStatementLine", func(t *testing.T) {
+		if _, err := toStatementLine(lineID.String(), "invalid", "", "", day, 1, "UAH", "", "", "PENDING", ""); err == nil {
+			t.Error("expected project id parse error")
+		}
+		if _, err := toStatementLine(lineID.String(), projectID.String(), "", "", day, 1, "UAH", "", "", "LOST", ""); err == nil {
+			t.Error("expected status error")
+		}
+		if _, err := toStatementLine(lineID.String(), projectID.String(), "", "", day, 1, "UAH", "", "", "BOOKED", "invalid"); err == nil {
+			t.Error("expected payment id parse error")
+		}
+	})
+}
+
+func TestPgRecurringPaymentRepository(t *testing.T) {
+	repo := NewPgRecurringPaymentRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	recurringID, projectID, ownerID, typeID, categoryID, paymentID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	row := []any{recurringID.String(), projectID.String(), "Project", "UAH", ownerID.String(), "John", "Johnny", typeID.String(), "Rent", categoryID.String(), "Services", "Flat", int64(1500000), "UAH", "UPON_COMPLETION", "FREQ=MONTHLY;INTERVAL=1", start, end, 2}
+	openEnded := []any{uuid.New().String(), projectID.String(), "Project", "UAH", ownerID.String(), "John", "", typeID.String(), "Rent", categoryID.String(), "Services", "", int64(100), "USD", "DOWN_PAYMENT", "FREQ=WEEKLY;INTERVAL=2;COUNT=3", start, nil, 0}
+
+	invalidRow := func(i int, value any) []any {
+		r := append([]any{}, row...)
+		r[i] = value
+		return r
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, projecta.RecurringPaymentCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.FindOne(ctx, projecta.RecurringPaymentFilter{RecurringID: recurringID, ProjectID: projectID}); err == nil {
+			t.Error("expected FindOne auth error")
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		p, err := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.RecurringPaymentFilter{RecurringID: recurringID, ProjectID: projectID})
+		if err != nil || p.ID != recurringID || p.Project.MainCurrency != "UAH" || p.Owner.DisplayName != "Johnny" || p.Type.Category.ID != categoryID || p.Amount.Amount() != 1500000 || p.Processed != 2 || !p.EndDate.Equal(end) {
+			t.Fatalf("unexpected recurring payment %+v, %v", p, err)
+		}
+		if p.Recurrence != (projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}) || p.Kind != projecta.UponCompletionPayment {
+			t.Errorf("unexpected recurrence %v, kind %v", p.Recurrence, p.Kind)
+		}
+
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), projecta.RecurringPaymentFilter{RecurringID: recurringID}); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), projecta.RecurringPaymentFilter{RecurringID: recurringID}); err == nil {
+			t.Error("expected FindOne db error")
+		}
+
+		for i, value := range map[int]any{0: "invalid", 1: "invalid", 4: "invalid", 14: "LATER", 15: "FREQ=HOURLY"} {
+			if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: invalidRow(i, value)}), projecta.RecurringPaymentFilter{RecurringID: recurringID}); err == nil {
+				t.Errorf("expected mapping error for column %d", i)
+			}
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{row, openEnded}}
+		col, err := repo.Find(withMockDb(authedCtx, db), projecta.RecurringPaymentCollectionFilter{ProjectID: projectID})
+		if err != nil || col.Total() != 1 || len(col.Elements()) != 2 {
+			t.Fatalf("unexpected recurring payments %v, %v", col, err)
+		}
+		if p := col.Elements()[1]; !p.EndDate.IsZero() || p.Recurrence.Count != 3 || p.Owner.DisplayName != "" {
+			t.Errorf("unexpected open ended recurring payment %+v", p)
+		}
+		if !strings.Contains(db.queries[1], "projecta_project_shares") {
+			t.Errorf("expected access check in %s", db.queries[1])
+		}
+
+		if col, err = repo.Find(withMockDb(authedCtx, &mockPgDb{zeroTotal: true}), projecta.RecurringPaymentCollectionFilter{ProjectID: projectID}); err != nil || col.Total() != 0 {
+			t.Errorf("unexpected empty recurring payments %v, %v", col, err)
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), projecta.RecurringPaymentCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected count error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projecta.RecurringPaymentCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}}), projecta.RecurringPaymentCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected mapping error")
+		}
+	})
+
+	t.Run("FindDue", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{row}}
+		due, err := repo.FindDue(withMockDb(context.Background(), db), time.Now())
+		if err != nil || len(due) != 1 || due[0].ID != recurringID {
+			t.Errorf("unexpected due recurring payments %v, %v", due, err)
+		}
+		if !strings.Contains(db.queries[0], "next_date <= $") || !strings.Contains(db.queries[0], "archived_at IS NULL") {
+			t.Errorf("unexpected due query %s", db.queries[0])
+		}
+
+		if _, err = repo.FindDue(withMockDb(context.Background(), &mockPgDb{queryErr: errors.New("query error")}), time.Now()); err == nil {
+			t.Error("expected query error")
+		}
+	})
+
+	t.Run("Save and Remove", func(t *testing.T) {
+		p, _ := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.RecurringPaymentFilter{RecurringID: recurringID})
+
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{}), p); err != nil {
+			t.Errorf("unexpected Save error: %v", err)
+		}
+
+		p.Processed = 100
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), p); err == nil {
+			t.Error("expected Save exec error")
+		}
+
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{}), p); err != nil {
+			t.Errorf("unexpected Remove error: %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), p); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected Remove not found error, got %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), p); err == nil {
+			t.Error("expected Remove exec error")
+		}
+	})
+
+	t.Run("occurrences", func(t *testing.T) {
+		paidOn := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+		db := &mockPgDb{rowsData: [][]any{
+			{start, "BOOKED", int64(1600000), "UAH", "Rent and utilities", paidOn, paymentID.String()},
+			{end, "SKIPPED", nil, nil, "", nil, ""},
+		}}
+
+		occurrences, err := repo.FindOccurrences(withMockDb(authedCtx, db), recurringID, start)
+		if err != nil || len(occurrences) != 2 {
+			t.Fatalf("unexpected occurrences %v, %v", occurrences, err)
+		}
+		if o := occurrences[0]; o.RecurringID != recurringID || o.Status != projecta.OccurrenceBooked || o.Amount.Amount() != 1600000 || !o.PaymentDate.Equal(paidOn) || o.PaymentID != paymentID {
+			t.Errorf("unexpected booked occurrence %+v", o)
+		}
+		if o := occurrences[1]; o.Amount != nil || !o.PaymentDate.IsZero() || o.PaymentID != uuid.Nil {
+			t.Errorf("unexpected skipped occurrence %+v", o)
+		}
+
+		if _, err = repo.FindOccurrences(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), recurringID, start); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.FindOccurrences(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{start, "LOST"}}}), recurringID, start); err == nil {
+			t.Error("expected status mapping error")
+		}
+		if _, err = repo.FindOccurrences(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{start, "BOOKED", nil, nil, "", nil, "invalid"}}}), recurringID, start); err == nil {
+			t.Error("expected payment id mapping error")
+		}
+
+		booked := occurrences[0]
+		if err = repo.SaveOccurrence(withMockDb(authedCtx, &mockPgDb{}), booked); err != nil {
+			t.Errorf("unexpected SaveOccurrence error: %v", err)
+		}
+		if err = repo.SaveOccurrence(withMockDb(authedCtx, &mockPgDb{}), occurrences[1]); err != nil {
+			t.Errorf("unexpected SaveOccurrence error: %v", err)
+		}
+		var exception exceptions.Exception
+		if err = repo.SaveOccurrence(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("INSERT 0 0")}), booked); !errors.As(err, &exception) || exception.Code != exceptions.ValidationFailed {
+			t.Errorf("expected validation error for a booked occurrence, got %v", err)
+		}
+		if err = repo.SaveOccurrence(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), booked); err == nil {
+			t.Error("expected SaveOccurrence exec error")
+		}
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		db := &mockPgDb{rowVal: []any{true}}
+		if locked, err := repo.Lock(withMockDb(authedCtx, db), recurringID); err != nil || !locked {
+			t.Errorf("unexpected lock %v, %v", locked, err)
+		}
+		if !strings.Contains(db.queries[0], "pg_try_advisory_xact_lock") {
+			t.Errorf("unexpected lock query %s", db.queries[0])
+		}
+
+		if locked, err := repo.Lock(withMockDb(authedCtx, &mockPgDb{rowVal: []any{false}}), recurringID); err != nil || locked {
+			t.Errorf("expected the lock to be held elsewhere, got %v, %v", locked, err)
+		}
+		if _, err := repo.Lock(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), recurringID); err == nil {
+			t.Error("expected Lock error")
+		}
+	})
+}
+
+func TestPgLoanRepository(t *testing.T) {
+	repo := NewPgLoanRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	loanID, projectID, paymentID := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	row := []any{loanID.String(), projectID.String(), "Bank loan", int64(1200000), "UAH", 12.5, 12, "ANNUITY", start}
+
+	invalidRow := func(i int, value any) []any {
+		r := append([]any{}, row...)
+		r[i] = value
+		return r
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, projecta.LoanCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.FindOne(ctx, projecta.LoanFilter{LoanID: loanID, ProjectID: projectID}); err == nil {
+			t.Error("expected FindOne auth error")
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		l, err := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.LoanFilter{LoanID: loanID, ProjectID: projectID})
+		if err != nil || l.ID != loanID || l.ProjectID != projectID || l.Principal.Amount() != 1200000 || l.AnnualRate != 12.5 || l.Term != 12 || l.Schedule != projecta.AnnuitySchedule || !l.StartDate.Equal(start) {
+			t.Fatalf("unexpected loan %+v, %v", l, err)
+		}
+
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), projecta.LoanFilter{LoanID: loanID}); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), projecta.LoanFilter{LoanID: loanID}); err == nil {
+			t.Error("expected FindOne db error")
+		}
+
+		for i, value := range map[int]any{0: "invalid", 1: "invalid", 6: 0, 7: "BALLOON"} {
+			if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: invalidRow(i, value)}), projecta.LoanFilter{LoanID: loanID}); err == nil {
+				t.Errorf("expected mapping error for column %d", i)
+			}
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{row}}
+		col, err := repo.Find(withMockDb(authedCtx, db), projecta.LoanCollectionFilter{ProjectID: projectID})
+		if err != nil || col.Total() != 1 || len(col.Elements()) != 1 || col.Elements()[0].ID != loanID {
+			t.Fatalf("unexpected loans %v, %v", col, err)
+		}
+		if !strings.Contains(db.queries[1], "projecta_project_shares") {
+			t.Errorf("expected access check in %s", db.queries[1])
+		}
+
+		if col, err = repo.Find(withMockDb(authedCtx, &mockPgDb{zeroTotal: true}), projecta.LoanCollectionFilter{ProjectID: projectID}); err != nil || col.Total() != 0 {
+			t.Errorf("unexpected empty loans %v, %v", col, err)
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), projecta.LoanCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected count error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projecta.LoanCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}}), projecta.LoanCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected mapping error")
+		}
+	})
+
+	t.Run("Save and Remove", func(t *testing.T) {
+		l, _ := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.LoanFilter{LoanID: loanID})
+
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{}), l); err != nil {
+			t.Errorf("unexpected Save error: %v", err)
+		}
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), l); err == nil {
+			t.Error("expected Save exec error")
+		}
+
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{}), l); err != nil {
+			t.Errorf("unexpected Remove error: %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), l); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected Remove not found error, got %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), l); err == nil {
+			t.Error("expected Remove exec error")
+		}
+	})
+
+	t.Run("payments", func(t *testing.T) {
+		paidOn := time.Date(2026, time.February, 14, 0, 0, 0, 0, time.UTC)
+		db := &mockPgDb{rowsData: [][]any{{paymentID.String(), 1, int64(112000), "UAH", paidOn}}}
+		payments, err := repo.FindPayments(withMockDb(authedCtx, db), loanID)
+		if err != nil || len(payments) != 1 || payments[0].PaymentID != paymentID || payments[0].LoanID != loanID || payments[0].Installment != 1 || payments[0].Amount.Amount() != 112000 || !payments[0].Date.Equal(paidOn) {
+			t.Fatalf("unexpected loan payments %v, %v", payments, err)
+		}
+		if !strings.Contains(db.queries[0], "projecta_payments.status = $") {
+			t.Errorf("expected paid payments only in %s", db.queries[0])
+		}
+
+		if _, err = repo.FindPayments(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), loanID); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.FindPayments(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid", 1, int64(1), "UAH", paidOn}}}), loanID); err == nil {
+			t.Error("expected mapping error")
+		}
+
+		lp := payments[0]
+		if err = repo.SavePayment(withMockDb(authedCtx, &mockPgDb{}), lp); err != nil {
+			t.Errorf("unexpected SavePayment error: %v", err)
+		}
+		if err = repo.SavePayment(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), lp); err == nil {
+			t.Error("expected SavePayment exec error")
+		}
+
+		if err = repo.RemovePayment(withMockDb(authedCtx, &mockPgDb{}), loanID, paymentID); err != nil {
+			t.Errorf("unexpected RemovePayment error: %v", err)
+		}
+		if err = repo.RemovePayment(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), loanID, paymentID); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected RemovePayment not found error, got %v", err)
+		}
+		if err = repo.RemovePayment(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), loanID, paymentID); err == nil {
+			t.Error("expected RemovePayment exec error")
+		}
+	})
+}
+
+func TestPgVendorRepository(t *testing.T) {
+	repo := NewPgVendorRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	vendorID, ownerID, projectID, paymentID, assetID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	row := []any{vendorID.String(), ownerID.String(), projectID.String(), "Electrician", "1234567890", "Taras", "taras@example.com", "+380501234567", "UA213223130000026007233566001"}
+
+	invalidRow := func(i int, value any) []any {
+		r := append([]any{}, row...)
+		r[i] = value
+		return r
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, projecta.VendorCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.FindOne(ctx, projecta.VendorFilter{VendorID: vendorID, ProjectID: projectID}); err == nil {
+			t.Error("expected FindOne auth error")
+		}
+		if _, err := repo.Totals(ctx, vendorID); err == nil {
+			t.Error("expected Totals auth error")
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		v, err := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.VendorFilter{VendorID: vendorID, ProjectID: projectID})
+		if err != nil || v.ID != vendorID || v.OwnerID != ownerID || v.ProjectID != projectID || v.Name != "Electrician" || v.TaxID != "1234567890" || v.Email != "taras@example.com" || v.IBAN != "UA213223130000026007233566001" {
+			t.Fatalf("unexpected vendor %+v, %v", v, err)
+		}
+
+		shared, err := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: invalidRow(2, "")}), projecta.VendorFilter{VendorID: vendorID, ProjectID: projectID})
+		if err != nil || !shared.IsShared() {
+			t.Errorf("expected shared vendor, got %+v, %v", shared, err)
+		}
+
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), projecta.VendorFilter{VendorID: vendorID}); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), projecta.VendorFilter{VendorID: vendorID}); err == nil {
+			t.Error("expected FindOne db error")
+		}
+
+		for i, value := range map[int]any{0: "invalid", 1: "invalid", 2: "invalid", 3: " ", 8: "UA00"} {
+			if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: invalidRow(i, value)}), projecta.VendorFilter{VendorID: vendorID}); err == nil {
+				t.Errorf("expected mapping error for column %d", i)
+			}
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{row}}
+		col, err := repo.Find(withMockDb(authedCtx, db), projecta.VendorCollectionFilter{ProjectID: projectID, Name: "elec"})
+		if err != nil || col.Total() != 1 || len(col.Elements()) != 1 || col.Elements()[0].ID != vendorID {
+			t.Fatalf("unexpected vendors %v, %v", col, err)
+		}
+		for _, clause := range []string{"projecta_project_shares", "projecta_vendors.project_id IS NULL", "projecta_vendors.name ILIKE $"} {
+			if !strings.Contains(db.queries[1], clause) {
+				t.Errorf("expected %q in %s", clause, db.queries[1])
+			}
+		}
+
+		if col, err = repo.Find(withMockDb(authedCtx, &mockPgDb{zeroTotal: true}), projecta.VendorCollectionFilter{ProjectID: projectID}); err != nil || col.Total() != 0 {
+			t.Errorf("unexpected empty vendors %v, %v", col, err)
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), projecta.VendorCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected count error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projecta.VendorCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}}), projecta.VendorCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected mapping error")
+		}
+	})
+
+	t.Run("Save and Remove", func(t *testing.T) {
+		v, _ := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.VendorFilter{VendorID: vendorID})
+		shared, _ := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: invalidRow(2, "")}), projecta.VendorFilter{VendorID: vendorID})
+
+		for _, vendor := range []*projecta.Vendor{v, shared} {
+			if err := repo.Save(withMockDb(authedCtx, &mockPgDb{}), vendor); err != nil {
+				t.Errorf("unexpected Save error: %v", err)
+			}
+		}
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), v); err == nil {
+			t.Error("expected Save exec error")
+		}
+
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{}), v); err != nil {
+			t.Errorf("unexpected Remove error: %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), v); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected Remove not found error, got %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), v); err == nil {
+			t.Error("expected Remove exec error")
+		}
+	})
+
+	t.Run("contracts", func(t *testing.T) {
+		projectID := uuid.New()
+		db := &mockPgDb{}
+		count, err := repo.CountContracts(withMockDb(authedCtx, db), vendorID, projectID)
+		if err != nil || count != 1 {
+			t.Errorf("expected 1 contract, got %d, %v", count, err)
+		}
+		if !strings.Contains(db.queries[0], "project_id <> $2") {
+			t.Errorf("expected the other projects to be counted, got %s", db.queries[0])
+		}
+		if _, err := repo.CountContracts(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), vendorID, projectID); err == nil {
+			t.Error("expected CountContracts row error")
+		}
+
+		if err := repo.RemoveContracts(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), vendorID, projectID); err != nil {
+			t.Errorf("unexpected RemoveContracts error: %v", err)
+		}
+		if err := repo.RemoveContracts(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), vendorID, projectID); err == nil {
+			t.Error("expected RemoveContracts exec error")
+		}
+	})
+
+	t.Run("links", func(t *testing.T) {
+		for name, link := range map[string]func(context.Context) error{
+			"link payment":   func(ctx context.Context) error { return repo.LinkPayment(ctx, vendorID, projectID, paymentID) },
+			"unlink payment": func(ctx context.Context) error { return repo.UnlinkPayment(ctx, vendorID, projectID, paymentID) },
+			"link asset":     func(ctx context.Context) error { return repo.LinkAsset(ctx, vendorID, projectID, assetID) },
+			"unlink asset":   func(ctx context.Context) error { return repo.UnlinkAsset(ctx, vendorID, projectID, assetID) },
+		} {
+			if err := link(withMockDb(authedCtx, &mockPgDb{})); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+			if err := link(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("UPDATE 0")})); !errors.Is(err, exceptions.NotFoundError) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+			if err := link(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")})); err == nil || errors.Is(err, exceptions.NotFoundError) {
+				t.Errorf("%s: expected exec error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Totals", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{
+			{"PAID", "UAH", int64(500000), 2},
+			{"", "UAH", int64(120000), 1},
+		}}
+		totals, err := repo.Totals(withMockDb(authedCtx, db), vendorID)
+		if err != nil || len(totals) != 2 || totals[0].Status != projecta.PaymentPaid || totals[0].Amount.Amount() != 500000 || totals[0].Count != 2 || totals[1].Status != "" {
+			t.Fatalf("unexpected totals %v, %v", totals, err)
+		}
+		for _, clause := range []string{"UNION ALL", "projecta_payments.vendor_id = $", "projecta_assets.vendor_id = $", "status::TEXT"} {
+			if !strings.Contains(db.queries[0], clause) {
+				t.Errorf("expected %q in %s", clause, db.queries[0])
+			}
+		}
+
+		if _, err = repo.Totals(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), vendorID); err == nil {
+			t.Error("expected query error")
+		}
+	})
+}
+
+func TestPgContractRepository(t *testing.T) {
+	repo := NewPgContractRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	contractID, projectID, vendorID, paymentID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	signed := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	row := []any{contractID.String(), projectID.String(), vendorID.String(), "Wiring", "UAH", int64(300000), int64(700000), signed}
+
+	invalidRow := func(i int, value any) []any {
+		r := append([]any{}, row...)
+		r[i] = value
+		return r
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, projecta.ContractCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.FindOne(ctx, projecta.ContractFilter{ContractID: contractID, ProjectID: projectID}); err == nil {
+			t.Error("expected FindOne auth error")
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		c, err := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.ContractFilter{ContractID: contractID, ProjectID: projectID})
+		if err != nil || c.ID != contractID || c.ProjectID != projectID || c.VendorID != vendorID || c.Title != "Wiring" || c.Total.Amount() != 1000000 || len(c.Stages) != 2 || !c.SignedAt.Equal(signed) {
+			t.Fatalf("unexpected contract %+v, %v", c, err)
+		}
+
+		// a contract paid in full upon completion stores no down payment
+		unsigned := invalidRow(5, int64(0))
+		unsigned[7] = nil
+		c, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: unsigned}), projecta.ContractFilter{ContractID: contractID})
+		if err != nil || len(c.Stages) != 1 || c.Stages[0].Kind != projecta.UponCompletionPayment || c.Total.Amount() != 700000 || !c.SignedAt.IsZero() {
+			t.Errorf("unexpected contract %+v, %v", c, err)
+		}
+
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), projecta.ContractFilter{ContractID: contractID}); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), projecta.ContractFilter{ContractID: contractID}); err == nil {
+			t.Error("expected FindOne db error")
+		}
+
+		for i, value := range map[int]any{0: "invalid", 1: "invalid", 2: "invalid", 3: " "} {
+			if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: invalidRow(i, value)}), projecta.ContractFilter{ContractID: contractID}); err == nil {
+				t.Errorf("expected mapping error for column %d", i)
+			}
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{row}}
+		col, err := repo.Find(withMockDb(authedCtx, db), projecta.ContractCollectionFilter{ProjectID: projectID, VendorID: vendorID})
+		if err != nil || col.Total() != 1 || len(col.Elements()) != 1 || col.Elements()[0].ID != contractID {
+			t.Fatalf("unexpected contracts %v, %v", col, err)
+		}
+		for _, clause := range []string{"projecta_project_shares", "projecta_contracts.vendor_id = $"} {
+			if !strings.Contains(db.queries[1], clause) {
+				t.Errorf("expected %q in %s", clause, db.queries[1])
+			}
+		}
+
+		if col, err = repo.Find(withMockDb(authedCtx, &mockPgDb{zeroTotal: true}), projecta.ContractCollectionFilter{ProjectID: projectID}); err != nil || col.Total() != 0 {
+			t.Errorf("unexpected empty contracts %v, %v", col, err)
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), projecta.ContractCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected count error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projecta.ContractCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}}), projecta.ContractCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected mapping error")
+		}
+	})
+
+	t.Run("Save and Remove", func(t *testing.T) {
+		c, _ := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.ContractFilter{ContractID: contractID})
+		unsigned, _ := projecta.NewContract(contractID, projectID, vendorID, "Wiring", money.New(100, "UAH"), nil, time.Time{})
+
+		for _, contract := range []*projecta.Contract{c, unsigned} {
+			if err := repo.Save(withMockDb(authedCtx, &mockPgDb{}), contract); err != nil {
+				t.Errorf("unexpected Save error: %v", err)
+			}
+		}
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), c); err == nil {
+			t.Error("expected Save exec error")
+		}
+
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{}), c); err != nil {
+			t.Errorf("unexpected Remove error: %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), c); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected Remove not found error, got %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), c); err == nil {
+			t.Error("expected Remove exec error")
+		}
+	})
+
+	t.Run("payments", func(t *testing.T) {
+		paidOn := time.Date(2026, time.February, 14, 0, 0, 0, 0, time.UTC)
+		db := &mockPgDb{rowsData: [][]any{{paymentID.String(), "DOWN_PAYMENT", "PAID", int64(300000), "UAH", paidOn}}}
+		payments, err := repo.FindPayments(withMockDb(authedCtx, db), contractID)
+		if err != nil || len(payments) != 1 || payments[0].PaymentID != paymentID || payments[0].ContractID != contractID || payments[0].Kind != projecta.DownPayment || payments[0].Status != projecta.PaymentPaid || payments[0].Amount.Amount() != 300000 || !payments[0].Date.Equal(paidOn) {
+			t.Fatalf("unexpected contract payments %v, %v", payments, err)
+		}
+		if !strings.Contains(db.queries[0], "projecta_payments.contract_id = $") {
+			t.Errorf("expected contract filter in %s", db.queries[0])
+		}
+
+		if _, err = repo.FindPayments(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), contractID); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.FindPayments(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid", "DOWN_PAYMENT", "PAID", int64(1), "UAH", paidOn}}}), contractID); err == nil {
+			t.Error("expected mapping error")
+		}
+
+		c, _ := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row}), projecta.ContractFilter{ContractID: contractID})
+		for name, link := range map[string]func(context.Context) error{
+			"link":   func(ctx context.Context) error { return repo.LinkPayment(ctx, c, paymentID) },
+			"unlink": func(ctx context.Context) error { return repo.UnlinkPayment(ctx, contractID, paymentID) },
+		} {
+			if err = link(withMockDb(authedCtx, &mockPgDb{})); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+			if err = link(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("UPDATE 0")})); !errors.Is(err, exceptions.NotFoundError) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+			if err = link(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")})); err == nil || errors.Is(err, exceptions.NotFoundError) {
+				t.Errorf("%s: expected exec error, got %v", name, err)
+			}
+		}
+	})
+}
+
+// sequencedPgDb answers the successive queries with their own rows and fails
+// the exec number failExec, counting from 1.
+type sequencedPgDb struct {
+	mockPgDb
+	results  [][][]any
+	failExec int
+	execs    int
+}
+
+func (m *sequencedPgDb) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	m.queries = append(m.queries, sql)
+	if len(m.results) == 0 {
+		return &mockRows{}, nil
+	}
+	data := m.results[0]
+	m.results = m.results[1:]
+	return &mockRows{data: data}, nil
+}
+
+func (m *sequencedPgDb) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	m.execs++
+	if m.execs == m.failExec {
+		return pgconn.CommandTag{}, errors.New("exec error")
+	}
+	m.queries = append(m.queries, sql)
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func TestPgEstimateRepository(t *testing.T) {
+	repo := NewPgEstimateRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	estimateID, projectID, quoteID, otherQuoteID, vendorID, typeID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	row := []any{estimateID.String(), projectID.String(), "Roof", quoteID.String()}
+	item := func(quoteID uuid.UUID, description string, quantity float64, unitPrice int64) []any {
+		return []any{quoteID.String(), estimateID.String(), vendorID.String(), "valid a month", typeID.String(), description, quantity, unitPrice, "UAH"}
+	}
+	items := [][]any{item(quoteID, "Tiles", 12.5, 40000), item(quoteID, "Labour", 1, 150000), item(otherQuoteID, "", 1, 600000)}
+
+	invalidItem := func(i int, value any) [][]any {
+		r := item(quoteID, "Tiles", 1, 1)
+		r[i] = value
+		return [][]any{r}
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, projecta.EstimateCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.FindOne(ctx, projecta.EstimateFilter{EstimateID: estimateID, ProjectID: projectID}); err == nil {
+			t.Error("expected FindOne auth error")
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		db := &sequencedPgDb{mockPgDb: mockPgDb{rowVal: row}, results: [][][]any{items}}
+		e, err := repo.FindOne(withMockDb(authedCtx, db), projecta.EstimateFilter{EstimateID: estimateID, ProjectID: projectID})
+		if err != nil || e.ID != estimateID || e.ProjectID != projectID || e.Title != "Roof" || e.AcceptedQuoteID != quoteID || len(e.Quotes) != 2 {
+			t.Fatalf("unexpected estimate %+v, %v", e, err)
+		}
+		if q := e.Quotes[0]; q.ID != quoteID || q.VendorID != vendorID || q.Note != "valid a month" || len(q.Items) != 2 || q.Items[0].Quantity != 12.5 || q.Totals()[0].Amount() != 650000 {
+			t.Errorf("unexpected quote %+v", q)
+		}
+		if !strings.Contains(db.queries[1], "projecta_quotes.estimate_id IN ($") {
+			t.Errorf("expected estimate filter in %s", db.queries[1])
+		}
+
+		open := append([]any{}, row...)
+		open[3] = ""
+		if e, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: open}), projecta.EstimateFilter{EstimateID: estimateID}); err != nil || e.IsAccepted() || len(e.Quotes) != 0 {
+			t.Errorf("unexpected open estimate %+v, %v", e, err)
+		}
+
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), projecta.EstimateFilter{EstimateID: estimateID}); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), projecta.EstimateFilter{EstimateID: estimateID}); err == nil {
+			t.Error("expected FindOne db error")
+		}
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: row, queryErr: errors.New("query error")}), projecta.EstimateFilter{EstimateID: estimateID}); err == nil {
+			t.Error("expected quotes query error")
+		}
+
+		for i, value := range map[int]any{0: "invalid", 1: "invalid", 2: " ", 3: "invalid"} {
+			r := append([]any{}, row...)
+			r[i] = value
+			if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: r}), projecta.EstimateFilter{EstimateID: estimateID}); err == nil {
+				t.Errorf("expected mapping error for column %d", i)
+			}
+		}
+
+		for name, rows := range map[string][][]any{
+			"quote id":         invalidItem(0, "invalid"),
+			"estimate id":      invalidItem(1, "invalid"),
+			"vendor id":        invalidItem(2, "invalid"),
+			"type id":          invalidItem(4, "invalid"),
+			"unknown estimate": invalidItem(1, uuid.New().String()),
+		} {
+			db := &sequencedPgDb{mockPgDb: mockPgDb{rowVal: row}, results: [][][]any{rows}}
+			if _, err = repo.FindOne(withMockDb(authedCtx, db), projecta.EstimateFilter{EstimateID: estimateID}); err == nil {
+				t.Errorf("expected %s mapping error", name)
+			}
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &sequencedPgDb{results: [][][]any{{row}, items}}
+		col, err := repo.Find(withMockDb(authedCtx, db), projecta.EstimateCollectionFilter{ProjectID: projectID})
+		if err != nil || col.Total() != 1 || len(col.Elements()) != 1 || len(col.Elements()[0].Quotes) != 2 {
+			t.Fatalf("unexpected estimates %v, %v", col, err)
+		}
+		if !strings.Contains(db.queries[1], "projecta_project_shares") {
+			t.Errorf("expected access check in %s", db.queries[1])
+		}
+
+		if col, err = repo.Find(withMockDb(authedCtx, &mockPgDb{zeroTotal: true}), projecta.EstimateCollectionFilter{ProjectID: projectID}); err != nil || col.Total() != 0 {
+			t.Errorf("unexpected empty estimates %v, %v", col, err)
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{countErr: errors.New("count error")}), projecta.EstimateCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected count error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), projecta.EstimateCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{{"invalid"}}}), projecta.EstimateCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected mapping error")
+		}
+		if _, err = repo.Find(withMockDb(authedCtx, &sequencedPgDb{results: [][][]any{{row}, invalidItem(0, "invalid")}}), projecta.EstimateCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected quotes mapping error")
+		}
+	})
+
+	t.Run("Save and Remove", func(t *testing.T) {
+		e, _ := projecta.NewEstimate(estimateID, projectID, "Roof")
+
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{}), e); err != nil {
+			t.Errorf("unexpected Save error: %v", err)
+		}
+		e.AcceptedQuoteID = quoteID
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{}), e); err != nil {
+			t.Errorf("unexpected Save error: %v", err)
+		}
+		if err := repo.Save(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("exec error")}), e); err == nil {
+			t.Error("expected Save exec error")
+		}
+
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{}), e); err != nil {
+			t.Errorf("unexpected Remove error: %v", err)
+		}
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), e); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected Remove not found error, got %v", err)
+		}
+	})
+
+	t.Run("quotes", func(t *testing.T) {
+		q, _ := projecta.NewQuote(quoteID, estimateID, vendorID, "", []*projecta.EstimateItem{
+			{TypeID: typeID, Quantity: 2, UnitPrice: money.New(100, "UAH")},
+			{TypeID: typeID, Quantity: 1, UnitPrice: money.New(50, "USD")},
+		})
+
+		db := &sequencedPgDb{}
+		if err := repo.SaveQuote(withMockDb(authedCtx, db), q); err != nil || len(db.queries) != 3 {
+			t.Fatalf("unexpected SaveQuote error: %v", err)
+		}
+		if !strings.Contains(db.queries[1], "DELETE FROM projecta_quote_items") || !strings.Contains(db.queries[2], "($8, $9, $10, $11, $12, $13, $14)") {
+			t.Errorf("expected the items replaced, got %v", db.queries)
+		}
+		for failExec := 1; failExec <= 3; failExec++ {
+			if err := repo.SaveQuote(withMockDb(authedCtx, &sequencedPgDb{failExec: failExec}), q); err == nil {
+				t.Errorf("expected SaveQuote error on exec %d", failExec)
+			}
+		}
+
+		if err := repo.RemoveQuote(withMockDb(authedCtx, &mockPgDb{}), q); err != nil {
+			t.Errorf("unexpected RemoveQuote error: %v", err)
+		}
+		if err := repo.RemoveQuote(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), q); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected RemoveQuote not found error, got %v", err)
+		}
+	})
+}
+
+func TestPgAttachmentRepository(t *testing.T) {
+	repo := NewPgAttachmentRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	attachmentID, projectID, paymentID, assetID, uploadedBy := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	checksum := attachment.Checksum([]byte("receipt"))
+	uploadedAt := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	paymentRow := []any{attachmentID.String(), projectID.String(), paymentID.String(), "", "receipt.pdf", "application/pdf", int64(7), checksum, uploadedBy.String(), uploadedAt}
+	assetRow := []any{uuid.New().String(), projectID.String(), "", assetID.String(), "warranty.png", "image/png", int64(7), checksum, uploadedBy.String(), uploadedAt}
+
+	invalidRow := func(i int, value any) []any {
+		r := append([]any{}, paymentRow...)
+		r[i] = value
+		return r
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, attachment.CollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.FindOne(ctx, attachment.Filter{ProjectID: projectID, AttachmentID: attachmentID}); err == nil {
+			t.Error("expected FindOne auth error")
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{paymentRow, assetRow}}
+		attachments, err := repo.Find(withMockDb(authedCtx, db), attachment.CollectionFilter{ProjectID: projectID, Target: attachment.Target{Kind: attachment.AssetTarget, ID: assetID}, Checksum: checksum})
+		if err != nil || len(attachments) != 2 {
+			t.Fatalf("unexpected attachments %v, %v", attachments, err)
+		}
+		if a := attachments[0]; a.ID != attachmentID || a.Target != (attachment.Target{Kind: attachment.PaymentTarget, ID: paymentID}) || a.Size != 7 || a.UploadedBy != uploadedBy || !a.UploadedAt.Equal(uploadedAt) {
+			t.Errorf("unexpected payment attachment %+v", a)
+		}
+		if a := attachments[1]; a.Target != (attachment.Target{Kind: attachment.AssetTarget, ID: assetID}) || a.FileName != "warranty.png" {
+			t.Errorf("unexpected asset attachment %+v", a)
+		}
+		for _, want := range []string{"projecta_project_shares", "projecta_attachments.asset_id = $", "projecta_attachments.checksum = $"} {
+			if !strings.Contains(db.queries[0], want) {
+				t.Errorf("expected %q in %s", want, db.queries[0])
+			}
+		}
+
+		db = &mockPgDb{}
+		if _, err = repo.Find(withMockDb(authedCtx, db), attachment.CollectionFilter{ProjectID: projectID}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if strings.Contains(db.queries[0], "checksum = $") || strings.Contains(db.queries[0], "payment_id = $") {
+			t.Errorf("expected no filters in %s", db.queries[0])
+		}
+
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("query error")}), attachment.CollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+		for i, value := range map[int]any{0: "invalid", 1: "invalid", 2: "invalid", 8: "invalid", 5: "text/html"} {
+			if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{rowsData: [][]any{invalidRow(i, value)}}), attachment.CollectionFilter{ProjectID: projectID}); err == nil {
+				t.Errorf("expected mapping error of column %d", i)
+			}
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		a, err := repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowVal: paymentRow}), attachment.Filter{ProjectID: projectID, AttachmentID: attachmentID})
+		if err != nil || a.ID != attachmentID || a.Checksum != checksum {
+			t.Errorf("unexpected attachment %v, %v", a, err)
+		}
+
+		_, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), attachment.Filter{ProjectID: projectID, AttachmentID: attachmentID})
+		if !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+
+		if _, err = repo.FindOne(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), attachment.Filter{ProjectID: projectID, AttachmentID: attachmentID}); err == nil {
+			t.Error("expected db error")
+		}
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		a, _ := attachment.NewAttachment(attachmentID, projectID, attachment.Target{Kind: attachment.AssetTarget, ID: assetID}, "warranty.png", "image/png", 7, checksum, uploadedBy, uploadedAt)
+
+		db := &sequencedPgDb{}
+		if err := repo.Save(withMockDb(authedCtx, db), a); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if sql := db.queries[0]; !strings.Contains(sql, "INSERT INTO projecta_attachments (attachment_id, project_id, asset_id,") || !strings.Contains(sql, "SELECT $1::UUID") || !strings.Contains(sql, "FROM projecta_assets WHERE asset_id = $") {
+			t.Errorf("unexpected insert %s", sql)
+		}
+
+		err := repo.Save(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("INSERT 0 0")}), a)
+		if !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error for a target of another project, got %v", err)
+		}
+
+		if err = repo.Save(withMockDb(authedCtx, &mockPgDb{execErr: errors.New("db error")}), a); err == nil {
+			t.Error("expected exec error")
+		}
+
+		unknown := *a
+		unknown.Target.Kind = "EXPENSE"
+		if err = repo.Save(withMockDb(authedCtx, &mockPgDb{}), &unknown); err == nil {
+			t.Error("expected unknown target error")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		a := &attachment.Attachment{ID: attachmentID, ProjectID: projectID}
+
+		if err := repo.Remove(withMockDb(authedCtx, &mockPgDb{}), a); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		err := repo.Remove(withMockDb(authedCtx, &mockPgDb{execTag: pgconn.NewCommandTag("DELETE 0")}), a)
+		if !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("released files", func(t *testing.T) {
+		file := attachment.File{ProjectID: projectID, Checksum: checksum}
+		ctx := context.Background()
+
+		if err := repo.Lock(withMockDb(ctx, &mockPgDb{}), file); err != nil {
+			t.Errorf("unexpected Lock error %v", err)
+		}
+
+		db := &mockPgDb{}
+		if inUse, err := repo.InUse(withMockDb(ctx, db), file); err != nil || !inUse {
+			t.Errorf("expected the file to be in use, got %v", err)
+		}
+		if !strings.Contains(db.queries[0], "checksum = $") {
+			t.Errorf("expected the file to be looked up by checksum, got %s", db.queries[0])
+		}
+		if inUse, err := repo.InUse(withMockDb(ctx, &mockPgDb{isNotFound: true}), file); err != nil || inUse {
+			t.Errorf("expected the file to be unused, got %v", err)
+		}
+		if _, err := repo.InUse(withMockDb(ctx, &mockPgDb{rowErr: errors.New("db error")}), file); err == nil {
+			t.Error("expected InUse error")
+		}
+
+		files, err := repo.FindReleased(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{projectID.String(), checksum}}}))
+		if err != nil || len(files) != 1 || files[0] != file {
+			t.Errorf("unexpected released files %v, %v", files, err)
+		}
+		if _, err = repo.FindReleased(withMockDb(ctx, &mockPgDb{queryErr: errors.New("query error")})); err == nil {
+			t.Error("expected FindReleased query error")
+		}
+		if _, err = repo.FindReleased(withMockDb(ctx, &mockPgDb{rowsData: [][]any{{"invalid", checksum}}})); err == nil {
+			t.Error("expected FindReleased mapping error")
+		}
+
+		if err = repo.Forget(withMockDb(ctx, &mockPgDb{}), file); err != nil {
+			t.Errorf("unexpected Forget error %v", err)
+		}
+	})
+}
+
+func TestPgTagRepository(t *testing.T) {
+	repo := NewPgTagRepository(&PgDbConnection{})
+	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+	projectID, paymentID := uuid.New(), uuid.New()
+	day := time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		ctx := withMockDb(context.Background(), &mockPgDb{})
+
+		if _, err := repo.Find(ctx, projecta.TagCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Find auth error")
+		}
+		if _, err := repo.Totals(ctx, projecta.TagTotalsFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected Totals auth error")
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{{"kitchen", 3, 1}, {"kids room", 1, 0}}}
+		tags, err := repo.Find(withMockDb(authedCtx, db), projecta.TagCollectionFilter{ProjectID: projectID, Name: "ki", Limit: 5})
+		if err != nil || len(tags) != 2 || *tags[0] != (projecta.Tag{Name: "kitchen", Payments: 3, Assets: 1}) {
+			t.Fatalf("unexpected tags %v, %v", tags, err)
+		}
+		for _, want := range []string{"projecta_project_shares", "projecta_tags.name LIKE $", `ESCAPE '\'`, "> 0", "DESC", "LIMIT"} {
+			if !strings.Contains(db.queries[0], want) {
+				t.Errorf("expected %q in %s", want, db.queries[0])
+			}
+		}
+
+		db = &mockPgDb{}
+		if _, err = repo.Find(withMockDb(authedCtx, db), projecta.TagCollectionFilter{ProjectID: projectID}); err != nil || strings.Contains(db.queries[0], "LIKE") {
+			t.Errorf("expected no name filter in %s, %v", db.queries[0], err)
+		}
+
+		if _, err = repo.Find(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("db error")}), projecta.TagCollectionFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+
+		if pattern := likePrefix(`50%_off\`); pattern != `50\%\_off\\%` {
+			t.Errorf("expected the wildcards to be escaped, got %s", pattern)
+		}
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		db := &sequencedPgDb{mockPgDb: mockPgDb{rowVal: []any{1}}}
+		if err := repo.Save(withMockDb(authedCtx, db), projecta.TaggedPayment, projectID, paymentID, []string{"kitchen", "tax-deductible"}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(db.queries) != 4 {
+			t.Fatalf("expected lock, unlink, tags and links, got %v", db.queries)
+		}
+		for i, want := range []string{
+			"FOR UPDATE",
+			"DELETE FROM projecta_payment_tags",
+			"ON CONFLICT (project_id, name) DO NOTHING",
+			"INSERT INTO projecta_payment_tags (payment_id, tag_id) SELECT $1::UUID, tag_id FROM projecta_tags",
+		} {
+			if !strings.Contains(db.queries[i], want) {
+				t.Errorf("expected %q in %s", want, db.queries[i])
+			}
+		}
+
+		db = &sequencedPgDb{mockPgDb: mockPgDb{rowVal: []any{1}}}
+		if err := repo.Save(withMockDb(authedCtx, db), projecta.TaggedAsset, projectID, uuid.New(), nil); err != nil || len(db.queries) != 2 || !strings.Contains(db.queries[1], "DELETE FROM projecta_asset_tags") {
+			t.Errorf("expected the tags of the asset removed, got %v, %v", db.queries, err)
+		}
+
+		err := repo.Save(withMockDb(authedCtx, &mockPgDb{isNotFound: true}), projecta.TaggedAsset, projectID, uuid.New(), nil)
+		if !errors.Is(err, exceptions.NotFoundError) || !strings.Contains(err.Error(), "asset not found") {
+			t.Errorf("expected not found error, got %v", err)
+		}
+		if err = repo.Save(withMockDb(authedCtx, &mockPgDb{rowErr: errors.New("db error")}), projecta.TaggedPayment, projectID, paymentID, nil); err == nil || errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("expected db error, got %v", err)
+		}
+		if err = repo.Save(withMockDb(authedCtx, &mockPgDb{}), "expense", projectID, paymentID, nil); err == nil {
+			t.Error("expected unknown kind error")
+		}
+		for step := 1; step <= 3; step++ {
+			failing := &sequencedPgDb{mockPgDb: mockPgDb{rowVal: []any{1}}, failExec: step}
+			if err = repo.Save(withMockDb(authedCtx, failing), projecta.TaggedPayment, projectID, paymentID, []string{"kitchen"}); err == nil {
+				t.Errorf("expected exec error at step %d", step)
+			}
+		}
+	})
+
+	t.Run("Totals", func(t *testing.T) {
+		db := &mockPgDb{rowsData: [][]any{
+			{"kitchen", "payment", "UAH", day, int64(3910), 2},
+			{"kitchen", "asset", "USD", day, int64(500), 1},
+		}}
+		totals, err := repo.Totals(withMockDb(authedCtx, db), projecta.TagTotalsFilter{ProjectID: projectID, From: day, To: day.AddDate(0, 1, 0)})
+		if err != nil || len(totals) != 2 {
+			t.Fatalf("unexpected totals %v, %v", totals, err)
+		}
+		if s := totals[0]; s.Tag != "kitchen" || s.Kind != projecta.TaggedPayment || s.Amount.Amount() != 3910 || !s.Date.Equal(day) || s.Count != 2 {
+			t.Errorf("unexpected payment subtotal %+v", s)
+		}
+		if s := totals[1]; s.Kind != projecta.TaggedAsset || s.Amount.Currency().Code != "USD" {
+			t.Errorf("unexpected asset subtotal %+v", s)
+		}
+		for _, want := range []string{"UNION ALL", "projecta_payments.status = $", "projecta_assets.acquired_at::DATE >= $", "projecta_assets.acquired_at::DATE <= $"} {
+			if !strings.Contains(db.queries[0], want) {
+				t.Errorf("expected %q in %s", want, db.queries[0])
+			}
+		}
+
+		if _, err = repo.Totals(withMockDb(authedCtx, &mockPgDb{queryErr: errors.New("db error")}), projecta.TagTotalsFilter{ProjectID: projectID}); err == nil {
+			t.Error("expected query error")
+		}
+	})
+}
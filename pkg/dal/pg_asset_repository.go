@@ -2,6 +2,7 @@ package dal
 
 import (
 	"context"
+	types "database/sql"
 	"errors"
 	"fmt"
 	"time"
@@ -160,6 +161,8 @@ func (r *PgAssetRepository) FindOne(ctx context.Context, filter asset.Filter) (*
 		categoryID          string
 		categoryName        string
 		categoryDescription string
+		vendorID            types.NullString
+		tags                []string
 	)
 
 	if err := r.db.QueryRow(
@@ -185,6 +188,8 @@ func (r *PgAssetRepository) FindOne(ctx context.Context, filter asset.Filter) (*
 		&categoryID,
 		&categoryName,
 		&categoryDescription,
+		&vendorID,
+		&tags,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrAssetNotFound
@@ -218,6 +223,9 @@ func (r *PgAssetRepository) FindOne(ctx context.Context, filter asset.Filter) (*
 		return nil, errors.Join(ErrAssetNotFound, err)
 	}
 
+	withAssetVendor(a, vendorID)
+	a.SetTags(tags)
+
 	return a, nil
 }
 
@@ -241,6 +249,8 @@ func (r *PgAssetRepository) Find(ctx context.Context, filter asset.CollectionFil
 		qb.Where(qb.Equal("projecta_assets.type_id", filter.TypeID.String()))
 	}
 
+	whereTagged(qb, projecta.TaggedAsset, filter.Tags)
+
 	qb.Select(qb.As("COUNT(*)", "total"))
 
 	sql, args := qb.Build()
@@ -258,10 +268,11 @@ func (r *PgAssetRepository) Find(ctx context.Context, filter asset.CollectionFil
 	qb.Offset(filter.Offset)
 	qb.Limit(filter.Limit)
 
+	// the id breaks ties, so the pages neither repeat nor skip assets
 	if filter.OrderBy != "" && filter.Order != "" {
-		qb.OrderBy(fmt.Sprintf("projecta_assets.%s %s", filter.OrderBy, filter.Order.String()))
+		qb.OrderBy(fmt.Sprintf("projecta_assets.%s %s", filter.OrderBy, filter.Order.String()), "projecta_assets.asset_id")
 	} else {
-		qb.OrderBy("projecta_assets.acquired_at DESC")
+		qb.OrderBy("projecta_assets.acquired_at DESC", "projecta_assets.asset_id")
 	}
 
 	sql, args = qb.Build()
@@ -294,6 +305,8 @@ func (r *PgAssetRepository) Find(ctx context.Context, filter asset.CollectionFil
 			categoryID          string
 			categoryName        string
 			categoryDescription string
+			vendorID            types.NullString
+			tags                []string
 		)
 
 		if err = rows.Scan(
@@ -315,6 +328,8 @@ func (r *PgAssetRepository) Find(ctx context.Context, filter asset.CollectionFil
 			&categoryID,
 			&categoryName,
 			&categoryDescription,
+			&vendorID,
+			&tags,
 		); err != nil {
 			return nil, err
 		}
@@ -344,12 +359,68 @@ func (r *PgAssetRepository) Find(ctx context.Context, filter asset.CollectionFil
 			return nil, err
 		}
 
+		withAssetVendor(a, vendorID)
+		a.SetTags(tags)
+
 		collection.Add(a)
 	}
 
 	return collection, nil
 }
 
+func (r *PgAssetRepository) Totals(ctx context.Context, filter asset.TotalsFilter) ([]*asset.Subtotal, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_assets")
+
+	if filter.OwnerID != uuid.Nil {
+		qb.Where(fmt.Sprintf("(projecta_assets.owner_id = %s OR projecta_assets.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(filter.OwnerID.String()), qb.Var(filter.OwnerID.String())))
+	}
+
+	qb.Where(qb.Equal("projecta_assets.project_id", filter.ProjectID.String()))
+
+	qb.Select(
+		"projecta_assets.currency",
+		"projecta_assets.acquired_at::DATE",
+		"SUM(projecta_assets.price)::BIGINT",
+		"COUNT(*)",
+	)
+	qb.GroupBy("1", "2")
+	qb.OrderBy("1", "2")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	totals := make([]*asset.Subtotal, 0)
+
+	for rows.Next() {
+		var (
+			currencyCode string
+			acquiredOn   time.Time
+			price        int64
+			count        int
+		)
+
+		if err = rows.Scan(&currencyCode, &acquiredOn, &price, &count); err != nil {
+			return nil, err
+		}
+
+		totals = append(totals, &asset.Subtotal{
+			Amount: money.New(price, currencyCode),
+			Date:   acquiredOn,
+			Count:  count,
+		})
+	}
+
+	return totals, nil
+}
+
 func setupSelectQueryBuilder(qb *sqlbuilder.SelectBuilder) {
 	qb.Select(
 		"asset_id",
@@ -370,6 +441,8 @@ func setupSelectQueryBuilder(qb *sqlbuilder.SelectBuilder) {
 		"projecta_cost_categories.category_id",
 		qb.As("projecta_cost_categories.name", "category_name"),
 		qb.As("projecta_cost_categories.description", "category_description"),
+		"projecta_assets.vendor_id::TEXT",
+		tagsColumn(projecta.TaggedAsset),
 	)
 
 	qb.Join("people", "people.person_id = projecta_assets.owner_id")
@@ -378,6 +451,13 @@ func setupSelectQueryBuilder(qb *sqlbuilder.SelectBuilder) {
 	qb.Join("projecta_cost_categories", "projecta_cost_categories.category_id = projecta_cost_types.category_id")
 }
 
+func withAssetVendor(a *asset.Asset, vendorID types.NullString) {
+	if vendorID.Valid {
+		id, _ := uuid.Parse(vendorID.String)
+		a.SetVendorID(id)
+	}
+}
+
 func toAssetFromPg(
 	assetID string,
 	name string,
@@ -0,0 +1,307 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"time"
+)
+
+const attachmentNotFound = "attachment not found"
+
+type PgAttachmentRepository struct {
+	db *PgRepository
+}
+
+func NewPgAttachmentRepository(db *PgDbConnection) *PgAttachmentRepository {
+	return &PgAttachmentRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var attachmentColumns = []string{
+	"projecta_attachments.attachment_id",
+	"projecta_attachments.project_id",
+	"COALESCE(projecta_attachments.payment_id::TEXT, '')",
+	"COALESCE(projecta_attachments.asset_id::TEXT, '')",
+	"projecta_attachments.file_name",
+	"projecta_attachments.content_type",
+	"projecta_attachments.size",
+	"projecta_attachments.checksum",
+	"projecta_attachments.uploaded_by",
+	"projecta_attachments.uploaded_at",
+}
+
+// attachmentTargets maps the kinds of attachment targets to their table and
+// the column referencing them.
+var attachmentTargets = map[attachment.TargetKind][2]string{
+	attachment.PaymentTarget: {"projecta_payments", "payment_id"},
+	attachment.AssetTarget:   {"projecta_assets", "asset_id"},
+}
+
+func newAttachmentSelectBuilder(personID uuid.UUID, projectID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.Select(attachmentColumns...)
+	qb.From("projecta_attachments")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_attachments.project_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_attachments.project_id", projectID.String()))
+
+	return qb
+}
+
+func (r *PgAttachmentRepository) Find(ctx context.Context, filter attachment.CollectionFilter) ([]*attachment.Attachment, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newAttachmentSelectBuilder(personID, filter.ProjectID)
+
+	if target, ok := attachmentTargets[filter.Target.Kind]; ok {
+		qb.Where(qb.Equal("projecta_attachments."+target[1], filter.Target.ID.String()))
+	}
+
+	if filter.Checksum != "" {
+		qb.Where(qb.Equal("projecta_attachments.checksum", filter.Checksum))
+	}
+
+	qb.OrderBy("projecta_attachments.uploaded_at", "projecta_attachments.attachment_id")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	attachments := make([]*attachment.Attachment, 0)
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *PgAttachmentRepository) FindOne(ctx context.Context, filter attachment.Filter) (*attachment.Attachment, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newAttachmentSelectBuilder(personID, filter.ProjectID)
+	qb.Where(qb.Equal("projecta_attachments.attachment_id", filter.AttachmentID.String()))
+
+	sql, args := qb.Build()
+
+	a, err := scanAttachment(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(attachmentNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Save inserts the attachment only when its target is a record of its project,
+// so a payment or an asset of another project cannot be attached to.
+func (r *PgAttachmentRepository) Save(ctx context.Context, a *attachment.Attachment) error {
+	target, ok := attachmentTargets[a.Target.Kind]
+
+	if !ok {
+		return fmt.Errorf("unknown attachment target %q", a.Target.Kind)
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_attachments")
+	qb.Cols("attachment_id", "project_id", target[1], "file_name", "content_type", "size", "checksum", "uploaded_by", "uploaded_at")
+
+	sb := qb.Select()
+	sb.Select(
+		sb.Var(a.ID.String())+"::UUID",
+		sb.Var(a.ProjectID.String())+"::UUID",
+		target[1],
+		sb.Var(a.FileName),
+		sb.Var(a.ContentType),
+		sb.Var(a.Size)+"::BIGINT",
+		sb.Var(a.Checksum),
+		sb.Var(a.UploadedBy.String())+"::UUID",
+		sb.Var(a.UploadedAt)+"::TIMESTAMP",
+	)
+	sb.From(target[0])
+	sb.Where(sb.Equal(target[1], a.Target.ID.String()))
+	sb.Where(sb.Equal("project_id", a.ProjectID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, attachmentNotFound, sql, args)
+}
+
+func (r *PgAttachmentRepository) Remove(ctx context.Context, a *attachment.Attachment) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_attachments")
+	qb.Where(qb.Equal("project_id", a.ProjectID.String()))
+	qb.Where(qb.Equal("attachment_id", a.ID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, attachmentNotFound, sql, args)
+}
+
+// Lock takes a transaction level advisory lock on the file, it is released on
+// commit or rollback.
+func (r *PgAttachmentRepository) Lock(ctx context.Context, file attachment.File) error {
+	_, err := r.db.Exec(
+		ctx,
+		"SELECT pg_advisory_xact_lock(hashtextextended($1, 0))",
+		"projecta_attachments:"+file.StorageKey(),
+	)
+
+	return err
+}
+
+func (r *PgAttachmentRepository) InUse(ctx context.Context, file attachment.File) (bool, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_attachments")
+	qb.Select("1 as exists")
+	qb.Where(qb.Equal("project_id", file.ProjectID.String()))
+	qb.Where(qb.Equal("checksum", file.Checksum))
+	qb.Limit(1)
+
+	sql, args := qb.Build()
+
+	var exists int
+	err := r.db.QueryRow(ctx, sql, args...).Scan(&exists)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PgAttachmentRepository) FindReleased(ctx context.Context) ([]attachment.File, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.Select("project_id", "checksum")
+	qb.From("projecta_attachment_releases")
+	qb.OrderBy("released_at", "project_id", "checksum")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	files := make([]attachment.File, 0)
+
+	for rows.Next() {
+		var projectID, checksum string
+
+		if err = rows.Scan(&projectID, &checksum); err != nil {
+			return nil, err
+		}
+
+		projectUUID, err := uuid.Parse(projectID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, attachment.File{ProjectID: projectUUID, Checksum: checksum})
+	}
+
+	return files, rows.Err()
+}
+
+func (r *PgAttachmentRepository) Forget(ctx context.Context, file attachment.File) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_attachment_releases")
+	qb.Where(qb.Equal("project_id", file.ProjectID.String()))
+	qb.Where(qb.Equal("checksum", file.Checksum))
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func scanAttachment(row pgx.Row) (*attachment.Attachment, error) {
+	var (
+		attachmentID string
+		projectID    string
+		paymentID    string
+		assetID      string
+		fileName     string
+		contentType  string
+		size         int64
+		checksum     string
+		uploadedBy   string
+		uploadedAt   time.Time
+	)
+
+	if err := row.Scan(&attachmentID, &projectID, &paymentID, &assetID, &fileName, &contentType, &size, &checksum, &uploadedBy, &uploadedAt); err != nil {
+		return nil, err
+	}
+
+	attachmentUUID, err := uuid.Parse(attachmentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploadedByUUID, err := uuid.Parse(uploadedBy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	target := attachment.Target{Kind: attachment.PaymentTarget}
+	targetID := paymentID
+
+	if assetID != "" {
+		target.Kind, targetID = attachment.AssetTarget, assetID
+	}
+
+	if target.ID, err = uuid.Parse(targetID); err != nil {
+		return nil, err
+	}
+
+	return attachment.NewAttachment(attachmentUUID, projectUUID, target, fileName, contentType, size, checksum, uploadedByUUID, uploadedAt)
+}
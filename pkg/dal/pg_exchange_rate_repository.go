@@ -0,0 +1,123 @@
+package dal
+
+import (
+	"context"
+	"github.com/huandu/go-sqlbuilder"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+	"time"
+)
+
+const rateDateLayout = "2006-01-02"
+
+type PgExchangeRateRepository struct {
+	db *PgRepository
+}
+
+func NewPgExchangeRateRepository(db *PgDbConnection) *PgExchangeRateRepository {
+	return &PgExchangeRateRepository{
+		db: &PgRepository{db},
+	}
+}
+
+func (r *PgExchangeRateRepository) FindLatest(ctx context.Context, source string, day time.Time) (*currency.DailyRates, error) {
+	latest := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	latest.From("exchange_rates")
+	latest.Select("MAX(rate_date)")
+	latest.Where(latest.Equal("source", source))
+	latest.Where(latest.LessEqualThan("rate_date", day.Format(rateDateLayout)))
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("exchange_rates")
+	qb.Select(
+		"rate_date",
+		"code",
+		"base_currency",
+		"exchange_rate",
+	)
+	qb.Where(qb.Equal("source", source))
+	qb.Where(qb.In("rate_date", latest))
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rates := &currency.DailyRates{
+		Source: source,
+		Rates:  make(map[string]float64),
+	}
+
+	for rows.Next() {
+		var (
+			rateDate     time.Time
+			code         string
+			baseCurrency string
+			exchangeRate float64
+		)
+
+		if err = rows.Scan(&rateDate, &code, &baseCurrency, &exchangeRate); err != nil {
+			return nil, err
+		}
+
+		rates.Day = rateDate
+		rates.Base = baseCurrency
+		rates.Rates[code] = exchangeRate
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(rates.Rates) == 0 {
+		return nil, exceptions.NewNotFoundException("exchange rates not found", nil)
+	}
+
+	// the base currency is implied by the other rates
+	rates.Rates[rates.Base] = 1
+
+	return rates, nil
+}
+
+func (r *PgExchangeRateRepository) Save(ctx context.Context, rates *currency.DailyRates) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("exchange_rates")
+	qb.Cols(
+		"source",
+		"rate_date",
+		"code",
+		"base_currency",
+		"exchange_rate",
+	)
+
+	for code, rate := range rates.Rates {
+		if code == rates.Base {
+			continue
+		}
+
+		qb.Values(
+			rates.Source,
+			rates.Day.Format(rateDateLayout),
+			code,
+			rates.Base,
+			rate,
+		)
+	}
+
+	qb.SQL("ON CONFLICT (source, rate_date, code) DO UPDATE SET base_currency = EXCLUDED.base_currency, exchange_rate = EXCLUDED.exchange_rate, fetched_at = current_timestamp")
+
+	sql, args := qb.Build()
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
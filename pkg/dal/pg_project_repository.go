@@ -25,6 +25,43 @@ func NewPgProjectRepository(db *PgDbConnection) *PgProjectRepository {
 	}
 }
 
+var projectColumns = []string{
+	"projecta_projects.project_id",
+	"projecta_projects.name",
+	"projecta_projects.description",
+	"projecta_projects.owner_id",
+	"projecta_projects.started_at",
+	"projecta_projects.ended_at",
+	"people.first_name",
+	"people.last_name",
+	"people.display_name",
+	"projecta_projects.share_token",
+	"projecta_projects.main_currency",
+	"projecta_projects.archived_at",
+}
+
+var shareLinkColumns = []string{
+	"projecta_share_links.link_id",
+	"projecta_share_links.token",
+	"projecta_share_links.role",
+	"projecta_share_links.created_by",
+	"projecta_share_links.created_at",
+	"projecta_share_links.expires_at",
+	"projecta_share_links.max_uses",
+	"projecta_share_links.uses",
+}
+
+// joinProjectMember joins the share record of the person, if any, so that the
+// role the person holds in the project can be selected as member.role.
+func joinProjectMember(qb *sqlbuilder.SelectBuilder, personID uuid.UUID) {
+	qb.JoinWithOption(
+		sqlbuilder.LeftJoin,
+		"projecta_project_shares member",
+		"member.project_id = projecta_projects.project_id",
+		fmt.Sprintf("member.person_id = %s", qb.Var(personID.String())),
+	)
+}
+
 func (r *PgProjectRepository) FindOne(ctx context.Context, filter projecta.ProjectFilter) (*projecta.Project, error) {
 	personID, err := core.AuthGuard(ctx)
 
@@ -34,20 +71,9 @@ func (r *PgProjectRepository) FindOne(ctx context.Context, filter projecta.Proje
 
 	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
 	qb.From("projecta_projects")
-	qb.Select(
-		"projecta_projects.project_id",
-		"name",
-		"description",
-		"owner_id",
-		"started_at",
-		"ended_at",
-		"people.first_name",
-		"people.last_name",
-		"people.display_name",
-		"projecta_projects.share_token",
-		"projecta_projects.main_currency",
-	)
+	qb.Select(append(append([]string{}, projectColumns...), "COALESCE(member.role, '') member_role")...)
 	qb.Join("people", "people.person_id = projecta_projects.owner_id")
+	joinProjectMember(qb, personID)
 
 	if filter.ProjectID != uuid.Nil {
 		qb.Where(qb.Equal("projecta_projects.project_id", filter.ProjectID.String()))
@@ -73,6 +99,8 @@ func (r *PgProjectRepository) FindOne(ctx context.Context, filter projecta.Proje
 		displayName   types.NullString
 		shareTokenStr string
 		mainCurrency  types.NullString
+		archivedAt    types.NullTime
+		memberRole    string
 	)
 
 	if err := r.db.QueryRow(
@@ -91,6 +119,8 @@ func (r *PgProjectRepository) FindOne(ctx context.Context, filter projecta.Proje
 		&displayName,
 		&shareTokenStr,
 		&mainCurrency,
+		&archivedAt,
+		&memberRole,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, exceptions.NewNotFoundException("project not found", err)
@@ -103,7 +133,9 @@ func (r *PgProjectRepository) FindOne(ctx context.Context, filter projecta.Proje
 	if err != nil {
 		return nil, err
 	}
+	p.ArchivedAt = archivedAt.Time
 	p.IsShared = (p.Owner.PersonID != personID)
+	p.Role = toProjectRole(p, personID, memberRole)
 	return p, nil
 }
 
@@ -114,19 +146,7 @@ func (r *PgProjectRepository) FindByShareToken(ctx context.Context, token uuid.U
 
 	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
 	qb.From("projecta_projects")
-	qb.Select(
-		"projecta_projects.project_id",
-		"name",
-		"description",
-		"owner_id",
-		"started_at",
-		"ended_at",
-		"people.first_name",
-		"people.last_name",
-		"people.display_name",
-		"projecta_projects.share_token",
-		"projecta_projects.main_currency",
-	)
+	qb.Select(projectColumns...)
 	qb.Join("people", "people.person_id = projecta_projects.owner_id")
 	qb.Where(qb.Equal("share_token", token.String()))
 
@@ -144,6 +164,7 @@ func (r *PgProjectRepository) FindByShareToken(ctx context.Context, token uuid.U
 		displayName   types.NullString
 		shareTokenStr string
 		mainCurrency  types.NullString
+		archivedAt    types.NullTime
 	)
 
 	if err := r.db.QueryRow(ctx, sql, args...).Scan(
@@ -158,6 +179,7 @@ func (r *PgProjectRepository) FindByShareToken(ctx context.Context, token uuid.U
 		&displayName,
 		&shareTokenStr,
 		&mainCurrency,
+		&archivedAt,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, exceptions.NewNotFoundException("project not found", err)
@@ -165,22 +187,346 @@ func (r *PgProjectRepository) FindByShareToken(ctx context.Context, token uuid.U
 		return nil, err
 	}
 
-	return toProject(projectID, name, description, ownerID, firstName, lastName, displayName.String, startedAt, endedAt, mainCurrency.String, shareTokenStr)
+	p, err := toProject(projectID, name, description, ownerID, firstName, lastName, displayName.String, startedAt, endedAt, mainCurrency.String, shareTokenStr)
+	if err != nil {
+		return nil, err
+	}
+	p.ArchivedAt = archivedAt.Time
+	return p, nil
 }
 
-func (r *PgProjectRepository) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+func (r *PgProjectRepository) CreateShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID, role projecta.ProjectRole) (bool, error) {
 	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
 	qb.InsertInto("projecta_project_shares")
-	qb.Cols("share_id", "project_id", "person_id")
-	qb.Values(uuid.New().String(), projectID.String(), personID.String())
+	qb.Cols("share_id", "project_id", "person_id", "role")
+	qb.Values(uuid.New().String(), projectID.String(), personID.String(), role.String())
 
 	sql, args := qb.Build()
 	sql += " ON CONFLICT (project_id, person_id) DO NOTHING"
 
+	res, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (r *PgProjectRepository) FindShareLink(ctx context.Context, shareToken uuid.UUID) (*projecta.ShareLink, error) {
+	if shareToken == uuid.Nil {
+		return nil, exceptions.NewValidationException("invalid share token", nil)
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_share_links")
+	qb.Select(append(append([]string{}, projectColumns...), shareLinkColumns...)...)
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_share_links.project_id")
+	qb.Join("people", "people.person_id = projecta_projects.owner_id")
+	qb.Where(qb.Equal("projecta_share_links.token", shareToken.String()))
+
+	sql, args := qb.Build()
+
+	var (
+		projectID     string
+		name          string
+		description   string
+		ownerID       string
+		startedAt     time.Time
+		endedAt       time.Time
+		firstName     string
+		lastName      string
+		displayName   types.NullString
+		shareTokenStr string
+		mainCurrency  types.NullString
+		archivedAt    types.NullTime
+		linkID        string
+		token         string
+		role          string
+		createdBy     string
+		createdAt     time.Time
+		expiresAt     types.NullTime
+		maxUses       int
+		uses          int
+	)
+
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(
+		&projectID,
+		&name,
+		&description,
+		&ownerID,
+		&startedAt,
+		&endedAt,
+		&firstName,
+		&lastName,
+		&displayName,
+		&shareTokenStr,
+		&mainCurrency,
+		&archivedAt,
+		&linkID,
+		&token,
+		&role,
+		&createdBy,
+		&createdAt,
+		&expiresAt,
+		&maxUses,
+		&uses,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException("share link not found", err)
+		}
+		return nil, err
+	}
+
+	p, err := toProject(projectID, name, description, ownerID, firstName, lastName, displayName.String, startedAt, endedAt, mainCurrency.String, shareTokenStr)
+	if err != nil {
+		return nil, err
+	}
+	p.ArchivedAt = archivedAt.Time
+
+	return toShareLink(p, linkID, token, role, createdBy, createdAt, expiresAt.Time, maxUses, uses)
+}
+
+func (r *PgProjectRepository) CreateShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_share_links")
+	qb.Cols("link_id", "project_id", "token", "role", "created_by", "created_at", "expires_at", "max_uses", "uses")
+	qb.Values(
+		link.ID.String(),
+		link.Project.ProjectID.String(),
+		link.Token.String(),
+		link.Role.String(),
+		link.CreatedBy.String(),
+		link.CreatedAt,
+		types.NullTime{Time: link.ExpiresAt, Valid: !link.ExpiresAt.IsZero()},
+		link.MaxUses,
+		link.Uses,
+	)
+
+	sql, args := qb.Build()
+
 	_, err := r.db.Exec(ctx, sql, args...)
+
 	return err
 }
 
+func (r *PgProjectRepository) FindShareLinks(ctx context.Context, project *projecta.Project) ([]*projecta.ShareLink, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_share_links")
+	qb.Select(shareLinkColumns...)
+	qb.Where(qb.Equal("projecta_share_links.project_id", project.ProjectID.String()))
+	qb.OrderBy("projecta_share_links.created_at DESC")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	links := make([]*projecta.ShareLink, 0)
+
+	for rows.Next() {
+		var (
+			linkID    string
+			token     string
+			role      string
+			createdBy string
+			createdAt time.Time
+			expiresAt types.NullTime
+			maxUses   int
+			uses      int
+		)
+
+		if err = rows.Scan(
+			&linkID,
+			&token,
+			&role,
+			&createdBy,
+			&createdAt,
+			&expiresAt,
+			&maxUses,
+			&uses,
+		); err != nil {
+			return nil, err
+		}
+
+		link, err := toShareLink(project, linkID, token, role, createdBy, createdAt, expiresAt.Time, maxUses, uses)
+
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// UseShareLink counts one more use of the link. The limits are checked again in
+// the same statement, so concurrent uses can not exceed them.
+func (r *PgProjectRepository) UseShareLink(ctx context.Context, link *projecta.ShareLink) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_share_links")
+	qb.Set("uses = uses + 1")
+	qb.Where(qb.Equal("link_id", link.ID.String()))
+	qb.Where("(max_uses = 0 OR uses < max_uses)")
+	qb.Where("(expires_at IS NULL OR expires_at > current_timestamp)")
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException("share link not found", nil)
+	}
+
+	link.Uses++
+
+	return nil
+}
+
+func (r *PgProjectRepository) RemoveShareLink(ctx context.Context, projectID uuid.UUID, linkID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_share_links")
+	qb.Where(qb.Equal("link_id", linkID.String()))
+	qb.Where(qb.Equal("project_id", projectID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException("share link not found", nil)
+	}
+
+	return nil
+}
+
+// FindMembers returns the owner of the project followed by the people it is
+// shared with, in the order they joined.
+func (r *PgProjectRepository) FindMembers(ctx context.Context, projectID uuid.UUID) ([]*projecta.ProjectMember, error) {
+	owners := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	owners.Select(
+		"people.person_id",
+		"people.first_name",
+		"people.last_name",
+		"people.display_name",
+		fmt.Sprintf("'%s' AS role", projecta.RoleOwner),
+		"projecta_projects.created_at AS joined_at",
+		"0 AS position",
+	)
+	owners.From("projecta_projects")
+	owners.Join("people", "people.person_id = projecta_projects.owner_id")
+	owners.Where(owners.Equal("projecta_projects.project_id", projectID.String()))
+
+	shares := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	shares.Select(
+		"people.person_id",
+		"people.first_name",
+		"people.last_name",
+		"people.display_name",
+		"projecta_project_shares.role",
+		"projecta_project_shares.created_at AS joined_at",
+		"1 AS position",
+	)
+	shares.From("projecta_project_shares")
+	shares.Join("people", "people.person_id = projecta_project_shares.person_id")
+	shares.Where(shares.Equal("projecta_project_shares.project_id", projectID.String()))
+
+	qb := sqlbuilder.UnionAll(owners, shares)
+	qb.SetFlavor(sqlbuilder.PostgreSQL)
+	qb.OrderBy("position", "joined_at")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := make([]*projecta.ProjectMember, 0)
+
+	for rows.Next() {
+		var (
+			personID    string
+			firstName   string
+			lastName    string
+			displayName types.NullString
+			role        string
+			joinedAt    types.NullTime
+			position    int
+		)
+
+		if err = rows.Scan(
+			&personID,
+			&firstName,
+			&lastName,
+			&displayName,
+			&role,
+			&joinedAt,
+			&position,
+		); err != nil {
+			return nil, err
+		}
+
+		person, err := people.NewPerson(uuid.MustParse(personID), firstName, lastName, displayName.String, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		memberRole, err := projecta.ToProjectRole(role)
+
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, &projecta.ProjectMember{
+			PersonID:    person.ID(),
+			DisplayName: person.DisplayName(),
+			Role:        memberRole,
+			JoinedAt:    joinedAt.Time,
+		})
+	}
+
+	return members, nil
+}
+
+func (r *PgProjectRepository) RemoveShareRecord(ctx context.Context, projectID uuid.UUID, personID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_project_shares")
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.Equal("person_id", personID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("failed to remove project share")
+	}
+
+	return nil
+}
+
 func (r *PgProjectRepository) Create(ctx context.Context, project *projecta.Project) error {
 	if project.ShareToken == uuid.Nil {
 		project.ShareToken = uuid.New()
@@ -236,6 +582,8 @@ func (r *PgProjectRepository) Update(ctx context.Context, project *projecta.Proj
 		qb.Assign("started_at", project.StartDate),
 		qb.Assign("ended_at", project.EndDate),
 		qb.Assign("main_currency", mainCurrency),
+		qb.Assign("archived_at", types.NullTime{Time: project.ArchivedAt, Valid: project.IsArchived()}),
+		qb.Assign("share_token", project.ShareToken.String()),
 	)
 	qb.Where(qb.Equal("project_id", project.ProjectID.String()))
 	qb.Where(qb.Equal("owner_id", project.Owner.PersonID.String()))
@@ -255,9 +603,17 @@ func (r *PgProjectRepository) Remove(ctx context.Context, project *projecta.Proj
 
 	sql, args := qb.Build()
 
-	_, err := r.db.Exec(ctx, sql, args...)
+	res, err := r.db.Exec(ctx, sql, args...)
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("failed to remove project")
+	}
+
+	return nil
 }
 
 func (r *PgProjectRepository) Find(ctx context.Context, filter projecta.ProjectCollectionFilter) ([]*projecta.Project, error) {
@@ -269,25 +625,20 @@ func (r *PgProjectRepository) Find(ctx context.Context, filter projecta.ProjectC
 
 	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
 	qb.From("projecta_projects")
-	qb.Select(
-		"projecta_projects.project_id",
-		"name",
-		"description",
-		"owner_id",
-		"started_at",
-		"ended_at",
-		"people.first_name",
-		"people.last_name",
-		"people.display_name",
-		"projecta_projects.share_token",
-		"projecta_projects.main_currency",
-	)
+	qb.Select(append(append([]string{}, projectColumns...), "COALESCE(member.role, '') member_role")...)
 	qb.Join("people", "people.person_id = projecta_projects.owner_id")
+	joinProjectMember(qb, personID)
 
 	if filter.Name != "" {
 		qb.Where(qb.Like("name", filter.Name))
 	}
 
+	if filter.Archived {
+		qb.Where(qb.IsNotNull("projecta_projects.archived_at"))
+	} else {
+		qb.Where(qb.IsNull("projecta_projects.archived_at"))
+	}
+
 	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
 
 	qb.Limit(filter.Limit)
@@ -318,6 +669,8 @@ func (r *PgProjectRepository) Find(ctx context.Context, filter projecta.ProjectC
 			displayName   types.NullString
 			shareTokenStr string
 			mainCurrency  types.NullString
+			archivedAt    types.NullTime
+			memberRole    string
 		)
 
 		if err = rows.Scan(
@@ -332,6 +685,8 @@ func (r *PgProjectRepository) Find(ctx context.Context, filter projecta.ProjectC
 			&displayName,
 			&shareTokenStr,
 			&mainCurrency,
+			&archivedAt,
+			&memberRole,
 		); err != nil {
 			return nil, err
 		}
@@ -342,7 +697,9 @@ func (r *PgProjectRepository) Find(ctx context.Context, filter projecta.ProjectC
 			return nil, err
 		}
 
+		p.ArchivedAt = archivedAt.Time
 		p.IsShared = (p.Owner.PersonID != personID)
+		p.Role = toProjectRole(p, personID, memberRole)
 
 		projects = append(projects, p)
 	}
@@ -350,6 +707,64 @@ func (r *PgProjectRepository) Find(ctx context.Context, filter projecta.ProjectC
 	return projects, nil
 }
 
+func toShareLink(
+	project *projecta.Project,
+	linkID string,
+	token string,
+	role string,
+	createdBy string,
+	createdAt time.Time,
+	expiresAt time.Time,
+	maxUses int,
+	uses int,
+) (*projecta.ShareLink, error) {
+	linkUUID, err := uuid.Parse(linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenUUID, err := uuid.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	createdByUUID, err := uuid.Parse(createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	linkRole, err := projecta.ToProjectRole(role)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := projecta.NewShareLink(linkUUID, tokenUUID, project, linkRole, createdByUUID, createdAt, expiresAt, maxUses)
+	if err != nil {
+		return nil, err
+	}
+
+	link.Uses = uses
+
+	return link, nil
+}
+
+// toProjectRole resolves the role of the person a project was loaded for. The
+// owner is not recorded in the shares, and an unknown share role is treated as
+// the least privileged one.
+func toProjectRole(p *projecta.Project, personID uuid.UUID, memberRole string) projecta.ProjectRole {
+	if p.Owner.PersonID == personID {
+		return projecta.RoleOwner
+	}
+
+	role, err := projecta.ToProjectRole(memberRole)
+
+	if err != nil {
+		return projecta.RoleViewer
+	}
+
+	return role
+}
+
 func toProject(projectID, name, description, ownerID, firstName, lastName, displayName string, startedAt, enddedAt time.Time, mainCurrency string, shareToken ...string) (*projecta.Project, error) {
 	person, err := people.NewPerson(
 		uuid.MustParse(ownerID),
@@ -0,0 +1,456 @@
+package dal
+
+import (
+	"context"
+	types "database/sql"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type PgBudgetRepository struct {
+	db *PgRepository
+}
+
+func NewPgBudgetRepository(db *PgDbConnection) *PgBudgetRepository {
+	return &PgBudgetRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var budgetColumns = []string{
+	"projecta_budgets.budget_id",
+	"projecta_budgets.project_id",
+	"projecta_budgets.category_id",
+	"projecta_cost_categories.name as category_name",
+	"COALESCE(projecta_budgets.type_id::text, '') type_id",
+	"COALESCE(projecta_cost_types.name, '') type_name",
+	"projecta_budgets.amount",
+	"projecta_budgets.currency",
+	"COALESCE(projecta_budgets.description, '') description",
+}
+
+func newBudgetSelectBuilder(personID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_budgets")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_budgets.project_id")
+	qb.Join("projecta_cost_categories", "projecta_cost_categories.category_id = projecta_budgets.category_id")
+	qb.JoinWithOption(sqlbuilder.LeftJoin, "projecta_cost_types", "projecta_cost_types.type_id = projecta_budgets.type_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+
+	return qb
+}
+
+func (r *PgBudgetRepository) FindOne(ctx context.Context, filter projecta.BudgetFilter) (*projecta.Budget, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newBudgetSelectBuilder(personID)
+	qb.Select(budgetColumns...)
+
+	if filter.BudgetID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_budgets.budget_id", filter.BudgetID.String()))
+	}
+
+	if filter.ProjectID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_budgets.project_id", filter.ProjectID.String()))
+	}
+
+	sql, args := qb.Build()
+
+	var (
+		budgetID     string
+		projectID    string
+		categoryID   string
+		categoryName string
+		typeID       string
+		typeName     string
+		amount       int64
+		currency     string
+		description  string
+	)
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(
+		&budgetID,
+		&projectID,
+		&categoryID,
+		&categoryName,
+		&typeID,
+		&typeName,
+		&amount,
+		&currency,
+		&description,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException("budget not found", err)
+		}
+
+		return nil, err
+	}
+
+	return toBudget(budgetID, projectID, categoryID, categoryName, typeID, typeName, amount, currency, description)
+}
+
+func (r *PgBudgetRepository) Find(ctx context.Context, filter projecta.BudgetCollectionFilter) (*projecta.BudgetCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newBudgetSelectBuilder(personID)
+	qb.Where(qb.Equal("projecta_budgets.project_id", filter.ProjectID.String()))
+
+	if filter.CategoryID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_budgets.category_id", filter.CategoryID.String()))
+	}
+
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	collection := projecta.NewBudgetCollection(total)
+
+	if total == 0 {
+		return collection, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(budgetColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_cost_categories.name ASC", "type_name ASC")
+
+	sql, args = qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			budgetID     string
+			projectID    string
+			categoryID   string
+			categoryName string
+			typeID       string
+			typeName     string
+			amount       int64
+			currency     string
+			description  string
+		)
+
+		if err = rows.Scan(
+			&budgetID,
+			&projectID,
+			&categoryID,
+			&categoryName,
+			&typeID,
+			&typeName,
+			&amount,
+			&currency,
+			&description,
+		); err != nil {
+			return nil, err
+		}
+
+		b, err := toBudget(budgetID, projectID, categoryID, categoryName, typeID, typeName, amount, currency, description)
+
+		if err != nil {
+			return nil, err
+		}
+
+		collection.Add(b)
+	}
+
+	return collection, nil
+}
+
+func (r *PgBudgetRepository) Save(ctx context.Context, budget *projecta.Budget) error {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_budgets")
+	qb.Select("1 as exists")
+	qb.Where(qb.Equal("budget_id", budget.ID.String()))
+	qb.Where(qb.Equal("project_id", budget.ProjectID.String()))
+
+	sql, args := qb.Build()
+
+	var exists int
+	err := r.db.QueryRow(ctx, sql, args...).Scan(&exists)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r.create(ctx, budget)
+		}
+
+		return err
+	}
+
+	return r.update(ctx, budget)
+}
+
+func (r *PgBudgetRepository) create(ctx context.Context, budget *projecta.Budget) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_budgets")
+	qb.Cols(
+		"budget_id",
+		"project_id",
+		"category_id",
+		"type_id",
+		"amount",
+		"currency",
+		"description",
+	)
+	qb.Values(
+		budget.ID.String(),
+		budget.ProjectID.String(),
+		budget.Category.ID.String(),
+		budgetTypeID(budget),
+		budget.Amount.Amount(),
+		budget.Amount.Currency().Code,
+		budget.Description,
+	)
+
+	sql, args := qb.Build()
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgBudgetRepository) update(ctx context.Context, budget *projecta.Budget) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_budgets")
+	qb.Set(
+		qb.Assign("category_id", budget.Category.ID.String()),
+		qb.Assign("type_id", budgetTypeID(budget)),
+		qb.Assign("amount", budget.Amount.Amount()),
+		qb.Assign("currency", budget.Amount.Currency().Code),
+		qb.Assign("description", budget.Description),
+	)
+	qb.Where(qb.Equal("budget_id", budget.ID.String()))
+	qb.Where(qb.Equal("project_id", budget.ProjectID.String()))
+
+	sql, args := qb.Build()
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgBudgetRepository) Remove(ctx context.Context, budget *projecta.Budget) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_budgets")
+	qb.Where(qb.Equal("budget_id", budget.ID.String()))
+	qb.Where(qb.Equal("project_id", budget.ProjectID.String()))
+
+	sql, args := qb.Build()
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("failed to remove budget")
+	}
+
+	return nil
+}
+
+// Report returns every budget of the project with the payments booked against
+// it. Payments are matched through their cost type: a category budget covers
+// all types of the category, a type budget only its own type. The actual amount
+// is summed per payment currency, so a budget yields one row per currency.
+func (r *PgBudgetRepository) Report(ctx context.Context, projectID uuid.UUID) ([]*projecta.BudgetReportLine, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newBudgetSelectBuilder(personID)
+	// payments booked in the project main currency are summed by their booked
+	// amount, the others per day to be converted with the rates of that day
+	qb.JoinWithOption(
+		sqlbuilder.LeftJoin,
+		fmt.Sprintf(`LATERAL (
+			SELECT CASE WHEN %[1]s THEN projecta_payments.home_currency ELSE projecta_payments.currency END AS currency,
+			       %[2]s AS day,
+			       SUM(CASE WHEN %[1]s THEN projecta_payments.home_amount ELSE projecta_payments.amount END) AS amount,
+			       COUNT(*) AS count
+			FROM projecta_payments
+			JOIN projecta_cost_types spent_types ON spent_types.type_id = projecta_payments.type_id
+			WHERE projecta_payments.project_id = projecta_budgets.project_id
+			  AND projecta_payments.status = 'PAID'
+			  AND spent_types.category_id = projecta_budgets.category_id
+			  AND (projecta_budgets.type_id IS NULL OR projecta_payments.type_id = projecta_budgets.type_id)
+			GROUP BY 1, 2
+		) spent`, paymentBooked, paymentDay),
+		"true",
+	)
+	qb.Select(append(
+		append([]string{}, budgetColumns...),
+		"COALESCE(spent.currency, '') spent_currency",
+		"spent.day",
+		"COALESCE(spent.amount, 0)::BIGINT spent_amount",
+		"COALESCE(spent.count, 0) spent_count",
+	)...)
+	qb.Where(qb.Equal("projecta_budgets.project_id", projectID.String()))
+	qb.OrderBy("projecta_cost_categories.name ASC", "type_name ASC", "projecta_budgets.budget_id", "spent.day")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	lines := make([]*projecta.BudgetReportLine, 0)
+	var current *projecta.BudgetReportLine
+
+	for rows.Next() {
+		var (
+			budgetID      string
+			budgetProject string
+			categoryID    string
+			categoryName  string
+			typeID        string
+			typeName      string
+			amount        int64
+			currency      string
+			description   string
+			spentCurrency string
+			spentDay      types.NullTime
+			spentAmount   int64
+			spentCount    int
+		)
+
+		if err = rows.Scan(
+			&budgetID,
+			&budgetProject,
+			&categoryID,
+			&categoryName,
+			&typeID,
+			&typeName,
+			&amount,
+			&currency,
+			&description,
+			&spentCurrency,
+			&spentDay,
+			&spentAmount,
+			&spentCount,
+		); err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.Budget.ID.String() != budgetID {
+			b, err := toBudget(budgetID, budgetProject, categoryID, categoryName, typeID, typeName, amount, currency, description)
+
+			if err != nil {
+				return nil, err
+			}
+
+			current = &projecta.BudgetReportLine{Budget: b, Actual: make([]*projecta.PaymentSubtotal, 0)}
+			lines = append(lines, current)
+		}
+
+		if spentCurrency != "" {
+			current.Actual = append(current.Actual, &projecta.PaymentSubtotal{
+				Amount: money.New(spentAmount, spentCurrency),
+				Date:   spentDay.Time,
+				Count:  spentCount,
+			})
+		}
+	}
+
+	return lines, nil
+}
+
+func budgetTypeID(budget *projecta.Budget) types.NullString {
+	if budget.Type == nil {
+		return types.NullString{}
+	}
+
+	return types.NullString{String: budget.Type.ID.String(), Valid: true}
+}
+
+func toBudget(
+	budgetID string,
+	projectID string,
+	categoryID string,
+	categoryName string,
+	typeID string,
+	typeName string,
+	amount int64,
+	currency string,
+	description string,
+) (*projecta.Budget, error) {
+	budgetUUID, err := uuid.Parse(budgetID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	categoryUUID, err := uuid.Parse(categoryID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	category := &projecta.CostCategory{
+		ID:        categoryUUID,
+		ProjectID: projectUUID,
+		Name:      categoryName,
+	}
+
+	var costType *projecta.CostType
+
+	if typeID != "" {
+		typeUUID, err := uuid.Parse(typeID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		costType = &projecta.CostType{
+			ID:        typeUUID,
+			ProjectID: projectUUID,
+			Category:  category,
+			Name:      typeName,
+		}
+	}
+
+	return projecta.NewBudget(budgetUUID, projectUUID, category, costType, money.New(amount, currency), description)
+}
@@ -0,0 +1,349 @@
+package dal
+
+import (
+	"context"
+	types "database/sql"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"time"
+)
+
+const contractNotFound = "contract not found"
+
+type PgContractRepository struct {
+	db *PgRepository
+}
+
+func NewPgContractRepository(db *PgDbConnection) *PgContractRepository {
+	return &PgContractRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var contractColumns = []string{
+	"projecta_contracts.contract_id",
+	"projecta_contracts.project_id",
+	"projecta_contracts.vendor_id",
+	"projecta_contracts.title",
+	"projecta_contracts.currency",
+	"projecta_contracts.down_payment",
+	"projecta_contracts.upon_completion",
+	"projecta_contracts.signed_at",
+}
+
+func newContractSelectBuilder(personID uuid.UUID, projectID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_contracts")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_contracts.project_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_contracts.project_id", projectID.String()))
+
+	return qb
+}
+
+func (r *PgContractRepository) Find(ctx context.Context, filter projecta.ContractCollectionFilter) (*projecta.ContractCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newContractSelectBuilder(personID, filter.ProjectID)
+
+	if filter.VendorID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_contracts.vendor_id", filter.VendorID.String()))
+	}
+
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	collection := projecta.NewContractCollection(total)
+
+	if total == 0 {
+		return collection, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(contractColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_contracts.created_at", "projecta_contracts.contract_id")
+
+	sql, args = qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanContract(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		collection.Add(c)
+	}
+
+	return collection, rows.Err()
+}
+
+func (r *PgContractRepository) FindOne(ctx context.Context, filter projecta.ContractFilter) (*projecta.Contract, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newContractSelectBuilder(personID, filter.ProjectID)
+	qb.Select(contractColumns...)
+	qb.Where(qb.Equal("projecta_contracts.contract_id", filter.ContractID.String()))
+
+	sql, args := qb.Build()
+
+	c, err := scanContract(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(contractNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *PgContractRepository) Save(ctx context.Context, contract *projecta.Contract) error {
+	var downPayment, uponCompletion int64
+
+	if stage := contract.Stage(projecta.DownPayment); stage != nil {
+		downPayment = stage.Amount.Amount()
+	}
+
+	if stage := contract.Stage(projecta.UponCompletionPayment); stage != nil {
+		uponCompletion = stage.Amount.Amount()
+	}
+
+	var signedAt any
+
+	if !contract.SignedAt.IsZero() {
+		signedAt = contract.SignedAt
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_contracts")
+	qb.Cols(
+		"contract_id",
+		"project_id",
+		"vendor_id",
+		"title",
+		"currency",
+		"down_payment",
+		"upon_completion",
+		"signed_at",
+	)
+	qb.Values(
+		contract.ID.String(),
+		contract.ProjectID.String(),
+		contract.VendorID.String(),
+		contract.Title,
+		contract.Total.Currency().Code,
+		downPayment,
+		uponCompletion,
+		signedAt,
+	)
+	qb.SQL("ON CONFLICT (contract_id) DO UPDATE SET vendor_id = EXCLUDED.vendor_id, title = EXCLUDED.title, currency = EXCLUDED.currency, down_payment = EXCLUDED.down_payment, upon_completion = EXCLUDED.upon_completion, signed_at = EXCLUDED.signed_at")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgContractRepository) Remove(ctx context.Context, contract *projecta.Contract) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_contracts")
+	qb.Where(qb.Equal("project_id", contract.ProjectID.String()))
+	qb.Where(qb.Equal("contract_id", contract.ID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, contractNotFound, sql, args)
+}
+
+func (r *PgContractRepository) FindPayments(ctx context.Context, contractID uuid.UUID) ([]*projecta.ContractPayment, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_payments")
+	qb.Select(
+		"projecta_payments.payment_id",
+		"projecta_payments.kind",
+		"projecta_payments.status",
+		"projecta_payments.amount",
+		"projecta_payments.currency",
+		"COALESCE(projecta_payments.payment_date, projecta_payments.created_at)",
+	)
+	qb.Where(qb.Equal("projecta_payments.contract_id", contractID.String()))
+	qb.OrderBy("COALESCE(projecta_payments.payment_date, projecta_payments.created_at)", "projecta_payments.payment_id")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	payments := make([]*projecta.ContractPayment, 0)
+
+	for rows.Next() {
+		var (
+			paymentID string
+			kind      string
+			status    string
+			amount    int64
+			currency  string
+			date      time.Time
+		)
+
+		if err = rows.Scan(&paymentID, &kind, &status, &amount, &currency, &date); err != nil {
+			return nil, err
+		}
+
+		paymentUUID, err := uuid.Parse(paymentID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, &projecta.ContractPayment{
+			ContractID: contractID,
+			PaymentID:  paymentUUID,
+			Kind:       projecta.PaymentKind(kind),
+			Status:     projecta.PaymentStatus(status),
+			Amount:     money.New(amount, currency),
+			Date:       date,
+		})
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *PgContractRepository) LinkPayment(ctx context.Context, contract *projecta.Contract, paymentID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_payments")
+	qb.Set(
+		qb.Assign("contract_id", contract.ID.String()),
+		qb.Assign("vendor_id", contract.VendorID.String()),
+	)
+	qb.Where(qb.Equal("payment_id", paymentID.String()))
+	qb.Where(qb.Equal("project_id", contract.ProjectID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "payment not found", sql, args)
+}
+
+func (r *PgContractRepository) UnlinkPayment(ctx context.Context, contractID uuid.UUID, paymentID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_payments")
+	qb.Set("contract_id = NULL")
+	qb.Where(qb.Equal("payment_id", paymentID.String()))
+	qb.Where(qb.Equal("contract_id", contractID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "contract payment not found", sql, args)
+}
+
+// scanContract reads a contract row, the stages of which are stored as the
+// amounts paid as down payments and upon completion.
+func scanContract(row pgx.Row) (*projecta.Contract, error) {
+	var (
+		contractID     string
+		projectID      string
+		vendorID       string
+		title          string
+		currency       string
+		downPayment    int64
+		uponCompletion int64
+		signedAt       types.NullTime
+	)
+
+	if err := row.Scan(
+		&contractID,
+		&projectID,
+		&vendorID,
+		&title,
+		&currency,
+		&downPayment,
+		&uponCompletion,
+		&signedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	contractUUID, err := uuid.Parse(contractID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vendorUUID, err := uuid.Parse(vendorID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stages := make([]*projecta.ContractStage, 0, 2)
+
+	if downPayment > 0 {
+		stages = append(stages, &projecta.ContractStage{Kind: projecta.DownPayment, Amount: money.New(downPayment, currency)})
+	}
+
+	if uponCompletion > 0 {
+		stages = append(stages, &projecta.ContractStage{Kind: projecta.UponCompletionPayment, Amount: money.New(uponCompletion, currency)})
+	}
+
+	return projecta.NewContract(
+		contractUUID,
+		projectUUID,
+		vendorUUID,
+		title,
+		money.New(downPayment+uponCompletion, currency),
+		stages,
+		signedAt.Time,
+	)
+}
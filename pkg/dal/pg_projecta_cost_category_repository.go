@@ -150,7 +150,9 @@ func (r *PgCostCategoryRepository) Save(ctx context.Context, category *projecta.
 	qb.Where(qb.Equal("project_id", category.ProjectID.String()))
 
 	sql, args := qb.Build()
-	err := r.db.QueryRow(ctx, sql, args...).Scan()
+
+	var exists int
+	err := r.db.QueryRow(ctx, sql, args...).Scan(&exists)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -0,0 +1,383 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+const estimateNotFound = "estimate not found"
+
+type PgEstimateRepository struct {
+	db *PgRepository
+}
+
+func NewPgEstimateRepository(db *PgDbConnection) *PgEstimateRepository {
+	return &PgEstimateRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var estimateColumns = []string{
+	"projecta_estimates.estimate_id",
+	"projecta_estimates.project_id",
+	"projecta_estimates.title",
+	"COALESCE(projecta_estimates.accepted_quote_id::TEXT, '')",
+}
+
+func newEstimateSelectBuilder(personID uuid.UUID, projectID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_estimates")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_estimates.project_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_estimates.project_id", projectID.String()))
+
+	return qb
+}
+
+func (r *PgEstimateRepository) Find(ctx context.Context, filter projecta.EstimateCollectionFilter) (*projecta.EstimateCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newEstimateSelectBuilder(personID, filter.ProjectID)
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	collection := projecta.NewEstimateCollection(total)
+
+	if total == 0 {
+		return collection, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(estimateColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_estimates.created_at", "projecta_estimates.estimate_id")
+
+	sql, args = qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make([]*projecta.Estimate, 0)
+
+	for rows.Next() {
+		e, err := scanEstimate(rows)
+
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		estimates = append(estimates, e)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// the quotes are read once the rows are closed, as a transaction runs a
+	// single query at a time
+	if err = r.findQuotes(ctx, estimates...); err != nil {
+		return nil, err
+	}
+
+	for _, e := range estimates {
+		collection.Add(e)
+	}
+
+	return collection, nil
+}
+
+func (r *PgEstimateRepository) FindOne(ctx context.Context, filter projecta.EstimateFilter) (*projecta.Estimate, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newEstimateSelectBuilder(personID, filter.ProjectID)
+	qb.Select(estimateColumns...)
+	qb.Where(qb.Equal("projecta_estimates.estimate_id", filter.EstimateID.String()))
+
+	sql, args := qb.Build()
+
+	e, err := scanEstimate(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(estimateNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	if err = r.findQuotes(ctx, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// findQuotes reads the quotes of the estimates with their items, in the order
+// they were added.
+func (r *PgEstimateRepository) findQuotes(ctx context.Context, estimates ...*projecta.Estimate) error {
+	byID := make(map[uuid.UUID]*projecta.Estimate, len(estimates))
+	ids := make([]any, 0, len(estimates))
+
+	for _, e := range estimates {
+		byID[e.ID] = e
+		ids = append(ids, e.ID.String())
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_quotes")
+	qb.Join("projecta_quote_items", "projecta_quote_items.quote_id = projecta_quotes.quote_id")
+	qb.Select(
+		"projecta_quotes.quote_id",
+		"projecta_quotes.estimate_id",
+		"projecta_quotes.vendor_id",
+		"COALESCE(projecta_quotes.note, '')",
+		"projecta_quote_items.type_id",
+		"COALESCE(projecta_quote_items.description, '')",
+		"projecta_quote_items.quantity::FLOAT8",
+		"projecta_quote_items.unit_price",
+		"projecta_quote_items.currency",
+	)
+	qb.Where(qb.In("projecta_quotes.estimate_id", ids...))
+	qb.OrderBy("projecta_quotes.created_at", "projecta_quotes.quote_id", "projecta_quote_items.position")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var last *projecta.Quote
+
+	for rows.Next() {
+		var (
+			quoteID     string
+			estimateID  string
+			vendorID    string
+			note        string
+			typeID      string
+			description string
+			quantity    float64
+			unitPrice   int64
+			currency    string
+		)
+
+		if err = rows.Scan(&quoteID, &estimateID, &vendorID, &note, &typeID, &description, &quantity, &unitPrice, &currency); err != nil {
+			return err
+		}
+
+		quoteUUID, err := uuid.Parse(quoteID)
+
+		if err != nil {
+			return err
+		}
+
+		typeUUID, err := uuid.Parse(typeID)
+
+		if err != nil {
+			return err
+		}
+
+		if last == nil || last.ID != quoteUUID {
+			estimateUUID, err := uuid.Parse(estimateID)
+
+			if err != nil {
+				return err
+			}
+
+			vendorUUID, err := uuid.Parse(vendorID)
+
+			if err != nil {
+				return err
+			}
+
+			e, ok := byID[estimateUUID]
+
+			if !ok {
+				return fmt.Errorf("quote %s of an unknown estimate %s", quoteID, estimateID)
+			}
+
+			last = &projecta.Quote{
+				ID:         quoteUUID,
+				EstimateID: estimateUUID,
+				VendorID:   vendorUUID,
+				Note:       note,
+			}
+			e.Quotes = append(e.Quotes, last)
+		}
+
+		last.Items = append(last.Items, &projecta.EstimateItem{
+			TypeID:      typeUUID,
+			Description: description,
+			Quantity:    quantity,
+			UnitPrice:   money.New(unitPrice, currency),
+		})
+	}
+
+	return rows.Err()
+}
+
+func (r *PgEstimateRepository) Save(ctx context.Context, estimate *projecta.Estimate) error {
+	var acceptedQuoteID any
+
+	if estimate.AcceptedQuoteID != uuid.Nil {
+		acceptedQuoteID = estimate.AcceptedQuoteID.String()
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_estimates")
+	qb.Cols("estimate_id", "project_id", "title", "accepted_quote_id")
+	qb.Values(estimate.ID.String(), estimate.ProjectID.String(), estimate.Title, acceptedQuoteID)
+	qb.SQL("ON CONFLICT (estimate_id) DO UPDATE SET title = EXCLUDED.title, accepted_quote_id = EXCLUDED.accepted_quote_id")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgEstimateRepository) Remove(ctx context.Context, estimate *projecta.Estimate) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_estimates")
+	qb.Where(qb.Equal("project_id", estimate.ProjectID.String()))
+	qb.Where(qb.Equal("estimate_id", estimate.ID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, estimateNotFound, sql, args)
+}
+
+func (r *PgEstimateRepository) SaveQuote(ctx context.Context, quote *projecta.Quote) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_quotes")
+	qb.Cols("quote_id", "estimate_id", "vendor_id", "note")
+	qb.Values(quote.ID.String(), quote.EstimateID.String(), quote.VendorID.String(), quote.Note)
+	qb.SQL("ON CONFLICT (quote_id) DO UPDATE SET vendor_id = EXCLUDED.vendor_id, note = EXCLUDED.note")
+
+	sql, args := qb.Build()
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return err
+	}
+
+	deleteItems := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	deleteItems.DeleteFrom("projecta_quote_items")
+	deleteItems.Where(deleteItems.Equal("quote_id", quote.ID.String()))
+
+	sql, args = deleteItems.Build()
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return err
+	}
+
+	insertItems := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	insertItems.InsertInto("projecta_quote_items")
+	insertItems.Cols("quote_id", "position", "type_id", "description", "quantity", "unit_price", "currency")
+
+	for i, item := range quote.Items {
+		insertItems.Values(
+			quote.ID.String(),
+			i+1,
+			item.TypeID.String(),
+			item.Description,
+			item.Quantity,
+			item.UnitPrice.Amount(),
+			item.UnitPrice.Currency().Code,
+		)
+	}
+
+	sql, args = insertItems.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgEstimateRepository) RemoveQuote(ctx context.Context, quote *projecta.Quote) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_quotes")
+	qb.Where(qb.Equal("estimate_id", quote.EstimateID.String()))
+	qb.Where(qb.Equal("quote_id", quote.ID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "quote not found", sql, args)
+}
+
+func scanEstimate(row pgx.Row) (*projecta.Estimate, error) {
+	var (
+		estimateID      string
+		projectID       string
+		title           string
+		acceptedQuoteID string
+	)
+
+	if err := row.Scan(&estimateID, &projectID, &title, &acceptedQuoteID); err != nil {
+		return nil, err
+	}
+
+	estimateUUID, err := uuid.Parse(estimateID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := projecta.NewEstimate(estimateUUID, projectUUID, title)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if acceptedQuoteID != "" {
+		if e.AcceptedQuoteID, err = uuid.Parse(acceptedQuoteID); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
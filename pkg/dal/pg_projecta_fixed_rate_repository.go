@@ -0,0 +1,110 @@
+package dal
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type PgFixedRateRepository struct {
+	db *PgRepository
+}
+
+func NewPgFixedRateRepository(db *PgDbConnection) *PgFixedRateRepository {
+	return &PgFixedRateRepository{
+		db: &PgRepository{db},
+	}
+}
+
+func (r *PgFixedRateRepository) Find(ctx context.Context, projectID uuid.UUID) ([]*projecta.FixedRate, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_currencies")
+	qb.Select(
+		"code",
+		"base_currency",
+		"exchange_rate",
+	)
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.OrderBy("code")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rates := make([]*projecta.FixedRate, 0)
+
+	for rows.Next() {
+		var (
+			code         string
+			baseCurrency string
+			exchangeRate float64
+		)
+
+		if err = rows.Scan(&code, &baseCurrency, &exchangeRate); err != nil {
+			return nil, err
+		}
+
+		rate, err := projecta.NewFixedRate(projectID, code, baseCurrency, exchangeRate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *PgFixedRateRepository) Save(ctx context.Context, rate *projecta.FixedRate) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_currencies")
+	qb.Cols(
+		"project_id",
+		"code",
+		"base_currency",
+		"exchange_rate",
+	)
+	qb.Values(
+		rate.ProjectID.String(),
+		rate.Currency,
+		rate.BaseCurrency,
+		rate.Rate,
+	)
+	qb.SQL("ON CONFLICT (project_id, code) DO UPDATE SET base_currency = EXCLUDED.base_currency, exchange_rate = EXCLUDED.exchange_rate")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgFixedRateRepository) Remove(ctx context.Context, projectID uuid.UUID, currency string) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_currencies")
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.Equal("code", currency))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException("fixed rate not found", nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"time"
+)
+
+const loanNotFound = "loan not found"
+
+type PgLoanRepository struct {
+	db *PgRepository
+}
+
+func NewPgLoanRepository(db *PgDbConnection) *PgLoanRepository {
+	return &PgLoanRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var loanColumns = []string{
+	"projecta_loans.loan_id",
+	"projecta_loans.project_id",
+	"COALESCE(projecta_loans.description, '')",
+	"projecta_loans.principal",
+	"projecta_loans.currency",
+	"projecta_loans.annual_rate::FLOAT8",
+	"projecta_loans.term",
+	"projecta_loans.schedule",
+	"projecta_loans.start_date",
+}
+
+func newLoanSelectBuilder(personID uuid.UUID, projectID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_loans")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_loans.project_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_loans.project_id", projectID.String()))
+
+	return qb
+}
+
+func (r *PgLoanRepository) Find(ctx context.Context, filter projecta.LoanCollectionFilter) (*projecta.LoanCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newLoanSelectBuilder(personID, filter.ProjectID)
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	collection := projecta.NewLoanCollection(total)
+
+	if total == 0 {
+		return collection, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(loanColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_loans.start_date", "projecta_loans.loan_id")
+
+	sql, args = qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanLoan(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		collection.Add(l)
+	}
+
+	return collection, rows.Err()
+}
+
+func (r *PgLoanRepository) FindOne(ctx context.Context, filter projecta.LoanFilter) (*projecta.Loan, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newLoanSelectBuilder(personID, filter.ProjectID)
+	qb.Select(loanColumns...)
+	qb.Where(qb.Equal("projecta_loans.loan_id", filter.LoanID.String()))
+
+	sql, args := qb.Build()
+
+	l, err := scanLoan(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(loanNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (r *PgLoanRepository) Save(ctx context.Context, loan *projecta.Loan) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_loans")
+	qb.Cols(
+		"loan_id",
+		"project_id",
+		"description",
+		"principal",
+		"currency",
+		"annual_rate",
+		"term",
+		"schedule",
+		"start_date",
+	)
+	qb.Values(
+		loan.ID.String(),
+		loan.ProjectID.String(),
+		loan.Description,
+		loan.Principal.Amount(),
+		loan.Principal.Currency().Code,
+		loan.AnnualRate,
+		loan.Term,
+		loan.Schedule.String(),
+		loan.StartDate,
+	)
+	qb.SQL("ON CONFLICT (loan_id) DO UPDATE SET description = EXCLUDED.description, principal = EXCLUDED.principal, currency = EXCLUDED.currency, annual_rate = EXCLUDED.annual_rate, term = EXCLUDED.term, schedule = EXCLUDED.schedule, start_date = EXCLUDED.start_date")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgLoanRepository) Remove(ctx context.Context, loan *projecta.Loan) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_loans")
+	qb.Where(qb.Equal("project_id", loan.ProjectID.String()))
+	qb.Where(qb.Equal("loan_id", loan.ID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException(loanNotFound, nil)
+	}
+
+	return nil
+}
+
+func (r *PgLoanRepository) FindPayments(ctx context.Context, loanID uuid.UUID) ([]*projecta.LoanPayment, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_loan_payments")
+	qb.Join("projecta_payments", "projecta_payments.payment_id = projecta_loan_payments.payment_id")
+	qb.Select(
+		"projecta_loan_payments.payment_id",
+		"projecta_loan_payments.installment",
+		"projecta_payments.amount",
+		"projecta_payments.currency",
+		"COALESCE(projecta_payments.payment_date, projecta_payments.created_at)",
+	)
+	qb.Where(qb.Equal("projecta_loan_payments.loan_id", loanID.String()))
+	qb.Where(qb.Equal("projecta_payments.status", projecta.PaymentPaid.String()))
+	qb.OrderBy(
+		"projecta_loan_payments.installment",
+		"COALESCE(projecta_payments.payment_date, projecta_payments.created_at)",
+		"projecta_loan_payments.payment_id",
+	)
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	payments := make([]*projecta.LoanPayment, 0)
+
+	for rows.Next() {
+		var (
+			paymentID   string
+			installment int
+			amount      int64
+			currency    string
+			date        time.Time
+		)
+
+		if err = rows.Scan(&paymentID, &installment, &amount, &currency, &date); err != nil {
+			return nil, err
+		}
+
+		paymentUUID, err := uuid.Parse(paymentID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, &projecta.LoanPayment{
+			LoanID:      loanID,
+			PaymentID:   paymentUUID,
+			Installment: installment,
+			Amount:      money.New(amount, currency),
+			Date:        date,
+		})
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *PgLoanRepository) SavePayment(ctx context.Context, payment *projecta.LoanPayment) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_loan_payments")
+	qb.Cols("payment_id", "loan_id", "installment")
+	qb.Values(payment.PaymentID.String(), payment.LoanID.String(), payment.Installment)
+	qb.SQL("ON CONFLICT (payment_id) DO UPDATE SET loan_id = EXCLUDED.loan_id, installment = EXCLUDED.installment")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgLoanRepository) RemovePayment(ctx context.Context, loanID uuid.UUID, paymentID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_loan_payments")
+	qb.Where(qb.Equal("loan_id", loanID.String()))
+	qb.Where(qb.Equal("payment_id", paymentID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException("loan payment not found", nil)
+	}
+
+	return nil
+}
+
+func scanLoan(row pgx.Row) (*projecta.Loan, error) {
+	var (
+		loanID      string
+		projectID   string
+		description string
+		principal   int64
+		currency    string
+		annualRate  float64
+		term        int
+		schedule    string
+		startDate   time.Time
+	)
+
+	if err := row.Scan(
+		&loanID,
+		&projectID,
+		&description,
+		&principal,
+		&currency,
+		&annualRate,
+		&term,
+		&schedule,
+		&startDate,
+	); err != nil {
+		return nil, err
+	}
+
+	loanUUID, err := uuid.Parse(loanID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.NewLoan(
+		loanUUID,
+		projectUUID,
+		description,
+		money.New(principal, currency),
+		annualRate,
+		term,
+		projecta.LoanSchedule(schedule),
+		startDate,
+	)
+}
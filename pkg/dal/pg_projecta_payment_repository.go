@@ -2,6 +2,7 @@ package dal
 
 import (
 	"context"
+	types "database/sql"
 	"fmt"
 	"github.com/Rhymond/go-money"
 	"github.com/google/uuid"
@@ -52,6 +53,16 @@ func (r *PgPaymentRepository) FindOne(ctx context.Context, filter projecta.Payme
 		"COALESCE(people.display_name, '') display_name",
 		"COALESCE(projecta_payments.payment_date, projecta_payments.created_at) payment_date",
 		"projecta_payments.kind",
+		"projecta_projects.main_currency",
+		"projecta_payments.home_amount",
+		"projecta_payments.home_currency",
+		"projecta_payments.exchange_rate",
+		"projecta_payments.manual_rate",
+		"projecta_payments.status",
+		"projecta_payments.due_date",
+		"projecta_payments.vendor_id::TEXT",
+		"projecta_payments.contract_id::TEXT",
+		tagsColumn(projecta.TaggedPayment),
 	)
 
 	if filter.ProjectID != uuid.Nil {
@@ -82,6 +93,16 @@ func (r *PgPaymentRepository) FindOne(ctx context.Context, filter projecta.Payme
 		displayName  string
 		expenseDate  time.Time
 		expenseKind  string
+		mainCurrency string
+		homeAmount   types.NullInt64
+		homeCurrency types.NullString
+		exchangeRate types.NullFloat64
+		manualRate   bool
+		status       string
+		dueDate      types.NullTime
+		vendorID     types.NullString
+		contractID   types.NullString
+		tags         []string
 	)
 
 	if err = r.db.QueryRow(
@@ -104,11 +125,21 @@ func (r *PgPaymentRepository) FindOne(ctx context.Context, filter projecta.Payme
 		&displayName,
 		&expenseDate,
 		&expenseKind,
+		&mainCurrency,
+		&homeAmount,
+		&homeCurrency,
+		&exchangeRate,
+		&manualRate,
+		&status,
+		&dueDate,
+		&vendorID,
+		&contractID,
+		&tags,
 	); err != nil {
 		return nil, err
 	}
 
-	return toExpense(
+	expense := toExpense(
 		expenseID,
 		projectID,
 		projectName,
@@ -124,7 +155,14 @@ func (r *PgPaymentRepository) FindOne(ctx context.Context, filter projecta.Payme
 		displayName,
 		expenseDate,
 		expenseKind,
-	), nil
+	)
+
+	withStoredConversion(expense, mainCurrency, homeAmount, homeCurrency, exchangeRate, manualRate)
+	withStatus(expense, status, dueDate)
+	withVendor(expense, vendorID, contractID)
+	expense.Tags = tags
+
+	return expense, nil
 }
 
 func (r *PgPaymentRepository) Save(ctx context.Context, expense *projecta.Payment) error {
@@ -178,8 +216,18 @@ func (r *PgPaymentRepository) create(ctx context.Context, expense *projecta.Paym
 		"owner_id",
 		"payment_date",
 		"kind",
+		"home_amount",
+		"home_currency",
+		"exchange_rate",
+		"manual_rate",
+		"status",
+		"due_date",
+		"vendor_id",
+		"contract_id",
 	)
 
+	homeAmount, homeCurrency, exchangeRate := storedConversion(expense)
+
 	qb.Values(
 		expense.ID.String(),
 		expense.Project.ProjectID.String(),
@@ -190,6 +238,14 @@ func (r *PgPaymentRepository) create(ctx context.Context, expense *projecta.Paym
 		expense.Owner.PersonID.String(),
 		expense.Date,
 		expense.Kind.String(),
+		homeAmount,
+		homeCurrency,
+		exchangeRate,
+		expense.ManualRate,
+		paymentStatus(expense),
+		dueDate(expense),
+		nullUUID(expense.VendorID),
+		nullUUID(expense.ContractID),
 	)
 
 	sql, args := qb.Build()
@@ -200,6 +256,8 @@ func (r *PgPaymentRepository) create(ctx context.Context, expense *projecta.Paym
 }
 
 func (r *PgPaymentRepository) update(ctx context.Context, payment *projecta.Payment) error {
+	homeAmount, homeCurrency, exchangeRate := storedConversion(payment)
+
 	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
 	qb.Update("projecta_payments")
 	qb.Set(
@@ -209,6 +267,12 @@ func (r *PgPaymentRepository) update(ctx context.Context, payment *projecta.Paym
 		qb.Assign("description", payment.Description),
 		qb.Assign("payment_date", payment.Date),
 		qb.Assign("kind", payment.Kind.String()),
+		qb.Assign("home_amount", homeAmount),
+		qb.Assign("home_currency", homeCurrency),
+		qb.Assign("exchange_rate", exchangeRate),
+		qb.Assign("manual_rate", payment.ManualRate),
+		qb.Assign("status", paymentStatus(payment)),
+		qb.Assign("due_date", dueDate(payment)),
 	)
 
 	qb.Where(qb.Equal("payment_id", payment.ID.String()))
@@ -271,6 +335,53 @@ func (r *PgPaymentRepository) Find(ctx context.Context, filter projecta.PaymentC
 		qb.Where(qb.Equal("projecta_payments.kind", filter.Kind.String()))
 	}
 
+	if filter.OwnerID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_payments.owner_id", filter.OwnerID.String()))
+	}
+
+	if !filter.From.IsZero() {
+		qb.Where(qb.GreaterEqualThan(paymentDay, filter.From.Format(time.DateOnly)))
+	}
+
+	if !filter.To.IsZero() {
+		qb.Where(qb.LessEqualThan(paymentDay, filter.To.Format(time.DateOnly)))
+	}
+
+	if filter.AmountMin != 0 {
+		qb.Where(qb.GreaterEqualThan("projecta_payments.amount", filter.AmountMin))
+	}
+
+	if filter.AmountMax != 0 {
+		qb.Where(qb.LessEqualThan("projecta_payments.amount", filter.AmountMax))
+	}
+
+	if filter.Currency != "" {
+		qb.Where(qb.Equal("projecta_payments.currency", filter.Currency))
+	}
+
+	if filter.Status != "" {
+		qb.Where(qb.Equal("projecta_payments.status", filter.Status.String()))
+	}
+
+	if filter.VendorID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_payments.vendor_id", filter.VendorID.String()))
+	}
+
+	if filter.ContractID != uuid.Nil {
+		qb.Where(qb.Equal("projecta_payments.contract_id", filter.ContractID.String()))
+	}
+
+	whereTagged(qb, projecta.TaggedPayment, filter.Tags)
+
+	// the descriptions are indexed in both languages, so the query is too
+	if filter.Query != "" {
+		qb.Where(fmt.Sprintf(
+			"projecta_payments.search_vector @@ (websearch_to_tsquery('english', %s) || websearch_to_tsquery('ukrainian', %s))",
+			qb.Var(filter.Query),
+			qb.Var(filter.Query),
+		))
+	}
+
 	qb.Select(qb.As("COUNT(*)", "total"))
 
 	sql, args := qb.Build()
@@ -290,10 +401,11 @@ func (r *PgPaymentRepository) Find(ctx context.Context, filter projecta.PaymentC
 	qb.Limit(filter.Limit)
 	qb.Offset(filter.Offset)
 
+	// the id breaks ties, so the pages neither repeat nor skip payments
 	if filter.OrderBy != "" && filter.Order != "" {
-		qb.OrderBy(fmt.Sprintf("projecta_payments.%s %s", filter.OrderBy, filter.Order.String()))
+		qb.OrderBy(fmt.Sprintf("projecta_payments.%s %s", filter.OrderBy, filter.Order.String()), "projecta_payments.payment_id")
 	} else {
-		qb.OrderBy("projecta_payments.payment_date DESC")
+		qb.OrderBy("projecta_payments.payment_date DESC", "projecta_payments.payment_id")
 	}
 
 	qb.Select(
@@ -312,6 +424,16 @@ func (r *PgPaymentRepository) Find(ctx context.Context, filter projecta.PaymentC
 		"COALESCE(people.display_name, '') display_name",
 		"COALESCE(projecta_payments.payment_date, projecta_payments.created_at) payment_date",
 		"projecta_payments.kind",
+		"projecta_projects.main_currency",
+		"projecta_payments.home_amount",
+		"projecta_payments.home_currency",
+		"projecta_payments.exchange_rate",
+		"projecta_payments.manual_rate",
+		"projecta_payments.status",
+		"projecta_payments.due_date",
+		"projecta_payments.vendor_id::TEXT",
+		"projecta_payments.contract_id::TEXT",
+		tagsColumn(projecta.TaggedPayment),
 	)
 
 	sql, args = qb.Build()
@@ -343,6 +465,16 @@ func (r *PgPaymentRepository) Find(ctx context.Context, filter projecta.PaymentC
 			displayName  string
 			expenseDate  time.Time
 			expenseKind  string
+			mainCurrency string
+			homeAmount   types.NullInt64
+			homeCurrency types.NullString
+			exchangeRate types.NullFloat64
+			manualRate   bool
+			status       string
+			dueDate      types.NullTime
+			vendorID     types.NullString
+			contractID   types.NullString
+			tags         []string
 		)
 		err = rows.Scan(
 			&expenseID,
@@ -360,6 +492,16 @@ func (r *PgPaymentRepository) Find(ctx context.Context, filter projecta.PaymentC
 			&displayName,
 			&expenseDate,
 			&expenseKind,
+			&mainCurrency,
+			&homeAmount,
+			&homeCurrency,
+			&exchangeRate,
+			&manualRate,
+			&status,
+			&dueDate,
+			&vendorID,
+			&contractID,
+			&tags,
 		)
 
 		if err != nil {
@@ -384,12 +526,94 @@ func (r *PgPaymentRepository) Find(ctx context.Context, filter projecta.PaymentC
 			expenseKind,
 		)
 
+		withStoredConversion(expense, mainCurrency, homeAmount, homeCurrency, exchangeRate, manualRate)
+		withStatus(expense, status, dueDate)
+		withVendor(expense, vendorID, contractID)
+		expense.Tags = tags
+
 		collection.Add(expense)
 	}
 
 	return collection, nil
 }
 
+// storedConversion returns the values of the conversion columns, which are
+// NULL for payments booked without an exchange rate.
+func storedConversion(payment *projecta.Payment) (any, any, any) {
+	if payment.HomeAmount == nil {
+		return nil, nil, nil
+	}
+
+	return payment.HomeAmount.Amount(), payment.HomeAmount.Currency().Code, payment.ExchangeRate
+}
+
+func withStoredConversion(
+	expense *projecta.Payment,
+	mainCurrency string,
+	homeAmount types.NullInt64,
+	homeCurrency types.NullString,
+	exchangeRate types.NullFloat64,
+	manualRate bool,
+) {
+	if mainCurrency != "" {
+		expense.Project.MainCurrency = mainCurrency
+	}
+
+	if homeAmount.Valid && homeCurrency.Valid && exchangeRate.Valid {
+		expense.HomeAmount = money.New(homeAmount.Int64, homeCurrency.String)
+		expense.ExchangeRate = exchangeRate.Float64
+		expense.ManualRate = manualRate
+	}
+}
+
+// paymentStatus returns the status column value, payments built without one
+// have been paid.
+func paymentStatus(payment *projecta.Payment) string {
+	if payment.Status == "" {
+		return projecta.PaymentPaid.String()
+	}
+
+	return payment.Status.String()
+}
+
+// dueDate returns the due_date column value, NULL for payments due by no day.
+func dueDate(payment *projecta.Payment) any {
+	if payment.DueDate.IsZero() {
+		return nil
+	}
+
+	return payment.DueDate
+}
+
+func withStatus(expense *projecta.Payment, status string, dueDate types.NullTime) {
+	if paymentStatus, err := projecta.ToPaymentStatus(status); err == nil {
+		expense.Status = paymentStatus
+	}
+
+	if dueDate.Valid {
+		expense.DueDate = dueDate.Time
+	}
+}
+
+// nullUUID returns the value of a nullable reference column.
+func nullUUID(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+
+	return id.String()
+}
+
+func withVendor(expense *projecta.Payment, vendorID types.NullString, contractID types.NullString) {
+	if vendorID.Valid {
+		expense.VendorID, _ = uuid.Parse(vendorID.String)
+	}
+
+	if contractID.Valid {
+		expense.ContractID, _ = uuid.Parse(contractID.String)
+	}
+}
+
 func toExpense(
 	expenseID string,
 	projectID string,
@@ -456,3 +680,160 @@ func toExpense(
 
 	return expense
 }
+
+// paymentDay is the day a payment was made, the day it was logged for the
+// payments nobody dated.
+const paymentDay = "COALESCE(projecta_payments.payment_date, projecta_payments.created_at)::DATE"
+
+// paymentBooked matches the payments already converted into the project main
+// currency, which are summed by their booked amount.
+const paymentBooked = "projecta_payments.home_amount IS NOT NULL AND projecta_payments.home_currency = projecta_projects.main_currency"
+
+func (r *PgPaymentRepository) Totals(ctx context.Context, filter projecta.PaymentTotalsFilter) ([]*projecta.PaymentSubtotal, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, core.FailedToIdentifyRequester
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_payments")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_payments.project_id")
+
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_payments.project_id", filter.ProjectID.String()))
+
+	// only the payments made are spent, the planned and due ones are committed
+	if filter.Committed {
+		qb.Where(qb.In("projecta_payments.status", projecta.PaymentPlanned.String(), projecta.PaymentDue.String()))
+	} else {
+		qb.Where(qb.Equal("projecta_payments.status", projecta.PaymentPaid.String()))
+	}
+
+	if !filter.From.IsZero() {
+		qb.Where(qb.GreaterEqualThan(paymentDay, filter.From.Format(time.DateOnly)))
+	}
+
+	if !filter.To.IsZero() {
+		qb.Where(qb.LessEqualThan(paymentDay, filter.To.Format(time.DateOnly)))
+	}
+
+	var (
+		currency         string
+		day              time.Time
+		categoryID       string
+		categoryName     string
+		typeID           string
+		typeName         string
+		kind             string
+		ownerID          string
+		ownerFirstName   string
+		ownerDisplayName string
+		amount           int64
+		count            int
+	)
+
+	columns := []string{
+		fmt.Sprintf("CASE WHEN %s THEN projecta_payments.home_currency ELSE projecta_payments.currency END", paymentBooked),
+		paymentDay,
+	}
+	dest := []any{&currency, &day}
+
+	if filter.Groups(projecta.GroupByCategory) || filter.Groups(projecta.GroupByType) {
+		qb.Join("projecta_cost_types", "projecta_cost_types.type_id = projecta_payments.type_id")
+	}
+
+	if filter.Groups(projecta.GroupByCategory) {
+		qb.Join("projecta_cost_categories", "projecta_cost_categories.category_id = projecta_cost_types.category_id")
+		columns = append(columns, "projecta_cost_categories.category_id", "projecta_cost_categories.name")
+		dest = append(dest, &categoryID, &categoryName)
+	}
+
+	if filter.Groups(projecta.GroupByType) {
+		columns = append(columns, "projecta_cost_types.type_id", "projecta_cost_types.name")
+		dest = append(dest, &typeID, &typeName)
+	}
+
+	if filter.Groups(projecta.GroupByKind) {
+		columns = append(columns, "projecta_payments.kind")
+		dest = append(dest, &kind)
+	}
+
+	if filter.Groups(projecta.GroupByOwner) {
+		qb.Join("people", "people.person_id = projecta_payments.owner_id")
+		columns = append(columns, "projecta_payments.owner_id", "people.first_name", "COALESCE(people.display_name, '')")
+		dest = append(dest, &ownerID, &ownerFirstName, &ownerDisplayName)
+	}
+
+	positions := make([]string, len(columns))
+	for i := range columns {
+		positions[i] = fmt.Sprint(i + 1)
+	}
+
+	qb.Select(append(
+		columns,
+		fmt.Sprintf("SUM(CASE WHEN %s THEN projecta_payments.home_amount ELSE projecta_payments.amount END)::BIGINT", paymentBooked),
+		"COUNT(*)",
+	)...)
+	qb.GroupBy(positions...)
+	qb.OrderBy(positions...)
+
+	dest = append(dest, &amount, &count)
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	totals := make([]*projecta.PaymentSubtotal, 0)
+
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		subtotal := &projecta.PaymentSubtotal{
+			Amount: money.New(amount, currency),
+			Date:   day,
+			Count:  count,
+		}
+
+		if filter.Groups(projecta.GroupByCategory) {
+			subtotal.Category = &projecta.CostCategory{
+				ID:        uuid.MustParse(categoryID),
+				ProjectID: filter.ProjectID,
+				Name:      categoryName,
+			}
+		}
+
+		if filter.Groups(projecta.GroupByType) {
+			subtotal.Type = &projecta.CostType{
+				ID:        uuid.MustParse(typeID),
+				ProjectID: filter.ProjectID,
+				Category:  subtotal.Category,
+				Name:      typeName,
+			}
+		}
+
+		if filter.Groups(projecta.GroupByKind) {
+			subtotal.Kind, _ = projecta.ToPaymentKind(kind)
+		}
+
+		if filter.Groups(projecta.GroupByOwner) {
+			subtotal.Owner = &projecta.Owner{
+				PersonID:    uuid.MustParse(ownerID),
+				FirstName:   ownerFirstName,
+				DisplayName: ownerDisplayName,
+			}
+		}
+
+		totals = append(totals, subtotal)
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,526 @@
+package dal
+
+import (
+	"context"
+	types "database/sql"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"time"
+)
+
+type PgRecurringPaymentRepository struct {
+	db *PgRepository
+}
+
+func NewPgRecurringPaymentRepository(db *PgDbConnection) *PgRecurringPaymentRepository {
+	return &PgRecurringPaymentRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var recurringPaymentColumns = []string{
+	"projecta_recurring_payments.recurring_id",
+	"projecta_recurring_payments.project_id",
+	"projecta_projects.name",
+	"projecta_projects.main_currency",
+	"projecta_recurring_payments.owner_id",
+	"people.first_name",
+	"COALESCE(people.display_name, '')",
+	"projecta_cost_types.type_id",
+	"projecta_cost_types.name",
+	"projecta_cost_categories.category_id",
+	"projecta_cost_categories.name",
+	"COALESCE(projecta_recurring_payments.description, '')",
+	"projecta_recurring_payments.amount",
+	"projecta_recurring_payments.currency",
+	"projecta_recurring_payments.kind",
+	"projecta_recurring_payments.recurrence",
+	"projecta_recurring_payments.start_date",
+	"projecta_recurring_payments.end_date",
+	"projecta_recurring_payments.processed",
+}
+
+func newRecurringPaymentSelectBuilder() *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_recurring_payments")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_recurring_payments.project_id")
+	qb.Join("people", "people.person_id = projecta_recurring_payments.owner_id")
+	qb.Join("projecta_cost_types", "projecta_cost_types.type_id = projecta_recurring_payments.type_id")
+	qb.Join("projecta_cost_categories", "projecta_cost_categories.category_id = projecta_cost_types.category_id")
+
+	return qb
+}
+
+func whereRecurringPaymentVisibleTo(qb *sqlbuilder.SelectBuilder, personID uuid.UUID, projectID uuid.UUID) {
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_recurring_payments.project_id", projectID.String()))
+}
+
+func (r *PgRecurringPaymentRepository) Find(ctx context.Context, filter projecta.RecurringPaymentCollectionFilter) (*projecta.RecurringPaymentCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newRecurringPaymentSelectBuilder()
+	whereRecurringPaymentVisibleTo(qb, personID, filter.ProjectID)
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	collection := projecta.NewRecurringPaymentCollection(total)
+
+	if total == 0 {
+		return collection, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(recurringPaymentColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_recurring_payments.created_at", "projecta_recurring_payments.recurring_id")
+
+	sql, args = qb.Build()
+
+	payments, err := r.query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range payments {
+		collection.Add(p)
+	}
+
+	return collection, nil
+}
+
+func (r *PgRecurringPaymentRepository) FindOne(ctx context.Context, filter projecta.RecurringPaymentFilter) (*projecta.RecurringPayment, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newRecurringPaymentSelectBuilder()
+	qb.Select(recurringPaymentColumns...)
+	whereRecurringPaymentVisibleTo(qb, personID, filter.ProjectID)
+	qb.Where(qb.Equal("projecta_recurring_payments.recurring_id", filter.RecurringID.String()))
+
+	sql, args := qb.Build()
+
+	p, err := scanRecurringPayment(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(recurringPaymentNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *PgRecurringPaymentRepository) FindDue(ctx context.Context, until time.Time) ([]*projecta.RecurringPayment, error) {
+	qb := newRecurringPaymentSelectBuilder()
+	qb.Select(recurringPaymentColumns...)
+	qb.Where(
+		qb.IsNotNull("projecta_recurring_payments.next_date"),
+		qb.LessEqualThan("projecta_recurring_payments.next_date", until),
+		qb.IsNull("projecta_projects.archived_at"),
+	)
+	qb.OrderBy("projecta_recurring_payments.next_date", "projecta_recurring_payments.recurring_id")
+
+	sql, args := qb.Build()
+
+	return r.query(ctx, sql, args...)
+}
+
+func (r *PgRecurringPaymentRepository) query(ctx context.Context, sql string, args ...any) ([]*projecta.RecurringPayment, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	payments := make([]*projecta.RecurringPayment, 0)
+
+	for rows.Next() {
+		p, err := scanRecurringPayment(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *PgRecurringPaymentRepository) Save(ctx context.Context, p *projecta.RecurringPayment) error {
+	var endDate, nextDate any
+
+	if !p.EndDate.IsZero() {
+		endDate = p.EndDate
+	}
+
+	if next, ok := p.NextOccurrence(); ok {
+		nextDate = next
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_recurring_payments")
+	qb.Cols(
+		"recurring_id",
+		"project_id",
+		"owner_id",
+		"type_id",
+		"description",
+		"amount",
+		"currency",
+		"kind",
+		"recurrence",
+		"start_date",
+		"end_date",
+		"processed",
+		"next_date",
+	)
+	qb.Values(
+		p.ID.String(),
+		p.Project.ProjectID.String(),
+		p.Owner.PersonID.String(),
+		p.Type.ID.String(),
+		p.Description,
+		p.Amount.Amount(),
+		p.Amount.Currency().Code,
+		p.Kind.String(),
+		p.Recurrence.String(),
+		p.StartDate,
+		endDate,
+		p.Processed,
+		nextDate,
+	)
+	qb.SQL("ON CONFLICT (recurring_id) DO UPDATE SET processed = EXCLUDED.processed, next_date = EXCLUDED.next_date, updated_at = current_timestamp")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgRecurringPaymentRepository) Remove(ctx context.Context, p *projecta.RecurringPayment) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_recurring_payments")
+	qb.Where(qb.Equal("project_id", p.Project.ProjectID.String()))
+	qb.Where(qb.Equal("recurring_id", p.ID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException(recurringPaymentNotFound, nil)
+	}
+
+	return nil
+}
+
+func (r *PgRecurringPaymentRepository) FindOccurrences(ctx context.Context, recurringID uuid.UUID, from time.Time) ([]*projecta.RecurringOccurrence, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_recurring_occurrences")
+	qb.Select(
+		"occurrence_date",
+		"status",
+		"amount",
+		"currency",
+		"COALESCE(description, '')",
+		"payment_date",
+		"COALESCE(payment_id::text, '')",
+	)
+	qb.Where(qb.Equal("recurring_id", recurringID.String()))
+	qb.Where(qb.GreaterEqualThan("occurrence_date", from))
+	qb.OrderBy("occurrence_date")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	occurrences := make([]*projecta.RecurringOccurrence, 0)
+
+	for rows.Next() {
+		var (
+			date        time.Time
+			status      string
+			amount      types.NullInt64
+			currency    types.NullString
+			description string
+			paymentDate types.NullTime
+			paymentID   string
+		)
+
+		if err = rows.Scan(&date, &status, &amount, &currency, &description, &paymentDate, &paymentID); err != nil {
+			return nil, err
+		}
+
+		occurrence, err := toRecurringOccurrence(recurringID, date, status, amount, currency, description, paymentDate, paymentID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, rows.Err()
+}
+
+func (r *PgRecurringPaymentRepository) SaveOccurrence(ctx context.Context, occurrence *projecta.RecurringOccurrence) error {
+	var amount, currency, paymentDate, paymentID any
+
+	if occurrence.Amount != nil {
+		amount, currency = occurrence.Amount.Amount(), occurrence.Amount.Currency().Code
+	}
+
+	if !occurrence.PaymentDate.IsZero() {
+		paymentDate = occurrence.PaymentDate
+	}
+
+	if occurrence.PaymentID != uuid.Nil {
+		paymentID = occurrence.PaymentID.String()
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_recurring_occurrences")
+	qb.Cols(
+		"recurring_id",
+		"occurrence_date",
+		"status",
+		"amount",
+		"currency",
+		"description",
+		"payment_date",
+		"payment_id",
+	)
+	qb.Values(
+		occurrence.RecurringID.String(),
+		occurrence.Date,
+		occurrence.Status.String(),
+		amount,
+		currency,
+		occurrence.Description,
+		paymentDate,
+		paymentID,
+	)
+	// a booked occurrence is final, which keeps it from being booked twice
+	qb.SQL(fmt.Sprintf(
+		"ON CONFLICT (recurring_id, occurrence_date) DO UPDATE SET status = EXCLUDED.status, amount = EXCLUDED.amount, currency = EXCLUDED.currency, description = EXCLUDED.description, payment_date = EXCLUDED.payment_date, payment_id = EXCLUDED.payment_id, updated_at = current_timestamp WHERE projecta_recurring_occurrences.status <> '%s'",
+		projecta.OccurrenceBooked,
+	))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewValidationException("recurring payment occurrence has been booked already", nil)
+	}
+
+	return nil
+}
+
+// Lock takes a transaction level advisory lock, it is released on commit or
+// rollback, so a replica crashing mid-way never leaves a schedule locked.
+func (r *PgRecurringPaymentRepository) Lock(ctx context.Context, recurringID uuid.UUID) (bool, error) {
+	var locked bool
+
+	err := r.db.QueryRow(
+		ctx,
+		"SELECT pg_try_advisory_xact_lock(hashtextextended($1, 0))",
+		"projecta_recurring_payments:"+recurringID.String(),
+	).Scan(&locked)
+
+	return locked, err
+}
+
+func scanRecurringPayment(row pgx.Row) (*projecta.RecurringPayment, error) {
+	var (
+		recurringID  string
+		projectID    string
+		projectName  string
+		mainCurrency string
+		ownerID      string
+		firstName    string
+		displayName  string
+		typeID       string
+		typeName     string
+		categoryID   string
+		categoryName string
+		description  string
+		amount       int64
+		currency     string
+		kind         string
+		recurrence   string
+		startDate    time.Time
+		endDate      types.NullTime
+		processed    int
+	)
+
+	if err := row.Scan(
+		&recurringID,
+		&projectID,
+		&projectName,
+		&mainCurrency,
+		&ownerID,
+		&firstName,
+		&displayName,
+		&typeID,
+		&typeName,
+		&categoryID,
+		&categoryName,
+		&description,
+		&amount,
+		&currency,
+		&kind,
+		&recurrence,
+		&startDate,
+		&endDate,
+		&processed,
+	); err != nil {
+		return nil, err
+	}
+
+	recurringUUID, err := uuid.Parse(recurringID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ownerUUID, err := uuid.Parse(ownerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paymentKind, err := projecta.ToPaymentKind(kind)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := projecta.ParseRecurrence(recurrence)
+
+	if err != nil {
+		return nil, err
+	}
+
+	costType, err := toCostType(typeID, projectID, typeName, "", categoryID, categoryName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &projecta.RecurringPayment{
+		ID: recurringUUID,
+		Project: &projecta.Project{
+			ProjectID:    projectUUID,
+			Name:         projectName,
+			MainCurrency: mainCurrency,
+		},
+		Owner: &projecta.Owner{
+			PersonID:    ownerUUID,
+			FirstName:   firstName,
+			DisplayName: displayName,
+		},
+		Type:        costType,
+		Description: description,
+		Amount:      money.New(amount, currency),
+		Kind:        paymentKind,
+		Recurrence:  rule,
+		StartDate:   startDate,
+		EndDate:     endDate.Time,
+		Processed:   processed,
+	}, nil
+}
+
+func toRecurringOccurrence(
+	recurringID uuid.UUID,
+	date time.Time,
+	status string,
+	amount types.NullInt64,
+	currency types.NullString,
+	description string,
+	paymentDate types.NullTime,
+	paymentID string,
+) (*projecta.RecurringOccurrence, error) {
+	occurrenceStatus, err := projecta.ToOccurrenceStatus(status)
+
+	if err != nil {
+		return nil, err
+	}
+
+	occurrence := &projecta.RecurringOccurrence{
+		RecurringID: recurringID,
+		Date:        date,
+		Status:      occurrenceStatus,
+		Description: description,
+		PaymentDate: paymentDate.Time,
+	}
+
+	if amount.Valid && currency.Valid {
+		occurrence.Amount = money.New(amount.Int64, currency.String)
+	}
+
+	if paymentID != "" {
+		if occurrence.PaymentID, err = uuid.Parse(paymentID); err != nil {
+			return nil, err
+		}
+	}
+
+	return occurrence, nil
+}
+
+const recurringPaymentNotFound = "recurring payment not found"
@@ -0,0 +1,225 @@
+package dal
+
+import (
+	"context"
+	types "database/sql"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"time"
+)
+
+type PgSettlementRepository struct {
+	db *PgRepository
+}
+
+func NewPgSettlementRepository(db *PgDbConnection) *PgSettlementRepository {
+	return &PgSettlementRepository{
+		db: &PgRepository{db},
+	}
+}
+
+func (r *PgSettlementRepository) FindWeights(ctx context.Context, projectID uuid.UUID) ([]*projecta.SettlementWeight, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_settlement_weights")
+	qb.Select(
+		"person_id",
+		"weight",
+	)
+	qb.Where(qb.Equal("project_id", projectID.String()))
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	weights := make([]*projecta.SettlementWeight, 0)
+
+	for rows.Next() {
+		var (
+			personID string
+			weight   float64
+		)
+
+		if err = rows.Scan(&personID, &weight); err != nil {
+			return nil, err
+		}
+
+		w, err := projecta.NewSettlementWeight(projectID, uuid.MustParse(personID), weight)
+
+		if err != nil {
+			return nil, err
+		}
+
+		weights = append(weights, w)
+	}
+
+	return weights, rows.Err()
+}
+
+func (r *PgSettlementRepository) SaveWeight(ctx context.Context, weight *projecta.SettlementWeight) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_settlement_weights")
+	qb.Cols(
+		"project_id",
+		"person_id",
+		"weight",
+	)
+	qb.Values(
+		weight.ProjectID.String(),
+		weight.PersonID.String(),
+		weight.Weight,
+	)
+	qb.SQL("ON CONFLICT (project_id, person_id) DO UPDATE SET weight = EXCLUDED.weight")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgSettlementRepository) FindTransfers(ctx context.Context, projectID uuid.UUID) ([]*projecta.SettlementTransfer, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_settlement_transfers")
+	qb.Join("people senders", "senders.person_id = projecta_settlement_transfers.from_person_id")
+	qb.Join("people recipients", "recipients.person_id = projecta_settlement_transfers.to_person_id")
+	qb.Select(
+		"projecta_settlement_transfers.transfer_id",
+		"projecta_settlement_transfers.from_person_id",
+		"senders.first_name",
+		"COALESCE(senders.display_name, '')",
+		"projecta_settlement_transfers.to_person_id",
+		"recipients.first_name",
+		"COALESCE(recipients.display_name, '')",
+		"projecta_settlement_transfers.amount",
+		"projecta_settlement_transfers.currency",
+		"projecta_settlement_transfers.transfer_date",
+		"projecta_settlement_transfers.description",
+	)
+	qb.Where(qb.Equal("projecta_settlement_transfers.project_id", projectID.String()))
+	qb.OrderBy("projecta_settlement_transfers.transfer_date DESC")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	transfers := make([]*projecta.SettlementTransfer, 0)
+
+	for rows.Next() {
+		var (
+			transferID      string
+			fromID          string
+			fromFirstName   string
+			fromDisplayName string
+			toID            string
+			toFirstName     string
+			toDisplayName   string
+			amount          int64
+			currency        string
+			transferDate    time.Time
+			description     types.NullString
+		)
+
+		if err = rows.Scan(
+			&transferID,
+			&fromID,
+			&fromFirstName,
+			&fromDisplayName,
+			&toID,
+			&toFirstName,
+			&toDisplayName,
+			&amount,
+			&currency,
+			&transferDate,
+			&description,
+		); err != nil {
+			return nil, err
+		}
+
+		transfer, err := projecta.NewSettlementTransfer(
+			uuid.MustParse(transferID),
+			projectID,
+			&projecta.Owner{PersonID: uuid.MustParse(fromID), FirstName: fromFirstName, DisplayName: fromDisplayName},
+			&projecta.Owner{PersonID: uuid.MustParse(toID), FirstName: toFirstName, DisplayName: toDisplayName},
+			money.New(amount, currency),
+			transferDate,
+			description.String,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r *PgSettlementRepository) SaveTransfer(ctx context.Context, transfer *projecta.SettlementTransfer) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_settlement_transfers")
+	qb.Cols(
+		"transfer_id",
+		"project_id",
+		"from_person_id",
+		"to_person_id",
+		"amount",
+		"currency",
+		"transfer_date",
+		"description",
+	)
+	qb.Values(
+		transfer.ID.String(),
+		transfer.ProjectID.String(),
+		transfer.From.PersonID.String(),
+		transfer.To.PersonID.String(),
+		transfer.Amount.Amount(),
+		transfer.Amount.Currency().Code,
+		transfer.Date,
+		transfer.Description,
+	)
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgSettlementRepository) RemoveTransfer(ctx context.Context, projectID uuid.UUID, transferID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_settlement_transfers")
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.Equal("transfer_id", transferID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException(settlementTransferNotFound, nil)
+	}
+
+	return nil
+}
+
+const settlementTransferNotFound = "settlement transfer not found"
@@ -0,0 +1,298 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"strings"
+	"time"
+)
+
+// taggedTable is a table of tagged records along with the table linking them
+// to their tags.
+type taggedTable struct {
+	table  string
+	links  string
+	column string
+}
+
+var taggedTables = map[projecta.TaggedKind]taggedTable{
+	projecta.TaggedPayment: {"projecta_payments", "projecta_payment_tags", "payment_id"},
+	projecta.TaggedAsset:   {"projecta_assets", "projecta_asset_tags", "asset_id"},
+}
+
+// tagsColumn selects the names of the tags of the record, sorted.
+func tagsColumn(kind projecta.TaggedKind) string {
+	t := taggedTables[kind]
+
+	return fmt.Sprintf(
+		"ARRAY(SELECT projecta_tags.name FROM %[2]s JOIN projecta_tags ON projecta_tags.tag_id = %[2]s.tag_id WHERE %[2]s.%[3]s = %[1]s.%[3]s ORDER BY projecta_tags.name)",
+		t.table, t.links, t.column,
+	)
+}
+
+// whereTagged limits the records to the ones carrying all the tags.
+func whereTagged(qb *sqlbuilder.SelectBuilder, kind projecta.TaggedKind, tags []string) {
+	t := taggedTables[kind]
+
+	for _, tag := range tags {
+		qb.Where(fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %[2]s JOIN projecta_tags ON projecta_tags.tag_id = %[2]s.tag_id WHERE %[2]s.%[3]s = %[1]s.%[3]s AND projecta_tags.name = %[4]s)",
+			t.table, t.links, t.column, qb.Var(tag),
+		))
+	}
+}
+
+type PgTagRepository struct {
+	db *PgRepository
+}
+
+func NewPgTagRepository(db *PgDbConnection) *PgTagRepository {
+	return &PgTagRepository{
+		db: &PgRepository{db},
+	}
+}
+
+func (r *PgTagRepository) Find(ctx context.Context, filter projecta.TagCollectionFilter) ([]*projecta.Tag, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	const (
+		payments = "(SELECT COUNT(*) FROM projecta_payment_tags WHERE projecta_payment_tags.tag_id = projecta_tags.tag_id)"
+		assets   = "(SELECT COUNT(*) FROM projecta_asset_tags WHERE projecta_asset_tags.tag_id = projecta_tags.tag_id)"
+	)
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.Select("projecta_tags.name", payments, assets)
+	qb.From("projecta_tags")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_tags.project_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_tags.project_id", filter.ProjectID.String()))
+
+	if filter.Name != "" {
+		qb.Where(fmt.Sprintf("projecta_tags.name LIKE %s ESCAPE '\\'", qb.Var(likePrefix(filter.Name))))
+	}
+
+	// the tags are kept once nothing carries them anymore, but not suggested
+	qb.Where(fmt.Sprintf("%s + %s > 0", payments, assets))
+	qb.OrderBy(fmt.Sprintf("%s + %s DESC", payments, assets), "projecta_tags.name")
+	qb.Limit(filter.Limit)
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := make([]*projecta.Tag, 0)
+
+	for rows.Next() {
+		tag := &projecta.Tag{}
+
+		if err = rows.Scan(&tag.Name, &tag.Payments, &tag.Assets); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// Save locks the record first, so concurrent edits of its tags do not mix,
+// and to tell a record of another project from one with no tags.
+func (r *PgTagRepository) Save(ctx context.Context, kind projecta.TaggedKind, projectID uuid.UUID, resourceID uuid.UUID, tags []string) error {
+	t, ok := taggedTables[kind]
+
+	if !ok {
+		return fmt.Errorf("unknown tagged kind %q", kind)
+	}
+
+	lock := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	lock.Select("1")
+	lock.From(t.table)
+	lock.Where(lock.Equal(t.column, resourceID.String()))
+	lock.Where(lock.Equal("project_id", projectID.String()))
+	lock.ForUpdate()
+
+	sql, args := lock.Build()
+
+	var found int
+
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&found); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return exceptions.NewNotFoundException(kind.String()+" not found", err)
+		}
+
+		return err
+	}
+
+	unlink := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	unlink.DeleteFrom(t.links)
+	unlink.Where(unlink.Equal(t.column, resourceID.String()))
+
+	sql, args = unlink.Build()
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	names := make([]any, 0, len(tags))
+	create := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	create.InsertInto("projecta_tags")
+	create.Cols("tag_id", "project_id", "name")
+
+	for _, tag := range tags {
+		create.Values(uuid.New().String(), projectID.String(), tag)
+		names = append(names, tag)
+	}
+
+	create.SQL("ON CONFLICT (project_id, name) DO NOTHING")
+
+	sql, args = create.Build()
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return err
+	}
+
+	link := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	link.InsertInto(t.links)
+	link.Cols(t.column, "tag_id")
+
+	sb := link.Select()
+	sb.Select(sb.Var(resourceID.String())+"::UUID", "tag_id")
+	sb.From("projecta_tags")
+	sb.Where(sb.Equal("project_id", projectID.String()))
+	sb.Where(sb.In("name", names...))
+
+	sql, args = link.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+// Totals sums the paid payments and the assets of the project per tag, day
+// and currency. A record with several tags counts towards each of them.
+func (r *PgTagRepository) Totals(ctx context.Context, filter projecta.TagTotalsFilter) ([]*projecta.TagSubtotal, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payments := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	payments.Select(
+		"projecta_tags.name",
+		fmt.Sprintf("'%s'", projecta.TaggedPayment),
+		fmt.Sprintf("CASE WHEN %s THEN projecta_payments.home_currency ELSE projecta_payments.currency END", paymentBooked),
+		paymentDay,
+		fmt.Sprintf("SUM(CASE WHEN %s THEN projecta_payments.home_amount ELSE projecta_payments.amount END)::BIGINT", paymentBooked),
+		"COUNT(*)",
+	)
+	payments.From("projecta_payment_tags")
+	payments.Join("projecta_tags", "projecta_tags.tag_id = projecta_payment_tags.tag_id")
+	payments.Join("projecta_payments", "projecta_payments.payment_id = projecta_payment_tags.payment_id")
+	payments.Join("projecta_projects", "projecta_projects.project_id = projecta_payments.project_id")
+	payments.Where(fmt.Sprintf("projecta_payments.project_id IN (%s)", accessibleProjects(&payments.Cond, personID)))
+	payments.Where(payments.Equal("projecta_payments.project_id", filter.ProjectID.String()))
+	payments.Where(payments.Equal("projecta_payments.status", projecta.PaymentPaid.String()))
+
+	if !filter.From.IsZero() {
+		payments.Where(payments.GreaterEqualThan(paymentDay, filter.From.Format(time.DateOnly)))
+	}
+
+	if !filter.To.IsZero() {
+		payments.Where(payments.LessEqualThan(paymentDay, filter.To.Format(time.DateOnly)))
+	}
+
+	payments.GroupBy("1", "3", "4")
+
+	assets := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	assets.Select(
+		"projecta_tags.name",
+		fmt.Sprintf("'%s'", projecta.TaggedAsset),
+		"projecta_assets.currency",
+		"projecta_assets.acquired_at::DATE",
+		"SUM(projecta_assets.price)::BIGINT",
+		"COUNT(*)",
+	)
+	assets.From("projecta_asset_tags")
+	assets.Join("projecta_tags", "projecta_tags.tag_id = projecta_asset_tags.tag_id")
+	assets.Join("projecta_assets", "projecta_assets.asset_id = projecta_asset_tags.asset_id")
+	assets.Where(fmt.Sprintf("projecta_assets.project_id IN (%s)", accessibleProjects(&assets.Cond, personID)))
+	assets.Where(assets.Equal("projecta_assets.project_id", filter.ProjectID.String()))
+
+	if !filter.From.IsZero() {
+		assets.Where(assets.GreaterEqualThan("projecta_assets.acquired_at::DATE", filter.From.Format(time.DateOnly)))
+	}
+
+	if !filter.To.IsZero() {
+		assets.Where(assets.LessEqualThan("projecta_assets.acquired_at::DATE", filter.To.Format(time.DateOnly)))
+	}
+
+	assets.GroupBy("1", "3", "4")
+
+	sql, args := sqlbuilder.PostgreSQL.NewUnionBuilder().UnionAll(payments, assets).Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	totals := make([]*projecta.TagSubtotal, 0)
+
+	for rows.Next() {
+		var (
+			tag      string
+			kind     string
+			currency string
+			day      time.Time
+			amount   int64
+			count    int
+		)
+
+		if err = rows.Scan(&tag, &kind, &currency, &day, &amount, &count); err != nil {
+			return nil, err
+		}
+
+		totals = append(totals, &projecta.TagSubtotal{
+			Tag:    tag,
+			Kind:   projecta.TaggedKind(kind),
+			Amount: money.New(amount, currency),
+			Date:   day,
+			Count:  count,
+		})
+	}
+
+	return totals, rows.Err()
+}
+
+// likePrefix is the LIKE pattern matching the values starting with prefix, its
+// wildcards and escape character taken literally.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
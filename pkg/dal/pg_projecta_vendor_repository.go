@@ -0,0 +1,410 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+const vendorNotFound = "vendor not found"
+
+type PgVendorRepository struct {
+	db *PgRepository
+}
+
+func NewPgVendorRepository(db *PgDbConnection) *PgVendorRepository {
+	return &PgVendorRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var vendorColumns = []string{
+	"projecta_vendors.vendor_id",
+	"projecta_vendors.owner_id",
+	"COALESCE(projecta_vendors.project_id::TEXT, '')",
+	"projecta_vendors.name",
+	"COALESCE(projecta_vendors.tax_id, '')",
+	"COALESCE(projecta_vendors.contact_name, '')",
+	"COALESCE(projecta_vendors.email, '')",
+	"COALESCE(projecta_vendors.phone, '')",
+	"COALESCE(projecta_vendors.iban, '')",
+}
+
+// accessibleProjects is the subquery of the projects the person owns or takes
+// part in.
+func accessibleProjects(qb *sqlbuilder.Cond, personID uuid.UUID) string {
+	return fmt.Sprintf("SELECT project_id FROM projecta_projects WHERE owner_id = %s UNION SELECT project_id FROM projecta_project_shares WHERE person_id = %s", qb.Var(personID.String()), qb.Var(personID.String()))
+}
+
+// newVendorSelectBuilder selects the vendors of the project: its own ones and
+// the ones shared by its owner and members.
+func newVendorSelectBuilder(personID uuid.UUID, projectID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_vendors")
+	qb.Where(fmt.Sprintf("%s IN (%s)", qb.Var(projectID.String()), accessibleProjects(&qb.Cond, personID)))
+	qb.Where(fmt.Sprintf(
+		"(projecta_vendors.project_id = %s OR (projecta_vendors.project_id IS NULL AND projecta_vendors.owner_id IN (SELECT owner_id FROM projecta_projects WHERE project_id = %s UNION SELECT person_id FROM projecta_project_shares WHERE project_id = %s)))",
+		qb.Var(projectID.String()),
+		qb.Var(projectID.String()),
+		qb.Var(projectID.String()),
+	))
+
+	return qb
+}
+
+func (r *PgVendorRepository) Find(ctx context.Context, filter projecta.VendorCollectionFilter) (*projecta.VendorCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newVendorSelectBuilder(personID, filter.ProjectID)
+
+	if filter.Name != "" {
+		qb.Where(qb.ILike("projecta_vendors.name", fmt.Sprintf("%s%%", filter.Name)))
+	}
+
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	collection := projecta.NewVendorCollection(total)
+
+	if total == 0 {
+		return collection, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(vendorColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_vendors.name", "projecta_vendors.vendor_id")
+
+	sql, args = qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := scanVendor(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		collection.Add(v)
+	}
+
+	return collection, rows.Err()
+}
+
+func (r *PgVendorRepository) FindOne(ctx context.Context, filter projecta.VendorFilter) (*projecta.Vendor, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newVendorSelectBuilder(personID, filter.ProjectID)
+	qb.Select(vendorColumns...)
+	qb.Where(qb.Equal("projecta_vendors.vendor_id", filter.VendorID.String()))
+
+	sql, args := qb.Build()
+
+	v, err := scanVendor(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(vendorNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (r *PgVendorRepository) Save(ctx context.Context, vendor *projecta.Vendor) error {
+	var projectID any
+
+	if !vendor.IsShared() {
+		projectID = vendor.ProjectID.String()
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_vendors")
+	qb.Cols(
+		"vendor_id",
+		"owner_id",
+		"project_id",
+		"name",
+		"tax_id",
+		"contact_name",
+		"email",
+		"phone",
+		"iban",
+	)
+	qb.Values(
+		vendor.ID.String(),
+		vendor.OwnerID.String(),
+		projectID,
+		vendor.Name,
+		vendor.TaxID,
+		vendor.ContactName,
+		vendor.Email,
+		vendor.Phone,
+		vendor.IBAN,
+	)
+	qb.SQL("ON CONFLICT (vendor_id) DO UPDATE SET name = EXCLUDED.name, tax_id = EXCLUDED.tax_id, contact_name = EXCLUDED.contact_name, email = EXCLUDED.email, phone = EXCLUDED.phone, iban = EXCLUDED.iban")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgVendorRepository) Remove(ctx context.Context, vendor *projecta.Vendor) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_vendors")
+	qb.Where(qb.Equal("vendor_id", vendor.ID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, vendorNotFound, sql, args)
+}
+
+func (r *PgVendorRepository) CountContracts(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID) (int, error) {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.Select("COUNT(*)")
+	qb.From("projecta_contracts")
+	qb.Where(qb.Equal("vendor_id", vendorID.String()))
+	qb.Where(qb.NotEqual("project_id", projectID.String()))
+
+	sql, args := qb.Build()
+
+	var count int
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *PgVendorRepository) RemoveContracts(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_contracts")
+	qb.Where(qb.Equal("vendor_id", vendorID.String()))
+	qb.Where(qb.Equal("project_id", projectID.String()))
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgVendorRepository) LinkPayment(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, paymentID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_payments")
+	qb.Set(qb.Assign("vendor_id", vendorID.String()))
+	qb.Where(qb.Equal("payment_id", paymentID.String()))
+	qb.Where(qb.Equal("project_id", projectID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "payment not found", sql, args)
+}
+
+func (r *PgVendorRepository) UnlinkPayment(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, paymentID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_payments")
+	qb.Set("vendor_id = NULL", "contract_id = NULL")
+	qb.Where(qb.Equal("payment_id", paymentID.String()))
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.Equal("vendor_id", vendorID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "vendor payment not found", sql, args)
+}
+
+func (r *PgVendorRepository) LinkAsset(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, assetID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_assets")
+	qb.Set(qb.Assign("vendor_id", vendorID.String()))
+	qb.Where(qb.Equal("asset_id", assetID.String()))
+	qb.Where(qb.Equal("project_id", projectID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "asset not found", sql, args)
+}
+
+func (r *PgVendorRepository) UnlinkAsset(ctx context.Context, vendorID uuid.UUID, projectID uuid.UUID, assetID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewUpdateBuilder()
+	qb.Update("projecta_assets")
+	qb.Set("vendor_id = NULL")
+	qb.Where(qb.Equal("asset_id", assetID.String()))
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.Equal("vendor_id", vendorID.String()))
+
+	sql, args := qb.Build()
+
+	return execAffecting(ctx, r.db, "vendor asset not found", sql, args)
+}
+
+// execAffecting runs the statement and reports notFound when it touched no
+// rows.
+func execAffecting(ctx context.Context, db *PgRepository, notFound string, sql string, args []any) error {
+	res, err := db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException(notFound, nil)
+	}
+
+	return nil
+}
+
+func (r *PgVendorRepository) Totals(ctx context.Context, vendorID uuid.UUID) ([]*projecta.VendorSubtotal, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payments := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	payments.From("projecta_payments")
+	payments.Select("projecta_payments.status::TEXT", "projecta_payments.currency", "SUM(projecta_payments.amount)::BIGINT", "COUNT(*)")
+	payments.Where(payments.Equal("projecta_payments.vendor_id", vendorID.String()))
+	payments.Where(fmt.Sprintf("projecta_payments.project_id IN (%s)", accessibleProjects(&payments.Cond, personID)))
+	payments.GroupBy("1", "2")
+
+	assets := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	assets.From("projecta_assets")
+	assets.Select("''", "projecta_assets.currency", "SUM(projecta_assets.price)::BIGINT", "COUNT(*)")
+	assets.Where(assets.Equal("projecta_assets.vendor_id", vendorID.String()))
+	assets.Where(fmt.Sprintf("projecta_assets.project_id IN (%s)", accessibleProjects(&assets.Cond, personID)))
+	assets.GroupBy("2")
+
+	sql, args := sqlbuilder.PostgreSQL.NewUnionBuilder().UnionAll(payments, assets).Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	totals := make([]*projecta.VendorSubtotal, 0)
+
+	for rows.Next() {
+		var (
+			status   string
+			currency string
+			amount   int64
+			count    int
+		)
+
+		if err = rows.Scan(&status, &currency, &amount, &count); err != nil {
+			return nil, err
+		}
+
+		totals = append(totals, &projecta.VendorSubtotal{
+			Amount: money.New(amount, currency),
+			Count:  count,
+			Status: projecta.PaymentStatus(status),
+		})
+	}
+
+	return totals, rows.Err()
+}
+
+func scanVendor(row pgx.Row) (*projecta.Vendor, error) {
+	var (
+		vendorID    string
+		ownerID     string
+		projectID   string
+		name        string
+		taxID       string
+		contactName string
+		email       string
+		phone       string
+		iban        string
+	)
+
+	if err := row.Scan(
+		&vendorID,
+		&ownerID,
+		&projectID,
+		&name,
+		&taxID,
+		&contactName,
+		&email,
+		&phone,
+		&iban,
+	); err != nil {
+		return nil, err
+	}
+
+	vendorUUID, err := uuid.Parse(vendorID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ownerUUID, err := uuid.Parse(ownerID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID := uuid.Nil
+
+	if projectID != "" {
+		if projectUUID, err = uuid.Parse(projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	return projecta.NewVendor(
+		vendorUUID,
+		ownerUUID,
+		projectUUID,
+		name,
+		taxID,
+		contactName,
+		email,
+		phone,
+		iban,
+	)
+}
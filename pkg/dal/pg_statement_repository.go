@@ -0,0 +1,453 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+	"time"
+)
+
+type PgStatementRepository struct {
+	db *PgRepository
+}
+
+func NewPgStatementRepository(db *PgDbConnection) *PgStatementRepository {
+	return &PgStatementRepository{
+		db: &PgRepository{db},
+	}
+}
+
+var statementLineColumns = []string{
+	"projecta_statement_lines.line_id",
+	"projecta_statement_lines.project_id",
+	"projecta_statement_lines.source",
+	"projecta_statement_lines.external_id",
+	"projecta_statement_lines.booked_at",
+	"projecta_statement_lines.amount",
+	"projecta_statement_lines.currency",
+	"COALESCE(projecta_statement_lines.description, '')",
+	"COALESCE(projecta_statement_lines.counterparty, '')",
+	"projecta_statement_lines.status",
+	"COALESCE(projecta_statement_lines.payment_id::text, '')",
+}
+
+func newStatementLineSelectBuilder(personID uuid.UUID, projectID uuid.UUID) *sqlbuilder.SelectBuilder {
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_statement_lines")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_statement_lines.project_id")
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_statement_lines.project_id", projectID.String()))
+
+	return qb
+}
+
+func (r *PgStatementRepository) FindLines(ctx context.Context, filter statement.LineCollectionFilter) (*statement.LineCollection, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newStatementLineSelectBuilder(personID, filter.ProjectID)
+
+	if filter.Status != "" {
+		qb.Where(qb.Equal("projecta_statement_lines.status", filter.Status.String()))
+	}
+
+	qb.Select(qb.As("COUNT(*)", "total"))
+
+	sql, args := qb.Build()
+
+	var total int
+
+	if err = r.db.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	lines := statement.NewLineCollection(total)
+
+	if total == 0 {
+		return lines, nil
+	}
+
+	qb.Select() // reset select
+	qb.Select(statementLineColumns...)
+
+	if filter.Limit == 0 {
+		filter.Limit = core.DefaultLimit
+	}
+
+	qb.Limit(filter.Limit)
+	qb.Offset(filter.Offset)
+	qb.OrderBy("projecta_statement_lines.booked_at DESC", "projecta_statement_lines.line_id")
+
+	sql, args = qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		line, err := scanStatementLine(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lines.Add(line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r *PgStatementRepository) FindLine(ctx context.Context, filter statement.LineFilter) (*statement.Line, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := newStatementLineSelectBuilder(personID, filter.ProjectID)
+	qb.Select(statementLineColumns...)
+	qb.Where(qb.Equal("projecta_statement_lines.line_id", filter.ID.String()))
+
+	sql, args := qb.Build()
+
+	line, err := scanStatementLine(r.db.QueryRow(ctx, sql, args...))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, exceptions.NewNotFoundException(statementLineNotFound, err)
+		}
+
+		return nil, err
+	}
+
+	return line, nil
+}
+
+func (r *PgStatementRepository) FindExternalIDs(ctx context.Context, projectID uuid.UUID, ids []string) ([]string, error) {
+	found := make([]string, 0)
+
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_statement_lines")
+	qb.Select("external_id")
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.In("external_id", sqlbuilder.Flatten(ids)...))
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		found = append(found, id)
+	}
+
+	return found, rows.Err()
+}
+
+func (r *PgStatementRepository) SaveLine(ctx context.Context, line *statement.Line) error {
+	var paymentID any
+
+	if line.PaymentID != uuid.Nil {
+		paymentID = line.PaymentID.String()
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_statement_lines")
+	qb.Cols(
+		"line_id",
+		"project_id",
+		"source",
+		"external_id",
+		"booked_at",
+		"amount",
+		"currency",
+		"description",
+		"counterparty",
+		"status",
+		"payment_id",
+	)
+	qb.Values(
+		line.ID.String(),
+		line.ProjectID.String(),
+		line.Source,
+		line.ExternalID,
+		line.Date,
+		line.Amount.Amount(),
+		line.Amount.Currency().Code,
+		line.Description,
+		line.Counterparty,
+		line.Status.String(),
+		paymentID,
+	)
+	qb.SQL("ON CONFLICT (line_id) DO UPDATE SET status = EXCLUDED.status, payment_id = EXCLUDED.payment_id")
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgStatementRepository) FindRules(ctx context.Context, projectID uuid.UUID) ([]*statement.Rule, error) {
+	personID, err := core.AuthGuard(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	qb.From("projecta_statement_rules")
+	qb.Join("projecta_projects", "projecta_projects.project_id = projecta_statement_rules.project_id")
+	qb.Join("projecta_cost_types", "projecta_cost_types.type_id = projecta_statement_rules.type_id")
+	qb.Join("projecta_cost_categories", "projecta_cost_categories.category_id = projecta_cost_types.category_id")
+	qb.Select(
+		"projecta_statement_rules.rule_id",
+		"projecta_statement_rules.field",
+		"projecta_statement_rules.pattern",
+		"projecta_statement_rules.priority",
+		"projecta_cost_types.type_id",
+		"projecta_cost_types.name",
+		"projecta_cost_types.description",
+		"projecta_cost_types.category_id",
+		"projecta_cost_categories.name",
+	)
+	qb.Where(fmt.Sprintf("(projecta_projects.owner_id = %s OR projecta_projects.project_id IN (SELECT project_id FROM projecta_project_shares WHERE person_id = %s))", qb.Var(personID.String()), qb.Var(personID.String())))
+	qb.Where(qb.Equal("projecta_statement_rules.project_id", projectID.String()))
+	qb.OrderBy("projecta_statement_rules.priority DESC", "projecta_statement_rules.created_at")
+
+	sql, args := qb.Build()
+
+	rows, err := r.db.Query(ctx, sql, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rules := make([]*statement.Rule, 0)
+
+	for rows.Next() {
+		var (
+			ruleID          string
+			field           string
+			pattern         string
+			priority        int
+			typeID          string
+			typeName        string
+			typeDescription string
+			categoryID      string
+			categoryName    string
+		)
+
+		if err = rows.Scan(
+			&ruleID,
+			&field,
+			&pattern,
+			&priority,
+			&typeID,
+			&typeName,
+			&typeDescription,
+			&categoryID,
+			&categoryName,
+		); err != nil {
+			return nil, err
+		}
+
+		ruleUUID, err := uuid.Parse(ruleID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		costType, err := toCostType(typeID, projectID.String(), typeName, typeDescription, categoryID, categoryName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ruleField, err := statement.ToRuleField(field)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rule, err := statement.NewRule(ruleUUID, projectID, ruleField, pattern, costType, priority)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *PgStatementRepository) SaveRule(ctx context.Context, rule *statement.Rule) error {
+	qb := sqlbuilder.PostgreSQL.NewInsertBuilder()
+	qb.InsertInto("projecta_statement_rules")
+	qb.Cols(
+		"rule_id",
+		"project_id",
+		"field",
+		"pattern",
+		"type_id",
+		"priority",
+	)
+	qb.Values(
+		rule.ID.String(),
+		rule.ProjectID.String(),
+		rule.Field.String(),
+		rule.Pattern,
+		rule.Type.ID.String(),
+		rule.Priority,
+	)
+
+	sql, args := qb.Build()
+
+	_, err := r.db.Exec(ctx, sql, args...)
+
+	return err
+}
+
+func (r *PgStatementRepository) RemoveRule(ctx context.Context, projectID uuid.UUID, ruleID uuid.UUID) error {
+	qb := sqlbuilder.PostgreSQL.NewDeleteBuilder()
+	qb.DeleteFrom("projecta_statement_rules")
+	qb.Where(qb.Equal("project_id", projectID.String()))
+	qb.Where(qb.Equal("rule_id", ruleID.String()))
+
+	sql, args := qb.Build()
+
+	res, err := r.db.Exec(ctx, sql, args...)
+
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return exceptions.NewNotFoundException(statementRuleNotFound, nil)
+	}
+
+	return nil
+}
+
+func scanStatementLine(row pgx.Row) (*statement.Line, error) {
+	var (
+		lineID       string
+		projectID    string
+		source       string
+		externalID   string
+		bookedAt     time.Time
+		amount       int64
+		currency     string
+		description  string
+		counterparty string
+		status       string
+		paymentID    string
+	)
+
+	if err := row.Scan(
+		&lineID,
+		&projectID,
+		&source,
+		&externalID,
+		&bookedAt,
+		&amount,
+		&currency,
+		&description,
+		&counterparty,
+		&status,
+		&paymentID,
+	); err != nil {
+		return nil, err
+	}
+
+	return toStatementLine(lineID, projectID, source, externalID, bookedAt, amount, currency, description, counterparty, status, paymentID)
+}
+
+func toStatementLine(
+	lineID string,
+	projectID string,
+	source string,
+	externalID string,
+	bookedAt time.Time,
+	amount int64,
+	currency string,
+	description string,
+	counterparty string,
+	status string,
+	paymentID string,
+) (*statement.Line, error) {
+	lineUUID, err := uuid.Parse(lineID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lineStatus, err := statement.ToLineStatus(status)
+
+	if err != nil {
+		return nil, err
+	}
+
+	line := &statement.Line{
+		ID:           lineUUID,
+		ProjectID:    projectUUID,
+		Source:       source,
+		ExternalID:   externalID,
+		Date:         bookedAt,
+		Amount:       money.New(amount, currency),
+		Description:  description,
+		Counterparty: counterparty,
+		Status:       lineStatus,
+	}
+
+	if paymentID != "" {
+		if line.PaymentID, err = uuid.Parse(paymentID); err != nil {
+			return nil, err
+		}
+	}
+
+	return line, nil
+}
+
+const (
+	statementLineNotFound = "statement line not found"
+	statementRuleNotFound = "statement rule not found"
+)
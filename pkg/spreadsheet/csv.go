@@ -0,0 +1,50 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) Write(row []any) error {
+	record := make([]string, len(row))
+
+	for i, cell := range row {
+		if number, ok := cellNumber(cell); ok {
+			record[i] = number
+			continue
+		}
+
+		record[i] = escapeFormula(cellText(cell))
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSVWriter) Close() error {
+	return c.Flush()
+}
+
+// escapeFormula keeps spreadsheet applications from running text that looks
+// like a formula, e.g. a payment description starting with "=", by prefixing
+// it with a quote the way they mark text themselves.
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
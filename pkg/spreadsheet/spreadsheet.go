@@ -0,0 +1,121 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// Format is the file format a table is exported in.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat reads a format from a request, "csv" or "xlsx". The empty string
+// stands for CSV.
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "csv", "":
+		return FormatCSV, nil
+	case "xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", exceptions.NewValidationException(fmt.Sprintf("unknown export format: %s", value), nil)
+	}
+}
+
+func (f Format) String() string {
+	return string(f)
+}
+
+// ContentType is the media type files of the format are served as.
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes a table row by row. Rows are handed to the underlying writer
+// as they come, so a table of any size takes the memory of a single row.
+type Writer interface {
+	// Write appends a row. Cells are strings, Decimal or integers; nil leaves
+	// the cell empty and anything else is written as fmt.Sprint formats it.
+	Write(row []any) error
+	// Flush hands the rows written so far to the underlying writer.
+	Flush() error
+	// Close finishes the file. It does not close the underlying writer.
+	Close() error
+}
+
+// NewWriter starts a table in format on w. Formats holding several sheets name
+// the table sheet after title.
+func NewWriter(format Format, w io.Writer, title string) Writer {
+	if format == FormatXLSX {
+		return NewXLSXWriter(w, title)
+	}
+
+	return NewCSVWriter(w)
+}
+
+// Decimal is a number kept as an integer count of 10^-Scale, e.g. {12345, 2}
+// for 123.45, so amounts in minor units are written without a detour through
+// floating point.
+type Decimal struct {
+	Units int64
+	Scale int
+}
+
+func (d Decimal) String() string {
+	if d.Scale <= 0 {
+		return strconv.FormatInt(d.Units, 10)
+	}
+
+	sign := ""
+	digits := strconv.FormatInt(d.Units, 10)
+
+	if d.Units < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	if len(digits) <= d.Scale {
+		digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+	}
+
+	point := len(digits) - d.Scale
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// cellText formats the cells that are not numbers.
+func cellText(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// cellNumber formats the cells that are numbers and reports whether cell is one.
+func cellNumber(cell any) (string, bool) {
+	switch v := cell.(type) {
+	case Decimal:
+		return v.String(), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	default:
+		return "", false
+	}
+}
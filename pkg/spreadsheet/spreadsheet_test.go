@@ -0,0 +1,180 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestParseFormat(t *testing.T) {
+	for value, expected := range map[string]Format{"": FormatCSV, "CSV": FormatCSV, " xlsx ": FormatXLSX} {
+		if format, err := ParseFormat(value); err != nil || format != expected {
+			t.Errorf("ParseFormat(%q) = %v, %v", value, format, err)
+		}
+	}
+
+	var ex exceptions.Exception
+	if _, err := ParseFormat("pdf"); !errors.As(err, &ex) || ex.Code != exceptions.ValidationFailed {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	if FormatCSV.String() != "csv" || !strings.HasPrefix(FormatCSV.ContentType(), "text/csv") || !strings.Contains(FormatXLSX.ContentType(), "spreadsheetml") {
+		t.Error("unexpected format details")
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	for expected, d := range map[string]Decimal{
+		"123.45": {12345, 2},
+		"-0.05":  {-5, 2},
+		"0.00":   {0, 2},
+		"1000":   {1000, 0},
+		"-1.500": {-1500, 3},
+	} {
+		if d.String() != expected {
+			t.Errorf("expected %s, got %s", expected, d.String())
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(FormatCSV, &buf, "ignored")
+
+	rows := [][]any{
+		{"Date", "Description", "Amount", "Count"},
+		{"2026-03-01", "Plumber, bathroom", Decimal{150000, 2}, 2},
+		{"2026-03-02", "=HYPERLINK(\"x\")", Decimal{-1, 2}, int64(1)},
+		{nil, 3.5, "-5", ""},
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Date,Description,Amount,Count\n" +
+		"2026-03-01,\"Plumber, bathroom\",1500.00,2\n" +
+		"2026-03-02,\"'=HYPERLINK(\"\"x\"\")\",-0.01,1\n" +
+		",3.5,'-5,\n"
+
+	if buf.String() != expected {
+		t.Errorf("unexpected csv:\n%s", buf.String())
+	}
+
+	failing := NewCSVWriter(failingWriter{})
+	_ = failing.Write([]any{"a"})
+	if err := failing.Flush(); err == nil {
+		t.Error("expected flush error")
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(FormatXLSX, &buf, "Payments: Q1/2026")
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header := make([]any, 28)
+	for i := range header {
+		header[i] = "col"
+	}
+	header[27] = "Last & <final>"
+
+	for _, row := range [][]any{header, {" Plumber ", Decimal{150000, 2}, nil, 7}} {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		content, _ := io.ReadAll(r)
+		parts[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		if parts[name] == "" {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="Payments_ Q1_2026"`) {
+		t.Errorf("unexpected workbook %s", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, fragment := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">col</t></is></c>`,
+		`<c r="AB1" t="inlineStr"><is><t xml:space="preserve">Last &amp; &lt;final&gt;</t></is></c></row>`,
+		`<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve"> Plumber </t></is></c><c r="B2"><v>1500.00</v></c><c r="D2"><v>7</v></c></row>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, fragment) {
+			t.Errorf("expected %s in %s", fragment, sheet)
+		}
+	}
+
+	var empty bytes.Buffer
+	if err = NewXLSXWriter(&empty, "").Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if archive, err = zip.NewReader(bytes.NewReader(empty.Bytes()), int64(empty.Len())); err != nil || len(archive.File) != 5 {
+		t.Errorf("expected an empty workbook, got %v", err)
+	}
+
+	failing := NewXLSXWriter(failingWriter{}, "x")
+	_ = failing.Write([]any{strings.Repeat("a", 8192)})
+	if err = failing.Flush(); err == nil {
+		t.Error("expected flush error")
+	}
+	if err = NewXLSXWriter(failingWriter{}, "x").Close(); err == nil {
+		t.Error("expected close error")
+	}
+}
+
+func TestSheetNameAndColumns(t *testing.T) {
+	if name := sheetName(strings.Repeat("я", 40)); len([]rune(name)) != 31 {
+		t.Errorf("expected the name to be cut to 31 characters, got %d", len([]rune(name)))
+	}
+	if sheetName("  ") != "Sheet1" {
+		t.Error("expected a default sheet name")
+	}
+
+	for index, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if columnName(index) != expected {
+			t.Errorf("columnName(%d) = %s, want %s", index, columnName(index), expected)
+		}
+	}
+}
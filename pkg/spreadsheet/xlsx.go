@@ -0,0 +1,194 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+
+	// sheet names are limited to 31 characters and must not contain any of these
+	xlsxSheetNameLimit = 31
+	xlsxSheetNameChars = `[]:*?/\`
+)
+
+// XLSXWriter writes a single sheet workbook. The parts describing the workbook
+// go first, so the sheet itself is the last part of the archive and its rows
+// stream straight into it.
+type XLSXWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	title   string
+	rows    int
+	started bool
+}
+
+func NewXLSXWriter(w io.Writer, title string) *XLSXWriter {
+	return &XLSXWriter{
+		zip:   zip.NewWriter(w),
+		title: sheetName(title),
+	}
+}
+
+func (x *XLSXWriter) Write(row []any) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	x.rows++
+	line := strconv.Itoa(x.rows)
+
+	var b strings.Builder
+	b.WriteString(`<row r="` + line + `">`)
+
+	for i, cell := range row {
+		ref := columnName(i) + line
+
+		if number, ok := cellNumber(cell); ok {
+			b.WriteString(`<c r="` + ref + `"><v>` + number + `</v></c>`)
+			continue
+		}
+
+		text := cellText(cell)
+		if text == "" {
+			continue
+		}
+
+		b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+		_ = xml.EscapeText(&b, []byte(text))
+		b.WriteString(`</t></is></c>`)
+	}
+
+	b.WriteString(`</row>`)
+
+	_, err := x.sheet.WriteString(b.String())
+
+	return err
+}
+
+func (x *XLSXWriter) Flush() error {
+	if !x.started {
+		return nil
+	}
+
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return x.zip.Flush()
+}
+
+func (x *XLSXWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return x.zip.Close()
+}
+
+func (x *XLSXWriter) start() error {
+	if x.started {
+		return nil
+	}
+
+	var title strings.Builder
+	_ = xml.EscapeText(&title, []byte(x.title))
+
+	for _, part := range []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", strings.Replace(xlsxWorkbook, "%s", title.String(), 1)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		w, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+
+	w, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+
+	x.sheet = bufio.NewWriter(w)
+	x.started = true
+
+	_, err = x.sheet.WriteString(xlsxSheetStart)
+
+	return err
+}
+
+// columnName turns a zero based column index into its letters, e.g. 0 into A
+// and 27 into AB.
+func columnName(index int) string {
+	name := ""
+
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
+
+// sheetName makes title fit the rules for sheet names.
+func sheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(xlsxSheetNameChars, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+
+	if runes := []rune(name); len(runes) > xlsxSheetNameLimit {
+		name = string(runes[:xlsxSheetNameLimit])
+	}
+
+	if name == "" {
+		return "Sheet1"
+	}
+
+	return name
+}
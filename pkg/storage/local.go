@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+// LocalStorage keeps the files in a directory of the server, one file per key.
+type LocalStorage struct {
+	root string
+}
+
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStorage{root: root}, nil
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, content []byte, _ string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// the file is written aside and moved in place, so a reader never sees a
+	// partly written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+func (s *LocalStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, exceptions.NewNotFoundException("file not found", err)
+	}
+
+	return f, err
+}
+
+func (s *LocalStorage) Remove(_ context.Context, key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path maps key to a file under the root, refusing the keys which would
+// escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(key) || strings.Contains(key, `\`) {
+		return "", exceptions.NewValidationException(fmt.Sprintf("invalid storage key %q", key), nil)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "attachments")
+
+	s, err := NewLocalStorage(root)
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	t.Run("Put and Open", func(t *testing.T) {
+		if err := s.Put(ctx, "project/checksum", []byte("receipt"), "application/pdf"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+
+		r, err := s.Open(ctx, "project/checksum")
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		defer r.Close()
+
+		content, _ := io.ReadAll(r)
+		if string(content) != "receipt" {
+			t.Errorf("Open() content = %q, want %q", content, "receipt")
+		}
+
+		entries, _ := os.ReadDir(filepath.Join(root, "project"))
+		if len(entries) != 1 {
+			t.Errorf("Put() left %d files, want 1", len(entries))
+		}
+	})
+
+	t.Run("Put replaces the file", func(t *testing.T) {
+		if err := s.Put(ctx, "project/replaced", []byte("first"), "image/png"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if err := s.Put(ctx, "project/replaced", []byte("second"), "image/png"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+
+		content, _ := os.ReadFile(filepath.Join(root, "project", "replaced"))
+		if string(content) != "second" {
+			t.Errorf("content = %q, want %q", content, "second")
+		}
+	})
+
+	t.Run("Open of a missing file", func(t *testing.T) {
+		_, err := s.Open(ctx, "project/missing")
+		if !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("Open() error = %v, want not found", err)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		if err := s.Put(ctx, "project/removed", []byte("receipt"), "image/png"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if err := s.Remove(ctx, "project/removed"); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		if _, err := s.Open(ctx, "project/removed"); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("Open() after Remove() error = %v, want not found", err)
+		}
+		if err := s.Remove(ctx, "project/removed"); err != nil {
+			t.Errorf("Remove() of a missing file error = %v", err)
+		}
+	})
+
+	t.Run("Keys escaping the root", func(t *testing.T) {
+		for _, key := range []string{"", "../outside", "/etc/passwd", `project\..\..\outside`, "project/../../outside"} {
+			if err := s.Put(ctx, key, []byte("x"), "image/png"); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("Put(%q) error = %v, want validation error", key, err)
+			}
+			if _, err := s.Open(ctx, key); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("Open(%q) error = %v, want validation error", key, err)
+			}
+			if err := s.Remove(ctx, key); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("Remove(%q) error = %v, want validation error", key, err)
+			}
+		}
+	})
+
+	t.Run("Unusable root", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		_ = os.WriteFile(file, nil, 0o600)
+
+		if _, err := NewLocalStorage(filepath.Join(file, "attachments")); err == nil {
+			t.Error("NewLocalStorage() error = nil, want error")
+		}
+
+		broken := &LocalStorage{root: file}
+		if err := broken.Put(ctx, "project/checksum", []byte("x"), "image/png"); err == nil {
+			t.Error("Put() error = nil, want error")
+		}
+	})
+}
+
+func hasCode(err error, code exceptions.ErrorCode) bool {
+	var ex exceptions.Exception
+	return errors.As(err, &ex) && ex.Code == code
+}
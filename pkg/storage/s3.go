@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+const (
+	s3Service        = "s3"
+	s3SigningScheme  = "AWS4-HMAC-SHA256"
+	s3DateLayout     = "20060102"
+	s3DateTimeLayout = "20060102T150405Z"
+	s3SignedHeaders  = "host;x-amz-content-sha256;x-amz-date"
+)
+
+type S3StorageOptions struct {
+	// Endpoint is the URL of the S3 compatible service, the AWS endpoint of
+	// Region when empty.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path instead of the host name, as
+	// most self-hosted services expect.
+	PathStyle  bool
+	HTTPClient *http.Client
+}
+
+// S3Storage keeps the files as objects of a bucket of an S3 compatible
+// service. The requests are signed with AWS Signature Version 4.
+type S3Storage struct {
+	endpoint   *url.URL
+	region     string
+	bucket     string
+	accessKey  string
+	secretKey  string
+	pathStyle  bool
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewS3Storage(opts S3StorageOptions) (*S3Storage, error) {
+	if opts.Region == "" || opts.Bucket == "" || opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, fmt.Errorf("S3 storage requires a region, a bucket and credentials")
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", opts.Region)
+	}
+
+	u, err := url.Parse(endpoint)
+
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &S3Storage{
+		endpoint:   u,
+		region:     opts.Region,
+		bucket:     opts.Bucket,
+		accessKey:  opts.AccessKey,
+		secretKey:  opts.SecretKey,
+		pathStyle:  opts.PathStyle,
+		httpClient: client,
+		now:        time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, content []byte, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, content)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp.Body, nil
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, exceptions.NewNotFoundException("file not found", s3Error(resp))
+	}
+
+	return nil, s3Error(resp)
+}
+
+func (s *S3Storage) Remove(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error(resp)
+	}
+}
+
+func (s *S3Storage) newRequest(ctx context.Context, method string, key string, content []byte) (*http.Request, error) {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("invalid storage key %q", key), nil)
+	}
+
+	u := *s.endpoint
+	path := "/" + key
+
+	if s.pathStyle {
+		path = "/" + s.bucket + path
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = escapePath(u.Path)
+
+	var body io.Reader
+	if content != nil {
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(req, content)
+
+	return req, nil
+}
+
+// sign adds the AWS Signature Version 4 of the request in its Authorization
+// header.
+func (s *S3Storage) sign(req *http.Request, content []byte) {
+	now := s.now().UTC()
+	date := now.Format(s3DateLayout)
+	payloadHash := hashHex(content)
+
+	req.Header.Set("X-Amz-Date", now.Format(s3DateTimeLayout))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + now.Format(s3DateTimeLayout),
+		"",
+		s3SignedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		s3SigningScheme,
+		now.Format(s3DateTimeLayout),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.secretKey, date, s.region, s3Service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningScheme, s.accessKey, scope, s3SignedHeaders, signature,
+	))
+}
+
+// signingKey derives the key signing the requests of a day to a service of a
+// region.
+func signingKey(secretKey string, date string, region string, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath percent-encodes every byte of path but the unreserved characters
+// and the slashes, as the signature expects.
+func escapePath(path string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func s3Error(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+
+	return fmt.Errorf("S3 request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+}
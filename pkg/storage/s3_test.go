@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+)
+
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	requests []*http.Request
+	status   int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r)
+
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if hashHex(body) != r.Header.Get("X-Amz-Content-Sha256") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.EscapedPath()] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newS3(t *testing.T, pathStyle bool) (*S3Storage, *fakeS3) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	endpoint := server.URL
+	client := server.Client()
+
+	if !pathStyle {
+		// the bucket is in the host name, which the test server does not
+		// resolve
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			},
+		}
+		client = &http.Client{Transport: transport}
+	}
+
+	s, err := NewS3Storage(S3StorageOptions{
+		Endpoint:   endpoint,
+		Region:     "eu-central-1",
+		Bucket:     "receipts",
+		AccessKey:  "AKIDEXAMPLE",
+		SecretKey:  "secret",
+		PathStyle:  pathStyle,
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage() error = %v", err)
+	}
+
+	s.now = func() time.Time { return time.Date(2026, 7, 24, 10, 30, 0, 0, time.UTC) }
+
+	return s, fake
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Put, Open and Remove", func(t *testing.T) {
+		s, fake := newS3(t, true)
+
+		if err := s.Put(ctx, "project/checksum", []byte("receipt"), "application/pdf"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+
+		put := fake.requests[0]
+		if put.URL.Path != "/receipts/project/checksum" {
+			t.Errorf("Put() path = %q, want %q", put.URL.Path, "/receipts/project/checksum")
+		}
+		if put.Header.Get("Content-Type") != "application/pdf" {
+			t.Errorf("Put() content type = %q", put.Header.Get("Content-Type"))
+		}
+		if put.Header.Get("X-Amz-Date") != "20260724T103000Z" {
+			t.Errorf("Put() date = %q", put.Header.Get("X-Amz-Date"))
+		}
+
+		auth := put.Header.Get("Authorization")
+		wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260724/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+		if !strings.HasPrefix(auth, wantPrefix) {
+			t.Errorf("Put() authorization = %q, want prefix %q", auth, wantPrefix)
+		}
+		if signature := strings.TrimPrefix(auth, wantPrefix); len(signature) != 64 {
+			t.Errorf("Put() signature = %q, want 64 hex characters", signature)
+		}
+
+		r, err := s.Open(ctx, "project/checksum")
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		content, _ := io.ReadAll(r)
+		_ = r.Close()
+		if string(content) != "receipt" {
+			t.Errorf("Open() content = %q, want %q", content, "receipt")
+		}
+
+		if err = s.Remove(ctx, "project/checksum"); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		if _, err = s.Open(ctx, "project/checksum"); !errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("Open() after Remove() error = %v, want not found", err)
+		}
+	})
+
+	t.Run("Virtual hosted bucket", func(t *testing.T) {
+		s, fake := newS3(t, false)
+
+		if err := s.Put(ctx, "project/checksum", []byte("receipt"), "image/png"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+
+		put := fake.requests[0]
+		if !strings.HasPrefix(put.Host, "receipts.") || put.URL.Path != "/project/checksum" {
+			t.Errorf("Put() host = %q, path = %q", put.Host, put.URL.Path)
+		}
+	})
+
+	t.Run("Keys are escaped", func(t *testing.T) {
+		s, fake := newS3(t, true)
+
+		if err := s.Put(ctx, "project/a b+c", []byte("receipt"), "image/png"); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if got := fake.requests[0].URL.EscapedPath(); got != "/receipts/project/a%20b%2Bc" {
+			t.Errorf("Put() path = %q", got)
+		}
+	})
+
+	t.Run("Failed requests", func(t *testing.T) {
+		s, fake := newS3(t, true)
+		fake.status = http.StatusForbidden
+
+		if err := s.Put(ctx, "project/checksum", []byte("receipt"), "image/png"); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+			t.Errorf("Put() error = %v, want the S3 error", err)
+		}
+		if _, err := s.Open(ctx, "project/checksum"); err == nil || errors.Is(err, exceptions.NotFoundError) {
+			t.Errorf("Open() error = %v, want the S3 error", err)
+		}
+		if err := s.Remove(ctx, "project/checksum"); err == nil {
+			t.Error("Remove() error = nil, want the S3 error")
+		}
+	})
+
+	t.Run("Unreachable service", func(t *testing.T) {
+		s, _ := newS3(t, true)
+		s.endpoint.Host = "127.0.0.1:1"
+
+		if err := s.Put(ctx, "project/checksum", []byte("receipt"), "image/png"); err == nil {
+			t.Error("Put() error = nil, want error")
+		}
+		if _, err := s.Open(ctx, "project/checksum"); err == nil {
+			t.Error("Open() error = nil, want error")
+		}
+		if err := s.Remove(ctx, "project/checksum"); err == nil {
+			t.Error("Remove() error = nil, want error")
+		}
+	})
+
+	t.Run("Invalid keys", func(t *testing.T) {
+		s, _ := newS3(t, true)
+
+		for _, key := range []string{"", "/project/checksum"} {
+			if err := s.Put(ctx, key, []byte("x"), "image/png"); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("Put(%q) error = %v, want validation error", key, err)
+			}
+			if _, err := s.Open(ctx, key); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("Open(%q) error = %v, want validation error", key, err)
+			}
+			if err := s.Remove(ctx, key); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("Remove(%q) error = %v, want validation error", key, err)
+			}
+		}
+	})
+}
+
+func TestNewS3Storage(t *testing.T) {
+	s, err := NewS3Storage(S3StorageOptions{Region: "eu-central-1", Bucket: "receipts", AccessKey: "key", SecretKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewS3Storage() error = %v", err)
+	}
+	if s.endpoint.String() != "https://s3.eu-central-1.amazonaws.com" {
+		t.Errorf("endpoint = %q", s.endpoint)
+	}
+
+	invalid := []S3StorageOptions{
+		{Bucket: "receipts", AccessKey: "key", SecretKey: "secret"},
+		{Region: "eu-central-1", AccessKey: "key", SecretKey: "secret"},
+		{Region: "eu-central-1", Bucket: "receipts"},
+		{Endpoint: "minio:9000", Region: "eu-central-1", Bucket: "receipts", AccessKey: "key", SecretKey: "secret"},
+	}
+
+	for _, opts := range invalid {
+		if _, err := NewS3Storage(opts); err == nil {
+			t.Errorf("NewS3Storage(%+v) error = nil, want error", opts)
+		}
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	// the example of the AWS Signature Version 4 documentation
+	got := hex.EncodeToString(signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+
+	if got != want {
+		t.Errorf("signingKey() = %s, want %s", got, want)
+	}
+}
@@ -30,13 +30,15 @@ type AssetDTO struct {
 	Owner        OwnerDTO   `json:"owner"`
 	Project      ProjectDTO `json:"project"`
 	Type         TypeDTO    `json:"type"`
+	VendorID     string     `json:"vendor_id,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
 }
 
 func toAssetDTO(a *asset.Asset, rateProvider currency.CurrencyRateProvider) AssetDTO {
 	if a == nil {
 		return AssetDTO{}
 	}
-	projDTO := toProjectDTO(a.Project())
+	projDTO := toEmbeddedProjectDTO(a.Project())
 	homeCurrency := projDTO.MainCurrency
 	if homeCurrency == "" {
 		homeCurrency = "UAH"
@@ -44,9 +46,10 @@ func toAssetDTO(a *asset.Asset, rateProvider currency.CurrencyRateProvider) Asse
 
 	homeAmount := a.Price().Amount()
 	if rateProvider != nil && a.Price().Currency().Code != homeCurrency {
-		converted, err := rateProvider.Convert(
+		converted, err := rateProvider.ConvertAt(
 			currency.NewCurrency(a.Price().Amount(), a.Price().Currency().Code),
 			currency.NewCurrency(0, homeCurrency),
+			a.AcquiredAt(),
 		)
 		if err == nil {
 			homeAmount = converted.Amount
@@ -57,7 +60,7 @@ func toAssetDTO(a *asset.Asset, rateProvider currency.CurrencyRateProvider) Asse
 		PersonID:    a.Owner().PersonID.String(),
 		DisplayName: a.Owner().DisplayName,
 	}
-	return AssetDTO{
+	dto := AssetDTO{
 		AssetID:      a.ID().String(),
 		Name:         a.Name(),
 		Description:  a.Description(),
@@ -77,6 +80,14 @@ func toAssetDTO(a *asset.Asset, rateProvider currency.CurrencyRateProvider) Asse
 			},
 		},
 	}
+
+	if a.VendorID() != uuid.Nil {
+		dto.VendorID = a.VendorID().String()
+	}
+
+	dto.Tags = a.Tags()
+
+	return dto
 }
 
 type CreateAssetDTO struct {
@@ -319,10 +330,17 @@ func decodeListAssetsRequest(_ context.Context, r *http.Request) (interface{}, e
 
 	order := core.ToOrder(r.URL.Query().Get("order"))
 
+	tags, err := decodeTagsQuery(r.URL.Query())
+
+	if err != nil {
+		return nil, err
+	}
+
 	filter := asset.CollectionFilter{
 		ProjectID: projectUUID,
 		Name:      r.URL.Query().Get("name"),
 		TypeID:    typeUUID,
+		Tags:      tags,
 		Pagination: core.Pagination{
 			Limit:  limit,
 			Offset: offset,
@@ -336,7 +354,7 @@ func decodeListAssetsRequest(_ context.Context, r *http.Request) (interface{}, e
 	return filter, nil
 }
 
-func makeGetAssetEndpoint(s asset.Service, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeGetAssetEndpoint(s asset.Service, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		filter := request.(asset.Filter)
 
@@ -346,11 +364,16 @@ func makeGetAssetEndpoint(s asset.Service, rateProvider currency.CurrencyRatePro
 			return nil, err
 		}
 
-		return toAssetDTO(a, rateProvider), nil
+		rates, err := projectRates(ctx, fixedRates, a.Project().ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toAssetDTO(a, rates), nil
 	}
 }
 
-func makeCreateAssetEndpoint(s asset.Service, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeCreateAssetEndpoint(s asset.Service, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		cmd := request.(asset.CreateAssetCommand)
 		a, err := s.Create(ctx, cmd)
@@ -359,7 +382,12 @@ func makeCreateAssetEndpoint(s asset.Service, rateProvider currency.CurrencyRate
 			return nil, err
 		}
 
-		return toAssetDTO(a, rateProvider), nil
+		rates, err := projectRates(ctx, fixedRates, a.Project().ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toAssetDTO(a, rates), nil
 	}
 }
 
@@ -389,7 +417,7 @@ func makeRemoveAssetEndpoint(svc asset.Service) endpoint.Endpoint {
 	}
 }
 
-func makeListAssetsEndpoint(svc asset.Service, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeListAssetsEndpoint(svc asset.Service, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		filter := request.(asset.CollectionFilter)
 
@@ -400,9 +428,13 @@ func makeListAssetsEndpoint(svc asset.Service, rateProvider currency.CurrencyRat
 		}
 
 		var list []AssetDTO = make([]AssetDTO, 0)
+		rates, err := projectRates(ctx, fixedRates, filter.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
 
 		for _, e := range collection.Elements() {
-			list = append(list, toAssetDTO(e, rateProvider))
+			list = append(list, toAssetDTO(e, rates))
 		}
 
 		return ListAssetsResponse{
@@ -0,0 +1,203 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+// attachmentFormOverhead is what the form around an attached file may take on
+// top of the file itself.
+const attachmentFormOverhead = 1 << 20
+
+type AttachmentDTO struct {
+	AttachmentID string `json:"attachment_id"`
+	TargetType   string `json:"target_type"`
+	TargetID     string `json:"target_id"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Checksum     string `json:"checksum"`
+	UploadedBy   string `json:"uploaded_by"`
+	UploadedAt   string `json:"uploaded_at"`
+}
+
+type ListAttachmentsResponse struct {
+	Attachments []AttachmentDTO `json:"attachments"`
+}
+
+// attachmentDownload is an attached file sent as it is read from the storage.
+type attachmentDownload struct {
+	attachment *attachment.Attachment
+	content    io.ReadCloser
+}
+
+func toAttachmentDTO(a *attachment.Attachment) AttachmentDTO {
+	return AttachmentDTO{
+		AttachmentID: a.ID.String(),
+		TargetType:   a.Target.Kind.String(),
+		TargetID:     a.Target.ID.String(),
+		FileName:     a.FileName,
+		ContentType:  a.ContentType,
+		Size:         a.Size,
+		Checksum:     a.Checksum,
+		UploadedBy:   a.UploadedBy.String(),
+		UploadedAt:   a.UploadedAt.Format(time.RFC3339),
+	}
+}
+
+// decodeAttachmentTarget reads the project and the payment or asset of the
+// path, targetIDKey naming the variable of the target.
+func decodeAttachmentTarget(ctx context.Context, r *http.Request, kind attachment.TargetKind, targetIDKey string) (uuid.UUID, attachment.Target, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", targetIDKey)(ctx, r)
+	if err != nil {
+		return uuid.Nil, attachment.Target{}, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return command.ProjectID, attachment.Target{Kind: kind, ID: command.ResourceID}, nil
+}
+
+// decodeUploadAttachmentRequest reads the file sent as the "file" field of a
+// form. Its type is detected from the content, whatever the client claims.
+func decodeUploadAttachmentRequest(kind attachment.TargetKind, targetIDKey string) func(context.Context, *http.Request) (any, error) {
+	return func(ctx context.Context, r *http.Request) (any, error) {
+		projectID, target, err := decodeAttachmentTarget(ctx, r, kind, targetIDKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
+			return nil, exceptions.NewValidationException("file must be sent as multipart/form-data", nil)
+		}
+
+		r.Body = http.MaxBytesReader(nil, r.Body, attachment.MaxSize+attachmentFormOverhead)
+		if err = r.ParseMultipartForm(attachment.MaxSize + attachmentFormOverhead); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, exceptions.NewValidationException(fmt.Sprintf("file must not be larger than %d MB", attachment.MaxSize>>20), err)
+			}
+
+			return nil, exceptions.NewValidationException("invalid form", err)
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, exceptions.NewValidationException("file is required", err)
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(io.LimitReader(file, attachment.MaxSize+1))
+		if err != nil {
+			return nil, exceptions.NewValidationException("failed to read the file", err)
+		}
+
+		return attachment.UploadCommand{
+			ProjectID:   projectID,
+			Target:      target,
+			FileName:    header.Filename,
+			ContentType: http.DetectContentType(content),
+			Content:     content,
+		}, nil
+	}
+}
+
+func decodeListAttachmentsRequest(kind attachment.TargetKind, targetIDKey string) func(context.Context, *http.Request) (any, error) {
+	return func(ctx context.Context, r *http.Request) (any, error) {
+		projectID, target, err := decodeAttachmentTarget(ctx, r, kind, targetIDKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return attachment.CollectionFilter{ProjectID: projectID, Target: target}, nil
+	}
+}
+
+func decodeGetAttachmentRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "attachment_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return attachment.Filter{ProjectID: command.ProjectID, AttachmentID: command.ResourceID}, nil
+}
+
+func makeUploadAttachmentEndpoint(svc attachment.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		a, err := svc.Upload(ctx, request.(attachment.UploadCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toAttachmentDTO(a), nil
+	}
+}
+
+func makeListAttachmentsEndpoint(svc attachment.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		attachments, err := svc.Find(ctx, request.(attachment.CollectionFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]AttachmentDTO, 0, len(attachments))
+		for _, a := range attachments {
+			list = append(list, toAttachmentDTO(a))
+		}
+
+		return ListAttachmentsResponse{Attachments: list}, nil
+	}
+}
+
+func makeDownloadAttachmentEndpoint(svc attachment.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		a, content, err := svc.Open(ctx, request.(attachment.Filter))
+		if err != nil {
+			return nil, err
+		}
+
+		return attachmentDownload{attachment: a, content: content}, nil
+	}
+}
+
+func makeRemoveAttachmentEndpoint(svc attachment.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func encodeAttachmentDownload(_ context.Context, w http.ResponseWriter, response any) error {
+	download := response.(attachmentDownload)
+	defer download.content.Close()
+
+	a := download.attachment
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}))
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("ETag", strconv.Quote(a.Checksum))
+	// the files come from the users, browsers must not guess another type
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, download.content); err != nil {
+		// the status is out already, the client sees a truncated file
+		panic(http.ErrAbortHandler)
+	}
+
+	return nil
+}
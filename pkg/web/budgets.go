@@ -0,0 +1,410 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+type BudgetTypeDTO struct {
+	TypeID string `json:"type_id"`
+	Name   string `json:"name"`
+}
+
+type BudgetDTO struct {
+	BudgetID    string          `json:"budget_id"`
+	Category    TypeCategoryDTO `json:"category"`
+	Type        *BudgetTypeDTO  `json:"type,omitempty"`
+	Amount      int64           `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+}
+
+type CreateBudgetDTO struct {
+	CategoryID  string `json:"category_id"`
+	TypeID      string `json:"type_id,omitempty"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+}
+
+type UpdateBudgetDTO = CreateBudgetDTO
+
+type ListBudgetsResponse struct {
+	Budgets []BudgetDTO `json:"budgets"`
+	PaginationDTO
+}
+
+type BudgetReportLineDTO struct {
+	Budget    BudgetDTO `json:"budget"`
+	Planned   int64     `json:"planned"`
+	Actual    int64     `json:"actual"`
+	Remaining int64     `json:"remaining"`
+	Overrun   bool      `json:"overrun"`
+	// Nested lines budget a cost type of a category budgeted as a whole, and
+	// are left out of the report totals.
+	Nested bool `json:"nested,omitempty"`
+}
+
+type BudgetReportDTO struct {
+	Currency  string                `json:"currency"`
+	Lines     []BudgetReportLineDTO `json:"lines"`
+	Planned   int64                 `json:"planned"`
+	Actual    int64                 `json:"actual"`
+	Remaining int64                 `json:"remaining"`
+}
+
+func toBudgetDTO(b *projecta.Budget) BudgetDTO {
+	dto := BudgetDTO{
+		BudgetID: b.ID.String(),
+		Category: TypeCategoryDTO{
+			CategoryID: b.Category.ID.String(),
+			Name:       b.Category.Name,
+		},
+		Amount:      b.Amount.Amount(),
+		Currency:    b.Amount.Currency().Code,
+		Description: b.Description,
+	}
+
+	if b.Type != nil {
+		dto.Type = &BudgetTypeDTO{
+			TypeID: b.Type.ID.String(),
+			Name:   b.Type.Name,
+		}
+	}
+
+	return dto
+}
+
+func decodeBudgetDTO(r *http.Request) (categoryID uuid.UUID, typeID uuid.UUID, req CreateBudgetDTO, err error) {
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return uuid.Nil, uuid.Nil, req, exceptions.NewValidationException("invalid request", err)
+	}
+
+	categoryID, err = uuid.Parse(req.CategoryID)
+
+	if err != nil {
+		return uuid.Nil, uuid.Nil, req, exceptions.NewValidationException("invalid category id", err)
+	}
+
+	if req.TypeID != "" {
+		typeID, err = uuid.Parse(req.TypeID)
+
+		if err != nil {
+			return uuid.Nil, uuid.Nil, req, exceptions.NewValidationException("invalid type id", err)
+		}
+	}
+
+	if req.Amount <= 0 {
+		return uuid.Nil, uuid.Nil, req, exceptions.NewValidationException("amount must be greater than 0", nil)
+	}
+
+	return categoryID, typeID, req, nil
+}
+
+func decodeCreateBudgetRequest(_ context.Context, r *http.Request) (any, error) {
+	vars := mux.Vars(r)
+
+	projectID, ok := vars["project_id"]
+
+	if !ok {
+		return nil, exceptions.NewValidationException("invalid project id", nil)
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid project id", err)
+	}
+
+	categoryID, typeID, req, err := decodeBudgetDTO(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.CreateBudgetCommand{
+		ProjectID:   projectUUID,
+		CategoryID:  categoryID,
+		TypeID:      typeID,
+		Amount:      req.Amount,
+		Description: req.Description,
+	}, nil
+}
+
+func decodeUpdateBudgetRequest(_ context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetBudgetRequest(context.Background(), r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID, typeID, req, err := decodeBudgetDTO(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.UpdateBudgetCommand{
+		ProjectID:   filter.(projecta.BudgetFilter).ProjectID,
+		ID:          filter.(projecta.BudgetFilter).BudgetID,
+		CategoryID:  categoryID,
+		TypeID:      typeID,
+		Amount:      req.Amount,
+		Description: req.Description,
+	}, nil
+}
+
+func decodeGetBudgetRequest(_ context.Context, r *http.Request) (any, error) {
+	vars := mux.Vars(r)
+
+	projectID, ok := vars["project_id"]
+
+	if !ok {
+		return nil, exceptions.NewValidationException("invalid project id", nil)
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid project id", err)
+	}
+
+	budgetID, ok := vars["budget_id"]
+
+	if !ok {
+		return nil, exceptions.NewValidationException("invalid budget id", nil)
+	}
+
+	budgetUUID, err := uuid.Parse(budgetID)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid budget id", err)
+	}
+
+	return projecta.BudgetFilter{
+		ProjectID: projectUUID,
+		BudgetID:  budgetUUID,
+	}, nil
+}
+
+func decodeListBudgetsRequest(_ context.Context, r *http.Request) (any, error) {
+	var err error
+	var limit, offset int
+	vars := mux.Vars(r)
+
+	projectID, ok := vars["project_id"]
+
+	if !ok {
+		return nil, exceptions.NewValidationException("missing project_id", nil)
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid project_id", err)
+	}
+
+	offsetStr := r.URL.Query().Get("offset")
+	limitStr := r.URL.Query().Get("limit")
+	categoryIDStr := r.URL.Query().Get("category_id")
+
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	} else {
+		limit = core.DefaultLimit
+	}
+
+	if offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	var categoryID uuid.UUID
+
+	if categoryIDStr != "" {
+		categoryID, err = uuid.Parse(categoryIDStr)
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid category_id", err)
+		}
+	}
+
+	return projecta.BudgetCollectionFilter{
+		Pagination: core.Pagination{
+			Limit:  limit,
+			Offset: offset,
+		},
+		ProjectID:  projectUUID,
+		CategoryID: categoryID,
+	}, nil
+}
+
+func makeCreateBudgetEndpoint(svc projecta.BudgetService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.CreateBudgetCommand)
+
+		b, err := svc.Create(ctx, command)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return toBudgetDTO(b), nil
+	}
+}
+
+func makeUpdateBudgetEndpoint(svc projecta.BudgetService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.UpdateBudgetCommand)
+
+		err := svc.Update(ctx, command)
+
+		return nil, err
+	}
+}
+
+func makeGetBudgetEndpoint(svc projecta.BudgetService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.BudgetFilter)
+
+		b, err := svc.FindOne(ctx, filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return toBudgetDTO(b), nil
+	}
+}
+
+func makeListBudgetsEndpoint(svc projecta.BudgetService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.BudgetCollectionFilter)
+
+		collection, err := svc.Find(ctx, filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]BudgetDTO, 0)
+
+		for _, b := range collection.Elements() {
+			list = append(list, toBudgetDTO(b))
+		}
+
+		return ListBudgetsResponse{
+			Budgets: list,
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeRemoveBudgetEndpoint(svc projecta.BudgetService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command, ok := request.(projecta.RemoveProjectResourceCommand)
+
+		if !ok {
+			return nil, exceptions.NewValidationException("invalid request", nil)
+		}
+
+		err := svc.Remove(ctx, command)
+
+		return nil, err
+	}
+}
+
+func makeShowBudgetReportEndpoint(svc projecta.BudgetService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		projectID := request.(uuid.UUID)
+
+		report, err := svc.Report(ctx, projectID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, projectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toBudgetReportDTO(report, rates)
+	}
+}
+
+// toBudgetReportDTO converts the plans with the current rates and the spending
+// with the rates of the days it was paid on. The totals leave the nested lines
+// out, so a type budget within a budgeted category is not counted twice.
+func toBudgetReportDTO(report *projecta.BudgetReport, rates currency.CurrencyRateProvider) (BudgetReportDTO, error) {
+	homeCurrency := report.Project.MainCurrency
+	if homeCurrency == "" {
+		homeCurrency = "UAH"
+	}
+
+	result := BudgetReportDTO{
+		Currency: homeCurrency,
+		Lines:    make([]BudgetReportLineDTO, 0, len(report.Lines)),
+	}
+
+	for _, line := range report.Lines {
+		planned, err := toHomeAmount(rates, line.Budget.Amount, homeCurrency, time.Time{})
+
+		if err != nil {
+			return BudgetReportDTO{}, err
+		}
+
+		var actual int64
+
+		for _, spent := range line.Actual {
+			amount, err := toHomeAmount(rates, spent.Amount, homeCurrency, spent.Date)
+
+			if err != nil {
+				return BudgetReportDTO{}, err
+			}
+
+			actual += amount
+		}
+
+		nested := report.Nested(line)
+
+		result.Lines = append(result.Lines, BudgetReportLineDTO{
+			Budget:    toBudgetDTO(line.Budget),
+			Planned:   planned,
+			Actual:    actual,
+			Remaining: planned - actual,
+			Overrun:   actual > planned,
+			Nested:    nested,
+		})
+
+		if !nested {
+			result.Planned += planned
+			result.Actual += actual
+		}
+	}
+
+	result.Remaining = result.Planned - result.Actual
+
+	return result, nil
+}
@@ -0,0 +1,359 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type ContractDTO struct {
+	ContractID     string `json:"contract_id"`
+	VendorID       string `json:"vendor_id"`
+	Title          string `json:"title"`
+	Total          int64  `json:"total"`
+	Currency       string `json:"currency"`
+	DownPayment    int64  `json:"down_payment"`
+	UponCompletion int64  `json:"upon_completion"`
+	SignedAt       string `json:"signed_at,omitempty"`
+}
+
+// CreateContractDTO splits the total into the down payment and the amount paid
+// upon completion. When neither is given the total is paid upon completion.
+type CreateContractDTO struct {
+	VendorID       string `json:"vendor_id"`
+	Title          string `json:"title"`
+	Total          int64  `json:"total"`
+	Currency       string `json:"currency"`
+	DownPayment    int64  `json:"down_payment,omitempty"`
+	UponCompletion int64  `json:"upon_completion,omitempty"`
+	SignedAt       string `json:"signed_at,omitempty"`
+}
+
+type UpdateContractDTO = CreateContractDTO
+
+type ListContractsResponse struct {
+	Contracts []ContractDTO `json:"contracts"`
+	PaginationDTO
+}
+
+type LinkContractPaymentDTO struct {
+	PaymentID string `json:"payment_id"`
+}
+
+type ContractStageReportDTO struct {
+	Kind      string `json:"kind"`
+	Amount    int64  `json:"amount"`
+	Paid      int64  `json:"paid"`
+	Committed int64  `json:"committed"`
+	Remaining int64  `json:"remaining"`
+}
+
+type ContractReportDTO struct {
+	Contract  ContractDTO              `json:"contract"`
+	Currency  string                   `json:"currency"`
+	Stages    []ContractStageReportDTO `json:"stages"`
+	Paid      int64                    `json:"paid"`
+	Committed int64                    `json:"committed"`
+	Remaining int64                    `json:"remaining"`
+}
+
+func toContractDTO(c *projecta.Contract) ContractDTO {
+	dto := ContractDTO{
+		ContractID: c.ID.String(),
+		VendorID:   c.VendorID.String(),
+		Title:      c.Title,
+		Total:      c.Total.Amount(),
+		Currency:   c.Total.Currency().Code,
+	}
+
+	if stage := c.Stage(projecta.DownPayment); stage != nil {
+		dto.DownPayment = stage.Amount.Amount()
+	}
+
+	if stage := c.Stage(projecta.UponCompletionPayment); stage != nil {
+		dto.UponCompletion = stage.Amount.Amount()
+	}
+
+	if !c.SignedAt.IsZero() {
+		dto.SignedAt = c.SignedAt.Format(reportDateLayout)
+	}
+
+	return dto
+}
+
+func toContractReportDTO(report *projecta.ContractReport) ContractReportDTO {
+	dto := ContractReportDTO{
+		Contract:  toContractDTO(report.Contract),
+		Currency:  report.Contract.Total.Currency().Code,
+		Stages:    make([]ContractStageReportDTO, 0, len(report.Stages)),
+		Paid:      report.Paid.Amount(),
+		Committed: report.Committed.Amount(),
+		Remaining: report.Remaining.Amount(),
+	}
+
+	for _, stage := range report.Stages {
+		dto.Stages = append(dto.Stages, ContractStageReportDTO{
+			Kind:      stage.Stage.Kind.String(),
+			Amount:    stage.Stage.Amount.Amount(),
+			Paid:      stage.Paid.Amount(),
+			Committed: stage.Committed.Amount(),
+			Remaining: stage.Remaining.Amount(),
+		})
+	}
+
+	return dto
+}
+
+func decodeContractDTO(r *http.Request) (CreateContractDTO, uuid.UUID, []*projecta.ContractStage, time.Time, error) {
+	var req CreateContractDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, uuid.Nil, nil, time.Time{}, exceptions.NewValidationException("invalid request", err)
+	}
+
+	vendorID, err := uuid.Parse(req.VendorID)
+	if err != nil {
+		return req, uuid.Nil, nil, time.Time{}, exceptions.NewValidationException("invalid vendor id", err)
+	}
+
+	stages := make([]*projecta.ContractStage, 0, 2)
+
+	if req.DownPayment != 0 {
+		stages = append(stages, &projecta.ContractStage{Kind: projecta.DownPayment, Amount: money.New(req.DownPayment, req.Currency)})
+	}
+
+	if req.UponCompletion != 0 {
+		stages = append(stages, &projecta.ContractStage{Kind: projecta.UponCompletionPayment, Amount: money.New(req.UponCompletion, req.Currency)})
+	}
+
+	var signedAt time.Time
+	if req.SignedAt != "" {
+		if signedAt, err = time.Parse(reportDateLayout, req.SignedAt); err != nil {
+			return req, uuid.Nil, nil, time.Time{}, exceptions.NewValidationException("invalid signed_at", err)
+		}
+	}
+
+	return req, vendorID, stages, signedAt, nil
+}
+
+func decodeCreateContractRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, vendorID, stages, signedAt, err := decodeContractDTO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.CreateContractCommand{
+		ProjectID: projectID.(uuid.UUID),
+		VendorID:  vendorID,
+		Title:     req.Title,
+		Total:     money.New(req.Total, req.Currency),
+		Stages:    stages,
+		SignedAt:  signedAt,
+	}, nil
+}
+
+func decodeUpdateContractRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetContractRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, vendorID, stages, signedAt, err := decodeContractDTO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.UpdateContractCommand{
+		ProjectID: filter.(projecta.ContractFilter).ProjectID,
+		ID:        filter.(projecta.ContractFilter).ContractID,
+		VendorID:  vendorID,
+		Title:     req.Title,
+		Total:     money.New(req.Total, req.Currency),
+		Stages:    stages,
+		SignedAt:  signedAt,
+	}, nil
+}
+
+func decodeGetContractRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "contract_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return projecta.ContractFilter{
+		ProjectID:  command.ProjectID,
+		ContractID: command.ResourceID,
+	}, nil
+}
+
+func decodeListContractsRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.ContractCollectionFilter{
+		Pagination: core.Pagination{Limit: core.DefaultLimit},
+		ProjectID:  projectID.(uuid.UUID),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	if vendorID := query.Get("vendor_id"); vendorID != "" {
+		if filter.VendorID, err = uuid.Parse(vendorID); err != nil {
+			return nil, exceptions.NewValidationException("invalid vendor_id", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func decodeLinkContractPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetContractRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req LinkContractPaymentDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	paymentID, err := uuid.Parse(req.PaymentID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid payment id", err)
+	}
+
+	return projecta.LinkContractPaymentCommand{
+		ProjectID:  filter.(projecta.ContractFilter).ProjectID,
+		ContractID: filter.(projecta.ContractFilter).ContractID,
+		PaymentID:  paymentID,
+	}, nil
+}
+
+func decodeUnlinkContractPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetContractRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentID, err := uuid.Parse(mux.Vars(r)["payment_id"])
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid payment id", err)
+	}
+
+	return projecta.LinkContractPaymentCommand{
+		ProjectID:  filter.(projecta.ContractFilter).ProjectID,
+		ContractID: filter.(projecta.ContractFilter).ContractID,
+		PaymentID:  paymentID,
+	}, nil
+}
+
+func makeCreateContractEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		c, err := svc.Create(ctx, request.(projecta.CreateContractCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toContractDTO(c), nil
+	}
+}
+
+func makeUpdateContractEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Update(ctx, request.(projecta.UpdateContractCommand))
+	}
+}
+
+func makeGetContractEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		c, err := svc.FindOne(ctx, request.(projecta.ContractFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toContractDTO(c), nil
+	}
+}
+
+func makeListContractsEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.ContractCollectionFilter)
+
+		collection, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]ContractDTO, 0)
+		for _, c := range collection.Elements() {
+			list = append(list, toContractDTO(c))
+		}
+
+		return ListContractsResponse{
+			Contracts: list,
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeRemoveContractEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func makeShowContractReportEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		report, err := svc.Report(ctx, request.(projecta.ContractFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toContractReportDTO(report), nil
+	}
+}
+
+func makeLinkContractPaymentEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.LinkPayment(ctx, request.(projecta.LinkContractPaymentCommand))
+	}
+}
+
+func makeUnlinkContractPaymentEndpoint(svc projecta.ContractService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.UnlinkPayment(ctx, request.(projecta.LinkContractPaymentCommand))
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"github.com/Rhymond/go-money"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+	"time"
+)
+
+// projectRates returns the rate provider to convert amounts of the project with,
+// preferring the rates fixed on the project over the ones of rateProvider. A
+// project that can not be found has no fixed rates, so its amounts are
+// converted at market rates.
+func projectRates(ctx context.Context, fixedRates projecta.FixedRateService, projectID uuid.UUID, rateProvider currency.CurrencyRateProvider) (currency.CurrencyRateProvider, error) {
+	if fixedRates == nil {
+		return rateProvider, nil
+	}
+
+	rates, err := fixedRates.Find(ctx, projectID)
+
+	if err != nil {
+		if errors.Is(err, exceptions.NotFoundError) {
+			return rateProvider, nil
+		}
+
+		return nil, err
+	}
+
+	if len(rates) == 0 {
+		return rateProvider, nil
+	}
+
+	fixed := make([]currency.FixedRate, 0, len(rates))
+
+	for _, r := range rates {
+		fixed = append(fixed, currency.FixedRate{
+			Currency: r.Currency,
+			Base:     r.BaseCurrency,
+			Rate:     r.Rate,
+		})
+	}
+
+	return currency.NewFixedRateProvider(rateProvider, fixed...), nil
+}
+
+// toHomeAmount converts amount into the home currency using the rates in effect
+// on date; the zero time stands for the current rates. The amount is returned
+// as is when no rate provider is configured or the currencies already match.
+func toHomeAmount(rateProvider currency.CurrencyRateProvider, amount *money.Money, homeCurrency string, date time.Time) (int64, error) {
+	if rateProvider == nil || amount.Currency().Code == homeCurrency {
+		return amount.Amount(), nil
+	}
+
+	converted, err := rateProvider.ConvertAt(
+		currency.NewCurrency(amount.Amount(), amount.Currency().Code),
+		currency.NewCurrency(0, homeCurrency),
+		date,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return converted.Amount, nil
+}
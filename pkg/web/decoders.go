@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/Rhymond/go-money"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func decodeRegisterUser(_ context.Context, r *http.Request) (any, error) {
@@ -37,6 +41,7 @@ func decodeListProjectsRequest(_ context.Context, r *http.Request) (any, error)
 	offsetStr := r.URL.Query().Get("offset")
 	limitStr := r.URL.Query().Get("limit")
 	name := r.URL.Query().Get("name")
+	archivedStr := r.URL.Query().Get("archived")
 
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
@@ -67,6 +72,14 @@ func decodeListProjectsRequest(_ context.Context, r *http.Request) (any, error)
 		filter.Name = name
 	}
 
+	if archivedStr != "" {
+		filter.Archived, err = strconv.ParseBool(archivedStr)
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid archived", err)
+		}
+	}
+
 	return filter, nil
 }
 
@@ -253,9 +266,98 @@ func decodeListPaymentsRequest(_ context.Context, r *http.Request) (any, error)
 		TypeID:     typeID,
 	}
 
+	if err = decodePaymentSearch(r.URL.Query(), &filter); err != nil {
+		return nil, err
+	}
+
 	return filter, nil
 }
 
+// decodePaymentSearch reads the narrowing of a payment list, e.g.
+// ?date_from=2026-03-01&date_to=2026-03-31&amount_min=50000&currency=UAH&q=plumber.
+// Amounts are in minor units of the payment currency.
+func decodePaymentSearch(query url.Values, filter *projecta.PaymentCollectionFilter) error {
+	var err error
+
+	if kind := query.Get("kind"); kind != "" {
+		if filter.Kind, err = projecta.ToPaymentKind(kind); err != nil {
+			return exceptions.NewValidationException("invalid kind", err)
+		}
+	}
+
+	if status := query.Get("status"); status != "" {
+		if filter.Status, err = projecta.ToPaymentStatus(status); err != nil {
+			return err
+		}
+	}
+
+	if ownerID := query.Get("owner_id"); ownerID != "" {
+		if filter.OwnerID, err = uuid.Parse(ownerID); err != nil {
+			return exceptions.NewValidationException("invalid owner_id", err)
+		}
+	}
+
+	if vendorID := query.Get("vendor_id"); vendorID != "" {
+		if filter.VendorID, err = uuid.Parse(vendorID); err != nil {
+			return exceptions.NewValidationException("invalid vendor_id", err)
+		}
+	}
+
+	if contractID := query.Get("contract_id"); contractID != "" {
+		if filter.ContractID, err = uuid.Parse(contractID); err != nil {
+			return exceptions.NewValidationException("invalid contract_id", err)
+		}
+	}
+
+	if filter.Tags, err = decodeTagsQuery(query); err != nil {
+		return err
+	}
+
+	if from := query.Get("date_from"); from != "" {
+		if filter.From, err = time.Parse(reportDateLayout, from); err != nil {
+			return exceptions.NewValidationException("invalid date_from", err)
+		}
+	}
+
+	if to := query.Get("date_to"); to != "" {
+		if filter.To, err = time.Parse(reportDateLayout, to); err != nil {
+			return exceptions.NewValidationException("invalid date_to", err)
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return exceptions.NewValidationException("date_to must not be before date_from", nil)
+	}
+
+	if amountMin := query.Get("amount_min"); amountMin != "" {
+		if filter.AmountMin, err = strconv.ParseInt(amountMin, 10, 64); err != nil || filter.AmountMin < 0 {
+			return exceptions.NewValidationException("invalid amount_min", err)
+		}
+	}
+
+	if amountMax := query.Get("amount_max"); amountMax != "" {
+		if filter.AmountMax, err = strconv.ParseInt(amountMax, 10, 64); err != nil || filter.AmountMax < 0 {
+			return exceptions.NewValidationException("invalid amount_max", err)
+		}
+	}
+
+	if filter.AmountMax != 0 && filter.AmountMax < filter.AmountMin {
+		return exceptions.NewValidationException("amount_max must not be less than amount_min", nil)
+	}
+
+	if code := query.Get("currency"); code != "" {
+		filter.Currency = strings.ToUpper(strings.TrimSpace(code))
+
+		if money.GetCurrency(filter.Currency) == nil {
+			return exceptions.NewValidationException("invalid currency", nil)
+		}
+	}
+
+	filter.Query = strings.TrimSpace(query.Get("q"))
+
+	return nil
+}
+
 func decodeProjectTotalsRequest(_ context.Context, r *http.Request) (any, error) {
 	var err error
 	vars := mux.Vars(r)
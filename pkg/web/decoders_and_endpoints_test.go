@@ -5,18 +5,29 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
 	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
 	"gitlab.com/massimo-ua/projecta/pkg/currency"
+	"gitlab.com/massimo-ua/projecta/pkg/spreadsheet"
 )
 
 func TestAuthMiddlewareAndErrorEncoder(t *testing.T) {
@@ -101,6 +112,12 @@ func TestDecodersValidationErrors(t *testing.T) {
 		if err == nil {
 			t.Errorf("expected offset validation error")
 		}
+
+		reqArchived, _ := http.NewRequest("GET", "/projects?archived=maybe", nil)
+		_, err = decodeListProjectsRequest(context.Background(), reqArchived)
+		if err == nil {
+			t.Errorf("expected archived validation error")
+		}
 	})
 
 	t.Run("decodeListTypesRequest validation errors", func(t *testing.T) {
@@ -211,6 +228,47 @@ func TestDecodersValidationErrors(t *testing.T) {
 		}
 	})
 
+	t.Run("decodeListPaymentsRequest search filters", func(t *testing.T) {
+		projectID, ownerID, vendorID, contractID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		vars := map[string]string{"project_id": projectID.String()}
+
+		req, _ := http.NewRequest("GET", "/payments?kind=DOWN_PAYMENT&owner_id="+ownerID.String()+"&vendor_id="+vendorID.String()+"&contract_id="+contractID.String()+"&date_from=2026-03-01&date_to=2026-03-31&amount_min=50000&amount_max=200000&currency=uah&status=due&q=+plumber+", nil)
+		res, err := decodeListPaymentsRequest(context.Background(), mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		filter := res.(projecta.PaymentCollectionFilter)
+		if filter.ProjectID != projectID || filter.Kind != projecta.DownPayment || filter.OwnerID != ownerID ||
+			filter.VendorID != vendorID || filter.ContractID != contractID ||
+			!filter.From.Equal(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)) ||
+			!filter.To.Equal(time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)) ||
+			filter.AmountMin != 50000 || filter.AmountMax != 200000 || filter.Currency != money.UAH || filter.Status != projecta.PaymentDue || filter.Query != "plumber" {
+			t.Errorf("unexpected filter %+v", filter)
+		}
+
+		for _, query := range []string{
+			"kind=LOAN",
+			"owner_id=bad",
+			"vendor_id=bad",
+			"contract_id=bad",
+			"date_from=03/01/2026",
+			"date_to=tomorrow",
+			"date_from=2026-03-31&date_to=2026-03-01",
+			"amount_min=ten",
+			"amount_min=-1",
+			"amount_max=1.5",
+			"amount_min=200&amount_max=100",
+			"currency=XYZ1",
+			"status=overdue",
+		} {
+			req, _ = http.NewRequest("GET", "/payments?"+query, nil)
+			if _, err = decodeListPaymentsRequest(context.Background(), mux.SetURLVars(req, vars)); err == nil {
+				t.Errorf("expected validation error for %s", query)
+			}
+		}
+	})
+
 	t.Run("decodeProjectTotalsRequest validation errors", func(t *testing.T) {
 		reqNoVars, _ := http.NewRequest("GET", "/totals", nil)
 		_, err := decodeProjectTotalsRequest(context.Background(), reqNoVars)
@@ -534,6 +592,22 @@ func TestPaymentsDecodersValidationErrors(t *testing.T) {
 		if err == nil {
 			t.Errorf("expected invalid payment kind")
 		}
+
+		bodyBadRate, _ := json.Marshal(UpdatePaymentDTO{PaymentDate: nowStr, TypeID: validUUID, ExchangeRate: -1})
+		reqBadRate, _ := http.NewRequest("PUT", "/", bytes.NewReader(bodyBadRate))
+		reqBadRate = mux.SetURLVars(reqBadRate, map[string]string{"project_id": validUUID, "payment_id": validUUID})
+		_, err = decodeUpdatePaymentRequest(context.Background(), reqBadRate)
+		if err == nil {
+			t.Errorf("expected invalid exchange rate")
+		}
+
+		bodyRate, _ := json.Marshal(UpdatePaymentDTO{PaymentDate: nowStr, TypeID: validUUID, Currency: "USD", ExchangeRate: 39.1})
+		reqRate, _ := http.NewRequest("PUT", "/", bytes.NewReader(bodyRate))
+		reqRate = mux.SetURLVars(reqRate, map[string]string{"project_id": validUUID, "payment_id": validUUID})
+		res, err := decodeUpdatePaymentRequest(context.Background(), reqRate)
+		if err != nil || res.(projecta.UpdatePaymentCommand).ExchangeRate != 39.1 {
+			t.Errorf("expected manual exchange rate, got %v", err)
+		}
 	})
 
 	t.Run("decodeGetPaymentRequest errors", func(t *testing.T) {
@@ -566,6 +640,152 @@ func TestPaymentsDecodersValidationErrors(t *testing.T) {
 	})
 }
 
+func TestPaymentStatusDecodersAndEndpoints(t *testing.T) {
+	validUUID := uuid.New().String()
+	vars := map[string]string{"project_id": validUUID, "payment_id": validUUID}
+	owner := &projecta.Owner{PersonID: uuid.New()}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+	dueAt := time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("decodePaymentStatus", func(t *testing.T) {
+		status, dueDate, err := decodePaymentStatus("planned", "2026-05-01")
+		if err != nil || status != projecta.PaymentPlanned || !dueDate.Equal(dueAt) {
+			t.Errorf("unexpected status %v due %v: %v", status, dueDate, err)
+		}
+		if status, dueDate, err = decodePaymentStatus("", ""); err != nil || status != "" || !dueDate.IsZero() {
+			t.Errorf("expected no status, got %v %v: %v", status, dueDate, err)
+		}
+		if _, _, err = decodePaymentStatus("late", ""); err == nil {
+			t.Errorf("expected invalid status")
+		}
+		if _, _, err = decodePaymentStatus("DUE", "01/05/2026"); err == nil {
+			t.Errorf("expected invalid due_date")
+		}
+	})
+
+	t.Run("create and update carry the status", func(t *testing.T) {
+		body, _ := json.Marshal(CreatePaymentDTO{TypeID: validUUID, Amount: 100, Currency: "USD", Status: "PLANNED", DueDate: "2026-05-01"})
+		req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+		res, err := DecodeCreatePaymentRequest(context.Background(), mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cmd := res.(projecta.CreatePaymentCommand); cmd.Status != projecta.PaymentPlanned || !cmd.DueDate.Equal(dueAt) || !cmd.PaymentDate.IsZero() {
+			t.Errorf("unexpected command %+v", cmd)
+		}
+
+		body, _ = json.Marshal(CreatePaymentDTO{TypeID: validUUID, Amount: 100, Currency: "USD", Status: "LATE"})
+		req, _ = http.NewRequest("POST", "/", bytes.NewReader(body))
+		if _, err = DecodeCreatePaymentRequest(context.Background(), mux.SetURLVars(req, vars)); err == nil {
+			t.Errorf("expected invalid status")
+		}
+
+		body, _ = json.Marshal(CreatePaymentDTO{TypeID: validUUID, Amount: 100, Currency: "USD", Status: "PAID"})
+		req, _ = http.NewRequest("POST", "/", bytes.NewReader(body))
+		if _, err = DecodeCreatePaymentRequest(context.Background(), mux.SetURLVars(req, vars)); err == nil {
+			t.Errorf("expected paid payment to need a date")
+		}
+
+		body, _ = json.Marshal(UpdatePaymentDTO{PaymentDate: time.Now().Format(time.RFC3339), TypeID: validUUID, Currency: "USD", Status: "due", DueDate: "2026-05-01"})
+		req, _ = http.NewRequest("PUT", "/", bytes.NewReader(body))
+		res, err = decodeUpdatePaymentRequest(context.Background(), mux.SetURLVars(req, vars))
+		if err != nil || res.(projecta.UpdatePaymentCommand).Status != projecta.PaymentDue || !res.(projecta.UpdatePaymentCommand).DueDate.Equal(dueAt) {
+			t.Errorf("unexpected update command %v: %v", res, err)
+		}
+
+		body, _ = json.Marshal(UpdatePaymentDTO{PaymentDate: time.Now().Format(time.RFC3339), TypeID: validUUID, DueDate: "soon"})
+		req, _ = http.NewRequest("PUT", "/", bytes.NewReader(body))
+		if _, err = decodeUpdatePaymentRequest(context.Background(), mux.SetURLVars(req, vars)); err == nil {
+			t.Errorf("expected invalid due_date")
+		}
+	})
+
+	t.Run("decodeMarkPaymentPaidRequest", func(t *testing.T) {
+		paidAt := time.Date(2026, time.May, 3, 0, 0, 0, 0, time.UTC)
+		body, _ := json.Marshal(MarkPaymentPaidDTO{Amount: 110, Currency: "USD", PaymentDate: paidAt.Format(time.RFC3339), ExchangeRate: 41})
+		req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+		res, err := decodeMarkPaymentPaidRequest(context.Background(), mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cmd := res.(projecta.MarkPaymentPaidCommand)
+		if cmd.ID.String() != validUUID || cmd.ProjectID.String() != validUUID || cmd.Amount.Amount() != 110 || !cmd.PaymentDate.Equal(paidAt) || cmd.ExchangeRate != 41 {
+			t.Errorf("unexpected command %+v", cmd)
+		}
+
+		req, _ = http.NewRequest("POST", "/", bytes.NewReader([]byte("{}")))
+		if res, err = decodeMarkPaymentPaidRequest(context.Background(), mux.SetURLVars(req, vars)); err != nil || res.(projecta.MarkPaymentPaidCommand).Amount != nil {
+			t.Errorf("expected the planned amount kept, got %v", err)
+		}
+
+		req, _ = http.NewRequest("POST", "/", nil)
+		if _, err = decodeMarkPaymentPaidRequest(context.Background(), mux.SetURLVars(req, map[string]string{"project_id": validUUID})); err == nil {
+			t.Errorf("expected missing payment_id")
+		}
+
+		for _, body := range []string{
+			"not json",
+			`{"exchange_rate":-1}`,
+			`{"amount":110}`,
+			`{"payment_date":"yesterday"}`,
+		} {
+			req, _ = http.NewRequest("POST", "/", bytes.NewReader([]byte(body)))
+			if _, err = decodeMarkPaymentPaidRequest(context.Background(), mux.SetURLVars(req, vars)); err == nil {
+				t.Errorf("expected validation error for %s", body)
+			}
+		}
+	})
+
+	t.Run("makeMarkPaymentPaidEndpoint", func(t *testing.T) {
+		pay := projecta.NewPayment(uuid.New(), proj, owner, costType, "Stage 2", money.New(100, money.USD), dueAt, projecta.DownPayment)
+		pay.DueDate = dueAt
+		pay.VendorID, pay.ContractID = uuid.New(), uuid.New()
+
+		res, err := makeMarkPaymentPaidEndpoint(&mockPaymentService{pay: pay}, nil, nil)(context.Background(), projecta.MarkPaymentPaidCommand{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if dto := res.(PaymentDTO); dto.Status != "PAID" || dto.DueDate != "2026-05-01" || dto.VendorID != pay.VendorID.String() || dto.ContractID != pay.ContractID.String() {
+			t.Errorf("unexpected payment %+v", dto)
+		}
+
+		if _, err = makeMarkPaymentPaidEndpoint(&mockPaymentService{err: errors.New("err")}, nil, nil)(context.Background(), projecta.MarkPaymentPaidCommand{}); err == nil {
+			t.Errorf("expected service error")
+		}
+	})
+
+	t.Run("totals show committed payments apart from the balance", func(t *testing.T) {
+		paid := projecta.NewPayment(uuid.New(), proj, owner, costType, "Stage 1", money.New(100, money.UAH), time.Now(), projecta.DownPayment)
+		planned := projecta.NewPayment(uuid.New(), proj, owner, costType, "Stage 2", money.New(300, money.UAH), dueAt, projecta.DownPayment)
+		planned.Status, planned.DueDate = projecta.PaymentPlanned, dueAt
+		col := projecta.NewPaymentCollection(2)
+		col.Add(paid, planned)
+
+		ep := makeShowProjectTotalsEndpoint(&mockProjectService{project: proj}, &mockPaymentServiceWithCol{col: col}, &mockAssetServiceWithCol{col: asset.NewCollection(0)}, nil, nil)
+		res, err := ep(context.Background(), proj.ProjectID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		amounts := map[string]int64{}
+		for _, total := range res.(ProjectTotalsDTO).Totals {
+			amounts[total.Title] = total.Amount
+		}
+		if amounts["Committed Payments"] != 300 || amounts["Project Balance"] != 100 {
+			t.Errorf("unexpected totals %v", amounts)
+		}
+
+		plannedUSD := projecta.NewPayment(uuid.New(), proj, owner, costType, "Stage 3", money.New(50, money.USD), dueAt, projecta.DownPayment)
+		plannedUSD.Status, plannedUSD.DueDate = projecta.PaymentDue, dueAt
+		col.Add(plannedUSD)
+		ep = makeShowProjectTotalsEndpoint(&mockProjectService{project: proj}, &mockPaymentServiceWithCol{col: col}, &mockAssetServiceWithCol{col: asset.NewCollection(0)}, nil, &mockRateProvider{err: errors.New("rates down")})
+		if _, err = ep(context.Background(), proj.ProjectID); err == nil {
+			t.Errorf("expected committed payments conversion error")
+		}
+	})
+}
+
 func TestProjectaDecodersValidationErrors(t *testing.T) {
 	validUUID := uuid.New().String()
 	authedCtx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
@@ -696,6 +916,22 @@ func TestProjectaDecodersValidationErrors(t *testing.T) {
 		if err == nil {
 			t.Errorf("expected invalid payment kind")
 		}
+
+		bodyBadRate, _ := json.Marshal(CreatePaymentDTO{PaymentDate: nowStr, TypeID: validUUID, ExchangeRate: -1})
+		reqBadRate, _ := http.NewRequest("POST", "/", bytes.NewReader(bodyBadRate))
+		reqBadRate = mux.SetURLVars(reqBadRate, map[string]string{"project_id": validUUID})
+		_, err = DecodeCreatePaymentRequest(context.Background(), reqBadRate)
+		if err == nil {
+			t.Errorf("expected invalid exchange rate")
+		}
+
+		bodyRate, _ := json.Marshal(CreatePaymentDTO{PaymentDate: nowStr, TypeID: validUUID, Currency: "USD", ExchangeRate: 39.1})
+		reqRate, _ := http.NewRequest("POST", "/", bytes.NewReader(bodyRate))
+		reqRate = mux.SetURLVars(reqRate, map[string]string{"project_id": validUUID})
+		res, err := DecodeCreatePaymentRequest(context.Background(), reqRate)
+		if err != nil || res.(projecta.CreatePaymentCommand).ExchangeRate != 39.1 {
+			t.Errorf("expected manual exchange rate, got %v", err)
+		}
 	})
 }
 
@@ -802,7 +1038,7 @@ func TestEndpointsErrorBranches(t *testing.T) {
 	})
 
 	t.Run("makeCreatePaymentEndpoint error", func(t *testing.T) {
-		ep := makeCreatePaymentEndpoint(paySvcErr, nil)
+		ep := makeCreatePaymentEndpoint(paySvcErr, nil, nil)
 		_, err := ep(context.Background(), projecta.CreatePaymentCommand{})
 		if err == nil {
 			t.Errorf("expected service error")
@@ -810,7 +1046,7 @@ func TestEndpointsErrorBranches(t *testing.T) {
 	})
 
 	t.Run("makeListPaymentsEndpoint error", func(t *testing.T) {
-		ep := makeListPaymentsEndpoint(paySvcErr, nil)
+		ep := makeListPaymentsEndpoint(paySvcErr, nil, nil)
 		_, err := ep(context.Background(), projecta.PaymentCollectionFilter{})
 		if err == nil {
 			t.Errorf("expected service error")
@@ -836,7 +1072,7 @@ func TestEndpointsErrorBranches(t *testing.T) {
 	t.Run("makeShowProjectTotalsEndpoint currency mismatch and service error", func(t *testing.T) {
 		mProjSvc := &mockProjectService{project: proj}
 		// Payments service error
-		epErr := makeShowProjectTotalsEndpoint(mProjSvc, paySvcErr, astSvcErr, nil)
+		epErr := makeShowProjectTotalsEndpoint(mProjSvc, paySvcErr, astSvcErr, nil, nil)
 		_, err := epErr(context.Background(), uuid.New())
 		if err == nil {
 			t.Errorf("expected payments service error")
@@ -844,7 +1080,7 @@ func TestEndpointsErrorBranches(t *testing.T) {
 
 		// Assets service error
 		paySvcOk := &mockPaymentService{pay: pay}
-		epAssetErr := makeShowProjectTotalsEndpoint(mProjSvc, paySvcOk, astSvcErr, nil)
+		epAssetErr := makeShowProjectTotalsEndpoint(mProjSvc, paySvcOk, astSvcErr, nil, nil)
 		_, err = epAssetErr(context.Background(), uuid.New())
 		if err == nil {
 			t.Errorf("expected assets service error")
@@ -857,7 +1093,7 @@ func TestEndpointsErrorBranches(t *testing.T) {
 		mPaySvc := &mockPaymentServiceWithCol{col: colMismatch}
 		mAstSvc := &mockAssetService{asset: ast}
 
-		epMismatch := makeShowProjectTotalsEndpoint(mProjSvc, mPaySvc, mAstSvc, nil)
+		epMismatch := makeShowProjectTotalsEndpoint(mProjSvc, mPaySvc, mAstSvc, nil, nil)
 		_, err = epMismatch(context.Background(), uuid.New())
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -873,7 +1109,7 @@ func TestEndpointsErrorBranches(t *testing.T) {
 
 		mAstSvcMismatch := &mockAssetServiceWithCol{col: astColMismatch}
 
-		epAstMismatch := makeShowProjectTotalsEndpoint(mProjSvc, mPaySvcSingle, mAstSvcMismatch, nil)
+		epAstMismatch := makeShowProjectTotalsEndpoint(mProjSvc, mPaySvcSingle, mAstSvcMismatch, nil, nil)
 		_, err = epAstMismatch(context.Background(), uuid.New())
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -890,6 +1126,23 @@ func (m *mockPaymentServiceWithCol) Find(_ context.Context, _ projecta.PaymentCo
 	return m.col, nil
 }
 
+// Totals sums every payment on its own, the way the repository keeps booked
+// payments apart from the ones still to be converted.
+func (m *mockPaymentServiceWithCol) Totals(_ context.Context, filter projecta.PaymentTotalsFilter) ([]*projecta.PaymentSubtotal, error) {
+	totals := make([]*projecta.PaymentSubtotal, 0)
+	for _, p := range m.col.Elements() {
+		if p.Status.IsCommitted() != filter.Committed {
+			continue
+		}
+		if hasBookedConversion(p, p.Project.MainCurrency) {
+			totals = append(totals, &projecta.PaymentSubtotal{Amount: p.HomeAmount, Count: 1})
+			continue
+		}
+		totals = append(totals, &projecta.PaymentSubtotal{Amount: p.Amount, Date: p.Date, Count: 1})
+	}
+	return totals, nil
+}
+
 type mockAssetServiceWithCol struct {
 	mockAssetService
 	col *asset.Collection
@@ -898,9 +1151,17 @@ type mockAssetServiceWithCol struct {
 func (m *mockAssetServiceWithCol) Find(_ context.Context, _ asset.CollectionFilter) (*asset.Collection, error) {
 	return m.col, nil
 }
+func (m *mockAssetServiceWithCol) Totals(_ context.Context, _ asset.TotalsFilter) ([]*asset.Subtotal, error) {
+	totals := make([]*asset.Subtotal, 0)
+	for _, a := range m.col.Elements() {
+		totals = append(totals, &asset.Subtotal{Amount: a.Price(), Date: a.AcquiredAt(), Count: 1})
+	}
+	return totals, nil
+}
 
 type mockRateProvider struct {
-	err error
+	err   error
+	dates []time.Time
 }
 
 func (m *mockRateProvider) Convert(a currency.Currency, b currency.Currency) (currency.Currency, error) {
@@ -910,6 +1171,11 @@ func (m *mockRateProvider) Convert(a currency.Currency, b currency.Currency) (cu
 	return currency.Currency{Amount: a.Amount * 40, Code: b.Code}, nil
 }
 
+func (m *mockRateProvider) ConvertAt(a currency.Currency, b currency.Currency, date time.Time) (currency.Currency, error) {
+	m.dates = append(m.dates, date)
+	return m.Convert(a, b)
+}
+
 func TestAcceptShareAndGetProjectDecodersAndEndpoints(t *testing.T) {
 	validUUID := uuid.New().String()
 
@@ -1014,6 +1280,169 @@ func TestAcceptShareAndGetProjectDecodersAndEndpoints(t *testing.T) {
 		}
 	})
 
+	t.Run("project owner command decoders and endpoints", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/projects/123", nil)
+		if _, err := decodeRemoveProjectRequest(context.Background(), req); err == nil {
+			t.Error("expected error for missing requester ID")
+		}
+
+		ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, uuid.New())
+		if _, err := decodeArchiveProjectRequest(ctx, req); err == nil {
+			t.Error("expected error for missing project_id")
+		}
+
+		reqBad := mux.SetURLVars(req, map[string]string{"project_id": "bad-id"})
+		if _, err := decodeRemoveProjectRequest(ctx, reqBad); err == nil {
+			t.Error("expected error for invalid project_id")
+		}
+		if _, err := decodeArchiveProjectRequest(ctx, reqBad); err == nil {
+			t.Error("expected error for invalid project_id")
+		}
+
+		svcErr := &mockProjectService{err: errors.New("err")}
+		if _, err := makeArchiveProjectEndpoint(svcErr)(ctx, projecta.ArchiveProjectCommand{}); err == nil {
+			t.Error("expected archive error")
+		}
+		if _, err := makeUnarchiveProjectEndpoint(svcErr)(ctx, projecta.ArchiveProjectCommand{}); err == nil {
+			t.Error("expected unarchive error")
+		}
+
+		owner := &projecta.Owner{PersonID: uuid.New()}
+		proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+		proj.Archive(time.Now())
+		if dto := toProjectDTO(proj); dto.ArchivedAt == "" {
+			t.Error("expected archived_at to be set")
+		}
+	})
+
+	t.Run("share link decoder, endpoint and project roles", func(t *testing.T) {
+		personID := uuid.New()
+		ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, personID)
+		projectID := uuid.New()
+
+		newRequest := func(body string) *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "/projects/"+projectID.String()+"/share-links", bytes.NewBufferString(body))
+			return mux.SetURLVars(req, map[string]string{"project_id": projectID.String()})
+		}
+
+		res, err := decodeCreateShareLinkRequest(ctx, newRequest(`{}`))
+		if err != nil || res.(projecta.CreateShareLinkCommand).Role != projecta.RoleViewer || res.(projecta.CreateShareLinkCommand).PersonID != personID {
+			t.Errorf("expected viewer link by default, got %v", err)
+		}
+		res, err = decodeCreateShareLinkRequest(ctx, newRequest(`{"role":"ADMIN"}`))
+		if err != nil || res.(projecta.CreateShareLinkCommand).Role != projecta.RoleAdmin {
+			t.Errorf("expected admin link, got %v", err)
+		}
+		if _, err = decodeCreateShareLinkRequest(ctx, newRequest(`{"role":"GUEST"}`)); err == nil {
+			t.Error("expected error for unknown role")
+		}
+		if _, err = decodeCreateShareLinkRequest(ctx, newRequest(`{bad`)); err == nil {
+			t.Error("expected error for invalid body")
+		}
+		if _, err = decodeCreateShareLinkRequest(context.Background(), newRequest(`{}`)); err == nil {
+			t.Error("expected error for missing requester ID")
+		}
+		expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		res, err = decodeCreateShareLinkRequest(ctx, newRequest(`{"role":"EDITOR","expires_at":"`+expiresAt.Format(time.RFC3339)+`","max_uses":3}`))
+		if err != nil || !res.(projecta.CreateShareLinkCommand).ExpiresAt.Equal(expiresAt) || res.(projecta.CreateShareLinkCommand).MaxUses != 3 {
+			t.Errorf("expected link limits to be decoded, got %v", err)
+		}
+		if _, err = decodeCreateShareLinkRequest(ctx, newRequest(`{"expires_at":"tomorrow"}`)); err == nil {
+			t.Error("expected error for invalid expires_at")
+		}
+
+		owner := &projecta.Owner{PersonID: personID}
+		proj, _ := projecta.NewProject(projectID, "Project", "Desc", owner, time.Now(), time.Now())
+
+		out, err := makeCreateShareLinkEndpoint(&mockProjectService{project: proj})(ctx, projecta.CreateShareLinkCommand{ProjectID: projectID, PersonID: personID, Role: projecta.RoleEditor})
+		if err != nil || out.(ShareLinkDTO).Role != "EDITOR" || out.(ShareLinkDTO).Token == "" {
+			t.Errorf("expected share link dto, got %v", err)
+		}
+		if _, err = makeCreateShareLinkEndpoint(&mockProjectService{err: errors.New("err")})(ctx, projecta.CreateShareLinkCommand{}); err == nil {
+			t.Error("expected create share link error")
+		}
+
+		if dto := toProjectDTO(proj); dto.Role != "OWNER" || dto.ShareToken == "" {
+			t.Errorf("expected owner to see the share token, got %+v", dto)
+		}
+		proj.Role = projecta.RoleEditor
+		if dto := toProjectDTO(proj); dto.Role != "EDITOR" || dto.ShareToken != "" {
+			t.Errorf("expected share token to be hidden from editors, got %+v", dto)
+		}
+		if dto := toEmbeddedProjectDTO(proj); dto.Role != "" || dto.ShareToken != "" {
+			t.Errorf("expected embedded project without role and share token, got %+v", dto)
+		}
+	})
+
+	t.Run("share management decoders and endpoints", func(t *testing.T) {
+		personID := uuid.New()
+		ctx := context.WithValue(context.Background(), core.RequesterIDContextKey, personID)
+		projectID, linkID, memberID := uuid.New(), uuid.New(), uuid.New()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+		req = mux.SetURLVars(req, map[string]string{"project_id": projectID.String(), "link_id": linkID.String(), "person_id": memberID.String()})
+
+		res, err := decodeRevokeShareLinkRequest(ctx, req)
+		if err != nil || res.(projecta.RevokeShareLinkCommand).LinkID != linkID || res.(projecta.RevokeShareLinkCommand).PersonID != personID {
+			t.Errorf("expected revoke share link command, got %v", err)
+		}
+		res, err = decodeRevokeMemberRequest(ctx, req)
+		if err != nil || res.(projecta.RevokeMemberCommand).MemberID != memberID || res.(projecta.RevokeMemberCommand).ProjectID != projectID {
+			t.Errorf("expected revoke member command, got %v", err)
+		}
+		res, err = decodeRotateShareTokenRequest(ctx, req)
+		if err != nil || res.(projecta.RotateShareTokenCommand).ProjectID != projectID {
+			t.Errorf("expected rotate share token command, got %v", err)
+		}
+
+		if _, err = decodeRevokeMemberRequest(context.Background(), req); err == nil {
+			t.Error("expected error for missing requester ID")
+		}
+		reqBad := mux.SetURLVars(req, map[string]string{"project_id": projectID.String(), "link_id": "bad-id"})
+		if _, err = decodeRevokeShareLinkRequest(ctx, reqBad); err == nil {
+			t.Error("expected error for invalid link_id")
+		}
+		if _, err = decodeRevokeMemberRequest(ctx, reqBad); err == nil {
+			t.Error("expected error for missing person_id")
+		}
+		if _, err = decodeRotateShareTokenRequest(context.Background(), req); err == nil {
+			t.Error("expected error for missing requester ID")
+		}
+
+		owner := &projecta.Owner{PersonID: personID}
+		proj, _ := projecta.NewProject(projectID, "Project", "Desc", owner, time.Now(), time.Now())
+		svc := &mockProjectService{project: proj}
+		svcErr := &mockProjectService{err: errors.New("err")}
+
+		out, err := makeListShareLinksEndpoint(svc)(ctx, projectID)
+		if err != nil || len(out.(ListShareLinksResponse).Links) != 1 || out.(ListShareLinksResponse).Links[0].ExpiresAt == "" {
+			t.Errorf("expected share links, got %v", err)
+		}
+		out, err = makeListProjectMembersEndpoint(svc)(ctx, projectID)
+		if err != nil || len(out.(ListProjectMembersResponse).Members) != 1 || out.(ListProjectMembersResponse).Members[0].Role != "OWNER" {
+			t.Errorf("expected project members, got %v", err)
+		}
+		if out, err = makeRotateShareTokenEndpoint(svc)(ctx, projecta.RotateShareTokenCommand{}); err != nil || out.(ProjectDTO).ShareToken == "" {
+			t.Errorf("expected rotated project, got %v", err)
+		}
+
+		if _, err = makeListShareLinksEndpoint(svcErr)(ctx, projectID); err == nil {
+			t.Error("expected list share links error")
+		}
+		if _, err = makeListProjectMembersEndpoint(svcErr)(ctx, projectID); err == nil {
+			t.Error("expected list members error")
+		}
+		if _, err = makeRotateShareTokenEndpoint(svcErr)(ctx, projecta.RotateShareTokenCommand{}); err == nil {
+			t.Error("expected rotate share token error")
+		}
+		if _, err = makeRevokeShareLinkEndpoint(svcErr)(ctx, projecta.RevokeShareLinkCommand{}); err == nil {
+			t.Error("expected revoke share link error")
+		}
+		if _, err = makeRevokeMemberEndpoint(svcErr)(ctx, projecta.RevokeMemberCommand{}); err == nil {
+			t.Error("expected revoke member error")
+		}
+	})
+
 	t.Run("currency conversion endpoints with rateProvider", func(t *testing.T) {
 		owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Owner"}
 		proj, _ := projecta.NewProject(uuid.New(), "Project One", "Desc", owner, time.Now(), time.Now())
@@ -1021,8 +1450,10 @@ func TestAcceptShareAndGetProjectDecodersAndEndpoints(t *testing.T) {
 		cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat1", "Desc")
 		costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type1", "Desc")
 
-		payUSD := projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay USD", money.New(100, money.USD), time.Now(), projecta.DownPayment)
-		astUSD := asset.NewAsset(uuid.New(), "Ast USD", "Desc", proj, costType, money.New(200, money.USD), time.Now(), owner)
+		paidAt := time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC)
+		acquiredAt := time.Date(2024, time.April, 7, 10, 0, 0, 0, time.UTC)
+		payUSD := projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay USD", money.New(100, money.USD), paidAt, projecta.DownPayment)
+		astUSD := asset.NewAsset(uuid.New(), "Ast USD", "Desc", proj, costType, money.New(200, money.USD), acquiredAt, owner)
 
 		rateProv := &mockRateProvider{}
 
@@ -1032,17 +1463,27 @@ func TestAcceptShareAndGetProjectDecodersAndEndpoints(t *testing.T) {
 		}
 
 		aDto := toAssetDTO(astUSD, rateProv)
-		if aDto.HomeAmount != 8000 {
+		if aDto.HomeAmount != 8000 || aDto.VendorID != "" {
 			t.Errorf("expected converted home amount 8000, got %d", aDto.HomeAmount)
 		}
 
+		vendorID := uuid.New()
+		astUSD.SetVendorID(vendorID)
+		if aDto = toAssetDTO(astUSD, nil); aDto.VendorID != vendorID.String() {
+			t.Errorf("expected vendor %s, got %q", vendorID, aDto.VendorID)
+		}
+
+		if len(rateProv.dates) != 2 || !rateProv.dates[0].Equal(paidAt) || !rateProv.dates[1].Equal(acquiredAt) {
+			t.Errorf("expected conversion at payment and acquisition dates, got %v", rateProv.dates)
+		}
+
 		paySvc := &mockPaymentService{pay: payUSD}
-		epCreatePay := makeCreatePaymentEndpoint(paySvc, rateProv)
+		epCreatePay := makeCreatePaymentEndpoint(paySvc, nil, rateProv)
 		if _, err := epCreatePay(context.Background(), projecta.CreatePaymentCommand{}); err != nil {
 			t.Errorf("makeCreatePaymentEndpoint error: %v", err)
 		}
 
-		epGetPay := makeGetPaymentEndpoint(paySvc, rateProv)
+		epGetPay := makeGetPaymentEndpoint(paySvc, nil, rateProv)
 		if _, err := epGetPay(context.Background(), projecta.PaymentFilter{}); err != nil {
 			t.Errorf("makeGetPaymentEndpoint error: %v", err)
 		}
@@ -1050,18 +1491,18 @@ func TestAcceptShareAndGetProjectDecodersAndEndpoints(t *testing.T) {
 		col := projecta.NewPaymentCollection(1)
 		col.Add(payUSD)
 		mPayColSvc := &mockPaymentServiceWithCol{col: col}
-		epListPay := makeListPaymentsEndpoint(mPayColSvc, rateProv)
+		epListPay := makeListPaymentsEndpoint(mPayColSvc, nil, rateProv)
 		if _, err := epListPay(context.Background(), projecta.PaymentCollectionFilter{}); err != nil {
 			t.Errorf("makeListPaymentsEndpoint error: %v", err)
 		}
 
 		astSvc := &mockAssetService{asset: astUSD}
-		epCreateAst := makeCreateAssetEndpoint(astSvc, rateProv)
+		epCreateAst := makeCreateAssetEndpoint(astSvc, nil, rateProv)
 		if _, err := epCreateAst(context.Background(), asset.CreateAssetCommand{}); err != nil {
 			t.Errorf("makeCreateAssetEndpoint error: %v", err)
 		}
 
-		epGetAst := makeGetAssetEndpoint(astSvc, rateProv)
+		epGetAst := makeGetAssetEndpoint(astSvc, nil, rateProv)
 		if _, err := epGetAst(context.Background(), asset.Filter{}); err != nil {
 			t.Errorf("makeGetAssetEndpoint error: %v", err)
 		}
@@ -1069,22 +1510,2624 @@ func TestAcceptShareAndGetProjectDecodersAndEndpoints(t *testing.T) {
 		astCol := asset.NewCollection(1)
 		astCol.Add(astUSD)
 		mAstColSvc := &mockAssetServiceWithCol{col: astCol}
-		epListAst := makeListAssetsEndpoint(mAstColSvc, rateProv)
+		epListAst := makeListAssetsEndpoint(mAstColSvc, nil, rateProv)
 		if _, err := epListAst(context.Background(), asset.CollectionFilter{}); err != nil {
 			t.Errorf("makeListAssetsEndpoint error: %v", err)
 		}
 
 		mProjSvc := &mockProjectService{project: proj}
-		epTotals := makeShowProjectTotalsEndpoint(mProjSvc, mPayColSvc, mAstColSvc, rateProv)
+		epTotals := makeShowProjectTotalsEndpoint(mProjSvc, mPayColSvc, mAstColSvc, nil, rateProv)
 		resTotals, err := epTotals(context.Background(), proj.ProjectID)
 		if err != nil || resTotals == nil {
 			t.Fatalf("makeShowProjectTotalsEndpoint error: %v", err)
 		}
 
 		errRateProv := &mockRateProvider{err: errors.New("rate error")}
-		epTotalsErr := makeShowProjectTotalsEndpoint(mProjSvc, mPayColSvc, mAstColSvc, errRateProv)
+		epTotalsErr := makeShowProjectTotalsEndpoint(mProjSvc, mPayColSvc, mAstColSvc, nil, errRateProv)
 		if _, err := epTotalsErr(context.Background(), proj.ProjectID); err == nil {
 			t.Error("expected rate error in makeShowProjectTotalsEndpoint")
 		}
 	})
+
+	t.Run("booked payment conversion wins over current rates", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Owner"}
+		proj, _ := projecta.NewProject(uuid.New(), "Project One", "Desc", owner, time.Now(), time.Now())
+		cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat1", "Desc")
+		costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type1", "Desc")
+
+		pay := projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay USD", money.New(100, money.USD), time.Now(), projecta.DownPayment)
+		_ = pay.ApplyExchangeRate(39.1, money.New(3910, money.UAH), true)
+
+		rateProv := &mockRateProvider{err: errors.New("rate error")}
+		dto := toPaymentDTO(pay, rateProv)
+		if dto.HomeAmount != 3910 || dto.ExchangeRate != 39.1 || !dto.ManualRate {
+			t.Errorf("expected booked conversion, got %+v", dto)
+		}
+
+		col := projecta.NewPaymentCollection(1)
+		col.Add(pay)
+		epTotals := makeShowProjectTotalsEndpoint(&mockProjectService{project: proj}, &mockPaymentServiceWithCol{col: col}, &mockAssetServiceWithCol{col: asset.NewCollection(0)}, nil, rateProv)
+		res, err := epTotals(context.Background(), proj.ProjectID)
+		if err != nil || res.(ProjectTotalsDTO).Totals[0].Amount != 3910 {
+			t.Errorf("expected totals from booked conversion, got %v, %v", res, err)
+		}
+
+		// a conversion booked into another currency is stale once the project
+		// main currency changes
+		proj.MainCurrency = money.EUR
+		dto = toPaymentDTO(pay, &mockRateProvider{})
+		if dto.HomeAmount != 4000 || dto.ExchangeRate != 0 {
+			t.Errorf("expected conversion with current rates, got %+v", dto)
+		}
+	})
+}
+
+func TestBudgetDecodersAndEndpoints(t *testing.T) {
+	validUUID := uuid.New().String()
+	validBody := `{"category_id":"` + validUUID + `","amount":100}`
+
+	t.Run("decodeCreateBudgetRequest errors", func(t *testing.T) {
+		reqNoProj, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte(validBody)))
+		if _, err := decodeCreateBudgetRequest(context.Background(), reqNoProj); err == nil {
+			t.Errorf("expected missing project_id")
+		}
+
+		reqBadProj, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte(validBody)))
+		reqBadProj = mux.SetURLVars(reqBadProj, map[string]string{"project_id": "bad"})
+		if _, err := decodeCreateBudgetRequest(context.Background(), reqBadProj); err == nil {
+			t.Errorf("expected invalid project_id")
+		}
+
+		bodies := map[string]string{
+			"invalid json":     `{bad`,
+			"invalid category": `{"category_id":"bad","amount":100}`,
+			"invalid type":     `{"category_id":"` + validUUID + `","type_id":"bad","amount":100}`,
+			"zero amount":      `{"category_id":"` + validUUID + `","amount":0}`,
+		}
+
+		for name, body := range bodies {
+			req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte(body)))
+			req = mux.SetURLVars(req, map[string]string{"project_id": validUUID})
+			if _, err := decodeCreateBudgetRequest(context.Background(), req); err == nil {
+				t.Errorf("expected error for %s", name)
+			}
+		}
+	})
+
+	t.Run("decodeUpdateBudgetRequest errors", func(t *testing.T) {
+		reqNoBudget, _ := http.NewRequest("PUT", "/", bytes.NewReader([]byte(validBody)))
+		reqNoBudget = mux.SetURLVars(reqNoBudget, map[string]string{"project_id": validUUID})
+		if _, err := decodeUpdateBudgetRequest(context.Background(), reqNoBudget); err == nil {
+			t.Errorf("expected missing budget_id")
+		}
+
+		reqBadBody, _ := http.NewRequest("PUT", "/", bytes.NewReader([]byte(`{bad`)))
+		reqBadBody = mux.SetURLVars(reqBadBody, map[string]string{"project_id": validUUID, "budget_id": validUUID})
+		if _, err := decodeUpdateBudgetRequest(context.Background(), reqBadBody); err == nil {
+			t.Errorf("expected invalid body")
+		}
+	})
+
+	t.Run("decodeGetBudgetRequest errors", func(t *testing.T) {
+		reqNoProj, _ := http.NewRequest("GET", "/", nil)
+		if _, err := decodeGetBudgetRequest(context.Background(), reqNoProj); err == nil {
+			t.Errorf("expected missing project_id")
+		}
+
+		reqBadProj, _ := http.NewRequest("GET", "/", nil)
+		reqBadProj = mux.SetURLVars(reqBadProj, map[string]string{"project_id": "bad"})
+		if _, err := decodeGetBudgetRequest(context.Background(), reqBadProj); err == nil {
+			t.Errorf("expected invalid project_id")
+		}
+
+		reqBadBudget, _ := http.NewRequest("GET", "/", nil)
+		reqBadBudget = mux.SetURLVars(reqBadBudget, map[string]string{"project_id": validUUID, "budget_id": "bad"})
+		if _, err := decodeGetBudgetRequest(context.Background(), reqBadBudget); err == nil {
+			t.Errorf("expected invalid budget_id")
+		}
+	})
+
+	t.Run("decodeListBudgetsRequest errors", func(t *testing.T) {
+		reqNoProj, _ := http.NewRequest("GET", "/", nil)
+		if _, err := decodeListBudgetsRequest(context.Background(), reqNoProj); err == nil {
+			t.Errorf("expected missing project_id")
+		}
+
+		reqBadProj, _ := http.NewRequest("GET", "/", nil)
+		reqBadProj = mux.SetURLVars(reqBadProj, map[string]string{"project_id": "bad"})
+		if _, err := decodeListBudgetsRequest(context.Background(), reqBadProj); err == nil {
+			t.Errorf("expected invalid project_id")
+		}
+
+		for _, query := range []string{"limit=bad", "offset=bad", "category_id=bad"} {
+			req, _ := http.NewRequest("GET", "/budgets?"+query, nil)
+			req = mux.SetURLVars(req, map[string]string{"project_id": validUUID})
+			if _, err := decodeListBudgetsRequest(context.Background(), req); err == nil {
+				t.Errorf("expected error for %s", query)
+			}
+		}
+
+		req, _ := http.NewRequest("GET", "/budgets", nil)
+		req = mux.SetURLVars(req, map[string]string{"project_id": validUUID})
+		filter, err := decodeListBudgetsRequest(context.Background(), req)
+		if err != nil || filter.(projecta.BudgetCollectionFilter).Limit != core.DefaultLimit {
+			t.Errorf("expected default limit, got %v", err)
+		}
+	})
+
+	t.Run("budget endpoints errors", func(t *testing.T) {
+		svc := &mockBudgetService{err: errors.New("err")}
+
+		if _, err := makeCreateBudgetEndpoint(svc)(context.Background(), projecta.CreateBudgetCommand{}); err == nil {
+			t.Errorf("expected create error")
+		}
+		if _, err := makeGetBudgetEndpoint(svc)(context.Background(), projecta.BudgetFilter{}); err == nil {
+			t.Errorf("expected get error")
+		}
+		if _, err := makeListBudgetsEndpoint(svc)(context.Background(), projecta.BudgetCollectionFilter{}); err == nil {
+			t.Errorf("expected list error")
+		}
+		if _, err := makeRemoveBudgetEndpoint(svc)(context.Background(), "invalid"); err == nil {
+			t.Errorf("expected invalid request error")
+		}
+		if _, err := makeShowBudgetReportEndpoint(svc, nil, nil)(context.Background(), uuid.New()); err == nil {
+			t.Errorf("expected report error")
+		}
+	})
+
+	t.Run("makeShowBudgetReportEndpoint converts to the main currency", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New()}
+		proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+		proj.MainCurrency = ""
+		cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+		budget, _ := projecta.NewBudget(uuid.New(), proj.ProjectID, cat, nil, money.New(10000, "UAH"), "")
+		paidOn := time.Date(2026, time.February, 10, 0, 0, 0, 0, time.UTC)
+		svc := &mockBudgetService{report: &projecta.BudgetReport{
+			Project: proj,
+			Lines: []*projecta.BudgetReportLine{{
+				Budget: budget,
+				Actual: []*projecta.PaymentSubtotal{
+					{Amount: money.New(2000, "UAH"), Date: paidOn},
+					{Amount: money.New(100, "USD"), Date: paidOn},
+				},
+			}},
+		}}
+
+		rates := &mockRateProvider{}
+		res, err := makeShowBudgetReportEndpoint(svc, nil, rates)(context.Background(), proj.ProjectID)
+		if err != nil {
+			t.Fatalf("makeShowBudgetReportEndpoint error: %v", err)
+		}
+		report := res.(BudgetReportDTO)
+		if report.Currency != "UAH" || report.Actual != 6000 || report.Remaining != 4000 || report.Lines[0].Overrun {
+			t.Errorf("unexpected budget report: %+v", report)
+		}
+		if len(rates.dates) != 1 || !rates.dates[0].Equal(paidOn) {
+			t.Errorf("expected the spending converted at the day it was paid, got %v", rates.dates)
+		}
+
+		// a type budget within a budgeted category is shown, but not counted twice
+		costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "")
+		typeBudget, _ := projecta.NewBudget(uuid.New(), proj.ProjectID, cat, costType, money.New(3000, "UAH"), "")
+		svc.report.Lines = append(svc.report.Lines, &projecta.BudgetReportLine{
+			Budget: typeBudget,
+			Actual: []*projecta.PaymentSubtotal{{Amount: money.New(2000, "UAH"), Date: paidOn}},
+		})
+		res, err = makeShowBudgetReportEndpoint(svc, nil, &mockRateProvider{})(context.Background(), proj.ProjectID)
+		if report = res.(BudgetReportDTO); err != nil || len(report.Lines) != 2 || !report.Lines[1].Nested || report.Lines[0].Nested || report.Planned != 10000 || report.Actual != 6000 {
+			t.Errorf("unexpected nested budget report: %+v, %v", report, err)
+		}
+		svc.report.Lines = svc.report.Lines[:1]
+
+		errRateProv := &mockRateProvider{err: errors.New("rate error")}
+		if _, err = makeShowBudgetReportEndpoint(svc, nil, errRateProv)(context.Background(), proj.ProjectID); err == nil {
+			t.Error("expected rate error converting actual amounts")
+		}
+
+		budgetUSD, _ := projecta.NewBudget(uuid.New(), proj.ProjectID, cat, nil, money.New(100, "USD"), "")
+		svcUSD := &mockBudgetService{report: &projecta.BudgetReport{
+			Project: proj,
+			Lines:   []*projecta.BudgetReportLine{{Budget: budgetUSD}},
+		}}
+		if _, err = makeShowBudgetReportEndpoint(svcUSD, nil, errRateProv)(context.Background(), proj.ProjectID); err == nil {
+			t.Error("expected rate error converting planned amount")
+		}
+	})
+}
+
+func TestFixedRateDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	vars := map[string]string{"project_id": projectID.String(), "currency": "USD"}
+
+	t.Run("decoders", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, "/", bytes.NewReader([]byte(`{"rate":41.5}`)))
+		res, err := decodeSetFixedRateRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil || res.(projecta.SetFixedRateCommand).Rate != 41.5 || res.(projecta.SetFixedRateCommand).Currency != "USD" {
+			t.Errorf("unexpected set fixed rate command %v, %v", res, err)
+		}
+
+		req, _ = http.NewRequest(http.MethodPut, "/", bytes.NewReader([]byte(`{bad`)))
+		if _, err = decodeSetFixedRateRequest(ctx, mux.SetURLVars(req, vars)); err == nil {
+			t.Error("expected invalid json")
+		}
+
+		req, _ = http.NewRequest(http.MethodPut, "/", nil)
+		if _, err = decodeSetFixedRateRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": "bad"})); err == nil {
+			t.Error("expected invalid project_id")
+		}
+
+		req, _ = http.NewRequest(http.MethodDelete, "/", nil)
+		if _, err = decodeRemoveFixedRateRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": projectID.String()})); err == nil {
+			t.Error("expected missing currency")
+		}
+
+		if _, err = decodeRemoveFixedRateRequest(ctx, mux.SetURLVars(req, map[string]string{"currency": "USD"})); err == nil {
+			t.Error("expected missing project_id")
+		}
+
+		res, err = decodeRemoveFixedRateRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil || res.(projecta.RemoveFixedRateCommand).Currency != "USD" {
+			t.Errorf("unexpected remove fixed rate command %v, %v", res, err)
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		rate, _ := projecta.NewFixedRate(projectID, "USD", "UAH", 41.5)
+		svc := &mockFixedRateService{rates: []*projecta.FixedRate{rate}}
+		svcErr := &mockFixedRateService{err: errors.New("service failure")}
+
+		res, err := makeListFixedRatesEndpoint(svc)(ctx, projectID)
+		if err != nil || len(res.(ListFixedRatesResponse).Rates) != 1 || res.(ListFixedRatesResponse).Rates[0].BaseCurrency != "UAH" {
+			t.Errorf("unexpected fixed rates %v, %v", res, err)
+		}
+		if _, err = makeListFixedRatesEndpoint(svcErr)(ctx, projectID); err == nil {
+			t.Error("expected list fixed rates error")
+		}
+
+		cmd := projecta.SetFixedRateCommand{ProjectID: projectID, Currency: "USD", Rate: 41.5}
+		if res, err = makeSetFixedRateEndpoint(svc)(ctx, cmd); err != nil || res.(FixedRateDTO).Rate != 41.5 {
+			t.Errorf("unexpected fixed rate %v, %v", res, err)
+		}
+		if _, err = makeSetFixedRateEndpoint(svcErr)(ctx, cmd); err == nil {
+			t.Error("expected set fixed rate error")
+		}
+
+		if _, err = makeRemoveFixedRateEndpoint(svcErr)(ctx, projecta.RemoveFixedRateCommand{}); err == nil {
+			t.Error("expected remove fixed rate error")
+		}
+	})
+
+	t.Run("fixed rates win over market rates", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New()}
+		proj, _ := projecta.NewProject(projectID, "Project", "Desc", owner, time.Now(), time.Now())
+		cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Cat", "Desc")
+		costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Type", "Desc")
+		ast := asset.NewAsset(uuid.New(), "Asset", "Desc", proj, costType, money.New(100, money.USD), time.Now(), owner)
+		rate, _ := projecta.NewFixedRate(projectID, "USD", "UAH", 41.5)
+
+		res, err := makeGetAssetEndpoint(&mockAssetService{asset: ast}, &mockFixedRateService{rates: []*projecta.FixedRate{rate}}, &mockRateProvider{})(ctx, asset.Filter{})
+		if err != nil || res.(AssetDTO).HomeAmount != 4150 {
+			t.Errorf("expected conversion at fixed rate, got %v, %v", res, err)
+		}
+
+		// market rates are used for a project without fixed rates
+		res, err = makeGetAssetEndpoint(&mockAssetService{asset: ast}, &mockFixedRateService{err: exceptions.NewNotFoundException("project not found", nil)}, &mockRateProvider{})(ctx, asset.Filter{})
+		if err != nil || res.(AssetDTO).HomeAmount != 4000 {
+			t.Errorf("expected conversion at market rate, got %v, %v", res, err)
+		}
+
+		// rather than when the fixed ones fail to load
+		if _, err = makeGetAssetEndpoint(&mockAssetService{asset: ast}, &mockFixedRateService{err: errors.New("db error")}, &mockRateProvider{})(ctx, asset.Filter{}); err == nil {
+			t.Error("expected fixed rates error")
+		}
+	})
+}
+
+func TestPaymentsReportDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	vars := map[string]string{"project_id": projectID.String()}
+
+	t.Run("decoder", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/?group_by=category,+kind,category,owner&period=quarter&from=2026-01-01&to=2026-03-31", nil)
+		res, err := decodePaymentsReportRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		filter := res.(projecta.PaymentReportFilter)
+		expectedGroups := []projecta.PaymentGrouping{projecta.GroupByCategory, projecta.GroupByKind, projecta.GroupByOwner}
+		if filter.ProjectID != projectID || !slices.Equal(filter.GroupBy, expectedGroups) || filter.Period != projecta.PeriodQuarter {
+			t.Errorf("unexpected filter %+v", filter)
+		}
+		if !filter.From.Equal(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)) || !filter.To.Equal(time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected date range %v - %v", filter.From, filter.To)
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, "/", nil)
+		res, err = decodePaymentsReportRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil || len(res.(projecta.PaymentReportFilter).GroupBy) != 0 || res.(projecta.PaymentReportFilter).Period != "" {
+			t.Errorf("expected a plain total, got %v, %v", res, err)
+		}
+
+		for _, query := range []string{
+			"group_by=vendor",
+			"period=week",
+			"from=01.01.2026",
+			"to=tomorrow",
+			"from=2026-02-01&to=2026-01-01",
+		} {
+			req, _ = http.NewRequest(http.MethodGet, "/?"+query, nil)
+			var ex exceptions.Exception
+			if _, err = decodePaymentsReportRequest(ctx, mux.SetURLVars(req, vars)); !errors.As(err, &ex) || ex.Code != exceptions.ValidationFailed {
+				t.Errorf("expected validation error for %s, got %v", query, err)
+			}
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, "/", nil)
+		if _, err = decodePaymentsReportRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": "bad"})); err == nil {
+			t.Error("expected invalid project_id")
+		}
+	})
+
+	t.Run("endpoint", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Owner"}
+		partner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Partner"}
+		proj, _ := projecta.NewProject(projectID, "Project", "Desc", owner, time.Now(), time.Now())
+		materials, _ := projecta.NewCostCategory(uuid.New(), projectID, "Materials", "")
+		labour, _ := projecta.NewCostCategory(uuid.New(), projectID, "Labour", "")
+
+		jan := time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC)
+		feb := time.Date(2026, time.February, 3, 0, 0, 0, 0, time.UTC)
+		apr := time.Date(2026, time.April, 20, 0, 0, 0, 0, time.UTC)
+
+		paySvc := &mockPaymentService{totals: []*projecta.PaymentSubtotal{
+			{Amount: money.New(1000, money.UAH), Date: jan, Count: 2, Category: materials, Owner: owner},
+			{Amount: money.New(100, money.USD), Date: feb, Count: 1, Category: materials, Owner: owner},
+			{Amount: money.New(500, money.UAH), Date: feb, Count: 1, Category: labour, Owner: partner},
+			{Amount: money.New(700, money.UAH), Date: apr, Count: 1, Category: labour, Owner: partner},
+		}}
+		rates := &mockRateProvider{}
+
+		filter := projecta.PaymentReportFilter{
+			PaymentTotalsFilter: projecta.PaymentTotalsFilter{
+				ProjectID: projectID,
+				GroupBy:   []projecta.PaymentGrouping{projecta.GroupByCategory, projecta.GroupByOwner},
+			},
+			Period: projecta.PeriodQuarter,
+		}
+
+		res, err := makeShowPaymentsReportEndpoint(&mockProjectService{project: proj}, paySvc, nil, rates)(ctx, filter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		report := res.(PaymentsReportDTO)
+		if report.Currency != money.UAH || report.Total != 1000+4000+500+700 || len(report.Lines) != 3 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		first, second, third := report.Lines[0], report.Lines[1], report.Lines[2]
+		if first.Period != "2026-Q1" || first.Category.Name != "Materials" || first.Owner.DisplayName != "Owner" || first.Amount != 5000 || first.Count != 3 {
+			t.Errorf("unexpected first line %+v", first)
+		}
+		if second.Period != "2026-Q1" || second.Category.Name != "Labour" || second.Amount != 500 {
+			t.Errorf("unexpected second line %+v", second)
+		}
+		if third.Period != "2026-Q2" || third.Amount != 700 || third.Type != nil || third.Kind != "" {
+			t.Errorf("unexpected third line %+v", third)
+		}
+		if len(rates.dates) != 1 || !rates.dates[0].Equal(feb) {
+			t.Errorf("expected the USD subtotal converted at its day, got %v", rates.dates)
+		}
+
+		// without a period every subtotal of a group adds up to one line
+		filter.Period = ""
+		filter.GroupBy = []projecta.PaymentGrouping{projecta.GroupByType, projecta.GroupByKind}
+		costType, _ := projecta.NewCostType(projectID, materials, "Bricks", "")
+		paySvc.totals = []*projecta.PaymentSubtotal{
+			{Amount: money.New(300, money.UAH), Date: jan, Count: 1, Type: costType, Kind: projecta.DownPayment},
+			{Amount: money.New(200, money.UAH), Date: apr, Count: 1, Type: costType, Kind: projecta.DownPayment},
+		}
+		res, err = makeShowPaymentsReportEndpoint(&mockProjectService{project: proj}, paySvc, nil, rates)(ctx, filter)
+		lines := res.(PaymentsReportDTO).Lines
+		if err != nil || len(lines) != 1 || lines[0].Period != "" || lines[0].Type.Name != "Bricks" || lines[0].Kind != "DOWN_PAYMENT" || lines[0].Amount != 500 {
+			t.Errorf("unexpected report %v, %v", res, err)
+		}
+
+		if _, err = makeShowPaymentsReportEndpoint(&mockProjectService{err: errors.New("not found")}, paySvc, nil, rates)(ctx, filter); err == nil {
+			t.Error("expected project error")
+		}
+		if _, err = makeShowPaymentsReportEndpoint(&mockProjectService{project: proj}, &mockPaymentService{err: errors.New("db error")}, nil, rates)(ctx, filter); err == nil {
+			t.Error("expected payments error")
+		}
+
+		paySvc.totals = []*projecta.PaymentSubtotal{{Amount: money.New(100, money.USD), Date: feb, Count: 1}}
+		if _, err = makeShowPaymentsReportEndpoint(&mockProjectService{project: proj}, paySvc, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, filter); err == nil {
+			t.Error("expected conversion error")
+		}
+	})
+}
+
+func TestCashFlowDecoderAndEndpoint(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	vars := map[string]string{"project_id": projectID.String()}
+
+	t.Run("decoder", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		res, err := decodeCashFlowRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil || res.(projecta.CashFlowFilter).Period != projecta.PeriodMonth || res.(projecta.CashFlowFilter).Window != projecta.DefaultBurnRateWindow {
+			t.Errorf("expected monthly defaults, got %v, %v", res, err)
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, "/?period=quarter&window=2", nil)
+		res, err = decodeCashFlowRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil || res.(projecta.CashFlowFilter).Period != projecta.PeriodQuarter || res.(projecta.CashFlowFilter).Window != 2 || res.(projecta.CashFlowFilter).ProjectID != projectID {
+			t.Errorf("unexpected filter %v, %v", res, err)
+		}
+
+		for _, query := range []string{"period=week", "window=0", "window=three"} {
+			req, _ = http.NewRequest(http.MethodGet, "/?"+query, nil)
+			if _, err = decodeCashFlowRequest(ctx, mux.SetURLVars(req, vars)); err == nil {
+				t.Errorf("expected validation error for %s", query)
+			}
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, "/", nil)
+		if _, err = decodeCashFlowRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": "bad"})); err == nil {
+			t.Error("expected invalid project_id")
+		}
+	})
+
+	t.Run("endpoint", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New()}
+		thisMonth := projecta.PeriodMonth.Start(time.Now())
+		lastMonth := thisMonth.AddDate(0, -1, 0)
+		proj, _ := projecta.NewProject(projectID, "Project", "Desc", owner, lastMonth, thisMonth.AddDate(0, 2, 0))
+		cat, _ := projecta.NewCostCategory(uuid.New(), projectID, "Cat", "")
+		budget, _ := projecta.NewBudget(uuid.New(), projectID, cat, nil, money.New(100, money.USD), "")
+
+		budgetSvc := &mockBudgetService{report: &projecta.BudgetReport{Project: proj, Lines: []*projecta.BudgetReportLine{{Budget: budget}}}}
+		paySvc := &mockPaymentService{totals: []*projecta.PaymentSubtotal{
+			{Amount: money.New(1000, money.UAH), Date: lastMonth.AddDate(0, 0, 4), Count: 2},
+			{Amount: money.New(50, money.USD), Date: lastMonth.AddDate(0, 0, 9), Count: 1},
+			{Amount: money.New(300, money.UAH), Date: thisMonth, Count: 1},
+		}}
+		filter := projecta.CashFlowFilter{ProjectID: projectID, Period: projecta.PeriodMonth, Window: 3}
+
+		res, err := makeShowCashFlowEndpoint(budgetSvc, paySvc, nil, &mockRateProvider{})(ctx, filter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// this month has spent 300 of the 3000 it is expected to
+		cf := res.(CashFlowDTO)
+		if cf.Currency != money.UAH || cf.Planned != 4000 || cf.Spent != 3300 || cf.BurnRate != 3000 || cf.RemainingPeriods != 2 || cf.Projected != 12000 || !cf.Overrun {
+			t.Errorf("unexpected cash flow %+v", cf)
+		}
+		if len(cf.Series) != 2 || cf.Series[0].Amount != 3000 || cf.Series[1].Cumulative != 3300 || cf.Series[1].Period != thisMonth.Format("2006-01") {
+			t.Errorf("unexpected series %+v", cf.Series)
+		}
+		if cf.ExhaustedAt != thisMonth.Format("2006-01") {
+			t.Errorf("expected the budget to run out this month, got %q", cf.ExhaustedAt)
+		}
+
+		// a type budget within the budgeted category does not add to the plan
+		costType, _ := projecta.NewCostType(projectID, cat, "Type", "")
+		typeBudget, _ := projecta.NewBudget(uuid.New(), projectID, cat, costType, money.New(50, money.USD), "")
+		budgetSvc.report.Lines = append(budgetSvc.report.Lines, &projecta.BudgetReportLine{Budget: typeBudget})
+		res, err = makeShowCashFlowEndpoint(budgetSvc, paySvc, nil, &mockRateProvider{})(ctx, filter)
+		if err != nil || res.(CashFlowDTO).Planned != 4000 {
+			t.Errorf("expected the nested budget counted once, got %v, %v", res, err)
+		}
+
+		budgetSvc.report.Lines = nil
+		res, err = makeShowCashFlowEndpoint(budgetSvc, paySvc, nil, &mockRateProvider{})(ctx, filter)
+		if err != nil || res.(CashFlowDTO).ExhaustedAt != "" || res.(CashFlowDTO).Overrun {
+			t.Errorf("expected no forecast without budgets, got %v, %v", res, err)
+		}
+
+		if _, err = makeShowCashFlowEndpoint(&mockBudgetService{err: errors.New("not found")}, paySvc, nil, nil)(ctx, filter); err == nil {
+			t.Error("expected budget report error")
+		}
+		if _, err = makeShowCashFlowEndpoint(budgetSvc, &mockPaymentService{err: errors.New("db error")}, nil, nil)(ctx, filter); err == nil {
+			t.Error("expected payments error")
+		}
+		if _, err = makeShowCashFlowEndpoint(budgetSvc, paySvc, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, filter); err == nil {
+			t.Error("expected payment conversion error")
+		}
+
+		budgetSvc.report.Lines = []*projecta.BudgetReportLine{{Budget: budget}}
+		if _, err = makeShowCashFlowEndpoint(budgetSvc, &mockPaymentService{}, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, filter); err == nil {
+			t.Error("expected budget conversion error")
+		}
+	})
+}
+
+func TestSettlementDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	fromID, toID := uuid.New(), uuid.New()
+
+	t.Run("decoders", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, "/", bytes.NewReader([]byte(`{"weight":2.5}`)))
+		res, err := decodeSetSettlementWeightRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": projectID.String(), "person_id": fromID.String()}))
+		if err != nil || res.(projecta.SetSettlementWeightCommand) != (projecta.SetSettlementWeightCommand{ProjectID: projectID, PersonID: fromID, Weight: 2.5}) {
+			t.Errorf("unexpected weight command %v, %v", res, err)
+		}
+
+		req, _ = http.NewRequest(http.MethodPut, "/", bytes.NewReader([]byte(`{"weight":2.5}`)))
+		if _, err = decodeSetSettlementWeightRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": projectID.String(), "person_id": "bad"})); err == nil {
+			t.Error("expected invalid person_id")
+		}
+
+		req, _ = http.NewRequest(http.MethodPut, "/", bytes.NewReader([]byte(`{bad`)))
+		if _, err = decodeSetSettlementWeightRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": projectID.String(), "person_id": fromID.String()})); err == nil {
+			t.Error("expected invalid body")
+		}
+
+		vars := map[string]string{"project_id": projectID.String()}
+		body := `{"from_id":"` + fromID.String() + `","to_id":"` + toID.String() + `","amount":500,"currency":"USD","transfer_date":"2026-03-01T10:00:00Z","description":"Tiles"}`
+		req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		res, err = decodeRecordSettlementTransferRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cmd := res.(projecta.RecordSettlementTransferCommand)
+		if cmd.ProjectID != projectID || cmd.FromID != fromID || cmd.ToID != toID || cmd.Amount.Amount() != 500 || cmd.Amount.Currency().Code != money.USD || cmd.Description != "Tiles" || !cmd.Date.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected transfer command %+v", cmd)
+		}
+
+		req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"from_id":"`+fromID.String()+`","to_id":"`+toID.String()+`","amount":500,"currency":"UAH"}`)))
+		res, err = decodeRecordSettlementTransferRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil || res.(projecta.RecordSettlementTransferCommand).Date.IsZero() {
+			t.Errorf("expected the transfer to default to now, got %v, %v", res, err)
+		}
+
+		for _, body := range []string{
+			`{bad`,
+			`{"from_id":"bad","to_id":"` + toID.String() + `"}`,
+			`{"from_id":"` + fromID.String() + `","to_id":"bad"}`,
+			`{"from_id":"` + fromID.String() + `","to_id":"` + toID.String() + `","transfer_date":"yesterday"}`,
+		} {
+			req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+			if _, err = decodeRecordSettlementTransferRequest(ctx, mux.SetURLVars(req, vars)); err == nil {
+				t.Errorf("expected validation error for %s", body)
+			}
+		}
+
+		req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		if _, err = decodeRecordSettlementTransferRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": "bad"})); err == nil {
+			t.Error("expected invalid project_id")
+		}
+	})
+
+	t.Run("settlement endpoint", func(t *testing.T) {
+		alice := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Alice"}
+		bob := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Bob"}
+		carol := &projecta.ProjectMember{PersonID: uuid.New(), DisplayName: "Carol"}
+		owner := func(m *projecta.ProjectMember) *projecta.Owner {
+			return &projecta.Owner{PersonID: m.PersonID, DisplayName: m.DisplayName}
+		}
+
+		proj, _ := projecta.NewProject(projectID, "Project", "Desc", owner(alice), time.Now(), time.Now())
+		weight, _ := projecta.NewSettlementWeight(projectID, carol.PersonID, 2)
+		transfer, _ := projecta.NewSettlementTransfer(uuid.New(), projectID, owner(carol), owner(alice), money.New(1000, money.UAH), time.Now(), "")
+
+		settleSvc := &mockSettlementService{ledger: &projecta.SettlementLedger{
+			Project:   proj,
+			Members:   []*projecta.ProjectMember{alice, bob, carol},
+			Weights:   []*projecta.SettlementWeight{weight},
+			Transfers: []*projecta.SettlementTransfer{transfer},
+		}}
+		paySvc := &mockPaymentService{totals: []*projecta.PaymentSubtotal{
+			{Amount: money.New(4000, money.UAH), Date: time.Now(), Count: 3, Owner: owner(alice)},
+			{Amount: money.New(50, money.USD), Date: time.Now(), Count: 1, Owner: owner(bob)},
+		}}
+
+		res, err := makeShowSettlementEndpoint(settleSvc, paySvc, nil, &mockRateProvider{})(ctx, projectID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		report := res.(SettlementDTO)
+		if report.Currency != money.UAH || report.Total != 6000 || len(report.Members) != 3 || len(report.Recorded) != 1 {
+			t.Fatalf("unexpected settlement %+v", report)
+		}
+		if m := report.Members[2]; m.DisplayName != "Carol" || m.Weight != 2 || m.Share != 3000 || m.Sent != 1000 || m.Balance != -2000 {
+			t.Errorf("unexpected balance %+v", m)
+		}
+		if len(report.Transfers) != 2 || report.Transfers[0].From.DisplayName != "Carol" || report.Transfers[0].To.DisplayName != "Alice" || report.Transfers[0].Amount != 1500 || report.Transfers[1].To.DisplayName != "Bob" || report.Transfers[1].Amount != 500 {
+			t.Errorf("unexpected transfers %+v", report.Transfers)
+		}
+
+		if _, err = makeShowSettlementEndpoint(&mockSettlementService{err: errors.New("not found")}, paySvc, nil, nil)(ctx, projectID); err == nil {
+			t.Error("expected ledger error")
+		}
+		if _, err = makeShowSettlementEndpoint(settleSvc, &mockPaymentService{err: errors.New("db error")}, nil, nil)(ctx, projectID); err == nil {
+			t.Error("expected payments error")
+		}
+		if _, err = makeShowSettlementEndpoint(settleSvc, paySvc, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, projectID); err == nil {
+			t.Error("expected payment conversion error")
+		}
+
+		usdTransfer, _ := projecta.NewSettlementTransfer(uuid.New(), projectID, owner(carol), owner(alice), money.New(10, money.USD), time.Now(), "")
+		settleSvc.ledger.Transfers = []*projecta.SettlementTransfer{usdTransfer}
+		if _, err = makeShowSettlementEndpoint(settleSvc, &mockPaymentService{}, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, projectID); err == nil {
+			t.Error("expected transfer conversion error")
+		}
+
+		zero, _ := projecta.NewSettlementWeight(projectID, alice.PersonID, 0)
+		settleSvc.ledger = &projecta.SettlementLedger{Project: proj, Members: []*projecta.ProjectMember{alice}, Weights: []*projecta.SettlementWeight{zero}}
+		if _, err = makeShowSettlementEndpoint(settleSvc, paySvc, nil, &mockRateProvider{})(ctx, projectID); err == nil {
+			t.Error("expected an error when nobody bears the costs")
+		}
+	})
+
+	t.Run("management endpoints", func(t *testing.T) {
+		svc := &mockSettlementService{}
+		svcErr := &mockSettlementService{err: errors.New("forbidden")}
+
+		res, err := makeSetSettlementWeightEndpoint(svc)(ctx, projecta.SetSettlementWeightCommand{ProjectID: projectID, PersonID: fromID, Weight: 2})
+		if err != nil || res.(SettlementWeightDTO) != (SettlementWeightDTO{PersonID: fromID.String(), Weight: 2}) {
+			t.Errorf("unexpected weight %v, %v", res, err)
+		}
+		if _, err = makeSetSettlementWeightEndpoint(svcErr)(ctx, projecta.SetSettlementWeightCommand{}); err == nil {
+			t.Error("expected set weight error")
+		}
+
+		date := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		cmd := projecta.RecordSettlementTransferCommand{ProjectID: projectID, FromID: fromID, ToID: toID, Amount: money.New(500, money.EUR), Date: date, Description: "Tiles"}
+		res, err = makeRecordSettlementTransferEndpoint(svc)(ctx, cmd)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if dto := res.(SettlementTransferDTO); dto.From.PersonID != fromID.String() || dto.To.PersonID != toID.String() || dto.Amount != 500 || dto.Currency != money.EUR || dto.TransferDate != date.Format(time.RFC3339) {
+			t.Errorf("unexpected transfer %+v", dto)
+		}
+		if _, err = makeRecordSettlementTransferEndpoint(svcErr)(ctx, cmd); err == nil {
+			t.Error("expected record transfer error")
+		}
+
+		if _, err = makeRemoveSettlementTransferEndpoint(svc)(ctx, projecta.RemoveProjectResourceCommand{}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err = makeRemoveSettlementTransferEndpoint(svcErr)(ctx, projecta.RemoveProjectResourceCommand{}); err == nil {
+			t.Error("expected remove transfer error")
+		}
+	})
+}
+
+type mockPagedPaymentService struct {
+	mockPaymentService
+	payments []*projecta.Payment
+	failAt   int
+	offsets  []int
+}
+
+func (m *mockPagedPaymentService) Find(_ context.Context, filter projecta.PaymentCollectionFilter) (*projecta.PaymentCollection, error) {
+	m.offsets = append(m.offsets, filter.Offset)
+	if m.failAt > 0 && filter.Offset >= m.failAt {
+		return nil, errors.New("db error")
+	}
+	col := projecta.NewPaymentCollection(len(m.payments))
+	col.Add(m.payments[filter.Offset:min(filter.Offset+filter.Limit, len(m.payments))]...)
+	return col, nil
+}
+
+type mockPagedAssetService struct {
+	mockAssetService
+	assets []*asset.Asset
+}
+
+func (m *mockPagedAssetService) Find(_ context.Context, filter asset.CollectionFilter) (*asset.Collection, error) {
+	col := asset.NewCollection(len(m.assets))
+	col.Add(m.assets[filter.Offset:min(filter.Offset+filter.Limit, len(m.assets))]...)
+	return col, nil
+}
+
+func TestExportDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Alice"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	proj.MainCurrency = money.UAH
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Works", "")
+	costType, _ := projecta.NewCostType(proj.ProjectID, cat, "Plumbing", "")
+	day := time.Date(2026, time.March, 5, 10, 0, 0, 0, time.UTC)
+	vars := map[string]string{"project_id": proj.ProjectID.String()}
+
+	t.Run("decoders", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/?format=xlsx&converted=true&q=plumber&limit=5&offset=10", nil)
+		res, err := decodeExportPaymentsRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		payments := res.(exportPaymentsRequest)
+		if payments.format != spreadsheet.FormatXLSX || !payments.converted || payments.filter.Query != "plumber" || payments.filter.Limit != exportBatchSize || payments.filter.Offset != 0 {
+			t.Errorf("unexpected payments export %+v", payments)
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, "/?type_id="+costType.ID.String(), nil)
+		res, err = decodeExportAssetsRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assets := res.(exportAssetsRequest)
+		if assets.format != spreadsheet.FormatCSV || assets.converted || assets.filter.TypeID != costType.ID || assets.filter.Limit != exportBatchSize {
+			t.Errorf("unexpected assets export %+v", assets)
+		}
+
+		for _, query := range []string{"format=pdf", "converted=maybe", "currency=XYZ1"} {
+			req, _ = http.NewRequest(http.MethodGet, "/?"+query, nil)
+			if _, err = decodeExportPaymentsRequest(ctx, mux.SetURLVars(req, vars)); err == nil {
+				t.Errorf("expected payments validation error for %s", query)
+			}
+		}
+
+		for _, query := range []string{"format=pdf", "converted=maybe", "type_id=bad"} {
+			req, _ = http.NewRequest(http.MethodGet, "/?"+query, nil)
+			if _, err = decodeExportAssetsRequest(ctx, mux.SetURLVars(req, vars)); err == nil {
+				t.Errorf("expected assets validation error for %s", query)
+			}
+		}
+	})
+
+	t.Run("payments endpoint pages through every payment", func(t *testing.T) {
+		svc := &mockPagedPaymentService{payments: []*projecta.Payment{
+			projecta.NewPayment(uuid.New(), proj, owner, costType, "Plumber, bathroom", money.New(150000, money.UAH), day, projecta.DownPayment),
+			projecta.NewPayment(uuid.New(), proj, owner, costType, "=cmd", money.New(2500, money.USD), day, projecta.UponCompletionPayment),
+			projecta.NewPayment(uuid.New(), proj, owner, costType, "Taps", money.New(700, money.JPY), day, projecta.CreditPayment),
+		}}
+		req := exportPaymentsRequest{exportOptions: exportOptions{format: spreadsheet.FormatCSV, converted: true}, filter: projecta.PaymentCollectionFilter{ProjectID: proj.ProjectID}}
+		req.filter.Limit = 2
+
+		res, err := makeExportPaymentsEndpoint(svc, nil, &mockRateProvider{})(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		if err = encodeExport(ctx, rec, res); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "Date,Category,Type,Kind,Status,Description,Owner,Amount,Currency,Main currency amount,Main currency\n" +
+			"2026-03-05,Works,Plumbing,DOWN_PAYMENT,PAID,\"Plumber, bathroom\",Alice,1500.00,UAH,1500.00,UAH\n" +
+			"2026-03-05,Works,Plumbing,UPON_COMPLETION,PAID,'=cmd,Alice,25.00,USD,1000.00,UAH\n" +
+			"2026-03-05,Works,Plumbing,CREDIT_PAYMENT,PAID,Taps,Alice,700,JPY,280.00,UAH\n"
+
+		if rec.Body.String() != expected {
+			t.Errorf("unexpected export:\n%s", rec.Body.String())
+		}
+		if !slices.Equal(svc.offsets, []int{0, 2}) || !rec.Flushed {
+			t.Errorf("expected two pages, got offsets %v", svc.offsets)
+		}
+
+		res, _ = makeExportPaymentsEndpoint(svc, nil, nil)(ctx, req)
+		deadlines := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+		if err = encodeExport(ctx, deadlines, res); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deadlines.deadlines) != 1 || time.Until(deadlines.deadlines[0]) <= 0 {
+			t.Errorf("expected the write deadline pushed back after the first page, got %v", deadlines.deadlines)
+		}
+		if rec.Header().Get("Content-Disposition") != `attachment; filename="payments.csv"` || rec.Header().Get("Content-Type") != spreadsheet.FormatCSV.ContentType() {
+			t.Errorf("unexpected headers %v", rec.Header())
+		}
+
+		req.converted = false
+		res, _ = makeExportPaymentsEndpoint(svc, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, req)
+		rec = httptest.NewRecorder()
+		_ = encodeExport(ctx, rec, res)
+		if !strings.HasPrefix(rec.Body.String(), "Date,Category,Type,Kind,Status,Description,Owner,Amount,Currency\n") {
+			t.Errorf("expected no converted columns, got %s", rec.Body.String())
+		}
+
+		req.converted = true
+		res, _ = makeExportPaymentsEndpoint(svc, nil, &mockRateProvider{err: errors.New("rate error")})(ctx, req)
+		rec = httptest.NewRecorder()
+		_ = encodeExport(ctx, rec, res)
+		if !strings.Contains(rec.Body.String(), "'=cmd,Alice,25.00,USD,,UAH\n") {
+			t.Errorf("expected an empty cell for a failed conversion, got %s", rec.Body.String())
+		}
+
+		if _, err = makeExportPaymentsEndpoint(&mockPagedPaymentService{payments: svc.payments, failAt: 1}, nil, nil)(ctx, exportPaymentsRequest{filter: projecta.PaymentCollectionFilter{Pagination: core.Pagination{Offset: 1, Limit: 2}}}); err == nil {
+			t.Error("expected the first page error to be returned")
+		}
+
+		res, _ = makeExportPaymentsEndpoint(&mockPagedPaymentService{payments: svc.payments, failAt: 2}, nil, nil)(ctx, req)
+		func() {
+			defer func() {
+				if recover() != http.ErrAbortHandler {
+					t.Error("expected the response to be aborted")
+				}
+			}()
+			_ = encodeExport(ctx, httptest.NewRecorder(), res)
+		}()
+	})
+
+	t.Run("assets endpoint", func(t *testing.T) {
+		svc := &mockPagedAssetService{assets: []*asset.Asset{
+			asset.NewAsset(uuid.New(), "Boiler", "Gas", proj, costType, money.New(1000, money.USD), day, owner),
+		}}
+		req := exportAssetsRequest{exportOptions: exportOptions{format: spreadsheet.FormatXLSX, converted: true}, filter: asset.CollectionFilter{ProjectID: proj.ProjectID}}
+		req.filter.Limit = exportBatchSize
+
+		res, err := makeExportAssetsEndpoint(svc, nil, &mockRateProvider{})(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		if err = encodeExport(ctx, rec, res); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rec.Header().Get("Content-Disposition") != `attachment; filename="assets.xlsx"` {
+			t.Errorf("unexpected response %v", rec.Header())
+		}
+		if !bytes.HasPrefix(rec.Body.Bytes(), []byte("PK")) {
+			t.Error("expected a zip archive")
+		}
+
+		rows := &recordingWriter{}
+		_ = res.(exportResponse).write(rows)
+		if len(rows.rows) != 2 || rows.rows[1][1] != "Boiler" || rows.rows[1][8] != (spreadsheet.Decimal{Units: 40000, Scale: 2}) {
+			t.Errorf("unexpected rows %v", rows.rows)
+		}
+
+		req.converted = false
+		res, _ = makeExportAssetsEndpoint(svc, nil, nil)(ctx, req)
+		rows = &recordingWriter{}
+		_ = res.(exportResponse).write(rows)
+		if len(rows.rows[0]) != 8 {
+			t.Errorf("expected no converted columns, got %v", rows.rows[0])
+		}
+
+		if _, err = makeExportAssetsEndpoint(&mockAssetService{err: errors.New("forbidden")}, nil, nil)(ctx, req); err == nil {
+			t.Error("expected assets error")
+		}
+
+		rows = &recordingWriter{err: errors.New("disk full")}
+		if err = res.(exportResponse).write(rows); err == nil {
+			t.Error("expected write error")
+		}
+	})
+
+	t.Run("write errors", func(t *testing.T) {
+		svc := &mockPagedPaymentService{payments: []*projecta.Payment{
+			projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay", money.New(100, money.UAH), day, projecta.DownPayment),
+			projecta.NewPayment(uuid.New(), proj, owner, costType, "Pay", money.New(100, money.UAH), day, projecta.DownPayment),
+		}}
+		req := exportPaymentsRequest{filter: projecta.PaymentCollectionFilter{Pagination: core.Pagination{Limit: 1}}}
+		res, _ := makeExportPaymentsEndpoint(svc, nil, nil)(ctx, req)
+
+		for _, w := range []*recordingWriter{{err: errors.New("disk full")}, {failAfter: 1}, {flushErr: errors.New("gone")}} {
+			if err := res.(exportResponse).write(w); err == nil {
+				t.Errorf("expected write error from %+v", w)
+			}
+		}
+	})
+}
+
+// deadlineRecorder records the write deadlines set through an
+// http.ResponseController.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadlines []time.Time
+}
+
+func (d *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	d.deadlines = append(d.deadlines, deadline)
+	return nil
+}
+
+type recordingWriter struct {
+	rows      [][]any
+	err       error
+	failAfter int
+	flushErr  error
+}
+
+func (r *recordingWriter) Write(row []any) error {
+	if r.err != nil || (r.failAfter > 0 && len(r.rows) > r.failAfter) {
+		return errors.New("write failed")
+	}
+	r.rows = append(r.rows, row)
+	return nil
+}
+func (r *recordingWriter) Flush() error { return r.flushErr }
+func (r *recordingWriter) Close() error { return nil }
+
+func TestImportDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Alice"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	vars := map[string]string{"project_id": proj.ProjectID.String()}
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	decode := func(query string, contentType string, body string) (projecta.ImportPaymentsCommand, error) {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		res, err := decodeImportPaymentsRequest(ctx, mux.SetURLVars(req, vars))
+		if err != nil {
+			return projecta.ImportPaymentsCommand{}, err
+		}
+		return res.(projecta.ImportPaymentsCommand), nil
+	}
+
+	t.Run("reads rows by the mapped columns", func(t *testing.T) {
+		body := "\ufeffДата;Сума;Тип;Опис;Категорія;Зайве\n" +
+			"05.03.2026;\"1 500,50\";Plumbing;\"Taps; two\";Works;x\n" +
+			";;;;;\n" +
+			"06.03.2026;20\n"
+
+		command, err := decode("delimiter=%3B&dry_run=false&create_types=1&date_column=дата&amount_column=Сума&type_column=Тип&description_column=Опис&category_column=Категорія", "text/csv", body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if command.ProjectID != proj.ProjectID || command.DryRun || !command.CreateMissingTypes || len(command.Rows) != 2 {
+			t.Fatalf("unexpected command %+v", command)
+		}
+
+		first := command.Rows[0]
+		if first.Line != 2 || first.Date != "05.03.2026" || first.Amount != "1 500,50" || first.Type != "Plumbing" || first.Description != "Taps; two" || first.Category != "Works" || first.Currency != "" || first.Kind != "" {
+			t.Errorf("unexpected row %+v", first)
+		}
+		if second := command.Rows[1]; second.Line != 4 || second.Amount != "20" || second.Type != "" {
+			t.Errorf("unexpected row %+v", second)
+		}
+	})
+
+	t.Run("defaults to a dry run of a comma separated file", func(t *testing.T) {
+		command, err := decode("", "text/csv", "Date,Amount,Currency,Type,Kind\n2026-03-05,100,USD,Plumbing,DOWN_PAYMENT\n")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !command.DryRun || command.CreateMissingTypes || len(command.Rows) != 1 || command.Rows[0].Currency != "USD" || command.Rows[0].Kind != "DOWN_PAYMENT" {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		command, err = decode("delimiter=tab", "text/csv", "date\tamount\ttype\n2026-03-05\t100\tPlumbing\n")
+		if err != nil || len(command.Rows) != 1 || command.Rows[0].Type != "Plumbing" {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+	})
+
+	t.Run("reads the file field of a form", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file, _ := form.CreateFormFile("file", "payments.csv")
+		_, _ = file.Write([]byte("date,amount,type\n2026-03-05,100,Plumbing\n"))
+		_ = form.Close()
+
+		command, err := decode("", form.FormDataContentType(), body.String())
+		if err != nil || len(command.Rows) != 1 {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		empty := multipart.NewWriter(&body)
+		_ = empty.Close()
+		if _, err = decode("", empty.FormDataContentType(), "--"+empty.Boundary()+"--\r\n"); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for a form without file, got %v", err)
+		}
+		if _, err = decode("", "multipart/form-data; boundary=x", "garbage"); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error for a broken form, got %v", err)
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		valid := "date,amount,type\n2026-03-05,100,Plumbing\n"
+
+		for name, tc := range map[string]struct{ query, body string }{
+			"dry run flag":      {"dry_run=maybe", valid},
+			"create types flag": {"create_types=maybe", valid},
+			"delimiter":         {"delimiter=%3B%3B", valid},
+			"quote delimiter":   {"delimiter=%22", valid},
+			"empty file":        {"", ""},
+			"missing column":    {"", "date,amount\n2026-03-05,100\n"},
+			"missing mapped":    {"kind_column=Вид", valid},
+			"broken quotes":     {"", "date,amount,type\n\"2026-03-05,100,Plumbing\n"},
+			"file too large":    {"", strings.Repeat("a", importMaxBytes+1)},
+		} {
+			if _, err := decode(tc.query, "text/csv", tc.body); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("expected validation error for %s, got %v", name, err)
+			}
+		}
+
+		req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(valid))
+		if _, err := decodeImportPaymentsRequest(ctx, mux.SetURLVars(req, map[string]string{"project_id": "bad"})); err == nil {
+			t.Error("expected error for invalid project id")
+		}
+	})
+
+	t.Run("endpoint reports every line", func(t *testing.T) {
+		newCat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Consumables", "")
+		newType, _ := projecta.NewCostType(proj.ProjectID, newCat, "Grout", "")
+		pay := projecta.NewPayment(uuid.New(), proj, owner, newType, "Grout", money.New(2500, money.USD), time.Now(), projecta.UponCompletionPayment)
+
+		svc := &mockPaymentImportService{report: &projecta.PaymentImport{
+			Lines: []*projecta.PaymentImportLine{
+				{Line: 2, Payment: pay, NewType: true},
+				{Line: 3, Errors: []string{"unknown cost type \"Pipes\""}},
+			},
+			NewCategories: []*projecta.CostCategory{newCat},
+			NewTypes:      []*projecta.CostType{newType},
+		}}
+		command := projecta.ImportPaymentsCommand{ProjectID: proj.ProjectID, DryRun: true}
+
+		res, err := makeImportPaymentsEndpoint(svc, nil, &mockRateProvider{})(ctx, command)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		dto := res.(PaymentImportDTO)
+		if dto.Committed || dto.Total != 2 || dto.Invalid != 1 || svc.command.ProjectID != proj.ProjectID {
+			t.Errorf("unexpected report %+v", dto)
+		}
+		if len(dto.NewCategories) != 1 || dto.NewCategories[0].Name != "Consumables" || len(dto.NewTypes) != 1 || dto.NewTypes[0].Category.Name != "Consumables" {
+			t.Errorf("unexpected new types %+v %+v", dto.NewCategories, dto.NewTypes)
+		}
+		if line := dto.Lines[0]; line.Payment == nil || !line.NewType || line.Payment.Amount != 2500 || line.Payment.HomeAmount != 100000 {
+			t.Errorf("unexpected line %+v", line)
+		}
+		if line := dto.Lines[1]; line.Payment != nil || len(line.Errors) != 1 {
+			t.Errorf("unexpected line %+v", line)
+		}
+
+		svc.err = exceptions.NewNotFoundException("project not found", nil)
+		if _, err = makeImportPaymentsEndpoint(svc, nil, nil)(ctx, command); !hasCode(err, exceptions.NotFound) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+}
+
+func TestStatementDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Alice"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Works", "")
+	costType := &projecta.CostType{ID: uuid.New(), ProjectID: proj.ProjectID, Category: cat, Name: "Materials"}
+	lineID, ruleID := uuid.New(), uuid.New()
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(query string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": proj.ProjectID.String()}
+	lineVars := map[string]string{"project_id": proj.ProjectID.String(), "line_id": lineID.String()}
+
+	t.Run("decodeImportStatementRequest", func(t *testing.T) {
+		body := `[{"id":"a1","time":1772353800,"description":"Epicentr","operationAmount":-2500,"currencyCode":980}]`
+
+		res, err := decodeImportStatementRequest(ctx, request("format=monobank", body, projectVars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		command := res.(statement.ImportCommand)
+		if command.ProjectID != proj.ProjectID || command.Source != "monobank" || len(command.Transactions) != 1 || command.Transactions[0].Amount.Amount() != -2500 {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"no project":     request("format=monobank", body, nil),
+			"no format":      request("", body, projectVars),
+			"invalid file":   request("format=camt053", body, projectVars),
+			"too large file": request("format=monobank", strings.Repeat(" ", importMaxBytes+1), projectVars),
+		} {
+			if _, err = decodeImportStatementRequest(ctx, req); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+
+	t.Run("decodeListStatementLinesRequest", func(t *testing.T) {
+		res, err := decodeListStatementLinesRequest(ctx, request("status=pending&limit=5&offset=10", "", projectVars))
+		filter := res.(statement.LineCollectionFilter)
+		if err != nil || filter.ProjectID != proj.ProjectID || filter.Status != statement.LinePending || filter.Limit != 5 || filter.Offset != 10 {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		res, _ = decodeListStatementLinesRequest(ctx, request("", "", projectVars))
+		if filter = res.(statement.LineCollectionFilter); filter.Limit != core.DefaultLimit || filter.Status != "" {
+			t.Errorf("unexpected default filter %+v", filter)
+		}
+
+		for _, query := range []string{"limit=x", "offset=x", "status=lost"} {
+			if _, err = decodeListStatementLinesRequest(ctx, request(query, "", projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", query, err)
+			}
+		}
+
+		if _, err = decodeListStatementLinesRequest(ctx, request("", "", nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+	})
+
+	t.Run("decodeConfirmStatementLineRequest", func(t *testing.T) {
+		res, err := decodeConfirmStatementLineRequest(ctx, request("", `{"type_id":"`+costType.ID.String()+`","description":"Tiles"}`, lineVars))
+		command := res.(statement.ConfirmLineCommand)
+		if err != nil || command.LineID != lineID || command.TypeID != costType.ID || command.Description != "Tiles" {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"no line":      request("", `{}`, projectVars),
+			"invalid body": request("", `{`, lineVars),
+			"invalid type": request("", `{"type_id":"x"}`, lineVars),
+		} {
+			if _, err = decodeConfirmStatementLineRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("decodeCreateStatementRuleRequest", func(t *testing.T) {
+		res, err := decodeCreateStatementRuleRequest(ctx, request("", `{"field":"counterparty","pattern":"epicentr","type_id":"`+costType.ID.String()+`","priority":5}`, projectVars))
+		command := res.(statement.CreateRuleCommand)
+		if err != nil || command.Field != statement.MatchCounterparty || command.Pattern != "epicentr" || command.TypeID != costType.ID || command.Priority != 5 {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"no project":    request("", `{}`, nil),
+			"invalid body":  request("", `{`, projectVars),
+			"invalid field": request("", `{"field":"amount"}`, projectVars),
+			"invalid type":  request("", `{"type_id":"x"}`, projectVars),
+		} {
+			if _, err = decodeCreateStatementRuleRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		day := time.Date(2026, time.March, 1, 8, 30, 0, 0, time.UTC)
+		booked := statement.NewLine(lineID, proj.ProjectID, "monobank", &statement.Transaction{ExternalID: "a1", Date: day, Amount: money.New(-2500, "UAH"), Description: "Epicentr"})
+		booked.Book(uuid.New())
+		pending := statement.NewLine(uuid.New(), proj.ProjectID, "monobank", &statement.Transaction{ExternalID: "a2", Date: day, Amount: money.New(-100, "UAH"), Counterparty: "Nova Poshta"})
+		lines := statement.NewLineCollection(2)
+		lines.Add(booked)
+		lines.Add(pending)
+		rule, _ := statement.NewRule(ruleID, proj.ProjectID, statement.MatchDescription, "epicentr", costType, 1)
+		payment := projecta.NewPayment(uuid.New(), proj, owner, costType, "Epicentr", money.New(2500, "UAH"), day, projecta.UponCompletionPayment)
+
+		svc := &mockStatementService{
+			report:  &statement.Import{Booked: []*statement.Line{booked}, Pending: []*statement.Line{pending}, Duplicates: 2, Skipped: 1},
+			lines:   lines,
+			payment: payment,
+			rule:    rule,
+		}
+
+		res, err := makeImportStatementEndpoint(svc)(ctx, statement.ImportCommand{})
+		report := res.(StatementImportDTO)
+		if err != nil || len(report.Booked) != 1 || len(report.Pending) != 1 || report.Duplicates != 2 || report.Skipped != 1 {
+			t.Fatalf("unexpected report %+v, %v", report, err)
+		}
+		if dto := report.Booked[0]; dto.LineID != lineID.String() || dto.PaymentID != booked.PaymentID.String() || dto.Amount != 2500 || dto.Status != "BOOKED" || dto.Date != "2026-03-01T08:30:00Z" {
+			t.Errorf("unexpected booked line %+v", dto)
+		}
+		if dto := report.Pending[0]; dto.PaymentID != "" || dto.Counterparty != "Nova Poshta" || dto.Status != "PENDING" {
+			t.Errorf("unexpected pending line %+v", dto)
+		}
+
+		res, err = makeListStatementLinesEndpoint(svc)(ctx, statement.LineCollectionFilter{Pagination: core.Pagination{Limit: 10}})
+		if list := res.(ListStatementLinesResponse); err != nil || len(list.Lines) != 2 || list.Total != 2 || list.Limit != 10 {
+			t.Errorf("unexpected lines %+v, %v", list, err)
+		}
+
+		res, err = makeConfirmStatementLineEndpoint(svc, &mockFixedRateService{}, nil)(ctx, statement.ConfirmLineCommand{})
+		if dto := res.(PaymentDTO); err != nil || dto.PaymentID != payment.ID.String() {
+			t.Errorf("unexpected payment %+v, %v", dto, err)
+		}
+
+		if _, err = makeDismissStatementLineEndpoint(svc)(ctx, projecta.RemoveProjectResourceCommand{}); err != nil {
+			t.Errorf("unexpected dismiss error: %v", err)
+		}
+
+		res, err = makeListStatementRulesEndpoint(svc)(ctx, proj.ProjectID)
+		if list := res.(ListStatementRulesResponse); err != nil || len(list.Rules) != 1 || list.Rules[0].Field != "DESCRIPTION" || list.Rules[0].Type.Category.Name != "Works" {
+			t.Errorf("unexpected rules %+v, %v", list, err)
+		}
+
+		res, err = makeCreateStatementRuleEndpoint(svc)(ctx, statement.CreateRuleCommand{})
+		if dto := res.(StatementRuleDTO); err != nil || dto.RuleID != ruleID.String() || dto.Priority != 1 {
+			t.Errorf("unexpected rule %+v, %v", dto, err)
+		}
+
+		if _, err = makeRemoveStatementRuleEndpoint(svc)(ctx, projecta.RemoveProjectResourceCommand{}); err != nil {
+			t.Errorf("unexpected remove error: %v", err)
+		}
+
+		failing := &mockStatementService{err: exceptions.NewNotFoundException("statement line not found", nil)}
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"import":  makeImportStatementEndpoint(failing),
+			"lines":   makeListStatementLinesEndpoint(failing),
+			"confirm": makeConfirmStatementLineEndpoint(failing, &mockFixedRateService{}, nil),
+			"dismiss": makeDismissStatementLineEndpoint(failing),
+			"rules":   makeListStatementRulesEndpoint(failing),
+			"create":  makeCreateStatementRuleEndpoint(failing),
+			"remove":  makeRemoveStatementRuleEndpoint(failing),
+		} {
+			requests := map[string]any{
+				"import":  statement.ImportCommand{},
+				"lines":   statement.LineCollectionFilter{},
+				"confirm": statement.ConfirmLineCommand{},
+				"dismiss": projecta.RemoveProjectResourceCommand{},
+				"rules":   proj.ProjectID,
+				"create":  statement.CreateRuleCommand{},
+				"remove":  projecta.RemoveProjectResourceCommand{},
+			}
+			if _, err = e(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestRecurringPaymentDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Alice"}
+	proj, _ := projecta.NewProject(uuid.New(), "Project", "Desc", owner, time.Now(), time.Now())
+	cat, _ := projecta.NewCostCategory(uuid.New(), proj.ProjectID, "Services", "")
+	costType := &projecta.CostType{ID: uuid.New(), ProjectID: proj.ProjectID, Category: cat, Name: "Rent"}
+	recurringID := uuid.New()
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(query string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": proj.ProjectID.String()}
+	recurringVars := map[string]string{"project_id": proj.ProjectID.String(), "recurring_id": recurringID.String()}
+	occurrenceVars := map[string]string{"project_id": proj.ProjectID.String(), "recurring_id": recurringID.String(), "date": "2026-02-28"}
+
+	t.Run("decodeCreateRecurringPaymentRequest", func(t *testing.T) {
+		body := `{"type_id":"` + costType.ID.String() + `","description":"Flat","amount":1500000,"currency":"UAH","recurrence":"FREQ=MONTHLY;COUNT=12","start_date":"2026-01-31","end_date":"2026-12-31"}`
+
+		res, err := decodeCreateRecurringPaymentRequest(ctx, request("", body, projectVars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		command := res.(projecta.CreateRecurringPaymentCommand)
+		if command.ProjectID != proj.ProjectID || command.TypeID != costType.ID || command.Amount.Amount() != 1500000 || command.Kind != projecta.UponCompletionPayment ||
+			command.Recurrence.Count != 12 || projecta.OccurrenceKey(command.StartDate) != "2026-01-31" || projecta.OccurrenceKey(command.EndDate) != "2026-12-31" {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		res, err = decodeCreateRecurringPaymentRequest(ctx, request("", `{"type_id":"`+costType.ID.String()+`","kind":"DOWN_PAYMENT","recurrence":"FREQ=WEEKLY","start_date":"2026-01-31"}`, projectVars))
+		if command = res.(projecta.CreateRecurringPaymentCommand); err != nil || command.Kind != projecta.DownPayment || !command.EndDate.IsZero() {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"no project":         request("", body, nil),
+			"invalid body":       request("", `{`, projectVars),
+			"invalid type":       request("", `{"type_id":"x"}`, projectVars),
+			"invalid kind":       request("", `{"type_id":"`+costType.ID.String()+`","kind":"later"}`, projectVars),
+			"invalid recurrence": request("", `{"type_id":"`+costType.ID.String()+`","recurrence":"FREQ=HOURLY"}`, projectVars),
+			"invalid start":      request("", `{"type_id":"`+costType.ID.String()+`","recurrence":"FREQ=DAILY","start_date":"31.01.2026"}`, projectVars),
+			"invalid end":        request("", `{"type_id":"`+costType.ID.String()+`","recurrence":"FREQ=DAILY","start_date":"2026-01-31","end_date":"x"}`, projectVars),
+		} {
+			if _, err = decodeCreateRecurringPaymentRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("decodeListRecurringPaymentsRequest", func(t *testing.T) {
+		res, err := decodeListRecurringPaymentsRequest(ctx, request("limit=5&offset=10", "", projectVars))
+		filter := res.(projecta.RecurringPaymentCollectionFilter)
+		if err != nil || filter.ProjectID != proj.ProjectID || filter.Limit != 5 || filter.Offset != 10 {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		res, _ = decodeListRecurringPaymentsRequest(ctx, request("", "", projectVars))
+		if filter = res.(projecta.RecurringPaymentCollectionFilter); filter.Limit != core.DefaultLimit {
+			t.Errorf("unexpected default filter %+v", filter)
+		}
+
+		for _, query := range []string{"limit=x", "offset=x"} {
+			if _, err = decodeListRecurringPaymentsRequest(ctx, request(query, "", projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", query, err)
+			}
+		}
+
+		if _, err = decodeListRecurringPaymentsRequest(ctx, request("", "", nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+	})
+
+	t.Run("decodeGetRecurringPaymentRequest", func(t *testing.T) {
+		res, err := decodeGetRecurringPaymentRequest(ctx, request("", "", recurringVars))
+		if filter := res.(projecta.RecurringPaymentFilter); err != nil || filter.ProjectID != proj.ProjectID || filter.RecurringID != recurringID {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		if _, err = decodeGetRecurringPaymentRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing recurring payment error")
+		}
+	})
+
+	t.Run("occurrence decoders", func(t *testing.T) {
+		res, err := decodeSkipOccurrenceRequest(ctx, request("", "", occurrenceVars))
+		if command := res.(projecta.SkipOccurrenceCommand); err != nil || command.RecurringID != recurringID || projecta.OccurrenceKey(command.Date) != "2026-02-28" {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		res, err = decodeEditOccurrenceRequest(ctx, request("", `{"amount":1600000,"currency":"UAH","description":"Rent and utilities","payment_date":"2026-03-02T10:00:00Z"}`, occurrenceVars))
+		command := res.(projecta.EditOccurrenceCommand)
+		if err != nil || command.ProjectID != proj.ProjectID || command.Amount.Amount() != 1600000 || command.Description != "Rent and utilities" || command.PaymentDate.Day() != 2 {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		res, err = decodeEditOccurrenceRequest(ctx, request("", `{"description":"Rent"}`, occurrenceVars))
+		if command = res.(projecta.EditOccurrenceCommand); err != nil || command.Amount != nil || !command.PaymentDate.IsZero() {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		badDate := map[string]string{"project_id": proj.ProjectID.String(), "recurring_id": recurringID.String(), "date": "28.02.2026"}
+		if _, err = decodeSkipOccurrenceRequest(ctx, request("", "", badDate)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err = decodeSkipOccurrenceRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing recurring payment error")
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid date":         request("", `{}`, badDate),
+			"invalid body":         request("", `{`, occurrenceVars),
+			"amount with currency": request("", `{"amount":100}`, occurrenceVars),
+			"invalid payment date": request("", `{"payment_date":"2026-03-02"}`, occurrenceVars),
+		} {
+			if _, err = decodeEditOccurrenceRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		start := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+		p, _ := projecta.NewRecurringPayment(recurringID, proj, owner, costType, "Flat", money.New(1500000, "UAH"), projecta.UponCompletionPayment, projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1, Count: 2}, start, start.AddDate(1, 0, 0))
+		paymentID := uuid.New()
+		booked := &projecta.RecurringOccurrence{RecurringID: recurringID, Date: start, Status: projecta.OccurrenceBooked, Amount: money.New(1600000, "UAH"), PaymentDate: start.AddDate(0, 0, 2), PaymentID: paymentID}
+		svc := &mockRecurringPaymentService{payment: p, occurrence: booked}
+
+		res, err := makeCreateRecurringPaymentEndpoint(svc)(ctx, projecta.CreateRecurringPaymentCommand{})
+		dto := res.(RecurringPaymentDTO)
+		if err != nil || dto.RecurringID != recurringID.String() || dto.Recurrence != "FREQ=MONTHLY;INTERVAL=1;COUNT=2" || dto.StartDate != "2026-01-31" || dto.EndDate != "2027-01-31" || dto.NextDate != "2026-01-31" || dto.Type.Category.Name != "Services" {
+			t.Errorf("unexpected recurring payment %+v, %v", dto, err)
+		}
+
+		res, err = makeListRecurringPaymentsEndpoint(svc)(ctx, projecta.RecurringPaymentCollectionFilter{Pagination: core.Pagination{Limit: 10}})
+		if list := res.(ListRecurringPaymentsResponse); err != nil || len(list.RecurringPayments) != 1 || list.Total != 1 || list.Limit != 10 {
+			t.Errorf("unexpected recurring payments %+v, %v", list, err)
+		}
+
+		p.Processed = 2
+		res, err = makeGetRecurringPaymentEndpoint(svc)(ctx, projecta.RecurringPaymentFilter{})
+		if dto = res.(RecurringPaymentDTO); err != nil || dto.NextDate != "" {
+			t.Errorf("expected no next date of a finished schedule, got %+v, %v", dto, err)
+		}
+
+		if _, err = makeRemoveRecurringPaymentEndpoint(svc)(ctx, projecta.RemoveProjectResourceCommand{}); err != nil {
+			t.Errorf("unexpected remove error: %v", err)
+		}
+
+		res, err = makeListRecurringOccurrencesEndpoint(svc)(ctx, projecta.RecurringPaymentFilter{})
+		list := res.(ListRecurringOccurrencesResponse)
+		if err != nil || len(list.Occurrences) != 1 {
+			t.Fatalf("unexpected occurrences %+v, %v", list, err)
+		}
+		if o := list.Occurrences[0]; o.Date != "2026-01-31" || o.Status != "BOOKED" || o.Amount != 1600000 || o.Currency != "UAH" || o.PaymentDate != "2026-02-02T00:00:00Z" || o.PaymentID != paymentID.String() {
+			t.Errorf("unexpected occurrence %+v", o)
+		}
+
+		if _, err = makeSkipOccurrenceEndpoint(svc)(ctx, projecta.SkipOccurrenceCommand{}); err != nil {
+			t.Errorf("unexpected skip error: %v", err)
+		}
+
+		svc.occurrence = &projecta.RecurringOccurrence{RecurringID: recurringID, Date: start, Status: projecta.OccurrenceEdited, Description: "Rent"}
+		res, err = makeEditOccurrenceEndpoint(svc)(ctx, projecta.EditOccurrenceCommand{})
+		if o := res.(RecurringOccurrenceDTO); err != nil || o.Status != "EDITED" || o.Amount != 0 || o.PaymentDate != "" || o.PaymentID != "" || o.Description != "Rent" {
+			t.Errorf("unexpected edited occurrence %+v, %v", o, err)
+		}
+
+		failing := &mockRecurringPaymentService{err: exceptions.NewNotFoundException("recurring payment not found", nil)}
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"create":      makeCreateRecurringPaymentEndpoint(failing),
+			"list":        makeListRecurringPaymentsEndpoint(failing),
+			"get":         makeGetRecurringPaymentEndpoint(failing),
+			"remove":      makeRemoveRecurringPaymentEndpoint(failing),
+			"occurrences": makeListRecurringOccurrencesEndpoint(failing),
+			"skip":        makeSkipOccurrenceEndpoint(failing),
+			"edit":        makeEditOccurrenceEndpoint(failing),
+		} {
+			requests := map[string]any{
+				"create":      projecta.CreateRecurringPaymentCommand{},
+				"list":        projecta.RecurringPaymentCollectionFilter{},
+				"get":         projecta.RecurringPaymentFilter{},
+				"remove":      projecta.RemoveProjectResourceCommand{},
+				"occurrences": projecta.RecurringPaymentFilter{},
+				"skip":        projecta.SkipOccurrenceCommand{},
+				"edit":        projecta.EditOccurrenceCommand{},
+			}
+			if _, err = e(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestLoanDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID, loanID, paymentID := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(query string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": projectID.String()}
+	loanVars := map[string]string{"project_id": projectID.String(), "loan_id": loanID.String()}
+	paymentVars := map[string]string{"project_id": projectID.String(), "loan_id": loanID.String(), "payment_id": paymentID.String()}
+
+	t.Run("create and update", func(t *testing.T) {
+		body := `{"description":"Bank loan","principal":1200000,"currency":"UAH","annual_rate":12,"term":12,"schedule":"differentiated","start_date":"2026-01-31"}`
+
+		res, err := decodeCreateLoanRequest(ctx, request("", body, projectVars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		command := res.(projecta.CreateLoanCommand)
+		if command.ProjectID != projectID || command.Principal.Amount() != 1200000 || command.AnnualRate != 12 || command.Term != 12 || command.Schedule != projecta.DifferentiatedSchedule || !command.StartDate.Equal(start) {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		res, err = decodeUpdateLoanRequest(ctx, request("", `{"principal":100,"currency":"UAH","term":3,"start_date":"2026-01-31"}`, loanVars))
+		update := res.(projecta.UpdateLoanCommand)
+		if err != nil || update.ID != loanID || update.ProjectID != projectID || update.Schedule != projecta.AnnuitySchedule {
+			t.Errorf("unexpected update command %+v, %v", update, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid body":       request("", `{`, projectVars),
+			"invalid schedule":   request("", `{"schedule":"balloon","start_date":"2026-01-31"}`, projectVars),
+			"invalid start date": request("", `{"start_date":"31.01.2026"}`, projectVars),
+		} {
+			if _, err = decodeCreateLoanRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		if _, err = decodeCreateLoanRequest(ctx, request("", body, nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+		if _, err = decodeUpdateLoanRequest(ctx, request("", body, projectVars)); err == nil {
+			t.Error("expected missing loan error")
+		}
+		if _, err = decodeUpdateLoanRequest(ctx, request("", `{`, loanVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		res, err := decodeListLoansRequest(ctx, request("limit=5&offset=10", "", projectVars))
+		filter := res.(projecta.LoanCollectionFilter)
+		if err != nil || filter.ProjectID != projectID || filter.Limit != 5 || filter.Offset != 10 {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		res, _ = decodeListLoansRequest(ctx, request("", "", projectVars))
+		if filter = res.(projecta.LoanCollectionFilter); filter.Limit != core.DefaultLimit {
+			t.Errorf("unexpected default filter %+v", filter)
+		}
+
+		for _, query := range []string{"limit=x", "offset=x"} {
+			if _, err = decodeListLoansRequest(ctx, request(query, "", projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", query, err)
+			}
+		}
+		if _, err = decodeListLoansRequest(ctx, request("", "", nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+
+		res, err = decodeGetLoanRequest(ctx, request("", "", loanVars))
+		if loan := res.(projecta.LoanFilter); err != nil || loan.LoanID != loanID || loan.ProjectID != projectID {
+			t.Errorf("unexpected filter %+v, %v", loan, err)
+		}
+	})
+
+	t.Run("link and unlink", func(t *testing.T) {
+		res, err := decodeLinkLoanPaymentRequest(ctx, request("", `{"payment_id":"`+paymentID.String()+`","installment":3}`, loanVars))
+		command := res.(projecta.LinkLoanPaymentCommand)
+		if err != nil || command.LoanID != loanID || command.ProjectID != projectID || command.PaymentID != paymentID || command.Installment != 3 {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid body":        request("", `{`, loanVars),
+			"invalid payment":     request("", `{"payment_id":"x"}`, loanVars),
+			"invalid installment": request("", `{"payment_id":"`+paymentID.String()+`","installment":-1}`, loanVars),
+		} {
+			if _, err = decodeLinkLoanPaymentRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+		if _, err = decodeLinkLoanPaymentRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing loan error")
+		}
+
+		res, err = decodeUnlinkLoanPaymentRequest(ctx, request("", "", paymentVars))
+		if unlink := res.(projecta.UnlinkLoanPaymentCommand); err != nil || unlink.LoanID != loanID || unlink.PaymentID != paymentID {
+			t.Errorf("unexpected command %+v, %v", unlink, err)
+		}
+		if _, err = decodeUnlinkLoanPaymentRequest(ctx, request("", "", loanVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err = decodeUnlinkLoanPaymentRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing loan error")
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		loan, _ := projecta.NewLoan(loanID, projectID, "Bank loan", money.New(1200000, "UAH"), 12, 12, projecta.DifferentiatedSchedule, start)
+		payment := &projecta.LoanPayment{LoanID: loanID, PaymentID: paymentID, Installment: 1, Amount: money.New(50000, "UAH"), Date: start.AddDate(0, 1, 0)}
+		svc := &mockLoanService{loan: loan, payment: payment}
+
+		res, err := makeCreateLoanEndpoint(svc)(ctx, projecta.CreateLoanCommand{})
+		dto := res.(LoanDTO)
+		if err != nil || dto.LoanID != loanID.String() || dto.Principal != 1200000 || dto.Currency != "UAH" || dto.Schedule != "DIFFERENTIATED" || dto.StartDate != "2026-01-31" {
+			t.Errorf("unexpected loan %+v, %v", dto, err)
+		}
+
+		res, err = makeListLoansEndpoint(svc)(ctx, projecta.LoanCollectionFilter{Pagination: core.Pagination{Limit: 10}})
+		if list := res.(ListLoansResponse); err != nil || len(list.Loans) != 1 || list.Total != 1 || list.Limit != 10 {
+			t.Errorf("unexpected loans %+v, %v", list, err)
+		}
+
+		if res, err = makeGetLoanEndpoint(svc)(ctx, projecta.LoanFilter{}); err != nil || res.(LoanDTO).Term != 12 {
+			t.Errorf("unexpected loan %+v, %v", res, err)
+		}
+
+		res, err = makeShowLoanReportEndpoint(svc)(ctx, projecta.LoanFilter{})
+		report := res.(LoanReportDTO)
+		if err != nil || len(report.Installments) != 12 || report.TotalInterest != 78000 || report.InterestPaid != 12000 || report.PrincipalPaid != 38000 || report.Outstanding != 1162000 || report.NextInstallment != 1 {
+			t.Fatalf("unexpected report %+v, %v", report, err)
+		}
+		if first := report.Installments[0]; first.DueDate != "2026-02-28" || first.Amount != 112000 || first.Paid != 50000 || first.PaidOff || len(first.Payments) != 1 || first.Payments[0].Interest != 12000 {
+			t.Errorf("unexpected first installment %+v", first)
+		}
+
+		// a link returns the split only when the payment has been paid
+		payment.Principal, payment.Interest = nil, nil
+		res, err = makeLinkLoanPaymentEndpoint(svc)(ctx, projecta.LinkLoanPaymentCommand{})
+		if linked := res.(LoanPaymentDTO); err != nil || linked.PaymentID != paymentID.String() || linked.Principal != 0 || linked.PaymentDate != "2026-03-03T00:00:00Z" {
+			t.Errorf("unexpected linked payment %+v, %v", linked, err)
+		}
+
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"update": makeUpdateLoanEndpoint(svc),
+			"remove": makeRemoveLoanEndpoint(svc),
+			"unlink": makeUnlinkLoanPaymentEndpoint(svc),
+		} {
+			requests := map[string]any{
+				"update": projecta.UpdateLoanCommand{},
+				"remove": projecta.RemoveProjectResourceCommand{},
+				"unlink": projecta.UnlinkLoanPaymentCommand{},
+			}
+			if _, err = e(ctx, requests[name]); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+		}
+
+		failing := &mockLoanService{err: exceptions.NewNotFoundException("loan not found", nil)}
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"create": makeCreateLoanEndpoint(failing),
+			"list":   makeListLoansEndpoint(failing),
+			"get":    makeGetLoanEndpoint(failing),
+			"report": makeShowLoanReportEndpoint(failing),
+			"link":   makeLinkLoanPaymentEndpoint(failing),
+			"update": makeUpdateLoanEndpoint(failing),
+		} {
+			requests := map[string]any{
+				"create": projecta.CreateLoanCommand{},
+				"list":   projecta.LoanCollectionFilter{},
+				"get":    projecta.LoanFilter{},
+				"report": projecta.LoanFilter{},
+				"link":   projecta.LinkLoanPaymentCommand{},
+				"update": projecta.UpdateLoanCommand{},
+			}
+			if _, err = e(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestVendorDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID, vendorID, paymentID, assetID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(query string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": projectID.String()}
+	vendorVars := map[string]string{"project_id": projectID.String(), "vendor_id": vendorID.String()}
+
+	t.Run("create and update", func(t *testing.T) {
+		body := `{"name":"Electrician","tax_id":"1234567890","contact_name":"Taras","email":"taras@example.com","phone":"+380501234567","iban":"UA213223130000026007233566001","shared":true}`
+
+		res, err := decodeCreateVendorRequest(ctx, request("", body, projectVars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		command := res.(projecta.CreateVendorCommand)
+		if command.ProjectID != projectID || !command.Shared || command.Name != "Electrician" || command.TaxID != "1234567890" || command.ContactName != "Taras" || command.Email != "taras@example.com" || command.Phone != "+380501234567" || command.IBAN != "UA213223130000026007233566001" {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		res, err = decodeUpdateVendorRequest(ctx, request("", `{"name":"Plumber"}`, vendorVars))
+		if update := res.(projecta.UpdateVendorCommand); err != nil || update.ID != vendorID || update.ProjectID != projectID || update.Name != "Plumber" {
+			t.Errorf("unexpected update command %+v, %v", update, err)
+		}
+
+		if _, err = decodeCreateVendorRequest(ctx, request("", `{`, projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err = decodeCreateVendorRequest(ctx, request("", body, nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+		if _, err = decodeUpdateVendorRequest(ctx, request("", body, projectVars)); err == nil {
+			t.Error("expected missing vendor error")
+		}
+		if _, err = decodeUpdateVendorRequest(ctx, request("", `{`, vendorVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		res, err := decodeListVendorsRequest(ctx, request("limit=5&offset=10&name=elec", "", projectVars))
+		filter := res.(projecta.VendorCollectionFilter)
+		if err != nil || filter.ProjectID != projectID || filter.Limit != 5 || filter.Offset != 10 || filter.Name != "elec" {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		res, _ = decodeListVendorsRequest(ctx, request("", "", projectVars))
+		if filter = res.(projecta.VendorCollectionFilter); filter.Limit != core.DefaultLimit {
+			t.Errorf("unexpected default filter %+v", filter)
+		}
+
+		for _, query := range []string{"limit=x", "offset=x"} {
+			if _, err = decodeListVendorsRequest(ctx, request(query, "", projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", query, err)
+			}
+		}
+		if _, err = decodeListVendorsRequest(ctx, request("", "", nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+
+		res, err = decodeGetVendorRequest(ctx, request("", "", vendorVars))
+		if vendor := res.(projecta.VendorFilter); err != nil || vendor.VendorID != vendorID || vendor.ProjectID != projectID {
+			t.Errorf("unexpected filter %+v, %v", vendor, err)
+		}
+	})
+
+	t.Run("link and unlink", func(t *testing.T) {
+		res, err := decodeLinkVendorPaymentRequest(ctx, request("", `{"payment_id":"`+paymentID.String()+`"}`, vendorVars))
+		if command := res.(projecta.LinkVendorResourceCommand); err != nil || command.VendorID != vendorID || command.ProjectID != projectID || command.ResourceID != paymentID {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		res, err = decodeLinkVendorAssetRequest(ctx, request("", `{"asset_id":"`+assetID.String()+`"}`, vendorVars))
+		if command := res.(projecta.LinkVendorResourceCommand); err != nil || command.VendorID != vendorID || command.ResourceID != assetID {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, decode := range map[string]func(context.Context, *http.Request) (any, error){
+			"payment": decodeLinkVendorPaymentRequest,
+			"asset":   decodeLinkVendorAssetRequest,
+		} {
+			for _, body := range []string{`{`, `{"payment_id":"x","asset_id":"x"}`} {
+				if _, err = decode(ctx, request("", body, vendorVars)); !hasCode(err, exceptions.ValidationFailed) {
+					t.Errorf("%s %s: expected validation error, got %v", name, body, err)
+				}
+			}
+			if _, err = decode(ctx, request("", "", projectVars)); err == nil {
+				t.Errorf("%s: expected missing vendor error", name)
+			}
+		}
+
+		unlinkVars := map[string]string{"project_id": projectID.String(), "vendor_id": vendorID.String(), "asset_id": assetID.String()}
+		res, err = decodeUnlinkVendorResourceRequest("asset_id")(ctx, request("", "", unlinkVars))
+		if unlink := res.(projecta.LinkVendorResourceCommand); err != nil || unlink.VendorID != vendorID || unlink.ResourceID != assetID {
+			t.Errorf("unexpected command %+v, %v", unlink, err)
+		}
+		if _, err = decodeUnlinkVendorResourceRequest("payment_id")(ctx, request("", "", unlinkVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err = decodeUnlinkVendorResourceRequest("payment_id")(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing vendor error")
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		vendor, _ := projecta.NewVendor(vendorID, uuid.New(), uuid.Nil, "Electrician", "", "", "", "", "GB82 WEST 1234 5698 7654 32")
+		svc := &mockVendorService{vendor: vendor}
+
+		res, err := makeCreateVendorEndpoint(svc)(ctx, projecta.CreateVendorCommand{})
+		if dto := res.(VendorDTO); err != nil || dto.VendorID != vendorID.String() || dto.Name != "Electrician" || dto.IBAN != "GB82WEST12345698765432" || !dto.Shared {
+			t.Errorf("unexpected vendor %+v, %v", dto, err)
+		}
+
+		res, err = makeListVendorsEndpoint(svc)(ctx, projecta.VendorCollectionFilter{Pagination: core.Pagination{Limit: 10}})
+		if list := res.(ListVendorsResponse); err != nil || len(list.Vendors) != 1 || list.Total != 1 || list.Limit != 10 {
+			t.Errorf("unexpected vendors %+v, %v", list, err)
+		}
+
+		if res, err = makeGetVendorEndpoint(svc)(ctx, projecta.VendorFilter{}); err != nil || res.(VendorDTO).Name != "Electrician" {
+			t.Errorf("unexpected vendor %+v, %v", res, err)
+		}
+
+		res, err = makeShowVendorReportEndpoint(svc)(ctx, projecta.VendorFilter{})
+		report := res.(VendorReportDTO)
+		if err != nil || len(report.Paid) != 1 || report.Paid[0].Amount != 500000 || report.Paid[0].Currency != "UAH" || len(report.Committed) != 0 || len(report.Assets) != 1 || report.Assets[0].Amount != 120000 || report.Payments != 2 {
+			t.Errorf("unexpected report %+v, %v", report, err)
+		}
+
+		requests := map[string]any{
+			"update":         projecta.UpdateVendorCommand{},
+			"remove":         projecta.RemoveProjectResourceCommand{},
+			"link payment":   projecta.LinkVendorResourceCommand{},
+			"unlink payment": projecta.LinkVendorResourceCommand{},
+			"link asset":     projecta.LinkVendorResourceCommand{},
+			"unlink asset":   projecta.LinkVendorResourceCommand{},
+		}
+		makers := map[string]func(projecta.VendorService) endpoint.Endpoint{
+			"update":         makeUpdateVendorEndpoint,
+			"remove":         makeRemoveVendorEndpoint,
+			"link payment":   makeLinkVendorPaymentEndpoint,
+			"unlink payment": makeUnlinkVendorPaymentEndpoint,
+			"link asset":     makeLinkVendorAssetEndpoint,
+			"unlink asset":   makeUnlinkVendorAssetEndpoint,
+		}
+		for name, makeEndpoint := range makers {
+			if _, err = makeEndpoint(svc)(ctx, requests[name]); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+		}
+
+		failing := &mockVendorService{err: exceptions.NewNotFoundException("vendor not found", nil)}
+		for name, makeEndpoint := range makers {
+			if _, err = makeEndpoint(failing)(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"create": makeCreateVendorEndpoint(failing),
+			"list":   makeListVendorsEndpoint(failing),
+			"get":    makeGetVendorEndpoint(failing),
+			"report": makeShowVendorReportEndpoint(failing),
+		} {
+			requests := map[string]any{
+				"create": projecta.CreateVendorCommand{},
+				"list":   projecta.VendorCollectionFilter{},
+				"get":    projecta.VendorFilter{},
+				"report": projecta.VendorFilter{},
+			}
+			if _, err = e(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestContractDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID, contractID, vendorID, paymentID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	signed := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(query string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": projectID.String()}
+	contractVars := map[string]string{"project_id": projectID.String(), "contract_id": contractID.String()}
+	paymentVars := map[string]string{"project_id": projectID.String(), "contract_id": contractID.String(), "payment_id": paymentID.String()}
+
+	t.Run("create and update", func(t *testing.T) {
+		body := `{"vendor_id":"` + vendorID.String() + `","title":"Wiring","total":1000000,"currency":"UAH","down_payment":300000,"upon_completion":700000,"signed_at":"2026-02-01"}`
+
+		res, err := decodeCreateContractRequest(ctx, request("", body, projectVars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		command := res.(projecta.CreateContractCommand)
+		if command.ProjectID != projectID || command.VendorID != vendorID || command.Title != "Wiring" || command.Total.Amount() != 1000000 || len(command.Stages) != 2 ||
+			command.Stages[0].Kind != projecta.DownPayment || command.Stages[1].Amount.Amount() != 700000 || !command.SignedAt.Equal(signed) {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		res, err = decodeUpdateContractRequest(ctx, request("", `{"vendor_id":"`+vendorID.String()+`","title":"Wiring","total":100,"currency":"UAH"}`, contractVars))
+		update := res.(projecta.UpdateContractCommand)
+		if err != nil || update.ID != contractID || update.ProjectID != projectID || len(update.Stages) != 0 || !update.SignedAt.IsZero() {
+			t.Errorf("unexpected update command %+v, %v", update, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid body":      request("", `{`, projectVars),
+			"invalid vendor":    request("", `{"vendor_id":"x"}`, projectVars),
+			"invalid signed at": request("", `{"vendor_id":"`+vendorID.String()+`","signed_at":"01.02.2026"}`, projectVars),
+		} {
+			if _, err = decodeCreateContractRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		if _, err = decodeCreateContractRequest(ctx, request("", body, nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+		if _, err = decodeUpdateContractRequest(ctx, request("", body, projectVars)); err == nil {
+			t.Error("expected missing contract error")
+		}
+		if _, err = decodeUpdateContractRequest(ctx, request("", `{`, contractVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		res, err := decodeListContractsRequest(ctx, request("limit=5&offset=10&vendor_id="+vendorID.String(), "", projectVars))
+		filter := res.(projecta.ContractCollectionFilter)
+		if err != nil || filter.ProjectID != projectID || filter.Limit != 5 || filter.Offset != 10 || filter.VendorID != vendorID {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		res, _ = decodeListContractsRequest(ctx, request("", "", projectVars))
+		if filter = res.(projecta.ContractCollectionFilter); filter.Limit != core.DefaultLimit {
+			t.Errorf("unexpected default filter %+v", filter)
+		}
+
+		for _, query := range []string{"limit=x", "offset=x", "vendor_id=x"} {
+			if _, err = decodeListContractsRequest(ctx, request(query, "", projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", query, err)
+			}
+		}
+		if _, err = decodeListContractsRequest(ctx, request("", "", nil)); err == nil {
+			t.Error("expected missing project error")
+		}
+
+		res, err = decodeGetContractRequest(ctx, request("", "", contractVars))
+		if contract := res.(projecta.ContractFilter); err != nil || contract.ContractID != contractID || contract.ProjectID != projectID {
+			t.Errorf("unexpected filter %+v, %v", contract, err)
+		}
+	})
+
+	t.Run("link and unlink", func(t *testing.T) {
+		res, err := decodeLinkContractPaymentRequest(ctx, request("", `{"payment_id":"`+paymentID.String()+`"}`, contractVars))
+		if command := res.(projecta.LinkContractPaymentCommand); err != nil || command.ContractID != contractID || command.ProjectID != projectID || command.PaymentID != paymentID {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for _, body := range []string{`{`, `{"payment_id":"x"}`} {
+			if _, err = decodeLinkContractPaymentRequest(ctx, request("", body, contractVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", body, err)
+			}
+		}
+		if _, err = decodeLinkContractPaymentRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing contract error")
+		}
+
+		res, err = decodeUnlinkContractPaymentRequest(ctx, request("", "", paymentVars))
+		if unlink := res.(projecta.LinkContractPaymentCommand); err != nil || unlink.ContractID != contractID || unlink.PaymentID != paymentID {
+			t.Errorf("unexpected command %+v, %v", unlink, err)
+		}
+		if _, err = decodeUnlinkContractPaymentRequest(ctx, request("", "", contractVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err = decodeUnlinkContractPaymentRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing contract error")
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		contract, _ := projecta.NewContract(contractID, projectID, vendorID, "Wiring", money.New(1000000, "UAH"), []*projecta.ContractStage{
+			{Kind: projecta.UponCompletionPayment, Amount: money.New(700000, "UAH")},
+			{Kind: projecta.DownPayment, Amount: money.New(300000, "UAH")},
+		}, signed)
+		svc := &mockContractService{contract: contract, payments: []*projecta.ContractPayment{
+			{ContractID: contractID, PaymentID: paymentID, Kind: projecta.DownPayment, Status: projecta.PaymentPaid, Amount: money.New(300000, "UAH"), Date: signed},
+			{ContractID: contractID, PaymentID: uuid.New(), Kind: projecta.UponCompletionPayment, Status: projecta.PaymentPlanned, Amount: money.New(200000, "UAH"), Date: signed},
+		}}
+
+		res, err := makeCreateContractEndpoint(svc)(ctx, projecta.CreateContractCommand{})
+		dto := res.(ContractDTO)
+		if err != nil || dto.ContractID != contractID.String() || dto.VendorID != vendorID.String() || dto.Total != 1000000 || dto.DownPayment != 300000 || dto.UponCompletion != 700000 || dto.SignedAt != "2026-02-01" {
+			t.Errorf("unexpected contract %+v, %v", dto, err)
+		}
+
+		res, err = makeListContractsEndpoint(svc)(ctx, projecta.ContractCollectionFilter{Pagination: core.Pagination{Limit: 10}})
+		if list := res.(ListContractsResponse); err != nil || len(list.Contracts) != 1 || list.Total != 1 || list.Limit != 10 {
+			t.Errorf("unexpected contracts %+v, %v", list, err)
+		}
+
+		if res, err = makeGetContractEndpoint(svc)(ctx, projecta.ContractFilter{}); err != nil || res.(ContractDTO).Title != "Wiring" {
+			t.Errorf("unexpected contract %+v, %v", res, err)
+		}
+
+		res, err = makeShowContractReportEndpoint(svc)(ctx, projecta.ContractFilter{})
+		report := res.(ContractReportDTO)
+		if err != nil || report.Currency != "UAH" || report.Paid != 300000 || report.Committed != 200000 || report.Remaining != 700000 || len(report.Stages) != 2 {
+			t.Fatalf("unexpected report %+v, %v", report, err)
+		}
+		if stage := report.Stages[0]; stage.Kind != "DOWN_PAYMENT" || stage.Amount != 300000 || stage.Paid != 300000 || stage.Remaining != 0 {
+			t.Errorf("unexpected first stage %+v", stage)
+		}
+
+		requests := map[string]any{
+			"update": projecta.UpdateContractCommand{},
+			"remove": projecta.RemoveProjectResourceCommand{},
+			"link":   projecta.LinkContractPaymentCommand{},
+			"unlink": projecta.LinkContractPaymentCommand{},
+		}
+		makers := map[string]func(projecta.ContractService) endpoint.Endpoint{
+			"update": makeUpdateContractEndpoint,
+			"remove": makeRemoveContractEndpoint,
+			"link":   makeLinkContractPaymentEndpoint,
+			"unlink": makeUnlinkContractPaymentEndpoint,
+		}
+		for name, makeEndpoint := range makers {
+			if _, err = makeEndpoint(svc)(ctx, requests[name]); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+		}
+
+		failing := &mockContractService{err: exceptions.NewNotFoundException("contract not found", nil)}
+		for name, makeEndpoint := range makers {
+			if _, err = makeEndpoint(failing)(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"create": makeCreateContractEndpoint(failing),
+			"list":   makeListContractsEndpoint(failing),
+			"get":    makeGetContractEndpoint(failing),
+			"report": makeShowContractReportEndpoint(failing),
+		} {
+			requests := map[string]any{
+				"create": projecta.CreateContractCommand{},
+				"list":   projecta.ContractCollectionFilter{},
+				"get":    projecta.ContractFilter{},
+				"report": projecta.ContractFilter{},
+			}
+			if _, err = e(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestEstimateDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID, estimateID, quoteID, vendorID, typeID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(query string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/?"+query, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": projectID.String()}
+	estimateVars := map[string]string{"project_id": projectID.String(), "estimate_id": estimateID.String()}
+	quoteVars := map[string]string{"project_id": projectID.String(), "estimate_id": estimateID.String(), "quote_id": quoteID.String()}
+
+	t.Run("estimates", func(t *testing.T) {
+		res, err := decodeCreateEstimateRequest(ctx, request("", `{"title":"Wiring"}`, projectVars))
+		if command := res.(projecta.CreateEstimateCommand); err != nil || command.ProjectID != projectID || command.Title != "Wiring" {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		res, err = decodeUpdateEstimateRequest(ctx, request("", `{"title":"Rewiring"}`, estimateVars))
+		if update := res.(projecta.UpdateEstimateCommand); err != nil || update.ID != estimateID || update.ProjectID != projectID || update.Title != "Rewiring" {
+			t.Errorf("unexpected update command %+v, %v", update, err)
+		}
+
+		res, err = decodeListEstimatesRequest(ctx, request("limit=5&offset=10", "", projectVars))
+		if filter := res.(projecta.EstimateCollectionFilter); err != nil || filter.ProjectID != projectID || filter.Limit != 5 || filter.Offset != 10 {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		res, err = decodeGetEstimateRequest(ctx, request("", "", estimateVars))
+		if filter := res.(projecta.EstimateFilter); err != nil || filter.EstimateID != estimateID || filter.ProjectID != projectID {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		for name, decode := range map[string]func() (any, error){
+			"create body":    func() (any, error) { return decodeCreateEstimateRequest(ctx, request("", `{`, projectVars)) },
+			"update body":    func() (any, error) { return decodeUpdateEstimateRequest(ctx, request("", `{`, estimateVars)) },
+			"list limit":     func() (any, error) { return decodeListEstimatesRequest(ctx, request("limit=x", "", projectVars)) },
+			"list offset":    func() (any, error) { return decodeListEstimatesRequest(ctx, request("offset=x", "", projectVars)) },
+			"estimate id":    func() (any, error) { return decodeGetEstimateRequest(ctx, request("", "", projectVars)) },
+			"update id":      func() (any, error) { return decodeUpdateEstimateRequest(ctx, request("", `{}`, projectVars)) },
+			"create project": func() (any, error) { return decodeCreateEstimateRequest(ctx, request("", `{}`, nil)) },
+			"list project":   func() (any, error) { return decodeListEstimatesRequest(ctx, request("", "", nil)) },
+		} {
+			if _, err = decode(); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+
+	t.Run("quotes", func(t *testing.T) {
+		body := `{"vendor_id":"` + vendorID.String() + `","note":"Copper","items":[{"type_id":"` + typeID.String() + `","description":"Cable","quantity":12.5,"unit_price":4000,"currency":"UAH"}]}`
+
+		res, err := decodeCreateQuoteRequest(ctx, request("", body, estimateVars))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		command := res.(projecta.CreateQuoteCommand)
+		if command.EstimateID != estimateID || command.VendorID != vendorID || command.Note != "Copper" || len(command.Items) != 1 ||
+			command.Items[0].TypeID != typeID || command.Items[0].Quantity != 12.5 || command.Items[0].UnitPrice.Amount() != 4000 {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		res, err = decodeUpdateQuoteRequest(ctx, request("", body, quoteVars))
+		if update := res.(projecta.UpdateQuoteCommand); err != nil || update.ID != quoteID || update.EstimateID != estimateID || len(update.Items) != 1 {
+			t.Errorf("unexpected update command %+v, %v", update, err)
+		}
+
+		res, err = decodeRemoveQuoteRequest(ctx, request("", "", quoteVars))
+		if remove := res.(projecta.RemoveQuoteCommand); err != nil || remove.QuoteID != quoteID || remove.ProjectID != projectID {
+			t.Errorf("unexpected remove command %+v, %v", remove, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid body":   request("", `{`, estimateVars),
+			"invalid vendor": request("", `{"vendor_id":"x"}`, estimateVars),
+			"invalid type":   request("", `{"vendor_id":"`+vendorID.String()+`","items":[{"type_id":"x"}]}`, estimateVars),
+		} {
+			if _, err = decodeCreateQuoteRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		if _, err = decodeCreateQuoteRequest(ctx, request("", body, projectVars)); err == nil {
+			t.Error("expected missing estimate error")
+		}
+		if _, err = decodeUpdateQuoteRequest(ctx, request("", body, estimateVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected invalid quote id, got %v", err)
+		}
+		if _, err = decodeUpdateQuoteRequest(ctx, request("", `{`, quoteVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if _, err = decodeUpdateQuoteRequest(ctx, request("", body, projectVars)); err == nil {
+			t.Error("expected missing estimate error")
+		}
+		if _, err = decodeRemoveQuoteRequest(ctx, request("", "", estimateVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected invalid quote id, got %v", err)
+		}
+		if _, err = decodeRemoveQuoteRequest(ctx, request("", "", projectVars)); err == nil {
+			t.Error("expected missing estimate error")
+		}
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		res, err := decodeAcceptQuoteRequest(ctx, request("", `{"target":"contract","down_payment":300000,"currency":"UAH","signed_at":"2026-02-01"}`, quoteVars))
+		command := res.(projecta.AcceptQuoteCommand)
+		if err != nil || command.QuoteID != quoteID || command.Target != projecta.QuoteToContract || command.DownPayment.Amount() != 300000 ||
+			command.DownPayment.Currency().Code != "UAH" || !command.SignedAt.Equal(time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		res, err = decodeAcceptQuoteRequest(ctx, request("", `{"target":"payments","due_date":"2026-03-01"}`, quoteVars))
+		if command = res.(projecta.AcceptQuoteCommand); err != nil || command.Target != projecta.QuoteToPayments || command.DownPayment != nil || command.DueDate.Day() != 1 {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, body := range map[string]string{
+			"invalid body":      `{`,
+			"invalid target":    `{"target":"invoice"}`,
+			"invalid signed at": `{"target":"contract","signed_at":"01.02.2026"}`,
+			"invalid due date":  `{"target":"payments","due_date":"01.03.2026"}`,
+		} {
+			if _, err = decodeAcceptQuoteRequest(ctx, request("", body, quoteVars)); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+		if _, err = decodeAcceptQuoteRequest(ctx, request("", `{"target":"contract"}`, estimateVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected invalid quote id, got %v", err)
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New(), DisplayName: "Owner"}
+		proj, _ := projecta.NewProject(projectID, "Project One", "Desc", owner, time.Now(), time.Now())
+		proj.MainCurrency = "UAH"
+
+		estimate, _ := projecta.NewEstimate(estimateID, projectID, "Wiring")
+		cheap, _ := projecta.NewQuote(uuid.New(), estimateID, vendorID, "", []*projecta.EstimateItem{
+			{TypeID: typeID, Quantity: 1, UnitPrice: money.New(100, "USD")},
+			{TypeID: typeID, Quantity: 2, UnitPrice: money.New(500, "UAH")},
+		})
+		pricey, _ := projecta.NewQuote(quoteID, estimateID, vendorID, "", []*projecta.EstimateItem{
+			{TypeID: typeID, Quantity: 1.5, UnitPrice: money.New(4000, "UAH")},
+		})
+		estimate.Quotes = append(estimate.Quotes, pricey, cheap)
+
+		rates := &mockRateProvider{}
+		res, err := makeCompareQuotesEndpoint(&mockEstimateService{estimate: estimate}, &mockProjectService{project: proj}, nil, rates)(ctx, projecta.EstimateFilter{ProjectID: projectID, EstimateID: estimateID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		comparison := res.(EstimateComparisonDTO)
+		if comparison.Currency != "UAH" || len(comparison.Quotes) != 2 {
+			t.Fatalf("unexpected comparison %+v", comparison)
+		}
+		if first := comparison.Quotes[0]; first.Rank != 1 || first.Total != 5000 || first.Difference != 0 || first.Quote.QuoteID != cheap.ID.String() || len(first.Quote.Totals) != 2 {
+			t.Errorf("unexpected cheapest quote %+v", first)
+		}
+		if second := comparison.Quotes[1]; second.Rank != 2 || second.Total != 6000 || second.Difference != 1000 || second.Quote.Items[0].Amount != 6000 {
+			t.Errorf("unexpected second quote %+v", second)
+		}
+		if len(rates.dates) != 1 || !rates.dates[0].IsZero() {
+			t.Errorf("expected the current rates, got %v", rates.dates)
+		}
+
+		failingRates := &mockRateProvider{err: errors.New("no rates")}
+		if _, err = makeCompareQuotesEndpoint(&mockEstimateService{estimate: estimate}, &mockProjectService{project: proj}, nil, failingRates)(ctx, projecta.EstimateFilter{}); err == nil {
+			t.Error("expected conversion error")
+		}
+		if _, err = makeCompareQuotesEndpoint(&mockEstimateService{estimate: estimate}, &mockProjectService{err: errors.New("db")}, nil, rates)(ctx, projecta.EstimateFilter{}); err == nil {
+			t.Error("expected project error")
+		}
+
+		svc := &mockEstimateService{estimate: estimate}
+
+		res, err = makeCreateEstimateEndpoint(svc)(ctx, projecta.CreateEstimateCommand{})
+		if dto := res.(EstimateDTO); err != nil || dto.EstimateID != estimateID.String() || len(dto.Quotes) != 2 || dto.AcceptedQuoteID != "" {
+			t.Errorf("unexpected estimate %+v, %v", dto, err)
+		}
+
+		res, err = makeListEstimatesEndpoint(svc)(ctx, projecta.EstimateCollectionFilter{Pagination: core.Pagination{Limit: 10}})
+		if list := res.(ListEstimatesResponse); err != nil || len(list.Estimates) != 1 || list.Total != 1 || list.Limit != 10 {
+			t.Errorf("unexpected estimates %+v, %v", list, err)
+		}
+
+		if res, err = makeGetEstimateEndpoint(svc)(ctx, projecta.EstimateFilter{}); err != nil || res.(EstimateDTO).Title != "Wiring" {
+			t.Errorf("unexpected estimate %+v, %v", res, err)
+		}
+
+		if res, err = makeAddQuoteEndpoint(svc)(ctx, projecta.CreateQuoteCommand{}); err != nil || res.(QuoteDTO).QuoteID != quoteID.String() {
+			t.Errorf("unexpected quote %+v, %v", res, err)
+		}
+
+		_ = estimate.Accept(quoteID)
+		contract, _ := projecta.NewContract(uuid.New(), projectID, vendorID, "Wiring", money.New(6000, "UAH"), nil, time.Time{})
+		costType, _ := projecta.NewCostType(projectID, &projecta.CostCategory{ID: uuid.New(), Name: "Works"}, "Wiring", "")
+		payment := projecta.NewPayment(uuid.New(), proj, owner, costType, "Cable", money.New(100, "USD"), time.Now(), projecta.UponCompletionPayment)
+
+		svc.acceptance = &projecta.QuoteAcceptance{Estimate: estimate, Contract: contract}
+		res, err = makeAcceptQuoteEndpoint(svc, nil, rates)(ctx, projecta.AcceptQuoteCommand{ProjectID: projectID})
+		if dto := res.(QuoteAcceptanceDTO); err != nil || dto.Estimate.AcceptedQuoteID != quoteID.String() || dto.Contract == nil || dto.Contract.Total != 6000 || dto.Payments != nil {
+			t.Errorf("unexpected acceptance %+v, %v", dto, err)
+		}
+
+		svc.acceptance = &projecta.QuoteAcceptance{Estimate: estimate, Payments: []*projecta.Payment{payment}}
+		res, err = makeAcceptQuoteEndpoint(svc, nil, rates)(ctx, projecta.AcceptQuoteCommand{ProjectID: projectID})
+		if dto := res.(QuoteAcceptanceDTO); err != nil || dto.Contract != nil || len(dto.Payments) != 1 || dto.Payments[0].HomeAmount != 4000 {
+			t.Errorf("unexpected acceptance %+v, %v", dto, err)
+		}
+
+		requests := map[string]any{
+			"update":       projecta.UpdateEstimateCommand{},
+			"remove":       projecta.RemoveProjectResourceCommand{},
+			"update quote": projecta.UpdateQuoteCommand{},
+			"remove quote": projecta.RemoveQuoteCommand{},
+		}
+		makers := map[string]func(projecta.EstimateService) endpoint.Endpoint{
+			"update":       makeUpdateEstimateEndpoint,
+			"remove":       makeRemoveEstimateEndpoint,
+			"update quote": makeUpdateQuoteEndpoint,
+			"remove quote": makeRemoveQuoteEndpoint,
+		}
+		for name, makeEndpoint := range makers {
+			if _, err = makeEndpoint(svc)(ctx, requests[name]); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+		}
+
+		failing := &mockEstimateService{err: exceptions.NewNotFoundException("estimate not found", nil)}
+		for name, makeEndpoint := range makers {
+			if _, err = makeEndpoint(failing)(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+		for name, e := range map[string]func(context.Context, any) (any, error){
+			"create":  makeCreateEstimateEndpoint(failing),
+			"list":    makeListEstimatesEndpoint(failing),
+			"get":     makeGetEstimateEndpoint(failing),
+			"add":     makeAddQuoteEndpoint(failing),
+			"compare": makeCompareQuotesEndpoint(failing, &mockProjectService{project: proj}, nil, rates),
+			"accept":  makeAcceptQuoteEndpoint(failing, nil, rates),
+		} {
+			requests := map[string]any{
+				"create":  projecta.CreateEstimateCommand{},
+				"list":    projecta.EstimateCollectionFilter{},
+				"get":     projecta.EstimateFilter{},
+				"add":     projecta.CreateQuoteCommand{},
+				"compare": projecta.EstimateFilter{},
+				"accept":  projecta.AcceptQuoteCommand{},
+			}
+			if _, err = e(ctx, requests[name]); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("disk error") }
+func (failingReader) Close() error             { return nil }
+
+func TestAttachmentDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID, paymentID, attachmentID := uuid.New(), uuid.New(), uuid.New()
+	png := "\x89PNG\r\n\x1a\n receipt"
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	paymentVars := map[string]string{"project_id": projectID.String(), "payment_id": paymentID.String()}
+	attachmentVars := map[string]string{"project_id": projectID.String(), "attachment_id": attachmentID.String()}
+
+	formRequest := func(field string, fileName string, content string, vars map[string]string) *http.Request {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file, _ := form.CreateFormFile(field, fileName)
+		_, _ = file.Write([]byte(content))
+		_ = form.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		return mux.SetURLVars(req, vars)
+	}
+
+	t.Run("upload", func(t *testing.T) {
+		decode := decodeUploadAttachmentRequest(attachment.PaymentTarget, "payment_id")
+
+		res, err := decode(ctx, formRequest("file", "scan.png", png, paymentVars))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		command := res.(attachment.UploadCommand)
+		if command.ProjectID != projectID || command.Target != (attachment.Target{Kind: attachment.PaymentTarget, ID: paymentID}) || command.FileName != "scan.png" || command.ContentType != "image/png" || string(command.Content) != png {
+			t.Errorf("unexpected command %+v", command)
+		}
+
+		// the type claimed by the client is ignored
+		res, err = decode(ctx, formRequest("file", "invoice.pdf", "<html><body>invoice</body></html>", paymentVars))
+		if command := res.(attachment.UploadCommand); err != nil || command.ContentType != "text/html; charset=utf-8" {
+			t.Errorf("expected the detected type, got %+v, %v", command, err)
+		}
+
+		notForm, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(png))
+		notForm.Header.Set("Content-Type", "image/png")
+
+		broken, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("garbage"))
+		broken.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+
+		for name, req := range map[string]*http.Request{
+			"invalid project": formRequest("file", "scan.png", png, map[string]string{"project_id": "x", "payment_id": paymentID.String()}),
+			"invalid target":  formRequest("file", "scan.png", png, map[string]string{"project_id": projectID.String(), "payment_id": "x"}),
+			"not a form":      mux.SetURLVars(notForm, paymentVars),
+			"broken form":     mux.SetURLVars(broken, paymentVars),
+			"missing file":    formRequest("document", "scan.png", png, paymentVars),
+			"large file":      formRequest("file", "scan.png", strings.Repeat("x", attachment.MaxSize+attachmentFormOverhead), paymentVars),
+		} {
+			if _, err := decode(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+
+		_, err = decode(ctx, formRequest("file", "scan.png", strings.Repeat("x", attachment.MaxSize+attachmentFormOverhead), paymentVars))
+		if err == nil || !strings.Contains(err.Error(), "10 MB") {
+			t.Errorf("expected the size limit in %v", err)
+		}
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+		res, err := decodeListAttachmentsRequest(attachment.AssetTarget, "asset_id")(ctx, mux.SetURLVars(req, map[string]string{"project_id": projectID.String(), "asset_id": paymentID.String()}))
+		if filter := res.(attachment.CollectionFilter); err != nil || filter.ProjectID != projectID || filter.Target != (attachment.Target{Kind: attachment.AssetTarget, ID: paymentID}) {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+		if _, err = decodeListAttachmentsRequest(attachment.AssetTarget, "asset_id")(ctx, mux.SetURLVars(req, paymentVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		res, err = decodeGetAttachmentRequest(ctx, mux.SetURLVars(req, attachmentVars))
+		if filter := res.(attachment.Filter); err != nil || filter.ProjectID != projectID || filter.AttachmentID != attachmentID {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+		if _, err = decodeGetAttachmentRequest(ctx, mux.SetURLVars(req, paymentVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		a, _ := attachment.NewAttachment(attachmentID, projectID, attachment.Target{Kind: attachment.PaymentTarget, ID: paymentID}, "scan.png", "image/png", int64(len(png)), attachment.Checksum([]byte(png)), uuid.New(), time.Now())
+		svc := &mockAttachmentService{attachment: a, content: png}
+
+		res, err := makeUploadAttachmentEndpoint(svc)(ctx, attachment.UploadCommand{ProjectID: projectID, Target: a.Target, FileName: "scan.png", ContentType: "image/png", Content: []byte(png)})
+		if dto := res.(AttachmentDTO); err != nil || dto.TargetType != "PAYMENT" || dto.TargetID != paymentID.String() || dto.Checksum != a.Checksum {
+			t.Errorf("unexpected attachment %+v, %v", dto, err)
+		}
+
+		res, err = makeListAttachmentsEndpoint(svc)(ctx, attachment.CollectionFilter{ProjectID: projectID})
+		if list := res.(ListAttachmentsResponse); err != nil || len(list.Attachments) != 1 || list.Attachments[0].AttachmentID != attachmentID.String() {
+			t.Errorf("unexpected attachments %+v, %v", list, err)
+		}
+
+		res, err = makeDownloadAttachmentEndpoint(svc)(ctx, attachment.Filter{ProjectID: projectID, AttachmentID: attachmentID})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		w := httptest.NewRecorder()
+		if err = encodeAttachmentDownload(ctx, w, res); err != nil || w.Body.String() != png {
+			t.Errorf("unexpected download %q, %v", w.Body.String(), err)
+		}
+		for header, want := range map[string]string{
+			"Content-Type":           "image/png",
+			"Content-Disposition":    "attachment; filename=scan.png",
+			"Content-Length":         strconv.Itoa(len(png)),
+			"ETag":                   `"` + a.Checksum + `"`,
+			"X-Content-Type-Options": "nosniff",
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s = %q, want %q", header, got, want)
+			}
+		}
+
+		func() {
+			defer func() {
+				if recover() != http.ErrAbortHandler {
+					t.Error("expected the response to be aborted")
+				}
+			}()
+			_ = encodeAttachmentDownload(ctx, httptest.NewRecorder(), attachmentDownload{attachment: a, content: failingReader{}})
+		}()
+
+		if _, err = makeRemoveAttachmentEndpoint(svc)(ctx, projecta.RemoveProjectResourceCommand{ProjectID: projectID, ResourceID: attachmentID}); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		failing := &mockAttachmentService{err: exceptions.NewNotFoundException("attachment not found", nil)}
+		for name, call := range map[string]func() (any, error){
+			"upload":   func() (any, error) { return makeUploadAttachmentEndpoint(failing)(ctx, attachment.UploadCommand{}) },
+			"list":     func() (any, error) { return makeListAttachmentsEndpoint(failing)(ctx, attachment.CollectionFilter{}) },
+			"download": func() (any, error) { return makeDownloadAttachmentEndpoint(failing)(ctx, attachment.Filter{}) },
+			"remove": func() (any, error) {
+				return makeRemoveAttachmentEndpoint(failing)(ctx, projecta.RemoveProjectResourceCommand{})
+			},
+		} {
+			if _, err := call(); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestTagDecodersAndEndpoints(t *testing.T) {
+	ctx := context.Background()
+	projectID, paymentID := uuid.New(), uuid.New()
+
+	hasCode := func(err error, code exceptions.ErrorCode) bool {
+		var ex exceptions.Exception
+		return errors.As(err, &ex) && ex.Code == code
+	}
+
+	request := func(target string, body string, vars map[string]string) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, target, strings.NewReader(body))
+		return mux.SetURLVars(req, vars)
+	}
+
+	projectVars := map[string]string{"project_id": projectID.String()}
+	paymentVars := map[string]string{"project_id": projectID.String(), "payment_id": paymentID.String()}
+
+	t.Run("tags query", func(t *testing.T) {
+		tags, err := decodeTagsQuery(url.Values{"tags": {"Tax-Deductible, kitchen,,kitchen"}})
+		if err != nil || strings.Join(tags, "|") != "kitchen|tax-deductible" {
+			t.Errorf("unexpected tags %v, %v", tags, err)
+		}
+		if tags, err = decodeTagsQuery(url.Values{"tags": {" , "}}); err != nil || tags != nil {
+			t.Errorf("expected no tags, got %v, %v", tags, err)
+		}
+		if _, err = decodeTagsQuery(url.Values{"tags": {strings.Repeat("x", projecta.MaxTagLength+1)}}); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		filter := projecta.PaymentCollectionFilter{}
+		if err = decodePaymentSearch(request("/?tags=kitchen", "", projectVars).URL.Query(), &filter); err != nil || len(filter.Tags) != 1 {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+		if err = decodePaymentSearch(request("/?tags="+strings.Repeat("x", projecta.MaxTagLength+1), "", projectVars).URL.Query(), &filter); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+
+		res, err := decodeListAssetsRequest(ctx, request("/?tags=kitchen", "", projectVars))
+		if filter := res.(asset.CollectionFilter); err != nil || len(filter.Tags) != 1 || filter.Tags[0] != "kitchen" {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+		if _, err = decodeListAssetsRequest(ctx, request("/?tags="+strings.Repeat("x", projecta.MaxTagLength+1), "", projectVars)); !hasCode(err, exceptions.ValidationFailed) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		res, err := decodeListTagsRequest(ctx, request("/?name=kit&limit=5", "", projectVars))
+		if filter := res.(projecta.TagCollectionFilter); err != nil || filter.ProjectID != projectID || filter.Name != "kit" || filter.Limit != 5 {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid project": request("/", "", map[string]string{"project_id": "x"}),
+			"invalid limit":   request("/?limit=x", "", projectVars),
+			"zero limit":      request("/?limit=0", "", projectVars),
+		} {
+			if _, err := decodeListTagsRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		decode := decodeSetTagsRequest("payment_id")
+
+		res, err := decode(ctx, request("/", `{"tags":["Kitchen"]}`, paymentVars))
+		if command := res.(projecta.TagResourceCommand); err != nil || command.ProjectID != projectID || command.ResourceID != paymentID || command.Tags[0] != "Kitchen" {
+			t.Errorf("unexpected command %+v, %v", command, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid payment": request("/", `{"tags":[]}`, projectVars),
+			"invalid body":    request("/", `{"tags":"kitchen"}`, paymentVars),
+		} {
+			if _, err := decode(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		res, err := decodeTagsReportRequest(ctx, request("/?from=2026-01-01&to=2026-06-30", "", projectVars))
+		if filter := res.(projecta.TagTotalsFilter); err != nil || filter.ProjectID != projectID || filter.From.Month() != time.January || filter.To.Month() != time.June {
+			t.Errorf("unexpected filter %+v, %v", filter, err)
+		}
+
+		for name, req := range map[string]*http.Request{
+			"invalid project": request("/", "", map[string]string{"project_id": "x"}),
+			"invalid from":    request("/?from=x", "", projectVars),
+			"invalid to":      request("/?to=x", "", projectVars),
+			"reversed":        request("/?from=2026-06-30&to=2026-01-01", "", projectVars),
+		} {
+			if _, err := decodeTagsReportRequest(ctx, req); !hasCode(err, exceptions.ValidationFailed) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		svc := &mockTagService{tags: []*projecta.Tag{{Name: "kitchen", Payments: 2, Assets: 1}}}
+
+		res, err := makeListTagsEndpoint(svc)(ctx, projecta.TagCollectionFilter{ProjectID: projectID})
+		if list := res.(ListTagsResponse); err != nil || len(list.Tags) != 1 || list.Tags[0] != (TagDTO{Name: "kitchen", Payments: 2, Assets: 1}) {
+			t.Errorf("unexpected tags %+v, %v", list, err)
+		}
+
+		res, err = makeSetPaymentTagsEndpoint(svc)(ctx, projecta.TagResourceCommand{Tags: []string{"Kitchen", "kitchen"}})
+		if dto := res.(SetTagsDTO); err != nil || len(dto.Tags) != 1 || dto.Tags[0] != "kitchen" {
+			t.Errorf("unexpected tags %+v, %v", dto, err)
+		}
+
+		res, err = makeSetAssetTagsEndpoint(svc)(ctx, projecta.TagResourceCommand{})
+		if dto := res.(SetTagsDTO); err != nil || len(dto.Tags) != 0 {
+			t.Errorf("unexpected tags %+v, %v", dto, err)
+		}
+
+		failing := &mockTagService{err: exceptions.NewNotFoundException("payment not found", nil)}
+		for name, call := range map[string]func() (any, error){
+			"list":  func() (any, error) { return makeListTagsEndpoint(failing)(ctx, projecta.TagCollectionFilter{}) },
+			"pay":   func() (any, error) { return makeSetPaymentTagsEndpoint(failing)(ctx, projecta.TagResourceCommand{}) },
+			"asset": func() (any, error) { return makeSetAssetTagsEndpoint(failing)(ctx, projecta.TagResourceCommand{}) },
+		} {
+			if _, err := call(); !hasCode(err, exceptions.NotFound) {
+				t.Errorf("%s: expected not found error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("report endpoint", func(t *testing.T) {
+		owner := &projecta.Owner{PersonID: uuid.New()}
+		proj, _ := projecta.NewProject(projectID, "Flat", "", owner, time.Now(), time.Now())
+		day := time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)
+		svc := &mockTagService{subtotals: []*projecta.TagSubtotal{
+			{Tag: "kitchen", Kind: projecta.TaggedPayment, Amount: money.New(1000, "UAH"), Date: day, Count: 2},
+			{Tag: "kitchen", Kind: projecta.TaggedPayment, Amount: money.New(100, "USD"), Date: day, Count: 1},
+			{Tag: "kitchen", Kind: projecta.TaggedAsset, Amount: money.New(500, "UAH"), Date: day, Count: 1},
+			{Tag: "bath", Kind: projecta.TaggedAsset, Amount: money.New(700, "UAH"), Date: day, Count: 1},
+			{Tag: "alpha", Kind: projecta.TaggedAsset, Amount: money.New(700, "UAH"), Date: day, Count: 1},
+		}}
+		rates := &mockRateProvider{}
+
+		res, err := makeShowTagsReportEndpoint(&mockProjectService{project: proj}, svc, nil, rates)(ctx, projecta.TagTotalsFilter{ProjectID: projectID})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		report := res.(TagsReportDTO)
+		want := []TagsReportLineDTO{
+			{Tag: "kitchen", Payments: 5000, PaymentsCount: 3, Assets: 500, AssetsCount: 1},
+			{Tag: "alpha", Assets: 700, AssetsCount: 1},
+			{Tag: "bath", Assets: 700, AssetsCount: 1},
+		}
+		if report.Currency != "UAH" || !reflect.DeepEqual(report.Lines, want) {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if len(rates.dates) != 1 || !rates.dates[0].Equal(day) {
+			t.Errorf("expected the USD subtotal converted at its day, got %v", rates.dates)
+		}
+
+		for name, ep := range map[string]endpoint.Endpoint{
+			"project": makeShowTagsReportEndpoint(&mockProjectService{err: exceptions.NewNotFoundException("project not found", nil)}, svc, nil, rates),
+			"totals":  makeShowTagsReportEndpoint(&mockProjectService{project: proj}, &mockTagService{err: errors.New("db down")}, nil, rates),
+			"rates":   makeShowTagsReportEndpoint(&mockProjectService{project: proj}, svc, nil, &mockRateProvider{err: errors.New("rates down")}),
+		} {
+			if _, err := ep(ctx, projecta.TagTotalsFilter{ProjectID: projectID}); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
 }
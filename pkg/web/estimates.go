@@ -0,0 +1,531 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+type EstimateItemDTO struct {
+	TypeID      string  `json:"type_id"`
+	Description string  `json:"description,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   int64   `json:"unit_price"`
+	Currency    string  `json:"currency"`
+	Amount      int64   `json:"amount,omitempty"`
+}
+
+type QuoteDTO struct {
+	QuoteID  string            `json:"quote_id"`
+	VendorID string            `json:"vendor_id"`
+	Note     string            `json:"note,omitempty"`
+	Items    []EstimateItemDTO `json:"items"`
+	Totals   []VendorAmountDTO `json:"totals"`
+}
+
+type EstimateDTO struct {
+	EstimateID      string     `json:"estimate_id"`
+	Title           string     `json:"title"`
+	AcceptedQuoteID string     `json:"accepted_quote_id,omitempty"`
+	Quotes          []QuoteDTO `json:"quotes"`
+}
+
+type CreateEstimateDTO struct {
+	Title string `json:"title"`
+}
+
+type UpdateEstimateDTO = CreateEstimateDTO
+
+type CreateQuoteDTO struct {
+	VendorID string            `json:"vendor_id"`
+	Note     string            `json:"note,omitempty"`
+	Items    []EstimateItemDTO `json:"items"`
+}
+
+type UpdateQuoteDTO = CreateQuoteDTO
+
+type ListEstimatesResponse struct {
+	Estimates []EstimateDTO `json:"estimates"`
+	PaginationDTO
+}
+
+type RankedQuoteDTO struct {
+	Rank       int      `json:"rank"`
+	Total      int64    `json:"total"`
+	Difference int64    `json:"difference"`
+	Quote      QuoteDTO `json:"quote"`
+}
+
+type EstimateComparisonDTO struct {
+	EstimateID string           `json:"estimate_id"`
+	Title      string           `json:"title"`
+	Currency   string           `json:"currency"`
+	Quotes     []RankedQuoteDTO `json:"quotes"`
+}
+
+// AcceptQuoteDTO turns the quote into a contract, optionally with a down
+// payment in the quote currency, or into payments planned by due_date.
+type AcceptQuoteDTO struct {
+	Target      string `json:"target"`
+	DownPayment int64  `json:"down_payment,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	SignedAt    string `json:"signed_at,omitempty"`
+	DueDate     string `json:"due_date,omitempty"`
+}
+
+type QuoteAcceptanceDTO struct {
+	Estimate EstimateDTO  `json:"estimate"`
+	Contract *ContractDTO `json:"contract,omitempty"`
+	Payments []PaymentDTO `json:"payments,omitempty"`
+}
+
+func toQuoteDTO(q *projecta.Quote) QuoteDTO {
+	dto := QuoteDTO{
+		QuoteID:  q.ID.String(),
+		VendorID: q.VendorID.String(),
+		Note:     q.Note,
+		Items:    make([]EstimateItemDTO, 0, len(q.Items)),
+		Totals:   toVendorAmountDTOs(q.Totals()),
+	}
+
+	for _, item := range q.Items {
+		dto.Items = append(dto.Items, EstimateItemDTO{
+			TypeID:      item.TypeID.String(),
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice.Amount(),
+			Currency:    item.UnitPrice.Currency().Code,
+			Amount:      item.Amount().Amount(),
+		})
+	}
+
+	return dto
+}
+
+func toEstimateDTO(e *projecta.Estimate) EstimateDTO {
+	dto := EstimateDTO{
+		EstimateID: e.ID.String(),
+		Title:      e.Title,
+		Quotes:     make([]QuoteDTO, 0, len(e.Quotes)),
+	}
+
+	if e.IsAccepted() {
+		dto.AcceptedQuoteID = e.AcceptedQuoteID.String()
+	}
+
+	for _, q := range e.Quotes {
+		dto.Quotes = append(dto.Quotes, toQuoteDTO(q))
+	}
+
+	return dto
+}
+
+func toEstimateComparisonDTO(comparison *projecta.EstimateComparison) EstimateComparisonDTO {
+	dto := EstimateComparisonDTO{
+		EstimateID: comparison.Estimate.ID.String(),
+		Title:      comparison.Estimate.Title,
+		Currency:   comparison.Currency,
+		Quotes:     make([]RankedQuoteDTO, 0, len(comparison.Quotes)),
+	}
+
+	for _, ranked := range comparison.Quotes {
+		dto.Quotes = append(dto.Quotes, RankedQuoteDTO{
+			Rank:       ranked.Rank,
+			Total:      ranked.Total,
+			Difference: ranked.Difference,
+			Quote:      toQuoteDTO(ranked.Quote),
+		})
+	}
+
+	return dto
+}
+
+func decodeGetEstimateRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "estimate_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return projecta.EstimateFilter{
+		ProjectID:  command.ProjectID,
+		EstimateID: command.ResourceID,
+	}, nil
+}
+
+func decodeCreateEstimateRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req CreateEstimateDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	return projecta.CreateEstimateCommand{
+		ProjectID: projectID.(uuid.UUID),
+		Title:     req.Title,
+	}, nil
+}
+
+func decodeUpdateEstimateRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetEstimateRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req UpdateEstimateDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	return projecta.UpdateEstimateCommand{
+		ProjectID: filter.(projecta.EstimateFilter).ProjectID,
+		ID:        filter.(projecta.EstimateFilter).EstimateID,
+		Title:     req.Title,
+	}, nil
+}
+
+func decodeListEstimatesRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.EstimateCollectionFilter{
+		Pagination: core.Pagination{Limit: core.DefaultLimit},
+		ProjectID:  projectID.(uuid.UUID),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func decodeQuoteDTO(r *http.Request) (CreateQuoteDTO, uuid.UUID, []*projecta.EstimateItem, error) {
+	var req CreateQuoteDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, uuid.Nil, nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	vendorID, err := uuid.Parse(req.VendorID)
+	if err != nil {
+		return req, uuid.Nil, nil, exceptions.NewValidationException("invalid vendor id", err)
+	}
+
+	items := make([]*projecta.EstimateItem, 0, len(req.Items))
+
+	for _, item := range req.Items {
+		typeID, err := uuid.Parse(item.TypeID)
+		if err != nil {
+			return req, uuid.Nil, nil, exceptions.NewValidationException("invalid type id", err)
+		}
+
+		items = append(items, &projecta.EstimateItem{
+			TypeID:      typeID,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   money.New(item.UnitPrice, item.Currency),
+		})
+	}
+
+	return req, vendorID, items, nil
+}
+
+func decodeQuoteID(r *http.Request) (uuid.UUID, error) {
+	quoteID, err := uuid.Parse(mux.Vars(r)["quote_id"])
+	if err != nil {
+		return uuid.Nil, exceptions.NewValidationException("invalid quote id", err)
+	}
+
+	return quoteID, nil
+}
+
+func decodeCreateQuoteRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetEstimateRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, vendorID, items, err := decodeQuoteDTO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.CreateQuoteCommand{
+		ProjectID:  filter.(projecta.EstimateFilter).ProjectID,
+		EstimateID: filter.(projecta.EstimateFilter).EstimateID,
+		VendorID:   vendorID,
+		Note:       req.Note,
+		Items:      items,
+	}, nil
+}
+
+func decodeUpdateQuoteRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetEstimateRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteID, err := decodeQuoteID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, vendorID, items, err := decodeQuoteDTO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.UpdateQuoteCommand{
+		ProjectID:  filter.(projecta.EstimateFilter).ProjectID,
+		EstimateID: filter.(projecta.EstimateFilter).EstimateID,
+		ID:         quoteID,
+		VendorID:   vendorID,
+		Note:       req.Note,
+		Items:      items,
+	}, nil
+}
+
+func decodeRemoveQuoteRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetEstimateRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteID, err := decodeQuoteID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.RemoveQuoteCommand{
+		ProjectID:  filter.(projecta.EstimateFilter).ProjectID,
+		EstimateID: filter.(projecta.EstimateFilter).EstimateID,
+		QuoteID:    quoteID,
+	}, nil
+}
+
+func decodeAcceptQuoteRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeRemoveQuoteRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := resource.(projecta.RemoveQuoteCommand)
+
+	var req AcceptQuoteDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	target, err := projecta.ToQuoteTarget(req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	command := projecta.AcceptQuoteCommand{
+		ProjectID:  quote.ProjectID,
+		EstimateID: quote.EstimateID,
+		QuoteID:    quote.QuoteID,
+		Target:     target,
+	}
+
+	if req.SignedAt != "" {
+		if command.SignedAt, err = time.Parse(reportDateLayout, req.SignedAt); err != nil {
+			return nil, exceptions.NewValidationException("invalid signed_at", err)
+		}
+	}
+
+	if req.DueDate != "" {
+		if command.DueDate, err = time.Parse(reportDateLayout, req.DueDate); err != nil {
+			return nil, exceptions.NewValidationException("invalid due_date", err)
+		}
+	}
+
+	if req.DownPayment != 0 {
+		command.DownPayment = money.New(req.DownPayment, req.Currency)
+	}
+
+	return command, nil
+}
+
+func makeCreateEstimateEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		e, err := svc.Create(ctx, request.(projecta.CreateEstimateCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toEstimateDTO(e), nil
+	}
+}
+
+func makeUpdateEstimateEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Update(ctx, request.(projecta.UpdateEstimateCommand))
+	}
+}
+
+func makeGetEstimateEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		e, err := svc.FindOne(ctx, request.(projecta.EstimateFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toEstimateDTO(e), nil
+	}
+}
+
+func makeListEstimatesEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.EstimateCollectionFilter)
+
+		collection, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]EstimateDTO, 0)
+		for _, e := range collection.Elements() {
+			list = append(list, toEstimateDTO(e))
+		}
+
+		return ListEstimatesResponse{
+			Estimates: list,
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeRemoveEstimateEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func makeAddQuoteEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		q, err := svc.AddQuote(ctx, request.(projecta.CreateQuoteCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toQuoteDTO(q), nil
+	}
+}
+
+func makeUpdateQuoteEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.UpdateQuote(ctx, request.(projecta.UpdateQuoteCommand))
+	}
+}
+
+func makeRemoveQuoteEndpoint(svc projecta.EstimateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.RemoveQuote(ctx, request.(projecta.RemoveQuoteCommand))
+	}
+}
+
+// makeCompareQuotesEndpoint converts the quote totals into the main currency of
+// the project at the latest rates and ranks the quotes from the cheapest one.
+func makeCompareQuotesEndpoint(svc projecta.EstimateService, projectSvc projecta.ProjectService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.EstimateFilter)
+
+		e, err := svc.FindOne(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		proj, err := projectSvc.FindOne(ctx, projecta.ProjectFilter{ProjectID: filter.ProjectID})
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, filter.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		homeCurrency := proj.MainCurrency
+		if homeCurrency == "" {
+			homeCurrency = "UAH"
+		}
+
+		totals := make(map[uuid.UUID]int64, len(e.Quotes))
+
+		for _, q := range e.Quotes {
+			for _, subtotal := range q.Totals() {
+				amount, err := toHomeAmount(rates, subtotal, homeCurrency, time.Time{})
+				if err != nil {
+					return nil, err
+				}
+				totals[q.ID] += amount
+			}
+		}
+
+		return toEstimateComparisonDTO(projecta.NewEstimateComparison(e, homeCurrency, totals)), nil
+	}
+}
+
+func makeAcceptQuoteEndpoint(svc projecta.EstimateService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.AcceptQuoteCommand)
+
+		acceptance, err := svc.Accept(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		dto := QuoteAcceptanceDTO{Estimate: toEstimateDTO(acceptance.Estimate)}
+
+		if acceptance.Contract != nil {
+			contract := toContractDTO(acceptance.Contract)
+			dto.Contract = &contract
+		}
+
+		if len(acceptance.Payments) > 0 {
+			rates, err := projectRates(ctx, fixedRates, command.ProjectID, rateProvider)
+			if err != nil {
+				return nil, err
+			}
+			dto.Payments = make([]PaymentDTO, 0, len(acceptance.Payments))
+
+			for _, p := range acceptance.Payments {
+				dto.Payments = append(dto.Payments, toPaymentDTO(p, rates))
+			}
+		}
+
+		return dto, nil
+	}
+}
@@ -0,0 +1,325 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+	"gitlab.com/massimo-ua/projecta/pkg/spreadsheet"
+)
+
+// exportBatchSize is the number of rows fetched at a time while exporting.
+const exportBatchSize = 500
+
+type exportOptions struct {
+	format    spreadsheet.Format
+	converted bool
+}
+
+type exportPaymentsRequest struct {
+	exportOptions
+	filter projecta.PaymentCollectionFilter
+}
+
+type exportAssetsRequest struct {
+	exportOptions
+	filter asset.CollectionFilter
+}
+
+// exportResponse is a file written while it is sent. Only the first page of
+// rows is fetched by the endpoint, so access errors still get their status.
+type exportResponse struct {
+	format spreadsheet.Format
+	name   string
+	write  func(w spreadsheet.Writer) error
+}
+
+// decodeExportOptions reads the file format and whether the amounts converted
+// into the project main currency are wanted, e.g. ?format=xlsx&converted=true.
+func decodeExportOptions(query url.Values) (exportOptions, error) {
+	var (
+		options exportOptions
+		err     error
+	)
+
+	if options.format, err = spreadsheet.ParseFormat(query.Get("format")); err != nil {
+		return options, err
+	}
+
+	if converted := query.Get("converted"); converted != "" {
+		if options.converted, err = strconv.ParseBool(converted); err != nil {
+			return options, exceptions.NewValidationException("invalid converted flag", err)
+		}
+	}
+
+	return options, nil
+}
+
+// decodeExportPaymentsRequest reads the same filters as the payment list. The
+// pagination is left out, an export holds every payment matching them.
+func decodeExportPaymentsRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeListPaymentsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := decodeExportOptions(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	req := exportPaymentsRequest{exportOptions: options, filter: filter.(projecta.PaymentCollectionFilter)}
+	req.filter.Pagination = core.Pagination{Limit: exportBatchSize}
+
+	return req, nil
+}
+
+func decodeExportAssetsRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeListAssetsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := decodeExportOptions(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	req := exportAssetsRequest{exportOptions: options, filter: filter.(asset.CollectionFilter)}
+	req.filter.Pagination = core.Pagination{Limit: exportBatchSize}
+
+	return req, nil
+}
+
+func makeExportPaymentsEndpoint(svc projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(exportPaymentsRequest)
+		filter := req.filter
+
+		first, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		var rates currency.CurrencyRateProvider
+		if req.converted {
+			if rates, err = projectRates(ctx, fixedRates, filter.ProjectID, rateProvider); err != nil {
+				return nil, err
+			}
+		}
+
+		header := []any{"Date", "Category", "Type", "Kind", "Status", "Description", "Owner", "Amount", "Currency"}
+		if req.converted {
+			header = append(header, "Main currency amount", "Main currency")
+		}
+
+		return exportResponse{
+			format: req.format,
+			name:   "payments",
+			write: func(w spreadsheet.Writer) error {
+				if err := w.Write(header); err != nil {
+					return err
+				}
+
+				next := func(offset int) (*projecta.PaymentCollection, error) {
+					filter.Offset = offset
+					return svc.Find(ctx, filter)
+				}
+
+				return writePages(w, first, next, func(p *projecta.Payment) []any {
+					row := []any{
+						p.Date.Format(reportDateLayout),
+						p.Type.Category.Name,
+						p.Type.Name,
+						p.Kind.String(),
+						p.Status.String(),
+						p.Description,
+						p.Owner.DisplayName,
+						exportAmount(p.Amount.Amount(), p.Amount.Currency().Code),
+						p.Amount.Currency().Code,
+					}
+
+					if req.converted {
+						homeCurrency := exportHomeCurrency(p.Project)
+						amount, err := toPaymentHomeAmount(p, homeCurrency, rates)
+						row = append(row, exportConverted(amount, homeCurrency, err), homeCurrency)
+					}
+
+					return row
+				})
+			},
+		}, nil
+	}
+}
+
+func makeExportAssetsEndpoint(svc asset.Service, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req := request.(exportAssetsRequest)
+		filter := req.filter
+
+		first, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		var rates currency.CurrencyRateProvider
+		if req.converted {
+			if rates, err = projectRates(ctx, fixedRates, filter.ProjectID, rateProvider); err != nil {
+				return nil, err
+			}
+		}
+
+		header := []any{"Acquired", "Name", "Description", "Category", "Type", "Owner", "Price", "Currency"}
+		if req.converted {
+			header = append(header, "Main currency price", "Main currency")
+		}
+
+		return exportResponse{
+			format: req.format,
+			name:   "assets",
+			write: func(w spreadsheet.Writer) error {
+				if err := w.Write(header); err != nil {
+					return err
+				}
+
+				next := func(offset int) (*asset.Collection, error) {
+					filter.Offset = offset
+					return svc.Find(ctx, filter)
+				}
+
+				return writePages(w, first, next, func(a *asset.Asset) []any {
+					row := []any{
+						a.AcquiredAt().Format(reportDateLayout),
+						a.Name(),
+						a.Description(),
+						a.Type().Category.Name,
+						a.Type().Name,
+						a.Owner().DisplayName,
+						exportAmount(a.Price().Amount(), a.Price().Currency().Code),
+						a.Price().Currency().Code,
+					}
+
+					if req.converted {
+						homeCurrency := exportHomeCurrency(a.Project())
+						amount, err := toHomeAmount(rates, a.Price(), homeCurrency, a.AcquiredAt())
+						row = append(row, exportConverted(amount, homeCurrency, err), homeCurrency)
+					}
+
+					return row
+				})
+			},
+		}, nil
+	}
+}
+
+// writePages writes the rows of first and of the pages after it, flushing
+// every page on its way to the client.
+func writePages[T any](w spreadsheet.Writer, first *core.PaginatedCollection[T], next func(offset int) (*core.PaginatedCollection[T], error), row func(T) []any) error {
+	page, written := first, 0
+
+	for {
+		elements := page.Elements()
+
+		for _, e := range elements {
+			if err := w.Write(row(e)); err != nil {
+				return err
+			}
+		}
+
+		written += len(elements)
+
+		if len(elements) == 0 || written >= page.Total() {
+			return nil
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		var err error
+		if page, err = next(written); err != nil {
+			return err
+		}
+	}
+}
+
+func exportAmount(amount int64, code string) spreadsheet.Decimal {
+	return spreadsheet.Decimal{Units: amount, Scale: currency.MinorUnits(code)}
+}
+
+// exportConverted leaves the cell empty when there was no rate to convert with
+// rather than passing the original amount off as converted.
+func exportConverted(amount int64, code string, err error) any {
+	if err != nil {
+		return nil
+	}
+
+	return exportAmount(amount, code)
+}
+
+func exportHomeCurrency(project *projecta.Project) string {
+	if project == nil || project.MainCurrency == "" {
+		return money.UAH
+	}
+
+	return project.MainCurrency
+}
+
+// exportPageTimeout is how long writing each page of an export may take. The
+// deadline is pushed back page after page, as the server write timeout would
+// otherwise cut long exports off.
+const exportPageTimeout = 45 * time.Second
+
+// flushingWriter pushes every flushed page out to the client straight away.
+type flushingWriter struct {
+	spreadsheet.Writer
+	w http.ResponseWriter
+}
+
+func (f flushingWriter) Flush() error {
+	if err := f.Writer.Flush(); err != nil {
+		return err
+	}
+
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	// not every writer supports deadlines, those without one need no extension
+	_ = http.NewResponseController(f.w).SetWriteDeadline(time.Now().Add(exportPageTimeout))
+
+	return nil
+}
+
+func encodeExport(_ context.Context, w http.ResponseWriter, response any) error {
+	export := response.(exportResponse)
+
+	w.Header().Set("Content-Type", export.format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, export.name, export.format))
+	w.WriteHeader(http.StatusOK)
+
+	writer := flushingWriter{Writer: spreadsheet.NewWriter(export.format, w, export.name), w: w}
+
+	err := export.write(writer)
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		// the status is out already, so the response is cut short for the
+		// client to see the file is incomplete rather than get a broken one
+		panic(http.ErrAbortHandler)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type SetFixedRateDTO struct {
+	Rate float64 `json:"rate"`
+}
+
+type FixedRateDTO struct {
+	Currency     string  `json:"currency"`
+	BaseCurrency string  `json:"base_currency"`
+	Rate         float64 `json:"rate"`
+}
+
+type ListFixedRatesResponse struct {
+	Rates []FixedRateDTO `json:"rates"`
+}
+
+func toFixedRateDTO(rate *projecta.FixedRate) FixedRateDTO {
+	return FixedRateDTO{
+		Currency:     rate.Currency,
+		BaseCurrency: rate.BaseCurrency,
+		Rate:         rate.Rate,
+	}
+}
+
+// decodeFixedRateResource reads the project and the currency a fixed rate is
+// addressed by.
+func decodeFixedRateResource(r *http.Request) (uuid.UUID, string, error) {
+	projectID, err := decodeProjectTotalsRequest(r.Context(), r)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	code, ok := mux.Vars(r)["currency"]
+	if !ok || code == "" {
+		return uuid.Nil, "", exceptions.NewValidationException("missing currency", nil)
+	}
+
+	return projectID.(uuid.UUID), code, nil
+}
+
+func decodeSetFixedRateRequest(_ context.Context, r *http.Request) (any, error) {
+	projectID, code, err := decodeFixedRateResource(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req SetFixedRateDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	return projecta.SetFixedRateCommand{
+		ProjectID: projectID,
+		Currency:  code,
+		Rate:      req.Rate,
+	}, nil
+}
+
+func decodeRemoveFixedRateRequest(_ context.Context, r *http.Request) (any, error) {
+	projectID, code, err := decodeFixedRateResource(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.RemoveFixedRateCommand{
+		ProjectID: projectID,
+		Currency:  code,
+	}, nil
+}
+
+func makeListFixedRatesEndpoint(svc projecta.FixedRateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		rates, err := svc.Find(ctx, request.(uuid.UUID))
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]FixedRateDTO, 0, len(rates))
+		for _, rate := range rates {
+			list = append(list, toFixedRateDTO(rate))
+		}
+
+		return ListFixedRatesResponse{Rates: list}, nil
+	}
+}
+
+func makeSetFixedRateEndpoint(svc projecta.FixedRateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		rate, err := svc.Set(ctx, request.(projecta.SetFixedRateCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toFixedRateDTO(rate), nil
+	}
+}
+
+func makeRemoveFixedRateEndpoint(svc projecta.FixedRateService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveFixedRateCommand))
+	}
+}
@@ -8,10 +8,12 @@ import (
 	ht "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/people"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
 	"gitlab.com/massimo-ua/projecta/pkg/currency"
 	"net/http"
 )
@@ -26,6 +28,8 @@ func errorCodeToHttpStatus(e exceptions.Exception) int {
 		return http.StatusInternalServerError
 	case exceptions.Unauthorized:
 		return http.StatusUnauthorized
+	case exceptions.Forbidden:
+		return http.StatusForbidden
 	default:
 		return http.StatusInternalServerError
 	}
@@ -55,6 +59,18 @@ func MakeHTTPHandler(
 	typeService projecta.TypeService,
 	expenseService projecta.PaymentService,
 	assetService asset.Service,
+	budgetService projecta.BudgetService,
+	fixedRateService projecta.FixedRateService,
+	settlementService projecta.SettlementService,
+	paymentImportService projecta.PaymentImportService,
+	statementService statement.Service,
+	recurringPaymentService projecta.RecurringPaymentService,
+	loanService projecta.LoanService,
+	vendorService projecta.VendorService,
+	contractService projecta.ContractService,
+	estimateService projecta.EstimateService,
+	attachmentService attachment.Service,
+	tagService projecta.TagService,
 	rateProvider currency.CurrencyRateProvider,
 ) (http.Handler, error) {
 	r := mux.NewRouter()
@@ -66,6 +82,18 @@ func MakeHTTPHandler(
 		typeService,
 		expenseService,
 		assetService,
+		budgetService,
+		fixedRateService,
+		settlementService,
+		paymentImportService,
+		statementService,
+		recurringPaymentService,
+		loanService,
+		vendorService,
+		contractService,
+		estimateService,
+		attachmentService,
+		tagService,
 		rateProvider,
 	)
 
@@ -137,6 +165,118 @@ func MakeHTTPHandler(
 		withAuth...,
 	))
 
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveProject),
+		decodeRemoveProjectRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/archive").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ArchiveProject),
+		decodeArchiveProjectRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/unarchive").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UnarchiveProject),
+		decodeArchiveProjectRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/share-links").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateShareLink),
+		decodeCreateShareLinkRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/share-links").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListShareLinks),
+		decodeProjectTotalsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/share-links/{link_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RevokeShareLink),
+		decodeRevokeShareLinkRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/share-token").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RotateShareToken),
+		decodeRotateShareTokenRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/members").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListMembers),
+		decodeProjectTotalsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/members/{person_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RevokeMember),
+		decodeRevokeMemberRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/rates").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListFixedRates),
+		decodeProjectTotalsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/rates/{currency}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.SetFixedRate),
+		decodeSetFixedRateRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/rates/{currency}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveFixedRate),
+		decodeRemoveFixedRateRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/settlement").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowSettlement),
+		decodeProjectTotalsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/settlement/weights/{person_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.SetSettlementWeight),
+		decodeSetSettlementWeightRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/settlement/transfers").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RecordSettlementTransfer),
+		decodeRecordSettlementTransferRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/settlement/transfers/{transfer_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveSettlementTransfer),
+		decodeProjectResourceRemoveCommand("project_id", "transfer_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
 	r.Methods(http.MethodPost).Path("/projects/share/{share_token}").Handler(ht.NewServer(
 		loggedInOnly(projectEndpoints.AcceptShare),
 		DecodeAcceptShareRequest,
@@ -186,6 +326,27 @@ func MakeHTTPHandler(
 		withAuth...,
 	))
 
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/reports/payments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowPaymentsReport),
+		decodePaymentsReportRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/reports/cashflow").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowCashFlow),
+		decodeCashFlowRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/reports/tags").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowTagsReport),
+		decodeTagsReportRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
 	r.Methods(http.MethodPost).Path("/projects/{project_id}/payments").Handler(ht.NewServer(
 		loggedInOnly(projectEndpoints.CreatePayment),
 		DecodeCreatePaymentRequest,
@@ -200,6 +361,69 @@ func MakeHTTPHandler(
 		withAuth...,
 	))
 
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/payments/export").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ExportPayments),
+		decodeExportPaymentsRequest,
+		encodeExport,
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/payments/import").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ImportPayments),
+		decodeImportPaymentsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/statements").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ImportStatement),
+		decodeImportStatementRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/statements/lines").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListStatementLines),
+		decodeListStatementLinesRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/statements/lines/{line_id}/confirm").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ConfirmStatementLine),
+		decodeConfirmStatementLineRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/statements/lines/{line_id}/dismiss").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.DismissStatementLine),
+		decodeProjectResourceRemoveCommand("project_id", "line_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/statements/rules").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListStatementRules),
+		decodeProjectTotalsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/statements/rules").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateStatementRule),
+		decodeCreateStatementRuleRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/statements/rules/{rule_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveStatementRule),
+		decodeProjectResourceRemoveCommand("project_id", "rule_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
 	r.Methods(http.MethodPut).Path("/projects/{project_id}/payments/{payment_id}").Handler(ht.NewServer(
 		loggedInOnly(projectEndpoints.UpdatePayment),
 		decodeUpdatePaymentRequest,
@@ -207,6 +431,13 @@ func MakeHTTPHandler(
 		withAuth...,
 	))
 
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/payments/{payment_id}/pay").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.MarkPaymentPaid),
+		decodeMarkPaymentPaidRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
 	r.Methods(http.MethodGet).Path("/projects/{project_id}/payments/{payment_id}").Handler(ht.NewServer(
 		loggedInOnly(projectEndpoints.GetPayment),
 		decodeGetPaymentRequest,
@@ -235,6 +466,13 @@ func MakeHTTPHandler(
 		withAuth...,
 	))
 
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/assets/export").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ExportAssets),
+		decodeExportAssetsRequest,
+		encodeExport,
+		withAuth...,
+	))
+
 	r.Methods(http.MethodDelete).Path("/projects/{project_id}/assets/{asset_id}").Handler(ht.NewServer(
 		loggedInOnly(projectEndpoints.RemoveAsset),
 		decodeProjectResourceRemoveCommand("project_id", "asset_id"),
@@ -256,5 +494,411 @@ func MakeHTTPHandler(
 		withAuth...,
 	))
 
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/budgets").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateBudget),
+		decodeCreateBudgetRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/budgets").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListBudgets),
+		decodeListBudgetsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/budgets/report").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowBudgetReport),
+		decodeProjectTotalsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/budgets/{budget_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.GetBudget),
+		decodeGetBudgetRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/budgets/{budget_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UpdateBudget),
+		decodeUpdateBudgetRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/budgets/{budget_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveBudget),
+		decodeProjectResourceRemoveCommand("project_id", "budget_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/recurring-payments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateRecurringPayment),
+		decodeCreateRecurringPaymentRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/recurring-payments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListRecurringPayments),
+		decodeListRecurringPaymentsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/recurring-payments/{recurring_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.GetRecurringPayment),
+		decodeGetRecurringPaymentRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/recurring-payments/{recurring_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveRecurringPayment),
+		decodeProjectResourceRemoveCommand("project_id", "recurring_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/recurring-payments/{recurring_id}/occurrences").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListRecurringOccurrences),
+		decodeGetRecurringPaymentRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/recurring-payments/{recurring_id}/occurrences/{date}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.EditOccurrence),
+		decodeEditOccurrenceRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/recurring-payments/{recurring_id}/occurrences/{date}/skip").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.SkipOccurrence),
+		decodeSkipOccurrenceRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/loans").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateLoan),
+		decodeCreateLoanRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/loans").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListLoans),
+		decodeListLoansRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/loans/{loan_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.GetLoan),
+		decodeGetLoanRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/loans/{loan_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UpdateLoan),
+		decodeUpdateLoanRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/loans/{loan_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveLoan),
+		decodeProjectResourceRemoveCommand("project_id", "loan_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/loans/{loan_id}/report").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowLoanReport),
+		decodeGetLoanRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/loans/{loan_id}/payments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.LinkLoanPayment),
+		decodeLinkLoanPaymentRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/loans/{loan_id}/payments/{payment_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UnlinkLoanPayment),
+		decodeUnlinkLoanPaymentRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/vendors").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateVendor),
+		decodeCreateVendorRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/vendors").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListVendors),
+		decodeListVendorsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/vendors/{vendor_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.GetVendor),
+		decodeGetVendorRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/vendors/{vendor_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UpdateVendor),
+		decodeUpdateVendorRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/vendors/{vendor_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveVendor),
+		decodeProjectResourceRemoveCommand("project_id", "vendor_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/vendors/{vendor_id}/report").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowVendorReport),
+		decodeGetVendorRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/vendors/{vendor_id}/payments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.LinkVendorPayment),
+		decodeLinkVendorPaymentRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/vendors/{vendor_id}/payments/{payment_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UnlinkVendorPayment),
+		decodeUnlinkVendorResourceRequest("payment_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/vendors/{vendor_id}/assets").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.LinkVendorAsset),
+		decodeLinkVendorAssetRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/vendors/{vendor_id}/assets/{asset_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UnlinkVendorAsset),
+		decodeUnlinkVendorResourceRequest("asset_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/contracts").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateContract),
+		decodeCreateContractRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/contracts").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListContracts),
+		decodeListContractsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/contracts/{contract_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.GetContract),
+		decodeGetContractRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/contracts/{contract_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UpdateContract),
+		decodeUpdateContractRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/contracts/{contract_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveContract),
+		decodeProjectResourceRemoveCommand("project_id", "contract_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/contracts/{contract_id}/report").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ShowContractReport),
+		decodeGetContractRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/contracts/{contract_id}/payments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.LinkContractPayment),
+		decodeLinkContractPaymentRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/contracts/{contract_id}/payments/{payment_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UnlinkContractPayment),
+		decodeUnlinkContractPaymentRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/estimates").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CreateEstimate),
+		decodeCreateEstimateRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/estimates").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListEstimates),
+		decodeListEstimatesRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/estimates/{estimate_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.GetEstimate),
+		decodeGetEstimateRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/estimates/{estimate_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UpdateEstimate),
+		decodeUpdateEstimateRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/estimates/{estimate_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveEstimate),
+		decodeProjectResourceRemoveCommand("project_id", "estimate_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/estimates/{estimate_id}/comparison").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.CompareQuotes),
+		decodeGetEstimateRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/estimates/{estimate_id}/quotes").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.AddQuote),
+		decodeCreateQuoteRequest,
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/estimates/{estimate_id}/quotes/{quote_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UpdateQuote),
+		decodeUpdateQuoteRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/estimates/{estimate_id}/quotes/{quote_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveQuote),
+		decodeRemoveQuoteRequest,
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/estimates/{estimate_id}/quotes/{quote_id}/accept").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.AcceptQuote),
+		decodeAcceptQuoteRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/payments/{payment_id}/attachments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UploadAttachment),
+		decodeUploadAttachmentRequest(attachment.PaymentTarget, "payment_id"),
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/payments/{payment_id}/attachments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListAttachments),
+		decodeListAttachmentsRequest(attachment.PaymentTarget, "payment_id"),
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPost).Path("/projects/{project_id}/assets/{asset_id}/attachments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.UploadAttachment),
+		decodeUploadAttachmentRequest(attachment.AssetTarget, "asset_id"),
+		encodeJSON(http.StatusCreated),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/assets/{asset_id}/attachments").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListAttachments),
+		decodeListAttachmentsRequest(attachment.AssetTarget, "asset_id"),
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/attachments/{attachment_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.DownloadAttachment),
+		decodeGetAttachmentRequest,
+		encodeAttachmentDownload,
+		withAuth...,
+	))
+
+	r.Methods(http.MethodDelete).Path("/projects/{project_id}/attachments/{attachment_id}").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.RemoveAttachment),
+		decodeProjectResourceRemoveCommand("project_id", "attachment_id"),
+		encodeJSON(http.StatusNoContent),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodGet).Path("/projects/{project_id}/tags").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.ListTags),
+		decodeListTagsRequest,
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/payments/{payment_id}/tags").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.SetPaymentTags),
+		decodeSetTagsRequest("payment_id"),
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
+	r.Methods(http.MethodPut).Path("/projects/{project_id}/assets/{asset_id}/tags").Handler(ht.NewServer(
+		loggedInOnly(projectEndpoints.SetAssetTags),
+		decodeSetTagsRequest("asset_id"),
+		encodeJSON(http.StatusOK),
+		withAuth...,
+	))
+
 	return r, nil
 }
@@ -0,0 +1,285 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+// importMaxBytes caps the size of an imported file.
+const importMaxBytes = 10 << 20
+
+// paymentImportColumns are the fields of a payment read from a file, each
+// mapped to a column by the query parameter of its name with a "_column"
+// suffix, e.g. ?date_column=Дата. By default the column has the field name.
+var paymentImportColumns = []string{"date", "amount", "currency", "type", "category", "description", "kind"}
+
+// requiredPaymentImportColumns must be in every file, the other columns are
+// read when they are there.
+var requiredPaymentImportColumns = []string{"date", "amount", "type"}
+
+type PaymentImportLineDTO struct {
+	Line    int         `json:"line"`
+	Payment *PaymentDTO `json:"payment,omitempty"`
+	NewType bool        `json:"new_type,omitempty"`
+	Errors  []string    `json:"errors,omitempty"`
+}
+
+type PaymentImportDTO struct {
+	Committed     bool                   `json:"committed"`
+	Total         int                    `json:"total"`
+	Invalid       int                    `json:"invalid"`
+	NewCategories []CategoryDTO          `json:"new_categories"`
+	NewTypes      []TypeDTO              `json:"new_types"`
+	Lines         []PaymentImportLineDTO `json:"lines"`
+}
+
+// decodeImportPaymentsRequest reads a CSV file sent either as the request body
+// or as the "file" field of a form. The first row names the columns. The
+// import is a dry run unless ?dry_run=false, ?create_types=true creates the
+// types and categories not found and ?delimiter sets the field separator.
+func decodeImportPaymentsRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	command := projecta.ImportPaymentsCommand{ProjectID: projectID.(uuid.UUID)}
+
+	if command.DryRun, err = decodeImportFlag(query, "dry_run", true); err != nil {
+		return nil, err
+	}
+
+	if command.CreateMissingTypes, err = decodeImportFlag(query, "create_types", false); err != nil {
+		return nil, err
+	}
+
+	delimiter, err := decodeImportDelimiter(query.Get("delimiter"))
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := readImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(file))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, exceptions.NewValidationException("failed to read the file header", err)
+	}
+
+	columns, err := mapPaymentImportColumns(query, header)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, exceptions.NewValidationException("failed to read the file", err)
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if index, ok := columns[name]; ok && index < len(record) {
+				return record[index]
+			}
+			return ""
+		}
+
+		command.Rows = append(command.Rows, projecta.PaymentImportRow{
+			Line:        line,
+			Date:        field("date"),
+			Amount:      field("amount"),
+			Currency:    field("currency"),
+			Type:        field("type"),
+			Category:    field("category"),
+			Description: field("description"),
+			Kind:        field("kind"),
+		})
+	}
+
+	return command, nil
+}
+
+func decodeImportFlag(query url.Values, name string, fallback bool) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, exceptions.NewValidationException(fmt.Sprintf("invalid %s flag", name), err)
+	}
+
+	return flag, nil
+}
+
+// decodeImportDelimiter reads the field separator, a comma unless set. Files
+// saved by spreadsheets set up for Ukrainian use are separated by semicolons.
+func decodeImportDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return ',', nil
+	case "tab":
+		return '\t', nil
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(value)
+	if size != len(value) || delimiter == utf8.RuneError || strings.ContainsRune("\"\r\n", delimiter) {
+		return 0, exceptions.NewValidationException("invalid delimiter", nil)
+	}
+
+	return delimiter, nil
+}
+
+func readImportFile(r *http.Request) ([]byte, error) {
+	body := io.Reader(r.Body)
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(nil, r.Body, importMaxBytes)
+		if err := r.ParseMultipartForm(importMaxBytes); err != nil {
+			return nil, exceptions.NewValidationException("invalid form", err)
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, exceptions.NewValidationException("file is required", err)
+		}
+		defer file.Close()
+
+		body = file
+	}
+
+	content, err := io.ReadAll(io.LimitReader(body, importMaxBytes+1))
+	if err != nil {
+		return nil, exceptions.NewValidationException("failed to read the file", err)
+	}
+
+	if len(content) > importMaxBytes {
+		return nil, exceptions.NewValidationException(fmt.Sprintf("file must not be larger than %d MB", importMaxBytes>>20), nil)
+	}
+
+	return bytes.TrimPrefix(content, []byte("\ufeff")), nil
+}
+
+// mapPaymentImportColumns finds the column of every field in the header, the
+// names compared regardless of case. A column mapped explicitly must be there.
+func mapPaymentImportColumns(query url.Values, header []string) (map[string]int, error) {
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := indexes[key]; !ok {
+			indexes[key] = i
+		}
+	}
+
+	columns := make(map[string]int, len(paymentImportColumns))
+
+	for _, field := range paymentImportColumns {
+		name, mapped := query.Get(field+"_column"), true
+		if name == "" {
+			name, mapped = field, false
+		}
+
+		index, ok := indexes[strings.ToLower(strings.TrimSpace(name))]
+		if ok {
+			columns[field] = index
+			continue
+		}
+
+		if mapped || slices.Contains(requiredPaymentImportColumns, field) {
+			return nil, exceptions.NewValidationException(fmt.Sprintf("column %q is missing", name), nil)
+		}
+	}
+
+	return columns, nil
+}
+
+func makeImportPaymentsEndpoint(svc projecta.PaymentImportService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.ImportPaymentsCommand)
+
+		report, err := svc.Import(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, command.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		dto := PaymentImportDTO{
+			Committed:     report.Committed,
+			Total:         len(report.Lines),
+			Invalid:       report.Invalid(),
+			NewCategories: make([]CategoryDTO, 0, len(report.NewCategories)),
+			NewTypes:      make([]TypeDTO, 0, len(report.NewTypes)),
+			Lines:         make([]PaymentImportLineDTO, 0, len(report.Lines)),
+		}
+
+		for _, category := range report.NewCategories {
+			dto.NewCategories = append(dto.NewCategories, CategoryDTO{
+				CategoryID:  category.ID.String(),
+				Name:        category.Name,
+				Description: category.Description,
+			})
+		}
+
+		for _, costType := range report.NewTypes {
+			dto.NewTypes = append(dto.NewTypes, TypeDTO{
+				TypeID: costType.ID.String(),
+				Name:   costType.Name,
+				Category: TypeCategoryDTO{
+					CategoryID: costType.Category.ID.String(),
+					Name:       costType.Category.Name,
+				},
+			})
+		}
+
+		for _, line := range report.Lines {
+			lineDTO := PaymentImportLineDTO{Line: line.Line, NewType: line.NewType, Errors: line.Errors}
+
+			if line.Payment != nil {
+				payment := toPaymentDTO(line.Payment, rates)
+				lineDTO.Payment = &payment
+			}
+
+			dto.Lines = append(dto.Lines, lineDTO)
+		}
+
+		return dto, nil
+	}
+}
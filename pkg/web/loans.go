@@ -0,0 +1,394 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type LoanDTO struct {
+	LoanID      string  `json:"loan_id"`
+	Description string  `json:"description"`
+	Principal   int64   `json:"principal"`
+	Currency    string  `json:"currency"`
+	AnnualRate  float64 `json:"annual_rate"`
+	Term        int     `json:"term"`
+	Schedule    string  `json:"schedule"`
+	StartDate   string  `json:"start_date"`
+}
+
+type CreateLoanDTO struct {
+	Description string  `json:"description"`
+	Principal   int64   `json:"principal"`
+	Currency    string  `json:"currency"`
+	AnnualRate  float64 `json:"annual_rate"`
+	Term        int     `json:"term"`
+	Schedule    string  `json:"schedule"`
+	StartDate   string  `json:"start_date"`
+}
+
+type UpdateLoanDTO = CreateLoanDTO
+
+type ListLoansResponse struct {
+	Loans []LoanDTO `json:"loans"`
+	PaginationDTO
+}
+
+type LinkLoanPaymentDTO struct {
+	PaymentID   string `json:"payment_id"`
+	Installment int    `json:"installment,omitempty"`
+}
+
+type LoanPaymentDTO struct {
+	PaymentID   string `json:"payment_id"`
+	Installment int    `json:"installment"`
+	Amount      int64  `json:"amount"`
+	Principal   int64  `json:"principal"`
+	Interest    int64  `json:"interest"`
+	PaymentDate string `json:"payment_date"`
+}
+
+type LoanInstallmentDTO struct {
+	Number        int              `json:"number"`
+	DueDate       string           `json:"due_date"`
+	Amount        int64            `json:"amount"`
+	Principal     int64            `json:"principal"`
+	Interest      int64            `json:"interest"`
+	Balance       int64            `json:"balance"`
+	Paid          int64            `json:"paid"`
+	PrincipalPaid int64            `json:"principal_paid"`
+	InterestPaid  int64            `json:"interest_paid"`
+	PaidOff       bool             `json:"paid_off"`
+	Payments      []LoanPaymentDTO `json:"payments"`
+}
+
+type LoanReportDTO struct {
+	Loan            LoanDTO              `json:"loan"`
+	Currency        string               `json:"currency"`
+	Installments    []LoanInstallmentDTO `json:"installments"`
+	TotalInterest   int64                `json:"total_interest"`
+	PrincipalPaid   int64                `json:"principal_paid"`
+	InterestPaid    int64                `json:"interest_paid"`
+	Outstanding     int64                `json:"outstanding"`
+	NextInstallment int                  `json:"next_installment,omitempty"`
+}
+
+func toLoanDTO(l *projecta.Loan) LoanDTO {
+	return LoanDTO{
+		LoanID:      l.ID.String(),
+		Description: l.Description,
+		Principal:   l.Principal.Amount(),
+		Currency:    l.Principal.Currency().Code,
+		AnnualRate:  l.AnnualRate,
+		Term:        l.Term,
+		Schedule:    l.Schedule.String(),
+		StartDate:   l.StartDate.Format(reportDateLayout),
+	}
+}
+
+func toLoanPaymentDTO(p *projecta.LoanPayment) LoanPaymentDTO {
+	dto := LoanPaymentDTO{
+		PaymentID:   p.PaymentID.String(),
+		Installment: p.Installment,
+		Amount:      p.Amount.Amount(),
+		PaymentDate: p.Date.Format(time.RFC3339),
+	}
+
+	if p.Principal != nil {
+		dto.Principal = p.Principal.Amount()
+	}
+
+	if p.Interest != nil {
+		dto.Interest = p.Interest.Amount()
+	}
+
+	return dto
+}
+
+func toLoanReportDTO(report *projecta.LoanReport) LoanReportDTO {
+	dto := LoanReportDTO{
+		Loan:            toLoanDTO(report.Loan),
+		Currency:        report.Loan.Principal.Currency().Code,
+		Installments:    make([]LoanInstallmentDTO, 0, len(report.Lines)),
+		PrincipalPaid:   report.PrincipalPaid.Amount(),
+		InterestPaid:    report.InterestPaid.Amount(),
+		Outstanding:     report.Outstanding.Amount(),
+		NextInstallment: report.NextInstallment(),
+	}
+
+	for _, line := range report.Lines {
+		installment := LoanInstallmentDTO{
+			Number:        line.Installment.Number,
+			DueDate:       line.Installment.DueDate.Format(reportDateLayout),
+			Amount:        line.Installment.Amount.Amount(),
+			Principal:     line.Installment.Principal.Amount(),
+			Interest:      line.Installment.Interest.Amount(),
+			Balance:       line.Installment.Balance.Amount(),
+			Paid:          line.Paid.Amount(),
+			PrincipalPaid: line.PrincipalPaid.Amount(),
+			InterestPaid:  line.InterestPaid.Amount(),
+			PaidOff:       line.IsPaid(),
+			Payments:      make([]LoanPaymentDTO, 0, len(line.Payments)),
+		}
+
+		for _, p := range line.Payments {
+			installment.Payments = append(installment.Payments, toLoanPaymentDTO(p))
+		}
+
+		dto.Installments = append(dto.Installments, installment)
+		dto.TotalInterest += installment.Interest
+	}
+
+	return dto
+}
+
+func decodeLoanDTO(r *http.Request) (CreateLoanDTO, projecta.LoanSchedule, time.Time, error) {
+	var req CreateLoanDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, "", time.Time{}, exceptions.NewValidationException("invalid request", err)
+	}
+
+	schedule := projecta.AnnuitySchedule
+	if req.Schedule != "" {
+		var err error
+		if schedule, err = projecta.ToLoanSchedule(req.Schedule); err != nil {
+			return req, "", time.Time{}, err
+		}
+	}
+
+	startDate, err := time.Parse(reportDateLayout, req.StartDate)
+	if err != nil {
+		return req, "", time.Time{}, exceptions.NewValidationException("invalid start_date", err)
+	}
+
+	return req, schedule, startDate, nil
+}
+
+func decodeCreateLoanRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, schedule, startDate, err := decodeLoanDTO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.CreateLoanCommand{
+		ProjectID:   projectID.(uuid.UUID),
+		Description: req.Description,
+		Principal:   money.New(req.Principal, req.Currency),
+		AnnualRate:  req.AnnualRate,
+		Term:        req.Term,
+		Schedule:    schedule,
+		StartDate:   startDate,
+	}, nil
+}
+
+func decodeUpdateLoanRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetLoanRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req, schedule, startDate, err := decodeLoanDTO(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.UpdateLoanCommand{
+		ProjectID:   filter.(projecta.LoanFilter).ProjectID,
+		ID:          filter.(projecta.LoanFilter).LoanID,
+		Description: req.Description,
+		Principal:   money.New(req.Principal, req.Currency),
+		AnnualRate:  req.AnnualRate,
+		Term:        req.Term,
+		Schedule:    schedule,
+		StartDate:   startDate,
+	}, nil
+}
+
+func decodeGetLoanRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "loan_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return projecta.LoanFilter{
+		ProjectID: command.ProjectID,
+		LoanID:    command.ResourceID,
+	}, nil
+}
+
+func decodeListLoansRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.LoanCollectionFilter{
+		Pagination: core.Pagination{Limit: core.DefaultLimit},
+		ProjectID:  projectID.(uuid.UUID),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func decodeLinkLoanPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetLoanRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req LinkLoanPaymentDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	paymentID, err := uuid.Parse(req.PaymentID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid payment id", err)
+	}
+
+	if req.Installment < 0 {
+		return nil, exceptions.NewValidationException("invalid installment", nil)
+	}
+
+	return projecta.LinkLoanPaymentCommand{
+		ProjectID:   filter.(projecta.LoanFilter).ProjectID,
+		LoanID:      filter.(projecta.LoanFilter).LoanID,
+		PaymentID:   paymentID,
+		Installment: req.Installment,
+	}, nil
+}
+
+func decodeUnlinkLoanPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetLoanRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentID, err := uuid.Parse(mux.Vars(r)["payment_id"])
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid payment id", err)
+	}
+
+	return projecta.UnlinkLoanPaymentCommand{
+		ProjectID: filter.(projecta.LoanFilter).ProjectID,
+		LoanID:    filter.(projecta.LoanFilter).LoanID,
+		PaymentID: paymentID,
+	}, nil
+}
+
+func makeCreateLoanEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		l, err := svc.Create(ctx, request.(projecta.CreateLoanCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toLoanDTO(l), nil
+	}
+}
+
+func makeUpdateLoanEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Update(ctx, request.(projecta.UpdateLoanCommand))
+	}
+}
+
+func makeGetLoanEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		l, err := svc.FindOne(ctx, request.(projecta.LoanFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toLoanDTO(l), nil
+	}
+}
+
+func makeListLoansEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.LoanCollectionFilter)
+
+		collection, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]LoanDTO, 0)
+		for _, l := range collection.Elements() {
+			list = append(list, toLoanDTO(l))
+		}
+
+		return ListLoansResponse{
+			Loans: list,
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeRemoveLoanEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func makeShowLoanReportEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		report, err := svc.Report(ctx, request.(projecta.LoanFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toLoanReportDTO(report), nil
+	}
+}
+
+func makeLinkLoanPaymentEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		p, err := svc.LinkPayment(ctx, request.(projecta.LinkLoanPaymentCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toLoanPaymentDTO(p), nil
+	}
+}
+
+func makeUnlinkLoanPaymentEndpoint(svc projecta.LoanService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.UnlinkPayment(ctx, request.(projecta.UnlinkLoanPaymentCommand))
+	}
+}
@@ -15,13 +15,25 @@ import (
 )
 
 type UpdatePaymentDTO struct {
-	ProjectID   string `json:"project_id"`
-	TypeID      string `json:"type_id"`
-	Description string `json:"description"`
-	Amount      int64  `json:"amount"`
-	Currency    string `json:"currency"`
-	PaymentDate string `json:"payment_date"`
-	Kind        string `json:"kind,omitempty"`
+	ProjectID    string  `json:"project_id"`
+	TypeID       string  `json:"type_id"`
+	Description  string  `json:"description"`
+	Amount       int64   `json:"amount"`
+	Currency     string  `json:"currency"`
+	PaymentDate  string  `json:"payment_date"`
+	Kind         string  `json:"kind,omitempty"`
+	ExchangeRate float64 `json:"exchange_rate,omitempty"`
+	Status       string  `json:"status,omitempty"`
+	DueDate      string  `json:"due_date,omitempty"`
+}
+
+// MarkPaymentPaidDTO records what was actually paid, the amount and date left
+// out keep the planned amount and take today.
+type MarkPaymentPaidDTO struct {
+	Amount       int64   `json:"amount,omitempty"`
+	Currency     string  `json:"currency,omitempty"`
+	PaymentDate  string  `json:"payment_date,omitempty"`
+	ExchangeRate float64 `json:"exchange_rate,omitempty"`
 }
 
 func decodeUpdatePaymentRequest(_ context.Context, r *http.Request) (any, error) {
@@ -72,6 +84,10 @@ func decodeUpdatePaymentRequest(_ context.Context, r *http.Request) (any, error)
 		return nil, exceptions.NewValidationException("invalid type id", err)
 	}
 
+	if req.ExchangeRate < 0 {
+		return nil, exceptions.NewValidationException("invalid exchange rate", nil)
+	}
+
 	var paymentKind projecta.PaymentKind
 
 	if req.Kind == "" {
@@ -83,17 +99,107 @@ func decodeUpdatePaymentRequest(_ context.Context, r *http.Request) (any, error)
 		}
 	}
 
+	status, dueDate, err := decodePaymentStatus(req.Status, req.DueDate)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return projecta.UpdatePaymentCommand{
-		ID:          paymentUUID,
-		ProjectID:   projectUUID,
-		TypeID:      typeUUID,
-		Description: req.Description,
-		Amount:      amount,
-		PaymentDate: date,
-		Kind:        paymentKind,
+		ID:           paymentUUID,
+		ProjectID:    projectUUID,
+		TypeID:       typeUUID,
+		Description:  req.Description,
+		Amount:       amount,
+		PaymentDate:  date,
+		Kind:         paymentKind,
+		ExchangeRate: req.ExchangeRate,
+		Status:       status,
+		DueDate:      dueDate,
 	}, err
 }
 
+// decodePaymentStatus reads the optional status and due date of a payment,
+// the due date in the YYYY-MM-DD form.
+func decodePaymentStatus(status string, due string) (projecta.PaymentStatus, time.Time, error) {
+	var (
+		paymentStatus projecta.PaymentStatus
+		dueDate       time.Time
+		err           error
+	)
+
+	if status != "" {
+		if paymentStatus, err = projecta.ToPaymentStatus(status); err != nil {
+			return "", time.Time{}, err
+		}
+	}
+
+	if due != "" {
+		if dueDate, err = time.Parse(reportDateLayout, due); err != nil {
+			return "", time.Time{}, exceptions.NewValidationException("invalid due_date", err)
+		}
+	}
+
+	return paymentStatus, dueDate, nil
+}
+
+func decodeMarkPaymentPaidRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetPaymentRequest(ctx, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var req MarkPaymentPaidDTO
+
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	if req.ExchangeRate < 0 {
+		return nil, exceptions.NewValidationException("invalid exchange rate", nil)
+	}
+
+	command := projecta.MarkPaymentPaidCommand{
+		ProjectID:    filter.(projecta.PaymentFilter).ProjectID,
+		ID:           filter.(projecta.PaymentFilter).PaymentID,
+		ExchangeRate: req.ExchangeRate,
+	}
+
+	if req.Amount != 0 {
+		if req.Currency == "" {
+			return nil, exceptions.NewValidationException("currency is required with amount", nil)
+		}
+
+		command.Amount = money.New(req.Amount, req.Currency)
+	}
+
+	if req.PaymentDate != "" {
+		if command.PaymentDate, err = time.Parse(time.RFC3339, req.PaymentDate); err != nil {
+			return nil, exceptions.NewValidationException("invalid date", err)
+		}
+	}
+
+	return command, nil
+}
+
+func makeMarkPaymentPaidEndpoint(svc projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		p, err := svc.MarkPaid(ctx, request.(projecta.MarkPaymentPaidCommand))
+
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, p.Project.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toPaymentDTO(p, rates), nil
+	}
+}
+
 func makeUpdatePaymentEndpoint(svc projecta.PaymentService) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		command := request.(projecta.UpdatePaymentCommand)
@@ -137,7 +243,7 @@ func decodeGetPaymentRequest(_ context.Context, r *http.Request) (interface{}, e
 	}, nil
 }
 
-func makeGetPaymentEndpoint(svc projecta.PaymentService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeGetPaymentEndpoint(svc projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		filter := request.(projecta.PaymentFilter)
 
@@ -147,6 +253,11 @@ func makeGetPaymentEndpoint(svc projecta.PaymentService, rateProvider currency.C
 			return nil, err
 		}
 
-		return toPaymentDTO(p, rateProvider), nil
+		rates, err := projectRates(ctx, fixedRates, p.Project.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toPaymentDTO(p, rates), nil
 	}
 }
@@ -12,9 +12,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
 	"gitlab.com/massimo-ua/projecta/pkg/currency"
 )
 
@@ -29,13 +31,16 @@ type CreateCategoryDTO struct {
 }
 
 type CreatePaymentDTO struct {
-	ProjectID   string `json:"project_id"`
-	TypeID      string `json:"type_id"`
-	Description string `json:"description"`
-	Amount      int64  `json:"amount"`
-	Currency    string `json:"currency"`
-	PaymentDate string `json:"payment_date"`
-	Kind        string `json:"kind,omitempty"`
+	ProjectID    string  `json:"project_id"`
+	TypeID       string  `json:"type_id"`
+	Description  string  `json:"description"`
+	Amount       int64   `json:"amount"`
+	Currency     string  `json:"currency"`
+	PaymentDate  string  `json:"payment_date"`
+	Kind         string  `json:"kind,omitempty"`
+	ExchangeRate float64 `json:"exchange_rate,omitempty"`
+	Status       string  `json:"status,omitempty"`
+	DueDate      string  `json:"due_date,omitempty"`
 }
 
 type OwnerDTO struct {
@@ -51,6 +56,8 @@ type ProjectDTO struct {
 	ShareToken   string   `json:"share_token,omitempty"`
 	IsShared     bool     `json:"is_shared,omitempty"`
 	MainCurrency string   `json:"mainCurrency,omitempty"`
+	ArchivedAt   string   `json:"archived_at,omitempty"`
+	Role         string   `json:"role,omitempty"`
 }
 
 type UpdateProjectDTO struct {
@@ -71,9 +78,14 @@ func toProjectDTO(project *projecta.Project) ProjectDTO {
 		ProjectID:    project.ProjectID.String(),
 		Name:         project.Name,
 		Description:  project.Description,
-		ShareToken:   project.ShareToken.String(),
 		IsShared:     project.IsShared,
 		MainCurrency: mainCurrency,
+		Role:         project.Role.String(),
+	}
+	// the share token lets anyone join the project, so only the people allowed
+	// to share the project get to see it
+	if project.Role.CanManage() {
+		dto.ShareToken = project.ShareToken.String()
 	}
 	if project.Owner != nil {
 		dto.Owner = OwnerDTO{
@@ -81,6 +93,18 @@ func toProjectDTO(project *projecta.Project) ProjectDTO {
 			DisplayName: project.Owner.DisplayName,
 		}
 	}
+	if project.IsArchived() {
+		dto.ArchivedAt = project.ArchivedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+// toEmbeddedProjectDTO maps a project nested into another resource. Such projects
+// are not loaded for the requester, so they carry no role or share token.
+func toEmbeddedProjectDTO(project *projecta.Project) ProjectDTO {
+	dto := toProjectDTO(project)
+	dto.Role = ""
+	dto.ShareToken = ""
 	return dto
 }
 
@@ -114,30 +138,31 @@ type PaymentDTO struct {
 	HomeCurrency string      `json:"home_currency,omitempty"`
 	PaymentDate  string      `json:"payment_date"`
 	Kind         string      `json:"kind,omitempty"`
+	ExchangeRate float64     `json:"exchange_rate,omitempty"`
+	ManualRate   bool        `json:"manual_rate,omitempty"`
+	Status       string      `json:"status"`
+	DueDate      string      `json:"due_date,omitempty"`
+	VendorID     string      `json:"vendor_id,omitempty"`
+	ContractID   string      `json:"contract_id,omitempty"`
+	Tags         []string    `json:"tags,omitempty"`
 }
 
 func toPaymentDTO(p *projecta.Payment, rateProvider currency.CurrencyRateProvider) PaymentDTO {
 	if p == nil {
 		return PaymentDTO{}
 	}
-	projDTO := toProjectDTO(p.Project)
+	projDTO := toEmbeddedProjectDTO(p.Project)
 	homeCurrency := projDTO.MainCurrency
 	if homeCurrency == "" {
 		homeCurrency = "UAH"
 	}
 
-	homeAmount := p.Amount.Amount()
-	if rateProvider != nil && p.Amount.Currency().Code != homeCurrency {
-		converted, err := rateProvider.Convert(
-			currency.NewCurrency(p.Amount.Amount(), p.Amount.Currency().Code),
-			currency.NewCurrency(0, homeCurrency),
-		)
-		if err == nil {
-			homeAmount = converted.Amount
-		}
+	homeAmount, err := toPaymentHomeAmount(p, homeCurrency, rateProvider)
+	if err != nil {
+		homeAmount = p.Amount.Amount()
 	}
 
-	return PaymentDTO{
+	dto := PaymentDTO{
 		PaymentID: p.ID.String(),
 		Project:   projDTO,
 		Owner: OwnerDTO{
@@ -159,31 +184,154 @@ func toPaymentDTO(p *projecta.Payment, rateProvider currency.CurrencyRateProvide
 		HomeCurrency: homeCurrency,
 		PaymentDate:  p.Date.Format(time.RFC3339),
 		Kind:         p.Kind.String(),
+		Status:       p.Status.String(),
+		Tags:         p.Tags,
+	}
+
+	if !p.DueDate.IsZero() {
+		dto.DueDate = p.DueDate.Format(reportDateLayout)
+	}
+
+	if p.VendorID != uuid.Nil {
+		dto.VendorID = p.VendorID.String()
+	}
+
+	if p.ContractID != uuid.Nil {
+		dto.ContractID = p.ContractID.String()
+	}
+
+	if hasBookedConversion(p, homeCurrency) {
+		dto.ExchangeRate = p.ExchangeRate
+		dto.ManualRate = p.ManualRate
+	}
+
+	return dto
+}
+
+// hasBookedConversion tells whether the payment carries a conversion into the
+// current home currency captured when it was booked.
+func hasBookedConversion(p *projecta.Payment, homeCurrency string) bool {
+	return p.HomeAmount != nil && p.HomeAmount.Currency().Code == homeCurrency
+}
+
+// toPaymentHomeAmount prefers the conversion booked with the payment, so reports
+// stay reproducible, and falls back to the rates of the payment date.
+func toPaymentHomeAmount(p *projecta.Payment, homeCurrency string, rateProvider currency.CurrencyRateProvider) (int64, error) {
+	if hasBookedConversion(p, homeCurrency) {
+		return p.HomeAmount.Amount(), nil
 	}
+
+	return toHomeAmount(rateProvider, p.Amount, homeCurrency, p.Date)
 }
 
 type ProjectEndpoints struct {
-	CreateProject     endpoint.Endpoint
-	GetProject        endpoint.Endpoint
-	AcceptShare       endpoint.Endpoint
-	CreateCategory    endpoint.Endpoint
-	CreateType        endpoint.Endpoint
-	CreatePayment     endpoint.Endpoint
-	ListProjects      endpoint.Endpoint
-	ListTypes         endpoint.Endpoint
-	ListCategories    endpoint.Endpoint
-	ListPayments      endpoint.Endpoint
-	ShowProjectTotals endpoint.Endpoint
-	RemoveType        endpoint.Endpoint
-	RemovePayment     endpoint.Endpoint
-	CreateAsset       endpoint.Endpoint
-	RemoveAsset       endpoint.Endpoint
-	ListAssets        endpoint.Endpoint
-	UpdateAsset       endpoint.Endpoint
-	GetAsset          endpoint.Endpoint
-	UpdatePayment     endpoint.Endpoint
-	GetPayment        endpoint.Endpoint
-	UpdateProject     endpoint.Endpoint
+	CreateProject            endpoint.Endpoint
+	GetProject               endpoint.Endpoint
+	AcceptShare              endpoint.Endpoint
+	CreateCategory           endpoint.Endpoint
+	CreateType               endpoint.Endpoint
+	CreatePayment            endpoint.Endpoint
+	ListProjects             endpoint.Endpoint
+	ListTypes                endpoint.Endpoint
+	ListCategories           endpoint.Endpoint
+	ListPayments             endpoint.Endpoint
+	ShowProjectTotals        endpoint.Endpoint
+	RemoveType               endpoint.Endpoint
+	RemovePayment            endpoint.Endpoint
+	CreateAsset              endpoint.Endpoint
+	RemoveAsset              endpoint.Endpoint
+	ListAssets               endpoint.Endpoint
+	UpdateAsset              endpoint.Endpoint
+	GetAsset                 endpoint.Endpoint
+	UpdatePayment            endpoint.Endpoint
+	MarkPaymentPaid          endpoint.Endpoint
+	GetPayment               endpoint.Endpoint
+	UpdateProject            endpoint.Endpoint
+	RemoveProject            endpoint.Endpoint
+	ArchiveProject           endpoint.Endpoint
+	UnarchiveProject         endpoint.Endpoint
+	CreateBudget             endpoint.Endpoint
+	ListBudgets              endpoint.Endpoint
+	GetBudget                endpoint.Endpoint
+	UpdateBudget             endpoint.Endpoint
+	RemoveBudget             endpoint.Endpoint
+	ShowBudgetReport         endpoint.Endpoint
+	ShowPaymentsReport       endpoint.Endpoint
+	ShowCashFlow             endpoint.Endpoint
+	CreateShareLink          endpoint.Endpoint
+	ListShareLinks           endpoint.Endpoint
+	RevokeShareLink          endpoint.Endpoint
+	RotateShareToken         endpoint.Endpoint
+	ListMembers              endpoint.Endpoint
+	RevokeMember             endpoint.Endpoint
+	ListFixedRates           endpoint.Endpoint
+	SetFixedRate             endpoint.Endpoint
+	RemoveFixedRate          endpoint.Endpoint
+	ShowSettlement           endpoint.Endpoint
+	SetSettlementWeight      endpoint.Endpoint
+	RecordSettlementTransfer endpoint.Endpoint
+	RemoveSettlementTransfer endpoint.Endpoint
+	ExportPayments           endpoint.Endpoint
+	ExportAssets             endpoint.Endpoint
+	ImportPayments           endpoint.Endpoint
+	ImportStatement          endpoint.Endpoint
+	ListStatementLines       endpoint.Endpoint
+	ConfirmStatementLine     endpoint.Endpoint
+	DismissStatementLine     endpoint.Endpoint
+	ListStatementRules       endpoint.Endpoint
+	CreateStatementRule      endpoint.Endpoint
+	RemoveStatementRule      endpoint.Endpoint
+	CreateRecurringPayment   endpoint.Endpoint
+	ListRecurringPayments    endpoint.Endpoint
+	GetRecurringPayment      endpoint.Endpoint
+	RemoveRecurringPayment   endpoint.Endpoint
+	ListRecurringOccurrences endpoint.Endpoint
+	SkipOccurrence           endpoint.Endpoint
+	EditOccurrence           endpoint.Endpoint
+	CreateLoan               endpoint.Endpoint
+	ListLoans                endpoint.Endpoint
+	GetLoan                  endpoint.Endpoint
+	UpdateLoan               endpoint.Endpoint
+	RemoveLoan               endpoint.Endpoint
+	ShowLoanReport           endpoint.Endpoint
+	LinkLoanPayment          endpoint.Endpoint
+	UnlinkLoanPayment        endpoint.Endpoint
+	CreateVendor             endpoint.Endpoint
+	ListVendors              endpoint.Endpoint
+	GetVendor                endpoint.Endpoint
+	UpdateVendor             endpoint.Endpoint
+	RemoveVendor             endpoint.Endpoint
+	ShowVendorReport         endpoint.Endpoint
+	LinkVendorPayment        endpoint.Endpoint
+	UnlinkVendorPayment      endpoint.Endpoint
+	LinkVendorAsset          endpoint.Endpoint
+	UnlinkVendorAsset        endpoint.Endpoint
+	CreateContract           endpoint.Endpoint
+	ListContracts            endpoint.Endpoint
+	GetContract              endpoint.Endpoint
+	UpdateContract           endpoint.Endpoint
+	RemoveContract           endpoint.Endpoint
+	ShowContractReport       endpoint.Endpoint
+	LinkContractPayment      endpoint.Endpoint
+	UnlinkContractPayment    endpoint.Endpoint
+	CreateEstimate           endpoint.Endpoint
+	ListEstimates            endpoint.Endpoint
+	GetEstimate              endpoint.Endpoint
+	UpdateEstimate           endpoint.Endpoint
+	RemoveEstimate           endpoint.Endpoint
+	AddQuote                 endpoint.Endpoint
+	UpdateQuote              endpoint.Endpoint
+	RemoveQuote              endpoint.Endpoint
+	CompareQuotes            endpoint.Endpoint
+	AcceptQuote              endpoint.Endpoint
+	UploadAttachment         endpoint.Endpoint
+	ListAttachments          endpoint.Endpoint
+	DownloadAttachment       endpoint.Endpoint
+	RemoveAttachment         endpoint.Endpoint
+	ListTags                 endpoint.Endpoint
+	SetPaymentTags           endpoint.Endpoint
+	SetAssetTags             endpoint.Endpoint
+	ShowTagsReport           endpoint.Endpoint
 }
 
 func DecodeCreateProjectRequest(ctx context.Context, r *http.Request) (any, error) {
@@ -307,10 +455,22 @@ func DecodeCreatePaymentRequest(_ context.Context, r *http.Request) (any, error)
 
 	amount := money.New(req.Amount, req.Currency)
 
-	date, err := time.Parse(time.RFC3339, req.PaymentDate)
+	status, dueDate, err := decodePaymentStatus(req.Status, req.DueDate)
 
 	if err != nil {
-		return nil, exceptions.NewValidationException("invalid date", err)
+		return nil, err
+	}
+
+	// a payment not made yet may leave the date out, it is dated by the day
+	// it is due
+	var date time.Time
+
+	if req.PaymentDate != "" || !status.IsCommitted() {
+		date, err = time.Parse(time.RFC3339, req.PaymentDate)
+
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid date", err)
+		}
 	}
 
 	typeUUID, err := uuid.Parse(req.TypeID)
@@ -319,6 +479,10 @@ func DecodeCreatePaymentRequest(_ context.Context, r *http.Request) (any, error)
 		return nil, exceptions.NewValidationException("invalid type id", err)
 	}
 
+	if req.ExchangeRate < 0 {
+		return nil, exceptions.NewValidationException("invalid exchange rate", nil)
+	}
+
 	var paymentKind projecta.PaymentKind
 
 	if req.Kind == "" {
@@ -331,12 +495,15 @@ func DecodeCreatePaymentRequest(_ context.Context, r *http.Request) (any, error)
 	}
 
 	return projecta.CreatePaymentCommand{
-		ProjectID:   projectUUID,
-		TypeID:      typeUUID,
-		Description: req.Description,
-		Amount:      amount,
-		PaymentDate: date,
-		Kind:        paymentKind,
+		ProjectID:    projectUUID,
+		TypeID:       typeUUID,
+		Description:  req.Description,
+		Amount:       amount,
+		PaymentDate:  date,
+		Kind:         paymentKind,
+		ExchangeRate: req.ExchangeRate,
+		Status:       status,
+		DueDate:      dueDate,
 	}, err
 }
 
@@ -389,7 +556,7 @@ func makeCreateTypeEndpoint(svc projecta.TypeService) endpoint.Endpoint {
 	}
 }
 
-func makeCreatePaymentEndpoint(svc projecta.PaymentService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeCreatePaymentEndpoint(svc projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		command := request.(projecta.CreatePaymentCommand)
 
@@ -399,7 +566,12 @@ func makeCreatePaymentEndpoint(svc projecta.PaymentService, rateProvider currenc
 			return nil, err
 		}
 
-		return toPaymentDTO(expense, rateProvider), nil
+		rates, err := projectRates(ctx, fixedRates, expense.Project.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toPaymentDTO(expense, rates), nil
 	}
 }
 
@@ -482,7 +654,7 @@ func makeListCategoriesEndpoint(svc projecta.CategoryService) endpoint.Endpoint
 	}
 }
 
-func makeListPaymentsEndpoint(svc projecta.PaymentService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeListPaymentsEndpoint(svc projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		filter := request.(projecta.PaymentCollectionFilter)
 
@@ -493,9 +665,13 @@ func makeListPaymentsEndpoint(svc projecta.PaymentService, rateProvider currency
 		}
 
 		var list []PaymentDTO = make([]PaymentDTO, 0)
+		rates, err := projectRates(ctx, fixedRates, filter.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
 
 		for _, e := range collection.Elements() {
-			list = append(list, toPaymentDTO(e, rateProvider))
+			list = append(list, toPaymentDTO(e, rates))
 		}
 
 		return ListPaymentsResponse{
@@ -509,7 +685,7 @@ func makeListPaymentsEndpoint(svc projecta.PaymentService, rateProvider currency
 	}
 }
 
-func makeShowProjectTotalsEndpoint(projectSvc projecta.ProjectService, payments projecta.PaymentService, assets asset.Service, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+func makeShowProjectTotalsEndpoint(projectSvc projecta.ProjectService, payments projecta.PaymentService, assets asset.Service, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		projectID := request.(uuid.UUID)
 
@@ -518,102 +694,61 @@ func makeShowProjectTotalsEndpoint(projectSvc projecta.ProjectService, payments
 			return nil, err
 		}
 
+		rates, err := projectRates(ctx, fixedRates, projectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
 		homeCurrency := proj.MainCurrency
 		if homeCurrency == "" {
 			homeCurrency = "UAH"
 		}
 
-		offset := 0
-		limit := 100
-		next := true
-		var totalPaymentsAmount int64
-		var totalAssetsAmount int64
-		hasPayments := false
-		hasAssets := false
-
-		for next {
-			page, err := payments.Find(ctx, projecta.PaymentCollectionFilter{
-				ProjectID: projectID,
-				Pagination: core.Pagination{
-					Limit:  limit,
-					Offset: offset,
-				},
-			})
+		paymentTotals, err := payments.Totals(ctx, projecta.PaymentTotalsFilter{ProjectID: projectID})
+		if err != nil {
+			return nil, err
+		}
 
+		var totalPaymentsAmount int64
+		for _, subtotal := range paymentTotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
 			if err != nil {
 				return nil, err
 			}
-
-			if page.Total() == 0 {
-				break
-			}
-
-			for _, e := range page.Elements() {
-				hasPayments = true
-				amount := e.Amount.Amount()
-				if rateProvider != nil && e.Amount.Currency().Code != homeCurrency {
-					converted, err := rateProvider.Convert(
-						currency.NewCurrency(e.Amount.Amount(), e.Amount.Currency().Code),
-						currency.NewCurrency(0, homeCurrency),
-					)
-					if err != nil {
-						return nil, err
-					}
-					amount = converted.Amount
-				}
-				totalPaymentsAmount += amount
-			}
-
-			if len(page.Elements()) < limit {
-				next = false
-			}
-
-			offset += limit
+			totalPaymentsAmount += amount
 		}
 
-		next = true
-		offset = 0
-		limit = 100
-		for next {
-			page, err := assets.Find(ctx, asset.CollectionFilter{
-				ProjectID: projectID,
-				Pagination: core.Pagination{
-					Limit:  limit,
-					Offset: offset,
-				},
-			})
+		committedTotals, err := payments.Totals(ctx, projecta.PaymentTotalsFilter{ProjectID: projectID, Committed: true})
+		if err != nil {
+			return nil, err
+		}
 
+		var totalCommittedAmount int64
+		for _, subtotal := range committedTotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
 			if err != nil {
 				return nil, err
 			}
+			totalCommittedAmount += amount
+		}
 
-			if page.Total() == 0 {
-				break
-			}
-
-			for _, e := range page.Elements() {
-				hasAssets = true
-				price := e.Price().Amount()
-				if rateProvider != nil && e.Price().Currency().Code != homeCurrency {
-					converted, err := rateProvider.Convert(
-						currency.NewCurrency(e.Price().Amount(), e.Price().Currency().Code),
-						currency.NewCurrency(0, homeCurrency),
-					)
-					if err != nil {
-						return nil, err
-					}
-					price = converted.Amount
-				}
-				totalAssetsAmount += price
-			}
+		assetTotals, err := assets.Totals(ctx, asset.TotalsFilter{ProjectID: projectID})
+		if err != nil {
+			return nil, err
+		}
 
-			if len(page.Elements()) < limit {
-				next = false
+		var totalAssetsAmount int64
+		for _, subtotal := range assetTotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
+			if err != nil {
+				return nil, err
 			}
-
-			offset += limit
+			totalAssetsAmount += amount
 		}
 
+		hasPayments := len(paymentTotals) > 0
+		hasAssets := len(assetTotals) > 0
+
 		totals := make([]TotalDTO, 0)
 
 		if hasPayments {
@@ -624,6 +759,15 @@ func makeShowProjectTotalsEndpoint(projectSvc projecta.ProjectService, payments
 			})
 		}
 
+		// the obligations not paid yet are shown apart and left out of the balance
+		if len(committedTotals) > 0 {
+			totals = append(totals, TotalDTO{
+				Title:    "Committed Payments",
+				Amount:   totalCommittedAmount,
+				Currency: homeCurrency,
+			})
+		}
+
 		if hasAssets || hasPayments {
 			totals = append(totals, TotalDTO{
 				Title:    "Project Balance",
@@ -778,35 +922,215 @@ func makeUpdateProjectEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
 	}
 }
 
+// decodeProjectOwnerCommand reads the project id from the path together with
+// the requester, for the actions reserved to the project owner.
+func decodeProjectOwnerCommand(ctx context.Context, r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	personID, ok := ctx.Value(core.RequesterIDContextKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, uuid.Nil, exceptions.NewUnauthorizedException("failed to identify requester", nil)
+	}
+
+	vars := mux.Vars(r)
+	projectID, ok := vars["project_id"]
+	if !ok {
+		return uuid.Nil, uuid.Nil, exceptions.NewValidationException("invalid project id", nil)
+	}
+
+	projectUUID, err := uuid.Parse(projectID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, exceptions.NewValidationException("invalid project id", err)
+	}
+
+	return projectUUID, personID, nil
+}
+
+func decodeRemoveProjectRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, personID, err := decodeProjectOwnerCommand(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.RemoveProjectCommand{
+		ProjectID: projectID,
+		PersonID:  personID,
+	}, nil
+}
+
+func decodeArchiveProjectRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, personID, err := decodeProjectOwnerCommand(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.ArchiveProjectCommand{
+		ProjectID: projectID,
+		PersonID:  personID,
+	}, nil
+}
+
+func makeRemoveProjectEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.RemoveProjectCommand)
+
+		err := svc.Remove(ctx, command)
+
+		return nil, err
+	}
+}
+
+func makeArchiveProjectEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.ArchiveProjectCommand)
+
+		project, err := svc.Archive(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		return toProjectDTO(project), nil
+	}
+}
+
+func makeUnarchiveProjectEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.ArchiveProjectCommand)
+
+		project, err := svc.Unarchive(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		return toProjectDTO(project), nil
+	}
+}
+
 func MakeProjectEndpoints(
 	projectService projecta.ProjectService,
 	categoryService projecta.CategoryService,
 	typeService projecta.TypeService,
 	expenseService projecta.PaymentService,
 	assetService asset.Service,
+	budgetService projecta.BudgetService,
+	fixedRateService projecta.FixedRateService,
+	settlementService projecta.SettlementService,
+	paymentImportService projecta.PaymentImportService,
+	statementService statement.Service,
+	recurringPaymentService projecta.RecurringPaymentService,
+	loanService projecta.LoanService,
+	vendorService projecta.VendorService,
+	contractService projecta.ContractService,
+	estimateService projecta.EstimateService,
+	attachmentService attachment.Service,
+	tagService projecta.TagService,
 	rateProvider currency.CurrencyRateProvider,
 ) (ProjectEndpoints, error) {
 	return ProjectEndpoints{
-		CreateProject:     makeCreateProjectEndpoint(projectService),
-		GetProject:        makeGetProjectEndpoint(projectService),
-		AcceptShare:       makeAcceptShareEndpoint(projectService),
-		CreateCategory:    makeCreateCategoryEndpoint(categoryService),
-		CreateType:        makeCreateTypeEndpoint(typeService),
-		CreatePayment:     makeCreatePaymentEndpoint(expenseService, rateProvider),
-		ListProjects:      makeListProjectsEndpoint(projectService),
-		ListTypes:         makeListProjectTypesEndpoint(typeService),
-		ListCategories:    makeListCategoriesEndpoint(categoryService),
-		ListPayments:      makeListPaymentsEndpoint(expenseService, rateProvider),
-		ShowProjectTotals: makeShowProjectTotalsEndpoint(projectService, expenseService, assetService, rateProvider),
-		RemoveType:        makeRemoveTypeEndpoint(typeService),
-		RemovePayment:     makeRemovePaymentEndpoint(expenseService),
-		CreateAsset:       makeCreateAssetEndpoint(assetService, rateProvider),
-		RemoveAsset:       makeRemoveAssetEndpoint(assetService),
-		ListAssets:        makeListAssetsEndpoint(assetService, rateProvider),
-		UpdateAsset:       makeUpdateAssetEndpoint(assetService),
-		GetAsset:          makeGetAssetEndpoint(assetService, rateProvider),
-		UpdatePayment:     makeUpdatePaymentEndpoint(expenseService),
-		GetPayment:        makeGetPaymentEndpoint(expenseService, rateProvider),
-		UpdateProject:     makeUpdateProjectEndpoint(projectService),
+		CreateProject:            makeCreateProjectEndpoint(projectService),
+		GetProject:               makeGetProjectEndpoint(projectService),
+		AcceptShare:              makeAcceptShareEndpoint(projectService),
+		CreateCategory:           makeCreateCategoryEndpoint(categoryService),
+		CreateType:               makeCreateTypeEndpoint(typeService),
+		CreatePayment:            makeCreatePaymentEndpoint(expenseService, fixedRateService, rateProvider),
+		ListProjects:             makeListProjectsEndpoint(projectService),
+		ListTypes:                makeListProjectTypesEndpoint(typeService),
+		ListCategories:           makeListCategoriesEndpoint(categoryService),
+		ListPayments:             makeListPaymentsEndpoint(expenseService, fixedRateService, rateProvider),
+		ShowProjectTotals:        makeShowProjectTotalsEndpoint(projectService, expenseService, assetService, fixedRateService, rateProvider),
+		RemoveType:               makeRemoveTypeEndpoint(typeService),
+		RemovePayment:            makeRemovePaymentEndpoint(expenseService),
+		CreateAsset:              makeCreateAssetEndpoint(assetService, fixedRateService, rateProvider),
+		RemoveAsset:              makeRemoveAssetEndpoint(assetService),
+		ListAssets:               makeListAssetsEndpoint(assetService, fixedRateService, rateProvider),
+		UpdateAsset:              makeUpdateAssetEndpoint(assetService),
+		GetAsset:                 makeGetAssetEndpoint(assetService, fixedRateService, rateProvider),
+		UpdatePayment:            makeUpdatePaymentEndpoint(expenseService),
+		MarkPaymentPaid:          makeMarkPaymentPaidEndpoint(expenseService, fixedRateService, rateProvider),
+		GetPayment:               makeGetPaymentEndpoint(expenseService, fixedRateService, rateProvider),
+		UpdateProject:            makeUpdateProjectEndpoint(projectService),
+		RemoveProject:            makeRemoveProjectEndpoint(projectService),
+		ArchiveProject:           makeArchiveProjectEndpoint(projectService),
+		UnarchiveProject:         makeUnarchiveProjectEndpoint(projectService),
+		CreateBudget:             makeCreateBudgetEndpoint(budgetService),
+		ListBudgets:              makeListBudgetsEndpoint(budgetService),
+		GetBudget:                makeGetBudgetEndpoint(budgetService),
+		UpdateBudget:             makeUpdateBudgetEndpoint(budgetService),
+		RemoveBudget:             makeRemoveBudgetEndpoint(budgetService),
+		ShowBudgetReport:         makeShowBudgetReportEndpoint(budgetService, fixedRateService, rateProvider),
+		ShowPaymentsReport:       makeShowPaymentsReportEndpoint(projectService, expenseService, fixedRateService, rateProvider),
+		ShowCashFlow:             makeShowCashFlowEndpoint(budgetService, expenseService, fixedRateService, rateProvider),
+		CreateShareLink:          makeCreateShareLinkEndpoint(projectService),
+		ListShareLinks:           makeListShareLinksEndpoint(projectService),
+		RevokeShareLink:          makeRevokeShareLinkEndpoint(projectService),
+		RotateShareToken:         makeRotateShareTokenEndpoint(projectService),
+		ListMembers:              makeListProjectMembersEndpoint(projectService),
+		RevokeMember:             makeRevokeMemberEndpoint(projectService),
+		ListFixedRates:           makeListFixedRatesEndpoint(fixedRateService),
+		SetFixedRate:             makeSetFixedRateEndpoint(fixedRateService),
+		RemoveFixedRate:          makeRemoveFixedRateEndpoint(fixedRateService),
+		ShowSettlement:           makeShowSettlementEndpoint(settlementService, expenseService, fixedRateService, rateProvider),
+		SetSettlementWeight:      makeSetSettlementWeightEndpoint(settlementService),
+		RecordSettlementTransfer: makeRecordSettlementTransferEndpoint(settlementService),
+		RemoveSettlementTransfer: makeRemoveSettlementTransferEndpoint(settlementService),
+		ExportPayments:           makeExportPaymentsEndpoint(expenseService, fixedRateService, rateProvider),
+		ExportAssets:             makeExportAssetsEndpoint(assetService, fixedRateService, rateProvider),
+		ImportPayments:           makeImportPaymentsEndpoint(paymentImportService, fixedRateService, rateProvider),
+		ImportStatement:          makeImportStatementEndpoint(statementService),
+		ListStatementLines:       makeListStatementLinesEndpoint(statementService),
+		ConfirmStatementLine:     makeConfirmStatementLineEndpoint(statementService, fixedRateService, rateProvider),
+		DismissStatementLine:     makeDismissStatementLineEndpoint(statementService),
+		ListStatementRules:       makeListStatementRulesEndpoint(statementService),
+		CreateStatementRule:      makeCreateStatementRuleEndpoint(statementService),
+		RemoveStatementRule:      makeRemoveStatementRuleEndpoint(statementService),
+		CreateRecurringPayment:   makeCreateRecurringPaymentEndpoint(recurringPaymentService),
+		ListRecurringPayments:    makeListRecurringPaymentsEndpoint(recurringPaymentService),
+		GetRecurringPayment:      makeGetRecurringPaymentEndpoint(recurringPaymentService),
+		RemoveRecurringPayment:   makeRemoveRecurringPaymentEndpoint(recurringPaymentService),
+		ListRecurringOccurrences: makeListRecurringOccurrencesEndpoint(recurringPaymentService),
+		SkipOccurrence:           makeSkipOccurrenceEndpoint(recurringPaymentService),
+		EditOccurrence:           makeEditOccurrenceEndpoint(recurringPaymentService),
+		CreateLoan:               makeCreateLoanEndpoint(loanService),
+		ListLoans:                makeListLoansEndpoint(loanService),
+		GetLoan:                  makeGetLoanEndpoint(loanService),
+		UpdateLoan:               makeUpdateLoanEndpoint(loanService),
+		RemoveLoan:               makeRemoveLoanEndpoint(loanService),
+		ShowLoanReport:           makeShowLoanReportEndpoint(loanService),
+		LinkLoanPayment:          makeLinkLoanPaymentEndpoint(loanService),
+		UnlinkLoanPayment:        makeUnlinkLoanPaymentEndpoint(loanService),
+		CreateVendor:             makeCreateVendorEndpoint(vendorService),
+		ListVendors:              makeListVendorsEndpoint(vendorService),
+		GetVendor:                makeGetVendorEndpoint(vendorService),
+		UpdateVendor:             makeUpdateVendorEndpoint(vendorService),
+		RemoveVendor:             makeRemoveVendorEndpoint(vendorService),
+		ShowVendorReport:         makeShowVendorReportEndpoint(vendorService),
+		LinkVendorPayment:        makeLinkVendorPaymentEndpoint(vendorService),
+		UnlinkVendorPayment:      makeUnlinkVendorPaymentEndpoint(vendorService),
+		LinkVendorAsset:          makeLinkVendorAssetEndpoint(vendorService),
+		UnlinkVendorAsset:        makeUnlinkVendorAssetEndpoint(vendorService),
+		CreateContract:           makeCreateContractEndpoint(contractService),
+		ListContracts:            makeListContractsEndpoint(contractService),
+		GetContract:              makeGetContractEndpoint(contractService),
+		UpdateContract:           makeUpdateContractEndpoint(contractService),
+		RemoveContract:           makeRemoveContractEndpoint(contractService),
+		ShowContractReport:       makeShowContractReportEndpoint(contractService),
+		LinkContractPayment:      makeLinkContractPaymentEndpoint(contractService),
+		UnlinkContractPayment:    makeUnlinkContractPaymentEndpoint(contractService),
+		CreateEstimate:           makeCreateEstimateEndpoint(estimateService),
+		ListEstimates:            makeListEstimatesEndpoint(estimateService),
+		GetEstimate:              makeGetEstimateEndpoint(estimateService),
+		UpdateEstimate:           makeUpdateEstimateEndpoint(estimateService),
+		RemoveEstimate:           makeRemoveEstimateEndpoint(estimateService),
+		AddQuote:                 makeAddQuoteEndpoint(estimateService),
+		UpdateQuote:              makeUpdateQuoteEndpoint(estimateService),
+		RemoveQuote:              makeRemoveQuoteEndpoint(estimateService),
+		CompareQuotes:            makeCompareQuotesEndpoint(estimateService, projectService, fixedRateService, rateProvider),
+		AcceptQuote:              makeAcceptQuoteEndpoint(estimateService, fixedRateService, rateProvider),
+		UploadAttachment:         makeUploadAttachmentEndpoint(attachmentService),
+		ListAttachments:          makeListAttachmentsEndpoint(attachmentService),
+		DownloadAttachment:       makeDownloadAttachmentEndpoint(attachmentService),
+		RemoveAttachment:         makeRemoveAttachmentEndpoint(attachmentService),
+		ListTags:                 makeListTagsEndpoint(tagService),
+		SetPaymentTags:           makeSetPaymentTagsEndpoint(tagService),
+		SetAssetTags:             makeSetAssetTagsEndpoint(tagService),
+		ShowTagsReport:           makeShowTagsReportEndpoint(projectService, tagService, fixedRateService, rateProvider),
 	}, nil
 }
@@ -0,0 +1,366 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type RecurringPaymentDTO struct {
+	RecurringID string   `json:"recurring_id"`
+	Owner       OwnerDTO `json:"owner"`
+	Type        TypeDTO  `json:"type"`
+	Description string   `json:"description"`
+	Amount      int64    `json:"amount"`
+	Currency    string   `json:"currency"`
+	Kind        string   `json:"kind"`
+	Recurrence  string   `json:"recurrence"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date,omitempty"`
+	NextDate    string   `json:"next_date,omitempty"`
+}
+
+type CreateRecurringPaymentDTO struct {
+	TypeID      string `json:"type_id"`
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Kind        string `json:"kind,omitempty"`
+	Recurrence  string `json:"recurrence"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date,omitempty"`
+}
+
+type ListRecurringPaymentsResponse struct {
+	RecurringPayments []RecurringPaymentDTO `json:"recurring_payments"`
+	PaginationDTO
+}
+
+type RecurringOccurrenceDTO struct {
+	Date        string `json:"date"`
+	Status      string `json:"status"`
+	Amount      int64  `json:"amount,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Description string `json:"description,omitempty"`
+	PaymentDate string `json:"payment_date,omitempty"`
+	PaymentID   string `json:"payment_id,omitempty"`
+}
+
+type ListRecurringOccurrencesResponse struct {
+	Occurrences []RecurringOccurrenceDTO `json:"occurrences"`
+}
+
+type EditOccurrenceDTO struct {
+	Amount      int64  `json:"amount,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Description string `json:"description,omitempty"`
+	PaymentDate string `json:"payment_date,omitempty"`
+}
+
+func toRecurringPaymentDTO(p *projecta.RecurringPayment) RecurringPaymentDTO {
+	dto := RecurringPaymentDTO{
+		RecurringID: p.ID.String(),
+		Owner: OwnerDTO{
+			PersonID:    p.Owner.PersonID.String(),
+			DisplayName: p.Owner.DisplayName,
+		},
+		Type: TypeDTO{
+			TypeID: p.Type.ID.String(),
+			Name:   p.Type.Name,
+			Category: TypeCategoryDTO{
+				CategoryID: p.Type.Category.ID.String(),
+				Name:       p.Type.Category.Name,
+			},
+		},
+		Description: p.Description,
+		Amount:      p.Amount.Amount(),
+		Currency:    p.Amount.Currency().Code,
+		Kind:        p.Kind.String(),
+		Recurrence:  p.Recurrence.String(),
+		StartDate:   projecta.OccurrenceKey(p.StartDate),
+	}
+
+	if !p.EndDate.IsZero() {
+		dto.EndDate = projecta.OccurrenceKey(p.EndDate)
+	}
+
+	if next, ok := p.NextOccurrence(); ok {
+		dto.NextDate = projecta.OccurrenceKey(next)
+	}
+
+	return dto
+}
+
+func toRecurringOccurrenceDTO(o *projecta.RecurringOccurrence) RecurringOccurrenceDTO {
+	dto := RecurringOccurrenceDTO{
+		Date:        projecta.OccurrenceKey(o.Date),
+		Status:      o.Status.String(),
+		Description: o.Description,
+	}
+
+	if o.Amount != nil {
+		dto.Amount = o.Amount.Amount()
+		dto.Currency = o.Amount.Currency().Code
+	}
+
+	if !o.PaymentDate.IsZero() {
+		dto.PaymentDate = o.PaymentDate.Format(time.RFC3339)
+	}
+
+	if o.PaymentID != uuid.Nil {
+		dto.PaymentID = o.PaymentID.String()
+	}
+
+	return dto
+}
+
+func decodeCreateRecurringPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req CreateRecurringPaymentDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	typeID, err := uuid.Parse(req.TypeID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid type id", err)
+	}
+
+	kind := projecta.UponCompletionPayment
+	if req.Kind != "" {
+		if kind, err = projecta.ToPaymentKind(req.Kind); err != nil {
+			return nil, err
+		}
+	}
+
+	recurrence, err := projecta.ParseRecurrence(req.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, err := projecta.ParseOccurrenceDate(req.StartDate)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid start_date", err)
+	}
+
+	var endDate time.Time
+	if req.EndDate != "" {
+		if endDate, err = projecta.ParseOccurrenceDate(req.EndDate); err != nil {
+			return nil, exceptions.NewValidationException("invalid end_date", err)
+		}
+	}
+
+	return projecta.CreateRecurringPaymentCommand{
+		ProjectID:   projectID.(uuid.UUID),
+		TypeID:      typeID,
+		Description: req.Description,
+		Amount:      money.New(req.Amount, req.Currency),
+		Kind:        kind,
+		Recurrence:  recurrence,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}, nil
+}
+
+func decodeListRecurringPaymentsRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.RecurringPaymentCollectionFilter{
+		Pagination: core.Pagination{Limit: core.DefaultLimit},
+		ProjectID:  projectID.(uuid.UUID),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func decodeGetRecurringPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "recurring_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return projecta.RecurringPaymentFilter{
+		ProjectID:   command.ProjectID,
+		RecurringID: command.ResourceID,
+	}, nil
+}
+
+func decodeOccurrenceRequest(ctx context.Context, r *http.Request) (projecta.RecurringPaymentFilter, time.Time, error) {
+	filter, err := decodeGetRecurringPaymentRequest(ctx, r)
+	if err != nil {
+		return projecta.RecurringPaymentFilter{}, time.Time{}, err
+	}
+
+	date, err := projecta.ParseOccurrenceDate(mux.Vars(r)["date"])
+	if err != nil {
+		return projecta.RecurringPaymentFilter{}, time.Time{}, err
+	}
+
+	return filter.(projecta.RecurringPaymentFilter), date, nil
+}
+
+func decodeSkipOccurrenceRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, date, err := decodeOccurrenceRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.SkipOccurrenceCommand{
+		ProjectID:   filter.ProjectID,
+		RecurringID: filter.RecurringID,
+		Date:        date,
+	}, nil
+}
+
+func decodeEditOccurrenceRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, date, err := decodeOccurrenceRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req EditOccurrenceDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	command := projecta.EditOccurrenceCommand{
+		ProjectID:   filter.ProjectID,
+		RecurringID: filter.RecurringID,
+		Date:        date,
+		Description: req.Description,
+	}
+
+	if req.Amount != 0 {
+		if req.Currency == "" {
+			return nil, exceptions.NewValidationException("currency is required with amount", nil)
+		}
+
+		command.Amount = money.New(req.Amount, req.Currency)
+	}
+
+	if req.PaymentDate != "" {
+		if command.PaymentDate, err = time.Parse(time.RFC3339, req.PaymentDate); err != nil {
+			return nil, exceptions.NewValidationException("invalid payment_date", err)
+		}
+	}
+
+	return command, nil
+}
+
+func makeCreateRecurringPaymentEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		p, err := svc.Create(ctx, request.(projecta.CreateRecurringPaymentCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toRecurringPaymentDTO(p), nil
+	}
+}
+
+func makeListRecurringPaymentsEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.RecurringPaymentCollectionFilter)
+
+		collection, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]RecurringPaymentDTO, 0, len(collection.Elements()))
+		for _, p := range collection.Elements() {
+			list = append(list, toRecurringPaymentDTO(p))
+		}
+
+		return ListRecurringPaymentsResponse{
+			RecurringPayments: list,
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeGetRecurringPaymentEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		p, err := svc.FindOne(ctx, request.(projecta.RecurringPaymentFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toRecurringPaymentDTO(p), nil
+	}
+}
+
+func makeRemoveRecurringPaymentEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func makeListRecurringOccurrencesEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		occurrences, err := svc.Upcoming(ctx, request.(projecta.RecurringPaymentFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]RecurringOccurrenceDTO, 0, len(occurrences))
+		for _, o := range occurrences {
+			list = append(list, toRecurringOccurrenceDTO(o))
+		}
+
+		return ListRecurringOccurrencesResponse{Occurrences: list}, nil
+	}
+}
+
+func makeSkipOccurrenceEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.SkipOccurrence(ctx, request.(projecta.SkipOccurrenceCommand))
+	}
+}
+
+func makeEditOccurrenceEndpoint(svc projecta.RecurringPaymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		occurrence, err := svc.EditOccurrence(ctx, request.(projecta.EditOccurrenceCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toRecurringOccurrenceDTO(occurrence), nil
+	}
+}
@@ -0,0 +1,368 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+const reportDateLayout = "2006-01-02"
+
+type PaymentsReportLineDTO struct {
+	Period   string           `json:"period,omitempty"`
+	Category *TypeCategoryDTO `json:"category,omitempty"`
+	Type     *BudgetTypeDTO   `json:"type,omitempty"`
+	Kind     string           `json:"kind,omitempty"`
+	Owner    *OwnerDTO        `json:"owner,omitempty"`
+	Amount   int64            `json:"amount"`
+	Count    int              `json:"count"`
+}
+
+type PaymentsReportDTO struct {
+	Currency string                  `json:"currency"`
+	Lines    []PaymentsReportLineDTO `json:"lines"`
+	Total    int64                   `json:"total"`
+}
+
+type CashFlowPointDTO struct {
+	Period     string `json:"period"`
+	Amount     int64  `json:"amount"`
+	Cumulative int64  `json:"cumulative"`
+}
+
+type CashFlowDTO struct {
+	Currency         string             `json:"currency"`
+	Period           string             `json:"period"`
+	Window           int                `json:"window"`
+	Series           []CashFlowPointDTO `json:"series"`
+	Spent            int64              `json:"spent"`
+	BurnRate         int64              `json:"burn_rate"`
+	Planned          int64              `json:"planned"`
+	RemainingPeriods int                `json:"remaining_periods"`
+	Projected        int64              `json:"projected"`
+	Overrun          bool               `json:"overrun"`
+	ExhaustedAt      string             `json:"exhausted_at,omitempty"`
+}
+
+// decodePaymentsReportRequest reads the breakdown from the query string, e.g.
+// ?group_by=category,owner&period=month&from=2026-01-01&to=2026-06-30.
+func decodePaymentsReportRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+
+	filter := projecta.PaymentReportFilter{
+		PaymentTotalsFilter: projecta.PaymentTotalsFilter{
+			ProjectID: projectID.(uuid.UUID),
+		},
+	}
+
+	for _, value := range strings.Split(query.Get("group_by"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		grouping, err := projecta.ToPaymentGrouping(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !filter.Groups(grouping) {
+			filter.GroupBy = append(filter.GroupBy, grouping)
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		if filter.Period, err = projecta.ToReportPeriod(period); err != nil {
+			return nil, err
+		}
+	}
+
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(reportDateLayout, from); err != nil {
+			return nil, exceptions.NewValidationException("invalid from date", err)
+		}
+	}
+
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(reportDateLayout, to); err != nil {
+			return nil, exceptions.NewValidationException("invalid to date", err)
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, exceptions.NewValidationException("to date must not be before from date", nil)
+	}
+
+	return filter, nil
+}
+
+type paymentsReportLine struct {
+	dto   PaymentsReportLineDTO
+	start time.Time
+}
+
+// makeShowPaymentsReportEndpoint converts the payment subtotals of every day
+// with the rates of that day and then sums them by the requested groupings and
+// calendar period.
+func makeShowPaymentsReportEndpoint(projectSvc projecta.ProjectService, payments projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.PaymentReportFilter)
+
+		proj, err := projectSvc.FindOne(ctx, projecta.ProjectFilter{ProjectID: filter.ProjectID})
+		if err != nil {
+			return nil, err
+		}
+
+		homeCurrency := proj.MainCurrency
+		if homeCurrency == "" {
+			homeCurrency = "UAH"
+		}
+
+		subtotals, err := payments.Totals(ctx, filter.PaymentTotalsFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, filter.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := make(map[string]*paymentsReportLine)
+		result := PaymentsReportDTO{
+			Currency: homeCurrency,
+			Lines:    make([]PaymentsReportLineDTO, 0),
+		}
+
+		for _, subtotal := range subtotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
+			if err != nil {
+				return nil, err
+			}
+
+			key := paymentsReportKey(subtotal, filter.Period)
+
+			line, ok := lines[key]
+			if !ok {
+				line = newPaymentsReportLine(subtotal, filter.Period)
+				lines[key] = line
+			}
+
+			line.dto.Amount += amount
+			line.dto.Count += subtotal.Count
+			result.Total += amount
+		}
+
+		sorted := make([]*paymentsReportLine, 0, len(lines))
+		for _, line := range lines {
+			sorted = append(sorted, line)
+		}
+
+		// periods in chronological order, the biggest spend first within each
+		sort.Slice(sorted, func(i, j int) bool {
+			if !sorted[i].start.Equal(sorted[j].start) {
+				return sorted[i].start.Before(sorted[j].start)
+			}
+			if sorted[i].dto.Amount != sorted[j].dto.Amount {
+				return sorted[i].dto.Amount > sorted[j].dto.Amount
+			}
+			return paymentsReportLabel(sorted[i].dto) < paymentsReportLabel(sorted[j].dto)
+		})
+
+		for _, line := range sorted {
+			result.Lines = append(result.Lines, line.dto)
+		}
+
+		return result, nil
+	}
+}
+
+func paymentsReportKey(subtotal *projecta.PaymentSubtotal, period projecta.ReportPeriod) string {
+	parts := make([]string, 0, 5)
+
+	if period != "" {
+		parts = append(parts, period.Label(subtotal.Date))
+	}
+	if subtotal.Category != nil {
+		parts = append(parts, subtotal.Category.ID.String())
+	}
+	if subtotal.Type != nil {
+		parts = append(parts, subtotal.Type.ID.String())
+	}
+	if subtotal.Kind != "" {
+		parts = append(parts, subtotal.Kind.String())
+	}
+	if subtotal.Owner != nil {
+		parts = append(parts, subtotal.Owner.PersonID.String())
+	}
+
+	return strings.Join(parts, "|")
+}
+
+func newPaymentsReportLine(subtotal *projecta.PaymentSubtotal, period projecta.ReportPeriod) *paymentsReportLine {
+	line := &paymentsReportLine{}
+
+	if period != "" {
+		line.start = period.Start(subtotal.Date)
+		line.dto.Period = period.Label(subtotal.Date)
+	}
+
+	if subtotal.Category != nil {
+		line.dto.Category = &TypeCategoryDTO{
+			CategoryID: subtotal.Category.ID.String(),
+			Name:       subtotal.Category.Name,
+		}
+	}
+
+	if subtotal.Type != nil {
+		line.dto.Type = &BudgetTypeDTO{
+			TypeID: subtotal.Type.ID.String(),
+			Name:   subtotal.Type.Name,
+		}
+	}
+
+	line.dto.Kind = subtotal.Kind.String()
+
+	if subtotal.Owner != nil {
+		line.dto.Owner = &OwnerDTO{
+			PersonID:    subtotal.Owner.PersonID.String(),
+			DisplayName: subtotal.Owner.DisplayName,
+		}
+	}
+
+	return line
+}
+
+// paymentsReportLabel orders lines of equal amounts by their names.
+func paymentsReportLabel(line PaymentsReportLineDTO) string {
+	parts := make([]string, 0, 4)
+
+	if line.Category != nil {
+		parts = append(parts, line.Category.Name)
+	}
+	if line.Type != nil {
+		parts = append(parts, line.Type.Name)
+	}
+	parts = append(parts, line.Kind)
+	if line.Owner != nil {
+		parts = append(parts, line.Owner.DisplayName, line.Owner.PersonID)
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// decodeCashFlowRequest reads the period, a month unless told otherwise, and the
+// number of periods the burn rate is averaged over, e.g. ?period=quarter&window=2.
+func decodeCashFlowRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+
+	filter := projecta.CashFlowFilter{
+		ProjectID: projectID.(uuid.UUID),
+		Period:    projecta.PeriodMonth,
+		Window:    projecta.DefaultBurnRateWindow,
+	}
+
+	if period := query.Get("period"); period != "" {
+		if filter.Period, err = projecta.ToReportPeriod(period); err != nil {
+			return nil, err
+		}
+	}
+
+	if window := query.Get("window"); window != "" {
+		filter.Window, err = strconv.Atoi(window)
+
+		if err != nil || filter.Window <= 0 {
+			return nil, exceptions.NewValidationException("window must be a positive number of periods", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// makeShowCashFlowEndpoint sums the converted payments per period and weighs
+// them against the budgets planned for the project.
+func makeShowCashFlowEndpoint(budgets projecta.BudgetService, payments projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.CashFlowFilter)
+
+		report, err := budgets.Report(ctx, filter.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, filter.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		// the plan is the one of the budget report, nested budgets counted once
+		budget, err := toBudgetReportDTO(report, rates)
+		if err != nil {
+			return nil, err
+		}
+
+		homeCurrency := budget.Currency
+
+		subtotals, err := payments.Totals(ctx, projecta.PaymentTotalsFilter{ProjectID: filter.ProjectID})
+		if err != nil {
+			return nil, err
+		}
+
+		spending := make(map[time.Time]int64)
+		for _, subtotal := range subtotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
+			if err != nil {
+				return nil, err
+			}
+			spending[filter.Period.Start(subtotal.Date)] += amount
+		}
+
+		cf := projecta.NewCashFlow(report.Project, filter.Period, spending, filter.Window, budget.Planned, time.Now())
+
+		result := CashFlowDTO{
+			Currency:         homeCurrency,
+			Period:           filter.Period.String(),
+			Window:           filter.Window,
+			Series:           make([]CashFlowPointDTO, 0, len(cf.Points)),
+			Spent:            cf.Spent,
+			BurnRate:         cf.BurnRate,
+			Planned:          cf.Planned,
+			RemainingPeriods: cf.RemainingPeriods,
+			Projected:        cf.Projected,
+			Overrun:          cf.Overrun(),
+		}
+
+		for _, point := range cf.Points {
+			result.Series = append(result.Series, CashFlowPointDTO{
+				Period:     filter.Period.Label(point.Start),
+				Amount:     point.Amount,
+				Cumulative: point.Cumulative,
+			})
+		}
+
+		if !cf.ExhaustedAt.IsZero() {
+			result.ExhaustedAt = filter.Period.Label(cf.ExhaustedAt)
+		}
+
+		return result, nil
+	}
+}
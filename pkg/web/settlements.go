@@ -0,0 +1,265 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+type SetSettlementWeightDTO struct {
+	Weight float64 `json:"weight"`
+}
+
+type SettlementWeightDTO struct {
+	PersonID string  `json:"person_id"`
+	Weight   float64 `json:"weight"`
+}
+
+type RecordSettlementTransferDTO struct {
+	FromID       string `json:"from_id"`
+	ToID         string `json:"to_id"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency"`
+	TransferDate string `json:"transfer_date,omitempty"`
+	Description  string `json:"description"`
+}
+
+type SettlementTransferDTO struct {
+	TransferID   string   `json:"transfer_id"`
+	From         OwnerDTO `json:"from"`
+	To           OwnerDTO `json:"to"`
+	Amount       int64    `json:"amount"`
+	Currency     string   `json:"currency"`
+	TransferDate string   `json:"transfer_date"`
+	Description  string   `json:"description"`
+}
+
+type MemberBalanceDTO struct {
+	PersonID    string  `json:"person_id"`
+	DisplayName string  `json:"display_name"`
+	Weight      float64 `json:"weight"`
+	Paid        int64   `json:"paid"`
+	Share       int64   `json:"share"`
+	Sent        int64   `json:"sent"`
+	Received    int64   `json:"received"`
+	Balance     int64   `json:"balance"`
+}
+
+type SuggestedTransferDTO struct {
+	From   OwnerDTO `json:"from"`
+	To     OwnerDTO `json:"to"`
+	Amount int64    `json:"amount"`
+}
+
+type SettlementDTO struct {
+	Currency  string                  `json:"currency"`
+	Total     int64                   `json:"total"`
+	Members   []MemberBalanceDTO      `json:"members"`
+	Transfers []SuggestedTransferDTO  `json:"transfers"`
+	Recorded  []SettlementTransferDTO `json:"recorded"`
+}
+
+func toSettlementTransferDTO(transfer *projecta.SettlementTransfer) SettlementTransferDTO {
+	return SettlementTransferDTO{
+		TransferID:   transfer.ID.String(),
+		From:         OwnerDTO{PersonID: transfer.From.PersonID.String(), DisplayName: transfer.From.DisplayName},
+		To:           OwnerDTO{PersonID: transfer.To.PersonID.String(), DisplayName: transfer.To.DisplayName},
+		Amount:       transfer.Amount.Amount(),
+		Currency:     transfer.Amount.Currency().Code,
+		TransferDate: transfer.Date.Format(time.RFC3339),
+		Description:  transfer.Description,
+	}
+}
+
+func toMemberOwnerDTO(balance *projecta.MemberBalance) OwnerDTO {
+	return OwnerDTO{
+		PersonID:    balance.PersonID.String(),
+		DisplayName: balance.DisplayName,
+	}
+}
+
+func decodeSetSettlementWeightRequest(_ context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "person_id")(r.Context(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req SetSettlementWeightDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return projecta.SetSettlementWeightCommand{
+		ProjectID: command.ProjectID,
+		PersonID:  command.ResourceID,
+		Weight:    req.Weight,
+	}, nil
+}
+
+// decodeRecordSettlementTransferRequest reads a transfer between two members.
+// Transfers made today may leave the date out.
+func decodeRecordSettlementTransferRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req RecordSettlementTransferDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	fromID, err := uuid.Parse(req.FromID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid from id", err)
+	}
+
+	toID, err := uuid.Parse(req.ToID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid to id", err)
+	}
+
+	date := time.Now()
+
+	if req.TransferDate != "" {
+		date, err = time.Parse(time.RFC3339, req.TransferDate)
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid transfer date", err)
+		}
+	}
+
+	return projecta.RecordSettlementTransferCommand{
+		ProjectID:   projectID.(uuid.UUID),
+		FromID:      fromID,
+		ToID:        toID,
+		Amount:      money.New(req.Amount, req.Currency),
+		Date:        date,
+		Description: req.Description,
+	}, nil
+}
+
+// makeShowSettlementEndpoint converts what every member paid and handed over
+// with the rates of the day it happened, then splits the total by the weights.
+func makeShowSettlementEndpoint(settlements projecta.SettlementService, payments projecta.PaymentService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		projectID := request.(uuid.UUID)
+
+		ledger, err := settlements.Ledger(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		homeCurrency := ledger.Project.MainCurrency
+		if homeCurrency == "" {
+			homeCurrency = "UAH"
+		}
+
+		subtotals, err := payments.Totals(ctx, projecta.PaymentTotalsFilter{
+			ProjectID: projectID,
+			GroupBy:   []projecta.PaymentGrouping{projecta.GroupByOwner},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, projectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+		settlement := projecta.NewSettlement(ledger.Members, ledger.Weights)
+
+		for _, subtotal := range subtotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
+			if err != nil {
+				return nil, err
+			}
+			settlement.AddPaid(subtotal.Owner, amount)
+		}
+
+		result := SettlementDTO{
+			Currency:  homeCurrency,
+			Members:   make([]MemberBalanceDTO, 0, len(settlement.Balances)),
+			Transfers: make([]SuggestedTransferDTO, 0),
+			Recorded:  make([]SettlementTransferDTO, 0, len(ledger.Transfers)),
+		}
+
+		for _, transfer := range ledger.Transfers {
+			amount, err := toHomeAmount(rates, transfer.Amount, homeCurrency, transfer.Date)
+			if err != nil {
+				return nil, err
+			}
+			settlement.AddTransfer(transfer.From, transfer.To, amount)
+			result.Recorded = append(result.Recorded, toSettlementTransferDTO(transfer))
+		}
+
+		if err = settlement.Settle(); err != nil {
+			return nil, err
+		}
+
+		result.Total = settlement.Total
+
+		for _, b := range settlement.Balances {
+			result.Members = append(result.Members, MemberBalanceDTO{
+				PersonID:    b.PersonID.String(),
+				DisplayName: b.DisplayName,
+				Weight:      b.Weight,
+				Paid:        b.Paid,
+				Share:       b.Share,
+				Sent:        b.Sent,
+				Received:    b.Received,
+				Balance:     b.Balance(),
+			})
+		}
+
+		for _, t := range settlement.Transfers {
+			result.Transfers = append(result.Transfers, SuggestedTransferDTO{
+				From:   toMemberOwnerDTO(t.From),
+				To:     toMemberOwnerDTO(t.To),
+				Amount: t.Amount,
+			})
+		}
+
+		return result, nil
+	}
+}
+
+func makeSetSettlementWeightEndpoint(svc projecta.SettlementService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		weight, err := svc.SetWeight(ctx, request.(projecta.SetSettlementWeightCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return SettlementWeightDTO{
+			PersonID: weight.PersonID.String(),
+			Weight:   weight.Weight,
+		}, nil
+	}
+}
+
+func makeRecordSettlementTransferEndpoint(svc projecta.SettlementService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		transfer, err := svc.RecordTransfer(ctx, request.(projecta.RecordSettlementTransferCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toSettlementTransferDTO(transfer), nil
+	}
+}
+
+func makeRemoveSettlementTransferEndpoint(svc projecta.SettlementService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.RemoveTransfer(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
@@ -0,0 +1,254 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type CreateShareLinkDTO struct {
+	Role      string `json:"role"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	MaxUses   int    `json:"max_uses,omitempty"`
+}
+
+type ShareLinkDTO struct {
+	LinkID    string `json:"link_id"`
+	Token     string `json:"token"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	MaxUses   int    `json:"max_uses,omitempty"`
+	Uses      int    `json:"uses"`
+}
+
+type ListShareLinksResponse struct {
+	Links []ShareLinkDTO `json:"links"`
+}
+
+type ProjectMemberDTO struct {
+	PersonID    string `json:"person_id"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role"`
+	JoinedAt    string `json:"joined_at,omitempty"`
+}
+
+type ListProjectMembersResponse struct {
+	Members []ProjectMemberDTO `json:"members"`
+}
+
+func toShareLinkDTO(link *projecta.ShareLink) ShareLinkDTO {
+	dto := ShareLinkDTO{
+		LinkID:    link.ID.String(),
+		Token:     link.Token.String(),
+		Role:      link.Role.String(),
+		CreatedAt: link.CreatedAt.Format(time.RFC3339),
+		MaxUses:   link.MaxUses,
+		Uses:      link.Uses,
+	}
+
+	if !link.ExpiresAt.IsZero() {
+		dto.ExpiresAt = link.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return dto
+}
+
+func toProjectMemberDTO(member *projecta.ProjectMember) ProjectMemberDTO {
+	dto := ProjectMemberDTO{
+		PersonID:    member.PersonID.String(),
+		DisplayName: member.DisplayName,
+		Role:        member.Role.String(),
+	}
+
+	if !member.JoinedAt.IsZero() {
+		dto.JoinedAt = member.JoinedAt.Format(time.RFC3339)
+	}
+
+	return dto
+}
+
+// decodeCreateShareLinkRequest reads the role the link grants. Links grant the
+// viewer role unless another one is requested.
+func decodeCreateShareLinkRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, personID, err := decodeProjectOwnerCommand(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req CreateShareLinkDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	role := projecta.RoleViewer
+
+	if req.Role != "" {
+		role, err = projecta.ToProjectRole(req.Role)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var expiresAt time.Time
+
+	if req.ExpiresAt != "" {
+		expiresAt, err = time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid expires_at", err)
+		}
+	}
+
+	return projecta.CreateShareLinkCommand{
+		ProjectID: projectID,
+		PersonID:  personID,
+		Role:      role,
+		ExpiresAt: expiresAt,
+		MaxUses:   req.MaxUses,
+	}, nil
+}
+
+// decodeProjectShareResource reads the requester along with the project and the
+// sharing resource, a link or a member, addressed by resourceIDKey.
+func decodeProjectShareResource(ctx context.Context, r *http.Request, resourceIDKey string) (projecta.RemoveProjectResourceCommand, uuid.UUID, error) {
+	personID, ok := ctx.Value(core.RequesterIDContextKey).(uuid.UUID)
+	if !ok {
+		return projecta.RemoveProjectResourceCommand{}, uuid.Nil, exceptions.NewUnauthorizedException("failed to identify requester", nil)
+	}
+
+	resource, err := decodeProjectResourceRemoveCommand("project_id", resourceIDKey)(ctx, r)
+	if err != nil {
+		return projecta.RemoveProjectResourceCommand{}, uuid.Nil, err
+	}
+
+	return resource.(projecta.RemoveProjectResourceCommand), personID, nil
+}
+
+func decodeRevokeShareLinkRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, personID, err := decodeProjectShareResource(ctx, r, "link_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.RevokeShareLinkCommand{
+		ProjectID: resource.ProjectID,
+		PersonID:  personID,
+		LinkID:    resource.ResourceID,
+	}, nil
+}
+
+func decodeRevokeMemberRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, personID, err := decodeProjectShareResource(ctx, r, "person_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.RevokeMemberCommand{
+		ProjectID: resource.ProjectID,
+		PersonID:  personID,
+		MemberID:  resource.ResourceID,
+	}, nil
+}
+
+func decodeRotateShareTokenRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, personID, err := decodeProjectOwnerCommand(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return projecta.RotateShareTokenCommand{
+		ProjectID: projectID,
+		PersonID:  personID,
+	}, nil
+}
+
+func makeCreateShareLinkEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.CreateShareLinkCommand)
+
+		link, err := svc.CreateShareLink(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		return toShareLinkDTO(link), nil
+	}
+}
+
+func makeListShareLinksEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		projectID := request.(uuid.UUID)
+
+		links, err := svc.ShareLinks(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]ShareLinkDTO, 0, len(links))
+
+		for _, link := range links {
+			list = append(list, toShareLinkDTO(link))
+		}
+
+		return ListShareLinksResponse{Links: list}, nil
+	}
+}
+
+func makeRevokeShareLinkEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.RevokeShareLinkCommand)
+
+		err := svc.RevokeShareLink(ctx, command)
+
+		return nil, err
+	}
+}
+
+func makeRotateShareTokenEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.RotateShareTokenCommand)
+
+		project, err := svc.RotateShareToken(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		return toProjectDTO(project), nil
+	}
+}
+
+func makeListProjectMembersEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		projectID := request.(uuid.UUID)
+
+		members, err := svc.Members(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]ProjectMemberDTO, 0, len(members))
+
+		for _, member := range members {
+			list = append(list, toProjectMemberDTO(member))
+		}
+
+		return ListProjectMembersResponse{Members: list}, nil
+	}
+}
+
+func makeRevokeMemberEndpoint(svc projecta.ProjectService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		command := request.(projecta.RevokeMemberCommand)
+
+		err := svc.RevokeMember(ctx, command)
+
+		return nil, err
+	}
+}
@@ -0,0 +1,326 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
+	"gitlab.com/massimo-ua/projecta/pkg/bank"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+type StatementLineDTO struct {
+	LineID       string `json:"line_id"`
+	Source       string `json:"source"`
+	ExternalID   string `json:"external_id"`
+	Date         string `json:"date"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency"`
+	Description  string `json:"description"`
+	Counterparty string `json:"counterparty"`
+	Status       string `json:"status"`
+	PaymentID    string `json:"payment_id,omitempty"`
+}
+
+type StatementImportDTO struct {
+	Booked     []StatementLineDTO `json:"booked"`
+	Pending    []StatementLineDTO `json:"pending"`
+	Duplicates int                `json:"duplicates"`
+	Skipped    int                `json:"skipped"`
+}
+
+type ListStatementLinesResponse struct {
+	Lines []StatementLineDTO `json:"lines"`
+	PaginationDTO
+}
+
+type ConfirmStatementLineDTO struct {
+	TypeID      string `json:"type_id"`
+	Description string `json:"description"`
+}
+
+type CreateStatementRuleDTO struct {
+	Field    string `json:"field"`
+	Pattern  string `json:"pattern"`
+	TypeID   string `json:"type_id"`
+	Priority int    `json:"priority"`
+}
+
+type StatementRuleDTO struct {
+	RuleID   string  `json:"rule_id"`
+	Field    string  `json:"field"`
+	Pattern  string  `json:"pattern"`
+	Type     TypeDTO `json:"type"`
+	Priority int     `json:"priority"`
+}
+
+type ListStatementRulesResponse struct {
+	Rules []StatementRuleDTO `json:"rules"`
+}
+
+func toStatementLineDTO(line *statement.Line) StatementLineDTO {
+	dto := StatementLineDTO{
+		LineID:       line.ID.String(),
+		Source:       line.Source,
+		ExternalID:   line.ExternalID,
+		Date:         line.Date.Format(time.RFC3339),
+		Amount:       line.Amount.Amount(),
+		Currency:     line.Amount.Currency().Code,
+		Description:  line.Description,
+		Counterparty: line.Counterparty,
+		Status:       line.Status.String(),
+	}
+
+	if line.PaymentID != uuid.Nil {
+		dto.PaymentID = line.PaymentID.String()
+	}
+
+	return dto
+}
+
+func toStatementLineDTOs(lines []*statement.Line) []StatementLineDTO {
+	list := make([]StatementLineDTO, 0, len(lines))
+
+	for _, line := range lines {
+		list = append(list, toStatementLineDTO(line))
+	}
+
+	return list
+}
+
+func toStatementRuleDTO(rule *statement.Rule) StatementRuleDTO {
+	return StatementRuleDTO{
+		RuleID:  rule.ID.String(),
+		Field:   rule.Field.String(),
+		Pattern: rule.Pattern,
+		Type: TypeDTO{
+			TypeID: rule.Type.ID.String(),
+			Name:   rule.Type.Name,
+			Category: TypeCategoryDTO{
+				CategoryID: rule.Type.Category.ID.String(),
+				Name:       rule.Type.Category.Name,
+			},
+		},
+		Priority: rule.Priority,
+	}
+}
+
+// decodeImportStatementRequest reads a bank statement sent either as the
+// request body or as the "file" field of a form, ?format tells the bank it
+// comes from.
+func decodeImportStatementRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := bank.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := readImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := bank.Parse(format, bytes.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+
+	return statement.ImportCommand{
+		ProjectID:    projectID.(uuid.UUID),
+		Source:       format.String(),
+		Transactions: transactions,
+	}, nil
+}
+
+func decodeListStatementLinesRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := statement.LineCollectionFilter{
+		Pagination: core.Pagination{Limit: core.DefaultLimit},
+		ProjectID:  projectID.(uuid.UUID),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	if status := query.Get("status"); status != "" {
+		if filter.Status, err = statement.ToLineStatus(status); err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+func decodeConfirmStatementLineRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "line_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req ConfirmStatementLineDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	typeID, err := uuid.Parse(req.TypeID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid type_id", err)
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return statement.ConfirmLineCommand{
+		ProjectID:   command.ProjectID,
+		LineID:      command.ResourceID,
+		TypeID:      typeID,
+		Description: req.Description,
+	}, nil
+}
+
+func decodeCreateStatementRuleRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req CreateStatementRuleDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	field, err := statement.ToRuleField(req.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	typeID, err := uuid.Parse(req.TypeID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid type_id", err)
+	}
+
+	return statement.CreateRuleCommand{
+		ProjectID: projectID.(uuid.UUID),
+		Field:     field,
+		Pattern:   req.Pattern,
+		TypeID:    typeID,
+		Priority:  req.Priority,
+	}, nil
+}
+
+func makeImportStatementEndpoint(svc statement.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		report, err := svc.Import(ctx, request.(statement.ImportCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return StatementImportDTO{
+			Booked:     toStatementLineDTOs(report.Booked),
+			Pending:    toStatementLineDTOs(report.Pending),
+			Duplicates: report.Duplicates,
+			Skipped:    report.Skipped,
+		}, nil
+	}
+}
+
+func makeListStatementLinesEndpoint(svc statement.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(statement.LineCollectionFilter)
+
+		collection, err := svc.Lines(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return ListStatementLinesResponse{
+			Lines: toStatementLineDTOs(collection.Elements()),
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeConfirmStatementLineEndpoint(svc statement.Service, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		payment, err := svc.Confirm(ctx, request.(statement.ConfirmLineCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, payment.Project.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		return toPaymentDTO(payment, rates), nil
+	}
+}
+
+func makeDismissStatementLineEndpoint(svc statement.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Dismiss(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func makeListStatementRulesEndpoint(svc statement.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		rules, err := svc.Rules(ctx, request.(uuid.UUID))
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]StatementRuleDTO, 0, len(rules))
+		for _, rule := range rules {
+			list = append(list, toStatementRuleDTO(rule))
+		}
+
+		return ListStatementRulesResponse{Rules: list}, nil
+	}
+}
+
+func makeCreateStatementRuleEndpoint(svc statement.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		rule, err := svc.CreateRule(ctx, request.(statement.CreateRuleCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toStatementRuleDTO(rule), nil
+	}
+}
+
+func makeRemoveStatementRuleEndpoint(svc statement.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.RemoveRule(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
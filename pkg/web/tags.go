@@ -0,0 +1,256 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/pkg/currency"
+)
+
+type TagDTO struct {
+	Name     string `json:"name"`
+	Payments int    `json:"payments"`
+	Assets   int    `json:"assets"`
+}
+
+type ListTagsResponse struct {
+	Tags []TagDTO `json:"tags"`
+}
+
+type SetTagsDTO struct {
+	Tags []string `json:"tags"`
+}
+
+type TagsReportLineDTO struct {
+	Tag           string `json:"tag"`
+	Payments      int64  `json:"payments"`
+	PaymentsCount int    `json:"payments_count"`
+	Assets        int64  `json:"assets"`
+	AssetsCount   int    `json:"assets_count"`
+}
+
+type TagsReportDTO struct {
+	Currency string              `json:"currency"`
+	Lines    []TagsReportLineDTO `json:"lines"`
+}
+
+// decodeTagsQuery reads the tags a list is narrowed to, e.g. ?tags=kitchen,tax-deductible.
+func decodeTagsQuery(query url.Values) ([]string, error) {
+	names := make([]string, 0)
+
+	for _, name := range strings.Split(query.Get("tags"), ",") {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	return projecta.NormalizeTags(names)
+}
+
+// decodeListTagsRequest reads the beginning of the tag typed so far, e.g.
+// ?name=kit&limit=5.
+func decodeListTagsRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.TagCollectionFilter{
+		ProjectID: projectID.(uuid.UUID),
+		Name:      query.Get("name"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+
+		if err != nil || filter.Limit <= 0 {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// decodeSetTagsRequest reads the tags replacing the ones of the payment or
+// asset under resourceIDKey.
+func decodeSetTagsRequest(resourceIDKey string) func(context.Context, *http.Request) (any, error) {
+	return func(ctx context.Context, r *http.Request) (any, error) {
+		resource, err := decodeProjectResourceRemoveCommand("project_id", resourceIDKey)(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		var req SetTagsDTO
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, exceptions.NewValidationException("invalid request", err)
+		}
+
+		command := resource.(projecta.RemoveProjectResourceCommand)
+
+		return projecta.TagResourceCommand{
+			ProjectID:  command.ProjectID,
+			ResourceID: command.ResourceID,
+			Tags:       req.Tags,
+		}, nil
+	}
+}
+
+// decodeTagsReportRequest reads the days the totals are limited to, e.g.
+// ?from=2026-01-01&to=2026-06-30.
+func decodeTagsReportRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.TagTotalsFilter{ProjectID: projectID.(uuid.UUID)}
+
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(reportDateLayout, from); err != nil {
+			return nil, exceptions.NewValidationException("invalid from date", err)
+		}
+	}
+
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(reportDateLayout, to); err != nil {
+			return nil, exceptions.NewValidationException("invalid to date", err)
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, exceptions.NewValidationException("to date must not be before from date", nil)
+	}
+
+	return filter, nil
+}
+
+func makeListTagsEndpoint(svc projecta.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		tags, err := svc.Find(ctx, request.(projecta.TagCollectionFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]TagDTO, 0, len(tags))
+		for _, tag := range tags {
+			list = append(list, TagDTO{
+				Name:     tag.Name,
+				Payments: tag.Payments,
+				Assets:   tag.Assets,
+			})
+		}
+
+		return ListTagsResponse{Tags: list}, nil
+	}
+}
+
+func makeSetPaymentTagsEndpoint(svc projecta.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		tags, err := svc.TagPayment(ctx, request.(projecta.TagResourceCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return SetTagsDTO{Tags: tags}, nil
+	}
+}
+
+func makeSetAssetTagsEndpoint(svc projecta.TagService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		tags, err := svc.TagAsset(ctx, request.(projecta.TagResourceCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return SetTagsDTO{Tags: tags}, nil
+	}
+}
+
+// makeShowTagsReportEndpoint converts the subtotals of every day with the rates
+// of that day and sums them per tag. Assets bought with a payment are counted
+// apart from it, so the two columns are not meant to be added up.
+func makeShowTagsReportEndpoint(projectSvc projecta.ProjectService, tags projecta.TagService, fixedRates projecta.FixedRateService, rateProvider currency.CurrencyRateProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.TagTotalsFilter)
+
+		proj, err := projectSvc.FindOne(ctx, projecta.ProjectFilter{ProjectID: filter.ProjectID})
+		if err != nil {
+			return nil, err
+		}
+
+		homeCurrency := proj.MainCurrency
+		if homeCurrency == "" {
+			homeCurrency = "UAH"
+		}
+
+		subtotals, err := tags.Totals(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		rates, err := projectRates(ctx, fixedRates, filter.ProjectID, rateProvider)
+		if err != nil {
+			return nil, err
+		}
+		lines := make(map[string]*TagsReportLineDTO)
+
+		for _, subtotal := range subtotals {
+			amount, err := toHomeAmount(rates, subtotal.Amount, homeCurrency, subtotal.Date)
+			if err != nil {
+				return nil, err
+			}
+
+			line, ok := lines[subtotal.Tag]
+			if !ok {
+				line = &TagsReportLineDTO{Tag: subtotal.Tag}
+				lines[subtotal.Tag] = line
+			}
+
+			if subtotal.Kind == projecta.TaggedAsset {
+				line.Assets += amount
+				line.AssetsCount += subtotal.Count
+			} else {
+				line.Payments += amount
+				line.PaymentsCount += subtotal.Count
+			}
+		}
+
+		result := TagsReportDTO{
+			Currency: homeCurrency,
+			Lines:    make([]TagsReportLineDTO, 0, len(lines)),
+		}
+
+		for _, line := range lines {
+			result.Lines = append(result.Lines, *line)
+		}
+
+		// the biggest spend first
+		sort.Slice(result.Lines, func(i, j int) bool {
+			if result.Lines[i].Payments != result.Lines[j].Payments {
+				return result.Lines[i].Payments > result.Lines[j].Payments
+			}
+			if result.Lines[i].Assets != result.Lines[j].Assets {
+				return result.Lines[i].Assets > result.Lines[j].Assets
+			}
+			return result.Lines[i].Tag < result.Lines[j].Tag
+		})
+
+		return result, nil
+	}
+}
@@ -0,0 +1,358 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.com/massimo-ua/projecta/internal/core"
+	"gitlab.com/massimo-ua/projecta/internal/exceptions"
+	"gitlab.com/massimo-ua/projecta/internal/projecta"
+)
+
+type VendorDTO struct {
+	VendorID    string `json:"vendor_id"`
+	Name        string `json:"name"`
+	TaxID       string `json:"tax_id,omitempty"`
+	ContactName string `json:"contact_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	IBAN        string `json:"iban,omitempty"`
+	Shared      bool   `json:"shared"`
+}
+
+type CreateVendorDTO struct {
+	Name        string `json:"name"`
+	TaxID       string `json:"tax_id"`
+	ContactName string `json:"contact_name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	IBAN        string `json:"iban"`
+	Shared      bool   `json:"shared"`
+}
+
+type UpdateVendorDTO struct {
+	Name        string `json:"name"`
+	TaxID       string `json:"tax_id"`
+	ContactName string `json:"contact_name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	IBAN        string `json:"iban"`
+}
+
+type ListVendorsResponse struct {
+	Vendors []VendorDTO `json:"vendors"`
+	PaginationDTO
+}
+
+type LinkVendorPaymentDTO struct {
+	PaymentID string `json:"payment_id"`
+}
+
+type LinkVendorAssetDTO struct {
+	AssetID string `json:"asset_id"`
+}
+
+type VendorAmountDTO struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type VendorReportDTO struct {
+	Vendor    VendorDTO         `json:"vendor"`
+	Paid      []VendorAmountDTO `json:"paid"`
+	Committed []VendorAmountDTO `json:"committed"`
+	Assets    []VendorAmountDTO `json:"assets"`
+	Payments  int               `json:"payments"`
+}
+
+func toVendorDTO(v *projecta.Vendor) VendorDTO {
+	return VendorDTO{
+		VendorID:    v.ID.String(),
+		Name:        v.Name,
+		TaxID:       v.TaxID,
+		ContactName: v.ContactName,
+		Email:       v.Email,
+		Phone:       v.Phone,
+		IBAN:        v.IBAN,
+		Shared:      v.IsShared(),
+	}
+}
+
+func toVendorAmountDTOs(amounts []*money.Money) []VendorAmountDTO {
+	list := make([]VendorAmountDTO, 0, len(amounts))
+
+	for _, amount := range amounts {
+		list = append(list, VendorAmountDTO{
+			Amount:   amount.Amount(),
+			Currency: amount.Currency().Code,
+		})
+	}
+
+	return list
+}
+
+func toVendorReportDTO(report *projecta.VendorReport) VendorReportDTO {
+	return VendorReportDTO{
+		Vendor:    toVendorDTO(report.Vendor),
+		Paid:      toVendorAmountDTOs(report.Paid),
+		Committed: toVendorAmountDTOs(report.Committed),
+		Assets:    toVendorAmountDTOs(report.Assets),
+		Payments:  report.Payments,
+	}
+}
+
+func decodeCreateVendorRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req CreateVendorDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	return projecta.CreateVendorCommand{
+		ProjectID:   projectID.(uuid.UUID),
+		Shared:      req.Shared,
+		Name:        req.Name,
+		TaxID:       req.TaxID,
+		ContactName: req.ContactName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		IBAN:        req.IBAN,
+	}, nil
+}
+
+func decodeUpdateVendorRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetVendorRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req UpdateVendorDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	return projecta.UpdateVendorCommand{
+		ProjectID:   filter.(projecta.VendorFilter).ProjectID,
+		ID:          filter.(projecta.VendorFilter).VendorID,
+		Name:        req.Name,
+		TaxID:       req.TaxID,
+		ContactName: req.ContactName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		IBAN:        req.IBAN,
+	}, nil
+}
+
+func decodeGetVendorRequest(ctx context.Context, r *http.Request) (any, error) {
+	resource, err := decodeProjectResourceRemoveCommand("project_id", "vendor_id")(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	command := resource.(projecta.RemoveProjectResourceCommand)
+
+	return projecta.VendorFilter{
+		ProjectID: command.ProjectID,
+		VendorID:  command.ResourceID,
+	}, nil
+}
+
+func decodeListVendorsRequest(ctx context.Context, r *http.Request) (any, error) {
+	projectID, err := decodeProjectTotalsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	filter := projecta.VendorCollectionFilter{
+		Pagination: core.Pagination{Limit: core.DefaultLimit},
+		ProjectID:  projectID.(uuid.UUID),
+		Name:       query.Get("name"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, exceptions.NewValidationException("invalid limit", err)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return nil, exceptions.NewValidationException("invalid offset", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func decodeLinkVendorPaymentRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetVendorRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req LinkVendorPaymentDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	paymentID, err := uuid.Parse(req.PaymentID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid payment id", err)
+	}
+
+	return projecta.LinkVendorResourceCommand{
+		ProjectID:  filter.(projecta.VendorFilter).ProjectID,
+		VendorID:   filter.(projecta.VendorFilter).VendorID,
+		ResourceID: paymentID,
+	}, nil
+}
+
+func decodeLinkVendorAssetRequest(ctx context.Context, r *http.Request) (any, error) {
+	filter, err := decodeGetVendorRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req LinkVendorAssetDTO
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, exceptions.NewValidationException("invalid request", err)
+	}
+
+	assetID, err := uuid.Parse(req.AssetID)
+	if err != nil {
+		return nil, exceptions.NewValidationException("invalid asset id", err)
+	}
+
+	return projecta.LinkVendorResourceCommand{
+		ProjectID:  filter.(projecta.VendorFilter).ProjectID,
+		VendorID:   filter.(projecta.VendorFilter).VendorID,
+		ResourceID: assetID,
+	}, nil
+}
+
+// decodeUnlinkVendorResourceRequest reads the vendor route with the id of the
+// payment or asset to unlink under resourceIDKey.
+func decodeUnlinkVendorResourceRequest(resourceIDKey string) func(context.Context, *http.Request) (any, error) {
+	return func(ctx context.Context, r *http.Request) (any, error) {
+		filter, err := decodeGetVendorRequest(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		resourceID, err := uuid.Parse(mux.Vars(r)[resourceIDKey])
+		if err != nil {
+			return nil, exceptions.NewValidationException("invalid "+resourceIDKey, err)
+		}
+
+		return projecta.LinkVendorResourceCommand{
+			ProjectID:  filter.(projecta.VendorFilter).ProjectID,
+			VendorID:   filter.(projecta.VendorFilter).VendorID,
+			ResourceID: resourceID,
+		}, nil
+	}
+}
+
+func makeCreateVendorEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		v, err := svc.Create(ctx, request.(projecta.CreateVendorCommand))
+		if err != nil {
+			return nil, err
+		}
+
+		return toVendorDTO(v), nil
+	}
+}
+
+func makeUpdateVendorEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Update(ctx, request.(projecta.UpdateVendorCommand))
+	}
+}
+
+func makeGetVendorEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		v, err := svc.FindOne(ctx, request.(projecta.VendorFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toVendorDTO(v), nil
+	}
+}
+
+func makeListVendorsEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		filter := request.(projecta.VendorCollectionFilter)
+
+		collection, err := svc.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]VendorDTO, 0)
+		for _, v := range collection.Elements() {
+			list = append(list, toVendorDTO(v))
+		}
+
+		return ListVendorsResponse{
+			Vendors: list,
+			PaginationDTO: PaginationDTO{
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+				Total:  collection.Total(),
+			},
+		}, nil
+	}
+}
+
+func makeRemoveVendorEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.Remove(ctx, request.(projecta.RemoveProjectResourceCommand))
+	}
+}
+
+func makeShowVendorReportEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		report, err := svc.Report(ctx, request.(projecta.VendorFilter))
+		if err != nil {
+			return nil, err
+		}
+
+		return toVendorReportDTO(report), nil
+	}
+}
+
+func makeLinkVendorPaymentEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.LinkPayment(ctx, request.(projecta.LinkVendorResourceCommand))
+	}
+}
+
+func makeUnlinkVendorPaymentEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.UnlinkPayment(ctx, request.(projecta.LinkVendorResourceCommand))
+	}
+}
+
+func makeLinkVendorAssetEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.LinkAsset(ctx, request.(projecta.LinkVendorResourceCommand))
+	}
+}
+
+func makeUnlinkVendorAssetEndpoint(svc projecta.VendorService) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return nil, svc.UnlinkAsset(ctx, request.(projecta.LinkVendorResourceCommand))
+	}
+}
@@ -4,18 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Rhymond/go-money"
 	"github.com/google/uuid"
 	"gitlab.com/massimo-ua/projecta/internal/asset"
+	"gitlab.com/massimo-ua/projecta/internal/attachment"
 	"gitlab.com/massimo-ua/projecta/internal/core"
 	"gitlab.com/massimo-ua/projecta/internal/exceptions"
 	"gitlab.com/massimo-ua/projecta/internal/people"
 	"gitlab.com/massimo-ua/projecta/internal/projecta"
+	"gitlab.com/massimo-ua/projecta/internal/statement"
 )
 
 // Mocks for web package tests
@@ -105,12 +111,55 @@ func (m *mockProjectService) Update(_ context.Context, _ projecta.UpdateProjectC
 	}
 	return m.project, nil
 }
+func (m *mockProjectService) Archive(_ context.Context, _ projecta.ArchiveProjectCommand) (*projecta.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.project, nil
+}
+func (m *mockProjectService) Unarchive(_ context.Context, _ projecta.ArchiveProjectCommand) (*projecta.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.project, nil
+}
 func (m *mockProjectService) AcceptShare(_ context.Context, _ uuid.UUID, _ uuid.UUID) (*projecta.Project, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	return m.project, nil
 }
+func (m *mockProjectService) CreateShareLink(_ context.Context, command projecta.CreateShareLinkCommand) (*projecta.ShareLink, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewShareLink(uuid.New(), uuid.New(), m.project, command.Role, command.PersonID, time.Now(), command.ExpiresAt, command.MaxUses)
+}
+func (m *mockProjectService) ShareLinks(_ context.Context, _ uuid.UUID) ([]*projecta.ShareLink, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	link, err := projecta.NewShareLink(uuid.New(), uuid.New(), m.project, projecta.RoleViewer, uuid.New(), time.Now(), time.Now().Add(time.Hour), 5)
+	return []*projecta.ShareLink{link}, err
+}
+func (m *mockProjectService) RevokeShareLink(_ context.Context, _ projecta.RevokeShareLinkCommand) error {
+	return m.err
+}
+func (m *mockProjectService) RotateShareToken(_ context.Context, _ projecta.RotateShareTokenCommand) (*projecta.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.project, nil
+}
+func (m *mockProjectService) Members(_ context.Context, _ uuid.UUID) ([]*projecta.ProjectMember, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []*projecta.ProjectMember{{PersonID: m.project.Owner.PersonID, Role: projecta.RoleOwner, JoinedAt: time.Now()}}, nil
+}
+func (m *mockProjectService) RevokeMember(_ context.Context, _ projecta.RevokeMemberCommand) error {
+	return m.err
+}
 
 type mockCategoryService struct {
 	cat *projecta.CostCategory
@@ -167,8 +216,10 @@ func (m *mockTypeService) Update(_ context.Context, _ projecta.UpdateTypeCommand
 }
 
 type mockPaymentService struct {
-	pay *projecta.Payment
-	err error
+	pay       *projecta.Payment
+	totals    []*projecta.PaymentSubtotal
+	committed []*projecta.PaymentSubtotal
+	err       error
 }
 
 func (m *mockPaymentService) FindOne(_ context.Context, _ projecta.PaymentFilter) (*projecta.Payment, error) {
@@ -192,9 +243,24 @@ func (m *mockPaymentService) Create(_ context.Context, _ projecta.CreatePaymentC
 func (m *mockPaymentService) Update(_ context.Context, _ projecta.UpdatePaymentCommand) error {
 	return m.err
 }
+func (m *mockPaymentService) MarkPaid(_ context.Context, _ projecta.MarkPaymentPaidCommand) (*projecta.Payment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.pay, nil
+}
 func (m *mockPaymentService) Remove(_ context.Context, _ projecta.RemovePaymentCommand) error {
 	return m.err
 }
+func (m *mockPaymentService) Totals(_ context.Context, filter projecta.PaymentTotalsFilter) ([]*projecta.PaymentSubtotal, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if filter.Committed {
+		return m.committed, nil
+	}
+	return m.totals, nil
+}
 
 type mockAssetService struct {
 	asset *asset.Asset
@@ -225,6 +291,407 @@ func (m *mockAssetService) Remove(_ context.Context, _ asset.RemoveAssetCommand)
 func (m *mockAssetService) Update(_ context.Context, _ asset.UpdateAssetCommand) error {
 	return m.err
 }
+func (m *mockAssetService) Totals(_ context.Context, _ asset.TotalsFilter) ([]*asset.Subtotal, error) {
+	return nil, m.err
+}
+
+type mockBudgetService struct {
+	budget *projecta.Budget
+	report *projecta.BudgetReport
+	err    error
+}
+
+func (m *mockBudgetService) Find(_ context.Context, _ projecta.BudgetCollectionFilter) (*projecta.BudgetCollection, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	col := projecta.NewBudgetCollection(1)
+	col.Add(m.budget)
+	return col, nil
+}
+func (m *mockBudgetService) FindOne(_ context.Context, _ projecta.BudgetFilter) (*projecta.Budget, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.budget, nil
+}
+func (m *mockBudgetService) Create(_ context.Context, _ projecta.CreateBudgetCommand) (*projecta.Budget, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.budget, nil
+}
+func (m *mockBudgetService) Update(_ context.Context, _ projecta.UpdateBudgetCommand) error {
+	return m.err
+}
+func (m *mockBudgetService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockBudgetService) Report(_ context.Context, _ uuid.UUID) (*projecta.BudgetReport, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.report, nil
+}
+
+type mockFixedRateService struct {
+	rates []*projecta.FixedRate
+	err   error
+}
+
+func (m *mockFixedRateService) Find(_ context.Context, _ uuid.UUID) ([]*projecta.FixedRate, error) {
+	return m.rates, m.err
+}
+func (m *mockFixedRateService) Set(_ context.Context, command projecta.SetFixedRateCommand) (*projecta.FixedRate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewFixedRate(command.ProjectID, command.Currency, "UAH", command.Rate)
+}
+func (m *mockFixedRateService) Remove(_ context.Context, _ projecta.RemoveFixedRateCommand) error {
+	return m.err
+}
+
+type mockPaymentImportService struct {
+	report  *projecta.PaymentImport
+	err     error
+	command projecta.ImportPaymentsCommand
+}
+
+func (m *mockPaymentImportService) Import(_ context.Context, command projecta.ImportPaymentsCommand) (*projecta.PaymentImport, error) {
+	m.command = command
+	return m.report, m.err
+}
+
+type mockStatementService struct {
+	report  *statement.Import
+	lines   *statement.LineCollection
+	payment *projecta.Payment
+	rule    *statement.Rule
+	err     error
+}
+
+func (m *mockStatementService) Import(_ context.Context, _ statement.ImportCommand) (*statement.Import, error) {
+	return m.report, m.err
+}
+func (m *mockStatementService) Lines(_ context.Context, _ statement.LineCollectionFilter) (*statement.LineCollection, error) {
+	return m.lines, m.err
+}
+func (m *mockStatementService) Confirm(_ context.Context, _ statement.ConfirmLineCommand) (*projecta.Payment, error) {
+	return m.payment, m.err
+}
+func (m *mockStatementService) Dismiss(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockStatementService) Rules(_ context.Context, _ uuid.UUID) ([]*statement.Rule, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []*statement.Rule{m.rule}, nil
+}
+func (m *mockStatementService) CreateRule(_ context.Context, _ statement.CreateRuleCommand) (*statement.Rule, error) {
+	return m.rule, m.err
+}
+func (m *mockStatementService) RemoveRule(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+
+type mockRecurringPaymentService struct {
+	payment    *projecta.RecurringPayment
+	occurrence *projecta.RecurringOccurrence
+	err        error
+}
+
+func (m *mockRecurringPaymentService) Find(_ context.Context, _ projecta.RecurringPaymentCollectionFilter) (*projecta.RecurringPaymentCollection, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	col := projecta.NewRecurringPaymentCollection(1)
+	col.Add(m.payment)
+	return col, nil
+}
+func (m *mockRecurringPaymentService) FindOne(_ context.Context, _ projecta.RecurringPaymentFilter) (*projecta.RecurringPayment, error) {
+	return m.payment, m.err
+}
+func (m *mockRecurringPaymentService) Upcoming(_ context.Context, _ projecta.RecurringPaymentFilter) ([]*projecta.RecurringOccurrence, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []*projecta.RecurringOccurrence{m.occurrence}, nil
+}
+func (m *mockRecurringPaymentService) Create(_ context.Context, _ projecta.CreateRecurringPaymentCommand) (*projecta.RecurringPayment, error) {
+	return m.payment, m.err
+}
+func (m *mockRecurringPaymentService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockRecurringPaymentService) SkipOccurrence(_ context.Context, _ projecta.SkipOccurrenceCommand) error {
+	return m.err
+}
+func (m *mockRecurringPaymentService) EditOccurrence(_ context.Context, _ projecta.EditOccurrenceCommand) (*projecta.RecurringOccurrence, error) {
+	return m.occurrence, m.err
+}
+func (m *mockRecurringPaymentService) Materialise(_ context.Context, _ time.Time) (int, error) {
+	return 0, m.err
+}
+
+type mockLoanService struct {
+	loan    *projecta.Loan
+	payment *projecta.LoanPayment
+	err     error
+}
+
+func (m *mockLoanService) Find(_ context.Context, _ projecta.LoanCollectionFilter) (*projecta.LoanCollection, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	col := projecta.NewLoanCollection(1)
+	col.Add(m.loan)
+	return col, nil
+}
+func (m *mockLoanService) FindOne(_ context.Context, _ projecta.LoanFilter) (*projecta.Loan, error) {
+	return m.loan, m.err
+}
+func (m *mockLoanService) Create(_ context.Context, _ projecta.CreateLoanCommand) (*projecta.Loan, error) {
+	return m.loan, m.err
+}
+func (m *mockLoanService) Update(_ context.Context, _ projecta.UpdateLoanCommand) error {
+	return m.err
+}
+func (m *mockLoanService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockLoanService) LinkPayment(_ context.Context, _ projecta.LinkLoanPaymentCommand) (*projecta.LoanPayment, error) {
+	return m.payment, m.err
+}
+func (m *mockLoanService) UnlinkPayment(_ context.Context, _ projecta.UnlinkLoanPaymentCommand) error {
+	return m.err
+}
+func (m *mockLoanService) Report(_ context.Context, _ projecta.LoanFilter) (*projecta.LoanReport, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewLoanReport(m.loan, []*projecta.LoanPayment{m.payment}), nil
+}
+
+type mockVendorService struct {
+	vendor *projecta.Vendor
+	err    error
+}
+
+func (m *mockVendorService) Find(_ context.Context, _ projecta.VendorCollectionFilter) (*projecta.VendorCollection, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	col := projecta.NewVendorCollection(1)
+	col.Add(m.vendor)
+	return col, nil
+}
+func (m *mockVendorService) FindOne(_ context.Context, _ projecta.VendorFilter) (*projecta.Vendor, error) {
+	return m.vendor, m.err
+}
+func (m *mockVendorService) Create(_ context.Context, _ projecta.CreateVendorCommand) (*projecta.Vendor, error) {
+	return m.vendor, m.err
+}
+func (m *mockVendorService) Update(_ context.Context, _ projecta.UpdateVendorCommand) error {
+	return m.err
+}
+func (m *mockVendorService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockVendorService) LinkPayment(_ context.Context, _ projecta.LinkVendorResourceCommand) error {
+	return m.err
+}
+func (m *mockVendorService) UnlinkPayment(_ context.Context, _ projecta.LinkVendorResourceCommand) error {
+	return m.err
+}
+func (m *mockVendorService) LinkAsset(_ context.Context, _ projecta.LinkVendorResourceCommand) error {
+	return m.err
+}
+func (m *mockVendorService) UnlinkAsset(_ context.Context, _ projecta.LinkVendorResourceCommand) error {
+	return m.err
+}
+func (m *mockVendorService) Report(_ context.Context, _ projecta.VendorFilter) (*projecta.VendorReport, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewVendorReport(m.vendor, []*projecta.VendorSubtotal{
+		{Amount: money.New(500000, "UAH"), Count: 2, Status: projecta.PaymentPaid},
+		{Amount: money.New(120000, "UAH"), Count: 1},
+	}), nil
+}
+
+type mockContractService struct {
+	contract *projecta.Contract
+	payments []*projecta.ContractPayment
+	err      error
+}
+
+func (m *mockContractService) Find(_ context.Context, _ projecta.ContractCollectionFilter) (*projecta.ContractCollection, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	col := projecta.NewContractCollection(1)
+	col.Add(m.contract)
+	return col, nil
+}
+func (m *mockContractService) FindOne(_ context.Context, _ projecta.ContractFilter) (*projecta.Contract, error) {
+	return m.contract, m.err
+}
+func (m *mockContractService) Create(_ context.Context, _ projecta.CreateContractCommand) (*projecta.Contract, error) {
+	return m.contract, m.err
+}
+func (m *mockContractService) Update(_ context.Context, _ projecta.UpdateContractCommand) error {
+	return m.err
+}
+func (m *mockContractService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockContractService) LinkPayment(_ context.Context, _ projecta.LinkContractPaymentCommand) error {
+	return m.err
+}
+func (m *mockContractService) UnlinkPayment(_ context.Context, _ projecta.LinkContractPaymentCommand) error {
+	return m.err
+}
+func (m *mockContractService) Report(_ context.Context, _ projecta.ContractFilter) (*projecta.ContractReport, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewContractReport(m.contract, m.payments), nil
+}
+
+type mockEstimateService struct {
+	estimate   *projecta.Estimate
+	acceptance *projecta.QuoteAcceptance
+	err        error
+}
+
+func (m *mockEstimateService) Find(_ context.Context, _ projecta.EstimateCollectionFilter) (*projecta.EstimateCollection, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	col := projecta.NewEstimateCollection(1)
+	col.Add(m.estimate)
+	return col, nil
+}
+func (m *mockEstimateService) FindOne(_ context.Context, _ projecta.EstimateFilter) (*projecta.Estimate, error) {
+	return m.estimate, m.err
+}
+func (m *mockEstimateService) Create(_ context.Context, _ projecta.CreateEstimateCommand) (*projecta.Estimate, error) {
+	return m.estimate, m.err
+}
+func (m *mockEstimateService) Update(_ context.Context, _ projecta.UpdateEstimateCommand) error {
+	return m.err
+}
+func (m *mockEstimateService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockEstimateService) AddQuote(_ context.Context, _ projecta.CreateQuoteCommand) (*projecta.Quote, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.estimate.Quotes[0], nil
+}
+func (m *mockEstimateService) UpdateQuote(_ context.Context, _ projecta.UpdateQuoteCommand) error {
+	return m.err
+}
+func (m *mockEstimateService) RemoveQuote(_ context.Context, _ projecta.RemoveQuoteCommand) error {
+	return m.err
+}
+func (m *mockEstimateService) Accept(_ context.Context, _ projecta.AcceptQuoteCommand) (*projecta.QuoteAcceptance, error) {
+	return m.acceptance, m.err
+}
+
+type mockAttachmentService struct {
+	attachment *attachment.Attachment
+	content    string
+	err        error
+}
+
+func (m *mockAttachmentService) Find(_ context.Context, _ attachment.CollectionFilter) ([]*attachment.Attachment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []*attachment.Attachment{m.attachment}, nil
+}
+func (m *mockAttachmentService) FindOne(_ context.Context, _ attachment.Filter) (*attachment.Attachment, error) {
+	return m.attachment, m.err
+}
+func (m *mockAttachmentService) Open(_ context.Context, _ attachment.Filter) (*attachment.Attachment, io.ReadCloser, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	return m.attachment, io.NopCloser(strings.NewReader(m.content)), nil
+}
+func (m *mockAttachmentService) Upload(_ context.Context, command attachment.UploadCommand) (*attachment.Attachment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return attachment.NewAttachment(uuid.New(), command.ProjectID, command.Target, command.FileName, command.ContentType, int64(len(command.Content)), attachment.Checksum(command.Content), uuid.New(), time.Now())
+}
+func (m *mockAttachmentService) Remove(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
+func (m *mockAttachmentService) Sweep(_ context.Context) (int, error) {
+	return 0, m.err
+}
+
+type mockTagService struct {
+	tags      []*projecta.Tag
+	subtotals []*projecta.TagSubtotal
+	err       error
+}
+
+func (m *mockTagService) Find(_ context.Context, _ projecta.TagCollectionFilter) ([]*projecta.Tag, error) {
+	return m.tags, m.err
+}
+func (m *mockTagService) TagPayment(_ context.Context, command projecta.TagResourceCommand) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NormalizeTags(command.Tags)
+}
+func (m *mockTagService) TagAsset(_ context.Context, command projecta.TagResourceCommand) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NormalizeTags(command.Tags)
+}
+func (m *mockTagService) Totals(_ context.Context, _ projecta.TagTotalsFilter) ([]*projecta.TagSubtotal, error) {
+	return m.subtotals, m.err
+}
+
+type mockSettlementService struct {
+	ledger *projecta.SettlementLedger
+	err    error
+}
+
+func (m *mockSettlementService) Ledger(_ context.Context, _ uuid.UUID) (*projecta.SettlementLedger, error) {
+	return m.ledger, m.err
+}
+func (m *mockSettlementService) SetWeight(_ context.Context, command projecta.SetSettlementWeightCommand) (*projecta.SettlementWeight, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewSettlementWeight(command.ProjectID, command.PersonID, command.Weight)
+}
+func (m *mockSettlementService) RecordTransfer(_ context.Context, command projecta.RecordSettlementTransferCommand) (*projecta.SettlementTransfer, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return projecta.NewSettlementTransfer(
+		uuid.New(),
+		command.ProjectID,
+		&projecta.Owner{PersonID: command.FromID},
+		&projecta.Owner{PersonID: command.ToID},
+		command.Amount,
+		command.Date,
+		command.Description,
+	)
+}
+func (m *mockSettlementService) RemoveTransfer(_ context.Context, _ projecta.RemoveProjectResourceCommand) error {
+	return m.err
+}
 
 func TestWebHandlersAndEndpoints(t *testing.T) {
 	personID := uuid.New()
@@ -245,8 +712,50 @@ func TestWebHandlersAndEndpoints(t *testing.T) {
 	typeSvc := &mockTypeService{costType: costType}
 	paySvc := &mockPaymentService{pay: pay}
 	astSvc := &mockAssetService{asset: ast}
+	budget, _ := projecta.NewBudget(uuid.New(), proj.ProjectID, cat, costType, money.New(1000, "UAH"), "Desc")
+	budgetSvc := &mockBudgetService{
+		budget: budget,
+		report: &projecta.BudgetReport{Project: proj, Lines: []*projecta.BudgetReportLine{{Budget: budget, Actual: []*projecta.PaymentSubtotal{{Amount: money.New(1200, "UAH"), Date: time.Now()}}}}},
+	}
+
+	rule, _ := statement.NewRule(uuid.New(), proj.ProjectID, statement.MatchAny, "epicentr", costType, 0)
+	statementSvc := &mockStatementService{
+		report:  &statement.Import{},
+		lines:   statement.NewLineCollection(0),
+		payment: pay,
+		rule:    rule,
+	}
 
-	handler, err := MakeHTTPHandler(peopleSvc, tokenProv, authSvc, projSvc, catSvc, typeSvc, paySvc, astSvc, nil)
+	recurring, _ := projecta.NewRecurringPayment(uuid.New(), proj, owner, costType, "Rent", money.New(1000, "UAH"), projecta.UponCompletionPayment, projecta.Recurrence{Frequency: projecta.Monthly, Interval: 1}, time.Now(), time.Time{})
+	recurringSvc := &mockRecurringPaymentService{
+		payment:    recurring,
+		occurrence: &projecta.RecurringOccurrence{RecurringID: recurring.ID, Date: recurring.StartDate, Status: projecta.OccurrencePending},
+	}
+
+	loan, _ := projecta.NewLoan(uuid.New(), proj.ProjectID, "Bank loan", money.New(1200000, "UAH"), 12, 12, projecta.AnnuitySchedule, time.Now())
+	loanSvc := &mockLoanService{
+		loan:    loan,
+		payment: &projecta.LoanPayment{LoanID: loan.ID, PaymentID: pay.ID, Installment: 1, Amount: money.New(106619, "UAH"), Date: time.Now()},
+	}
+
+	vendor, _ := projecta.NewVendor(uuid.New(), proj.Owner.PersonID, proj.ProjectID, "Electrician", "", "", "", "", "")
+	contract, _ := projecta.NewContract(uuid.New(), proj.ProjectID, vendor.ID, "Wiring", money.New(1000000, "UAH"), nil, time.Time{})
+
+	estimate, _ := projecta.NewEstimate(uuid.New(), proj.ProjectID, "Wiring")
+	quote, _ := projecta.NewQuote(uuid.New(), estimate.ID, vendor.ID, "", []*projecta.EstimateItem{{TypeID: costType.ID, Quantity: 2, UnitPrice: money.New(500000, "UAH")}})
+	estimate.Quotes = append(estimate.Quotes, quote)
+	estimateSvc := &mockEstimateService{estimate: estimate, acceptance: &projecta.QuoteAcceptance{Estimate: estimate, Contract: contract}}
+
+	receipt := "%PDF-1.7 receipt"
+	attached, _ := attachment.NewAttachment(uuid.New(), proj.ProjectID, attachment.Target{Kind: attachment.PaymentTarget, ID: pay.ID}, "receipt.pdf", "application/pdf", int64(len(receipt)), attachment.Checksum([]byte(receipt)), proj.Owner.PersonID, time.Now())
+	attachmentSvc := &mockAttachmentService{attachment: attached, content: receipt}
+
+	tagSvc := &mockTagService{
+		tags:      []*projecta.Tag{{Name: "kitchen", Payments: 2, Assets: 1}},
+		subtotals: []*projecta.TagSubtotal{{Tag: "kitchen", Kind: projecta.TaggedPayment, Amount: money.New(1000, "UAH"), Date: time.Now(), Count: 2}},
+	}
+
+	handler, err := MakeHTTPHandler(peopleSvc, tokenProv, authSvc, projSvc, catSvc, typeSvc, paySvc, astSvc, budgetSvc, &mockFixedRateService{}, &mockSettlementService{ledger: &projecta.SettlementLedger{Project: proj}}, &mockPaymentImportService{report: &projecta.PaymentImport{}}, statementSvc, recurringSvc, loanSvc, &mockVendorService{vendor: vendor}, &mockContractService{contract: contract}, estimateSvc, attachmentSvc, tagSvc, nil)
 	if err != nil || handler == nil {
 		t.Fatalf("failed to create http handler: %v", err)
 	}
@@ -336,6 +845,91 @@ func TestWebHandlersAndEndpoints(t *testing.T) {
 		if err != nil || respPatch.StatusCode != http.StatusOK {
 			t.Errorf("expected 200 for PATCH /projects/{id}, got %v", respPatch.StatusCode)
 		}
+
+		// GET /projects?archived=true
+		reqArchivedList, _ := http.NewRequest("GET", server.URL+"/projects?archived=true", nil)
+		reqArchivedList.Header.Set("Authorization", "Bearer token")
+		respArchivedList, err := client.Do(reqArchivedList)
+		if err != nil || respArchivedList.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for GET /projects?archived=true, got %v", respArchivedList.StatusCode)
+		}
+
+		// POST /projects/{id}/archive
+		reqArchive, _ := http.NewRequest("POST", server.URL+"/projects/"+proj.ProjectID.String()+"/archive", nil)
+		reqArchive.Header.Set("Authorization", "Bearer token")
+		respArchive, err := client.Do(reqArchive)
+		if err != nil || respArchive.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for POST /projects/{id}/archive, got %v", respArchive.StatusCode)
+		}
+
+		// POST /projects/{id}/unarchive
+		reqUnarchive, _ := http.NewRequest("POST", server.URL+"/projects/"+proj.ProjectID.String()+"/unarchive", nil)
+		reqUnarchive.Header.Set("Authorization", "Bearer token")
+		respUnarchive, err := client.Do(reqUnarchive)
+		if err != nil || respUnarchive.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for POST /projects/{id}/unarchive, got %v", respUnarchive.StatusCode)
+		}
+
+		// POST /projects/{id}/share-links
+		reqLink, _ := http.NewRequest("POST", server.URL+"/projects/"+proj.ProjectID.String()+"/share-links", bytes.NewReader([]byte(`{"role":"EDITOR"}`)))
+		reqLink.Header.Set("Authorization", "Bearer token")
+		respLink, err := client.Do(reqLink)
+		if err != nil || respLink.StatusCode != http.StatusCreated {
+			t.Errorf("expected 201 for POST /projects/{id}/share-links, got %v", respLink.StatusCode)
+		}
+
+		// sharing management
+		for _, route := range []struct {
+			method string
+			path   string
+			status int
+		}{
+			{http.MethodGet, "/share-links", http.StatusOK},
+			{http.MethodDelete, "/share-links/" + uuid.New().String(), http.StatusNoContent},
+			{http.MethodPost, "/share-token", http.StatusOK},
+			{http.MethodGet, "/members", http.StatusOK},
+			{http.MethodDelete, "/members/" + uuid.New().String(), http.StatusNoContent},
+			{http.MethodGet, "/rates", http.StatusOK},
+			{http.MethodPut, "/rates/USD", http.StatusOK},
+			{http.MethodDelete, "/rates/USD", http.StatusNoContent},
+		} {
+			reqShare, _ := http.NewRequest(route.method, server.URL+"/projects/"+proj.ProjectID.String()+route.path, bytes.NewReader([]byte(`{"rate":41.5}`)))
+			reqShare.Header.Set("Authorization", "Bearer token")
+			respShare, err := client.Do(reqShare)
+			if err != nil || respShare.StatusCode != route.status {
+				t.Errorf("expected %d for %s /projects/{id}%s, got %v", route.status, route.method, route.path, respShare.StatusCode)
+			}
+		}
+
+		// settlement
+		transferBody := fmt.Sprintf(`{"from_id":%q,"to_id":%q,"amount":500,"currency":"UAH"}`, uuid.New(), uuid.New())
+		for _, route := range []struct {
+			method string
+			path   string
+			body   string
+			status int
+		}{
+			{http.MethodGet, "/settlement", "", http.StatusOK},
+			{http.MethodPut, "/settlement/weights/" + uuid.New().String(), `{"weight":2}`, http.StatusOK},
+			{http.MethodPost, "/settlement/transfers", transferBody, http.StatusCreated},
+			{http.MethodPost, "/settlement/transfers", `{"from_id":"bad"}`, http.StatusBadRequest},
+			{http.MethodDelete, "/settlement/transfers/" + uuid.New().String(), "", http.StatusNoContent},
+		} {
+			reqSettle, _ := http.NewRequest(route.method, server.URL+"/projects/"+proj.ProjectID.String()+route.path, strings.NewReader(route.body))
+			reqSettle.Header.Set("Authorization", "Bearer token")
+			respSettle, err := client.Do(reqSettle)
+			if err != nil || respSettle.StatusCode != route.status {
+				t.Errorf("expected %d for %s /projects/{id}%s, got %v", route.status, route.method, route.path, respSettle.StatusCode)
+			}
+		}
+
+		// DELETE /projects/{id}
+		reqDelete, _ := http.NewRequest("DELETE", server.URL+"/projects/"+proj.ProjectID.String(), nil)
+		reqDelete.Header.Set("Authorization", "Bearer token")
+		respDelete, err := client.Do(reqDelete)
+		if err != nil || respDelete.StatusCode != http.StatusNoContent {
+			t.Errorf("expected 204 for DELETE /projects/{id}, got %v", respDelete.StatusCode)
+		}
 	})
 
 	t.Run("Categories endpoints", func(t *testing.T) {
@@ -413,6 +1007,121 @@ func TestWebHandlersAndEndpoints(t *testing.T) {
 			t.Errorf("expected 200 for GET totals")
 		}
 
+		// GET /projects/{id}/reports/payments
+		reqReport, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+"/reports/payments?group_by=category,owner&period=month", nil)
+		reqReport.Header.Set("Authorization", "Bearer token")
+		respReport, _ := client.Do(reqReport)
+		if respReport.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for GET payments report, got %v", respReport.StatusCode)
+		}
+
+		reqCashFlow, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+"/reports/cashflow?period=quarter", nil)
+		reqCashFlow.Header.Set("Authorization", "Bearer token")
+		respCashFlow, _ := client.Do(reqCashFlow)
+		if respCashFlow.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for GET cash flow, got %v", respCashFlow.StatusCode)
+		}
+
+		for _, path := range []string{"/payments/export?format=xlsx&converted=true", "/assets/export"} {
+			reqExport, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+path, nil)
+			reqExport.Header.Set("Authorization", "Bearer token")
+			respExport, err := client.Do(reqExport)
+			if err != nil || respExport.StatusCode != http.StatusOK || respExport.Header.Get("Content-Disposition") == "" {
+				t.Errorf("expected an attachment for GET %s, got %v", path, respExport.StatusCode)
+			}
+		}
+
+		reqImport, _ := http.NewRequest("POST", server.URL+"/projects/"+pID+"/payments/import?dry_run=true", strings.NewReader("date,amount,type\n2026-03-05,100,Plumbing\n"))
+		reqImport.Header.Set("Authorization", "Bearer token")
+		respImport, _ := client.Do(reqImport)
+		if respImport.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for POST payments import, got %v", respImport.StatusCode)
+		}
+
+		for _, route := range []struct {
+			method string
+			path   string
+			body   string
+			status int
+		}{
+			{http.MethodPost, "/payments/" + payID + "/pay", `{"amount":55,"currency":"USD","payment_date":"` + nowStr + `"}`, http.StatusOK},
+			{http.MethodPost, "/statements?format=monobank", "[]", http.StatusOK},
+			{http.MethodGet, "/statements/lines?status=pending", "", http.StatusOK},
+			{http.MethodPost, "/statements/lines/" + payID + "/confirm", `{"type_id":"` + costType.ID.String() + `"}`, http.StatusCreated},
+			{http.MethodPost, "/statements/lines/" + payID + "/dismiss", "", http.StatusNoContent},
+			{http.MethodGet, "/statements/rules", "", http.StatusOK},
+			{http.MethodPost, "/statements/rules", `{"pattern":"epicentr","type_id":"` + costType.ID.String() + `"}`, http.StatusCreated},
+			{http.MethodDelete, "/statements/rules/" + rule.ID.String(), "", http.StatusNoContent},
+			{http.MethodPost, "/recurring-payments", `{"type_id":"` + costType.ID.String() + `","amount":1000,"currency":"UAH","recurrence":"FREQ=MONTHLY","start_date":"2026-01-31"}`, http.StatusCreated},
+			{http.MethodGet, "/recurring-payments", "", http.StatusOK},
+			{http.MethodGet, "/recurring-payments/" + recurring.ID.String(), "", http.StatusOK},
+			{http.MethodGet, "/recurring-payments/" + recurring.ID.String() + "/occurrences", "", http.StatusOK},
+			{http.MethodPut, "/recurring-payments/" + recurring.ID.String() + "/occurrences/2026-02-28", `{"amount":1200,"currency":"UAH"}`, http.StatusOK},
+			{http.MethodPost, "/recurring-payments/" + recurring.ID.String() + "/occurrences/2026-03-31/skip", "", http.StatusNoContent},
+			{http.MethodDelete, "/recurring-payments/" + recurring.ID.String(), "", http.StatusNoContent},
+			{http.MethodPost, "/loans", `{"principal":1200000,"currency":"UAH","annual_rate":12,"term":12,"schedule":"ANNUITY","start_date":"2026-01-15"}`, http.StatusCreated},
+			{http.MethodGet, "/loans", "", http.StatusOK},
+			{http.MethodGet, "/loans/" + loan.ID.String(), "", http.StatusOK},
+			{http.MethodPut, "/loans/" + loan.ID.String(), `{"principal":1200000,"currency":"UAH","annual_rate":11,"term":24,"schedule":"DIFFERENTIATED","start_date":"2026-01-15"}`, http.StatusNoContent},
+			{http.MethodGet, "/loans/" + loan.ID.String() + "/report", "", http.StatusOK},
+			{http.MethodPost, "/loans/" + loan.ID.String() + "/payments", `{"payment_id":"` + pay.ID.String() + `","installment":1}`, http.StatusOK},
+			{http.MethodDelete, "/loans/" + loan.ID.String() + "/payments/" + pay.ID.String(), "", http.StatusNoContent},
+			{http.MethodDelete, "/loans/" + loan.ID.String(), "", http.StatusNoContent},
+			{http.MethodPost, "/vendors", `{"name":"Electrician","iban":"UA213223130000026007233566001"}`, http.StatusCreated},
+			{http.MethodGet, "/vendors?name=elec", "", http.StatusOK},
+			{http.MethodGet, "/vendors/" + vendor.ID.String(), "", http.StatusOK},
+			{http.MethodPut, "/vendors/" + vendor.ID.String(), `{"name":"Electrician","phone":"+380501234567"}`, http.StatusNoContent},
+			{http.MethodGet, "/vendors/" + vendor.ID.String() + "/report", "", http.StatusOK},
+			{http.MethodPost, "/vendors/" + vendor.ID.String() + "/payments", `{"payment_id":"` + pay.ID.String() + `"}`, http.StatusNoContent},
+			{http.MethodDelete, "/vendors/" + vendor.ID.String() + "/payments/" + pay.ID.String(), "", http.StatusNoContent},
+			{http.MethodPost, "/vendors/" + vendor.ID.String() + "/assets", `{"asset_id":"` + uuid.NewString() + `"}`, http.StatusNoContent},
+			{http.MethodDelete, "/vendors/" + vendor.ID.String() + "/assets/" + uuid.NewString(), "", http.StatusNoContent},
+			{http.MethodDelete, "/vendors/" + vendor.ID.String(), "", http.StatusNoContent},
+			{http.MethodPost, "/contracts", `{"vendor_id":"` + vendor.ID.String() + `","title":"Wiring","total":1000000,"currency":"UAH","down_payment":300000,"upon_completion":700000}`, http.StatusCreated},
+			{http.MethodGet, "/contracts?vendor_id=" + vendor.ID.String(), "", http.StatusOK},
+			{http.MethodGet, "/contracts/" + contract.ID.String(), "", http.StatusOK},
+			{http.MethodPut, "/contracts/" + contract.ID.String(), `{"vendor_id":"` + vendor.ID.String() + `","title":"Wiring","total":1000000,"currency":"UAH","signed_at":"2026-02-01"}`, http.StatusNoContent},
+			{http.MethodGet, "/contracts/" + contract.ID.String() + "/report", "", http.StatusOK},
+			{http.MethodPost, "/contracts/" + contract.ID.String() + "/payments", `{"payment_id":"` + pay.ID.String() + `"}`, http.StatusNoContent},
+			{http.MethodDelete, "/contracts/" + contract.ID.String() + "/payments/" + pay.ID.String(), "", http.StatusNoContent},
+			{http.MethodDelete, "/contracts/" + contract.ID.String(), "", http.StatusNoContent},
+			{http.MethodPost, "/estimates", `{"title":"Wiring"}`, http.StatusCreated},
+			{http.MethodGet, "/estimates?limit=5", "", http.StatusOK},
+			{http.MethodGet, "/estimates/" + estimate.ID.String(), "", http.StatusOK},
+			{http.MethodPut, "/estimates/" + estimate.ID.String(), `{"title":"Wiring"}`, http.StatusNoContent},
+			{http.MethodGet, "/estimates/" + estimate.ID.String() + "/comparison", "", http.StatusOK},
+			{http.MethodPost, "/estimates/" + estimate.ID.String() + "/quotes", `{"vendor_id":"` + vendor.ID.String() + `","items":[{"type_id":"` + costType.ID.String() + `","quantity":2,"unit_price":500000,"currency":"UAH"}]}`, http.StatusCreated},
+			{http.MethodPut, "/estimates/" + estimate.ID.String() + "/quotes/" + quote.ID.String(), `{"vendor_id":"` + vendor.ID.String() + `","items":[{"type_id":"` + costType.ID.String() + `","quantity":1,"unit_price":900000,"currency":"UAH"}]}`, http.StatusNoContent},
+			{http.MethodPost, "/estimates/" + estimate.ID.String() + "/quotes/" + quote.ID.String() + "/accept", `{"target":"contract","down_payment":300000,"currency":"UAH"}`, http.StatusOK},
+			{http.MethodDelete, "/estimates/" + estimate.ID.String() + "/quotes/" + quote.ID.String(), "", http.StatusNoContent},
+			{http.MethodDelete, "/estimates/" + estimate.ID.String(), "", http.StatusNoContent},
+			{http.MethodGet, "/payments/" + pay.ID.String() + "/attachments", "", http.StatusOK},
+			{http.MethodGet, "/assets/" + uuid.NewString() + "/attachments", "", http.StatusOK},
+			{http.MethodPost, "/payments/" + pay.ID.String() + "/attachments", "receipt", http.StatusBadRequest},
+			{http.MethodDelete, "/attachments/" + attached.ID.String(), "", http.StatusNoContent},
+			{http.MethodGet, "/tags?name=kit&limit=5", "", http.StatusOK},
+			{http.MethodPut, "/payments/" + pay.ID.String() + "/tags", `{"tags":["Kitchen","tax-deductible"]}`, http.StatusOK},
+			{http.MethodPut, "/assets/" + uuid.NewString() + "/tags", `{"tags":[]}`, http.StatusOK},
+			{http.MethodPut, "/payments/" + pay.ID.String() + "/tags", `{"tags":["a,b"]}`, http.StatusBadRequest},
+			{http.MethodGet, "/reports/tags?from=2026-01-01&to=2026-12-31", "", http.StatusOK},
+			{http.MethodGet, "/payments?tags=kitchen", "", http.StatusOK},
+			{http.MethodGet, "/assets?tags=kitchen,tax-deductible", "", http.StatusOK},
+		} {
+			reqStatement, _ := http.NewRequest(route.method, server.URL+"/projects/"+pID+route.path, strings.NewReader(route.body))
+			reqStatement.Header.Set("Authorization", "Bearer token")
+			respStatement, _ := client.Do(reqStatement)
+			if respStatement.StatusCode != route.status {
+				t.Errorf("expected %d for %s %s, got %v", route.status, route.method, route.path, respStatement.StatusCode)
+			}
+		}
+
+		reqBadReport, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+"/reports/payments?period=week", nil)
+		reqBadReport.Header.Set("Authorization", "Bearer token")
+		respBadReport, _ := client.Do(reqBadReport)
+		if respBadReport.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 for invalid report period, got %v", respBadReport.StatusCode)
+		}
+
 		// POST /projects/{id}/payments
 		payBody, _ := json.Marshal(CreatePaymentDTO{TypeID: costType.ID.String(), Description: "Pay", Amount: 50, Currency: "USD", PaymentDate: nowStr, Kind: "DOWN_PAYMENT"})
 		reqCreate, _ := http.NewRequest("POST", server.URL+"/projects/"+pID+"/payments", bytes.NewReader(payBody))
@@ -497,6 +1206,110 @@ func TestWebHandlersAndEndpoints(t *testing.T) {
 		}
 	})
 
+	t.Run("Budgets endpoints", func(t *testing.T) {
+		client := &http.Client{}
+		pID := proj.ProjectID.String()
+		budgetID := budget.ID.String()
+
+		// POST /projects/{id}/budgets
+		budgetBody, _ := json.Marshal(CreateBudgetDTO{CategoryID: cat.ID.String(), TypeID: costType.ID.String(), Amount: 1000})
+		reqCreate, _ := http.NewRequest("POST", server.URL+"/projects/"+pID+"/budgets", bytes.NewReader(budgetBody))
+		reqCreate.Header.Set("Authorization", "Bearer token")
+		respCreate, _ := client.Do(reqCreate)
+		if respCreate.StatusCode != http.StatusCreated {
+			t.Errorf("expected 201 for POST budget, got %v", respCreate.StatusCode)
+		}
+
+		// GET /projects/{id}/budgets
+		reqList, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+"/budgets?limit=5&offset=0&category_id="+cat.ID.String(), nil)
+		reqList.Header.Set("Authorization", "Bearer token")
+		respList, _ := client.Do(reqList)
+		if respList.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for GET budgets, got %v", respList.StatusCode)
+		}
+
+		// GET /projects/{id}/budgets/report
+		reqReport, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+"/budgets/report", nil)
+		reqReport.Header.Set("Authorization", "Bearer token")
+		respReport, _ := client.Do(reqReport)
+		if respReport.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for GET budget report, got %v", respReport.StatusCode)
+		}
+		var report BudgetReportDTO
+		_ = json.NewDecoder(respReport.Body).Decode(&report)
+		if len(report.Lines) != 1 || report.Remaining != -200 || !report.Lines[0].Overrun {
+			t.Errorf("unexpected budget report: %+v", report)
+		}
+
+		// GET /projects/{id}/budgets/{budget_id}
+		reqGetOne, _ := http.NewRequest("GET", server.URL+"/projects/"+pID+"/budgets/"+budgetID, nil)
+		reqGetOne.Header.Set("Authorization", "Bearer token")
+		respGetOne, _ := client.Do(reqGetOne)
+		if respGetOne.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 for GET budget, got %v", respGetOne.StatusCode)
+		}
+
+		// PUT /projects/{id}/budgets/{budget_id}
+		reqPut, _ := http.NewRequest("PUT", server.URL+"/projects/"+pID+"/budgets/"+budgetID, bytes.NewReader(budgetBody))
+		reqPut.Header.Set("Authorization", "Bearer token")
+		respPut, _ := client.Do(reqPut)
+		if respPut.StatusCode != http.StatusNoContent {
+			t.Errorf("expected 204 for PUT budget, got %v", respPut.StatusCode)
+		}
+
+		// DELETE /projects/{id}/budgets/{budget_id}
+		reqDel, _ := http.NewRequest("DELETE", server.URL+"/projects/"+pID+"/budgets/"+budgetID, nil)
+		reqDel.Header.Set("Authorization", "Bearer token")
+		respDel, _ := client.Do(reqDel)
+		if respDel.StatusCode != http.StatusNoContent {
+			t.Errorf("expected 204 for DELETE budget, got %v", respDel.StatusCode)
+		}
+	})
+
+	t.Run("Attachments upload and download", func(t *testing.T) {
+		client := &http.Client{}
+		pID := proj.ProjectID.String()
+
+		for _, path := range []string{"/payments/" + pay.ID.String(), "/assets/" + uuid.NewString()} {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			file, _ := form.CreateFormFile("file", "receipt.pdf")
+			_, _ = file.Write([]byte(receipt))
+			_ = form.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/projects/"+pID+path+"/attachments", &body)
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			resp, _ := client.Do(req)
+			if resp.StatusCode != http.StatusCreated {
+				t.Errorf("expected 201 for POST %s/attachments, got %v", path, resp.StatusCode)
+			}
+
+			var dto AttachmentDTO
+			_ = json.NewDecoder(resp.Body).Decode(&dto)
+			if dto.FileName != "receipt.pdf" || dto.ContentType != "application/pdf" || dto.Size != int64(len(receipt)) {
+				t.Errorf("unexpected attachment %+v", dto)
+			}
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/projects/"+pID+"/attachments/"+attached.ID.String(), nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, _ := client.Do(req)
+		content, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(content) != receipt {
+			t.Errorf("expected the file for GET attachment, got %v %q", resp.StatusCode, content)
+		}
+		if resp.Header.Get("Content-Type") != "application/pdf" || resp.Header.Get("Content-Disposition") != `attachment; filename=receipt.pdf` {
+			t.Errorf("unexpected headers %v", resp.Header)
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, server.URL+"/projects/"+pID+"/attachments/"+attached.ID.String(), nil)
+		resp, _ = client.Do(req)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401 for GET attachment without token, got %v", resp.StatusCode)
+		}
+	})
+
 	t.Run("Swagger UI handler", func(t *testing.T) {
 		resp, err := http.Get(server.URL + "/swagger/")
 		if err != nil {
@@ -515,6 +1328,7 @@ func TestErrorCodeToHttpStatus(t *testing.T) {
 		{exceptions.ValidationFailed, http.StatusBadRequest},
 		{exceptions.Internal, http.StatusInternalServerError},
 		{exceptions.Unauthorized, http.StatusUnauthorized},
+		{exceptions.Forbidden, http.StatusForbidden},
 		{"UNKNOWN_CODE", http.StatusInternalServerError},
 	}
 